@@ -23,6 +23,7 @@ import (
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/internal/env"
 	"github.com/superfly/flyctl/internal/sentry"
+	"github.com/superfly/flyctl/internal/transport"
 	"github.com/superfly/flyctl/internal/wireguard"
 )
 
@@ -219,7 +220,7 @@ func (s *server) buildTunnel(org *api.Organization, recycle bool) (tunnel *wg.Tu
 	}
 
 	// WIP: can't stay this way, need something more clever than this
-	if env.IsCI() || os.Getenv("WSWG") != "" || viper.GetBool(flyctl.ConfigWireGuardWebsockets) {
+	if env.IsCI() || os.Getenv("WSWG") != "" || viper.GetBool(flyctl.ConfigWireGuardWebsockets) || behindProxy(state) {
 		if tunnel, err = wg.ConnectWS(context.Background(), state); err != nil {
 			return
 		}
@@ -234,6 +235,13 @@ func (s *server) buildTunnel(org *api.Organization, recycle bool) (tunnel *wg.Tu
 	return
 }
 
+// behindProxy reports whether the WireGuard gateway of state is to be reached
+// through a proxy, which only websockets can go through.
+func behindProxy(state *wg.WireGuardState) bool {
+	proxyURL, err := transport.Proxy(net.JoinHostPort(state.Peer.Endpointip, "443"))
+	return err == nil && proxyURL != nil
+}
+
 func (s *server) fetchInstances(ctx context.Context, tunnel *wg.Tunnel, app string) (*agent.Instances, error) {
 	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
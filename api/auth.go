@@ -18,12 +18,26 @@ type CLISessionAuth struct {
 
 // StartCLISessionWebAuth starts a session with the platform via web auth
 func StartCLISessionWebAuth(machineName string, signup bool) (CLISessionAuth, error) {
-	var result CLISessionAuth
-
-	postData, _ := json.Marshal(map[string]interface{}{
+	return startCLISession(map[string]interface{}{
 		"name":   machineName,
 		"signup": signup,
 	})
+}
+
+// StartCLISessionSSOAuth starts a session with the platform via web auth,
+// going through the single sign-on provider of the given organization
+func StartCLISessionSSOAuth(machineName, orgSlug string) (CLISessionAuth, error) {
+	return startCLISession(map[string]interface{}{
+		"name": machineName,
+		"org":  orgSlug,
+		"sso":  true,
+	})
+}
+
+func startCLISession(params map[string]interface{}) (CLISessionAuth, error) {
+	var result CLISessionAuth
+
+	postData, _ := json.Marshal(params)
 
 	url := fmt.Sprintf("%s/api/v1/cli_sessions", baseURL)
 
@@ -11,6 +11,13 @@ const (
 	MachineConfigMetadataKeyFlyReleaseId       = "fly_release_id"
 	MachineConfigMetadataKeyFlyReleaseVersion  = "fly_release_version"
 	MachineConfigMetadataKeyFlyProcessGroup    = "fly_process_group"
+	MachineConfigMetadataKeyFlyImageDigest     = "fly_image_digest"
+	MachineConfigMetadataKeyFlyGitSHA          = "fly_git_sha"
+	MachineConfigMetadataKeyFlyDeployedAt      = "fly_deployed_at"
+	MachineConfigMetadataKeyFlyReleaseChannel  = "fly_release_channel"
+	MachineConfigMetadataKeyFlyPaused          = "fly_paused"
+	MachineConfigMetadataKeyFlyBlueGreenOld    = "fly_bg_old"
+	MachineReleaseChannelStable                = "stable"
 	MachineFlyPlatformVersion2                 = "v2"
 	MachineProcessGroupApp                     = "app"
 	MachineProcessGroupFlyAppReleaseCommand    = "fly_app_release_command"
@@ -20,6 +27,17 @@ const (
 	MachineStateStopped                        = "stopped"
 )
 
+const (
+	// MachineConfigMetadataKeyFlyProxyChannelTraffic is the percentage of the
+	// requests fly-proxy routes to the machines of a release channel, rather
+	// than to those of the stable one.
+	MachineConfigMetadataKeyFlyProxyChannelTraffic = "fly_proxy_channel_traffic"
+	// MachineReleaseChannelSelector is the request header, or cookie, naming
+	// the release channel fly-proxy routes a request to, whatever its share
+	// of the traffic.
+	MachineReleaseChannelSelector = "fly-release-channel"
+)
+
 type Machine struct {
 	ID       string          `json:"id,omitempty"`
 	Name     string          `json:"name,omitempty"`
@@ -89,6 +107,28 @@ func (m *Machine) ProcessGroup() string {
 	return m.Config.ProcessGroup()
 }
 
+// ReleaseChannel is the channel of the pool of machines m belongs to, which
+// is deployed to separately, stable unless set in its metadata.
+func (m *Machine) ReleaseChannel() string {
+	if m.Config == nil || m.Config.Metadata[MachineConfigMetadataKeyFlyReleaseChannel] == "" {
+		return MachineReleaseChannelStable
+	}
+	return m.Config.Metadata[MachineConfigMetadataKeyFlyReleaseChannel]
+}
+
+// IsPaused reports whether m was stopped by fly apps pause, which records
+// what to resume it to in its metadata.
+func (m *Machine) IsPaused() bool {
+	return m.Config != nil && m.Config.Metadata[MachineConfigMetadataKeyFlyPaused] != ""
+}
+
+// IsKeptBlue reports whether m is a blue machine a bluegreen deployment
+// stopped and kept around rather than destroying it, which later deployments
+// leave alone.
+func (m *Machine) IsKeptBlue() bool {
+	return m.Config != nil && m.Config.Metadata[MachineConfigMetadataKeyFlyBlueGreenOld] != ""
+}
+
 func (m *Machine) HasProcessGroup(desired string) bool {
 	return m.Config != nil && m.ProcessGroup() == desired
 }
@@ -145,6 +185,42 @@ func (m *Machine) GetLatestEventOfTypeAfterType(latestEventType, firstEventType
 	return nil
 }
 
+// LatestExitEvent returns how the machine exited since it was last started or
+// updated, or nil if it hasn't. Events are listed newest first.
+func (m *Machine) LatestExitEvent() *MachineExitEvent {
+	for _, e := range m.Events {
+		switch e.Type {
+		case "launch", "start", "update":
+			return nil
+		case "exit":
+			if e.Request == nil {
+				return nil
+			}
+			if e.Request.MonitorEvent != nil && e.Request.MonitorEvent.ExitEvent != nil {
+				return e.Request.MonitorEvent.ExitEvent
+			}
+			return e.Request.ExitEvent
+		}
+	}
+	return nil
+}
+
+// ExitError describes how the machine crashed, or was killed for running out
+// of memory, since it was last started or updated, in which case waiting for
+// it to start or become healthy is pointless. It's nil otherwise.
+func (m *Machine) ExitError() error {
+	exit := m.LatestExitEvent()
+	switch {
+	case exit == nil || exit.RequestedStop:
+		return nil
+	case exit.OOMKilled:
+		return fmt.Errorf("machine %s ran out of memory and was killed", m.ID)
+	case exit.ExitCode != 0:
+		return fmt.Errorf("machine %s exited with code %d", m.ID, exit.ExitCode)
+	}
+	return nil
+}
+
 func (m *Machine) IsReleaseCommandMachine() bool {
 	return m.HasProcessGroup(MachineProcessGroupFlyAppReleaseCommand) || m.Config.Metadata["process_group"] == "release_command"
 }
@@ -295,6 +371,9 @@ type MachineCheck struct {
 	HTTPProtocol      *string             `json:"protocol,omitempty"`
 	HTTPSkipTLSVerify *bool               `json:"tls_skip_verify,omitempty"`
 	HTTPHeaders       []MachineHTTPHeader `json:"headers,omitempty"`
+	Command           []string            `json:"command,omitempty"`
+	GRPCService       *string             `json:"grpc_service,omitempty"`
+	GRPCUseTLS        *bool               `json:"grpc_use_tls,omitempty"`
 }
 
 type MachineHTTPHeader struct {
@@ -310,11 +389,20 @@ type MachineCheckStatus struct {
 }
 
 type MachinePort struct {
-	Port       *int     `json:"port,omitempty" toml:"port,omitempty"`
-	StartPort  *int     `json:"start_port,omitempty" toml:"start_port,omitempty"`
-	EndPort    *int     `json:"end_port,omitempty" toml:"end_port,omitempty"`
-	Handlers   []string `json:"handlers,omitempty" toml:"handlers,omitempty"`
-	ForceHttps bool     `json:"force_https,omitempty" toml:"force_https,omitempty"`
+	Port       *int        `json:"port,omitempty" toml:"port,omitempty"`
+	StartPort  *int        `json:"start_port,omitempty" toml:"start_port,omitempty"`
+	EndPort    *int        `json:"end_port,omitempty" toml:"end_port,omitempty"`
+	Handlers   []string    `json:"handlers,omitempty" toml:"handlers,omitempty"`
+	ForceHttps bool        `json:"force_https,omitempty" toml:"force_https,omitempty"`
+	TLSOptions *TLSOptions `json:"tls_options,omitempty" toml:"tls_options,omitempty"`
+}
+
+// TLSOptions configure the TLS the tls handler of a port terminates: the
+// protocols negotiated with ALPN, h3 enabling HTTP/3 over QUIC, and the TLS
+// versions accepted.
+type TLSOptions struct {
+	ALPN     []string `json:"alpn,omitempty" toml:"alpn,omitempty"`
+	Versions []string `json:"versions,omitempty" toml:"versions,omitempty"`
 }
 
 func (mp *MachinePort) ContainsPort(port int) bool {
@@ -371,6 +459,12 @@ type MachineService struct {
 	Ports        []MachinePort              `json:"ports,omitempty" toml:"ports,omitempty"`
 	Checks       []MachineCheck             `json:"checks,omitempty" toml:"checks,omitempty"`
 	Concurrency  *MachineServiceConcurrency `json:"concurrency,omitempty" toml:"concurrency"`
+	// Autostop and Autostart let the proxy stop the machine when it's idle
+	// and start it again for requests, keeping MinMachinesRunning machines
+	// running in the region
+	Autostop           *bool `json:"autostop,omitempty" toml:"autostop,omitempty"`
+	Autostart          *bool `json:"autostart,omitempty" toml:"autostart,omitempty"`
+	MinMachinesRunning *int  `json:"min_machines_running,omitempty" toml:"min_machines_running,omitempty"`
 }
 
 type MachineServiceConcurrency struct {
@@ -392,6 +486,7 @@ type MachineConfig struct {
 	Guest                   *MachineGuest           `json:"guest,omitempty"`
 	Metrics                 *MachineMetrics         `json:"metrics,omitempty"`
 	Schedule                string                  `json:"schedule,omitempty"`
+	ScheduleTimezone        string                  `json:"schedule_timezone,omitempty"`
 	Checks                  map[string]MachineCheck `json:"checks,omitempty"`
 	AutoDestroy             bool                    `json:"auto_destroy,omitempty"`
 	DNS                     *DNSConfig              `json:"dns,omitempty"`
@@ -462,6 +557,8 @@ type LaunchMachineInput struct {
 	OrgSlug string         `json:"organizationId,omitempty"`
 	Region  string         `json:"region,omitempty"`
 	Config  *MachineConfig `json:"config,omitempty"`
+	// SkipLaunch updates the config of a stopped machine without starting it
+	SkipLaunch bool `json:"skip_launch,omitempty"`
 	// Client side only
 	SkipHealthChecks bool
 }
@@ -117,3 +117,55 @@ func TestGetProcessGroup(t *testing.T) {
 		}
 	}
 }
+
+func TestExitError(t *testing.T) {
+	exit := func(e MachineExitEvent) *MachineEvent {
+		return &MachineEvent{Type: "exit", Request: &MachineRequest{ExitEvent: &e}}
+	}
+	start := &MachineEvent{Type: "start"}
+
+	type testcase struct {
+		name     string
+		events   []*MachineEvent
+		expected string
+	}
+
+	cases := []testcase{
+		{
+			name:     "running machine",
+			events:   []*MachineEvent{start},
+			expected: "",
+		},
+		{
+			name:     "crashed machine",
+			events:   []*MachineEvent{exit(MachineExitEvent{ExitCode: 1}), start},
+			expected: "machine m exited with code 1",
+		},
+		{
+			name:     "machine killed for running out of memory",
+			events:   []*MachineEvent{exit(MachineExitEvent{ExitCode: 137, OOMKilled: true}), start},
+			expected: "machine m ran out of memory and was killed",
+		},
+		{
+			name:     "stopped machine",
+			events:   []*MachineEvent{exit(MachineExitEvent{ExitCode: 143, RequestedStop: true}), start},
+			expected: "",
+		},
+		{
+			name:     "machine restarted since it crashed",
+			events:   []*MachineEvent{start, exit(MachineExitEvent{ExitCode: 1})},
+			expected: "",
+		},
+	}
+
+	for _, tc := range cases {
+		err := (&Machine{ID: "m", Events: tc.events}).ExitError()
+		result := ""
+		if err != nil {
+			result = err.Error()
+		}
+		if result != tc.expected {
+			t.Errorf("%s, got '%v', want '%v'", tc.name, result, tc.expected)
+		}
+	}
+}
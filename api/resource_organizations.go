@@ -355,3 +355,66 @@ func (c *Client) GetAppsV2DefaultOnForOrg(ctx context.Context, orgSlug string) (
 		return appsV2DefaultOn, nil
 	}
 }
+
+// GetOrganizationLimits returns the limits of an organization on the number of
+// apps, machines, volumes, IP addresses and concurrent builds, along with how
+// many of each it has.
+func (c *Client) GetOrganizationLimits(ctx context.Context, slug string) ([]OrganizationLimit, error) {
+	query := `
+		query($slug: String!) {
+			organization(slug: $slug) {
+				limits {
+					resource
+					limit
+					usage
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+	req.Var("slug", slug)
+
+	data, err := c.RunWithContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if data.Organization == nil {
+		return nil, ErrNotFound
+	}
+
+	return data.Organization.Limits, nil
+}
+
+// RequestOrganizationLimitIncrease asks Fly for an organization's limit on
+// resource to be raised to limit.
+func (c *Client) RequestOrganizationLimitIncrease(ctx context.Context, orgID, resource string, limit int, reason string) (*LimitIncreaseRequest, error) {
+	query := `
+		mutation($input: RequestOrganizationLimitIncreaseInput!) {
+			requestOrganizationLimitIncrease(input: $input) {
+				limitIncreaseRequest {
+					id
+					resource
+					limit
+					status
+					createdAt
+				}
+			}
+		}
+	`
+
+	req := c.NewRequest(query)
+	req.Var("input", map[string]any{
+		"organizationId": orgID,
+		"resource":       resource,
+		"limit":          limit,
+		"reason":         reason,
+	})
+
+	data, err := c.RunWithContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.RequestOrganizationLimitIncrease.LimitIncreaseRequest, nil
+}
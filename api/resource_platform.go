@@ -10,6 +10,8 @@ func (c *Client) PlatformRegions(ctx context.Context) ([]Region, *Region, error)
 				regions {
 					name
 					code
+					latitude
+					longitude
 					gatewayAvailable
 					requiresPaidPlan
 				}
@@ -73,6 +75,8 @@ func (c *Client) PlatformVMSizes(ctx context.Context) ([]VMSize, error) {
 					cpuCores
 					memoryGb
 					memoryMb
+					maxMemoryMb
+					memoryIncrementsMb
 					priceMonth
 					priceSecond
 				}
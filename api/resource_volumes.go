@@ -149,6 +149,33 @@ func (c *Client) DeleteVolume(ctx context.Context, volID string) (App *App, err
 	return &data.DeleteVolume.App, nil
 }
 
+// CreateVolumeSnapshot starts taking a snapshot of a volume, which is listed
+// by GetVolumeSnapshots once taken.
+func (c *Client) CreateVolumeSnapshot(ctx context.Context, volID string) (*Volume, error) {
+	query := `
+		mutation($input: CreateVolumeSnapshotInput!) {
+			createVolumeSnapshot(input: $input) {
+				volume {
+					id
+				}
+			}
+		}
+	`
+
+	input := CreateVolumeSnapshotInput{VolumeID: volID}
+
+	req := c.NewRequest(query)
+
+	req.Var("input", input)
+
+	data, err := c.RunWithContext(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return &data.CreateVolumeSnapshot.Volume, nil
+}
+
 func (c *Client) GetVolume(ctx context.Context, volID string) (Volume *Volume, err error) {
 	query := `
 	query($id: ID!) {
@@ -173,6 +173,8 @@ type Query struct {
 	DeleteVolume DeleteVolumePayload
 	ExtendVolume ExtendVolumePayload
 
+	CreateVolumeSnapshot CreateVolumeSnapshotPayload
+
 	AddWireGuardPeer              CreatedWireGuardPeer
 	EstablishSSHKey               SSHCertificate
 	IssueCertificate              IssuedCertificate
@@ -199,6 +201,10 @@ type Query struct {
 
 	CreateOrganizationInvitation CreateOrganizationInvitation
 
+	RequestOrganizationLimitIncrease struct {
+		LimitIncreaseRequest LimitIncreaseRequest
+	}
+
 	ValidateWireGuardPeers struct {
 		InvalidPeerIPs []string
 	}
@@ -414,6 +420,14 @@ type DeleteVolumePayload struct {
 	App App
 }
 
+type CreateVolumeSnapshotInput struct {
+	VolumeID string `json:"volumeId"`
+}
+
+type CreateVolumeSnapshotPayload struct {
+	Volume Volume
+}
+
 type AppCertsCompact struct {
 	Certificates struct {
 		Nodes []AppCertificateCompact
@@ -522,6 +536,7 @@ type Organization struct {
 	Type               string
 	PaidPlan           bool
 	Settings           map[string]any
+	Limits             []OrganizationLimit
 
 	Domains struct {
 		Nodes *[]*Domain
@@ -998,9 +1013,11 @@ type VMSize struct {
 	CPUClass    string
 	MemoryGB    float32
 	MemoryMB    int
+	MaxMemoryMB int
 	PriceMonth  float32
 	PriceSecond float32
-	// MemoryIncrementsMB []int
+
+	MemoryIncrementsMB []int
 }
 
 type ProcessGroup struct {
@@ -1287,6 +1304,29 @@ type CreateOrganizationInvitation struct {
 	Invitation Invitation
 }
 
+// Resources organizations have limits on.
+const (
+	OrganizationLimitApps             = "apps"
+	OrganizationLimitMachines         = "machines"
+	OrganizationLimitVolumes          = "volumes"
+	OrganizationLimitIPAddresses      = "ip_addresses"
+	OrganizationLimitConcurrentBuilds = "concurrent_builds"
+)
+
+type OrganizationLimit struct {
+	Resource string
+	Limit    int
+	Usage    int
+}
+
+type LimitIncreaseRequest struct {
+	ID        string
+	Resource  string
+	Limit     int
+	Status    string
+	CreatedAt time.Time
+}
+
 type GqlMachine struct {
 	ID     string
 	Name   string
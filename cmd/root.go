@@ -44,6 +44,12 @@ func NewRootCmd(client *client.Client) *cobra.Command {
 	err = viper.BindPFlag(flyctl.ConfigJSONOutput, rootCmd.PersistentFlags().Lookup("json"))
 	checkErr(err)
 
+	rootCmd.PersistentFlags().String("cache-dir", "", "Directory of the local caches, instead of ~/.fly, such as for CI runners with ephemeral or shared disks. Also set with FLY_CACHE_DIR")
+
+	rootCmd.PersistentFlags().String("app-dir", "", "Directory to look for the app config file in, along with its subdirectories, such as the directory of a monorepo")
+
+	rootCmd.PersistentFlags().String("ca-bundle", "", "PEM file of certificates to trust besides the system ones, such as of a TLS intercepting proxy. Also set with FLY_CA_BUNDLE")
+
 	rootCmd.PersistentFlags().String("builtinsfile", "", "Load builtins from named file")
 	err = viper.BindPFlag(flyctl.ConfigBuiltinsfile, rootCmd.PersistentFlags().Lookup("builtinsfile"))
 	checkErr(err)
@@ -60,7 +66,6 @@ func NewRootCmd(client *client.Client) *cobra.Command {
 		newCertificatesCommand(client),
 		newDashboardCommand(client),
 		newRegionsCommand(client),
-		newAutoscaleCommand(client),
 		newDNSCommand(client),
 		newDomainsCommand(client),
 		newWireGuardCommand(client),
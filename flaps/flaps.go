@@ -17,6 +17,7 @@ import (
 
 	"github.com/google/go-querystring/query"
 	"github.com/samber/lo"
+	"golang.org/x/sync/errgroup"
 
 	"github.com/superfly/flyctl/agent"
 	"github.com/superfly/flyctl/api"
@@ -272,14 +273,25 @@ func (f *Client) Get(ctx context.Context, machineID string) (*api.Machine, error
 	return out, nil
 }
 
+// getManyConcurrency is how many machines GetMany fetches at the same time
+const getManyConcurrency = 8
+
+// GetMany fetches the machines concurrently, returning them in the order of
+// machineIDs.
 func (f *Client) GetMany(ctx context.Context, machineIDs []string) ([]*api.Machine, error) {
-	machines := make([]*api.Machine, 0, len(machineIDs))
-	for _, id := range machineIDs {
-		m, err := f.Get(ctx, id)
-		if err != nil {
-			return machines, err
-		}
-		machines = append(machines, m)
+	machines := make([]*api.Machine, len(machineIDs))
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(getManyConcurrency)
+	for i, id := range machineIDs {
+		i, id := i, id
+		eg.Go(func() (err error) {
+			machines[i], err = f.Get(ctx, id)
+			return err
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
 	}
 	return machines, nil
 }
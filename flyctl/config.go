@@ -6,6 +6,7 @@ import (
 
 const (
 	ConfigAPIToken        = "access_token"
+	ConfigAPITokenStorage = "access_token_storage"
 	ConfigAPIBaseURL      = "api_base_url"
 	ConfigFlapsBaseUrl    = "flaps_base_url"
 	ConfigAppName         = "app"
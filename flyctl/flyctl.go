@@ -6,15 +6,20 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"sync"
 
 	"github.com/spf13/viper"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/helpers"
+	intconfig "github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/terminal"
 	"gopkg.in/yaml.v2"
 )
 
-var configDir string
+var (
+	configDir string
+	cacheDir  string
+)
 
 // InitConfig - Initialises config file for Viper
 func InitConfig() {
@@ -31,6 +36,20 @@ func ConfigDir() string {
 	return configDir
 }
 
+// CacheDir - Returns Directory holding the local caches, the config directory
+// unless set otherwise with SetCacheDir
+func CacheDir() string {
+	if cacheDir != "" {
+		return cacheDir
+	}
+	return configDir
+}
+
+// SetCacheDir - Sets the directory holding the local caches
+func SetCacheDir(dir string) {
+	cacheDir = dir
+}
+
 // ConfigFilePath - returns the path to the config file
 func ConfigFilePath() string {
 	return path.Join(configDir, "config.yml")
@@ -129,10 +148,32 @@ func GetAPIToken() string {
 	}
 
 	viperAuth := viper.GetString(ConfigAPIToken)
+	if viperAuth == "" && viper.GetString(ConfigAPITokenStorage) == "keychain" {
+		return keychainAPIToken()
+	}
 
 	return viperAuth
 }
 
+var (
+	keychainTokenOnce sync.Once
+	keychainToken     string
+)
+
+// keychainAPIToken returns the token stored in the OS keychain, which is only
+// read once as that takes running a command on most platforms.
+func keychainAPIToken() string {
+	keychainTokenOnce.Do(func() {
+		token, err := intconfig.KeychainAccessToken()
+		if err != nil {
+			terminal.Debugf("failed reading access token from the OS keychain: %v\n", err)
+		}
+		keychainToken = token
+	})
+
+	return keychainToken
+}
+
 var writeableConfigKeys = []string{ConfigAPIToken, ConfigInstaller, ConfigWireGuardState, ConfigWireGuardWebsockets, BuildKitNodeID}
 
 func SaveConfig() error {
@@ -843,6 +843,146 @@ type FlyctlConfigCurrentReleaseResponse struct {
 // GetApp returns FlyctlConfigCurrentReleaseResponse.App, and is useful for accessing the field via an interface.
 func (v *FlyctlConfigCurrentReleaseResponse) GetApp() FlyctlConfigCurrentReleaseApp { return v.App }
 
+// FlyctlRollbackReleasesApp includes the requested fields of the GraphQL type App.
+type FlyctlRollbackReleasesApp struct {
+	// Individual releases for this application, without any config processing
+	ReleasesUnprocessed FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnection `json:"releasesUnprocessed"`
+}
+
+// GetReleasesUnprocessed returns FlyctlRollbackReleasesApp.ReleasesUnprocessed, and is useful for accessing the field via an interface.
+func (v *FlyctlRollbackReleasesApp) GetReleasesUnprocessed() FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnection {
+	return v.ReleasesUnprocessed
+}
+
+// FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnection includes the requested fields of the GraphQL type ReleaseUnprocessedConnection.
+// The GraphQL type's documentation follows.
+//
+// The connection type for ReleaseUnprocessed.
+type FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnection struct {
+	// A list of nodes.
+	Nodes []FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed `json:"nodes"`
+	// Information to aid in pagination.
+	PageInfo FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionPageInfo `json:"pageInfo"`
+}
+
+// GetNodes returns FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnection.Nodes, and is useful for accessing the field via an interface.
+func (v *FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnection) GetNodes() []FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed {
+	return v.Nodes
+}
+
+// GetPageInfo returns FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnection.PageInfo, and is useful for accessing the field via an interface.
+func (v *FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnection) GetPageInfo() FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionPageInfo {
+	return v.PageInfo
+}
+
+// FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed includes the requested fields of the GraphQL type ReleaseUnprocessed.
+type FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed struct {
+	RollbackReleaseFields `json:"-"`
+}
+
+// GetVersion returns FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed.Version, and is useful for accessing the field via an interface.
+func (v *FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) GetVersion() int {
+	return v.RollbackReleaseFields.Version
+}
+
+// GetImageRef returns FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed.ImageRef, and is useful for accessing the field via an interface.
+func (v *FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) GetImageRef() string {
+	return v.RollbackReleaseFields.ImageRef
+}
+
+// GetImage returns FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed.Image, and is useful for accessing the field via an interface.
+func (v *FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) GetImage() RollbackReleaseFieldsImage {
+	return v.RollbackReleaseFields.Image
+}
+
+// GetConfigDefinition returns FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed.ConfigDefinition, and is useful for accessing the field via an interface.
+func (v *FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) GetConfigDefinition() interface{} {
+	return v.RollbackReleaseFields.ConfigDefinition
+}
+
+func (v *FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) UnmarshalJSON(b []byte) error {
+
+	if string(b) == "null" {
+		return nil
+	}
+
+	var firstPass struct {
+		*FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed
+		graphql.NoUnmarshalJSON
+	}
+	firstPass.FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed = v
+
+	err := json.Unmarshal(b, &firstPass)
+	if err != nil {
+		return err
+	}
+
+	err = json.Unmarshal(
+		b, &v.RollbackReleaseFields)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+type __premarshalFlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed struct {
+	Version int `json:"version"`
+
+	ImageRef string `json:"imageRef"`
+
+	Image RollbackReleaseFieldsImage `json:"image"`
+
+	ConfigDefinition interface{} `json:"configDefinition"`
+}
+
+func (v *FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) MarshalJSON() ([]byte, error) {
+	premarshaled, err := v.__premarshalJSON()
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(premarshaled)
+}
+
+func (v *FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed) __premarshalJSON() (*__premarshalFlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed, error) {
+	var retval __premarshalFlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionNodesReleaseUnprocessed
+
+	retval.Version = v.RollbackReleaseFields.Version
+	retval.ImageRef = v.RollbackReleaseFields.ImageRef
+	retval.Image = v.RollbackReleaseFields.Image
+	retval.ConfigDefinition = v.RollbackReleaseFields.ConfigDefinition
+	return &retval, nil
+}
+
+// FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionPageInfo includes the requested fields of the GraphQL type PageInfo.
+// The GraphQL type's documentation follows.
+//
+// Information about pagination in a connection.
+type FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionPageInfo struct {
+	// When paginating forwards, are there more items?
+	HasNextPage bool `json:"hasNextPage"`
+	// When paginating forwards, the cursor to continue.
+	EndCursor string `json:"endCursor"`
+}
+
+// GetHasNextPage returns FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionPageInfo.HasNextPage, and is useful for accessing the field via an interface.
+func (v *FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionPageInfo) GetHasNextPage() bool {
+	return v.HasNextPage
+}
+
+// GetEndCursor returns FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionPageInfo.EndCursor, and is useful for accessing the field via an interface.
+func (v *FlyctlRollbackReleasesAppReleasesUnprocessedReleaseUnprocessedConnectionPageInfo) GetEndCursor() string {
+	return v.EndCursor
+}
+
+// FlyctlRollbackReleasesResponse is returned by FlyctlRollbackReleases on success.
+type FlyctlRollbackReleasesResponse struct {
+	// Find an app by name
+	App FlyctlRollbackReleasesApp `json:"app"`
+}
+
+// GetApp returns FlyctlRollbackReleasesResponse.App, and is useful for accessing the field via an interface.
+func (v *FlyctlRollbackReleasesResponse) GetApp() FlyctlRollbackReleasesApp { return v.App }
+
 // GetAddOnAddOn includes the requested fields of the GraphQL type AddOn.
 type GetAddOnAddOn struct {
 	Id string `json:"id"`
@@ -1506,6 +1646,37 @@ func (v *ResolverFinishBuildResponse) GetFinishBuild() ResolverFinishBuildFinish
 	return v.FinishBuild
 }
 
+// RollbackReleaseFields includes the GraphQL fields of ReleaseUnprocessed requested by the fragment RollbackReleaseFields.
+type RollbackReleaseFields struct {
+	// The version of the release
+	Version int `json:"version"`
+	// Docker image URI
+	ImageRef string `json:"imageRef"`
+	// Docker image
+	Image            RollbackReleaseFieldsImage `json:"image"`
+	ConfigDefinition interface{}                `json:"configDefinition"`
+}
+
+// GetVersion returns RollbackReleaseFields.Version, and is useful for accessing the field via an interface.
+func (v *RollbackReleaseFields) GetVersion() int { return v.Version }
+
+// GetImageRef returns RollbackReleaseFields.ImageRef, and is useful for accessing the field via an interface.
+func (v *RollbackReleaseFields) GetImageRef() string { return v.ImageRef }
+
+// GetImage returns RollbackReleaseFields.Image, and is useful for accessing the field via an interface.
+func (v *RollbackReleaseFields) GetImage() RollbackReleaseFieldsImage { return v.Image }
+
+// GetConfigDefinition returns RollbackReleaseFields.ConfigDefinition, and is useful for accessing the field via an interface.
+func (v *RollbackReleaseFields) GetConfigDefinition() interface{} { return v.ConfigDefinition }
+
+// RollbackReleaseFieldsImage includes the requested fields of the GraphQL type Image.
+type RollbackReleaseFieldsImage struct {
+	Digest string `json:"digest"`
+}
+
+// GetDigest returns RollbackReleaseFieldsImage.Digest, and is useful for accessing the field via an interface.
+func (v *RollbackReleaseFieldsImage) GetDigest() string { return v.Digest }
+
 type RuntimeType string
 
 const (
@@ -1802,6 +1973,22 @@ type __FlyctlConfigCurrentReleaseInput struct {
 // GetAppName returns __FlyctlConfigCurrentReleaseInput.AppName, and is useful for accessing the field via an interface.
 func (v *__FlyctlConfigCurrentReleaseInput) GetAppName() string { return v.AppName }
 
+// __FlyctlRollbackReleasesInput is used internally by genqlient
+type __FlyctlRollbackReleasesInput struct {
+	AppName string `json:"appName"`
+	Limit   int    `json:"limit"`
+	After   string `json:"after,omitempty"`
+}
+
+// GetAppName returns __FlyctlRollbackReleasesInput.AppName, and is useful for accessing the field via an interface.
+func (v *__FlyctlRollbackReleasesInput) GetAppName() string { return v.AppName }
+
+// GetLimit returns __FlyctlRollbackReleasesInput.Limit, and is useful for accessing the field via an interface.
+func (v *__FlyctlRollbackReleasesInput) GetLimit() int { return v.Limit }
+
+// GetAfter returns __FlyctlRollbackReleasesInput.After, and is useful for accessing the field via an interface.
+func (v *__FlyctlRollbackReleasesInput) GetAfter() string { return v.After }
+
 // __GetAddOnInput is used internally by genqlient
 type __GetAddOnInput struct {
 	Name string `json:"name"`
@@ -2184,6 +2371,58 @@ query FlyctlConfigCurrentRelease ($appName: String!) {
 	return &data, err
 }
 
+func FlyctlRollbackReleases(
+	ctx context.Context,
+	client graphql.Client,
+	appName string,
+	limit int,
+	after string,
+) (*FlyctlRollbackReleasesResponse, error) {
+	req := &graphql.Request{
+		OpName: "FlyctlRollbackReleases",
+		Query: `
+query FlyctlRollbackReleases ($appName: String!, $limit: Int!, $after: String) {
+	app(name: $appName) {
+		releasesUnprocessed(first: $limit, after: $after) {
+			nodes {
+				... RollbackReleaseFields
+			}
+			pageInfo {
+				hasNextPage
+				endCursor
+			}
+		}
+	}
+}
+fragment RollbackReleaseFields on ReleaseUnprocessed {
+	version
+	imageRef
+	image {
+		digest
+	}
+	configDefinition
+}
+`,
+		Variables: &__FlyctlRollbackReleasesInput{
+			AppName: appName,
+			Limit:   limit,
+			After:   after,
+		},
+	}
+	var err error
+
+	var data FlyctlRollbackReleasesResponse
+	resp := &graphql.Response{Data: &data}
+
+	err = client.MakeRequest(
+		ctx,
+		req,
+		resp,
+	)
+
+	return &data, err
+}
+
 func GetAddOn(
 	ctx context.Context,
 	client graphql.Client,
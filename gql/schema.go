@@ -0,0 +1,54 @@
+package gql
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/superfly/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// schemaErrorPatterns match the messages the API fails queries with when they
+// refer to parts of its schema which don't exist (anymore), along with how to
+// name the part.
+var schemaErrorPatterns = []struct {
+	re     *regexp.Regexp
+	format string
+}{
+	{regexp.MustCompile(`Field '(\w+)' doesn't exist on type '(\w+)'`), "field %[2]s.%[1]s"},
+	{regexp.MustCompile(`Field '(\w+)' doesn't accept argument '(\w+)'`), "argument %[2]s of field %[1]s"},
+	{regexp.MustCompile(`InputObject '(\w+)' doesn't accept argument '(\w+)'`), "input field %[1]s.%[2]s"},
+	{regexp.MustCompile(`(\w+) isn't a defined input type`), "input type %[1]s"},
+}
+
+// IsSchemaError reports whether err is the API rejecting a query written
+// against a schema it no longer serves, which happens when flyctl is too old.
+// part names the part of the schema the query refers to, such as
+// "field App.machines".
+func IsSchemaError(err error) (part string, ok bool) {
+	var message string
+
+	var genqErr *gqlerror.Error
+	var gqlErr *graphql.GraphQLError
+	switch {
+	case errors.As(err, &genqErr):
+		message = genqErr.Message
+	case errors.As(err, &gqlErr):
+		message = gqlErr.Message
+	default:
+		return "", false
+	}
+
+	for _, p := range schemaErrorPatterns {
+		if m := p.re.FindStringSubmatch(message); m != nil {
+			args := make([]interface{}, len(m)-1)
+			for i := range args {
+				args[i] = m[i+1]
+			}
+			return fmt.Sprintf(p.format, args...), true
+		}
+	}
+
+	return "", false
+}
@@ -0,0 +1,30 @@
+package gql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestIsSchemaError(t *testing.T) {
+	part, ok := IsSchemaError(fmt.Errorf("failed retrieving app: %w", gqlerror.List{{
+		Message:    "Field 'machines' doesn't exist on type 'App'",
+		Extensions: map[string]interface{}{"code": "undefinedField"},
+	}}))
+	assert.True(t, ok)
+	assert.Equal(t, "field App.machines", part)
+
+	part, ok = IsSchemaError(&graphql.GraphQLError{Message: "InputObject 'DeployImageInput' doesn't accept argument 'strategy'"})
+	assert.True(t, ok)
+	assert.Equal(t, "input field DeployImageInput.strategy", part)
+
+	_, ok = IsSchemaError(gqlerror.List{{Message: "Could not find App"}})
+	assert.False(t, ok)
+
+	_, ok = IsSchemaError(errors.New("Field 'machines' doesn't exist on type 'App'"))
+	assert.False(t, ok)
+}
@@ -0,0 +1,35 @@
+package gql
+
+import (
+	"errors"
+
+	"github.com/superfly/graphql"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+// ssoErrorCodes are the codes of the errors the API fails requests with when
+// the organization they concern enforces single sign-on, and the SSO session
+// of the user for it is missing or has expired.
+var ssoErrorCodes = map[string]bool{
+	"SSO_REQUIRED":        true,
+	"SSO_SESSION_EXPIRED": true,
+}
+
+// IsSSORequiredError reports whether err is the API refusing a request for
+// lack of a valid SSO session. org is the slug of the organization enforcing
+// SSO, when the API reports it.
+func IsSSORequiredError(err error) (org string, ok bool) {
+	var genqErr *gqlerror.Error
+	if errors.As(err, &genqErr) {
+		code, _ := genqErr.Extensions["code"].(string)
+		org, _ = genqErr.Extensions["organization"].(string)
+		return org, ssoErrorCodes[code]
+	}
+
+	var gqlErr *graphql.GraphQLError
+	if errors.As(err, &gqlErr) {
+		return "", ssoErrorCodes[gqlErr.Extensions.Code]
+	}
+
+	return "", false
+}
@@ -0,0 +1,32 @@
+package gql
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+)
+
+func TestIsSSORequiredError(t *testing.T) {
+	err := fmt.Errorf("failed retrieving app: %w", gqlerror.List{{
+		Message: "SSO session expired",
+		Extensions: map[string]interface{}{
+			"code":         "SSO_SESSION_EXPIRED",
+			"organization": "acme",
+		},
+	}})
+	org, ok := IsSSORequiredError(err)
+	assert.True(t, ok)
+	assert.Equal(t, "acme", org)
+
+	_, ok = IsSSORequiredError(gqlerror.List{{
+		Message:    "Could not find App",
+		Extensions: map[string]interface{}{"code": "NOT_FOUND"},
+	}})
+	assert.False(t, ok)
+
+	_, ok = IsSSORequiredError(errors.New("boom"))
+	assert.False(t, ok)
+}
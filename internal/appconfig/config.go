@@ -2,7 +2,12 @@
 // configuration files.
 package appconfig
 
-import "github.com/superfly/flyctl/api"
+import (
+	"sort"
+
+	"github.com/samber/lo"
+	"github.com/superfly/flyctl/api"
+)
 
 const (
 	// DefaultConfigFileName denotes the default application configuration file name.
@@ -37,6 +42,23 @@ type Config struct {
 	Processes     map[string]string         `toml:"processes,omitempty" json:"processes,omitempty"`
 	Checks        map[string]*ToplevelCheck `toml:"checks,omitempty" json:"checks,omitempty"`
 	Services      []Service                 `toml:"services,omitempty" json:"services,omitempty"`
+	Artifacts     []Artifact                `toml:"artifacts,omitempty" json:"artifacts,omitempty"`
+
+	// Compute sizes and places the machines of process groups, in [[vm]]
+	// sections
+	Compute []*Compute `toml:"vm,omitempty" json:"vm,omitempty"`
+
+	// Autoscaling bounds how many machines of process groups run with load,
+	// in [[autoscaling]] sections
+	Autoscaling []*Autoscaling `toml:"autoscaling,omitempty" json:"autoscaling,omitempty"`
+
+	// ScaleSchedule runs numbers of machines of process groups at times of
+	// the week, in [[scale_schedule]] sections
+	ScaleSchedule []*ScaleRule `toml:"scale_schedule,omitempty" json:"scale_schedule,omitempty"`
+
+	// CLI are the flags flyctl commands default to for this app, keyed by the
+	// command's path without flyctl, such as "deploy" or "machine run"
+	CLI map[string]map[string]any `toml:"cli,omitempty" json:"cli,omitempty"`
 
 	// RawDefinition contains fly.toml parsed as-is
 	// If you add any config field that is v2 specific, be sure to remove it in SanitizeDefinition()
@@ -45,6 +67,10 @@ type Config struct {
 	// Path to application configuration file, usually fly.toml.
 	configFilePath string
 
+	// The values interpolated on load, by path, which are written back as
+	// the references they came from
+	references map[string]reference
+
 	// Indicates the intended platform to use: machines or nomad
 	platformVersion string
 
@@ -54,8 +80,61 @@ type Config struct {
 }
 
 type Deploy struct {
-	ReleaseCommand string `toml:"release_command,omitempty" json:"release_command,omitempty"`
-	Strategy       string `toml:"strategy,omitempty" json:"strategy,omitempty"`
+	ReleaseCommand  string           `toml:"release_command,omitempty" json:"release_command,omitempty"`
+	ReleaseCommands []ReleaseCommand `toml:"release_commands,omitempty" json:"release_commands,omitempty"`
+	Strategy        string           `toml:"strategy,omitempty" json:"strategy,omitempty"`
+	Hooks           *DeployHooks     `toml:"hooks,omitempty" json:"hooks,omitempty"`
+	// ReleaseCommandVM overrides the machine config of release commands
+	ReleaseCommandVM *ReleaseCommandVM `toml:"release_command_vm,omitempty" json:"release_command_vm,omitempty"`
+	// Processes tunes how the machines of individual process groups are
+	// replaced
+	Processes map[string]*ProcessDeploy `toml:"processes,omitempty" json:"processes,omitempty"`
+}
+
+// The failure policies of drain commands
+const (
+	DrainFailureContinue = "continue"
+	DrainFailureAbort    = "abort"
+)
+
+// ProcessDeploy is how the machines of a process group are replaced. Before a
+// machine is updated, DrainCommand runs in it, such as to tell a job queue
+// worker to stop taking jobs and finish its current ones, for up to
+// DrainTimeout. When the drain command fails or times out the machine is
+// updated anyway, unless DrainFailure is abort, which fails the deployment.
+type ProcessDeploy struct {
+	DrainCommand string        `toml:"drain_command,omitempty" json:"drain_command,omitempty"`
+	DrainTimeout *api.Duration `toml:"drain_timeout,omitempty" json:"drain_timeout,omitempty"`
+	DrainFailure string        `toml:"drain_failure,omitempty" json:"drain_failure,omitempty"`
+}
+
+// ReleaseCommandVM is how the ephemeral machines release commands run in
+// differ from the app's machines: the guest size preset, the region, which
+// otherwise is the primary region, and the volume to mount.
+type ReleaseCommandVM struct {
+	Size   string  `toml:"size,omitempty" json:"size,omitempty"`
+	Region string  `toml:"region,omitempty" json:"region,omitempty"`
+	Mounts *Volume `toml:"mounts,omitempty" json:"mounts,omitempty"`
+}
+
+// ReleaseCommand is one of the commands run in turn before a deployment rolls
+// out, each in an ephemeral machine of the new release. The deployment is
+// aborted when one fails or runs longer than its timeout, unless AllowFailure
+// is set.
+type ReleaseCommand struct {
+	Command      string        `toml:"command" json:"command,omitempty"`
+	Timeout      *api.Duration `toml:"timeout,omitempty" json:"timeout,omitempty"`
+	AllowFailure bool          `toml:"allow_failure,omitempty" json:"allow_failure,omitempty"`
+}
+
+// DeployHooks are commands fly deploy runs with the shell of the local
+// machine: Pre ones before building the image, and Post ones once every
+// machine was updated successfully. With PostInMachine, Post commands run in
+// an ephemeral machine of the new release instead, like the release command.
+type DeployHooks struct {
+	Pre           []string `toml:"pre,omitempty" json:"pre,omitempty"`
+	Post          []string `toml:"post,omitempty" json:"post,omitempty"`
+	PostInMachine bool     `toml:"post_in_machine,omitempty" json:"post_in_machine,omitempty"`
 }
 
 type Static struct {
@@ -68,11 +147,70 @@ type Volume struct {
 	Destination string `toml:"destination" json:"destination,omitempty"`
 }
 
+// Artifact is a local file uploaded to the volume of each machine on deploy,
+// instead of being baked into the image.
+type Artifact struct {
+	Source      string `toml:"source" json:"source,omitempty"`
+	Destination string `toml:"destination" json:"destination,omitempty"`
+}
+
 type VM struct {
 	CpuCount int `toml:"cpu_count,omitempty" json:"cpu_count,omitempty"`
 	Memory   int `toml:"memory,omitempty" json:"memory,omitempty"`
 }
 
+// Compute is the guest size of the machines of process groups, all of them
+// when Processes is empty, and how many of them run where. With Regions, Count
+// machines run in each of them; otherwise Count is the number of machines of
+// each group, new ones being created in the primary region.
+type Compute struct {
+	Processes []string `toml:"processes,omitempty" json:"processes,omitempty"`
+	// Size is a guest size preset, such as shared-cpu-2x, which CPUKind, CPUs
+	// and MemoryMB override
+	Size     string   `toml:"size,omitempty" json:"size,omitempty"`
+	CPUKind  string   `toml:"cpu_kind,omitempty" json:"cpu_kind,omitempty"`
+	CPUs     int      `toml:"cpus,omitempty" json:"cpus,omitempty"`
+	MemoryMB int      `toml:"memory_mb,omitempty" json:"memory_mb,omitempty"`
+	Regions  []string `toml:"regions,omitempty" json:"regions,omitempty"`
+	Count    int      `toml:"count,omitempty" json:"count,omitempty"`
+}
+
+// Autoscaling is how the machines of process groups, all of them when
+// Processes is empty, scale with load. Deploys keep MaxMachines machines of
+// each group in each of its regions, those of its [[vm]] section or else the
+// primary region, and the proxy stops idle ones down to MinMachines, starting
+// them again once the running ones are past the soft limit of their services.
+// MinMachines defaults to 1, and can only be 0 with ScaleToZero. Regions
+// overrides the bounds in individual regions, which the group is deployed to
+// as well.
+type Autoscaling struct {
+	Processes   []string                      `toml:"processes,omitempty" json:"processes,omitempty"`
+	MinMachines *int                          `toml:"min_machines,omitempty" json:"min_machines,omitempty"`
+	MaxMachines int                           `toml:"max_machines,omitempty" json:"max_machines,omitempty"`
+	ScaleToZero bool                          `toml:"scale_to_zero,omitempty" json:"scale_to_zero,omitempty"`
+	SoftLimit   int                           `toml:"soft_limit,omitempty" json:"soft_limit,omitempty"`
+	HardLimit   int                           `toml:"hard_limit,omitempty" json:"hard_limit,omitempty"`
+	Regions     map[string]*RegionAutoscaling `toml:"regions,omitempty" json:"regions,omitempty"`
+}
+
+// RegionAutoscaling overrides the bounds of an [[autoscaling]] section in a
+// region, those it leaves empty being inherited.
+type RegionAutoscaling struct {
+	MinMachines *int `toml:"min_machines,omitempty" json:"min_machines,omitempty"`
+	MaxMachines int  `toml:"max_machines,omitempty" json:"max_machines,omitempty"`
+}
+
+// ScaleRule runs Count machines of ProcessGroup, the default process group
+// when empty, either during Window, days and UTC hours such as "mon-fri 8-20",
+// or from the times of the cron expression Cron, in UTC, until another rule of
+// the group fires. A rule with neither applies the rest of the time.
+type ScaleRule struct {
+	ProcessGroup string `toml:"process_group,omitempty" json:"process_group,omitempty"`
+	Window       string `toml:"window,omitempty" json:"window,omitempty"`
+	Cron         string `toml:"cron,omitempty" json:"cron,omitempty"`
+	Count        int    `toml:"count" json:"count"`
+}
+
 type Build struct {
 	Builder           string            `toml:"builder,omitempty" json:"builder,omitempty"`
 	Args              map[string]string `toml:"args,omitempty" json:"args,omitempty"`
@@ -83,6 +221,19 @@ type Build struct {
 	Dockerfile        string            `toml:"dockerfile,omitempty" json:"dockerfile,omitempty"`
 	Ignorefile        string            `toml:"ignorefile,omitempty" json:"ignorefile,omitempty"`
 	DockerBuildTarget string            `toml:"build-target,omitempty" json:"build-target,omitempty"`
+	// CacheNamespace keys the cache mounts of the Dockerfile, so successive
+	// builds of a project on the remote builder reuse their caches
+	CacheNamespace string `toml:"cache_namespace,omitempty" json:"cache_namespace,omitempty"`
+	// Processes overrides the image of individual process groups
+	Processes map[string]*ProcessBuild `toml:"processes,omitempty" json:"processes,omitempty"`
+}
+
+// ProcessBuild overrides how the image of a single process group is resolved
+// or built. Settings it leaves empty are inherited from the build section.
+type ProcessBuild struct {
+	Image             string `toml:"image,omitempty" json:"image,omitempty"`
+	Dockerfile        string `toml:"dockerfile,omitempty" json:"dockerfile,omitempty"`
+	DockerBuildTarget string `toml:"build-target,omitempty" json:"build-target,omitempty"`
 }
 
 type Experimental struct {
@@ -147,3 +298,38 @@ func (c *Config) DockerBuildTarget() string {
 	}
 	return c.Build.DockerBuildTarget
 }
+
+// ProcessGroupsWithOwnImage returns the names of the process groups whose
+// image differs from the one of the rest of the app, in ascending order.
+func (c *Config) ProcessGroupsWithOwnImage() []string {
+	if c == nil || c.Build == nil {
+		return nil
+	}
+	groups := lo.Keys(c.Build.Processes)
+	sort.Strings(groups)
+	return groups
+}
+
+// BuildForProcessGroup returns the build section the image of the given
+// process group results from, or nil if the group uses the app's image.
+func (c *Config) BuildForProcessGroup(name string) *Build {
+	if c == nil || c.Build == nil || c.Build.Processes[name] == nil {
+		return nil
+	}
+	override := c.Build.Processes[name]
+
+	build := *c.Build
+	build.Processes = nil
+	if override.Image != "" {
+		return &Build{Image: override.Image}
+	}
+	// an image at the top level is meaningless to a group built from source
+	build.Image = ""
+	if override.Dockerfile != "" {
+		build.Dockerfile = override.Dockerfile
+	}
+	if override.DockerBuildTarget != "" {
+		build.DockerBuildTarget = override.DockerBuildTarget
+	}
+	return &build
+}
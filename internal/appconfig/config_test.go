@@ -77,3 +77,29 @@ func TestManyBuildStrategies(t *testing.T) {
 
 	assert.Equal(t, 4, len(cfg.BuildStrategies()))
 }
+
+func TestBuildForProcessGroup(t *testing.T) {
+	cfg := Config{
+		Build: &Build{
+			Dockerfile: "Dockerfile",
+			Args:       map[string]string{"A": "B"},
+			Processes: map[string]*ProcessBuild{
+				"worker": {DockerBuildTarget: "worker"},
+				"proxy":  {Image: "nginx:latest"},
+			},
+		},
+	}
+
+	assert.Equal(t, []string{"proxy", "worker"}, cfg.ProcessGroupsWithOwnImage())
+	assert.Nil(t, cfg.BuildForProcessGroup("app"))
+	assert.Equal(t, &Build{Image: "nginx:latest"}, cfg.BuildForProcessGroup("proxy"))
+	assert.Equal(t, &Build{
+		Dockerfile:        "Dockerfile",
+		DockerBuildTarget: "worker",
+		Args:              map[string]string{"A": "B"},
+	}, cfg.BuildForProcessGroup("worker"))
+
+	var nilCfg *Config
+	assert.Nil(t, nilCfg.ProcessGroupsWithOwnImage())
+	assert.Nil(t, nilCfg.BuildForProcessGroup("app"))
+}
@@ -37,5 +37,13 @@ func (c *Config) SanitizedDefinition() map[string]any {
 	delete(definition, "build")
 	delete(definition, "primary_region")
 	delete(definition, "http_service")
+	delete(definition, "artifacts")
+	delete(definition, "cli")
+	delete(definition, "vm")
+	delete(definition, "autoscaling")
+	delete(definition, "scale_schedule")
+	if deploy, ok := definition["deploy"].(map[string]any); ok {
+		definition["deploy"] = lo.OmitByKeys(deploy, []string{"hooks", "release_commands", "release_command_vm", "processes"})
+	}
 	return definition
 }
@@ -181,6 +181,12 @@ func TestToDefinition(t *testing.T) {
 				"param1": "value1",
 				"param2": "value2",
 			},
+			"processes": map[string]any{
+				"task": map[string]any{
+					"dockerfile":   "Dockerfile.task",
+					"build-target": "task",
+				},
+			},
 		},
 
 		"http_service": map[string]any{
@@ -191,6 +197,10 @@ func TestToDefinition(t *testing.T) {
 				"hard_limit": int64(10),
 				"soft_limit": int64(4),
 			},
+			"tls_options": map[string]any{
+				"alpn":     []any{"h3", "h2", "http/1.1"},
+				"versions": []any{"TLSv1.2", "TLSv1.3"},
+			},
 		},
 
 		"experimental": map[string]any{
@@ -205,6 +215,19 @@ func TestToDefinition(t *testing.T) {
 		"deploy": map[string]any{
 			"release_command": "release command",
 			"strategy":        "rolling-eyes",
+			"hooks": map[string]any{
+				"pre":             []any{"npm run assets"},
+				"post":            []any{"./bin/purge-cache"},
+				"post_in_machine": true,
+			},
+			"release_command_vm": map[string]any{
+				"size":   "performance-2x",
+				"region": "ord",
+				"mounts": map[string]any{
+					"source":      "migrations",
+					"destination": "/migrations",
+				},
+			},
 		},
 		"env": map[string]any{
 			"FOO": "BAR",
@@ -223,6 +246,56 @@ func TestToDefinition(t *testing.T) {
 			"source":      "data",
 			"destination": "/data",
 		},
+		"artifacts": []map[string]any{
+			{
+				"source":      "models/weights.bin",
+				"destination": "/data/models/weights.bin",
+			},
+		},
+		"vm": []map[string]any{
+			{
+				"processes": []any{"task"},
+				"size":      "shared-cpu-2x",
+				"memory_mb": int64(1024),
+				"regions":   []any{"ord", "ams"},
+				"count":     int64(2),
+			},
+		},
+		"autoscaling": []map[string]any{
+			{
+				"processes":     []any{"web"},
+				"min_machines":  int64(0),
+				"max_machines":  int64(6),
+				"scale_to_zero": true,
+				"soft_limit":    int64(20),
+				"hard_limit":    int64(25),
+				"regions": map[string]any{
+					"syd": map[string]any{
+						"min_machines": int64(1),
+						"max_machines": int64(2),
+					},
+				},
+			},
+		},
+		"scale_schedule": []map[string]any{
+			{
+				"process_group": "web",
+				"window":        "mon-fri 8-20",
+				"count":         int64(10),
+			},
+			{
+				"process_group": "task",
+				"cron":          "0 2 * * *",
+				"count":         int64(0),
+			},
+		},
+		"cli": map[string]any{
+			"deploy": map[string]any{
+				"remote-only":  true,
+				"wait-timeout": float64(600),
+				"no-cache":     true,
+			},
+		},
 		"processes": map[string]any{
 			"web":  "run web",
 			"task": "task all day",
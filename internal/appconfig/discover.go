@@ -0,0 +1,92 @@
+package appconfig
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// maxDiscoveryDepth is how many directories deep FindConfigFilesBelow looks
+// for app config files.
+const maxDiscoveryDepth = 4
+
+// skippedDirs are the directories FindConfigFilesBelow doesn't look in, as
+// they hold dependencies rather than apps.
+var skippedDirs = map[string]bool{
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// existingConfigFileIn returns the path of the app config file of dir, empty
+// when it has none.
+func existingConfigFileIn(dir string) string {
+	for _, name := range ConfigFileNames {
+		path := filepath.Join(dir, name)
+		if fi, err := os.Stat(path); err == nil && !fi.IsDir() {
+			return path
+		}
+	}
+	return ""
+}
+
+// FindConfigFileAbove returns the path of the app config file of the closest
+// parent directory of dir which has one. It doesn't look past the root of the
+// repository dir is in, nor past the home directory of the user.
+func FindConfigFileAbove(dir string) (string, bool) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", false
+	}
+	home, _ := os.UserHomeDir()
+
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil || dir == home {
+			return "", false
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", false
+		}
+		dir = parent
+
+		if path := existingConfigFileIn(dir); path != "" {
+			return path, true
+		}
+	}
+}
+
+// FindConfigFilesBelow returns the paths, sorted, of the app config files of
+// dir and of its subdirectories, such as those of the apps of a monorepo.
+// Hidden directories and those of dependencies are left out.
+func FindConfigFilesBelow(dir string) ([]string, error) {
+	var paths []string
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case !d.IsDir():
+			return nil
+		case path != dir && (strings.HasPrefix(d.Name(), ".") || skippedDirs[d.Name()]):
+			return filepath.SkipDir
+		}
+
+		if p := existingConfigFileIn(path); p != "" {
+			paths = append(paths, p)
+		}
+
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != "." && len(strings.Split(rel, string(filepath.Separator))) >= maxDiscoveryDepth {
+			return filepath.SkipDir
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(paths)
+	return paths, nil
+}
@@ -0,0 +1,68 @@
+package appconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, paths ...string) {
+	t.Helper()
+	for _, p := range paths {
+		p = filepath.Join(root, p)
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte("app = \"test\"\n"), 0o644))
+	}
+}
+
+func TestFindConfigFileAbove(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		".git/HEAD",
+		"fly.toml",
+		"services/api/fly.toml",
+		"services/api/src/handlers/main.go",
+	)
+
+	path, ok := FindConfigFileAbove(filepath.Join(root, "services/api/src/handlers"))
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(root, "services/api/fly.toml"), path)
+
+	// the directory itself isn't looked in
+	path, ok = FindConfigFileAbove(filepath.Join(root, "services/api"))
+	assert.True(t, ok)
+	assert.Equal(t, filepath.Join(root, "fly.toml"), path)
+
+	// nor is anything above the root of the repository
+	nested := filepath.Join(root, "nested")
+	writeFiles(t, nested, ".git/HEAD", "web/index.html")
+	_, ok = FindConfigFileAbove(filepath.Join(nested, "web"))
+	assert.False(t, ok)
+}
+
+func TestFindConfigFilesBelow(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root,
+		"fly.toml",
+		"services/api/fly.toml",
+		"services/web/fly.yaml",
+		"services/web/node_modules/pkg/fly.toml",
+		".github/fly.toml",
+		"a/b/c/d/e/fly.toml",
+	)
+
+	paths, err := FindConfigFilesBelow(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		filepath.Join(root, "fly.toml"),
+		filepath.Join(root, "services/api/fly.toml"),
+		filepath.Join(root, "services/web/fly.yaml"),
+	}, paths)
+
+	paths, err = FindConfigFilesBelow(filepath.Join(root, "services/api"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{filepath.Join(root, "services/api/fly.toml")}, paths)
+}
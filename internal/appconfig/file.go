@@ -2,7 +2,6 @@ package appconfig
 
 import (
 	"os"
-	"path"
 	"path/filepath"
 )
 
@@ -23,7 +22,7 @@ func ResolveConfigFileFromPath(p string) (string, error) {
 
 	// Ok, something exists. Is it a file - yes? return the path
 	if pd.IsDir() {
-		return path.Join(p, DefaultConfigFileName), nil
+		return ConfigFilePathIn(p), nil
 	}
 
 	return p, nil
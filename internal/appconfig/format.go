@@ -0,0 +1,166 @@
+package appconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigFileNames are the names the app config file of a directory is looked
+// up by, in order. JSON and YAML files have the same semantics as TOML ones.
+var ConfigFileNames = []string{DefaultConfigFileName, "fly.json", "fly.yaml", "fly.yml"}
+
+// ConfigFilePathIn returns the path of the app config file of dir, the first
+// of ConfigFileNames there, or else the path of a fly.toml.
+func ConfigFilePathIn(dir string) string {
+	for _, name := range ConfigFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return filepath.Join(dir, DefaultConfigFileName)
+}
+
+// IsConfigFileName reports whether name is one of ConfigFileNames.
+func IsConfigFileName(name string) bool {
+	for _, n := range ConfigFileNames {
+		if name == n {
+			return true
+		}
+	}
+	return false
+}
+
+type fileFormat int
+
+const (
+	formatTOML fileFormat = iota
+	formatJSON
+	formatYAML
+)
+
+// formatOf returns the format of the app config file at path, by its
+// extension. Anything but JSON and YAML is TOML.
+func formatOf(path string) fileFormat {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return formatJSON
+	case ".yaml", ".yml":
+		return formatYAML
+	default:
+		return formatTOML
+	}
+}
+
+// decodeDefinition decodes the app config file at path, buf, into the raw
+// definition TOML would decode it into.
+func decodeDefinition(path string, buf []byte) (map[string]any, error) {
+	definition := map[string]any{}
+
+	switch formatOf(path) {
+	case formatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(buf))
+		decoder.UseNumber()
+		if err := decoder.Decode(&definition); err != nil {
+			return nil, fmt.Errorf("failed parsing %s: %w", path, err)
+		}
+	case formatYAML:
+		if err := yaml.Unmarshal(buf, &definition); err != nil {
+			return nil, fmt.Errorf("failed parsing %s: %w", path, err)
+		}
+	default:
+		if err := toml.Unmarshal(buf, &definition); err != nil {
+			return nil, err
+		}
+		return definition, nil
+	}
+
+	normalized, err := normalizeValue(definition)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing %s: %w", path, err)
+	}
+	return normalized.(map[string]any), nil
+}
+
+// normalizeValue converts the numbers JSON decodes into integers where they
+// are, and the tables YAML decodes with other than string keys into string
+// keyed ones, as TOML would have them.
+func normalizeValue(value any) (any, error) {
+	switch v := value.(type) {
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			return n, nil
+		}
+		return v.Float64()
+	case map[string]any:
+		for key, value := range v {
+			normalized, err := normalizeValue(value)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = normalized
+		}
+		return v, nil
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for key, value := range v {
+			normalized, err := normalizeValue(value)
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(key)] = normalized
+		}
+		return m, nil
+	case []any:
+		for i, value := range v {
+			normalized, err := normalizeValue(value)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = normalized
+		}
+		return v, nil
+	case nil:
+		return nil, fmt.Errorf("null values aren't supported, leave the key out instead")
+	default:
+		return v, nil
+	}
+}
+
+// convertTOML converts buf, an app config file in TOML, to the format of the
+// one at path.
+func convertTOML(path string, buf []byte) ([]byte, error) {
+	definition := map[string]any{}
+	if err := toml.Unmarshal(buf, &definition); err != nil {
+		return nil, err
+	}
+
+	switch formatOf(path) {
+	case formatJSON:
+		out, err := json.MarshalIndent(definition, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		return append(out, '\n'), nil
+	case formatYAML:
+		var b bytes.Buffer
+		encoder := yaml.NewEncoder(&b)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(definition); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+		return b.Bytes(), nil
+	default:
+		return buf, nil
+	}
+}
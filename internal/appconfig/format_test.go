@@ -0,0 +1,58 @@
+package appconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadConfig_Formats(t *testing.T) {
+	expected, err := LoadConfig("./testdata/formats.toml")
+	require.NoError(t, err)
+
+	for _, path := range []string{"./testdata/formats.json", "./testdata/formats.yaml"} {
+		cfg, err := LoadConfig(path)
+		require.NoError(t, err, path)
+
+		assert.Equal(t, path, cfg.ConfigFilePath())
+		cfg.configFilePath = expected.configFilePath
+		assert.Equal(t, expected, cfg, path)
+	}
+}
+
+func TestWriteToFile_Formats(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/formats.toml")
+	require.NoError(t, err)
+	require.NoError(t, cfg.SetMachinesPlatform())
+
+	dir := t.TempDir()
+	for _, name := range ConfigFileNames {
+		path := filepath.Join(dir, name)
+		require.NoError(t, cfg.WriteToFile(path))
+
+		written, err := LoadConfig(path)
+		require.NoError(t, err, name)
+		assert.Equal(t, cfg.AppName, written.AppName, name)
+		assert.Equal(t, cfg.Env, written.Env, name)
+		assert.Equal(t, cfg.Mounts, written.Mounts, name)
+		assert.Equal(t, cfg.Services, written.Services, name)
+	}
+
+	buf, err := os.ReadFile(filepath.Join(dir, "fly.json"))
+	require.NoError(t, err)
+	assert.Contains(t, string(buf), `"internal_port": 8080`)
+}
+
+func TestConfigFilePathIn(t *testing.T) {
+	dir := t.TempDir()
+	assert.Equal(t, filepath.Join(dir, "fly.toml"), ConfigFilePathIn(dir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fly.yaml"), []byte("app: a\n"), 0o600))
+	assert.Equal(t, filepath.Join(dir, "fly.yaml"), ConfigFilePathIn(dir))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fly.toml"), []byte("app = \"a\"\n"), 0o600))
+	assert.Equal(t, filepath.Join(dir, "fly.toml"), ConfigFilePathIn(dir))
+}
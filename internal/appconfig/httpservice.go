@@ -10,6 +10,7 @@ type HTTPService struct {
 	InternalPort int                            `json:"internal_port,omitempty" toml:"internal_port" validate:"required,numeric"`
 	ForceHttps   bool                           `toml:"force_https" json:"force_https,omitempty"`
 	Concurrency  *api.MachineServiceConcurrency `toml:"concurrency,omitempty" json:"concurrency,omitempty"`
+	TLSOptions   *api.TLSOptions                `toml:"tls_options,omitempty" json:"tls_options,omitempty"`
 }
 
 func (svc *HTTPService) toMachineService() *api.MachineService {
@@ -33,8 +34,9 @@ func (svc *HTTPService) toMachineService() *api.MachineService {
 			Handlers:   []string{"http"},
 			ForceHttps: svc.ForceHttps,
 		}, {
-			Port:     api.IntPointer(443),
-			Handlers: []string{"http", "tls"},
+			Port:       api.IntPointer(443),
+			Handlers:   []string{"http", "tls"},
+			TLSOptions: svc.TLSOptions,
 		}},
 		Concurrency: concurrency,
 	}
@@ -0,0 +1,154 @@
+package appconfig
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// variablePattern matches the ${NAME} and ${NAME:-default} references of the
+// values of app config files, and $${ which escapes them.
+var variablePattern = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// reference is a value of an app config which was interpolated on load.
+type reference struct {
+	raw   string
+	value any
+}
+
+// interpolate replaces the variable references of the string values of
+// definition, in place, with the value of vars, or else of the environment,
+// or else with the default of the reference. A value made of a single
+// reference to a number becomes that number, so that ports can come from
+// variables too. It returns the values it replaced, by path.
+func interpolate(definition map[string]any, vars map[string]string) (map[string]reference, error) {
+	lookup := func(name string) (string, bool) {
+		if value, ok := vars[name]; ok {
+			return value, true
+		}
+		return os.LookupEnv(name)
+	}
+
+	references := map[string]reference{}
+	for key, value := range definition {
+		interpolated, err := interpolateValue(key, value, lookup, references)
+		if err != nil {
+			return nil, err
+		}
+		definition[key] = interpolated
+	}
+	return references, nil
+}
+
+// interpolateValue interpolates value, found at path, and its elements,
+// recording the values it replaced in references.
+func interpolateValue(path string, value any, lookup func(string) (string, bool), references map[string]reference) (any, error) {
+	switch v := value.(type) {
+	case string:
+		interpolated, err := interpolateString(v, lookup)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if interpolated != v {
+			references[path] = reference{raw: v, value: interpolated}
+		}
+		return interpolated, nil
+	case map[string]any:
+		for key, value := range v {
+			interpolated, err := interpolateValue(path+"."+key, value, lookup, references)
+			if err != nil {
+				return nil, err
+			}
+			v[key] = interpolated
+		}
+		return v, nil
+	case []map[string]any:
+		for i, value := range v {
+			if _, err := interpolateValue(fmt.Sprintf("%s[%d]", path, i), value, lookup, references); err != nil {
+				return nil, err
+			}
+		}
+		return v, nil
+	case []any:
+		for i, value := range v {
+			interpolated, err := interpolateValue(fmt.Sprintf("%s[%d]", path, i), value, lookup, references)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = interpolated
+		}
+		return v, nil
+	default:
+		return value, nil
+	}
+}
+
+func interpolateString(s string, lookup func(string) (string, bool)) (any, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var missing string
+	replaced := variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		if ref == "$${" {
+			return "${"
+		}
+		match := variablePattern.FindStringSubmatch(ref)
+		if value, ok := lookup(match[1]); ok {
+			return value
+		}
+		if match[2] != "" {
+			return match[3]
+		}
+		if missing == "" {
+			missing = match[1]
+		}
+		return ref
+	})
+	if missing != "" {
+		return nil, fmt.Errorf("%s is referenced but not set; set it in the environment or with --var %s=value, or escape the reference as $${%s}", missing, missing, missing)
+	}
+
+	if match := variablePattern.FindString(s); match == s && match != "$${" {
+		if n, err := strconv.ParseInt(replaced, 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	return replaced, nil
+}
+
+// restoreReferences replaces the values of definition which are still those
+// references were interpolated to with the references, in place.
+func restoreReferences(definition map[string]any, references map[string]reference) {
+	for key, value := range definition {
+		definition[key] = restoreValue(key, value, references)
+	}
+}
+
+func restoreValue(path string, value any, references map[string]reference) any {
+	switch v := value.(type) {
+	case map[string]any:
+		for key, value := range v {
+			v[key] = restoreValue(path+"."+key, value, references)
+		}
+		return v
+	case []map[string]any:
+		for i, value := range v {
+			restoreValue(fmt.Sprintf("%s[%d]", path, i), value, references)
+		}
+		return v
+	case []any:
+		for i, value := range v {
+			v[i] = restoreValue(fmt.Sprintf("%s[%d]", path, i), value, references)
+		}
+		return v
+	default:
+		// values changed since they were loaded keep their new value
+		if ref, ok := references[path]; ok && fmt.Sprint(ref.value) == fmt.Sprint(v) {
+			return ref.raw
+		}
+		return value
+	}
+}
@@ -0,0 +1,311 @@
+package appconfig
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+
+	"github.com/pelletier/go-toml"
+	"golang.org/x/exp/slices"
+)
+
+// LintProblem is a problem of an app config file: a key which is unknown,
+// deprecated or only meaningful to apps on the nomad platform, or a setting
+// which doesn't validate. Line is 0 when it isn't known, as with JSON and YAML
+// files, and Key is empty for validation errors. Fixable problems are those
+// LintFix rewrites away.
+type LintProblem struct {
+	Line    int    `json:"line,omitempty"`
+	Key     string `json:"key,omitempty"`
+	Message string `json:"message"`
+	Fixable bool   `json:"fixable"`
+}
+
+func (p LintProblem) String() string {
+	var location string
+	if p.Line > 0 {
+		location = fmt.Sprintf("line %d: ", p.Line)
+	}
+	if p.Key != "" {
+		location += p.Key + ": "
+	}
+	return location + p.Message
+}
+
+// nomadOnlyKeys are the settings apps on machines ignore, by their dotted
+// path, services and checks indices left out. They're only reported when set
+// to something else than 0 or false.
+var nomadOnlyKeys = map[string]string{
+	"experimental.auto_rollback":         "apps on machines are rolled back with fly deploy --rollback-on-failure",
+	"experimental.enable_etcd":           "machines have no etcd cluster",
+	"experimental.private_network":       "machines are always on the private network of their organization",
+	"experimental.allowed_public_ports":  "the ports of machines are exposed by [[services]] and [http_service]",
+	"services.tcp_checks.restart_limit":  "machines aren't restarted by their checks",
+	"services.http_checks.restart_limit": "machines aren't restarted by their checks",
+}
+
+// Lint returns the problems of the keys of the file c was loaded from, sorted
+// by line, followed by those ValidateForMachinesPlatform finds in c.
+func (c *Config) Lint() ([]LintProblem, error) {
+	path := c.configFilePath
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var tree *toml.Tree
+	if formatOf(path) == formatTOML {
+		if tree, err = toml.LoadBytes(buf); err != nil {
+			return nil, fmt.Errorf("failed parsing %s: %w", path, err)
+		}
+	} else {
+		definition, err := decodeDefinition(path, buf)
+		if err != nil {
+			return nil, err
+		}
+		if tree, err = toml.TreeFromMap(definition); err != nil {
+			return nil, err
+		}
+	}
+
+	l := &linter{fromTOML: formatOf(path) == formatTOML}
+	l.table(tree, "", "", reflect.TypeOf(Config{}))
+
+	sort.SliceStable(l.problems, func(i, j int) bool {
+		return l.problems[i].Line < l.problems[j].Line
+	})
+
+	for _, err := range c.machinesValidationErrors() {
+		p := LintProblem{Message: err.Error()}
+		if !slices.Contains(l.problems, p) {
+			l.problems = append(l.problems, p)
+		}
+	}
+	return l.problems, nil
+}
+
+// LintFix rewrites the app config file at path in the current format, which
+// fixes the fixable problems Lint finds: deprecated forms are migrated as
+// loading the file does, and nomad only settings are dropped. It refuses to
+// when the file has unknown keys, as the rewrite would drop them as well.
+func LintFix(path string) error {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(buf, []byte("${")) {
+		return errors.New("rewriting the config would replace its ${...} references with their values, fix it by hand instead")
+	}
+
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		return err
+	}
+
+	problems, err := cfg.Lint()
+	if err != nil {
+		return err
+	}
+	for _, p := range problems {
+		if p.Key != "" && !p.Fixable {
+			return fmt.Errorf("%s can't be fixed automatically and would be lost, fix it first", p)
+		}
+	}
+
+	if err := cfg.SetMachinesPlatform(); err != nil {
+		return err
+	}
+
+	if cfg.Experimental != nil {
+		cfg.Experimental.AutoRollback = false
+		cfg.Experimental.EnableEtcd = false
+		if reflect.ValueOf(*cfg.Experimental).IsZero() {
+			cfg.Experimental = nil
+		}
+	}
+	for i := range cfg.Services {
+		for _, check := range cfg.Services[i].TCPChecks {
+			check.RestartLimit = 0
+		}
+		for _, check := range cfg.Services[i].HTTPChecks {
+			check.RestartLimit = 0
+		}
+	}
+
+	return cfg.WriteToFile(path)
+}
+
+type linter struct {
+	fromTOML bool
+	problems []LintProblem
+}
+
+func (l *linter) add(tree *toml.Tree, key, path, message string, fixable bool) {
+	p := LintProblem{Key: path, Message: message, Fixable: fixable}
+	if l.fromTOML {
+		p.Line = tree.GetPositionPath([]string{key}).Line
+	}
+	l.problems = append(l.problems, p)
+}
+
+// table checks the keys of tree, at path, against the fields of t. schema is
+// path without indices, as nomadOnlyKeys are.
+func (l *linter) table(tree *toml.Tree, path, schema string, t reflect.Type) {
+	fields := schemaFields(t)
+
+	keys := tree.Keys()
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		var (
+			value      = tree.GetPath([]string{key})
+			keyPath    = joinKey(path, key)
+			keySchema  = joinKey(schema, key)
+			field, ok  = fields[key]
+			deprecated = l.deprecated(schema, key, value)
+		)
+
+		switch {
+		case deprecated != "":
+			l.add(tree, key, keyPath, deprecated, true)
+		case nomadOnlyKeys[keySchema] != "" && value != int64(0) && value != false:
+			l.add(tree, key, keyPath, "only applies to apps on the nomad platform, "+nomadOnlyKeys[keySchema], true)
+		case !ok:
+			message := "unknown key"
+			if suggestion := closestKey(key, fields); suggestion != "" {
+				message += fmt.Sprintf(", did you mean %s?", suggestion)
+			}
+			l.add(tree, key, keyPath, message, false)
+		default:
+			l.value(value, keyPath, keySchema, field.Type)
+		}
+	}
+}
+
+func (l *linter) value(value any, path, schema string, t reflect.Type) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		if tree, ok := value.(*toml.Tree); ok {
+			l.table(tree, path, schema, t)
+		}
+	case reflect.Map:
+		tree, ok := value.(*toml.Tree)
+		if !ok {
+			return
+		}
+		for _, key := range tree.Keys() {
+			l.value(tree.GetPath([]string{key}), joinKey(path, key), schema, t.Elem())
+		}
+	case reflect.Slice:
+		if trees, ok := value.([]*toml.Tree); ok {
+			for i, tree := range trees {
+				l.value(tree, fmt.Sprintf("%s[%d]", path, i), schema, t.Elem())
+			}
+		}
+	}
+}
+
+// deprecated returns why key of the table at schema, of value, is in a form
+// that's deprecated, which loading the config migrates.
+func (l *linter) deprecated(schema, key string, value any) string {
+	switch joinKey(schema, key) {
+	case "mount":
+		return "mount was renamed to mounts"
+	case "mounts":
+		if _, ok := value.([]*toml.Tree); ok {
+			return "[[mounts]] is a single [mounts] table, only its first entry is used"
+		}
+	case "processes":
+		if _, ok := value.(*toml.Tree); !ok {
+			return "processes is a table of the command of each process group"
+		}
+	case "env":
+		if tree, ok := value.(*toml.Tree); ok {
+			for _, name := range tree.Keys() {
+				if _, ok := tree.GetPath([]string{name}).(string); !ok {
+					return fmt.Sprintf("environment variables are strings, %s isn't", name)
+				}
+			}
+		}
+	case "experimental.cmd", "experimental.entrypoint", "experimental.exec":
+		if _, ok := value.(string); ok {
+			return key + " is a list of arguments"
+		}
+	case "services.concurrency":
+		if _, ok := value.(string); ok {
+			return `concurrency is a table, such as { type = "requests", soft_limit = 20, hard_limit = 25 }`
+		}
+	case "services.tcp_checks.interval", "services.tcp_checks.timeout", "services.http_checks.interval", "services.http_checks.timeout":
+		if _, ok := value.(int64); ok {
+			return key + ` is a duration, such as "10s", rather than a number of milliseconds`
+		}
+	}
+
+	return ""
+}
+
+func joinKey(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// closestKey returns the key of fields closest to key, when it's close enough
+// to be a typo of it.
+func closestKey(key string, fields map[string]reflect.StructField) string {
+	var (
+		closest  string
+		distance = len(key)/3 + 1
+	)
+	for name := range fields {
+		if d := editDistance(key, name); d < distance || (d == distance && name < closest) {
+			closest, distance = name, d
+		}
+	}
+	return closest
+}
+
+func editDistance(a, b string) int {
+	previous := make([]int, len(b)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		current := make([]int, len(b)+1)
+		current[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			current[j] = minOf(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+
+	return previous[len(b)]
+}
+
+func minOf(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
@@ -0,0 +1,61 @@
+package appconfig
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLint(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/lint.toml")
+	require.NoError(t, err)
+
+	problems, err := cfg.Lint()
+	require.NoError(t, err)
+	assert.Equal(t, []LintProblem{
+		{Line: 3, Key: "kill_timout", Message: "unknown key, did you mean kill_timeout?"},
+		{Line: 5, Key: "mount", Message: "mount was renamed to mounts", Fixable: true},
+		{Line: 10, Key: "experimental.cmd", Message: "cmd is a list of arguments", Fixable: true},
+		{Line: 11, Key: "experimental.auto_rollback", Message: "only applies to apps on the nomad platform, apps on machines are rolled back with fly deploy --rollback-on-failure", Fixable: true},
+		{Line: 13, Key: "env", Message: "environment variables are strings, PORT isn't", Fixable: true},
+		{Line: 19, Key: "services[0].concurrency", Message: `concurrency is a table, such as { type = "requests", soft_limit = 20, hard_limit = 25 }`, Fixable: true},
+		{Line: 27, Key: "services[0].tcp_checks[0].interval", Message: `interval is a duration, such as "10s", rather than a number of milliseconds`, Fixable: true},
+		{Line: 28, Key: "services[0].tcp_checks[0].restart_limit", Message: "only applies to apps on the nomad platform, machines aren't restarted by their checks", Fixable: true},
+		{Line: 0, Message: "[[services]] #1 port 443: force_https redirects HTTP requests and needs the http handler"},
+	}, problems)
+}
+
+func TestLintFix(t *testing.T) {
+	buf, err := os.ReadFile("./testdata/lint.toml")
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "fly.toml")
+	require.NoError(t, os.WriteFile(path, buf, 0o644))
+
+	// the unknown key would be lost
+	assert.ErrorContains(t, LintFix(path), "kill_timout: unknown key")
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+app = "foo"
+
+[mount]
+  source = "data"
+  destination = "/data"
+
+[experimental]
+  cmd = "./start.sh"
+  auto_rollback = true
+`), 0o644))
+	require.NoError(t, LintFix(path))
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	problems, err := cfg.Lint()
+	require.NoError(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, &Volume{Source: "data", Destination: "/data"}, cfg.Mounts)
+	assert.Equal(t, []string{"./start.sh"}, cfg.Experimental.Cmd)
+	assert.False(t, cfg.Experimental.AutoRollback)
+}
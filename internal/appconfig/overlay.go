@@ -0,0 +1,37 @@
+package appconfig
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// OverlayPath returns the path of the overlay of the app config at path for
+// the environment env, such as fly.staging.toml next to fly.toml.
+func OverlayPath(path, env string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "." + env + ext
+}
+
+// MergeDefinitions returns the definition of an app config, base, with the one
+// of an overlay merged on top of it. Tables, such as [env] or [http_service],
+// are merged key by key, recursively. Any other value of overlay, arrays of
+// tables such as [[services]] or [[mounts]] included, replaces the one of base
+// as a whole. Neither base nor overlay are modified.
+func MergeDefinitions(base, overlay map[string]any) map[string]any {
+	merged := make(map[string]any, len(base)+len(overlay))
+	for key, value := range base {
+		merged[key] = value
+	}
+
+	for key, value := range overlay {
+		baseTable, baseIsTable := merged[key].(map[string]any)
+		overlayTable, overlayIsTable := value.(map[string]any)
+		if baseIsTable && overlayIsTable {
+			merged[key] = MergeDefinitions(baseTable, overlayTable)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
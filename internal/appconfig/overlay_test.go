@@ -0,0 +1,65 @@
+package appconfig
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOverlayPath(t *testing.T) {
+	assert.Equal(t, "fly.staging.toml", OverlayPath("fly.toml", "staging"))
+	assert.Equal(t, "/app/web.production.toml", OverlayPath("/app/web.toml", "production"))
+}
+
+func TestMergeDefinitions(t *testing.T) {
+	base := map[string]any{
+		"app": "base",
+		"env": map[string]any{"A": "1", "B": "2"},
+		"services": []map[string]any{
+			{"internal_port": 8080},
+			{"internal_port": 9000},
+		},
+	}
+	overlay := map[string]any{
+		"app":      "overlay",
+		"env":      map[string]any{"B": "3", "C": "4"},
+		"services": []map[string]any{{"internal_port": 8081}},
+	}
+
+	assert.Equal(t, map[string]any{
+		"app":      "overlay",
+		"env":      map[string]any{"A": "1", "B": "3", "C": "4"},
+		"services": []map[string]any{{"internal_port": 8081}},
+	}, MergeDefinitions(base, overlay))
+
+	// neither is modified
+	assert.Equal(t, "base", base["app"])
+	assert.Equal(t, map[string]any{"A": "1", "B": "2"}, base["env"])
+}
+
+func TestLoadConfigWithOverlay(t *testing.T) {
+	t.Setenv("STAGE", "staging")
+
+	cfg, err := LoadConfigWithOverlay("./testdata/overlay.toml", "./testdata/overlay.staging.toml", map[string]string{})
+	require.NoError(t, err)
+
+	assert.Equal(t, "./testdata/overlay.toml", cfg.ConfigFilePath())
+	assert.Equal(t, "overlay-app-staging", cfg.AppName)
+	assert.Equal(t, "ord", cfg.PrimaryRegion)
+	assert.Equal(t, map[string]string{
+		"LOG_LEVEL":    "info",
+		"DATABASE_URL": "postgres://staging.internal/app",
+	}, cfg.Env)
+	require.NotNil(t, cfg.HttpService)
+	assert.Equal(t, 8080, cfg.HttpService.InternalPort)
+	assert.False(t, cfg.HttpService.ForceHttps)
+	require.Len(t, cfg.Services, 1)
+	assert.Equal(t, 9001, cfg.Services[0].InternalPort)
+	assert.Equal(t, "udp", cfg.Services[0].Protocol)
+
+	_, err = LoadConfigWithOverlay("./testdata/overlay.toml", "./testdata/overlay.production.toml", nil)
+	assert.ErrorContains(t, err, "failed loading overlay")
+	assert.NotErrorIs(t, err, fs.ErrNotExist)
+}
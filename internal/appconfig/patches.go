@@ -6,6 +6,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"golang.org/x/exp/slices"
 )
 
 type patchFuncType func(map[string]any) (map[string]any, error)
@@ -78,7 +80,9 @@ func patchProcesses(cfg map[string]any) (map[string]any, error) {
 			// GQL GetConfig returns an empty array when there are not processes
 			delete(cfg, "processes")
 		case map[string]any:
-			// Nothing to do here
+			if err := patchProcessBuilds(cfg, cast); err != nil {
+				return nil, err
+			}
 		default:
 			return nil, fmt.Errorf("Unknown processes type: %T", cast)
 		}
@@ -86,6 +90,70 @@ func patchProcesses(cfg map[string]any) (map[string]any, error) {
 	return cfg, nil
 }
 
+// processBuildKeys are the keys of a process group given as a table which set
+// how its image is built, as in [build.processes.<group>].
+var processBuildKeys = []string{"image", "dockerfile", "build-target"}
+
+// patchProcessBuilds turns the process groups of processes given as tables,
+// with a cmd and how their image is built, into their command, moving the
+// rest to [build.processes.<group>].
+func patchProcessBuilds(cfg map[string]any, processes map[string]any) error {
+	for group, raw := range processes {
+		entry, ok := raw.(map[string]any)
+		if !ok {
+			continue
+		}
+
+		cmd, ok := entry["cmd"].(string)
+		if !ok {
+			return fmt.Errorf("process group %s must set its command with cmd", group)
+		}
+		processes[group] = cmd
+
+		override := map[string]any{}
+		for k, v := range entry {
+			switch {
+			case k == "cmd":
+			case k == "build":
+				build, ok := v.(map[string]any)
+				if !ok {
+					return fmt.Errorf("build of process group %s must be a table", group)
+				}
+				for bk, bv := range build {
+					if !slices.Contains(processBuildKeys, bk) {
+						return fmt.Errorf("unknown key %s in the build of process group %s, expected one of %s", bk, group, strings.Join(processBuildKeys, ", "))
+					}
+					override[bk] = bv
+				}
+			case slices.Contains(processBuildKeys, k):
+				override[k] = v
+			default:
+				return fmt.Errorf("unknown key %s in process group %s, expected cmd, build or one of %s", k, group, strings.Join(processBuildKeys, ", "))
+			}
+		}
+		if len(override) == 0 {
+			continue
+		}
+
+		build, ok := cfg["build"].(map[string]any)
+		if !ok {
+			build = map[string]any{}
+			cfg["build"] = build
+		}
+		builds, ok := build["processes"].(map[string]any)
+		if !ok {
+			builds = map[string]any{}
+			build["processes"] = builds
+		}
+		if _, ok := builds[group]; ok {
+			return fmt.Errorf("process group %s sets how its image is built both in [processes] and in [build.processes.%s]", group, group)
+		}
+		builds[group] = override
+	}
+
+	return nil
+}
+
 func patchExperimental(cfg map[string]any) (map[string]any, error) {
 	raw, ok := cfg["experimental"]
 	if !ok {
@@ -2,6 +2,8 @@ package appconfig
 
 import (
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/google/shlex"
 	"github.com/samber/lo"
@@ -13,6 +15,21 @@ type ProcessConfig struct {
 	Cmd      []string
 	Services []api.MachineService
 	Checks   map[string]api.MachineCheck
+	// Guest, Regions and Count come from the [[vm]] section of the group,
+	// and are left empty without one
+	Guest   *api.MachineGuest
+	Regions []string
+	Count   int
+	// Autoscaling are the bounds of the number of machines of the group in
+	// each region, from its [[autoscaling]] section, nil without one
+	Autoscaling map[string]MachineBounds
+}
+
+// MachineBounds are the least and the most machines of a group running in a
+// region.
+type MachineBounds struct {
+	Min int
+	Max int
 }
 
 func (c *Config) GetProcessConfigs() (map[string]*ProcessConfig, error) {
@@ -83,9 +100,243 @@ func (c *Config) GetProcessConfigs() (map[string]*ProcessConfig, error) {
 			}
 		}
 	}
+
+	if err := c.applyCompute(res); err != nil {
+		return nil, err
+	}
+	if err := c.applyAutoscaling(res); err != nil {
+		return nil, err
+	}
 	return res, nil
 }
 
+// applyCompute sets the guest size and placement of process groups from their
+// [[vm]] sections. A section naming processes takes precedence over one
+// without, which applies to every other group.
+func (c *Config) applyCompute(processConfigs map[string]*ProcessConfig) error {
+	sections := map[string]*Compute{}
+	var fallback *Compute
+
+	for _, compute := range c.Compute {
+		if compute == nil {
+			continue
+		}
+		if len(compute.Processes) == 0 {
+			if fallback != nil {
+				return fmt.Errorf("only one [[vm]] section can leave out processes")
+			}
+			fallback = compute
+			continue
+		}
+		for _, name := range compute.Processes {
+			if _, ok := processConfigs[name]; !ok {
+				return fmt.Errorf("[[vm]] section refers to '%s' process group which is not defined in [processes]", name)
+			}
+			if _, ok := sections[name]; ok {
+				return fmt.Errorf("more than one [[vm]] section refers to '%s' process group", name)
+			}
+			sections[name] = compute
+		}
+	}
+
+	for name, pc := range processConfigs {
+		compute, ok := sections[name]
+		if !ok {
+			compute = fallback
+		}
+		if compute == nil {
+			continue
+		}
+
+		guest, err := compute.guest()
+		if err != nil {
+			return fmt.Errorf("[[vm]] section of '%s' process group: %w", name, err)
+		}
+		if compute.Count < 0 {
+			return fmt.Errorf("[[vm]] section of '%s' process group can't have a negative count", name)
+		}
+		pc.Guest = guest
+		pc.Regions = compute.Regions
+		pc.Count = compute.Count
+	}
+
+	return nil
+}
+
+// applyAutoscaling sets the machine bounds of process groups from their
+// [[autoscaling]] sections, and lets the proxy stop and start the machines of
+// their services. As with [[vm]] sections, one naming processes takes
+// precedence over one without.
+func (c *Config) applyAutoscaling(processConfigs map[string]*ProcessConfig) error {
+	sections := map[string]*Autoscaling{}
+	var fallback *Autoscaling
+
+	for _, autoscaling := range c.Autoscaling {
+		if autoscaling == nil {
+			continue
+		}
+		if len(autoscaling.Processes) == 0 {
+			if fallback != nil {
+				return fmt.Errorf("only one [[autoscaling]] section can leave out processes")
+			}
+			fallback = autoscaling
+			continue
+		}
+		for _, name := range autoscaling.Processes {
+			if _, ok := processConfigs[name]; !ok {
+				return fmt.Errorf("[[autoscaling]] section refers to '%s' process group which is not defined in [processes]", name)
+			}
+			if _, ok := sections[name]; ok {
+				return fmt.Errorf("more than one [[autoscaling]] section refers to '%s' process group", name)
+			}
+			sections[name] = autoscaling
+		}
+	}
+
+	for name, pc := range processConfigs {
+		autoscaling, ok := sections[name]
+		if !ok {
+			autoscaling = fallback
+		}
+		if autoscaling == nil {
+			continue
+		}
+		if pc.Count > 0 {
+			return fmt.Errorf("[[vm]] section of '%s' process group sets a count, which its [[autoscaling]] section's max_machines replaces", name)
+		}
+
+		bounds, err := autoscaling.bounds(pc.Regions, c.PrimaryRegion)
+		if err != nil {
+			return fmt.Errorf("[[autoscaling]] section of '%s' process group: %w", name, err)
+		}
+		pc.Autoscaling = bounds
+
+		for i := range pc.Services {
+			autoscaling.applyTo(&pc.Services[i])
+		}
+	}
+
+	return nil
+}
+
+// bounds resolves the machine bounds of the section in regions, or the
+// primary region, and in the regions it overrides them in.
+func (autoscaling *Autoscaling) bounds(regions []string, primaryRegion string) (map[string]MachineBounds, error) {
+	if autoscaling.SoftLimit < 0 || autoscaling.HardLimit < 0 {
+		return nil, fmt.Errorf("soft_limit and hard_limit can't be negative")
+	}
+	if autoscaling.SoftLimit > 0 && autoscaling.HardLimit > 0 && autoscaling.SoftLimit > autoscaling.HardLimit {
+		return nil, fmt.Errorf("soft_limit %d is above hard_limit %d", autoscaling.SoftLimit, autoscaling.HardLimit)
+	}
+
+	defaults := MachineBounds{Min: 1, Max: autoscaling.MaxMachines}
+	if autoscaling.MinMachines != nil {
+		defaults.Min = *autoscaling.MinMachines
+	}
+
+	if len(regions) == 0 {
+		regions = []string{primaryRegion}
+	}
+	res := map[string]MachineBounds{}
+	for _, region := range regions {
+		res[region] = defaults
+	}
+	for region, override := range autoscaling.Regions {
+		b := defaults
+		if override != nil {
+			if override.MinMachines != nil {
+				b.Min = *override.MinMachines
+			}
+			if override.MaxMachines != 0 {
+				b.Max = override.MaxMachines
+			}
+		}
+		res[region] = b
+	}
+
+	for region, b := range res {
+		switch {
+		case b.Min < 0:
+			return nil, fmt.Errorf("min_machines can't be negative")
+		case b.Min == 0 && !autoscaling.ScaleToZero:
+			return nil, fmt.Errorf("min_machines is 0 in %s, which requires scale_to_zero", region)
+		case b.Max == 0:
+			b.Max = lo.Max([]int{b.Min, 1})
+			res[region] = b
+		case b.Max < b.Min:
+			return nil, fmt.Errorf("max_machines %d is below min_machines %d in %s", b.Max, b.Min, region)
+		}
+	}
+
+	return res, nil
+}
+
+// applyTo lets the proxy stop and start the machines of service, and applies
+// the concurrency limits of the section to it.
+func (autoscaling *Autoscaling) applyTo(service *api.MachineService) {
+	service.Autostop = api.Pointer(true)
+	service.Autostart = api.Pointer(true)
+
+	if autoscaling.SoftLimit == 0 && autoscaling.HardLimit == 0 {
+		return
+	}
+	concurrency := api.MachineServiceConcurrency{Type: "connections"}
+	if service.Concurrency != nil {
+		concurrency = *service.Concurrency
+	}
+	if autoscaling.HardLimit > 0 {
+		concurrency.HardLimit = autoscaling.HardLimit
+	}
+	if autoscaling.SoftLimit > 0 {
+		concurrency.SoftLimit = autoscaling.SoftLimit
+	}
+	if concurrency.SoftLimit > concurrency.HardLimit && concurrency.HardLimit > 0 {
+		concurrency.SoftLimit = concurrency.HardLimit
+	}
+	service.Concurrency = &concurrency
+}
+
+// guest returns the guest size of the section, nil when it leaves the size of
+// machines alone.
+func (compute *Compute) guest() (*api.MachineGuest, error) {
+	if compute.Size == "" && compute.CPUKind == "" && compute.CPUs == 0 && compute.MemoryMB == 0 {
+		return nil, nil
+	}
+
+	guest := &api.MachineGuest{}
+	if compute.Size != "" {
+		preset, ok := api.MachinePresets[compute.Size]
+		if !ok {
+			sizes := lo.Keys(api.MachinePresets)
+			sort.Strings(sizes)
+			return nil, fmt.Errorf("invalid size '%s', available: %s", compute.Size, strings.Join(sizes, ", "))
+		}
+		*guest = *preset
+	} else if compute.CPUKind == "performance" {
+		*guest = *api.MachinePresets["performance-1x"]
+	} else {
+		*guest = *api.MachinePresets["shared-cpu-1x"]
+	}
+
+	if compute.CPUKind != "" {
+		if compute.CPUKind != "shared" && compute.CPUKind != "performance" {
+			return nil, fmt.Errorf("invalid cpu_kind '%s', expected shared or performance", compute.CPUKind)
+		}
+		guest.CPUKind = compute.CPUKind
+	}
+	if compute.CPUs < 0 || compute.MemoryMB < 0 {
+		return nil, fmt.Errorf("cpus and memory_mb can't be negative")
+	}
+	if compute.CPUs != 0 {
+		guest.CPUs = compute.CPUs
+	}
+	if compute.MemoryMB != 0 {
+		guest.MemoryMB = compute.MemoryMB
+	}
+
+	return guest, nil
+}
+
 // DefaultProcessName returns:
 // * "app" when no processes are defined
 // * "app" if present in the processes map
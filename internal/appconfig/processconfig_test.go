@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
 )
 
 func TestGetDefaultProcessName_Nil(t *testing.T) {
@@ -62,3 +63,125 @@ func TestGetDefaultProcessName_ManyWithApp(t *testing.T) {
 	assert.NoError(t, cfg.SetMachinesPlatform())
 	assert.Equal(t, "app", cfg.DefaultProcessName())
 }
+
+func TestGetProcessConfigs_ExecCheck(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/checks-exec.toml")
+	assert.NoError(t, err)
+
+	configs, err := cfg.GetProcessConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, api.MachineCheck{
+		Type:        api.Pointer("exec"),
+		Interval:    mustParseDuration("15s"),
+		Timeout:     mustParseDuration("5s"),
+		Command:     []string{"bin/healthcheck", "--quiet"},
+		HTTPHeaders: []api.MachineHTTPHeader{},
+	}, configs["worker"].Checks["alive"])
+
+	cfg.Checks["alive"].Command = nil
+	_, err = cfg.GetProcessConfigs()
+	assert.Error(t, err)
+}
+
+func TestGetProcessConfigs_GRPCCheck(t *testing.T) {
+	cfg, err := LoadConfig("./testdata/checks-grpc.toml")
+	assert.NoError(t, err)
+
+	configs, err := cfg.GetProcessConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, api.MachineCheck{
+		Type:        api.Pointer("grpc"),
+		Port:        api.Pointer(50051),
+		GRPCService: api.Pointer("helloworld.Greeter"),
+		GRPCUseTLS:  api.Pointer(true),
+		HTTPHeaders: []api.MachineHTTPHeader{},
+	}, configs["app"].Checks["grpc"])
+
+	cfg.Checks["grpc"].Port = nil
+	_, err = cfg.GetProcessConfigs()
+	assert.Error(t, err)
+}
+
+func TestGetProcessConfigs_Compute(t *testing.T) {
+	cfg := &Config{
+		Processes: map[string]string{"web": "run web", "worker": "run worker", "cron": "run cron"},
+		Compute: []*Compute{
+			{Size: "shared-cpu-2x"},
+			{Processes: []string{"worker"}, CPUKind: "performance", Regions: []string{"ord", "ams"}, Count: 2},
+		},
+	}
+
+	processConfigs, err := cfg.GetProcessConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, &api.MachineGuest{CPUKind: "shared", CPUs: 2, MemoryMB: 512}, processConfigs["web"].Guest)
+	assert.Equal(t, &api.MachineGuest{CPUKind: "shared", CPUs: 2, MemoryMB: 512}, processConfigs["cron"].Guest)
+	assert.Empty(t, processConfigs["web"].Regions)
+	assert.Equal(t, &api.MachineGuest{CPUKind: "performance", CPUs: 1, MemoryMB: 2048}, processConfigs["worker"].Guest)
+	assert.Equal(t, []string{"ord", "ams"}, processConfigs["worker"].Regions)
+	assert.Equal(t, 2, processConfigs["worker"].Count)
+
+	cfg.Compute[1].Processes = []string{"jobs"}
+	_, err = cfg.GetProcessConfigs()
+	assert.ErrorContains(t, err, "'jobs' process group which is not defined")
+
+	cfg.Compute[1].Processes = []string{"worker"}
+	cfg.Compute = append(cfg.Compute, &Compute{Processes: []string{"worker"}})
+	_, err = cfg.GetProcessConfigs()
+	assert.ErrorContains(t, err, "more than one [[vm]] section refers to 'worker'")
+
+	cfg.Compute = []*Compute{{Size: "huge"}}
+	_, err = cfg.GetProcessConfigs()
+	assert.ErrorContains(t, err, "invalid size 'huge'")
+}
+
+func TestGetProcessConfigs_Autoscaling(t *testing.T) {
+	cfg := &Config{
+		PrimaryRegion: "ord",
+		Processes:     map[string]string{"web": "run web", "worker": "run worker"},
+		Services: []Service{
+			{Protocol: "tcp", InternalPort: 8080, Processes: []string{"web"}},
+		},
+		Autoscaling: []*Autoscaling{
+			{MaxMachines: 2},
+			{
+				Processes:   []string{"web"},
+				MinMachines: api.Pointer(0),
+				MaxMachines: 5,
+				ScaleToZero: true,
+				SoftLimit:   20,
+				HardLimit:   25,
+				Regions:     map[string]*RegionAutoscaling{"ams": {MinMachines: api.Pointer(1)}},
+			},
+		},
+	}
+
+	processConfigs, err := cfg.GetProcessConfigs()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]MachineBounds{"ord": {Min: 0, Max: 5}, "ams": {Min: 1, Max: 5}}, processConfigs["web"].Autoscaling)
+	assert.Equal(t, map[string]MachineBounds{"ord": {Min: 1, Max: 2}}, processConfigs["worker"].Autoscaling)
+
+	service := processConfigs["web"].Services[0]
+	assert.Equal(t, api.Pointer(true), service.Autostop)
+	assert.Equal(t, api.Pointer(true), service.Autostart)
+	assert.Equal(t, &api.MachineServiceConcurrency{Type: "connections", SoftLimit: 20, HardLimit: 25}, service.Concurrency)
+
+	cfg.Autoscaling[1].ScaleToZero = false
+	_, err = cfg.GetProcessConfigs()
+	assert.ErrorContains(t, err, "min_machines is 0 in ord, which requires scale_to_zero")
+
+	cfg.Autoscaling[1].ScaleToZero = true
+	cfg.Autoscaling[1].MaxMachines = 0
+	cfg.Autoscaling[1].Regions["ams"].MinMachines = api.Pointer(3)
+	cfg.Autoscaling[1].Regions["ams"].MaxMachines = 2
+	_, err = cfg.GetProcessConfigs()
+	assert.ErrorContains(t, err, "max_machines 2 is below min_machines 3 in ams")
+
+	cfg.Autoscaling = []*Autoscaling{{SoftLimit: 30, HardLimit: 25}}
+	_, err = cfg.GetProcessConfigs()
+	assert.ErrorContains(t, err, "soft_limit 30 is above hard_limit 25")
+
+	cfg.Autoscaling = []*Autoscaling{{}}
+	cfg.Compute = []*Compute{{Count: 3}}
+	_, err = cfg.GetProcessConfigs()
+	assert.ErrorContains(t, err, "replaces")
+}
@@ -0,0 +1,113 @@
+package appconfig
+
+//go:generate go run ../.. config schema --output schema.json
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// JSONSchema returns the JSON Schema of app config files, generated from
+// Config so that it can't drift from what flyctl loads. Editors use it to
+// complete and validate fly.toml, and the fly.json and fly.yaml equivalents.
+func JSONSchema() ([]byte, error) {
+	schema := typeSchema(reflect.TypeOf(Config{}))
+
+	// process groups may also be tables setting how their image is built,
+	// see patchProcessBuilds
+	build := typeSchema(reflect.TypeOf(ProcessBuild{}))
+	table := typeSchema(reflect.TypeOf(ProcessBuild{}))
+	table["properties"].(map[string]any)["cmd"] = map[string]any{"type": "string"}
+	table["properties"].(map[string]any)["build"] = build
+	table["required"] = []string{"cmd"}
+	schema["properties"].(map[string]any)["processes"] = map[string]any{
+		"type":                 "object",
+		"additionalProperties": map[string]any{"oneOf": []any{map[string]any{"type": "string"}, table}},
+	}
+
+	schema["$schema"] = "http://json-schema.org/draft-07/schema#"
+	schema["title"] = "Fly app config"
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+var durationSchema = map[string]any{
+	"type":        []string{"string", "integer"},
+	"description": `A duration such as "10s" or "1m30s", or a number of milliseconds`,
+}
+
+func typeSchema(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if reflect.PointerTo(t).Implements(reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()) {
+		return durationSchema
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		return map[string]any{"type": "array", "items": typeSchema(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": typeSchema(t.Elem())}
+	case reflect.Struct:
+		var (
+			properties = map[string]any{}
+			required   []string
+		)
+		for name, field := range schemaFields(t) {
+			properties[name] = typeSchema(field.Type)
+			if strings.Contains(field.Tag.Get("validate"), "required") {
+				required = append(required, name)
+			}
+		}
+		schema := map[string]any{
+			"type":                 "object",
+			"properties":           properties,
+			"additionalProperties": false,
+		}
+		if len(required) > 0 {
+			sort.Strings(required)
+			schema["required"] = required
+		}
+		return schema
+	default:
+		// any value, such as the build settings of buildpacks
+		return map[string]any{}
+	}
+}
+
+// schemaFields returns the fields of struct t by the key they're loaded from.
+func schemaFields(t reflect.Type) map[string]reflect.StructField {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+
+	return fields
+}
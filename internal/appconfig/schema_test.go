@@ -0,0 +1,32 @@
+package appconfig
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	schema, err := JSONSchema()
+	require.NoError(t, err)
+
+	published, err := os.ReadFile("schema.json")
+	require.NoError(t, err)
+	assert.Equal(t, string(published), string(schema)+"\n", "schema.json is out of date, run go generate ./internal/appconfig")
+
+	var decoded struct {
+		Properties map[string]struct {
+			Type       string
+			Properties map[string]map[string]any
+			Required   []string
+		}
+	}
+	require.NoError(t, json.Unmarshal(schema, &decoded))
+	assert.Equal(t, "object", decoded.Properties["http_service"].Type)
+	assert.Equal(t, []string{"internal_port"}, decoded.Properties["http_service"].Required)
+	assert.Equal(t, "array", decoded.Properties["services"].Type)
+	assert.Equal(t, map[string]any{"type": "integer"}, decoded.Properties["metrics"].Properties["port"])
+}
@@ -14,19 +14,77 @@ import (
 	"github.com/superfly/flyctl/iostreams"
 )
 
-// LoadConfig loads the app config at the given path.
+// LoadConfig loads the app config at the given path, leaving the ${NAME}
+// references of its values as they are.
 func LoadConfig(path string) (cfg *Config, err error) {
+	return LoadConfigWithVars(path, nil)
+}
+
+// LoadConfigWithVars loads the app config at the given path, replacing the
+// ${NAME} references of its values with vars or the environment. Interpolation
+// is opt-in: references are left as they are when vars is nil, as they may be
+// meant for the shell of the machines, such as in [env] or [processes].
+func LoadConfigWithVars(path string, vars map[string]string) (cfg *Config, err error) {
+	return LoadConfigWithOverlay(path, "", vars)
+}
+
+// LoadConfigWithOverlay loads the app config at the given path with the one at
+// overlayPath, unless empty, merged on top of it as MergeDefinitions does.
+// The ${NAME} references of the values of both are replaced with vars or the
+// environment, unless vars is nil. Either may be in TOML, JSON or YAML, as
+// their extension tells.
+func LoadConfigWithOverlay(path, overlayPath string, vars map[string]string) (cfg *Config, err error) {
 	buf, err := os.ReadFile(path)
 	if err != nil {
 		return nil, err
 	}
 
+	var overlay []byte
+	if overlayPath != "" {
+		if overlay, err = os.ReadFile(overlayPath); err != nil {
+			// not wrapped, a missing overlay isn't a missing app config
+			return nil, fmt.Errorf("failed loading overlay: %v", err)
+		}
+	}
+
+	// anything but a plain TOML file goes through its raw definition, which is
+	// then encoded to TOML
+	interpolating := vars != nil && bytes.Contains(buf, []byte("${"))
+	var references map[string]reference
+	if overlay != nil || formatOf(path) != formatTOML || interpolating {
+		definition, err := decodeDefinition(path, buf)
+		if err != nil {
+			return nil, err
+		}
+
+		if overlay != nil {
+			overlayDefinition, err := decodeDefinition(overlayPath, overlay)
+			if err != nil {
+				return nil, err
+			}
+			definition = MergeDefinitions(definition, overlayDefinition)
+		}
+
+		if vars != nil {
+			if references, err = interpolate(definition, vars); err != nil {
+				return nil, fmt.Errorf("failed interpolating %s: %w", path, err)
+			}
+		}
+
+		var b bytes.Buffer
+		if err := toml.NewEncoder(&b).Encode(definition); err != nil {
+			return nil, err
+		}
+		buf = b.Bytes()
+	}
+
 	cfg, err = unmarshalTOML(buf)
 	if err != nil {
 		return nil, err
 	}
 
 	cfg.configFilePath = path
+	cfg.references = references
 	// cfg.WriteToFile("patched-fly.toml")
 	return cfg, nil
 }
@@ -46,7 +104,22 @@ func (c *Config) WriteToFile(filename string) (err error) {
 		}
 	}()
 
-	err = c.marshalTOML(file)
+	if formatOf(filename) == formatTOML {
+		err = c.marshalTOML(file)
+		return
+	}
+
+	// JSON and YAML files are converted from the TOML one, so that they
+	// serialize the same
+	var b bytes.Buffer
+	if err = c.marshalTOML(&b); err != nil {
+		return
+	}
+	var buf []byte
+	if buf, err = convertTOML(filename, b.Bytes()); err != nil {
+		return
+	}
+	_, err = file.Write(buf)
 	return
 }
 
@@ -94,8 +167,7 @@ func (c *Config) marshalTOML(w io.Writer) error {
 	// For machines apps, encode and write directly, bypassing custom marshalling
 	if c.platformVersion == MachinesPlatform {
 		encoder.Encode(&c)
-		_, err := b.WriteTo(w)
-		return err
+		return c.writeWithReferences(w, &b)
 	}
 
 	// Write app name first to be sure it will be there at the top
@@ -132,8 +204,7 @@ func (c *Config) marshalTOML(w io.Writer) error {
 		}
 	}
 
-	_, err := b.WriteTo(w)
-	return err
+	return c.writeWithReferences(w, &b)
 }
 
 func (c *Config) toTOMLString() (string, error) {
@@ -209,3 +280,21 @@ func unmarshalBuild(data map[string]interface{}) *Build {
 
 	return b
 }
+
+// writeWithReferences writes the TOML encoded config b to w, with the values
+// which were interpolated on load written as the references they came from,
+// so that writing a config back doesn't replace them with local values.
+func (c *Config) writeWithReferences(w io.Writer, b *bytes.Buffer) error {
+	if len(c.references) == 0 {
+		_, err := b.WriteTo(w)
+		return err
+	}
+
+	definition := map[string]any{}
+	if err := toml.Unmarshal(b.Bytes(), &definition); err != nil {
+		return err
+	}
+	restoreReferences(definition, c.references)
+
+	return toml.NewEncoder(w).Encode(definition)
+}
@@ -2,9 +2,13 @@ package appconfig
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/superfly/flyctl/api"
 )
 
@@ -48,6 +52,85 @@ func TestLoadTOMLAppConfigWithBuilderNameAndArgs(t *testing.T) {
 	assert.Equal(t, p.Build.Args, map[string]string{"A": "B", "C": "D"})
 }
 
+func TestLoadTOMLAppConfigWithReleaseCommands(t *testing.T) {
+	const path = "./testdata/release-commands.toml"
+
+	p, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, []ReleaseCommand{
+		{Command: "bin/rails db:migrate", Timeout: &api.Duration{Duration: 10 * time.Minute}},
+		{Command: "bin/rails cache:warm", AllowFailure: true},
+	}, p.Deploy.ReleaseCommands)
+	assert.NoError(t, p.validateReleaseCommands())
+
+	p.Deploy.ReleaseCommand = "bin/rails db:migrate"
+	assert.Error(t, p.validateReleaseCommands())
+}
+
+func TestLoadTOMLAppConfigWithProcessDrain(t *testing.T) {
+	const path = "./testdata/processes-drain.toml"
+
+	p, err := LoadConfig(path)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]*ProcessDeploy{
+		"worker": {
+			DrainCommand: "bin/sidekiq-quiet",
+			DrainTimeout: &api.Duration{Duration: 5 * time.Minute},
+			DrainFailure: DrainFailureAbort,
+		},
+	}, p.Deploy.Processes)
+	assert.NoError(t, p.validateProcessDeploys())
+	assert.NotContains(t, p.SanitizedDefinition()["deploy"], "processes")
+
+	p.Deploy.Processes["worker"].DrainFailure = "retry"
+	assert.Error(t, p.validateProcessDeploys())
+
+	p.Deploy.Processes["worker"].DrainFailure = ""
+	p.Deploy.Processes["jobs"] = p.Deploy.Processes["worker"]
+	assert.Error(t, p.validateProcessDeploys())
+}
+
+func TestLoadTOMLAppConfigWithProcessBuilds(t *testing.T) {
+	const path = "./testdata/processes-build.toml"
+
+	cfg, err := LoadConfig(path)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"app":    "bin/rails server",
+		"worker": "bundle exec sidekiq",
+		"ml":     "python serve.py",
+	}, cfg.Processes)
+	assert.Equal(t, map[string]*ProcessBuild{
+		"worker": {Dockerfile: "Dockerfile.worker", DockerBuildTarget: "worker"},
+		"ml":     {Image: "registry.example.com/ml:latest"},
+	}, cfg.Build.Processes)
+	assert.Equal(t, "Dockerfile", cfg.Build.Dockerfile)
+	assert.Equal(t, []string{"ml", "worker"}, cfg.ProcessGroupsWithOwnImage())
+
+	// written back as [build.processes.<group>]
+	require.NoError(t, cfg.SetMachinesPlatform())
+	out := filepath.Join(t.TempDir(), "fly.toml")
+	require.NoError(t, cfg.WriteToFile(out))
+	reloaded, err := LoadConfig(out)
+	require.NoError(t, err)
+	assert.Equal(t, cfg.Processes, reloaded.Processes)
+	assert.Equal(t, cfg.Build.Processes, reloaded.Build.Processes)
+
+	for _, tc := range []struct {
+		toml string
+		err  string
+	}{
+		{"[processes.worker]\ndockerfile = \"Dockerfile.worker\"", "process group worker must set its command with cmd"},
+		{"[processes.worker]\ncmd = \"run\"\nregion = \"ams\"", "unknown key region in process group worker"},
+		{"[processes.worker]\ncmd = \"run\"\nbuild = { args = {} }", "unknown key args in the build of process group worker"},
+		{"[processes.worker]\ncmd = \"run\"\nimage = \"a\"\n[build.processes.worker]\nimage = \"b\"", "both in [processes] and in [build.processes.worker]"},
+	} {
+		cfg, err := unmarshalTOML([]byte("app = \"foo\"\n" + tc.toml))
+		require.NoError(t, err)
+		assert.ErrorContains(t, cfg.v2UnmarshalError, tc.err)
+	}
+}
+
 func TestLoadTOMLAppConfigInvalidV2(t *testing.T) {
 	const path = "./testdata/always-invalid-v2.toml"
 	cfg, err := LoadConfig(path)
@@ -270,11 +353,31 @@ func TestLoadTOMLAppConfigReferenceFormat(t *testing.T) {
 				"param1": "value1",
 				"param2": "value2",
 			},
+
+			Processes: map[string]*ProcessBuild{
+				"task": {
+					Dockerfile:        "Dockerfile.task",
+					DockerBuildTarget: "task",
+				},
+			},
 		},
 
 		Deploy: &Deploy{
 			ReleaseCommand: "release command",
 			Strategy:       "rolling-eyes",
+			Hooks: &DeployHooks{
+				Pre:           []string{"npm run assets"},
+				Post:          []string{"./bin/purge-cache"},
+				PostInMachine: true,
+			},
+			ReleaseCommandVM: &ReleaseCommandVM{
+				Size:   "performance-2x",
+				Region: "ord",
+				Mounts: &Volume{
+					Source:      "migrations",
+					Destination: "/migrations",
+				},
+			},
 		},
 
 		Env: map[string]string{
@@ -294,6 +397,10 @@ func TestLoadTOMLAppConfigReferenceFormat(t *testing.T) {
 				HardLimit: 10,
 				SoftLimit: 4,
 			},
+			TLSOptions: &api.TLSOptions{
+				ALPN:     []string{"h3", "h2", "http/1.1"},
+				Versions: []string{"TLSv1.2", "TLSv1.3"},
+			},
 		},
 
 		Statics: []Static{
@@ -308,6 +415,50 @@ func TestLoadTOMLAppConfigReferenceFormat(t *testing.T) {
 			Destination: "/data",
 		},
 
+		Artifacts: []Artifact{
+			{
+				Source:      "models/weights.bin",
+				Destination: "/data/models/weights.bin",
+			},
+		},
+
+		Compute: []*Compute{
+			{
+				Processes: []string{"task"},
+				Size:      "shared-cpu-2x",
+				MemoryMB:  1024,
+				Regions:   []string{"ord", "ams"},
+				Count:     2,
+			},
+		},
+
+		Autoscaling: []*Autoscaling{
+			{
+				Processes:   []string{"web"},
+				MinMachines: api.Pointer(0),
+				MaxMachines: 6,
+				ScaleToZero: true,
+				SoftLimit:   20,
+				HardLimit:   25,
+				Regions: map[string]*RegionAutoscaling{
+					"syd": {MinMachines: api.Pointer(1), MaxMachines: 2},
+				},
+			},
+		},
+
+		ScaleSchedule: []*ScaleRule{
+			{ProcessGroup: "web", Window: "mon-fri 8-20", Count: 10},
+			{ProcessGroup: "task", Cron: "0 2 * * *", Count: 0},
+		},
+
+		CLI: map[string]map[string]any{
+			"deploy": {
+				"remote-only":  true,
+				"wait-timeout": float64(600),
+				"no-cache":     true,
+			},
+		},
+
 		Processes: map[string]string{
 			"web":  "run web",
 			"task": "task all day",
@@ -395,3 +546,80 @@ func mustParseDuration(v any) *api.Duration {
 	}
 	return d
 }
+
+func TestLoadConfigWithVars(t *testing.T) {
+	const path = "./testdata/interpolate.toml"
+
+	t.Setenv("APP_NAME", "from-env")
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("PORT", "8080")
+
+	cfg, err := LoadConfigWithVars(path, map[string]string{
+		"APP_NAME":    "from-var",
+		"PUBLIC_PORT": "443",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "from-var", cfg.AppName)
+	assert.Equal(t, "ord", cfg.PrimaryRegion)
+	assert.Equal(t, map[string]string{
+		"DATABASE_URL": "postgres://db.internal/app",
+		"TEMPLATE":     "${NOT_A_VARIABLE}",
+	}, cfg.Env)
+	assert.Equal(t, "data", cfg.Mounts.Source)
+	require.Len(t, cfg.Services, 1)
+	assert.Equal(t, 8080, cfg.Services[0].InternalPort)
+	require.Len(t, cfg.Services[0].Ports, 1)
+	assert.Equal(t, api.IntPointer(443), cfg.Services[0].Ports[0].Port)
+}
+
+func TestLoadConfigWithVars_Missing(t *testing.T) {
+	const path = "./testdata/interpolate.toml"
+
+	t.Setenv("APP_NAME", "from-env")
+	t.Setenv("PORT", "8080")
+
+	_, err := LoadConfigWithVars(path, map[string]string{"PUBLIC_PORT": "443"})
+	assert.ErrorContains(t, err, "env.DATABASE_URL: DB_HOST is referenced but not set")
+}
+
+func TestLoadConfig_LeavesReferences(t *testing.T) {
+	t.Setenv("DB_HOST", "db.internal")
+
+	cfg, err := LoadConfig("./testdata/interpolate.toml")
+	require.NoError(t, err)
+	assert.Equal(t, "${APP_NAME}", cfg.AppName)
+	assert.Equal(t, "postgres://${DB_HOST}/app", cfg.RawDefinition["env"].(map[string]any)["DATABASE_URL"])
+}
+
+func TestLoadConfigWithVars_WriteKeepsReferences(t *testing.T) {
+	t.Setenv("APP_NAME", "from-env")
+	t.Setenv("DB_HOST", "db.internal")
+	t.Setenv("PORT", "8080")
+
+	cfg, err := LoadConfigWithVars("./testdata/interpolate.toml", map[string]string{"PUBLIC_PORT": "443"})
+	require.NoError(t, err)
+	require.NoError(t, cfg.SetMachinesPlatform())
+
+	// values changed since loading keep their new value
+	cfg.PrimaryRegion = "cdg"
+
+	path := filepath.Join(t.TempDir(), "fly.toml")
+	require.NoError(t, cfg.WriteToFile(path))
+
+	buf, err := os.ReadFile(path)
+	require.NoError(t, err)
+	written := string(buf)
+	assert.Contains(t, written, `app = "${APP_NAME}"`)
+	assert.Contains(t, written, `DATABASE_URL = "postgres://${DB_HOST}/app"`)
+	assert.Contains(t, written, `internal_port = "${PORT}"`)
+	assert.Contains(t, written, `port = "${PUBLIC_PORT}"`)
+	assert.Contains(t, written, `TEMPLATE = "$${NOT_A_VARIABLE}"`)
+	assert.Contains(t, written, `primary_region = "cdg"`)
+	assert.NotContains(t, written, "db.internal")
+
+	reloaded, err := LoadConfigWithVars(path, map[string]string{"PUBLIC_PORT": "443"})
+	require.NoError(t, err)
+	assert.Equal(t, "postgres://db.internal/app", reloaded.Env["DATABASE_URL"])
+	assert.Equal(t, 8080, reloaded.Services[0].InternalPort)
+}
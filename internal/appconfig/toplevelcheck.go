@@ -21,6 +21,13 @@ type ToplevelCheck struct {
 	HTTPProtocol      *string           `json:"protocol,omitempty" toml:"protocol,omitempty"`
 	HTTPTLSSkipVerify *bool             `json:"tls_skip_verify,omitempty" toml:"tls_skip_verify,omitempty"`
 	HTTPHeaders       map[string]string `json:"headers,omitempty" toml:"headers,omitempty"`
+	// Command is run inside the machine by exec checks, which pass when it
+	// exits with status 0. They suit workers which don't listen on any port.
+	Command []string `json:"command,omitempty" toml:"command,omitempty"`
+	// GRPCService is the service grpc checks ask the standard gRPC health
+	// checking protocol about; the server's overall health when empty.
+	GRPCService *string `json:"grpc_service,omitempty" toml:"grpc_service,omitempty"`
+	GRPCUseTLS  *bool   `json:"grpc_use_tls,omitempty" toml:"grpc_use_tls,omitempty"`
 }
 
 func topLevelCheckFromMachineCheck(mc api.MachineCheck) *ToplevelCheck {
@@ -44,12 +51,21 @@ func topLevelCheckFromMachineCheck(mc api.MachineCheck) *ToplevelCheck {
 		HTTPProtocol:      mc.HTTPProtocol,
 		HTTPTLSSkipVerify: mc.HTTPSkipTLSVerify,
 		HTTPHeaders:       headers,
+		Command:           mc.Command,
+		GRPCService:       mc.GRPCService,
+		GRPCUseTLS:        mc.GRPCUseTLS,
 	}
 }
 
 func (chk *ToplevelCheck) toMachineCheck() (*api.MachineCheck, error) {
-	if chk.Type == nil || !slices.Contains([]string{"http", "tcp"}, *chk.Type) {
-		return nil, fmt.Errorf("Missing or invalid check type, must be 'http' or 'tcp'")
+	if chk.Type == nil || !slices.Contains([]string{"http", "tcp", "exec", "grpc"}, *chk.Type) {
+		return nil, fmt.Errorf("Missing or invalid check type, must be 'http', 'tcp', 'exec' or 'grpc'")
+	}
+	if *chk.Type == "exec" && len(chk.Command) == 0 {
+		return nil, fmt.Errorf("Missing command for exec check")
+	}
+	if *chk.Type == "grpc" && chk.Port == nil {
+		return nil, fmt.Errorf("Missing port for grpc check")
 	}
 
 	res := &api.MachineCheck{
@@ -61,6 +77,9 @@ func (chk *ToplevelCheck) toMachineCheck() (*api.MachineCheck, error) {
 		HTTPPath:          chk.HTTPPath,
 		HTTPProtocol:      chk.HTTPProtocol,
 		HTTPSkipTLSVerify: chk.HTTPTLSSkipVerify,
+		Command:           chk.Command,
+		GRPCService:       chk.GRPCService,
+		GRPCUseTLS:        chk.GRPCUseTLS,
 		HTTPHeaders: lo.MapToSlice(
 			chk.HTTPHeaders, func(k string, v string) api.MachineHTTPHeader {
 				return api.MachineHTTPHeader{Name: k, Values: []string{v}}
@@ -82,6 +101,10 @@ func (chk *ToplevelCheck) String() string {
 		return fmt.Sprintf("tcp-%d", chk.Port)
 	case "http":
 		return fmt.Sprintf("http-%d-%v", chk.Port, chk.HTTPMethod)
+	case "grpc":
+		return fmt.Sprintf("grpc-%d-%s", chk.Port, lo.FromPtr(chk.GRPCService))
+	case "exec":
+		return fmt.Sprintf("exec-%s", strings.Join(chk.Command, " "))
 	default:
 		return fmt.Sprintf("%s-%d", chkType, chk.Port)
 	}
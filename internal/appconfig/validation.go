@@ -4,11 +4,15 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"path"
 	"strings"
 
 	"github.com/logrusorgru/aurora"
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/cron"
 	"github.com/superfly/flyctl/internal/sentry"
+	"golang.org/x/exp/slices"
 )
 
 func (cfg *Config) Validate(ctx context.Context) (err error, extra_info string) {
@@ -55,16 +59,41 @@ func (cfg *Config) Validate(ctx context.Context) (err error, extra_info string)
 
 func (cfg *Config) ValidateForMachinesPlatform(ctx context.Context) (err error, extra_info string) {
 	extra_info += cfg.validateBuildStrategies()
-	err = cfg.EnsureV2Config()
-	if err == nil {
+	if errs := cfg.machinesValidationErrors(); len(errs) == 0 {
 		extra_info += fmt.Sprintf("%s Configuration is valid\n", aurora.Green("✓"))
 		return nil, extra_info
 	} else {
-		extra_info += fmt.Sprintf("\n   %s%s\n", aurora.Red("✘"), err)
+		extra_info += fmt.Sprintf("\n   %s%s\n", aurora.Red("✘"), errs[0])
 		return errors.New("App configuration is not valid"), extra_info
 	}
 }
 
+// machinesValidationErrors returns what's invalid about cfg for the machines
+// platform, the config not parsing into its v2 form aside from everything
+// else.
+func (cfg *Config) machinesValidationErrors() []error {
+	if err := cfg.EnsureV2Config(); err != nil {
+		return []error{err}
+	}
+
+	var errs []error
+	for _, validate := range []func() error{
+		cfg.validateArtifacts,
+		cfg.validateProcessBuilds,
+		cfg.validateReleaseCommands,
+		cfg.validateProcessDeploys,
+		cfg.validateCompute,
+		cfg.validateAutoscaling,
+		cfg.validateScaleSchedule,
+		cfg.validateServices,
+	} {
+		if err := validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
 func (cfg *Config) ValidateForNomadPlatform(ctx context.Context) (err error, extra_info string) {
 	extra_info += cfg.validateBuildStrategies()
 	appName := NameFromContext(ctx)
@@ -86,6 +115,318 @@ func (cfg *Config) ValidateForNomadPlatform(ctx context.Context) (err error, ext
 	}
 }
 
+// validateArtifacts ensures artifacts are uploaded to the volume mounted by
+// the app, as anything else would be lost on the next machine update.
+func (cfg *Config) validateArtifacts() error {
+	if len(cfg.Artifacts) == 0 {
+		return nil
+	}
+
+	if cfg.Mounts == nil {
+		return errors.New("artifacts require a volume; add a [mounts] section to upload them to")
+	}
+
+	mount := path.Clean(cfg.Mounts.Destination)
+	for _, a := range cfg.Artifacts {
+		if a.Source == "" {
+			return fmt.Errorf("artifact with destination '%s' has no source", a.Destination)
+		}
+		if !strings.HasPrefix(path.Clean(a.Destination), mount+"/") {
+			return fmt.Errorf("artifact destination '%s' must be within the volume mounted at '%s'", a.Destination, cfg.Mounts.Destination)
+		}
+	}
+
+	return nil
+}
+
+func (cfg *Config) validateProcessBuilds() error {
+	groups := cfg.ProcessGroupsWithOwnImage()
+	if len(groups) == 0 {
+		return nil
+	}
+
+	processConfigs, err := cfg.GetProcessConfigs()
+	if err != nil {
+		return err
+	}
+
+	for _, name := range groups {
+		if _, ok := processConfigs[name]; !ok {
+			return fmt.Errorf("[build.processes.%s] refers to a process group which is not defined in [processes]", name)
+		}
+		if override := cfg.Build.Processes[name]; override == nil || *override == (ProcessBuild{}) {
+			return fmt.Errorf("[build.processes.%s] must set an image, a dockerfile or a build-target", name)
+		}
+	}
+
+	return nil
+}
+
+func (cfg *Config) validateReleaseCommands() error {
+	if cfg.Deploy == nil || len(cfg.Deploy.ReleaseCommands) == 0 {
+		return nil
+	}
+
+	if cfg.Deploy.ReleaseCommand != "" {
+		return errors.New("[deploy] can't set both release_command and release_commands; move release_command to release_commands")
+	}
+
+	for i, rc := range cfg.Deploy.ReleaseCommands {
+		if strings.TrimSpace(rc.Command) == "" {
+			return fmt.Errorf("release command #%d has no command", i+1)
+		}
+		if rc.Timeout != nil && rc.Timeout.Duration <= 0 {
+			return fmt.Errorf("release command '%s' must have a positive timeout", rc.Command)
+		}
+	}
+
+	return nil
+}
+
+func (cfg *Config) validateProcessDeploys() error {
+	if cfg.Deploy == nil || len(cfg.Deploy.Processes) == 0 {
+		return nil
+	}
+
+	processConfigs, err := cfg.GetProcessConfigs()
+	if err != nil {
+		return err
+	}
+
+	for name, pd := range cfg.Deploy.Processes {
+		if _, ok := processConfigs[name]; !ok {
+			return fmt.Errorf("[deploy.processes.%s] refers to a process group which is not defined in [processes]", name)
+		}
+		if pd == nil || strings.TrimSpace(pd.DrainCommand) == "" {
+			return fmt.Errorf("[deploy.processes.%s] has no drain_command", name)
+		}
+		if pd.DrainTimeout != nil && pd.DrainTimeout.Duration <= 0 {
+			return fmt.Errorf("[deploy.processes.%s] must have a positive drain_timeout", name)
+		}
+		switch pd.DrainFailure {
+		case "", DrainFailureContinue, DrainFailureAbort:
+		default:
+			return fmt.Errorf("[deploy.processes.%s] drain_failure must be %s or %s, not '%s'", name, DrainFailureContinue, DrainFailureAbort, pd.DrainFailure)
+		}
+	}
+
+	return nil
+}
+
+// validateCompute catches [[vm]] sections of unknown process groups or with
+// invalid sizes, which are resolved along with the process groups.
+func (cfg *Config) validateCompute() error {
+	if len(cfg.Compute) == 0 {
+		return nil
+	}
+
+	_, err := cfg.GetProcessConfigs()
+	return err
+}
+
+// validateAutoscaling catches [[autoscaling]] sections of unknown process
+// groups or with inconsistent bounds, which are resolved along with the
+// process groups.
+func (cfg *Config) validateAutoscaling() error {
+	if len(cfg.Autoscaling) == 0 {
+		return nil
+	}
+
+	_, err := cfg.GetProcessConfigs()
+	return err
+}
+
+// validateScaleSchedule catches [[scale_schedule]] rules of unknown process
+// groups, with invalid times, or applying at the same times as another.
+func (cfg *Config) validateScaleSchedule() error {
+	if len(cfg.ScaleSchedule) == 0 {
+		return nil
+	}
+
+	processConfigs, err := cfg.GetProcessConfigs()
+	if err != nil {
+		return err
+	}
+
+	defaultGroup := cfg.DefaultProcessName()
+	seen := map[string]bool{}
+
+	for i, rule := range cfg.ScaleSchedule {
+		if rule == nil {
+			continue
+		}
+		name := fmt.Sprintf("[[scale_schedule]] #%d", i+1)
+
+		group := rule.ProcessGroup
+		if group == "" {
+			group = defaultGroup
+		} else if _, ok := processConfigs[group]; !ok {
+			return fmt.Errorf("%s refers to '%s' process group which is not defined in [processes]", name, group)
+		}
+
+		switch {
+		case rule.Window != "" && rule.Cron != "":
+			return fmt.Errorf("%s sets both a window and a cron expression, only one of them can be set", name)
+		case rule.Window != "":
+			if _, err := cron.ParseWindow(rule.Window); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		case rule.Cron != "":
+			if _, err := cron.Parse(rule.Cron); err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+		}
+		if rule.Count < 0 {
+			return fmt.Errorf("%s has a negative count", name)
+		}
+
+		key := strings.Join([]string{group, rule.Window, rule.Cron}, "|")
+		if seen[key] {
+			return fmt.Errorf("%s applies to '%s' process group at the same times as another rule", name, group)
+		}
+		seen[key] = true
+	}
+
+	return nil
+}
+
+// knownHandlers are the handlers the proxy applies to connections.
+var knownHandlers = []string{"http", "tls", "proxy_proto", "pg_tls", "edge_http"}
+
+// validateServices catches services the proxy can't route as configured, so
+// that deployments fail before any machine is updated.
+func (cfg *Config) validateServices() error {
+	type exposed struct {
+		service    string
+		start, end int
+	}
+	public := map[string][]exposed{}
+
+	services := make([]Service, 0, len(cfg.Services)+1)
+	names := make([]string, 0, len(cfg.Services)+1)
+	if cfg.HttpService != nil {
+		// leave the concurrency of the config alone, its defaults don't
+		// matter here
+		httpService := *cfg.HttpService
+		httpService.Concurrency = nil
+		ms := httpService.toMachineService()
+		services = append(services, Service{Protocol: ms.Protocol, InternalPort: ms.InternalPort, Ports: ms.Ports})
+		names = append(names, "[http_service]")
+	}
+	for i, svc := range cfg.Services {
+		services = append(services, svc)
+		names = append(names, fmt.Sprintf("[[services]] #%d", i+1))
+	}
+
+	for i, svc := range services {
+		name := names[i]
+		protocol := strings.ToLower(svc.Protocol)
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		if protocol != "tcp" && protocol != "udp" {
+			return fmt.Errorf("%s has protocol '%s', expected tcp or udp", name, svc.Protocol)
+		}
+
+		for _, port := range svc.Ports {
+			start, end, err := portRange(port)
+			if err != nil {
+				return fmt.Errorf("%s: %w", name, err)
+			}
+			label := fmt.Sprint(start)
+			if end != start {
+				label = fmt.Sprintf("%d-%d", start, end)
+			}
+			if err := validateHandlers(protocol, port); err != nil {
+				return fmt.Errorf("%s port %s: %w", name, label, err)
+			}
+			if err := validateTLSOptions(port); err != nil {
+				return fmt.Errorf("%s port %s: %w", name, label, err)
+			}
+
+			for _, other := range public[protocol] {
+				if start <= other.end && other.start <= end {
+					return fmt.Errorf("%s port %s is also exposed by %s; every public %s port can only route to one service", name, label, other.service, protocol)
+				}
+			}
+			public[protocol] = append(public[protocol], exposed{service: name, start: start, end: end})
+		}
+	}
+
+	return nil
+}
+
+func portRange(port api.MachinePort) (start, end int, err error) {
+	switch {
+	case port.Port != nil && (port.StartPort != nil || port.EndPort != nil):
+		return 0, 0, errors.New("ports set either port or start_port and end_port, not both")
+	case port.Port != nil:
+		start, end = *port.Port, *port.Port
+	case port.StartPort != nil && port.EndPort != nil:
+		start, end = *port.StartPort, *port.EndPort
+	default:
+		return 0, 0, errors.New("ports must set port, or start_port and end_port")
+	}
+
+	if start < 1 || end > 65535 || start > end {
+		return 0, 0, fmt.Errorf("invalid port range %d-%d", start, end)
+	}
+
+	return start, end, nil
+}
+
+func validateHandlers(protocol string, port api.MachinePort) error {
+	seen := map[string]bool{}
+	for _, h := range port.Handlers {
+		if !slices.Contains(knownHandlers, h) {
+			return fmt.Errorf("unknown handler '%s', expected one of %s", h, strings.Join(knownHandlers, ", "))
+		}
+		if seen[h] {
+			return fmt.Errorf("handler '%s' is listed twice", h)
+		}
+		seen[h] = true
+	}
+
+	switch {
+	case protocol == "udp" && len(port.Handlers) > 0:
+		return errors.New("udp services forward raw datagrams and can't have handlers")
+	case seen["proxy_proto"] && seen["http"]:
+		return errors.New("the proxy_proto handler forwards raw tcp and can't be combined with the http handler; the http handler already passes the client address in Fly-Client-IP")
+	case seen["pg_tls"] && (seen["tls"] || seen["http"]):
+		return errors.New("the pg_tls handler terminates TLS of postgres connections and can't be combined with the tls or http handlers")
+	case port.ForceHttps && !seen["http"]:
+		return errors.New("force_https redirects HTTP requests and needs the http handler")
+	}
+
+	return nil
+}
+
+// knownTLSVersions are the TLS versions the proxy accepts.
+var knownTLSVersions = []string{"TLSv1.2", "TLSv1.3"}
+
+func validateTLSOptions(port api.MachinePort) error {
+	options := port.TLSOptions
+	if options == nil {
+		return nil
+	}
+
+	if !slices.Contains(port.Handlers, "tls") {
+		return errors.New("tls_options configure the TLS the tls handler terminates and need it")
+	}
+	for _, version := range options.Versions {
+		if !slices.Contains(knownTLSVersions, version) {
+			return fmt.Errorf("unknown TLS version '%s' in tls_options, expected %s", version, strings.Join(knownTLSVersions, " or "))
+		}
+	}
+	for _, protocol := range options.ALPN {
+		if protocol == "" {
+			return errors.New("tls_options list an empty ALPN protocol")
+		}
+	}
+
+	return nil
+}
+
 func (cfg *Config) validateBuildStrategies() (extraInfo string) {
 	buildStrats := cfg.BuildStrategies()
 	if len(buildStrats) > 1 {
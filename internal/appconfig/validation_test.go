@@ -0,0 +1,240 @@
+package appconfig
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestValidateServices(t *testing.T) {
+	port := func(p int, handlers ...string) api.MachinePort {
+		return api.MachinePort{Port: api.IntPointer(p), Handlers: handlers}
+	}
+
+	tests := []struct {
+		name   string
+		cfg    *Config
+		errMsg string
+	}{
+		{
+			name: "http service and tcp service",
+			cfg: &Config{
+				HttpService: &HTTPService{InternalPort: 8080},
+				Services:    []Service{{Protocol: "tcp", InternalPort: 5432, Ports: []api.MachinePort{port(5432, "pg_tls")}}},
+			},
+		},
+		{
+			name:   "unknown handler",
+			cfg:    &Config{Services: []Service{{Protocol: "tcp", Ports: []api.MachinePort{port(443, "https")}}}},
+			errMsg: "unknown handler 'https'",
+		},
+		{
+			name:   "proxy_proto with http",
+			cfg:    &Config{Services: []Service{{Protocol: "tcp", Ports: []api.MachinePort{port(80, "proxy_proto", "http")}}}},
+			errMsg: "can't be combined with the http handler",
+		},
+		{
+			name:   "udp handlers",
+			cfg:    &Config{Services: []Service{{Protocol: "udp", Ports: []api.MachinePort{port(53, "proxy_proto")}}}},
+			errMsg: "udp services forward raw datagrams",
+		},
+		{
+			name:   "force_https without http",
+			cfg:    &Config{Services: []Service{{Protocol: "tcp", Ports: []api.MachinePort{{Port: api.IntPointer(443), Handlers: []string{"tls"}, ForceHttps: true}}}}},
+			errMsg: "force_https redirects HTTP requests",
+		},
+		{
+			name: "tls_options",
+			cfg: &Config{Services: []Service{{Protocol: "tcp", Ports: []api.MachinePort{{
+				Port:       api.IntPointer(443),
+				Handlers:   []string{"tls", "http"},
+				TLSOptions: &api.TLSOptions{ALPN: []string{"h3", "h2"}, Versions: []string{"TLSv1.3"}},
+			}}}}},
+		},
+		{
+			name: "tls_options without tls",
+			cfg: &Config{Services: []Service{{Protocol: "tcp", Ports: []api.MachinePort{{
+				Port:       api.IntPointer(80),
+				Handlers:   []string{"http"},
+				TLSOptions: &api.TLSOptions{Versions: []string{"TLSv1.3"}},
+			}}}}},
+			errMsg: "tls_options configure the TLS the tls handler terminates",
+		},
+		{
+			name: "unknown TLS version",
+			cfg: &Config{HttpService: &HTTPService{
+				InternalPort: 8080,
+				TLSOptions:   &api.TLSOptions{Versions: []string{"TLSv1.0"}},
+			}},
+			errMsg: "[http_service] port 443: unknown TLS version 'TLSv1.0'",
+		},
+		{
+			name: "duplicate port across services",
+			cfg: &Config{
+				HttpService: &HTTPService{InternalPort: 8080},
+				Services:    []Service{{Protocol: "tcp", Ports: []api.MachinePort{port(443, "tls")}}},
+			},
+			errMsg: "[[services]] #1 port 443 is also exposed by [http_service]",
+		},
+		{
+			name: "overlapping range",
+			cfg: &Config{Services: []Service{
+				{Protocol: "tcp", Ports: []api.MachinePort{{StartPort: api.IntPointer(8000), EndPort: api.IntPointer(8100)}}},
+				{Protocol: "tcp", Ports: []api.MachinePort{port(8080)}},
+			}},
+			errMsg: "[[services]] #2 port 8080 is also exposed by [[services]] #1",
+		},
+		{
+			name: "same port over tcp and udp",
+			cfg: &Config{Services: []Service{
+				{Protocol: "tcp", Ports: []api.MachinePort{port(53)}},
+				{Protocol: "udp", Ports: []api.MachinePort{port(53)}},
+			}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := tc.cfg.validateServices()
+			if tc.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateArtifacts(t *testing.T) {
+	data := &Volume{Source: "data", Destination: "/data"}
+
+	tests := []struct {
+		name      string
+		mounts    *Volume
+		artifacts []Artifact
+		errMsg    string
+	}{
+		{
+			name: "no artifacts without mounts",
+		},
+		{
+			name:      "within the volume",
+			mounts:    data,
+			artifacts: []Artifact{{Source: "models/small.bin", Destination: "/data/models/small.bin"}},
+		},
+		{
+			name:      "mount destination with a trailing slash",
+			mounts:    &Volume{Source: "data", Destination: "/data/"},
+			artifacts: []Artifact{{Source: "seed.db", Destination: "/data/seed.db"}},
+		},
+		{
+			name:      "missing mounts",
+			artifacts: []Artifact{{Source: "seed.db", Destination: "/data/seed.db"}},
+			errMsg:    "artifacts require a volume",
+		},
+		{
+			name:      "missing source",
+			mounts:    data,
+			artifacts: []Artifact{{Destination: "/data/seed.db"}},
+			errMsg:    "artifact with destination '/data/seed.db' has no source",
+		},
+		{
+			name:      "outside the volume",
+			mounts:    data,
+			artifacts: []Artifact{{Source: "seed.db", Destination: "/app/seed.db"}},
+			errMsg:    "artifact destination '/app/seed.db' must be within the volume mounted at '/data'",
+		},
+		{
+			name:      "sibling of the volume",
+			mounts:    data,
+			artifacts: []Artifact{{Source: "seed.db", Destination: "/database/seed.db"}},
+			errMsg:    "must be within the volume",
+		},
+		{
+			name:      "escaping the volume",
+			mounts:    data,
+			artifacts: []Artifact{{Source: "seed.db", Destination: "/data/../etc/seed.db"}},
+			errMsg:    "must be within the volume",
+		},
+		{
+			name:      "the mount point itself",
+			mounts:    data,
+			artifacts: []Artifact{{Source: "seed.db", Destination: "/data"}},
+			errMsg:    "must be within the volume",
+		},
+		{
+			name:      "relative destination",
+			mounts:    data,
+			artifacts: []Artifact{{Source: "seed.db", Destination: "data/seed.db"}},
+			errMsg:    "must be within the volume",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{Mounts: tc.mounts, Artifacts: tc.artifacts}
+			err := cfg.validateArtifacts()
+			if tc.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.errMsg)
+			}
+		})
+	}
+}
+
+func TestValidateScaleSchedule(t *testing.T) {
+	tests := []struct {
+		name   string
+		rules  []*ScaleRule
+		errMsg string
+	}{
+		{
+			name: "windows, cron and otherwise",
+			rules: []*ScaleRule{
+				{Window: "mon-fri 8-20", Count: 10},
+				{Cron: "0 2 * * *", Count: 0},
+				{Count: 2},
+			},
+		},
+		{
+			name:   "unknown process group",
+			rules:  []*ScaleRule{{ProcessGroup: "worker", Window: "daily 0-6", Count: 1}},
+			errMsg: "refers to 'worker' process group",
+		},
+		{
+			name:   "window and cron",
+			rules:  []*ScaleRule{{Window: "daily 0-6", Cron: "@daily", Count: 1}},
+			errMsg: "sets both a window and a cron expression",
+		},
+		{
+			name:   "invalid cron",
+			rules:  []*ScaleRule{{Cron: "0 25 * * *", Count: 1}},
+			errMsg: "invalid hour 25",
+		},
+		{
+			name:   "negative count",
+			rules:  []*ScaleRule{{Window: "daily 0-6", Count: -1}},
+			errMsg: "has a negative count",
+		},
+		{
+			name:   "two otherwise rules",
+			rules:  []*ScaleRule{{Count: 1}, {ProcessGroup: "app", Count: 2}},
+			errMsg: "[[scale_schedule]] #2 applies to 'app' process group at the same times as another rule",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := &Config{ScaleSchedule: tc.rules}
+			err := cfg.validateScaleSchedule()
+			if tc.errMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, tc.errMsg)
+			}
+		})
+	}
+}
@@ -33,7 +33,7 @@ func CreateArchive(dockerfile, workingDir, ignoreFile string, compressed bool) (
 		compressed: compressed,
 	}
 
-	excludes, err := readDockerignore(workingDir, ignoreFile)
+	excludes, err := ReadDockerignore(workingDir, ignoreFile)
 	if err != nil {
 		return nil, errors.Wrap(err, "error reading .dockerignore")
 	}
@@ -102,7 +102,9 @@ func archiveDirectory(options archiveOptions) (io.ReadCloser, error) {
 	return r, nil
 }
 
-func readDockerignore(workingDir string, ignoreFile string) ([]string, error) {
+// ReadDockerignore returns the patterns of the files left out of the build
+// context of workingDir, read from ignoreFile or else its .dockerignore.
+func ReadDockerignore(workingDir string, ignoreFile string) ([]string, error) {
 	if ignoreFile == "" {
 		ignoreFile = filepath.Join(workingDir, ".dockerignore")
 	}
@@ -20,6 +20,7 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
 	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/terminal"
 	"golang.org/x/net/context"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
@@ -32,6 +33,11 @@ func buildkitEnabled(docker *dockerclient.Client) (buildkitEnabled bool, err err
 		return false, err
 	}
 
+	if isPodman(context.Background(), docker) {
+		terminal.Debug("podman doesn't support buildkit, using the classic builder")
+		return false, nil
+	}
+
 	buildkitEnabled = ping.BuilderVersion == types.BuilderBuildKit
 	if buildkitEnv := os.Getenv("DOCKER_BUILDKIT"); buildkitEnv != "" {
 		buildkitEnabled, err = strconv.ParseBool(buildkitEnv)
@@ -141,7 +147,10 @@ func newBuildkitAuthProvider() session.Attachable {
 	return &buildkitAuthProvider{}
 }
 
-type buildkitAuthProvider struct{}
+type buildkitAuthProvider struct {
+	// extra are credentials of other registries, keyed by host
+	extra map[string]types.AuthConfig
+}
 
 func (ap *buildkitAuthProvider) Register(server *grpc.Server) {
 	auth.RegisterAuthServer(server, ap)
@@ -149,6 +158,9 @@ func (ap *buildkitAuthProvider) Register(server *grpc.Server) {
 
 func (ap *buildkitAuthProvider) Credentials(ctx context.Context, req *auth.CredentialsRequest) (*auth.CredentialsResponse, error) {
 	auths := authConfigs()
+	for host, a := range ap.extra {
+		auths[host] = a
+	}
 	res := &auth.CredentialsResponse{}
 	if a, ok := auths[req.Host]; ok {
 		res.Username = a.Username
@@ -0,0 +1,312 @@
+package imgsrc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/containerd/console"
+	buildkitClient "github.com/moby/buildkit/client"
+	"github.com/moby/buildkit/session"
+	"github.com/moby/buildkit/session/filesync"
+	"github.com/moby/buildkit/session/secrets/secretsprovider"
+	"github.com/moby/buildkit/util/progress/progressui"
+	"github.com/pkg/errors"
+	fstypes "github.com/tonistiigi/fsutil/types"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/cmdfmt"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// buildkitHostEnv names the environment variable holding the address of the
+// buildkitd to build with when there's no Docker daemon, as buildctl does.
+const buildkitHostEnv = "BUILDKIT_HOST"
+
+// BuildkitAddress returns the address of the buildkitd images are built with
+// when no Docker daemon is available: $BUILDKIT_HOST, or else the socket of a
+// rootless or a system-wide buildkitd, standalone or nerdctl's, if one is
+// listening. It returns an empty string when there's none.
+func BuildkitAddress() string {
+	if addr := os.Getenv(buildkitHostEnv); addr != "" {
+		return addr
+	}
+
+	// nerdctl runs a buildkitd per containerd namespace
+	var sockets []string
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		sockets = append(sockets,
+			filepath.Join(dir, "buildkit", "buildkitd.sock"),
+			filepath.Join(dir, "buildkit-default", "buildkitd.sock"),
+		)
+	}
+	sockets = append(sockets, "/run/buildkit/buildkitd.sock", "/run/buildkit-default/buildkitd.sock")
+
+	for _, sock := range sockets {
+		if helpers.FileExists(sock) {
+			return "unix://" + sock
+		}
+	}
+
+	return ""
+}
+
+// buildkitBuilder builds Dockerfiles with a buildkitd directly, so that hosts
+// without a Docker daemon, such as rootless environments and minimal CI
+// runners, can build locally. Images are pushed straight from buildkitd.
+type buildkitBuilder struct {
+	addr string
+}
+
+func (*buildkitBuilder) Name() string {
+	return "BuildKit"
+}
+
+func (b *buildkitBuilder) Run(ctx context.Context, dockerFactory *dockerClientFactory, streams *iostreams.IOStreams, opts ImageOptions, build *build) (*DeploymentImage, string, error) {
+	build.BuildStart()
+	defer build.BuildFinish()
+
+	var dockerfile string
+	if opts.DockerfilePath != "" {
+		if !helpers.FileExists(opts.DockerfilePath) {
+			return nil, "", fmt.Errorf("Dockerfile '%s' not found", opts.DockerfilePath)
+		}
+		dockerfile = opts.DockerfilePath
+	} else {
+		dockerfile = ResolveDockerfile(opts.WorkingDir)
+	}
+	if dockerfile == "" {
+		terminal.Debug("dockerfile not found, skipping")
+		return nil, "", nil
+	}
+
+	build.BuilderInitStart()
+	build.SetBuilderMetaPart1(false, "", "")
+	c, err := buildkitClient.New(ctx, b.addr)
+	if err != nil {
+		build.BuilderInitFinish()
+		return nil, "", errors.Wrapf(err, "error connecting to buildkitd at %s", b.addr)
+	}
+	defer c.Close() //skipcq: GO-S2307
+	build.BuilderInitFinish()
+
+	build.ContextBuildStart()
+	excludes, err := ReadDockerignore(opts.WorkingDir, opts.IgnorefilePath)
+	if err != nil {
+		build.ContextBuildFinish()
+		return nil, "", errors.Wrap(err, "error reading .dockerignore")
+	}
+
+	dockerfileDir := filepath.Dir(dockerfile)
+	dockerfileName := filepath.Base(dockerfile)
+	if opts.CacheNamespace != "" {
+		data, err := os.ReadFile(dockerfile)
+		if err != nil {
+			build.ContextBuildFinish()
+			return nil, "", errors.Wrap(err, "error reading Dockerfile")
+		}
+		if dockerfileDir, err = os.MkdirTemp("", "flyctl-dockerfile"); err != nil {
+			build.ContextBuildFinish()
+			return nil, "", err
+		}
+		defer os.RemoveAll(dockerfileDir)
+		dockerfileName = namespacedDockerfileName
+		if err := os.WriteFile(filepath.Join(dockerfileDir, dockerfileName), namespaceCacheMounts(data, opts.CacheNamespace), 0o600); err != nil {
+			build.ContextBuildFinish()
+			return nil, "", err
+		}
+	}
+	build.ContextBuildFinish()
+
+	solveOpt := newBuildkitSolveOpt(opts, dockerfileDir, dockerfileName, excludes)
+
+	build.ImageBuildStart()
+	tb := render.NewTextBlock(ctx, "Building image with BuildKit")
+	tb.Done(fmt.Sprintf("buildkitd: %s", b.addr))
+
+	res, err := solveWithProgress(ctx, streams, c, solveOpt)
+	build.ImageBuildFinish()
+	if err != nil {
+		return nil, "", errors.Wrap(err, "error building")
+	}
+	cmdfmt.PrintDone(streams.ErrOut, "Building image done")
+
+	digest := res.ExporterResponse["containerimage.digest"]
+	if opts.Publish {
+		// buildkitd pushed the image as it exported it
+		build.PushStart()
+		build.PushFinish()
+	}
+
+	if opts.OutputPath != "" {
+		// a solve only has one export, so the archive is exported by
+		// another one, served from the cache of the first
+		tb := render.NewTextBlock(ctx, fmt.Sprintf("Writing image to %s", opts.OutputPath))
+		pr, pw := io.Pipe()
+		archiveOpt := solveOpt
+		archiveOpt.CacheExports = nil
+		archiveOpt.Exports = []buildkitClient.ExportEntry{{
+			Type:   buildkitClient.ExporterDocker,
+			Attrs:  map[string]string{"name": opts.Tag},
+			Output: func(map[string]string) (io.WriteCloser, error) { return pw, nil },
+		}}
+
+		eg, egCtx := errgroup.WithContext(ctx)
+		eg.Go(func() error {
+			err := writeArchive(opts.OutputPath, pr)
+			pr.CloseWithError(err)
+			return err
+		})
+		eg.Go(func() error {
+			_, err := c.Solve(egCtx, nil, archiveOpt, nil)
+			pw.CloseWithError(err)
+			return err
+		})
+		if err := eg.Wait(); err != nil {
+			return nil, "", errors.Wrap(err, "error exporting image")
+		}
+		tb.Done(fmt.Sprintf("Writing image to %s done", opts.OutputPath))
+	}
+
+	if err := generateSBOM(ctx, opts, nil); err != nil {
+		return nil, "", err
+	}
+
+	return &DeploymentImage{
+		ID:  digest,
+		Tag: opts.Tag,
+	}, "", nil
+}
+
+// newBuildkitSolveOpt translates opts to a solve of the dockerfile frontend.
+// Without a Docker daemon to load the image into, it's only exported when it's
+// pushed somewhere.
+func newBuildkitSolveOpt(opts ImageOptions, dockerfileDir, dockerfileName string, excludes []string) buildkitClient.SolveOpt {
+	attrs := map[string]string{
+		"filename": dockerfileName,
+		"platform": opts.buildPlatform(),
+	}
+	if opts.Target != "" {
+		attrs["target"] = opts.Target
+	}
+	if opts.NoCache {
+		attrs["no-cache"] = ""
+	}
+	if network := opts.buildNetwork(); network != "" {
+		attrs["force-network-mode"] = network
+	}
+	for k, v := range opts.BuildArgs {
+		attrs["build-arg:"+k] = v
+	}
+
+	secrets := make(map[string][]byte, len(opts.BuildSecrets))
+	for k, v := range opts.BuildSecrets {
+		secrets[k] = []byte(v)
+	}
+
+	// build contexts are sent by uid 0, as docker does
+	resetOwner := func(_ string, st *fstypes.Stat) bool {
+		st.Uid, st.Gid = 0, 0
+		return true
+	}
+
+	solveOpt := buildkitClient.SolveOpt{
+		Frontend:      "dockerfile.v0",
+		FrontendAttrs: attrs,
+		Session: []session.Attachable{
+			filesync.NewFSSyncProvider([]filesync.SyncedDir{
+				{Name: "context", Dir: opts.WorkingDir, Excludes: excludes, Map: resetOwner},
+				{Name: "dockerfile", Dir: dockerfileDir, Map: resetOwner},
+			}),
+			&buildkitAuthProvider{extra: pushAuthConfigs(opts)},
+			secretsprovider.FromMap(secrets),
+		},
+	}
+
+	// buildkitd pushes every name, so the image is archived in the
+	// registries of opts.PushTo as it's pushed to Fly
+	names := opts.PushTo
+	if opts.Publish {
+		names = append([]string{opts.Tag}, names...)
+	}
+	if len(names) > 0 {
+		attrs := map[string]string{
+			"name": strings.Join(names, ","),
+			"push": "true",
+		}
+		if opts.SeekableLayers {
+			// layers of base images are recompressed too, and eStargz
+			// layers need OCI media types
+			attrs["compression"] = "estargz"
+			attrs["force-compression"] = "true"
+			attrs["oci-mediatypes"] = "true"
+		}
+		solveOpt.Exports = []buildkitClient.ExportEntry{{
+			Type:  buildkitClient.ExporterImage,
+			Attrs: attrs,
+		}}
+	}
+
+	if opts.CacheRef != "" {
+		solveOpt.CacheImports = []buildkitClient.CacheOptionsEntry{{
+			Type:  "registry",
+			Attrs: map[string]string{"ref": opts.CacheRef},
+		}}
+		if opts.Publish {
+			solveOpt.CacheExports = []buildkitClient.CacheOptionsEntry{{
+				Type:  "registry",
+				Attrs: map[string]string{"ref": opts.CacheRef, "mode": "max"},
+			}}
+		}
+	}
+
+	return solveOpt
+}
+
+func solveWithProgress(ctx context.Context, streams *iostreams.IOStreams, c *buildkitClient.Client, solveOpt buildkitClient.SolveOpt) (*buildkitClient.SolveResponse, error) {
+	var (
+		res    *buildkitClient.SolveResponse
+		status = make(chan *buildkitClient.SolveStatus)
+	)
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.Go(func() (err error) {
+		res, err = c.Solve(egCtx, nil, solveOpt, status)
+		return
+	})
+	eg.Go(func() error {
+		var cons console.Console
+		if streams.ColorEnabled() {
+			if c, err := console.ConsoleFromFile(os.Stderr); err == nil {
+				cons = c
+			}
+		}
+		// the status channel is closed by Solve, so the display outlives
+		// ctx to report why the solve failed
+		return progressui.DisplaySolveStatus(context.TODO(), "", cons, streams.ErrOut, status)
+	})
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// describeBuildkitAddress names where BuildkitAddress looks, for errors about
+// there being nothing to build with.
+func describeBuildkitAddress() string {
+	return strings.Join([]string{
+		"$" + buildkitHostEnv,
+		"$XDG_RUNTIME_DIR/buildkit/buildkitd.sock",
+		"$XDG_RUNTIME_DIR/buildkit-default/buildkitd.sock",
+		"/run/buildkit/buildkitd.sock",
+		"/run/buildkit-default/buildkitd.sock",
+	}, ", ")
+}
@@ -0,0 +1,62 @@
+package imgsrc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	buildkitClient "github.com/moby/buildkit/client"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildkitAddress(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("XDG_RUNTIME_DIR", dir)
+
+	t.Setenv(buildkitHostEnv, "tcp://buildkitd:1234")
+	assert.Equal(t, "tcp://buildkitd:1234", BuildkitAddress())
+
+	t.Setenv(buildkitHostEnv, "")
+	sock := filepath.Join(dir, "buildkit", "buildkitd.sock")
+	require.NoError(t, os.MkdirAll(filepath.Dir(sock), 0o700))
+	require.NoError(t, os.WriteFile(sock, nil, 0o600))
+	assert.Equal(t, "unix://"+sock, BuildkitAddress())
+}
+
+func TestNewBuildkitSolveOpt(t *testing.T) {
+	opts := ImageOptions{
+		WorkingDir: "/src",
+		Tag:        "registry.fly.io/app:deployment-1",
+		Target:     "release",
+		BuildArgs:  map[string]string{"VERSION": "1.2"},
+		CacheRef:   "registry.fly.io/app:cache",
+	}
+
+	solveOpt := newBuildkitSolveOpt(opts, "/src", "Dockerfile", nil)
+	assert.Equal(t, "dockerfile.v0", solveOpt.Frontend)
+	assert.Equal(t, "release", solveOpt.FrontendAttrs["target"])
+	assert.Equal(t, DefaultBuildPlatform, solveOpt.FrontendAttrs["platform"])
+	assert.Equal(t, "1.2", solveOpt.FrontendAttrs["build-arg:VERSION"])
+	assert.NotContains(t, solveOpt.FrontendAttrs, "force-network-mode")
+	assert.Empty(t, solveOpt.Exports)
+	assert.Empty(t, solveOpt.CacheExports)
+	require.Len(t, solveOpt.CacheImports, 1)
+
+	opts.Publish = true
+	opts.Network = BuildNetworkNone
+	solveOpt = newBuildkitSolveOpt(opts, "/src", "Dockerfile", nil)
+	require.Len(t, solveOpt.Exports, 1)
+	assert.Equal(t, buildkitClient.ExporterImage, solveOpt.Exports[0].Type)
+	assert.Equal(t, "none", solveOpt.FrontendAttrs["force-network-mode"])
+	assert.Equal(t, map[string]string{"name": opts.Tag, "push": "true"}, solveOpt.Exports[0].Attrs)
+
+	opts.SeekableLayers = true
+	solveOpt = newBuildkitSolveOpt(opts, "/src", "Dockerfile", nil)
+	require.Len(t, solveOpt.Exports, 1)
+	assert.Equal(t, "estargz", solveOpt.Exports[0].Attrs["compression"])
+	assert.Equal(t, "true", solveOpt.Exports[0].Attrs["force-compression"])
+	assert.Equal(t, "true", solveOpt.Exports[0].Attrs["oci-mediatypes"])
+	require.Len(t, solveOpt.CacheExports, 1)
+	assert.Equal(t, "registry.fly.io/app:cache", solveOpt.CacheExports[0].Attrs["ref"])
+}
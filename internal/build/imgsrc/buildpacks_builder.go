@@ -65,12 +65,16 @@ func (*buildpacksBuilder) Run(ctx context.Context, dockerFactory *dockerClientFa
 		build.SetBuilderMetaPart2(false, serverInfo.ServerVersion, fmt.Sprintf("%s/%s/%s", serverInfo.OSType, serverInfo.Architecture, serverInfo.OSVersion))
 	}
 
+	if opts.buildPlatform() != DefaultBuildPlatform {
+		terminal.Warnf("Buildpacks only build %s images, ignoring the build platform %s\n", DefaultBuildPlatform, opts.Platform)
+	}
+
 	cmdfmt.PrintBegin(streams.ErrOut, "Building image with Buildpacks")
 	msg := fmt.Sprintf("docker host: %s %s %s", serverInfo.ServerVersion, serverInfo.OSType, serverInfo.Architecture)
 	cmdfmt.PrintDone(streams.ErrOut, msg)
 
 	build.ContextBuildStart()
-	excludes, err := readDockerignore(opts.WorkingDir, opts.IgnorefilePath)
+	excludes, err := ReadDockerignore(opts.WorkingDir, opts.IgnorefilePath)
 	if err != nil {
 		build.ContextBuildFinish()
 		build.BuildFinish()
@@ -87,6 +91,9 @@ func (*buildpacksBuilder) Run(ctx context.Context, dockerFactory *dockerClientFa
 		Env:            normalizeBuildArgs(opts.BuildArgs),
 		TrustBuilder:   true,
 		AdditionalTags: []string{opts.Tag},
+		ContainerConfig: pack.ContainerConfig{
+			Network: opts.buildNetwork(),
+		},
 		ProjectDescriptor: projectTypes.Descriptor{
 			Build: projectTypes.Build{
 				Exclude: excludes,
@@ -115,6 +122,14 @@ func (*buildpacksBuilder) Run(ctx context.Context, dockerFactory *dockerClientFa
 		cmdfmt.PrintDone(streams.ErrOut, "Pushing image done")
 	}
 
+	if err := exportImage(ctx, docker, streams, opts); err != nil {
+		return nil, "", err
+	}
+
+	if err := generateSBOM(ctx, opts, localDocker(dockerFactory, docker)); err != nil {
+		return nil, "", err
+	}
+
 	img, err := findImageWithDocker(ctx, docker, opts.Tag)
 	if err != nil {
 		return nil, "", err
@@ -63,7 +63,7 @@ func (*builtinBuilder) Run(ctx context.Context, dockerFactory *dockerClientFacto
 		compressed: dockerFactory.IsRemote(),
 	}
 
-	excludes, err := readDockerignore(opts.WorkingDir, opts.IgnorefilePath)
+	excludes, err := ReadDockerignore(opts.WorkingDir, opts.IgnorefilePath)
 	if err != nil {
 		build.BuildFinish()
 		return nil, "", errors.Wrap(err, "error reading .dockerignore")
@@ -129,6 +129,14 @@ func (*builtinBuilder) Run(ctx context.Context, dockerFactory *dockerClientFacto
 		cmdfmt.PrintDone(streams.ErrOut, "Pushing image done")
 	}
 
+	if err := exportImage(ctx, docker, streams, opts); err != nil {
+		return nil, "", err
+	}
+
+	if err := generateSBOM(ctx, opts, localDocker(dockerFactory, docker)); err != nil {
+		return nil, "", err
+	}
+
 	img, _, err := docker.ImageInspectWithRaw(ctx, imageID)
 	if err != nil {
 		return nil, "", errors.Wrap(err, "count not find built image")
@@ -0,0 +1,50 @@
+package imgsrc
+
+import (
+	"regexp"
+	"strings"
+)
+
+var mountFlagPattern = regexp.MustCompile(`--mount=(\S+)`)
+
+// namespaceCacheMounts prefixes the id of every cache mount
+// (RUN --mount=type=cache) of dockerfile with namespace. BuildKit shares cache
+// mounts with the same id between every build running on a builder, so
+// namespacing them lets builds of the same project reuse their compiler caches
+// while keeping them apart from the caches of other projects built on the same
+// remote builder. Cache mounts without an id are identified by their target.
+func namespaceCacheMounts(dockerfile []byte, namespace string) []byte {
+	return mountFlagPattern.ReplaceAllFunc(dockerfile, func(flag []byte) []byte {
+		options := strings.Split(string(flag[len("--mount="):]), ",")
+
+		var (
+			isCache bool
+			idIndex = -1
+			target  string
+		)
+		for i, option := range options {
+			key, value, _ := strings.Cut(option, "=")
+			switch key {
+			case "type":
+				isCache = value == "cache"
+			case "id":
+				idIndex = i
+			case "target", "dst", "destination":
+				target = value
+			}
+		}
+
+		if !isCache {
+			return flag
+		}
+
+		if idIndex >= 0 {
+			_, id, _ := strings.Cut(options[idIndex], "=")
+			options[idIndex] = "id=" + namespace + "/" + id
+		} else if target != "" {
+			options = append(options, "id="+namespace+"/"+strings.TrimPrefix(target, "/"))
+		}
+
+		return []byte("--mount=" + strings.Join(options, ","))
+	})
+}
@@ -0,0 +1,23 @@
+package imgsrc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNamespaceCacheMounts(t *testing.T) {
+	dockerfile := `FROM golang:1.20
+RUN --mount=type=cache,target=/root/.cache/go-build \
+    --mount=type=cache,id=gomod,target=/go/pkg/mod \
+    --mount=type=secret,id=token \
+    go build ./...
+`
+
+	assert.Equal(t, `FROM golang:1.20
+RUN --mount=type=cache,target=/root/.cache/go-build,id=my-app/root/.cache/go-build \
+    --mount=type=cache,id=my-app/gomod,target=/go/pkg/mod \
+    --mount=type=secret,id=token \
+    go build ./...
+`, string(namespaceCacheMounts([]byte(dockerfile), "my-app")))
+}
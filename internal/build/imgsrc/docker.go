@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/azazeal/pause"
@@ -153,13 +154,32 @@ func (t DockerDaemonType) PrefersLocal() bool {
 	return (t & DockerDaemonTypePrefersLocal) != 0
 }
 
+// NewLocalDockerClient connects to the local daemon of $DOCKER_HOST or, when
+// it isn't set, to the first of Docker and the Docker compatible daemons, such
+// as Podman's, which listens on its usual socket.
 func NewLocalDockerClient() (*dockerclient.Client, error) {
-	c, err := dockerclient.NewClientWithOpts(dockerclient.WithAPIVersionNegotiation())
-	if err != nil {
-		return nil, err
+	c, err := newLocalDockerClient(dockerclient.FromEnv)
+	if err == nil || os.Getenv("DOCKER_HOST") != "" {
+		return c, err
+	}
+
+	for _, sock := range localDockerSockets() {
+		if !helpers.FileExists(sock) {
+			continue
+		}
+		if alt, altErr := newLocalDockerClient(dockerclient.WithHost("unix://" + sock)); altErr == nil {
+			terminal.Debugf("using the docker compatible daemon at %s\n", sock)
+			return alt, nil
+		}
 	}
 
-	if err := dockerclient.FromEnv(c); err != nil {
+	// the error of the default daemon tells best why none is available
+	return nil, err
+}
+
+func newLocalDockerClient(opt dockerclient.Opt) (*dockerclient.Client, error) {
+	c, err := dockerclient.NewClientWithOpts(dockerclient.WithAPIVersionNegotiation(), opt)
+	if err != nil {
 		return nil, err
 	}
 
@@ -170,6 +190,36 @@ func NewLocalDockerClient() (*dockerclient.Client, error) {
 	return c, nil
 }
 
+// localDockerSockets are the sockets Docker compatible daemons usually listen
+// on besides Docker's default one, in order of preference.
+func localDockerSockets() (sockets []string) {
+	if home, err := os.UserHomeDir(); err == nil {
+		// Docker Desktop without its /var/run/docker.sock symlink
+		sockets = append(sockets, filepath.Join(home, ".docker", "run", "docker.sock"))
+	}
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		sockets = append(sockets, filepath.Join(dir, "docker.sock"), filepath.Join(dir, "podman", "podman.sock"))
+	}
+	sockets = append(sockets, "/run/podman/podman.sock")
+
+	return
+}
+
+// isPodman reports whether the daemon is Podman's Docker compatible API,
+// which lacks BuildKit sessions.
+func isPodman(ctx context.Context, docker *dockerclient.Client) bool {
+	version, err := docker.ServerVersion(ctx)
+	if err != nil {
+		return false
+	}
+	for _, component := range version.Components {
+		if strings.Contains(strings.ToLower(component.Name), "podman") {
+			return true
+		}
+	}
+	return false
+}
+
 func newRemoteDockerClient(ctx context.Context, apiClient *api.Client, appName string, streams *iostreams.IOStreams, build *build) (*dockerclient.Client, error) {
 	startedAt := time.Now()
 
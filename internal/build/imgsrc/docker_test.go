@@ -29,3 +29,15 @@ func TestAllowedDockerDaemonMode(t *testing.T) {
 		assert.Equal(t, test.expected, m)
 	}
 }
+
+func TestLocalDockerSockets(t *testing.T) {
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+
+	assert.Equal(t, []string{
+		"/home/me/.docker/run/docker.sock",
+		"/run/user/1000/docker.sock",
+		"/run/user/1000/podman/podman.sock",
+		"/run/podman/podman.sock",
+	}, localDockerSockets())
+}
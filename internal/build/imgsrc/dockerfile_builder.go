@@ -26,6 +26,7 @@ import (
 	"github.com/superfly/flyctl/helpers"
 	"github.com/superfly/flyctl/internal/cmdfmt"
 	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/transport"
 	"github.com/superfly/flyctl/iostreams"
 	"github.com/superfly/flyctl/terminal"
 	"golang.org/x/sync/errgroup"
@@ -33,6 +34,10 @@ import (
 
 type dockerfileBuilder struct{}
 
+// namespacedDockerfileName is the name the Dockerfile is added to the build
+// context under once its cache mounts are namespaced.
+const namespacedDockerfileName = "Dockerfile.flyctl-cache"
+
 func (*dockerfileBuilder) Name() string {
 	return "Dockerfile"
 }
@@ -90,6 +95,9 @@ func (*dockerfileBuilder) Run(ctx context.Context, dockerFactory *dockerClientFa
 
 	build.BuilderInitFinish()
 	defer clearDeploymentTags(ctx, docker, opts.Tag)
+	if opts.CacheRef != "" {
+		defer clearDeploymentTags(ctx, docker, opts.CacheRef)
+	}
 
 	build.ContextBuildStart()
 	tb := render.NewTextBlock(ctx, "Creating build context")
@@ -99,7 +107,7 @@ func (*dockerfileBuilder) Run(ctx context.Context, dockerFactory *dockerClientFa
 		compressed: dockerFactory.IsRemote(),
 	}
 
-	excludes, err := readDockerignore(opts.WorkingDir, opts.IgnorefilePath)
+	excludes, err := ReadDockerignore(opts.WorkingDir, opts.IgnorefilePath)
 	if err != nil {
 		build.BuildFinish()
 		build.ContextBuildFinish()
@@ -109,16 +117,23 @@ func (*dockerfileBuilder) Run(ctx context.Context, dockerFactory *dockerClientFa
 
 	var relativedockerfilePath string
 
-	// copy dockerfile into the archive if it's outside the context dir
-	if !isPathInRoot(dockerfile, opts.WorkingDir) {
+	// copy dockerfile into the archive if it's outside the context dir, or
+	// when it has to be rewritten to namespace its cache mounts
+	if !isPathInRoot(dockerfile, opts.WorkingDir) || opts.CacheNamespace != "" {
 		dockerfileData, err := os.ReadFile(dockerfile)
 		if err != nil {
 			build.BuildFinish()
 			build.ContextBuildFinish()
 			return nil, "", errors.Wrap(err, "error reading Dockerfile")
 		}
+		name := "Dockerfile"
+		if opts.CacheNamespace != "" {
+			dockerfileData = namespaceCacheMounts(dockerfileData, opts.CacheNamespace)
+			name = namespacedDockerfileName
+			relativedockerfilePath = name
+		}
 		archiveOpts.additions = map[string][]byte{
-			"Dockerfile": dockerfileData,
+			name: dockerfileData,
 		}
 	} else {
 		// pass the relative path to Dockerfile within the context
@@ -178,6 +193,16 @@ func (*dockerfileBuilder) Run(ctx context.Context, dockerFactory *dockerClientFa
 		build.BuildFinish()
 		return nil, "", fmt.Errorf("error parsing build args: %w", err)
 	}
+	if dockerFactory.IsLocal() {
+		// builds on this host go through the proxies flyctl does, unless told
+		// otherwise
+		for key, value := range transport.ProxyBuildArgs() {
+			if _, ok := buildArgs[key]; !ok {
+				value := value
+				buildArgs[key] = &value
+			}
+		}
+	}
 
 	buildkitEnabled, err := buildkitEnabled(docker)
 	terminal.Debugf("buildkitEnabled", buildkitEnabled)
@@ -187,6 +212,17 @@ func (*dockerfileBuilder) Run(ctx context.Context, dockerFactory *dockerClientFa
 		return nil, "", errors.Wrap(err, "error checking for buildkit support")
 	}
 	build.SetBuilderMetaPart2(buildkitEnabled, serverInfo.ServerVersion, fmt.Sprintf("%s/%s/%s", serverInfo.OSType, serverInfo.Architecture, serverInfo.OSVersion))
+	if opts.CacheRef != "" {
+		if buildkitEnabled {
+			// embed the cache metadata in the image, so that it can serve as
+			// the cache of the next build once pushed to opts.CacheRef
+			inlineCache := "1"
+			buildArgs["BUILDKIT_INLINE_CACHE"] = &inlineCache
+		} else {
+			terminal.Warnf("Ignoring the build cache ref %s, which needs a BuildKit builder\n", opts.CacheRef)
+			opts.CacheRef = ""
+		}
+	}
 	if buildkitEnabled {
 		imageID, err = runBuildKitBuild(ctx, streams, docker, r, opts, relativedockerfilePath, buildArgs)
 		if err != nil {
@@ -217,6 +253,18 @@ func (*dockerfileBuilder) Run(ctx context.Context, dockerFactory *dockerClientFa
 		build.PushFinish()
 
 		tb.Done("Pushing image done")
+
+		if opts.CacheRef != "" {
+			exportBuildCache(ctx, docker, streams, imageID, opts.CacheRef)
+		}
+	}
+
+	if err := exportImage(ctx, docker, streams, opts); err != nil {
+		return nil, "", err
+	}
+
+	if err := generateSBOM(ctx, opts, localDocker(dockerFactory, docker)); err != nil {
+		return nil, "", err
 	}
 
 	img, _, err := docker.ImageInspectWithRaw(ctx, imageID)
@@ -247,10 +295,11 @@ func runClassicBuild(ctx context.Context, streams *iostreams.IOStreams, docker *
 		Tags:        []string{opts.Tag},
 		BuildArgs:   buildArgs,
 		AuthConfigs: authConfigs(),
-		Platform:    "linux/amd64",
+		Platform:    opts.buildPlatform(),
 		Dockerfile:  dockerfilePath,
 		Target:      opts.Target,
 		NoCache:     opts.NoCache,
+		NetworkMode: opts.buildNetwork(),
 	}
 
 	resp, err := docker.ImageBuild(ctx, r, options)
@@ -331,10 +380,14 @@ func runBuildKitBuild(ctx context.Context, streams *iostreams.IOStreams, docker
 			SessionID:     s.ID(),
 			RemoteContext: uploadRequestRemote,
 			BuildID:       buildID,
-			Platform:      "linux/amd64",
+			Platform:      opts.buildPlatform(),
 			Dockerfile:    dockerfilePath,
 			Target:        opts.Target,
 			NoCache:       opts.NoCache,
+			NetworkMode:   opts.buildNetwork(),
+		}
+		if opts.CacheRef != "" {
+			buildOpts.CacheFrom = []string{opts.CacheRef}
 		}
 
 		return func() error {
@@ -412,9 +465,70 @@ func runBuildKitBuild(ctx context.Context, streams *iostreams.IOStreams, docker
 	return imageID, nil
 }
 
+// exportBuildCache pushes the image just built to cacheRef, for the next build
+// to import its cache from. Failing to do so only makes that build slower, so
+// it's merely reported.
+func exportBuildCache(ctx context.Context, docker *dockerclient.Client, streams *iostreams.IOStreams, imageID, cacheRef string) {
+	tb := render.NewTextBlock(ctx, fmt.Sprintf("Exporting build cache to %s", cacheRef))
+
+	if err := docker.ImageTag(ctx, imageID, cacheRef); err != nil {
+		terminal.Warnf("Failed exporting the build cache: %v\n", err)
+		return
+	}
+	if err := pushToFly(ctx, docker, streams, cacheRef); err != nil {
+		terminal.Warnf("Failed exporting the build cache: %v\n", err)
+		return
+	}
+
+	tb.Done("Exporting build cache done")
+}
+
 func pushToFly(ctx context.Context, docker *dockerclient.Client, streams *iostreams.IOStreams, tag string) error {
+	return pushImage(ctx, docker, streams, tag, flyRegistryAuth())
+}
+
+const (
+	// pushAttempts is how many times an image push is attempted before giving
+	// up, as pushes over flaky networks or from laptops going to sleep are
+	// often interrupted.
+	pushAttempts = 4
+	// pushRetryDelay is how long to wait before the first retry of a push,
+	// doubled for each retry after it.
+	pushRetryDelay = 2 * time.Second
+)
+
+// pushImage pushes tag to its registry, retrying when the push is
+// interrupted. The registry keeps the layers already pushed, which the daemon
+// skips on retries, so a retry resumes where the push left off.
+func pushImage(ctx context.Context, docker *dockerclient.Client, streams *iostreams.IOStreams, tag, registryAuth string) error {
+	delay := pushRetryDelay
+	for attempt := 1; ; attempt++ {
+		err := pushImageOnce(ctx, docker, streams, tag, registryAuth)
+		if err == nil || attempt == pushAttempts || !retryablePushError(ctx, err) {
+			return err
+		}
+
+		fmt.Fprintf(streams.ErrOut, "Push of %s interrupted (%v), resuming in %s; layers already pushed won't be pushed again\n", tag, err, delay)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// retryablePushError tells whether a push which failed with err may succeed
+// when retried.
+func retryablePushError(ctx context.Context, err error) bool {
+	var unauthorized *RegistryUnauthorizedError
+	return ctx.Err() == nil && !errors.As(err, &unauthorized)
+}
+
+func pushImageOnce(ctx context.Context, docker *dockerclient.Client, streams *iostreams.IOStreams, tag, registryAuth string) error {
 	pushResp, err := docker.ImagePush(ctx, tag, types.ImagePushOptions{
-		RegistryAuth: flyRegistryAuth(),
+		RegistryAuth: registryAuth,
 	})
 	if err != nil {
 		return errors.Wrap(err, "error pushing image to registry")
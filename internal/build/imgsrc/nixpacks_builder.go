@@ -27,8 +27,8 @@ func (*nixpacksBuilder) Name() string {
 }
 
 func ensureNixpacksBinary(ctx context.Context, streams *iostreams.IOStreams) error {
-	confDir := flyctl.ConfigDir()
-	binDir := path.Join(confDir, "bin")
+	cacheDir := flyctl.CacheDir()
+	binDir := path.Join(cacheDir, "bin")
 
 	_, err := os.Stat(filepath.Join(binDir, "nixpacks"))
 	if err == nil {
@@ -104,6 +104,11 @@ func (*nixpacksBuilder) Run(ctx context.Context, dockerFactory *dockerClientFact
 		return nil, note, nil
 	}
 
+	if opts.buildNetwork() != "" {
+		build.BuildFinish()
+		return nil, "", fmt.Errorf("nixpacks builds can't run with build network %s", opts.Network)
+	}
+
 	if err := ensureNixpacksBinary(ctx, streams); err != nil {
 		build.BuildFinish()
 		return nil, "", errors.Wrap(err, "could not install nixpacks")
@@ -193,10 +198,10 @@ func (*nixpacksBuilder) Run(ctx context.Context, dockerFactory *dockerClientFact
 	build.BuilderInitFinish()
 
 	build.ImageBuildStart()
-	confDir := flyctl.ConfigDir()
-	nixpacksPath := filepath.Join(confDir, "bin", "nixpacks")
+	cacheDir := flyctl.CacheDir()
+	nixpacksPath := filepath.Join(cacheDir, "bin", "nixpacks")
 
-	nixpacksArgs := []string{"build", "--name", opts.Tag, opts.WorkingDir}
+	nixpacksArgs := []string{"build", "--name", opts.Tag, "--platform", opts.buildPlatform(), opts.WorkingDir}
 	for _, kv := range os.Environ() {
 		if strings.HasPrefix(kv, "NIXPACKS_") {
 			nixpacksArgs = append(nixpacksArgs, "--env", kv)
@@ -226,6 +231,14 @@ func (*nixpacksBuilder) Run(ctx context.Context, dockerFactory *dockerClientFact
 	}
 	build.PushFinish()
 
+	if err := exportImage(ctx, docker, streams, opts); err != nil {
+		return nil, "", err
+	}
+
+	if err := generateSBOM(ctx, opts, localDocker(dockerFactory, docker)); err != nil {
+		return nil, "", err
+	}
+
 	img, err := findImageWithDocker(ctx, docker, opts.Tag)
 	if err != nil {
 		return nil, "", err
@@ -0,0 +1,157 @@
+package imgsrc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/docker/distribution/reference"
+	"github.com/docker/docker/api/types"
+	dockerclient "github.com/docker/docker/client"
+
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// RegistryCredentials authenticate pushes to registries other than Fly's.
+type RegistryCredentials struct {
+	Username string
+	Password string
+}
+
+// ValidatePushRef checks ref names an image tag images can be pushed to.
+func ValidatePushRef(ref string) error {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return fmt.Errorf("invalid image reference %s: %w", ref, err)
+	}
+	if _, ok := named.(reference.Digested); ok {
+		return fmt.Errorf("image reference %s can't pin a digest, as it's pushed to", ref)
+	}
+	return nil
+}
+
+// registryHost returns the host of the registry of ref, docker.io for Docker
+// Hub images.
+func registryHost(ref string) string {
+	named, err := reference.ParseNormalizedNamed(ref)
+	if err != nil {
+		return ""
+	}
+	return reference.Domain(named)
+}
+
+// pushAuthConfigs keys the credentials of opts.PushTo by registry host.
+func pushAuthConfigs(opts ImageOptions) map[string]types.AuthConfig {
+	if opts.PushToAuth == nil {
+		return nil
+	}
+
+	auths := map[string]types.AuthConfig{}
+	for _, ref := range opts.PushTo {
+		host := registryHost(ref)
+		auths[host] = types.AuthConfig{
+			Username:      opts.PushToAuth.Username,
+			Password:      opts.PushToAuth.Password,
+			ServerAddress: host,
+		}
+		// BuildKit asks for the credentials of Docker Hub by its API host
+		if host == "docker.io" {
+			auths["registry-1.docker.io"] = auths[host]
+		}
+	}
+
+	return auths
+}
+
+// exportImage ships the image built as opts.Tag to where it's needed besides
+// Fly's registry: the registries of opts.PushTo and the archive at
+// opts.OutputPath.
+func exportImage(ctx context.Context, docker *dockerclient.Client, streams *iostreams.IOStreams, opts ImageOptions) error {
+	if err := pushToRegistries(ctx, docker, streams, opts); err != nil {
+		return err
+	}
+	if opts.OutputPath == "" {
+		return nil
+	}
+
+	tb := render.NewTextBlock(ctx, fmt.Sprintf("Writing image to %s", opts.OutputPath))
+
+	archive, err := docker.ImageSave(ctx, []string{opts.Tag})
+	if err != nil {
+		return fmt.Errorf("failed exporting image: %w", err)
+	}
+	defer archive.Close() //skipcq: GO-S2307
+
+	if err := writeArchive(opts.OutputPath, archive); err != nil {
+		return err
+	}
+
+	tb.Done(fmt.Sprintf("Writing image to %s done", opts.OutputPath))
+	return nil
+}
+
+// writeArchive writes r to path through a temporary file, so that path is
+// never left with a partial archive.
+func writeArchive(path string, r io.Reader) (err error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+"-*")
+	if err != nil {
+		return fmt.Errorf("failed creating %s: %w", path, err)
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if _, err = io.Copy(f, r); err != nil {
+		return fmt.Errorf("failed writing %s: %w", path, err)
+	}
+	if err = f.Close(); err != nil {
+		return fmt.Errorf("failed writing %s: %w", path, err)
+	}
+
+	return os.Rename(f.Name(), path)
+}
+
+// pushToRegistries tags the image built as opts.Tag as every ref of
+// opts.PushTo and pushes them, so that it's also archived elsewhere.
+func pushToRegistries(ctx context.Context, docker *dockerclient.Client, streams *iostreams.IOStreams, opts ImageOptions) error {
+	auths := pushAuthConfigs(opts)
+
+	for _, ref := range opts.PushTo {
+		tb := render.NewTextBlock(ctx, fmt.Sprintf("Pushing image to %s", ref))
+
+		if err := docker.ImageTag(ctx, opts.Tag, ref); err != nil {
+			return fmt.Errorf("failed tagging image as %s: %w", ref, err)
+		}
+
+		var auth string
+		if config, ok := auths[registryHost(ref)]; ok {
+			encoded, err := json.Marshal(config)
+			if err != nil {
+				return err
+			}
+			auth = base64.URLEncoding.EncodeToString(encoded)
+		}
+
+		err := pushImage(ctx, docker, streams, ref, auth)
+		// only untag, the image is still tagged for Fly's registry
+		if _, rmErr := docker.ImageRemove(ctx, ref, types.ImageRemoveOptions{}); rmErr != nil {
+			terminal.Debug("Error untagging image", rmErr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed pushing image to %s: %w", ref, err)
+		}
+
+		tb.Done(fmt.Sprintf("Pushing image to %s done", ref))
+	}
+
+	return nil
+}
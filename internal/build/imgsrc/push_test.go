@@ -0,0 +1,47 @@
+package imgsrc
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidatePushRef(t *testing.T) {
+	assert.NoError(t, ValidatePushRef("ghcr.io/my-org/my-app:v1"))
+	assert.NoError(t, ValidatePushRef("my-org/my-app"))
+	assert.Error(t, ValidatePushRef("ghcr.io/My-Org/my-app"))
+	assert.ErrorContains(t, ValidatePushRef("ghcr.io/my-org/my-app@sha256:"+
+		"0123456789012345678901234567890123456789012345678901234567890123"), "can't pin a digest")
+}
+
+func TestPushAuthConfigs(t *testing.T) {
+	opts := ImageOptions{PushTo: []string{"ghcr.io/my-org/my-app:v1", "my-org/my-app:v1"}}
+	assert.Nil(t, pushAuthConfigs(opts))
+
+	opts.PushToAuth = &RegistryCredentials{Username: "me", Password: "secret"}
+	auths := pushAuthConfigs(opts)
+	assert.Equal(t, "ghcr.io", auths["ghcr.io"].ServerAddress)
+	assert.Equal(t, "me", auths["docker.io"].Username)
+	assert.Equal(t, "secret", auths["registry-1.docker.io"].Password)
+}
+
+func TestWriteArchive(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.tar")
+
+	require.NoError(t, writeArchive(path, strings.NewReader("archive")))
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "archive", string(data))
+
+	// a failed write leaves neither the archive nor a temporary file behind
+	require.Error(t, writeArchive(path+"2", iotest.ErrReader(errors.New("boom"))))
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+}
@@ -6,6 +6,7 @@ import (
 	"net"
 	"net/http"
 	"net/url"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -41,6 +42,83 @@ type ImageOptions struct {
 	BuiltInSettings map[string]interface{}
 	Builder         string
 	Buildpacks      []string
+	// CacheNamespace, when set, namespaces the cache mounts of the Dockerfile
+	CacheNamespace string
+	// CacheRef, when set, is a registry image BuildKit builds import their
+	// layer cache from, and which is replaced by the new image once pushed
+	CacheRef string
+	// Platform is the platform images are built for, DefaultBuildPlatform
+	// when empty
+	Platform string
+	// Network is the network mode of the RUN steps of builds, the builder's
+	// default when empty. Base images are pulled by the builder regardless.
+	Network string
+	// PushTo are image refs in other registries built images are also pushed
+	// to, authenticated with PushToAuth when set
+	PushTo     []string
+	PushToAuth *RegistryCredentials
+	// OutputPath, when set, is the file built images are written to as a
+	// docker archive
+	OutputPath string
+	// SBOMFormat, when set, is the format the packages of built images are
+	// cataloged in, written to SBOMOutput when set
+	SBOMFormat string
+	SBOMOutput string
+	// SeekableLayers, when set, has the layers of pushed images compressed
+	// as eStargz, which runtimes supporting it can pull lazily. Only
+	// buildkitd can export them.
+	SeekableLayers bool
+}
+
+// DefaultBuildPlatform is the platform of the hardware machines run on.
+const DefaultBuildPlatform = "linux/amd64"
+
+// supportedBuildPlatforms are the platforms images can be built for.
+var supportedBuildPlatforms = []string{"linux/amd64", "linux/arm64"}
+
+// ValidateBuildPlatform checks images can be built for platform.
+func ValidateBuildPlatform(platform string) error {
+	for _, p := range supportedBuildPlatforms {
+		if platform == p {
+			return nil
+		}
+	}
+	return fmt.Errorf("unsupported build platform %q, expected one of %s", platform, strings.Join(supportedBuildPlatforms, ", "))
+}
+
+const (
+	// BuildNetworkDefault builds with the network access of the builder.
+	BuildNetworkDefault = "default"
+	// BuildNetworkNone builds without network access, so that build steps
+	// can't call out to the internet.
+	BuildNetworkNone = "none"
+)
+
+// ValidateBuildNetwork checks builds can run with network mode.
+func ValidateBuildNetwork(network string) error {
+	switch network {
+	case BuildNetworkDefault, BuildNetworkNone:
+		return nil
+	default:
+		return fmt.Errorf("unsupported build network %q, expected %s or %s", network, BuildNetworkDefault, BuildNetworkNone)
+	}
+}
+
+// buildNetwork is the network mode builds run with, empty for the builder's
+// default.
+func (opts ImageOptions) buildNetwork() string {
+	if opts.Network == BuildNetworkDefault {
+		return ""
+	}
+	return opts.Network
+}
+
+// buildPlatform is the platform the image is built for.
+func (opts ImageOptions) buildPlatform() string {
+	if opts.Platform == "" {
+		return DefaultBuildPlatform
+	}
+	return opts.Platform
 }
 
 type RefOptions struct {
@@ -107,8 +185,19 @@ func (r *Resolver) ResolveReference(ctx context.Context, streams *iostreams.IOSt
 
 // BuildImage converts source code to an image using a Dockerfile, buildpacks, or builtins.
 func (r *Resolver) BuildImage(ctx context.Context, streams *iostreams.IOStreams, opts ImageOptions) (img *DeploymentImage, err error) {
-	if !r.dockerFactory.mode.IsAvailable() {
-		return nil, errors.New("docker is unavailable to build the deployment image")
+	var buildkitAddr string
+	switch {
+	case opts.SeekableLayers:
+		// the image exporter of dockerd can't compress layers as eStargz
+		if buildkitAddr = BuildkitAddress(); buildkitAddr == "" {
+			return nil, fmt.Errorf("seekable layers can only be built by a buildkitd, and none was found at %s", describeBuildkitAddress())
+		}
+		terminal.Debugf("building seekable layers with buildkitd at %s\n", buildkitAddr)
+	case !r.dockerFactory.mode.IsAvailable():
+		if buildkitAddr = BuildkitAddress(); buildkitAddr == "" {
+			return nil, fmt.Errorf("docker is unavailable to build the deployment image, and no buildkitd was found at %s", describeBuildkitAddress())
+		}
+		terminal.Debugf("no docker daemon, building with buildkitd at %s\n", buildkitAddr)
 	}
 
 	if opts.Tag == "" {
@@ -117,7 +206,10 @@ func (r *Resolver) BuildImage(ctx context.Context, streams *iostreams.IOStreams,
 
 	strategies := []imageBuilder{}
 
-	if r.dockerFactory.mode.UseNixpacks() {
+	if buildkitAddr != "" {
+		// buildpacks, nixpacks and builtins all need a Docker daemon
+		strategies = append(strategies, &buildkitBuilder{addr: buildkitAddr})
+	} else if r.dockerFactory.mode.UseNixpacks() {
 		strategies = append(strategies, &nixpacksBuilder{})
 	} else {
 		strategies = []imageBuilder{
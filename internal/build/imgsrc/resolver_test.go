@@ -0,0 +1,26 @@
+package imgsrc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildPlatform(t *testing.T) {
+	assert.NoError(t, ValidateBuildPlatform("linux/amd64"))
+	assert.NoError(t, ValidateBuildPlatform("linux/arm64"))
+	assert.ErrorContains(t, ValidateBuildPlatform("windows/amd64"), `unsupported build platform "windows/amd64"`)
+
+	assert.Equal(t, DefaultBuildPlatform, ImageOptions{}.buildPlatform())
+	assert.Equal(t, "linux/arm64", ImageOptions{Platform: "linux/arm64"}.buildPlatform())
+}
+
+func TestBuildNetwork(t *testing.T) {
+	assert.NoError(t, ValidateBuildNetwork("default"))
+	assert.NoError(t, ValidateBuildNetwork("none"))
+	assert.ErrorContains(t, ValidateBuildNetwork("host"), `unsupported build network "host"`)
+
+	assert.Equal(t, "", ImageOptions{}.buildNetwork())
+	assert.Equal(t, "", ImageOptions{Network: BuildNetworkDefault}.buildNetwork())
+	assert.Equal(t, "none", ImageOptions{Network: BuildNetworkNone}.buildNetwork())
+}
@@ -0,0 +1,280 @@
+package imgsrc
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	dockerclient "github.com/docker/docker/client"
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+
+	"github.com/superfly/flyctl/flyctl"
+	"github.com/superfly/flyctl/internal/render"
+)
+
+// SBOM formats images can be cataloged in.
+const (
+	SBOMFormatCycloneDX = "cyclonedx"
+	SBOMFormatSPDX      = "spdx"
+)
+
+// sbomFormat is how syft writes an SBOM format and how it's stored in a
+// registry.
+type sbomFormat struct {
+	syftOutput string
+	mediaType  types.MediaType
+}
+
+var sbomFormats = map[string]sbomFormat{
+	SBOMFormatCycloneDX: {syftOutput: "cyclonedx-json", mediaType: "application/vnd.cyclonedx+json"},
+	SBOMFormatSPDX:      {syftOutput: "spdx-json", mediaType: "application/spdx+json"},
+}
+
+// ValidateSBOMFormat checks images can be cataloged in format.
+func ValidateSBOMFormat(format string) error {
+	if _, ok := sbomFormats[format]; !ok {
+		return fmt.Errorf("unsupported SBOM format %q, expected %s or %s", format, SBOMFormatCycloneDX, SBOMFormatSPDX)
+	}
+	return nil
+}
+
+// syftBinary finds syft, which catalogs the packages of images, in flyctl's
+// bin directory or on the PATH.
+func syftBinary() (string, error) {
+	bin := filepath.Join(flyctl.CacheDir(), "bin", "syft")
+	if _, err := os.Stat(bin); err == nil {
+		return bin, nil
+	}
+	if bin, err := exec.LookPath("syft"); err == nil {
+		return bin, nil
+	}
+	return "", fmt.Errorf("generating an SBOM requires syft, install it from https://github.com/anchore/syft#installation")
+}
+
+// sbomSource is where syft reads the image built as opts.Tag from, along with
+// the environment it needs to: the registry it was pushed to, the local Docker
+// daemon it was loaded into, or the archive it was written to. docker is nil
+// unless the image was built by a local daemon.
+func sbomSource(opts ImageOptions, docker *dockerclient.Client) (string, []string, error) {
+	switch {
+	case opts.Publish:
+		return "registry:" + opts.Tag, []string{
+			"SYFT_REGISTRY_AUTH_AUTHORITY=registry.fly.io",
+			"SYFT_REGISTRY_AUTH_USERNAME=x",
+			"SYFT_REGISTRY_AUTH_PASSWORD=" + flyctl.GetAPIToken(),
+		}, nil
+	case docker != nil:
+		return "docker:" + opts.Tag, []string{"DOCKER_HOST=" + docker.DaemonHost()}, nil
+	case opts.OutputPath != "":
+		return "docker-archive:" + opts.OutputPath, nil, nil
+	default:
+		return "", nil, fmt.Errorf("an SBOM can only be generated for images pushed to Fly's registry, built locally or written to --output")
+	}
+}
+
+// localDocker returns docker when it's a daemon on this host, which syft can
+// read images from.
+func localDocker(dockerFactory *dockerClientFactory, docker *dockerclient.Client) *dockerclient.Client {
+	if dockerFactory.IsRemote() {
+		return nil
+	}
+	return docker
+}
+
+// generateSBOM catalogs the packages of the image built as opts.Tag in
+// opts.SBOMFormat, writes the SBOM to opts.SBOMOutput and attaches it to the
+// image in Fly's registry when it was pushed there.
+func generateSBOM(ctx context.Context, opts ImageOptions, docker *dockerclient.Client) error {
+	if opts.SBOMFormat == "" {
+		return nil
+	}
+	format, ok := sbomFormats[opts.SBOMFormat]
+	if !ok {
+		return ValidateSBOMFormat(opts.SBOMFormat)
+	}
+
+	bin, err := syftBinary()
+	if err != nil {
+		return err
+	}
+	source, env, err := sbomSource(opts, docker)
+	if err != nil {
+		return err
+	}
+
+	tb := render.NewTextBlock(ctx, "Generating SBOM")
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, bin, source, "--output", format.syftOutput, "--quiet")
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed generating SBOM: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	sbom := stdout.Bytes()
+
+	summary, err := summarizeSBOM(opts.SBOMFormat, sbom)
+	if err != nil {
+		return err
+	}
+	tb.Donef("Generating SBOM done, %s", summary)
+
+	if opts.SBOMOutput != "" {
+		if err := writeArchive(opts.SBOMOutput, bytes.NewReader(sbom)); err != nil {
+			return err
+		}
+		tb.Detailf("SBOM written to %s", opts.SBOMOutput)
+	}
+
+	if opts.Publish {
+		ref, err := attachSBOM(ctx, opts.Tag, format.mediaType, sbom)
+		if err != nil {
+			return fmt.Errorf("failed attaching SBOM to image: %w", err)
+		}
+		tb.Detailf("SBOM attached to the image as %s", ref)
+	}
+
+	return nil
+}
+
+// sbomSummary counts the packages an SBOM lists by ecosystem, such as deb or
+// npm.
+type sbomSummary map[string]int
+
+func (s sbomSummary) String() string {
+	var (
+		total      int
+		ecosystems = make([]string, 0, len(s))
+	)
+	for ecosystem, n := range s {
+		total += n
+		ecosystems = append(ecosystems, ecosystem)
+	}
+	sort.Slice(ecosystems, func(i, j int) bool {
+		if s[ecosystems[i]] != s[ecosystems[j]] {
+			return s[ecosystems[i]] > s[ecosystems[j]]
+		}
+		return ecosystems[i] < ecosystems[j]
+	})
+
+	parts := make([]string, 0, len(ecosystems))
+	for _, ecosystem := range ecosystems {
+		parts = append(parts, fmt.Sprintf("%d %s", s[ecosystem], ecosystem))
+	}
+	if len(parts) == 0 {
+		return "no packages found"
+	}
+	return fmt.Sprintf("%d packages: %s", total, strings.Join(parts, ", "))
+}
+
+// summarizeSBOM counts the packages of a JSON SBOM by the type of their
+// package URL, "other" when they have none.
+func summarizeSBOM(format string, sbom []byte) (sbomSummary, error) {
+	var purls []string
+
+	switch format {
+	case SBOMFormatCycloneDX:
+		var doc struct {
+			Components []struct {
+				PURL string `json:"purl"`
+			} `json:"components"`
+		}
+		if err := json.Unmarshal(sbom, &doc); err != nil {
+			return nil, fmt.Errorf("failed parsing SBOM: %w", err)
+		}
+		for _, c := range doc.Components {
+			purls = append(purls, c.PURL)
+		}
+	case SBOMFormatSPDX:
+		var doc struct {
+			Packages []struct {
+				ExternalRefs []struct {
+					ReferenceType    string `json:"referenceType"`
+					ReferenceLocator string `json:"referenceLocator"`
+				} `json:"externalRefs"`
+			} `json:"packages"`
+		}
+		if err := json.Unmarshal(sbom, &doc); err != nil {
+			return nil, fmt.Errorf("failed parsing SBOM: %w", err)
+		}
+		for _, p := range doc.Packages {
+			var purl string
+			for _, ref := range p.ExternalRefs {
+				if ref.ReferenceType == "purl" {
+					purl = ref.ReferenceLocator
+					break
+				}
+			}
+			purls = append(purls, purl)
+		}
+	default:
+		return nil, ValidateSBOMFormat(format)
+	}
+
+	summary := sbomSummary{}
+	for _, purl := range purls {
+		summary[purlType(purl)]++
+	}
+
+	return summary, nil
+}
+
+// purlType returns the type of a package URL, such as npm for
+// pkg:npm/left-pad@1.3.0.
+func purlType(purl string) string {
+	typ, _, ok := strings.Cut(strings.TrimPrefix(purl, "pkg:"), "/")
+	if !strings.HasPrefix(purl, "pkg:") || !ok || typ == "" {
+		return "other"
+	}
+	return typ
+}
+
+// sbomTag is the tag the SBOM of the image with the given digest is stored
+// at, following the convention of cosign attach sbom, which tools look SBOMs
+// up by.
+func sbomTag(repo name.Repository, digest string) name.Tag {
+	return repo.Tag(strings.Replace(digest, ":", "-", 1) + ".sbom")
+}
+
+// attachSBOM pushes sbom as a single layer artifact next to the image tag in
+// Fly's registry, returning its ref.
+func attachSBOM(ctx context.Context, tag string, mediaType types.MediaType, sbom []byte) (string, error) {
+	ref, err := name.ParseReference(tag)
+	if err != nil {
+		return "", err
+	}
+	options := []remote.Option{
+		remote.WithContext(ctx),
+		remote.WithAuth(&authn.Basic{Username: "x", Password: flyctl.GetAPIToken()}),
+	}
+
+	desc, err := remote.Head(ref, options...)
+	if err != nil {
+		return "", err
+	}
+
+	artifact, err := mutate.AppendLayers(empty.Image, static.NewLayer(sbom, mediaType))
+	if err != nil {
+		return "", err
+	}
+
+	target := sbomTag(ref.Context(), desc.Digest.String())
+	if err := remote.Write(target, artifact, options...); err != nil {
+		return "", err
+	}
+
+	return target.String(), nil
+}
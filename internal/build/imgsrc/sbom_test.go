@@ -0,0 +1,76 @@
+package imgsrc
+
+import (
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSummarizeSBOM(t *testing.T) {
+	cyclonedx := `{
+		"bomFormat": "CycloneDX",
+		"components": [
+			{"name": "libc6", "purl": "pkg:deb/debian/libc6@2.36"},
+			{"name": "openssl", "purl": "pkg:deb/debian/openssl@3.0.9"},
+			{"name": "left-pad", "purl": "pkg:npm/left-pad@1.3.0"},
+			{"name": "debian"}
+		]
+	}`
+	summary, err := summarizeSBOM(SBOMFormatCycloneDX, []byte(cyclonedx))
+	require.NoError(t, err)
+	assert.Equal(t, sbomSummary{"deb": 2, "npm": 1, "other": 1}, summary)
+	assert.Equal(t, "4 packages: 2 deb, 1 npm, 1 other", summary.String())
+
+	spdx := `{
+		"spdxVersion": "SPDX-2.3",
+		"packages": [
+			{"name": "rack", "externalRefs": [
+				{"referenceType": "cpe23Type", "referenceLocator": "cpe:2.3:a:rack:rack:3.0.8"},
+				{"referenceType": "purl", "referenceLocator": "pkg:gem/rack@3.0.8"}
+			]}
+		]
+	}`
+	summary, err = summarizeSBOM(SBOMFormatSPDX, []byte(spdx))
+	require.NoError(t, err)
+	assert.Equal(t, sbomSummary{"gem": 1}, summary)
+
+	summary, err = summarizeSBOM(SBOMFormatSPDX, []byte(`{"packages": []}`))
+	require.NoError(t, err)
+	assert.Equal(t, "no packages found", summary.String())
+
+	_, err = summarizeSBOM(SBOMFormatCycloneDX, []byte("not json"))
+	assert.Error(t, err)
+}
+
+func TestSBOMTag(t *testing.T) {
+	repo, err := name.NewRepository("registry.fly.io/my-app")
+	require.NoError(t, err)
+
+	tag := sbomTag(repo, "sha256:0123abcd")
+	assert.Equal(t, "registry.fly.io/my-app:sha256-0123abcd.sbom", tag.String())
+}
+
+func TestSBOMSource(t *testing.T) {
+	opts := ImageOptions{Tag: "registry.fly.io/my-app:deployment-1"}
+
+	_, _, err := sbomSource(opts, nil)
+	assert.Error(t, err)
+
+	opts.OutputPath = "my-app.tar"
+	source, _, err := sbomSource(opts, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "docker-archive:my-app.tar", source)
+
+	opts.Publish = true
+	source, _, err = sbomSource(opts, nil)
+	require.NoError(t, err)
+	assert.Equal(t, "registry:registry.fly.io/my-app:deployment-1", source)
+}
+
+func TestValidateSBOMFormat(t *testing.T) {
+	assert.NoError(t, ValidateSBOMFormat("cyclonedx"))
+	assert.NoError(t, ValidateSBOMFormat("spdx"))
+	assert.Error(t, ValidateSBOMFormat("syft-json"))
+}
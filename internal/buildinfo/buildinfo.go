@@ -36,16 +36,18 @@ type info struct {
 	OS           string
 	Architecture string
 	Environment  string
+	GoVersion    string
 }
 
 func (i info) String() string {
-	return fmt.Sprintf("%s v%s %s/%s Commit: %s BuildDate: %s",
+	return fmt.Sprintf("%s v%s %s/%s Commit: %s BuildDate: %s GoVersion: %s",
 		i.Name,
 		i.Version,
 		i.OS,
 		i.Architecture,
 		i.Commit,
-		i.BuildDate.Format(time.RFC3339))
+		i.BuildDate.Format(time.RFC3339),
+		i.GoVersion)
 }
 
 func Info() info {
@@ -57,6 +59,7 @@ func Info() info {
 		OS:           OS(),
 		Architecture: Arch(),
 		Environment:  Environment(),
+		GoVersion:    runtime.Version(),
 	}
 }
 
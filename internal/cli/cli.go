@@ -11,9 +11,11 @@ import (
 	"github.com/AlecAivazis/survey/v2/terminal"
 	"github.com/spf13/cobra"
 
+	"github.com/superfly/flyctl/gql"
 	"github.com/superfly/flyctl/iostreams"
 	"github.com/superfly/graphql"
 
+	"github.com/superfly/flyctl/internal/buildinfo"
 	"github.com/superfly/flyctl/internal/flyerr"
 	"github.com/superfly/flyctl/internal/logger"
 
@@ -33,6 +35,7 @@ func Run(ctx context.Context, io *iostreams.IOStreams, args ...string) int {
 
 	cs := io.ColorScheme()
 
+	var exitErr flyerr.ExitCodeError
 	switch _, err := cmd.ExecuteContextC(ctx); {
 	case err == nil:
 		return 0
@@ -42,18 +45,69 @@ func Run(ctx context.Context, io *iostreams.IOStreams, args ...string) int {
 		printError(io.ErrOut, cs, err)
 
 		return 126
+	case errors.As(err, &exitErr):
+		return exitErr.Code
 	case isUnchangedError(err):
 		// This means the deployment was a noop, which is noteworthy but not something we should
 		// fail CI on. Print a warning and exit 0. Remove this once we're fully on Machines!
 		printError(io.ErrOut, cs, err)
 		return 0
 	default:
+		if org, ok := gql.IsSSORequiredError(err); ok {
+			err = ssoRequiredError{error: err, org: org}
+		} else if part, ok := gql.IsSchemaError(err); ok {
+			err = staleClientError{error: err, part: part}
+		}
+
 		printError(io.ErrOut, cs, err)
 
 		return 1
 	}
 }
 
+// ssoRequiredError points users whose SSO session has expired at the command
+// which signs them in again, rather than leaving them with a bare API error.
+type ssoRequiredError struct {
+	error
+	org string
+}
+
+func (e ssoRequiredError) Unwrap() error {
+	return e.error
+}
+
+func (e ssoRequiredError) Suggestion() string {
+	org := e.org
+	if org == "" {
+		org = "<org>"
+	}
+
+	return fmt.Sprintf(`Your organization requires single sign-on, and your SSO session for it has expired.
+Run 'fly auth refresh --org %s' to sign in again.
+Tokens used by CI and other long running sessions have to be issued again once their SSO session expires.`, org)
+}
+
+// staleClientError explains API errors about parts of its schema which don't
+// exist as flyctl being too old, rather than leaving users with a bare
+// GraphQL error.
+type staleClientError struct {
+	error
+	part string
+}
+
+func (e staleClientError) Unwrap() error {
+	return e.error
+}
+
+func (e staleClientError) Description() string {
+	return fmt.Sprintf("The Fly.io API no longer supports the %s this version of flyctl (v%s) relies on.",
+		e.part, buildinfo.Version())
+}
+
+func (e staleClientError) Suggestion() string {
+	return "Upgrade flyctl with 'fly version update', then run the command again."
+}
+
 // isUnchangedError returns true if the error returned is an UNCHANGED GraphQL error.
 // Remove this once we're fully on Machines!
 func isUnchangedError(err error) bool {
@@ -35,10 +35,12 @@ The LIST command will list all currently registered applications.
 		newDestroy(),
 		newRestart(),
 		newMove(),
+		newPause(),
 		newResume(),
 		newSuspend(),
 		NewOpen(),
 		NewReleases(),
+		newErrors(),
 	)
 
 	return apps
@@ -0,0 +1,222 @@
+package apps
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/format"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// earlierImage stands for the image of exits which happened before their
+// machine was last updated, as machines only report their current image.
+const earlierImage = "(earlier image)"
+
+func newErrors() (cmd *cobra.Command) {
+	const (
+		long = `Summarize the recent unrequested exits of the app's machines: crashes,
+out of memory kills and terminations by a signal, grouped by process group and
+image, to tell whether a new release is crashing.
+
+Exits from before a machine was last updated are grouped under an earlier image,
+as machines only report the image they run now.
+`
+		short = "Summarize recent machine crashes"
+	)
+
+	cmd = command.New("errors", short, long, runErrors,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.String{
+			Name:        "since",
+			Description: "How far back to look for exits, such as 30m or 48h",
+			Default:     "24h",
+		},
+	)
+
+	return
+}
+
+// exitSummary counts the exits of the machines of a process group running an
+// image.
+type exitSummary struct {
+	ProcessGroup string      `json:"process_group"`
+	Image        string      `json:"image"`
+	Exits        int         `json:"exits"`
+	OOMKills     int         `json:"oom_kills"`
+	Signals      int         `json:"signals"`
+	ExitCodes    map[int]int `json:"exit_codes"`
+	Machines     []string    `json:"machines"`
+	LastExit     time.Time   `json:"last_exit"`
+}
+
+func runErrors(ctx context.Context) error {
+	var (
+		appName = appconfig.NameFromContext(ctx)
+		client  = client.FromContext(ctx).API()
+	)
+
+	window, err := time.ParseDuration(flag.GetString(ctx, "since"))
+	if err != nil || window <= 0 {
+		return fmt.Errorf("invalid --since %q, expected a duration such as 30m or 48h", flag.GetString(ctx, "since"))
+	}
+
+	app, err := client.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+	if app.PlatformVersion != "machines" {
+		return fmt.Errorf("app %s isn't a machines app", appName)
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return fmt.Errorf("could not create flaps client: %w", err)
+	}
+	machines, err := flapsClient.List(ctx, "")
+	if err != nil {
+		return err
+	}
+
+	summaries := summarizeExits(machines, time.Now().Add(-window))
+
+	out := iostreams.FromContext(ctx).Out
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, summaries)
+	}
+
+	if len(summaries) == 0 {
+		fmt.Fprintf(out, "No machine of %s exited unexpectedly in the last %s\n", appName, window)
+		return nil
+	}
+
+	var rows [][]string
+	for _, s := range summaries {
+		rows = append(rows, []string{
+			s.ProcessGroup,
+			s.Image,
+			fmt.Sprint(s.Exits),
+			fmt.Sprint(s.OOMKills),
+			fmt.Sprint(s.Signals),
+			formatExitCodes(s.ExitCodes),
+			fmt.Sprint(len(s.Machines)),
+			format.RelativeTime(s.LastExit),
+		})
+	}
+
+	title := fmt.Sprintf("Unexpected exits in the last %s", window)
+	return render.Table(out, title, rows, "Process Group", "Image", "Exits", "OOM Kills", "Signals", "Exit Codes", "Machines", "Last Exit")
+}
+
+// summarizeExits groups the unrequested exits of machines since the given time
+// by process group and image, most recently exited first.
+func summarizeExits(machines []*api.Machine, since time.Time) []*exitSummary {
+	summaries := map[[2]string]*exitSummary{}
+
+	for _, m := range machines {
+		image := m.ImageRefWithVersion()
+		machineCounted := map[[2]string]bool{}
+
+		// events are listed newest first, so passing an update means the
+		// exits after it ran an earlier image
+		for _, e := range m.Events {
+			if e.Type == "update" {
+				image = earlierImage
+			}
+			if e.Type != "exit" || e.Request == nil {
+				continue
+			}
+			at := time.UnixMilli(e.Timestamp)
+			if at.Before(since) {
+				break
+			}
+
+			exit := e.Request.ExitEvent
+			if e.Request.MonitorEvent != nil && e.Request.MonitorEvent.ExitEvent != nil {
+				exit = e.Request.MonitorEvent.ExitEvent
+			}
+			if exit == nil || exit.RequestedStop {
+				continue
+			}
+
+			key := [2]string{m.ProcessGroup(), image}
+			s, ok := summaries[key]
+			if !ok {
+				s = &exitSummary{ProcessGroup: key[0], Image: key[1], ExitCodes: map[int]int{}}
+				summaries[key] = s
+			}
+
+			s.Exits++
+			switch {
+			case exit.OOMKilled:
+				s.OOMKills++
+			case exit.Signal != 0 || exit.GuestSignal != 0:
+				s.Signals++
+			}
+			s.ExitCodes[exit.ExitCode]++
+			if at.After(s.LastExit) {
+				s.LastExit = at
+			}
+			if !machineCounted[key] {
+				machineCounted[key] = true
+				s.Machines = append(s.Machines, m.ID)
+			}
+		}
+	}
+
+	sorted := make([]*exitSummary, 0, len(summaries))
+	for _, s := range summaries {
+		sorted = append(sorted, s)
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].LastExit.After(sorted[j].LastExit)
+	})
+
+	return sorted
+}
+
+// formatExitCodes lists exit codes with how often they occurred, most
+// frequent first, such as "1 (x3), 137".
+func formatExitCodes(codes map[int]int) string {
+	keys := make([]int, 0, len(codes))
+	for code := range codes {
+		keys = append(keys, code)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if codes[keys[i]] != codes[keys[j]] {
+			return codes[keys[i]] > codes[keys[j]]
+		}
+		return keys[i] < keys[j]
+	})
+
+	parts := make([]string, 0, len(keys))
+	for _, code := range keys {
+		if n := codes[code]; n > 1 {
+			parts = append(parts, fmt.Sprintf("%d (x%d)", code, n))
+		} else {
+			parts = append(parts, fmt.Sprint(code))
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
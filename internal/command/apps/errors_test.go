@@ -0,0 +1,69 @@
+package apps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func Test_summarizeExits(t *testing.T) {
+	now := time.Now()
+	exit := func(ago time.Duration, e api.MachineExitEvent) *api.MachineEvent {
+		return &api.MachineEvent{
+			Type:      "exit",
+			Timestamp: now.Add(-ago).UnixMilli(),
+			Request:   &api.MachineRequest{ExitEvent: &e},
+		}
+	}
+	update := func(ago time.Duration) *api.MachineEvent {
+		return &api.MachineEvent{Type: "update", Timestamp: now.Add(-ago).UnixMilli()}
+	}
+
+	machines := []*api.Machine{
+		{
+			ID:       "m1",
+			Config:   &api.MachineConfig{Metadata: map[string]string{"fly_process_group": "web"}},
+			ImageRef: api.MachineImageRef{Repository: "app", Tag: "v2"},
+			Events: []*api.MachineEvent{
+				exit(time.Minute, api.MachineExitEvent{ExitCode: 1}),
+				exit(2*time.Minute, api.MachineExitEvent{ExitCode: 137, OOMKilled: true}),
+				exit(3*time.Minute, api.MachineExitEvent{ExitCode: 143, RequestedStop: true}),
+				update(4 * time.Minute),
+				exit(5*time.Minute, api.MachineExitEvent{ExitCode: 1}),
+				exit(48*time.Hour, api.MachineExitEvent{ExitCode: 1}),
+			},
+		},
+		{
+			ID:       "m2",
+			Config:   &api.MachineConfig{Metadata: map[string]string{"fly_process_group": "web"}},
+			ImageRef: api.MachineImageRef{Repository: "app", Tag: "v2"},
+			Events: []*api.MachineEvent{
+				exit(10*time.Minute, api.MachineExitEvent{ExitCode: 1}),
+				exit(11*time.Minute, api.MachineExitEvent{ExitCode: 0, Signal: 9}),
+			},
+		},
+	}
+
+	summaries := summarizeExits(machines, now.Add(-24*time.Hour))
+	require.Len(t, summaries, 2)
+
+	current := summaries[0]
+	assert.Equal(t, "web", current.ProcessGroup)
+	assert.Equal(t, "app:v2", current.Image)
+	assert.Equal(t, 4, current.Exits)
+	assert.Equal(t, 1, current.OOMKills)
+	assert.Equal(t, 1, current.Signals)
+	assert.Equal(t, map[int]int{0: 1, 1: 2, 137: 1}, current.ExitCodes)
+	assert.Equal(t, []string{"m1", "m2"}, current.Machines)
+
+	earlier := summaries[1]
+	assert.Equal(t, earlierImage, earlier.Image)
+	assert.Equal(t, 1, earlier.Exits)
+	assert.Equal(t, []string{"m1"}, earlier.Machines)
+
+	assert.Equal(t, "1 (x2), 0, 137", formatExitCodes(current.ExitCodes))
+}
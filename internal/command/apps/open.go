@@ -4,11 +4,16 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net/http"
 	"net/url"
+	"strings"
+	"time"
 
 	"github.com/skratchdot/open-golang/open"
 	"github.com/spf13/cobra"
 
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/iostreams"
 
 	"github.com/superfly/flyctl/client"
@@ -17,11 +22,16 @@ import (
 	"github.com/superfly/flyctl/internal/flag"
 )
 
+const probeTimeout = 10 * time.Second
+
 // TODO: make internal once the open command has been deprecated
 func NewOpen() (cmd *cobra.Command) {
 	const (
 		long = `Open browser to current deployed application. If an optional relative URI is specified, it is appended
 to the root URL of the deployed application.
+
+With --probe, the URL is requested first and the browser is only opened when
+the app answers; otherwise the error returned by the Fly proxy is shown.
 `
 		short = "Open browser to current deployed application"
 
@@ -38,6 +48,14 @@ to the root URL of the deployed application.
 	flag.Add(cmd,
 		flag.App(),
 		flag.AppConfig(),
+		flag.String{
+			Name:        "path",
+			Description: "Relative URI to open, same as passing it as an argument",
+		},
+		flag.Bool{
+			Name:        "probe",
+			Description: "Check that the app is serving the URL before opening it",
+		},
 	)
 
 	return
@@ -61,11 +79,28 @@ func runOpen(ctx context.Context) error {
 	}
 
 	relURI := flag.FirstArg(ctx)
+	if path := flag.GetString(ctx, "path"); path != "" {
+		if relURI != "" {
+			return errors.New("pass the relative URI either as an argument or with --path, not both")
+		}
+		relURI = path
+	}
 	if appURL, err = appURL.Parse(relURI); err != nil {
 		return fmt.Errorf("failed parsing relative URI %s: %w", relURI, err)
 	}
 
 	iostream := iostreams.FromContext(ctx)
+
+	if err := printAddresses(ctx, app); err != nil {
+		return err
+	}
+
+	if flag.GetBool(ctx, "probe") {
+		if err := probe(ctx, appURL); err != nil {
+			return err
+		}
+	}
+
 	fmt.Fprintf(iostream.Out, "opening %s ...\n", appURL)
 
 	if err := open.Run(appURL.String()); err != nil {
@@ -74,3 +109,79 @@ func runOpen(ctx context.Context) error {
 
 	return nil
 }
+
+// printAddresses prints the public addresses the app's hostname resolves to,
+// warning about the clients which won't be able to reach it.
+func printAddresses(ctx context.Context, app *api.AppCompact) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+	)
+
+	addresses, err := client.FromContext(ctx).API().GetIPAddresses(ctx, app.Name)
+	if err != nil {
+		return fmt.Errorf("failed retrieving IP addresses of %s: %w", app.Name, err)
+	}
+
+	var v4, v6 []string
+	for _, addr := range addresses {
+		switch addr.Type {
+		case "v4", "shared_v4":
+			v4 = append(v4, addr.Address)
+		case "v6":
+			v6 = append(v6, addr.Address)
+		}
+	}
+
+	if len(v4) > 0 || len(v6) > 0 {
+		fmt.Fprintf(io.Out, "%s resolves to %s\n", app.Hostname, strings.Join(append(v4, v6...), ", "))
+	}
+
+	switch {
+	case len(v4) == 0 && len(v6) == 0:
+		fmt.Fprintf(io.ErrOut, "%s %s has no public IP addresses and can't be reached from the internet; allocate some with `fly ips allocate-v4` and `fly ips allocate-v6`\n", colorize.Yellow("WARN"), app.Name)
+	case len(v4) == 0:
+		fmt.Fprintf(io.ErrOut, "%s %s only has IPv6 addresses; clients without IPv6 connectivity can't reach it. Allocate an IPv4 address with `fly ips allocate-v4 --shared`\n", colorize.Yellow("WARN"), app.Name)
+	}
+
+	return nil
+}
+
+// probe requests u and fails unless the app answers it.
+func probe(ctx context.Context, u *url.URL) error {
+	out := iostreams.FromContext(ctx).Out
+
+	fmt.Fprintf(out, "probing %s ...\n", u)
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("app isn't serving %s: %w", u, err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode < http.StatusInternalServerError {
+		fmt.Fprintf(out, "%s responded with %s\n", u, res.Status)
+		return nil
+	}
+
+	msg := fmt.Sprintf("app isn't serving %s: %s", u, res.Status)
+
+	body, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+	if details := strings.TrimSpace(string(body)); details != "" {
+		msg += "\n" + details
+	}
+
+	if requestID := res.Header.Get("fly-request-id"); requestID != "" {
+		msg += fmt.Sprintf("\nrequest id: %s (run `fly trace %s` for details)", requestID, requestID)
+	}
+
+	return errors.New(msg)
+}
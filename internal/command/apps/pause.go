@@ -0,0 +1,292 @@
+package apps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// pausedState is what pausing a machine records in its metadata, to resume it
+// to the same state.
+type pausedState struct {
+	// State is the state of the machine when it was paused
+	State string `json:"state"`
+	// Autostart is the autostart setting of each service of the machine, all
+	// of which are turned off so that requests don't start it
+	Autostart []*bool   `json:"autostart,omitempty"`
+	PausedAt  time.Time `json:"paused_at"`
+}
+
+func newPause() *cobra.Command {
+	const (
+		long = `The APPS PAUSE command stops every machine of an application, recording
+which of them were running, and keeps requests from starting them until the
+APPS RESUME command starts the same machines again. Use --org instead of an
+app name to pause every Apps v2 application of an organization, such as
+non-production environments over the weekend.
+`
+		short = "Stop every machine of an app until it's resumed"
+		usage = "pause [<APPNAME>]"
+	)
+
+	cmd := command.New(usage, short, long, runPause,
+		command.RequireSession,
+	)
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.Yes(),
+	)
+
+	return cmd
+}
+
+func runPause(ctx context.Context) error {
+	apps, err := targetApps(ctx, "Pause")
+	if err != nil {
+		return err
+	}
+
+	for _, app := range apps {
+		if app.PlatformVersion != "machines" {
+			return fmt.Errorf("%s runs on Apps v1, which can't be paused; use 'fly scale count 0' to stop it instead", app.Name)
+		}
+		if err := pauseApp(ctx, app); err != nil {
+			return fmt.Errorf("failed pausing %s: %w", app.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// targetApps returns the app named by the first argument, or the Apps v2 apps
+// of the --org organization, confirming the action on them unless --yes is
+// passed.
+func targetApps(ctx context.Context, action string) ([]*api.AppCompact, error) {
+	var (
+		appName = flag.FirstArg(ctx)
+		orgSlug = flag.GetOrg(ctx)
+		client  = client.FromContext(ctx).API()
+	)
+
+	switch {
+	case appName != "" && orgSlug != "":
+		return nil, errors.New("pass either an app name or --org, not both")
+	case appName != "":
+		app, err := client.GetAppCompact(ctx, appName)
+		if err != nil {
+			return nil, err
+		}
+		return []*api.AppCompact{app}, nil
+	case orgSlug == "":
+		return nil, errors.New("an app name or --org is required")
+	}
+
+	all, err := client.GetApps(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	names := lo.FilterMap(all, func(app api.App, _ int) (string, bool) {
+		return app.Name, app.Organization.Slug == orgSlug && app.PlatformVersion == "machines"
+	})
+	sort.Strings(names)
+
+	if len(names) == 0 {
+		return nil, fmt.Errorf("organization %s has no Apps v2 apps", orgSlug)
+	}
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirmf(ctx, "%s %d apps of %s: %s?", action, len(names), orgSlug, strings.Join(names, ", ")); {
+		case err == nil:
+			if !confirmed {
+				return nil, nil
+			}
+		case prompt.IsNonInteractive(err):
+			return nil, prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return nil, err
+		}
+	}
+
+	apps := make([]*api.AppCompact, 0, len(names))
+	for _, name := range names {
+		app, err := client.GetAppCompact(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+// appMachines returns a context holding a flaps client of app, along with its
+// machines which pick returns true for, leased.
+func appMachines(ctx context.Context, app *api.AppCompact, pick func(*api.Machine) bool) (context.Context, []*api.Machine, func(), error) {
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	machines, _, err := flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	machines = lo.Filter(machines, func(m *api.Machine, _ int) bool { return pick(m) })
+
+	machines, releaseFunc, err := machine.AcquireLeases(ctx, machines)
+	release := func() { releaseFunc(ctx, machines) }
+	if err != nil {
+		release()
+		return nil, nil, nil, err
+	}
+	return ctx, machines, release, nil
+}
+
+func pauseApp(ctx context.Context, app *api.AppCompact) error {
+	io := iostreams.FromContext(ctx)
+
+	ctx, machines, release, err := appMachines(ctx, app, func(m *api.Machine) bool { return !m.IsPaused() })
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if len(machines) == 0 {
+		fmt.Fprintf(io.Out, "%s has no machines left to pause\n", app.Name)
+		return nil
+	}
+
+	flapsClient := flaps.FromContext(ctx)
+	now := time.Now().UTC()
+	running := 0
+
+	for _, m := range machines {
+		if m.State == api.MachineStateStarted {
+			fmt.Fprintf(io.ErrOut, "Stopping machine %s\n", m.ID)
+			if err := flapsClient.Stop(ctx, api.StopMachineInput{ID: m.ID}); err != nil {
+				return err
+			}
+			if err := flapsClient.Wait(ctx, m, api.MachineStateStopped, time.Minute); err != nil {
+				return err
+			}
+			running++
+		}
+
+		cfg, err := pausedConfig(m, now)
+		if err != nil {
+			return err
+		}
+		input := api.LaunchMachineInput{ID: m.ID, Region: m.Region, Config: cfg, SkipLaunch: true}
+		if _, err := flapsClient.Update(ctx, input, m.LeaseNonce); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(io.Out, "Paused %s, stopping %d of its %d machines. Run 'fly apps resume %s' to start them again\n", app.Name, running, len(machines), app.Name)
+	return nil
+}
+
+// pausedConfig returns the config of m recording its state and the autostart
+// setting of its services, which it turns off.
+func pausedConfig(m *api.Machine, now time.Time) (*api.MachineConfig, error) {
+	cfg := machine.CloneConfig(m.Config)
+
+	state := pausedState{State: m.State, PausedAt: now}
+	for i := range cfg.Services {
+		state.Autostart = append(state.Autostart, cfg.Services[i].Autostart)
+		cfg.Services[i].Autostart = api.Pointer(false)
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Metadata == nil {
+		cfg.Metadata = map[string]string{}
+	}
+	cfg.Metadata[api.MachineConfigMetadataKeyFlyPaused] = string(data)
+
+	return cfg, nil
+}
+
+// resumedConfig returns the config of the paused machine m as it was before
+// it was paused, along with the state it was in.
+func resumedConfig(m *api.Machine) (*api.MachineConfig, string, error) {
+	var state pausedState
+	if err := json.Unmarshal([]byte(m.Config.Metadata[api.MachineConfigMetadataKeyFlyPaused]), &state); err != nil {
+		return nil, "", fmt.Errorf("machine %s has an invalid pause record: %w", m.ID, err)
+	}
+
+	cfg := machine.CloneConfig(m.Config)
+	delete(cfg.Metadata, api.MachineConfigMetadataKeyFlyPaused)
+	for i := range cfg.Services {
+		if i < len(state.Autostart) {
+			cfg.Services[i].Autostart = state.Autostart[i]
+		}
+	}
+
+	return cfg, state.State, nil
+}
+
+func resumeMachinesApp(ctx context.Context, app *api.AppCompact) error {
+	io := iostreams.FromContext(ctx)
+
+	ctx, machines, release, err := appMachines(ctx, app, (*api.Machine).IsPaused)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	if len(machines) == 0 {
+		fmt.Fprintf(io.Out, "%s isn't paused\n", app.Name)
+		return nil
+	}
+
+	flapsClient := flaps.FromContext(ctx)
+	started := 0
+
+	for _, m := range machines {
+		cfg, state, err := resumedConfig(m)
+		if err != nil {
+			return err
+		}
+		input := api.LaunchMachineInput{ID: m.ID, Region: m.Region, Config: cfg, SkipLaunch: true}
+		updated, err := flapsClient.Update(ctx, input, m.LeaseNonce)
+		if err != nil {
+			return err
+		}
+
+		if state != api.MachineStateStarted {
+			continue
+		}
+		fmt.Fprintf(io.ErrOut, "Starting machine %s\n", m.ID)
+		if _, err := flapsClient.Start(ctx, m.ID); err != nil {
+			return err
+		}
+		if err := flapsClient.Wait(ctx, updated, api.MachineStateStarted, time.Minute); err != nil {
+			return err
+		}
+		started++
+	}
+
+	fmt.Fprintf(io.Out, "Resumed %s, starting %d of its %d machines\n", app.Name, started, len(machines))
+	return nil
+}
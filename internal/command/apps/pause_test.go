@@ -0,0 +1,50 @@
+package apps
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestPauseRoundTrip(t *testing.T) {
+	m := &api.Machine{
+		ID:    "abc",
+		State: api.MachineStateStarted,
+		Config: &api.MachineConfig{
+			Metadata: map[string]string{api.MachineConfigMetadataKeyFlyProcessGroup: "web"},
+			Services: []api.MachineService{
+				{Protocol: "tcp", InternalPort: 8080, Autostart: api.Pointer(true)},
+				{Protocol: "udp", InternalPort: 5353},
+			},
+		},
+	}
+
+	paused, err := pausedConfig(m, time.Date(2023, 5, 5, 18, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, api.Pointer(false), paused.Services[0].Autostart)
+	assert.Equal(t, api.Pointer(false), paused.Services[1].Autostart)
+	assert.JSONEq(t,
+		`{"state": "started", "autostart": [true, null], "paused_at": "2023-05-05T18:00:00Z"}`,
+		paused.Metadata[api.MachineConfigMetadataKeyFlyPaused],
+	)
+	assert.False(t, m.IsPaused(), "the config of the machine itself is left alone")
+
+	m.State = api.MachineStateStopped
+	m.Config = paused
+	assert.True(t, m.IsPaused())
+
+	resumed, state, err := resumedConfig(m)
+	require.NoError(t, err)
+	assert.Equal(t, api.MachineStateStarted, state)
+	assert.Equal(t, api.Pointer(true), resumed.Services[0].Autostart)
+	assert.Nil(t, resumed.Services[1].Autostart)
+	assert.Equal(t, map[string]string{api.MachineConfigMetadataKeyFlyProcessGroup: "web"}, resumed.Metadata)
+
+	m.Config.Metadata[api.MachineConfigMetadataKeyFlyPaused] = "yes"
+	_, _, err = resumedConfig(m)
+	assert.ErrorContains(t, err, "machine abc has an invalid pause record")
+}
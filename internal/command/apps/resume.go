@@ -19,30 +19,58 @@ import (
 
 func newResume() *cobra.Command {
 	const (
-		long = `The APPS RESUME command will restart a previously suspended application.
-The application will resume with its original region pool and a min count of one
-meaning there will be one running instance once restarted. Use SCALE SET MIN= to raise
-the number of configured instances.
+		long = `The APPS RESUME command starts the machines of an application paused with
+the APPS PAUSE command that were running when it was paused, and lets requests
+start the others again. Use --org instead of an app name to resume every
+paused application of an organization.
+
+Apps v1 applications that were suspended resume with their original region pool
+and a min count of one, meaning there will be one running instance once restarted.
+Use SCALE SET MIN= to raise the number of configured instances.
 `
-		short = "Resume an application"
-		usage = "resume <APPNAME>"
+		short = "Resume a paused application"
+		usage = "resume [<APPNAME>]"
 	)
 
 	resume := command.New(usage, short, long, RunResume,
 		command.RequireSession)
 
-	resume.Hidden = true
-	resume.Args = cobra.ExactArgs(1)
+	resume.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(resume,
+		flag.Org(),
+		flag.Yes(),
+	)
 
 	return resume
 }
 
 // TODO: make internal once the resume package is removed
-func RunResume(ctx context.Context) (err error) {
+func RunResume(ctx context.Context) error {
+	apps, err := targetApps(ctx, "Resume")
+	if err != nil {
+		return err
+	}
+
+	for _, app := range apps {
+		if app.PlatformVersion != "machines" {
+			if err := resumeNomadApp(ctx, app.Name); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := resumeMachinesApp(ctx, app); err != nil {
+			return fmt.Errorf("failed resuming %s: %w", app.Name, err)
+		}
+	}
+
+	return nil
+}
+
+func resumeNomadApp(ctx context.Context, appName string) (err error) {
 	io := iostreams.FromContext(ctx)
 
-	fmt.Fprintf(io.ErrOut, "Warning: this command is deprecated. Only use it if you have a previously suspended app. Use 'fly scale count 0' if you need to stop an app temporarily.\n")
-	appName := flag.FirstArg(ctx)
+	fmt.Fprintf(io.ErrOut, "Warning: resuming Apps v1 applications is deprecated. Only use it if you have a previously suspended app. Use 'fly scale count 0' if you need to stop an app temporarily.\n")
 
 	client := client.FromContext(ctx).API()
 
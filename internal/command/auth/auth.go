@@ -5,6 +5,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"os"
 	"time"
 
 	"github.com/azazeal/pause"
@@ -19,6 +20,8 @@ import (
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/env"
+	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/logger"
 	"github.com/superfly/flyctl/internal/state"
 )
@@ -42,6 +45,7 @@ If you do have and account, begin with the AUTH LOGIN subcommand.
 		newDocker(),
 		newLogout(),
 		newSignup(),
+		newRefresh(),
 	)
 
 	return auth
@@ -53,6 +57,12 @@ func runWebLogin(ctx context.Context, signup bool) error {
 		return err
 	}
 
+	return completeWebLogin(ctx, auth)
+}
+
+// completeWebLogin opens the URL of a started CLI session in a browser, and
+// waits for the session to be approved to persist its access token.
+func completeWebLogin(ctx context.Context, auth api.CLISessionAuth) error {
 	io := iostreams.FromContext(ctx)
 	if err := open.Run(auth.AuthURL); err != nil {
 		fmt.Fprintf(io.ErrOut,
@@ -125,9 +135,23 @@ func waitForCLISession(parent context.Context, logger *logger.Logger, w io.Write
 func persistAccessToken(ctx context.Context, token string) (err error) {
 	path := state.ConfigFile(ctx)
 
-	if err = config.SetAccessToken(path, token); err != nil {
+	var inKeychain bool
+	if inKeychain, err = config.SetAccessToken(path, token); err != nil {
 		err = fmt.Errorf("failed persisting %s in %s: %w\n",
 			config.AccessTokenFileKey, path, err)
+
+		return
+	}
+
+	if !inKeychain && !env.IsTruthy(config.NoKeychainEnvKey) {
+		fmt.Fprintf(iostreams.FromContext(ctx).ErrOut,
+			"The OS keychain isn't available, so the access token was stored in %s\n", path)
+	}
+
+	if path := flag.GetString(ctx, "token-output-file"); path != "" {
+		if err = os.WriteFile(path, []byte(token+"\n"), 0o600); err != nil {
+			err = fmt.Errorf("failed writing access token to %s: %w", path, err)
+		}
 	}
 
 	return
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/azazeal/pause"
+	"github.com/skip2/go-qrcode"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/logger"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+const (
+	// deviceLoginTimeout is how long a headless login waits for the session
+	// to be approved.
+	deviceLoginTimeout = 15 * time.Minute
+
+	// deviceLoginStatusInterval is the interval between the status lines a
+	// headless login prints while it waits.
+	deviceLoginStatusInterval = 30 * time.Second
+)
+
+// runDeviceLogin logs in without opening a browser: the login URL is shown as
+// text and as a QR code, to be opened on any other device, while the session
+// is polled for until it's approved or expires.
+func runDeviceLogin(ctx context.Context) error {
+	auth, err := api.StartCLISessionWebAuth(state.Hostname(ctx), false)
+	if err != nil {
+		return err
+	}
+
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		logger   = logger.FromContext(ctx)
+	)
+
+	fmt.Fprintf(io.ErrOut, "To log in, open the following URL on any device with a browser:\n\n  %s\n\n", colorize.Bold(auth.AuthURL))
+
+	if code, err := qrcode.New(auth.AuthURL, qrcode.Low); err != nil {
+		logger.Debugf("failed encoding login URL as a QR code: %v", err)
+	} else {
+		fmt.Fprintln(io.ErrOut, "Or scan this QR code:")
+		fmt.Fprintln(io.ErrOut)
+		if err := renderQRCode(io.ErrOut, code); err != nil {
+			return err
+		}
+		fmt.Fprintln(io.ErrOut)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, deviceLoginTimeout)
+	defer cancel()
+
+	var (
+		token      string
+		expiresAt  = time.Now().Add(deviceLoginTimeout)
+		lastStatus time.Time
+	)
+	for token == "" {
+		if time.Since(lastStatus) >= deviceLoginStatusInterval {
+			lastStatus = time.Now()
+			fmt.Fprintf(io.ErrOut, "Waiting for the login to be approved (expires in %s)...\n",
+				time.Until(expiresAt).Round(time.Second))
+		}
+
+		if token, err = api.GetAccessTokenForCLISession(ctx, auth.ID); err != nil {
+			logger.Debugf("failed retrieving token: %v", err)
+		}
+
+		if token == "" {
+			if pause.For(ctx, time.Second); ctx.Err() != nil {
+				break
+			}
+		}
+	}
+
+	if token == "" {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return errors.New("Login expired, please try again")
+		}
+		return errors.New("failed to log in, please try again")
+	}
+
+	fmt.Fprintln(io.ErrOut, "Login approved")
+
+	if err := persistAccessToken(ctx, token); err != nil {
+		return err
+	}
+
+	user, err := client.FromToken(token).API().GetCurrentUser(ctx)
+	if err != nil {
+		return fmt.Errorf("failed retrieving current user: %w", err)
+	}
+
+	fmt.Fprintf(io.Out, "successfully logged in as %s\n", colorize.Bold(user.Email))
+
+	return nil
+}
+
+// renderQRCode writes code to w using half block characters, two rows of
+// modules per line. Colors are set explicitly, so the code scans on light and
+// dark terminals alike.
+func renderQRCode(w io.Writer, code *qrcode.QRCode) error {
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSuffix(code.ToSmallString(true), "\n"), "\n") {
+		b.WriteString("\x1b[30;47m" + line + "\x1b[0m\n")
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
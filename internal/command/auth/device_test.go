@@ -0,0 +1,28 @@
+package auth
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/skip2/go-qrcode"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderQRCode(t *testing.T) {
+	code, err := qrcode.New("https://fly.io/app/auth/cli/0123456789abcdef0123456789abcdef", qrcode.Low)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	require.NoError(t, renderQRCode(&buf, code))
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	size := len(code.Bitmap())
+	assert.Len(t, lines, (size+1)/2)
+	for _, line := range lines {
+		assert.True(t, strings.HasPrefix(line, "\x1b[30;47m"))
+		assert.True(t, strings.HasSuffix(line, "\x1b[0m"))
+		assert.Equal(t, size, len([]rune(strings.TrimSuffix(strings.TrimPrefix(line, "\x1b[30;47m"), "\x1b[0m"))))
+	}
+}
@@ -17,6 +17,10 @@ func newLogin() *cobra.Command {
 		long = `Logs a user into the Fly platform. Supports browser-based,
 email/password and one-time-password authentication. Defaults to using
 browser-based authentication.
+
+With --headless no browser is opened; the login URL is printed along with a
+QR code instead, to be opened on any other device. Use it to log in from
+remote shells and machines without a browser.
 `
 		short = "Log in a user"
 	)
@@ -41,6 +45,14 @@ browser-based authentication.
 			Name:        "otp",
 			Description: "One time password",
 		},
+		flag.Bool{
+			Name:        "headless",
+			Description: "Log in from another device, by printing the login URL and a QR code of it instead of opening a browser",
+		},
+		flag.String{
+			Name:        "token-output-file",
+			Description: "Also write the access token to this file, readable only by the current user",
+		},
 	)
 
 	return cmd
@@ -57,6 +69,8 @@ func runLogin(ctx context.Context) error {
 	switch {
 	case interactive, email != "", password != "", otp != "":
 		return runShellLogin(ctx, email, password, otp)
+	case flag.GetBool(ctx, "headless"):
+		return runDeviceLogin(ctx)
 	default:
 		return runWebLogin(ctx, false)
 	}
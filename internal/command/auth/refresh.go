@@ -0,0 +1,45 @@
+package auth
+
+import (
+	"context"
+	"errors"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/state"
+)
+
+func newRefresh() *cobra.Command {
+	const (
+		long = `Signs in again through the single sign-on provider of an organization
+which enforces SSO, once the SSO session has expired. The SSO login page is
+opened in a browser, and the new access token replaces the current one.
+`
+		short = "Sign in again through the SSO provider of an organization"
+	)
+
+	cmd := command.New("refresh", short, long, runRefresh)
+
+	flag.Add(cmd,
+		flag.Org(),
+	)
+
+	return cmd
+}
+
+func runRefresh(ctx context.Context) error {
+	org := flag.GetOrg(ctx)
+	if org == "" {
+		return errors.New("the organization enforcing SSO must be specified with --org")
+	}
+
+	auth, err := api.StartCLISessionSSOAuth(state.Hostname(ctx), org)
+	if err != nil {
+		return err
+	}
+
+	return completeWebLogin(ctx, auth)
+}
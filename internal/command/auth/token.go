@@ -2,6 +2,7 @@ package auth
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	"github.com/spf13/cobra"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/render"
 )
 
@@ -18,18 +20,33 @@ func newToken() *cobra.Command {
 		long = `Shows the authentication token that is currently in use.
 This can be used as an authentication token with API services,
 independent of flyctl.
+
+Tokens stored in the OS keychain are only shown with --reveal.
 `
 		short = "Show the current auth token"
 	)
 
-	return command.New("token", short, long, runAuthToken,
+	cmd := command.New("token", short, long, runAuthToken,
 		command.RequireSession)
+
+	flag.Add(cmd,
+		flag.Bool{
+			Name:        "reveal",
+			Description: "Show the token even though it's stored in the OS keychain",
+		},
+	)
+
+	return cmd
 }
 
 func runAuthToken(ctx context.Context) error {
 	cfg := config.FromContext(ctx)
 	token := cfg.AccessToken
 
+	if cfg.AccessTokenFromKeychain() && !flag.GetBool(ctx, "reveal") {
+		return errors.New("the access token is stored in the OS keychain; pass --reveal to show it")
+	}
+
 	if io := iostreams.FromContext(ctx); cfg.JSONOutput {
 		render.JSON(io.Out, map[string]string{"token": token})
 	} else {
@@ -0,0 +1,191 @@
+// Package autoscale implements the autoscale command chain.
+package autoscale
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+)
+
+// New initializes and returns a new autoscale command.
+func New() *cobra.Command {
+	const (
+		short = "Autoscaling app resources"
+		long  = `Autoscaling application resources.
+
+The machines of Apps v2 are autoscaled by the proxy, which stops idle ones and
+starts them again as load grows. Their policies are kept in the [[autoscaling]]
+sections of fly.toml, per process group, and applied by fly deploy.`
+	)
+
+	cmd := command.New("autoscale", short, long, nil)
+
+	cmd.AddCommand(
+		newShow(),
+		newSet(),
+		newDisable(),
+	)
+
+	return cmd
+}
+
+func newShow() *cobra.Command {
+	const (
+		short = "Show current autoscaling configuration"
+		long  = `Show current autoscaling configuration. For Apps v2, the policies of each
+process group and region in fly.toml.`
+	)
+
+	cmd := command.New("show", short, long, runShow,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+func newSet() *cobra.Command {
+	const (
+		short = "Set app autoscaling parameters"
+		long  = `Enable autoscaling and set the application's autoscaling parameters:
+
+min=int - minimum number of machines, or instances for V1 apps, to keep running.
+max=int - maximum number of machines, or instances for V1 apps, to run.
+
+For Apps v2 the parameters apply per region, to the process group given with
+--process-group, every group otherwise. They're written to fly.toml, and
+applied by the next deploy. These are supported as well:
+
+soft_limit=int     - concurrency past which the proxy starts more machines.
+hard_limit=int     - concurrency past which a machine gets no more requests.
+scale_to_zero=bool - whether every machine may be stopped, allowing min=0.
+
+With --region, min and max are only set in that region, which the group is
+deployed to as well.`
+		usage = "set [min=int] [max=int] [soft_limit=int] [hard_limit=int] [scale_to_zero=bool]"
+	)
+
+	cmd := command.New(usage, short, long, runSet,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+	cmd.Args = cobra.MinimumNArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		processGroupFlag(),
+		regionFlag(),
+	)
+
+	return cmd
+}
+
+func newDisable() *cobra.Command {
+	const (
+		short = "Disable autoscaling"
+		long  = `Disable autoscaling to manually controlling app resources. For Apps v2, the
+autoscaling policy of the process group given with --process-group, or the one
+of every group otherwise, is removed from fly.toml, or only its overrides in
+the region given with --region.`
+	)
+
+	cmd := command.New("disable", short, long, runDisable,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		processGroupFlag(),
+		regionFlag(),
+	)
+
+	return cmd
+}
+
+func processGroupFlag() flag.String {
+	return flag.String{
+		Name:        "process-group",
+		Description: "The process group of the policy, every group when left out (Apps v2 only)",
+	}
+}
+
+func regionFlag() flag.String {
+	return flag.String{
+		Name:        "region",
+		Shorthand:   "r",
+		Description: "The region the bounds of the policy apply to, every region when left out (Apps v2 only)",
+	}
+}
+
+func runShow(ctx context.Context) error {
+	app, err := appCompact(ctx)
+	if err != nil {
+		return err
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return showNomad(ctx)
+	}
+	return showMachines(ctx)
+}
+
+func runSet(ctx context.Context) error {
+	params, err := parseParams(flag.Args(ctx))
+	if err != nil {
+		return err
+	}
+
+	app, err := appCompact(ctx)
+	if err != nil {
+		return err
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return setNomad(ctx, params)
+	}
+	return setMachines(ctx, params)
+}
+
+func runDisable(ctx context.Context) error {
+	app, err := appCompact(ctx)
+	if err != nil {
+		return err
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return disableNomad(ctx)
+	}
+	return disableMachines(ctx)
+}
+
+func appCompact(ctx context.Context) (*api.AppCompact, error) {
+	return client.FromContext(ctx).API().GetAppCompact(ctx, appconfig.NameFromContext(ctx))
+}
+
+// parseParams parses NAME=VALUE pairs, keyed by their lowercased name.
+func parseParams(args []string) (map[string]string, error) {
+	params := make(map[string]string, len(args))
+	for _, arg := range args {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, fmt.Errorf("scale parameters must be provided as NAME=VALUE pairs (%s is invalid)", arg)
+		}
+		params[strings.ToLower(name)] = value
+	}
+	return params, nil
+}
@@ -0,0 +1,338 @@
+package autoscale
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// localConfig returns the local fly.toml of the app, which autoscaling
+// policies of Apps v2 are kept in.
+func localConfig(ctx context.Context) (*appconfig.Config, error) {
+	appName := appconfig.NameFromContext(ctx)
+
+	cfg := appconfig.ConfigFromContext(ctx)
+	if cfg == nil || cfg.AppName != appName || cfg.ConfigFilePath() == "" {
+		return nil, fmt.Errorf("no fly.toml of %s found, which the autoscaling policies of Apps v2 are kept in; fetch it with fly config save -a %s", appName, appName)
+	}
+	return cfg, nil
+}
+
+func showMachines(ctx context.Context) error {
+	cfg, err := localConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	out := iostreams.FromContext(ctx).Out
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, cfg.Autoscaling)
+	}
+
+	processConfigs, err := cfg.GetProcessConfigs()
+	if err != nil {
+		return err
+	}
+
+	groups := lo.Keys(processConfigs)
+	sort.Strings(groups)
+
+	var rows [][]string
+	for _, group := range groups {
+		bounds := processConfigs[group].Autoscaling
+		if bounds == nil {
+			rows = append(rows, []string{group, "", "", "", "disabled", "", ""})
+			continue
+		}
+		section := sectionOf(cfg, group)
+
+		regions := lo.Keys(bounds)
+		sort.Strings(regions)
+		for _, region := range regions {
+			b := bounds[region]
+			rows = append(rows, []string{
+				group,
+				lo.Ternary(region == "", "(primary)", region),
+				strconv.Itoa(b.Min),
+				strconv.Itoa(b.Max),
+				lo.Ternary(section.ScaleToZero, "yes", "no"),
+				limit(section.SoftLimit),
+				limit(section.HardLimit),
+			})
+		}
+	}
+
+	return render.Table(out, "", rows, "Process Group", "Region", "Min", "Max", "Scale To Zero", "Soft Limit", "Hard Limit")
+}
+
+func limit(n int) string {
+	if n == 0 {
+		return "-"
+	}
+	return strconv.Itoa(n)
+}
+
+func setMachines(ctx context.Context, params map[string]string) error {
+	cfg, err := localConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	group := flag.GetString(ctx, "process-group")
+	if err := checkGroup(cfg, group); err != nil {
+		return err
+	}
+
+	section := ownSection(cfg, group)
+	if err := applyParams(section, flag.GetString(ctx, "region"), params); err != nil {
+		return err
+	}
+
+	return save(ctx, cfg)
+}
+
+func disableMachines(ctx context.Context) error {
+	cfg, err := localConfig(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		group  = flag.GetString(ctx, "process-group")
+		region = flag.GetString(ctx, "region")
+	)
+
+	if err := checkGroup(cfg, group); err != nil {
+		return err
+	}
+
+	if !removePolicy(cfg, group, region) {
+		if group != "" && region == "" && sectionOf(cfg, group) != nil {
+			return fmt.Errorf("process group %s is scaled by the autoscaling policy of every group, disable that one by leaving out --process-group", group)
+		}
+		return fmt.Errorf("fly.toml has no autoscaling policy to disable for %s", describe(group, region))
+	}
+
+	return save(ctx, cfg)
+}
+
+// checkGroup fails unless group is empty or one of the process groups of cfg.
+func checkGroup(cfg *appconfig.Config, group string) error {
+	if group == "" {
+		return nil
+	}
+	processConfigs, err := cfg.GetProcessConfigs()
+	if err != nil {
+		return err
+	}
+	if _, ok := processConfigs[group]; !ok {
+		groups := lo.Keys(processConfigs)
+		sort.Strings(groups)
+		return fmt.Errorf("process group %s isn't defined in fly.toml, which has %s", group, strings.Join(groups, ", "))
+	}
+	return nil
+}
+
+// save validates cfg, and writes it to its fly.toml.
+func save(ctx context.Context, cfg *appconfig.Config) error {
+	if _, err := cfg.GetProcessConfigs(); err != nil {
+		return err
+	}
+	if err := cfg.WriteToDisk(ctx, cfg.ConfigFilePath()); err != nil {
+		return err
+	}
+	fmt.Fprintln(iostreams.FromContext(ctx).Out, "Run fly deploy to apply the autoscaling policies")
+	return nil
+}
+
+func describe(group, region string) string {
+	s := "every process group"
+	if group != "" {
+		s = fmt.Sprintf("process group %s", group)
+	}
+	if region != "" {
+		s += " in " + region
+	}
+	return s
+}
+
+// sectionOf returns the [[autoscaling]] section group is scaled by, the one
+// naming it or else the one without processes, nil when there's neither.
+func sectionOf(cfg *appconfig.Config, group string) *appconfig.Autoscaling {
+	var fallback *appconfig.Autoscaling
+	for _, section := range cfg.Autoscaling {
+		switch {
+		case section == nil:
+		case slices.Contains(section.Processes, group):
+			return section
+		case len(section.Processes) == 0:
+			fallback = section
+		}
+	}
+	return fallback
+}
+
+// ownSection returns the [[autoscaling]] section of group alone, or of every
+// group when it's empty, adding one to cfg when there's none. A section
+// shared with other groups is split so that changing it leaves them alone,
+// and a new section of a group starts from the one it was scaled by.
+func ownSection(cfg *appconfig.Config, group string) *appconfig.Autoscaling {
+	inherited := sectionOf(cfg, group)
+
+	for _, section := range cfg.Autoscaling {
+		switch {
+		case section == nil:
+		case group == "" && len(section.Processes) == 0:
+			return section
+		case group != "" && slices.Equal(section.Processes, []string{group}):
+			return section
+		case group != "" && slices.Contains(section.Processes, group):
+			section.Processes = lo.Without(section.Processes, group)
+		}
+	}
+
+	section := &appconfig.Autoscaling{}
+	if group != "" && inherited != nil {
+		*section = copySection(inherited)
+	}
+	if group != "" {
+		section.Processes = []string{group}
+	}
+	cfg.Autoscaling = append(cfg.Autoscaling, section)
+	return section
+}
+
+func copySection(section *appconfig.Autoscaling) appconfig.Autoscaling {
+	c := *section
+	c.Processes = nil
+	if section.Regions != nil {
+		c.Regions = make(map[string]*appconfig.RegionAutoscaling, len(section.Regions))
+		for region, override := range section.Regions {
+			o := *override
+			c.Regions[region] = &o
+		}
+	}
+	return c
+}
+
+// applyParams sets the parameters of set in section, min and max only in
+// region when it isn't empty.
+func applyParams(section *appconfig.Autoscaling, region string, params map[string]string) error {
+	var override *appconfig.RegionAutoscaling
+	if region != "" {
+		if section.Regions == nil {
+			section.Regions = map[string]*appconfig.RegionAutoscaling{}
+		}
+		if section.Regions[region] == nil {
+			section.Regions[region] = &appconfig.RegionAutoscaling{}
+		}
+		override = section.Regions[region]
+	}
+
+	names := lo.Keys(params)
+	sort.Strings(names)
+	if unknown := lo.Without(names, "min", "max", "soft_limit", "hard_limit", "scale_to_zero"); len(unknown) > 0 {
+		return fmt.Errorf("unrecognised parameters in command: %s", strings.Join(unknown, ", "))
+	}
+
+	for _, name := range names {
+		value := params[name]
+
+		if name == "scale_to_zero" {
+			if region != "" {
+				return fmt.Errorf("%s applies to every region, leave out --region to set it", name)
+			}
+			b, err := strconv.ParseBool(value)
+			if err != nil {
+				return fmt.Errorf("could not parse %s value %s, expected true or false", name, value)
+			}
+			section.ScaleToZero = b
+			continue
+		}
+
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return fmt.Errorf("could not parse %s value %s, expected a non-negative number", name, value)
+		}
+
+		switch name {
+		case "min":
+			if override != nil {
+				override.MinMachines = api.Pointer(n)
+			} else {
+				section.MinMachines = api.Pointer(n)
+			}
+		case "max":
+			if override != nil {
+				override.MaxMachines = n
+			} else {
+				section.MaxMachines = n
+			}
+		case "soft_limit", "hard_limit":
+			if region != "" {
+				return fmt.Errorf("%s applies to every region, leave out --region to set it", name)
+			}
+			if name == "soft_limit" {
+				section.SoftLimit = n
+			} else {
+				section.HardLimit = n
+			}
+		}
+	}
+
+	return nil
+}
+
+// removePolicy removes the [[autoscaling]] section of group, or every section
+// when it's empty, or only the overrides of the section in region. It reports
+// whether there was one.
+func removePolicy(cfg *appconfig.Config, group, region string) bool {
+	if region != "" {
+		section := sectionOf(cfg, group)
+		if group == "" {
+			section = lo.FindOrElse(cfg.Autoscaling, nil, func(s *appconfig.Autoscaling) bool { return s != nil && len(s.Processes) == 0 })
+		}
+		if section == nil || section.Regions[region] == nil {
+			return false
+		}
+		if group != "" && !slices.Equal(section.Processes, []string{group}) {
+			section = ownSection(cfg, group)
+		}
+		delete(section.Regions, region)
+		if len(section.Regions) == 0 {
+			section.Regions = nil
+		}
+		return true
+	}
+
+	removed := false
+	cfg.Autoscaling = lo.Filter(cfg.Autoscaling, func(section *appconfig.Autoscaling, _ int) bool {
+		switch {
+		case section == nil:
+			return false
+		case group == "" || slices.Equal(section.Processes, []string{group}):
+			removed = true
+			return false
+		case slices.Contains(section.Processes, group):
+			section.Processes = lo.Without(section.Processes, group)
+			removed = true
+		}
+		return true
+	})
+	if len(cfg.Autoscaling) == 0 {
+		cfg.Autoscaling = nil
+	}
+	return removed
+}
@@ -0,0 +1,85 @@
+package autoscale
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestParseParams(t *testing.T) {
+	params, err := parseParams([]string{"min=1", "MAX=5"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"min": "1", "max": "5"}, params)
+
+	_, err = parseParams([]string{"min"})
+	assert.ErrorContains(t, err, "NAME=VALUE")
+}
+
+func TestApplyParams(t *testing.T) {
+	section := &appconfig.Autoscaling{}
+	require.NoError(t, applyParams(section, "", map[string]string{
+		"min": "0", "max": "4", "soft_limit": "20", "hard_limit": "25", "scale_to_zero": "true",
+	}))
+	assert.Equal(t, &appconfig.Autoscaling{
+		MinMachines: api.Pointer(0),
+		MaxMachines: 4,
+		ScaleToZero: true,
+		SoftLimit:   20,
+		HardLimit:   25,
+	}, section)
+
+	require.NoError(t, applyParams(section, "ams", map[string]string{"min": "2"}))
+	assert.Equal(t, &appconfig.RegionAutoscaling{MinMachines: api.Pointer(2)}, section.Regions["ams"])
+	assert.Equal(t, api.Pointer(0), section.MinMachines)
+
+	assert.ErrorContains(t, applyParams(section, "ams", map[string]string{"soft_limit": "2"}), "leave out --region")
+	assert.ErrorContains(t, applyParams(section, "", map[string]string{"max": "-1"}), "non-negative")
+	assert.ErrorContains(t, applyParams(section, "", map[string]string{"burst": "x"}), "unrecognised parameters in command: burst")
+}
+
+func TestOwnSection(t *testing.T) {
+	shared := &appconfig.Autoscaling{Processes: []string{"web", "api"}, MaxMachines: 3}
+	cfg := &appconfig.Config{Autoscaling: []*appconfig.Autoscaling{shared}}
+
+	web := ownSection(cfg, "web")
+	assert.Equal(t, &appconfig.Autoscaling{Processes: []string{"web"}, MaxMachines: 3}, web)
+	assert.Equal(t, []string{"api"}, shared.Processes)
+	assert.Same(t, web, ownSection(cfg, "web"))
+
+	all := ownSection(cfg, "")
+	assert.Empty(t, all.Processes)
+	assert.Len(t, cfg.Autoscaling, 3)
+
+	all.MaxMachines = 2
+	all.Regions = map[string]*appconfig.RegionAutoscaling{"ams": {MaxMachines: 1}}
+	worker := ownSection(cfg, "worker")
+	assert.Equal(t, 2, worker.MaxMachines)
+	worker.Regions["ams"].MaxMachines = 5
+	assert.Equal(t, 1, all.Regions["ams"].MaxMachines)
+}
+
+func TestRemovePolicy(t *testing.T) {
+	cfg := &appconfig.Config{Autoscaling: []*appconfig.Autoscaling{
+		{Processes: []string{"web", "api"}, MaxMachines: 3},
+		{MaxMachines: 2, Regions: map[string]*appconfig.RegionAutoscaling{"ams": {MaxMachines: 1}}},
+	}}
+
+	assert.False(t, removePolicy(cfg, "worker", ""))
+	assert.False(t, removePolicy(cfg, "", "syd"))
+
+	assert.True(t, removePolicy(cfg, "worker", "ams"))
+	assert.Len(t, cfg.Autoscaling, 3)
+	assert.Equal(t, []string{"worker"}, cfg.Autoscaling[2].Processes)
+	assert.Nil(t, cfg.Autoscaling[2].Regions)
+	assert.NotNil(t, cfg.Autoscaling[1].Regions["ams"])
+
+	assert.True(t, removePolicy(cfg, "web", ""))
+	assert.Equal(t, []string{"api"}, cfg.Autoscaling[0].Processes)
+
+	assert.True(t, removePolicy(cfg, "", ""))
+	assert.Nil(t, cfg.Autoscaling)
+}
@@ -0,0 +1,126 @@
+package autoscale
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// failOnMachinesFlags rejects the flags which only apply to Apps v2.
+func failOnMachinesFlags(ctx context.Context) error {
+	if flag.GetString(ctx, "process-group") != "" || flag.GetString(ctx, "region") != "" {
+		return errors.New("--process-group and --region are only supported for Apps v2")
+	}
+	return nil
+}
+
+func showNomad(ctx context.Context) error {
+	cfg, err := client.FromContext(ctx).API().AppAutoscalingConfig(ctx, appconfig.NameFromContext(ctx))
+	if err != nil {
+		return err
+	}
+
+	return printNomadConfig(ctx, cfg)
+}
+
+func setNomad(ctx context.Context, params map[string]string) error {
+	if err := failOnMachinesFlags(ctx); err != nil {
+		return err
+	}
+
+	var (
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+	)
+
+	current, err := apiClient.AppAutoscalingConfig(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	input := api.UpdateAutoscaleConfigInput{
+		AppID:          appName,
+		BalanceRegions: api.BoolPointer(false),
+		MinCount:       &current.MinCount,
+		MaxCount:       &current.MaxCount,
+	}
+
+	for name, value := range params {
+		var count *int
+		switch name {
+		case "min":
+			count = input.MinCount
+		case "max":
+			count = input.MaxCount
+		default:
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("could not parse %s count value", name)
+		}
+		*count = n
+	}
+
+	if unknown := lo.Without(lo.Keys(params), "min", "max"); len(unknown) > 0 {
+		sort.Strings(unknown)
+		return fmt.Errorf("unrecognised parameters in command: %s", strings.Join(unknown, ", "))
+	}
+
+	cfg, err := apiClient.UpdateAutoscaleConfig(ctx, input)
+	if err != nil {
+		return err
+	}
+
+	return printNomadConfig(ctx, cfg)
+}
+
+func disableNomad(ctx context.Context) error {
+	if err := failOnMachinesFlags(ctx); err != nil {
+		return err
+	}
+
+	cfg, err := client.FromContext(ctx).API().UpdateAutoscaleConfig(ctx, api.UpdateAutoscaleConfigInput{
+		AppID:   appconfig.NameFromContext(ctx),
+		Enabled: api.BoolPointer(false),
+	})
+	if err != nil {
+		return err
+	}
+
+	return printNomadConfig(ctx, cfg)
+}
+
+func printNomadConfig(ctx context.Context, cfg *api.AutoscalingConfig) error {
+	out := iostreams.FromContext(ctx).Out
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, cfg)
+	}
+
+	mode := "Disabled"
+	if cfg.Enabled {
+		mode = "Enabled"
+	}
+
+	fmt.Fprintf(out, "%15s: %s\n", "Autoscaling", mode)
+	if cfg.Enabled {
+		fmt.Fprintf(out, "%15s: %d\n", "Min Count", cfg.MinCount)
+		fmt.Fprintf(out, "%15s: %d\n", "Max Count", cfg.MaxCount)
+	}
+
+	return nil
+}
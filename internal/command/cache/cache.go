@@ -0,0 +1,126 @@
+// Package cache implements the cache command chain.
+package cache
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	statecache "github.com/superfly/flyctl/internal/cache"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/state"
+)
+
+// New initializes and returns a new cache Command.
+func New() (cmd *cobra.Command) {
+	const (
+		short = "Inspect and prune the local caches"
+		long  = `The CACHE commands report the size of the local caches of flyctl and prune
+them: cached API responses, downloaded build tools, agent logs, and the build
+contexts and template checkouts interrupted commands leave behind.
+
+The caches live in ~/.fly unless --cache-dir or FLY_CACHE_DIR point elsewhere,
+such as a disk CI runners share. Agent logs stay next to the agent in ~/.fly,
+and leftovers in the temporary directory.`
+	)
+	cmd = command.New("cache", short, long, nil)
+
+	cmd.AddCommand(
+		newList(),
+		newPrune(),
+	)
+	return
+}
+
+// location is one of the local caches, made of the files matching pattern.
+type location struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Pattern     string `json:"pattern"`
+	Size        int64  `json:"size"`
+
+	paths []string
+}
+
+// locations returns the local caches, sized.
+func locations(ctx context.Context) ([]*location, error) {
+	var (
+		cacheDir  = state.CacheDirectory(ctx)
+		configDir = state.ConfigDirectory(ctx)
+		tempDir   = os.TempDir()
+	)
+
+	locations := []*location{
+		{
+			Name:        "api",
+			Description: "Cached API responses, such as the latest release of flyctl",
+			Pattern:     filepath.Join(cacheDir, statecache.FileName),
+		},
+		{
+			Name:        "tools",
+			Description: "Build tools downloaded on demand, such as nixpacks",
+			Pattern:     filepath.Join(cacheDir, "bin"),
+		},
+		{
+			Name:        "agent",
+			Description: "Logs of the agents started",
+			Pattern:     filepath.Join(configDir, "agent-logs"),
+		},
+		{
+			Name:        "build",
+			Description: "Build contexts of interrupted builds",
+			Pattern:     filepath.Join(tempDir, "flyctl-dockerfile*"),
+		},
+		{
+			Name:        "templates",
+			Description: "Template checkouts of interrupted commands",
+			Pattern:     filepath.Join(tempDir, "flyctl-templates-*"),
+		},
+	}
+
+	for _, l := range locations {
+		paths, err := filepath.Glob(l.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		sort.Strings(paths)
+		l.paths = paths
+
+		for _, path := range paths {
+			size, err := diskUsage(path)
+			if err != nil {
+				return nil, err
+			}
+			l.Size += size
+		}
+	}
+
+	return locations, nil
+}
+
+// diskUsage returns the size of the files at or under path.
+func diskUsage(path string) (size int64, err error) {
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			return nil
+		case err != nil:
+			return err
+		case d.IsDir():
+			return nil
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += fi.Size()
+		return nil
+	})
+	return
+}
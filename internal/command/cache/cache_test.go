@@ -0,0 +1,59 @@
+package cache
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/internal/state"
+)
+
+func TestLocations(t *testing.T) {
+	var (
+		configDir = t.TempDir()
+		cacheDir  = t.TempDir()
+		tempDir   = t.TempDir()
+	)
+	t.Setenv("TMPDIR", tempDir)
+
+	write := func(path string, size int) {
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o700))
+		require.NoError(t, os.WriteFile(path, make([]byte, size), 0o600))
+	}
+	write(filepath.Join(cacheDir, "state.yml"), 10)
+	write(filepath.Join(cacheDir, "bin", "nixpacks"), 100)
+	write(filepath.Join(configDir, "agent-logs", "1.log"), 20)
+	write(filepath.Join(configDir, "agent-logs", "2.log"), 30)
+	write(filepath.Join(tempDir, "flyctl-dockerfile123", "Dockerfile"), 5)
+	// not a cache, config stays in the config directory
+	write(filepath.Join(configDir, "config.yml"), 1000)
+
+	ctx := state.WithConfigDirectory(context.Background(), configDir)
+	ctx = state.WithCacheDirectory(ctx, cacheDir)
+
+	locations, err := locations(ctx)
+	require.NoError(t, err)
+
+	sizes := map[string]int64{}
+	for _, l := range locations {
+		sizes[l.Name] = l.Size
+	}
+	assert.Equal(t, map[string]int64{"api": 10, "tools": 100, "agent": 50, "build": 5, "templates": 0}, sizes)
+
+	selected, err := selectLocations(locations, []string{"tools", "build", "tools"})
+	require.NoError(t, err)
+	require.Len(t, selected, 2)
+	assert.Equal(t, "tools", selected[0].Name)
+	assert.Equal(t, []string{filepath.Join(tempDir, "flyctl-dockerfile123")}, selected[1].paths)
+
+	all, err := selectLocations(locations, nil)
+	require.NoError(t, err)
+	assert.Len(t, all, len(locations))
+
+	_, err = selectLocations(locations, []string{"docker"})
+	assert.EqualError(t, err, "unknown cache docker, pick among api, tools, agent, build, templates")
+}
@@ -0,0 +1,53 @@
+package cache
+
+import (
+	"context"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newList() (cmd *cobra.Command) {
+	const (
+		short = "List the local caches and their size"
+		long  = short + "\n"
+	)
+
+	cmd = command.New("list", short, long, runList)
+	cmd.Args = cobra.NoArgs
+	cmd.Aliases = []string{"ls"}
+
+	return
+}
+
+func runList(ctx context.Context) error {
+	locations, err := locations(ctx)
+	if err != nil {
+		return err
+	}
+
+	out := iostreams.FromContext(ctx).Out
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, locations)
+	}
+
+	var total int64
+	rows := make([][]string, 0, len(locations))
+	for _, l := range locations {
+		rows = append(rows, []string{
+			l.Name,
+			humanize.Bytes(uint64(l.Size)),
+			l.Pattern,
+			l.Description,
+		})
+		total += l.Size
+	}
+	rows = append(rows, []string{"total", humanize.Bytes(uint64(total)), "", ""})
+
+	return render.Table(out, "", rows, "Name", "Size", "Path", "Description")
+}
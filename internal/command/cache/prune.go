@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newPrune() (cmd *cobra.Command) {
+	const (
+		short = "Delete the local caches"
+		long  = `Delete the local caches named, or all of them. Caches are refilled as
+commands need them again, so build tools are downloaded again.`
+		usage = "prune [name...]"
+	)
+
+	cmd = command.New(usage, short, long, runPrune)
+	cmd.Args = cobra.ArbitraryArgs
+
+	flag.Add(cmd, flag.Yes())
+
+	return
+}
+
+func runPrune(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	locations, err := locations(ctx)
+	if err != nil {
+		return err
+	}
+
+	pruned, err := selectLocations(locations, flag.Args(ctx))
+	if err != nil {
+		return err
+	}
+
+	size := lo.SumBy(pruned, func(l *location) int64 { return l.Size })
+	if size == 0 {
+		fmt.Fprintln(io.Out, "Nothing to prune")
+		return nil
+	}
+
+	if !flag.GetYes(ctx) {
+		names := lo.Map(pruned, func(l *location, _ int) string { return l.Name })
+		switch confirmed, err := prompt.Confirmf(ctx, "Delete %s of caches (%s)?", humanize.Bytes(uint64(size)), strings.Join(names, ", ")); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	for _, l := range pruned {
+		for _, path := range l.paths {
+			if err := os.RemoveAll(path); err != nil {
+				return fmt.Errorf("failed pruning the %s cache: %w", l.Name, err)
+			}
+		}
+	}
+
+	fmt.Fprintf(io.Out, "Pruned %s of caches\n", humanize.Bytes(uint64(size)))
+	return nil
+}
+
+// selectLocations returns the locations named, or all of them when no names
+// are given.
+func selectLocations(locations []*location, names []string) ([]*location, error) {
+	if len(names) == 0 {
+		return locations, nil
+	}
+
+	selected := make([]*location, 0, len(names))
+	for _, name := range lo.Uniq(names) {
+		l, ok := lo.Find(locations, func(l *location) bool { return l.Name == name })
+		if !ok {
+			known := lo.Map(locations, func(l *location, _ int) string { return l.Name })
+			return nil, fmt.Errorf("unknown cache %s, pick among %s", name, strings.Join(known, ", "))
+		}
+		selected = append(selected, l)
+	}
+	return selected, nil
+}
@@ -9,6 +9,7 @@ import (
 	"os"
 	"path/filepath"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/blang/semver"
@@ -18,17 +19,21 @@ import (
 	"github.com/superfly/flyctl/iostreams"
 
 	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flyctl"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/buildinfo"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/env"
 	"github.com/superfly/flyctl/internal/logger"
+	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/internal/update"
 
 	"github.com/superfly/flyctl/internal/cache"
+	"github.com/superfly/flyctl/internal/cmdutil"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/state"
 	"github.com/superfly/flyctl/internal/task"
+	"github.com/superfly/flyctl/internal/transport"
 )
 
 type (
@@ -52,12 +57,16 @@ func New(usage, short, long string, fn Runner, p ...Preparer) *cobra.Command {
 var commonPreparers = []Preparer{
 	determineHostname,
 	determineWorkingDir,
+	applyFlagDefaults,
 	determineUserHomeDir,
 	determineConfigDir,
 	ensureConfigDirExists,
 	ensureConfigDirPerms,
+	determineCacheDir,
+	configureTransport,
 	loadCache,
 	loadConfig,
+	loadAnswersFile,
 	initTaskManager,
 	startQueryingForNewRelease,
 	promptToUpdate,
@@ -134,7 +143,7 @@ func finalize(ctx context.Context) {
 
 	// flush the cache to disk if required
 	if c := cache.FromContext(ctx); c.Dirty() {
-		path := filepath.Join(state.ConfigDirectory(ctx), cache.FileName)
+		path := filepath.Join(state.CacheDirectory(ctx), cache.FileName)
 
 		if err := c.Save(path); err != nil {
 			logger.FromContext(ctx).
@@ -239,10 +248,74 @@ func ensureConfigDirPerms(parent context.Context) (ctx context.Context, err erro
 	return
 }
 
+// cacheDirEnvKey is the environment variable the cache directory may be set
+// with instead of --cache-dir.
+const cacheDirEnvKey = "FLY_CACHE_DIR"
+
+// determineCacheDir determines the directory of the local caches, the config
+// directory unless --cache-dir or FLY_CACHE_DIR point elsewhere.
+func determineCacheDir(ctx context.Context) (context.Context, error) {
+	dir := flag.GetString(ctx, flag.CacheDirName)
+	if dir == "" {
+		dir = env.First(cacheDirEnvKey)
+	}
+
+	if dir == "" {
+		dir = state.ConfigDirectory(ctx)
+	} else {
+		var err error
+		if dir, err = filepath.Abs(dir); err != nil {
+			return nil, fmt.Errorf("failed determining cache directory: %w", err)
+		}
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed creating cache directory: %w", err)
+		}
+	}
+
+	// the legacy code paths, such as those of the builders, locate their
+	// caches on their own
+	flyctl.SetCacheDir(dir)
+
+	logger.FromContext(ctx).
+		Debugf("determined cache directory: %q", dir)
+
+	return state.WithCacheDirectory(ctx, dir), nil
+}
+
+// configureTransport makes flyctl trust the CA bundle --ca-bundle or
+// FLY_CA_BUNDLE point to, if any, such as that of a TLS intercepting proxy.
+func configureTransport(ctx context.Context) (context.Context, error) {
+	path := flag.GetString(ctx, flag.CABundleName)
+	if path == "" {
+		path = env.First(transport.CABundleEnvKey)
+	}
+	if path == "" {
+		return ctx, nil
+	}
+
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed determining CA bundle path: %w", err)
+	}
+	if err := transport.UseCABundle(path); err != nil {
+		return nil, err
+	}
+
+	// the processes flyctl starts, such as the agent, trust it as well
+	if err := os.Setenv(transport.CABundleEnvKey, path); err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).
+		Debugf("trusting CA bundle: %q", path)
+
+	return ctx, nil
+}
+
 func loadCache(ctx context.Context) (context.Context, error) {
 	logger := logger.FromContext(ctx)
 
-	path := filepath.Join(state.ConfigDirectory(ctx), cache.FileName)
+	path := filepath.Join(state.CacheDirectory(ctx), cache.FileName)
 
 	c, err := cache.Load(path)
 	if err != nil {
@@ -280,6 +353,24 @@ func loadConfig(ctx context.Context) (context.Context, error) {
 	return config.NewContext(ctx, cfg), nil
 }
 
+// loadAnswersFile loads the answers file of commands which accept one, so
+// that their prompts can be answered without a terminal.
+func loadAnswersFile(ctx context.Context) (context.Context, error) {
+	path := flag.GetString(ctx, flag.AnswersFileName)
+	if path == "" {
+		return ctx, nil
+	}
+
+	answers, err := prompt.LoadAnswers(path)
+	if err != nil {
+		return nil, err
+	}
+
+	logger.FromContext(ctx).Debugf("answers loaded from %s.", path)
+
+	return prompt.WithAnswers(ctx, answers), nil
+}
+
 func initClient(ctx context.Context) (context.Context, error) {
 	logger := logger.FromContext(ctx)
 	cfg := config.FromContext(ctx)
@@ -387,6 +478,47 @@ func promptToUpdate(ctx context.Context) (context.Context, error) {
 	return ctx, nil
 }
 
+// compatibilityCheckInterval is how long RequireCompatibleVersion trusts the
+// cached minimum versions for before querying them again.
+const compatibilityCheckInterval = 24 * time.Hour
+
+// RequireCompatibleVersion returns a Preparer which fails, asking to upgrade
+// flyctl, when the API no longer supports the named command, such as
+// "machine run", with this version of flyctl. It doesn't fail the command
+// when the latest release can't be queried.
+func RequireCompatibleVersion(command string) Preparer {
+	return func(ctx context.Context) (context.Context, error) {
+		if !buildinfo.IsRelease() || env.IsTruthy("FLY_NO_UPDATE_CHECK") {
+			return ctx, nil
+		}
+
+		c := cache.FromContext(ctx)
+
+		r := c.LatestRelease()
+		if r == nil || time.Since(c.LastCheckedAt()) > compatibilityCheckInterval {
+			queryCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+			defer cancel()
+
+			switch latest, err := update.LatestRelease(queryCtx, c.Channel()); {
+			case err != nil:
+				logger.FromContext(ctx).Debugf("failed querying latest release: %v", err)
+			case latest != nil:
+				c.SetLatestRelease(c.Channel(), latest)
+				r = latest
+			}
+		}
+
+		if r == nil {
+			return ctx, nil
+		}
+		if err := r.CheckCompatible(buildinfo.Version(), command); err != nil {
+			return nil, err
+		}
+
+		return ctx, nil
+	}
+}
+
 func killOldAgent(ctx context.Context) (context.Context, error) {
 	path := filepath.Join(state.ConfigDirectory(ctx), "agent.pid")
 
@@ -456,22 +588,23 @@ func LoadAppConfigIfPresent(ctx context.Context) (context.Context, error) {
 		return ctx, nil
 	}
 
+	// --app-dir picks the config file among those of a directory tree, unless
+	// --config names one outright
+	if dir := flag.GetString(ctx, flag.AppDirName); dir != "" && flag.GetAppConfigFilePath(ctx) == "" {
+		path, err := selectAppConfigBelow(ctx, dir)
+		if err != nil {
+			return nil, err
+		}
+		return useDiscoveredAppConfig(ctx, path)
+	}
+
 	logger := logger.FromContext(ctx)
 	for _, path := range appConfigFilePaths(ctx) {
-		switch cfg, err := appconfig.LoadConfig(path); {
+		switch cfg, err := loadAppConfig(ctx, path); {
 		case err == nil:
 			logger.Debugf("app config loaded from %s", path)
 
-			// Query Web API for platform version
-			platformVersion, _ := determinePlatform(ctx, cfg.AppName)
-			if platformVersion != "" {
-				err := cfg.SetPlatformVersion(platformVersion)
-				if err != nil {
-					logger.Warnf("WARNING the config file at '%s' is not valid: %s", path, err)
-				}
-			}
-
-			return appconfig.WithConfig(ctx, cfg), nil // we loaded a configuration file
+			return withAppConfig(ctx, cfg, path), nil // we loaded a configuration file
 		case errors.Is(err, fs.ErrNotExist):
 			logger.Debugf("no app config found at %s; skipped.", path)
 			continue
@@ -483,6 +616,99 @@ func LoadAppConfigIfPresent(ctx context.Context) (context.Context, error) {
 	return ctx, nil
 }
 
+// withAppConfig returns a copy of ctx carrying cfg, loaded from path, once
+// the platform of its app is known.
+func withAppConfig(ctx context.Context, cfg *appconfig.Config, path string) context.Context {
+	// Query Web API for platform version
+	platformVersion, _ := determinePlatform(ctx, cfg.AppName)
+	if platformVersion != "" {
+		err := cfg.SetPlatformVersion(platformVersion)
+		if err != nil {
+			logger.FromContext(ctx).Warnf("WARNING the config file at '%s' is not valid: %s", path, err)
+		}
+	}
+
+	return appconfig.WithConfig(ctx, cfg)
+}
+
+// useDiscoveredAppConfig loads the app config at path, found away from the
+// working directory, and makes its directory the working directory, as
+// commands expect the files of the app around their config file.
+func useDiscoveredAppConfig(ctx context.Context, path string) (context.Context, error) {
+	cfg, err := loadAppConfig(ctx, path)
+	if err != nil {
+		return nil, fmt.Errorf("failed loading app config from %s: %w", path, err)
+	}
+
+	if ctx, err = ChangeWorkingDirectory(ctx, filepath.Dir(path)); err != nil {
+		return nil, err
+	}
+
+	io := iostreams.FromContext(ctx)
+	fmt.Fprintf(io.ErrOut, "Using the app config file at %s\n", io.ColorScheme().Bold(path))
+
+	return withAppConfig(ctx, cfg, path), nil
+}
+
+// selectAppConfigBelow returns the path of the app config file of dir or of
+// one of its subdirectories, asking which one when there are several.
+func selectAppConfigBelow(ctx context.Context, dir string) (string, error) {
+	paths, err := appconfig.FindConfigFilesBelow(dir)
+	switch {
+	case err != nil:
+		return "", fmt.Errorf("failed looking for app config files in %s: %w", dir, err)
+	case len(paths) == 0:
+		return "", fmt.Errorf("found no app config file in %s nor in its subdirectories", dir)
+	case len(paths) == 1:
+		return paths[0], nil
+	}
+
+	options := make([]string, len(paths))
+	for i, path := range paths {
+		if rel, err := filepath.Rel(dir, path); err == nil {
+			options[i] = rel
+		} else {
+			options[i] = path
+		}
+	}
+
+	var index int
+	switch err := prompt.Select(ctx, &index, "Which app config file should be used?", "", options...); {
+	case prompt.IsNonInteractive(err):
+		return "", prompt.NonInteractiveError(fmt.Sprintf("%s has several app config files, pick one of %s with --config",
+			dir, strings.Join(options, ", ")))
+	case err != nil:
+		return "", err
+	}
+
+	return paths[index], nil
+}
+
+// loadAppConfig loads the app config at path as the flags of commands which
+// have them ask: with the overlay of the --env-config environment merged on
+// top, and the variables of --var replacing its references.
+func loadAppConfig(ctx context.Context, path string) (*appconfig.Config, error) {
+	// interpolating is opt-in, as references may be meant for the shell of
+	// the machines
+	var vars map[string]string
+	if flag.GetBool(ctx, "interpolate") || len(flag.GetStringSlice(ctx, "var")) > 0 {
+		var err error
+		if vars, err = cmdutil.ParseKVStringsToMap(flag.GetStringSlice(ctx, "var")); err != nil {
+			return nil, fmt.Errorf("invalid --var: %w", err)
+		}
+		if vars == nil {
+			vars = map[string]string{}
+		}
+	}
+
+	var overlayPath string
+	if env := flag.GetString(ctx, "env-config"); env != "" {
+		overlayPath = appconfig.OverlayPath(path, env)
+	}
+
+	return appconfig.LoadConfigWithOverlay(path, overlayPath, vars)
+}
+
 func determinePlatform(ctx context.Context, appName string) (string, error) {
 	client := client.FromContext(ctx)
 	if appName == "" {
@@ -500,14 +726,15 @@ func determinePlatform(ctx context.Context, appName string) (string, error) {
 // in order of preference. it takes into consideration whether the user has
 // specified a command-line path to a config file.
 func appConfigFilePaths(ctx context.Context) (paths []string) {
+	dir := state.WorkingDirectory(ctx)
 	if p := flag.GetAppConfigFilePath(ctx); p != "" {
-		paths = append(paths, p, filepath.Join(p, appconfig.DefaultConfigFileName))
-
-		return
+		paths = append(paths, p)
+		dir = p
 	}
 
-	wd := state.WorkingDirectory(ctx)
-	paths = append(paths, filepath.Join(wd, appconfig.DefaultConfigFileName))
+	for _, name := range appconfig.ConfigFileNames {
+		paths = append(paths, filepath.Join(dir, name))
+	}
 
 	return
 }
@@ -526,13 +753,23 @@ func RequireAppName(ctx context.Context) (context.Context, error) {
 	name := flag.GetApp(ctx)
 	if name == "" {
 		// if there's no flag present, first consult with the environment
-		if name = env.First("FLY_APP"); name == "" {
-			// and then with the config file (if any)
-			if cfg := appconfig.ConfigFromContext(ctx); cfg != nil {
-				name = cfg.AppName
+		name = env.First("FLY_APP")
+	}
+	if name == "" && appconfig.ConfigFromContext(ctx) == nil && flag.GetAppConfigFilePath(ctx) == "" {
+		// then look for the config file of a parent directory, such as when
+		// running from a subdirectory of the app
+		if path, ok := appconfig.FindConfigFileAbove(state.WorkingDirectory(ctx)); ok {
+			if ctx, err = useDiscoveredAppConfig(ctx, path); err != nil {
+				return nil, err
 			}
 		}
 	}
+	if name == "" {
+		// and then with the config file (if any)
+		if cfg := appconfig.ConfigFromContext(ctx); cfg != nil {
+			name = cfg.AppName
+		}
+	}
 
 	if name == "" {
 		return nil, errRequireAppName
@@ -0,0 +1,22 @@
+// Package compose implements the compose command chain.
+package compose
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+)
+
+// New initializes and returns a new compose Command.
+func New() (cmd *cobra.Command) {
+	const (
+		short = "Work with Docker Compose projects"
+		long  = `The COMPOSE commands deploy the services of a Docker Compose file to Fly apps.`
+	)
+	cmd = command.New("compose", short, long, nil)
+
+	cmd.AddCommand(
+		newDeploy(),
+	)
+	return
+}
@@ -0,0 +1,344 @@
+package compose
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy"
+	"github.com/superfly/flyctl/internal/compose"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// DefaultMappingFileName is the name of the mapping file looked up next to the
+// compose file when --mapping isn't given.
+const DefaultMappingFileName = "fly.compose.toml"
+
+func newDeploy() (cmd *cobra.Command) {
+	const (
+		short = "Deploy the services of a Docker Compose file"
+		long  = `Deploy the services of a Docker Compose file to one or more Fly apps.
+
+By default every service runs as a process group, named after the service, of
+the app given with --app. A mapping file (fly.compose.toml next to the compose
+file, or the one given with --mapping) can assign services to other apps and
+process groups, or skip them:
+
+  app = "my-stack"
+  primary_region = "ord"
+
+  [services.worker]
+  app = "my-stack-worker"
+  process = "jobs"
+
+  [services.db]
+  skip = true
+
+Services deployed to the same app must share their image or build. Every image
+is built before any app is deployed, and apps are deployed in the order
+implied by depends_on.`
+	)
+
+	cmd = command.New("deploy", short, long, runDeploy,
+		command.RequireSession,
+	)
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.RemoteOnly(false),
+		flag.LocalOnly(),
+		flag.Detach(),
+		flag.Strategy(),
+		flag.NoCache(),
+		flag.BuildOnly(),
+		deploy.MachinesFlags,
+		flag.String{
+			Name:        "file",
+			Shorthand:   "f",
+			Description: "Path to the compose file. Defaults to the compose file in the working directory.",
+		},
+		flag.String{
+			Name:        "mapping",
+			Description: "Path to the file mapping services to apps. Defaults to " + DefaultMappingFileName + " next to the compose file.",
+		},
+	)
+
+	return
+}
+
+// Mapping assigns compose services to apps and process groups.
+type Mapping struct {
+	App           string                    `toml:"app"`
+	PrimaryRegion string                    `toml:"primary_region"`
+	Services      map[string]ServiceMapping `toml:"services"`
+}
+
+// ServiceMapping assigns a single compose service.
+type ServiceMapping struct {
+	App     string `toml:"app"`
+	Process string `toml:"process"`
+	Skip    bool   `toml:"skip"`
+}
+
+// target is an app along with the services deployed to it.
+type target struct {
+	app       string
+	processes map[string]string
+	cfg       *appconfig.Config
+	img       *imgsrc.DeploymentImage
+}
+
+func runDeploy(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	path := flag.GetString(ctx, "file")
+	if path == "" {
+		path = state.WorkingDirectory(ctx)
+	}
+
+	file, err := compose.Load(path)
+	if err != nil {
+		return err
+	}
+
+	mapping, err := loadMapping(ctx, file)
+	if err != nil {
+		return err
+	}
+
+	targets, err := resolveTargets(file, mapping, flag.GetApp(ctx))
+	if err != nil {
+		return err
+	}
+
+	for _, t := range targets {
+		app, err := apiClient.GetAppCompact(ctx, t.app)
+		if err != nil {
+			return fmt.Errorf("failed retrieving app %s: %w", t.app, err)
+		}
+		if app.PlatformVersion == appconfig.NomadPlatform {
+			return fmt.Errorf("app %s runs on the nomad platform; compose deploy only supports machines apps", t.app)
+		}
+
+		var warnings []string
+		if t.cfg, warnings, err = file.AppConfig(t.app, t.processes); err != nil {
+			return err
+		}
+		for _, w := range warnings {
+			fmt.Fprintf(io.ErrOut, "%s %s\n", io.ColorScheme().Yellow("WARN"), w)
+		}
+
+		t.cfg.PrimaryRegion = mapping.PrimaryRegion
+		if err := t.cfg.SetMachinesPlatform(); err != nil {
+			return err
+		}
+	}
+
+	builds := make([]deploy.ImageBuild, len(targets))
+	for i, t := range targets {
+		builds[i] = deploy.ImageBuild{
+			App:     t.app,
+			Source:  strings.Join(sortedServices(t), ", "),
+			Context: targetContext(ctx, file, t),
+			Config:  t.cfg,
+		}
+	}
+	imgs, err := deploy.BuildImages(ctx, builds)
+	if err != nil {
+		return err
+	}
+	for i, t := range targets {
+		t.img = imgs[i]
+	}
+
+	if flag.GetBuildOnly(ctx) {
+		return nil
+	}
+
+	for _, t := range targets {
+		fmt.Fprintf(io.Out, "==> Deploying %s\n", t.app)
+
+		err := deploy.DeployWithConfig(targetContext(ctx, file, t), t.cfg, deploy.DeployWithConfigArgs{
+			ForceMachines: true,
+			ForceYes:      flag.GetBool(ctx, "auto-confirm"),
+			Image:         t.img,
+		})
+		if err != nil {
+			return fmt.Errorf("failed deploying %s: %w", t.app, err)
+		}
+	}
+
+	return nil
+}
+
+// targetContext derives the context deployments of t run with.
+func targetContext(ctx context.Context, file *compose.File, t *target) context.Context {
+	svc := file.Services[sortedServices(t)[0]]
+
+	ctx = state.WithWorkingDirectory(ctx, svc.ContextDir(file))
+	ctx = appconfig.WithName(ctx, t.app)
+	return appconfig.WithConfig(ctx, t.cfg)
+}
+
+func loadMapping(ctx context.Context, file *compose.File) (*Mapping, error) {
+	path := flag.GetString(ctx, "mapping")
+	explicit := path != ""
+	if !explicit {
+		path = filepath.Join(file.Dir(), DefaultMappingFileName)
+	}
+
+	mapping := &Mapping{}
+
+	buf, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && !explicit:
+		return mapping, nil
+	case err != nil:
+		return nil, err
+	}
+
+	if err := toml.Unmarshal(buf, mapping); err != nil {
+		return nil, fmt.Errorf("failed parsing %s: %w", path, err)
+	}
+
+	for name := range mapping.Services {
+		if _, ok := file.Services[name]; !ok {
+			return nil, fmt.Errorf("%s maps service %s, which is not defined in %s", path, name, file.Path())
+		}
+	}
+
+	return mapping, nil
+}
+
+// resolveTargets groups the services of file by the app they're deployed to,
+// and returns the apps in the order they must be deployed in.
+func resolveTargets(file *compose.File, mapping *Mapping, defaultApp string) ([]*target, error) {
+	if mapping.App != "" && defaultApp == "" {
+		defaultApp = mapping.App
+	}
+
+	order, err := file.Order()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		targets []*target
+		byApp   = map[string]*target{}
+		appOf   = map[string]string{}
+	)
+
+	for _, name := range order {
+		m := mapping.Services[name]
+		if m.Skip {
+			continue
+		}
+
+		app := m.App
+		if app == "" {
+			app = defaultApp
+		}
+		if app == "" {
+			return nil, fmt.Errorf("no app to deploy service %s to; pass one with --app or set it in the mapping file", name)
+		}
+
+		process := m.Process
+		if process == "" {
+			process = name
+		}
+
+		t, ok := byApp[app]
+		if !ok {
+			t = &target{app: app, processes: map[string]string{}}
+			byApp[app] = t
+			targets = append(targets, t)
+		}
+		for svc, group := range t.processes {
+			if group == process {
+				return nil, fmt.Errorf("services %s and %s both map to process group %s of app %s", svc, name, process, app)
+			}
+		}
+		t.processes[name] = process
+		appOf[name] = app
+	}
+
+	if len(targets) == 0 {
+		return nil, errors.New("every service is skipped; nothing to deploy")
+	}
+
+	// services come in dependency order, but an app made of several services
+	// may depend on an app which was first seen later on
+	deps := map[string]map[string]bool{}
+	for name, app := range appOf {
+		for _, dep := range file.Services[name].DependsOn {
+			if depApp, ok := appOf[dep]; ok && depApp != app {
+				if deps[app] == nil {
+					deps[app] = map[string]bool{}
+				}
+				deps[app][depApp] = true
+			}
+		}
+	}
+
+	var (
+		sorted   []*target
+		visited  = map[string]bool{}
+		visiting = map[string]bool{}
+		visit    func(*target) error
+	)
+	visit = func(t *target) error {
+		switch {
+		case visited[t.app]:
+			return nil
+		case visiting[t.app]:
+			return fmt.Errorf("circular dependency between the services of app %s and other apps", t.app)
+		}
+		visiting[t.app] = true
+		for _, candidate := range targets {
+			if deps[t.app][candidate.app] {
+				if err := visit(candidate); err != nil {
+					return err
+				}
+			}
+		}
+		visiting[t.app] = false
+		visited[t.app] = true
+		sorted = append(sorted, t)
+		return nil
+	}
+	for _, t := range targets {
+		if err := visit(t); err != nil {
+			return nil, err
+		}
+	}
+
+	return sorted, nil
+}
+
+func sortedServices(t *target) []string {
+	names := make([]string, 0, len(t.processes))
+	for name := range t.processes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
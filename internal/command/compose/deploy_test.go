@@ -0,0 +1,70 @@
+package compose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/superfly/flyctl/internal/compose"
+)
+
+func TestResolveTargets(t *testing.T) {
+	file, err := compose.Parse([]byte(`
+services:
+  web:
+    build: .
+    depends_on: [api]
+  worker:
+    build: .
+    command: bin/worker
+  api:
+    image: example/api
+    depends_on: [cache]
+  cache:
+    image: redis
+`))
+	require.NoError(t, err)
+
+	targets, err := resolveTargets(file, &Mapping{
+		Services: map[string]ServiceMapping{
+			"api":   {App: "stack-api", Process: "http"},
+			"cache": {Skip: true},
+		},
+	}, "stack")
+	require.NoError(t, err)
+
+	require.Len(t, targets, 2)
+	assert.Equal(t, "stack-api", targets[0].app)
+	assert.Equal(t, map[string]string{"api": "http"}, targets[0].processes)
+	assert.Equal(t, "stack", targets[1].app)
+	assert.Equal(t, map[string]string{"web": "web", "worker": "worker"}, targets[1].processes)
+}
+
+func TestResolveTargets_Errors(t *testing.T) {
+	file, err := compose.Parse([]byte(`
+services:
+  web:
+    image: nginx
+  worker:
+    image: nginx
+`))
+	require.NoError(t, err)
+
+	_, err = resolveTargets(file, &Mapping{}, "")
+	assert.ErrorContains(t, err, "no app to deploy service")
+
+	_, err = resolveTargets(file, &Mapping{
+		Services: map[string]ServiceMapping{
+			"worker": {Process: "web"},
+		},
+	}, "stack")
+	assert.ErrorContains(t, err, "both map to process group web")
+
+	_, err = resolveTargets(file, &Mapping{
+		Services: map[string]ServiceMapping{
+			"web":    {Skip: true},
+			"worker": {Skip: true},
+		},
+	}, "stack")
+	assert.ErrorContains(t, err, "nothing to deploy")
+}
@@ -19,6 +19,9 @@ func New() (cmd *cobra.Command) {
 		newSave(),
 		newValidate(),
 		newEnv(),
+		newDiff(),
+		newLint(),
+		newSchema(),
 	)
 	return
 }
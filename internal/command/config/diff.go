@@ -0,0 +1,209 @@
+package config
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newDiff() (cmd *cobra.Command) {
+	const (
+		short = "Show how the local config differs from the deployed app"
+		long  = `Show how the local fly.toml differs from the configuration the app was
+last deployed with, field by field, and what deploying it would change on
+each machine of the app: environment, services, mounts and guest size.
+
+Images and volumes are resolved when deploying, use fly deploy --dry-run for
+the full plan of a deployment.`
+	)
+	cmd = command.New("diff", short, long, runDiff,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+	cmd.Args = cobra.NoArgs
+	flag.Add(cmd, flag.App(), flag.AppConfig())
+	return
+}
+
+// configDiff is how a local config differs from the deployed app.
+type configDiff struct {
+	App        string
+	Definition []deploy.ConfigChange `json:",omitempty"`
+	Machines   []deploy.MachinePlan  `json:",omitempty"`
+}
+
+func runDiff(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		appName   = appconfig.NameFromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		local     = appconfig.ConfigFromContext(ctx)
+	)
+
+	if local == nil {
+		return errors.New("no fly.toml was found to diff, run this from the directory of the app or pass --config")
+	}
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("error getting app with name %s: %w", appName, err)
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	remote, err := appconfig.FromRemoteApp(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	localDefinition, err := local.ToDefinition()
+	if err != nil {
+		return err
+	}
+	remoteDefinition, err := remote.ToDefinition()
+	if err != nil {
+		return err
+	}
+
+	diff := configDiff{
+		App:        appName,
+		Definition: diffDefinitions(*remoteDefinition, *localDefinition),
+	}
+
+	if app.PlatformVersion == appconfig.MachinesPlatform {
+		machines, err := mach.ListActive(ctx)
+		if err != nil {
+			return err
+		}
+		processConfigs, err := local.GetProcessConfigs()
+		if err != nil {
+			return err
+		}
+
+		for _, m := range machines {
+			diff.Machines = append(diff.Machines, deploy.MachinePlan{
+				ID:      m.ID,
+				Group:   m.ProcessGroup(),
+				Region:  m.Region,
+				Action:  "update",
+				Changes: deploy.DiffMachineConfigs(m.Config, localMachineConfig(local, processConfigs, m)),
+			})
+		}
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, diff)
+	}
+
+	colorize := io.ColorScheme()
+
+	if len(diff.Definition) == 0 {
+		fmt.Fprintf(io.Out, "%s matches the config %s was last deployed with\n", local.ConfigFilePath(), colorize.Bold(appName))
+	} else {
+		fmt.Fprintf(io.Out, "Changes to the config %s was last deployed with:\n", colorize.Bold(appName))
+		deploy.RenderChanges(io, diff.Definition)
+	}
+
+	for _, m := range diff.Machines {
+		if len(m.Changes) == 0 {
+			continue
+		}
+		fmt.Fprintf(io.Out, "\nChanges to machine %s (%s, %s):\n", colorize.Bold(m.ID), m.Group, m.Region)
+		deploy.RenderChanges(io, m.Changes)
+	}
+
+	return nil
+}
+
+// diffDefinitions lists the changes between two app definitions by the
+// dotted path of each field, tables and arrays of tables being descended into.
+func diffDefinitions(old, new map[string]any) []deploy.ConfigChange {
+	oldFields, newFields := map[string]string{}, map[string]string{}
+	flattenDefinition("", old, oldFields)
+	flattenDefinition("", new, newFields)
+
+	fields := lo.Uniq(append(lo.Keys(oldFields), lo.Keys(newFields)...))
+	sort.Strings(fields)
+
+	var changes []deploy.ConfigChange
+	for _, field := range fields {
+		if o, n := oldFields[field], newFields[field]; o != n {
+			changes = append(changes, deploy.ConfigChange{Field: field, Old: o, New: n})
+		}
+	}
+
+	return changes
+}
+
+func flattenDefinition(path string, value any, fields map[string]string) {
+	join := func(key string) string {
+		if path == "" {
+			return key
+		}
+		return path + "." + key
+	}
+
+	switch v := value.(type) {
+	case map[string]any:
+		for key, value := range v {
+			flattenDefinition(join(key), value, fields)
+		}
+	case []map[string]any:
+		for i, value := range v {
+			flattenDefinition(fmt.Sprintf("%s[%d]", path, i), value, fields)
+		}
+	default:
+		buf, _ := json.Marshal(v)
+		fields[path] = string(buf)
+	}
+}
+
+// localMachineConfig returns the config m would be updated to from the fields
+// of the machine config that come from cfg, as fly deploy resolves them.
+func localMachineConfig(cfg *appconfig.Config, processConfigs map[string]*appconfig.ProcessConfig, m *api.Machine) *api.MachineConfig {
+	mConfig := mach.CloneConfig(m.Config)
+
+	mConfig.Env = lo.Assign(cfg.Env)
+	if mConfig.Env["PRIMARY_REGION"] == "" && m.Config.Env["PRIMARY_REGION"] != "" {
+		mConfig.Env["PRIMARY_REGION"] = m.Config.Env["PRIMARY_REGION"]
+	}
+
+	if len(mConfig.Mounts) == 1 && cfg.Mounts != nil {
+		mConfig.Mounts[0].Path = cfg.Mounts.Destination
+	}
+
+	if processConfig, ok := processConfigs[mConfig.ProcessGroup()]; ok {
+		mConfig.Services = processConfig.Services
+		mConfig.Checks = processConfig.Checks
+		if processConfig.Guest != nil {
+			guest := *processConfig.Guest
+			if mConfig.Guest != nil {
+				guest.KernelArgs = mConfig.Guest.KernelArgs
+			}
+			mConfig.Guest = &guest
+		}
+	}
+
+	return mConfig
+}
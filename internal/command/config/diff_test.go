@@ -0,0 +1,69 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command/deploy"
+)
+
+func TestDiffDefinitions(t *testing.T) {
+	old := map[string]any{
+		"app": "balloon",
+		"env": map[string]any{"LOG_LEVEL": "info", "REMOVED": "gone"},
+		"services": []map[string]any{
+			{"internal_port": int64(8080), "protocol": "tcp"},
+		},
+	}
+	new := map[string]any{
+		"app":            "balloon",
+		"primary_region": "ams",
+		"env":            map[string]any{"LOG_LEVEL": "debug"},
+		"services": []map[string]any{
+			{"internal_port": int64(8081), "protocol": "tcp"},
+		},
+	}
+
+	assert.Equal(t, []deploy.ConfigChange{
+		{Field: "env.LOG_LEVEL", Old: `"info"`, New: `"debug"`},
+		{Field: "env.REMOVED", Old: `"gone"`},
+		{Field: "primary_region", New: `"ams"`},
+		{Field: "services[0].internal_port", Old: "8080", New: "8081"},
+	}, diffDefinitions(old, new))
+
+	assert.Empty(t, diffDefinitions(old, old))
+}
+
+func TestLocalMachineConfig(t *testing.T) {
+	cfg := &appconfig.Config{
+		Env:    map[string]string{"LOG_LEVEL": "debug"},
+		Mounts: &appconfig.Volume{Source: "data", Destination: "/var/data"},
+	}
+	m := &api.Machine{
+		Config: &api.MachineConfig{
+			Image:  "super/balloon:1",
+			Env:    map[string]string{"LOG_LEVEL": "info", "PRIMARY_REGION": "ams"},
+			Mounts: []api.MachineMount{{Path: "/data", Volume: "vol_123"}},
+			Metadata: map[string]string{
+				api.MachineConfigMetadataKeyFlyProcessGroup: api.MachineProcessGroupApp,
+			},
+		},
+	}
+	processConfigs := map[string]*appconfig.ProcessConfig{
+		api.MachineProcessGroupApp: {
+			Services: []api.MachineService{{Protocol: "tcp", InternalPort: 8080}},
+			Guest:    &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 512},
+		},
+	}
+
+	assert.Equal(t, []deploy.ConfigChange{
+		{Field: "env.LOG_LEVEL", Old: "info", New: "debug"},
+		{Field: "services.tcp/8080", New: `{"protocol":"tcp","internal_port":8080}`},
+		{Field: "mounts./data", Old: "vol_123"},
+		{Field: "mounts./var/data", New: "vol_123"},
+		{Field: "guest", New: "shared-cpu-1x, 512MB"},
+	}, deploy.DiffMachineConfigs(m.Config, localMachineConfig(cfg, processConfigs, m)))
+}
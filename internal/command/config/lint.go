@@ -0,0 +1,111 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"github.com/logrusorgru/aurora"
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newLint() (cmd *cobra.Command) {
+	const (
+		short = "Check an app's config file for problems"
+		long  = `Check an application's config file for every problem at once, without
+contacting the Fly service: unknown keys, deprecated forms of settings,
+settings only apps on the nomad platform use, and what fly config validate
+finds for apps on machines. Problems are reported with their line in TOML
+files.
+
+With --fix, the config file is rewritten to fix the problems marked as
+fixable, such as by migrating deprecated settings.`
+	)
+	cmd = command.New("lint", short, long, runLint)
+	cmd.Args = cobra.NoArgs
+	flag.Add(cmd,
+		flag.AppConfig(),
+		flag.Bool{
+			Name:        "fix",
+			Description: "Rewrite the config file to fix the problems which can be fixed automatically",
+		},
+	)
+	return
+}
+
+func runLint(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	// the config is looked up by hand rather than by a preparer, which would
+	// ask the Fly service about the app
+	path := state.WorkingDirectory(ctx)
+	if flag.IsSpecified(ctx, "config") {
+		path = flag.GetString(ctx, "config")
+	}
+	path, err := appconfig.ResolveConfigFileFromPath(path)
+	if err != nil {
+		return err
+	}
+
+	if flag.GetBool(ctx, "fix") {
+		if err := appconfig.LintFix(path); err != nil {
+			return err
+		}
+		fmt.Fprintf(io.ErrOut, "Rewrote %s\n", path)
+	}
+
+	cfg, err := appconfig.LoadConfig(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return errors.New("App config file not found")
+	case err != nil:
+		return err
+	}
+
+	problems, err := cfg.Lint()
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		if err := render.JSON(io.Out, problems); err != nil {
+			return err
+		}
+	} else {
+		for _, p := range problems {
+			location := path
+			if p.Line > 0 {
+				location += fmt.Sprintf(":%d", p.Line)
+			}
+			if p.Key != "" {
+				location += " " + p.Key
+			}
+			var fixable string
+			if p.Fixable {
+				fixable = " (fixable)"
+			}
+			fmt.Fprintf(io.Out, "%s %s: %s%s\n", aurora.Red("✘"), location, p.Message, fixable)
+		}
+	}
+
+	fixable := lo.CountBy(problems, func(p appconfig.LintProblem) bool { return p.Fixable })
+	switch {
+	case len(problems) == 0:
+		fmt.Fprintf(io.ErrOut, "%s %s has no problems\n", aurora.Green("✓"), path)
+		return nil
+	case fixable > 0:
+		return fmt.Errorf("found %d problems in %s, run fly config lint --fix to fix %d of them", len(problems), path, fixable)
+	default:
+		return fmt.Errorf("found %d problems in %s", len(problems), path)
+	}
+}
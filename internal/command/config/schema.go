@@ -0,0 +1,58 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newSchema() (cmd *cobra.Command) {
+	const (
+		short = "Print the JSON Schema of app config files"
+		long  = `Print the JSON Schema of app config files, for editors to complete and
+validate fly.toml, and fly.json and fly.yaml files, with. It's generated from
+the config flyctl loads, so it matches the version of flyctl.`
+	)
+	cmd = command.New("schema", short, long, runSchema)
+	cmd.Args = cobra.NoArgs
+	flag.Add(cmd,
+		flag.String{
+			Name:        "output",
+			Shorthand:   "o",
+			Description: "The file to write the schema to, instead of stdout",
+		},
+	)
+	return
+}
+
+func runSchema(ctx context.Context) error {
+	var (
+		io     = iostreams.FromContext(ctx)
+		output = flag.GetString(ctx, "output")
+	)
+
+	schema, err := appconfig.JSONSchema()
+	if err != nil {
+		return err
+	}
+	schema = append(schema, '\n')
+
+	if output == "" {
+		_, err = io.Out.Write(schema)
+		return err
+	}
+
+	if err := os.WriteFile(output, schema, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(io.ErrOut, "Wrote the schema to %s\n", output)
+
+	return nil
+}
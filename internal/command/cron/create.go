@@ -0,0 +1,140 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/shlex"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/machine"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newCreate() *cobra.Command {
+	const (
+		short = "Create a scheduled job"
+		long  = `Create a job running a command at the times of --schedule, in a machine of
+its own. The job runs the image of the latest release, along with its
+environment, unless --image is given.
+`
+		usage = "create <name>"
+	)
+
+	cmd := command.New(usage, short, long, runCreate,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Region(),
+		flag.String{
+			Name:        "schedule",
+			Description: `When to run the job: hourly, daily, weekly, monthly or a cron expression such as "0 2 * * *"`,
+		},
+		flag.String{
+			Name:        "schedule-timezone",
+			Description: `Timezone the cron expression of --schedule is evaluated in, such as "Europe/Paris". Defaults to UTC`,
+		},
+		flag.String{
+			Name:        "command",
+			Description: "Command the job runs",
+		},
+		flag.String{
+			Name:        "image",
+			Description: "Image the job runs, instead of the image of the latest release",
+		},
+		flag.String{
+			Name:        "vm-size",
+			Description: `Size of the machine running the job, such as "shared-cpu-1x"`,
+			Default:     "shared-cpu-1x",
+		},
+	)
+
+	return cmd
+}
+
+func runCreate(ctx context.Context) error {
+	name := flag.FirstArg(ctx)
+	if err := validateJobName(name); err != nil {
+		return err
+	}
+
+	schedule, command := flag.GetString(ctx, "schedule"), flag.GetString(ctx, "command")
+	if schedule == "" || command == "" {
+		return fmt.Errorf("a job needs both a --schedule and a --command")
+	}
+	cmd, err := shlex.Split(command)
+	if err != nil {
+		return fmt.Errorf("invalid --command: %w", err)
+	}
+
+	guest, ok := api.MachinePresets[flag.GetString(ctx, "vm-size")]
+	if !ok {
+		return fmt.Errorf("invalid --vm-size %s, see fly platform vm-sizes", flag.GetString(ctx, "vm-size"))
+	}
+
+	ctx, app, err := jobContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := findJob(ctx, name); err == nil {
+		return fmt.Errorf("%s already has a job named %s, delete it with fly cron delete %s first", app.Name, name, name)
+	}
+
+	release, err := mach.LatestRelease(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg := &api.MachineConfig{
+		Init:     api.MachineInit{Cmd: cmd},
+		Guest:    guest,
+		Restart:  api.MachineRestart{Policy: api.MachineRestartPolicyNo},
+		Metadata: map[string]string{metadataKeyCronJob: name},
+	}
+	if err := machine.SetSchedule(cfg, schedule, flag.GetString(ctx, "schedule-timezone")); err != nil {
+		return err
+	}
+
+	region := flag.GetString(ctx, "region")
+	switch image := flag.GetString(ctx, "image"); {
+	case image != "":
+		cfg.Image = image
+	case release == nil:
+		return fmt.Errorf("%s has no release to take the image of, pass --image", app.Name)
+	default:
+		cfg.Image = release.Config.Image
+		cfg.Env = release.Config.Env
+		if region == "" {
+			region = release.Region
+		}
+	}
+
+	m, err := flaps.FromContext(ctx).Launch(ctx, api.LaunchMachineInput{
+		AppID:  app.Name,
+		Name:   "cron-" + name,
+		Region: region,
+		Config: cfg,
+	})
+	if err != nil {
+		return fmt.Errorf("failed creating the machine of job %s: %w", name, err)
+	}
+
+	out := iostreams.FromContext(ctx).Out
+	fmt.Fprintf(out, "Created job %s running %s on machine %s\n", name, command, m.ID)
+	fmt.Fprintf(out, "It runs %s, run it now with fly cron run %s\n", machine.DescribeSchedule(cfg, time.Now()), name)
+
+	return nil
+}
@@ -0,0 +1,163 @@
+// Package cron implements the cron command chain, which manages the
+// scheduled jobs of an app.
+package cron
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+)
+
+// metadataKeyCronJob tells the machines running the jobs of an app apart,
+// and holds the name of their job.
+const metadataKeyCronJob = "fly_cron_job"
+
+// New initializes and returns a new cron Command.
+func New() *cobra.Command {
+	const (
+		short = "Manage the scheduled jobs of an app"
+		long  = `Manage the scheduled jobs of an app. Each job runs a command in a machine
+of its own at the times of a cron expression, or hourly, daily, weekly or
+monthly, with the image of the latest release unless given another.
+`
+	)
+
+	cmd := command.New("cron", short, long, nil)
+
+	cmd.AddCommand(
+		newCreate(),
+		newList(),
+		newRun(),
+		newLogs(),
+		newDelete(),
+	)
+
+	return cmd
+}
+
+var jobNameRE = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// validateJobName fails unless name can name a job, and the machine running it.
+func validateJobName(name string) error {
+	if !jobNameRE.MatchString(name) {
+		return fmt.Errorf("invalid job name %q, expected up to 63 lowercase letters, digits and dashes", name)
+	}
+	return nil
+}
+
+// jobContext derives a context carrying a flaps client for the app, which
+// must run on machines.
+func jobContext(ctx context.Context) (context.Context, *api.AppCompact, error) {
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appconfig.NameFromContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return nil, nil, fmt.Errorf("scheduled jobs are only supported for apps running on machines")
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return flaps.NewContext(ctx, flapsClient), app, nil
+}
+
+// jobs returns the machines running the jobs of the app, sorted by the name
+// of their job.
+func jobs(ctx context.Context) ([]*api.Machine, error) {
+	machines, err := flaps.FromContext(ctx).List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	machines = lo.Filter(machines, func(m *api.Machine, _ int) bool {
+		return m.IsActive() && jobName(m) != ""
+	})
+	sort.Slice(machines, func(i, j int) bool { return jobName(machines[i]) < jobName(machines[j]) })
+
+	return machines, nil
+}
+
+// findJob returns the machine running the job name.
+func findJob(ctx context.Context, name string) (*api.Machine, error) {
+	machines, err := jobs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	m, ok := lo.Find(machines, func(m *api.Machine) bool { return jobName(m) == name })
+	if !ok {
+		return nil, fmt.Errorf("no job named %s, list the jobs of the app with fly cron list", name)
+	}
+	return m, nil
+}
+
+func jobName(m *api.Machine) string {
+	if m.Config == nil {
+		return ""
+	}
+	return m.Config.Metadata[metadataKeyCronJob]
+}
+
+// lastRun returns when the job m runs was last started, the zero time when
+// it never was, and how that run went.
+func lastRun(m *api.Machine) (time.Time, string) {
+	var started, exited *api.MachineEvent
+
+	// events are listed newest first
+	for _, e := range m.Events {
+		switch {
+		case e.Type == "start" && started == nil:
+			started = e
+		case e.Type == "exit" && exited == nil && started == nil:
+			exited = e
+		}
+	}
+
+	if started == nil {
+		return time.Time{}, "never run"
+	}
+	at := time.UnixMilli(started.Timestamp)
+
+	if m.State == api.MachineStateStarted || exited == nil {
+		return at, "running"
+	}
+
+	var exit *api.MachineExitEvent
+	if exited.Request != nil {
+		exit = exited.Request.ExitEvent
+		if exited.Request.MonitorEvent != nil && exited.Request.MonitorEvent.ExitEvent != nil {
+			exit = exited.Request.MonitorEvent.ExitEvent
+		}
+	}
+
+	switch {
+	case exit == nil:
+		return at, "exited"
+	case exit.OOMKilled:
+		return at, "failed, out of memory"
+	case exit.ExitCode != 0:
+		return at, fmt.Sprintf("failed, exit code %d", exit.ExitCode)
+	default:
+		return at, "succeeded"
+	}
+}
+
+// jobCommand returns the command of the job m runs.
+func jobCommand(m *api.Machine) string {
+	return strings.Join(m.Config.Init.Cmd, " ")
+}
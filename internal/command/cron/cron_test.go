@@ -0,0 +1,58 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestValidateJobName(t *testing.T) {
+	assert.NoError(t, validateJobName("nightly-cleanup"))
+	assert.Error(t, validateJobName("Nightly"))
+	assert.Error(t, validateJobName("-cleanup"))
+	assert.Error(t, validateJobName(""))
+}
+
+func TestLastRun(t *testing.T) {
+	started := time.Date(2023, 5, 5, 2, 0, 0, 0, time.UTC)
+	exit := func(code int) *api.MachineEvent {
+		return &api.MachineEvent{Type: "exit", Request: &api.MachineRequest{ExitEvent: &api.MachineExitEvent{ExitCode: code}}}
+	}
+	start := &api.MachineEvent{Type: "start", Timestamp: started.UnixMilli()}
+
+	at, status := lastRun(&api.Machine{State: "created", Events: []*api.MachineEvent{{Type: "launch"}}})
+	assert.True(t, at.IsZero())
+	assert.Equal(t, "never run", status)
+
+	at, status = lastRun(&api.Machine{State: api.MachineStateStarted, Events: []*api.MachineEvent{start, exit(0)}})
+	assert.True(t, started.Equal(at))
+	assert.Equal(t, "running", status)
+
+	// the exit of the previous run doesn't count
+	_, status = lastRun(&api.Machine{State: api.MachineStateStopped, Events: []*api.MachineEvent{exit(0), start, exit(1)}})
+	assert.Equal(t, "succeeded", status)
+
+	_, status = lastRun(&api.Machine{State: api.MachineStateStopped, Events: []*api.MachineEvent{exit(3), start}})
+	assert.Equal(t, "failed, exit code 3", status)
+}
+
+func TestJobRunConfig(t *testing.T) {
+	m := &api.Machine{Config: &api.MachineConfig{
+		Image:            "registry.fly.io/my-app:deployment-1",
+		Init:             api.MachineInit{Cmd: []string{"bin/cleanup"}},
+		Schedule:         "daily",
+		ScheduleTimezone: "Europe/Paris",
+		Metadata:         map[string]string{metadataKeyCronJob: "cleanup"},
+	}}
+
+	cfg := jobRunConfig(m)
+	assert.Equal(t, []string{"bin/cleanup"}, cfg.Init.Cmd)
+	assert.Equal(t, "registry.fly.io/my-app:deployment-1", cfg.Image)
+	assert.Empty(t, cfg.Schedule)
+	assert.Empty(t, cfg.ScheduleTimezone)
+	assert.Empty(t, jobName(&api.Machine{Config: cfg}), "the temporary machine isn't listed as a job")
+	assert.Equal(t, "cleanup", jobName(m), "the config of the job is left alone")
+}
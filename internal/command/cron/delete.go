@@ -0,0 +1,73 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newDelete() *cobra.Command {
+	const (
+		short = "Delete a scheduled job"
+		long  = short + ", destroying its machine\n"
+		usage = "delete <name>"
+	)
+
+	cmd := command.New(usage, short, long, runDelete,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+	cmd.Aliases = []string{"rm"}
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+	)
+
+	return cmd
+}
+
+func runDelete(ctx context.Context) error {
+	name := flag.FirstArg(ctx)
+
+	ctx, app, err := jobContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	m, err := findJob(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirmf(ctx, "Delete job %s, destroying machine %s?", name, m.ID); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	if err := flaps.FromContext(ctx).Destroy(ctx, api.RemoveMachineInput{AppID: app.Name, ID: m.ID, Kill: true}); err != nil {
+		return fmt.Errorf("failed deleting job %s: %w", name, err)
+	}
+
+	fmt.Fprintf(iostreams.FromContext(ctx).Out, "Deleted job %s\n", name)
+	return nil
+}
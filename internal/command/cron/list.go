@@ -0,0 +1,105 @@
+package cron
+
+import (
+	"context"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/machine"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newList() *cobra.Command {
+	const (
+		short = "List the scheduled jobs of an app"
+		long  = short + ", along with how their last run went\n"
+	)
+
+	cmd := command.New("list", short, long, runList,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Aliases = []string{"ls"}
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+// job is how jobs are listed as JSON.
+type job struct {
+	Name             string    `json:"name"`
+	Schedule         string    `json:"schedule"`
+	ScheduleTimezone string    `json:"schedule_timezone,omitempty"`
+	Command          string    `json:"command"`
+	Image            string    `json:"image"`
+	Machine          string    `json:"machine"`
+	Region           string    `json:"region"`
+	LastRun          time.Time `json:"last_run,omitempty"`
+	LastRunStatus    string    `json:"last_run_status"`
+}
+
+func runList(ctx context.Context) error {
+	ctx, _, err := jobContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	machines, err := jobs(ctx)
+	if err != nil {
+		return err
+	}
+
+	out := iostreams.FromContext(ctx).Out
+
+	if config.FromContext(ctx).JSONOutput {
+		list := make([]job, 0, len(machines))
+		for _, m := range machines {
+			at, status := lastRun(m)
+			list = append(list, job{
+				Name:             jobName(m),
+				Schedule:         m.Config.Schedule,
+				ScheduleTimezone: m.Config.ScheduleTimezone,
+				Command:          jobCommand(m),
+				Image:            m.Config.Image,
+				Machine:          m.ID,
+				Region:           m.Region,
+				LastRun:          at,
+				LastRunStatus:    status,
+			})
+		}
+		return render.JSON(out, list)
+	}
+
+	now := time.Now()
+	rows := make([][]string, 0, len(machines))
+	for _, m := range machines {
+		at, status := lastRun(m)
+		last := "-"
+		if !at.IsZero() {
+			last = humanize.Time(at)
+		}
+		rows = append(rows, []string{
+			jobName(m),
+			machine.DescribeSchedule(m.Config, now),
+			jobCommand(m),
+			m.ID,
+			m.Region,
+			last,
+			status,
+		})
+	}
+
+	return render.Table(out, "", rows, "Name", "Schedule", "Command", "Machine", "Region", "Last Run", "Status")
+}
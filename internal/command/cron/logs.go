@@ -0,0 +1,52 @@
+package cron
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	cmdlogs "github.com/superfly/flyctl/internal/command/logs"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/logs"
+)
+
+func newLogs() *cobra.Command {
+	const (
+		short = "View the logs of a scheduled job"
+		long  = short + "\n"
+		usage = "logs <name>"
+	)
+
+	cmd := command.New(usage, short, long, runLogs,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+func runLogs(ctx context.Context) error {
+	ctx, _, err := jobContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	m, err := findJob(ctx, flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+
+	return cmdlogs.Tail(ctx, &logs.LogOptions{
+		AppName: appconfig.NameFromContext(ctx),
+		VMID:    m.ID,
+	})
+}
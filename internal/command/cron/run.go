@@ -0,0 +1,125 @@
+package cron
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/logs"
+)
+
+func newRun() *cobra.Command {
+	const (
+		short = "Run a scheduled job now"
+		long  = `Run a scheduled job now, besides the times of its schedule. Follow its
+output with fly cron logs, or pass --wait to run it in a temporary machine
+instead, whose output is streamed until the job exits, with the exit code of
+the job.
+`
+		usage = "run <name>"
+	)
+
+	cmd := command.New(usage, short, long, runRun,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Bool{
+			Name:        "wait",
+			Description: "Run the job in a temporary machine, streaming its output until it exits",
+		},
+		flag.Int{
+			Name:        "timeout",
+			Description: "Seconds to wait for the job to exit before killing it, with --wait",
+			Default:     3600,
+		},
+	)
+
+	return cmd
+}
+
+func runRun(ctx context.Context) error {
+	name := flag.FirstArg(ctx)
+
+	ctx, app, err := jobContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	m, err := findJob(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if flag.GetBool(ctx, "wait") {
+		return runJobMachine(ctx, app, m)
+	}
+
+	if m.State == api.MachineStateStarted {
+		return fmt.Errorf("job %s is already running on machine %s", name, m.ID)
+	}
+
+	if _, err := flaps.FromContext(ctx).Start(ctx, m.ID); err != nil {
+		return fmt.Errorf("failed running job %s: %w", name, err)
+	}
+
+	fmt.Fprintf(iostreams.FromContext(ctx).Out, "Started job %s on machine %s, follow its output with fly cron logs %s\n", name, m.ID, name)
+	return nil
+}
+
+// runJobMachine runs the job of m in a temporary machine, streaming its output
+// until it exits.
+func runJobMachine(ctx context.Context, app *api.AppCompact, m *api.Machine) error {
+	io := iostreams.FromContext(ctx)
+	name := jobName(m)
+
+	cfg := jobRunConfig(m)
+	fmt.Fprintf(io.ErrOut, "Running job %s in a temporary machine\n", name)
+
+	_, exitCode, err := machine.RunEphemeral(ctx, machine.EphemeralInput{
+		LaunchInput: api.LaunchMachineInput{
+			AppID:  app.Name,
+			Region: m.Region,
+			Config: cfg,
+		},
+		StartTimeout: time.Minute,
+		Timeout:      time.Duration(flag.GetInt(ctx, "timeout")) * time.Second,
+		PrintLog: func(entry logs.LogEntry) {
+			if entry.Meta.Event.Provider == "app" {
+				fmt.Fprintln(io.Out, entry.Message)
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed running job %s: %w", name, err)
+	}
+	if exitCode != 0 {
+		return flyerr.ExitCodeError{Code: exitCode}
+	}
+	return nil
+}
+
+// jobRunConfig returns the config of a temporary machine running the job of
+// m once, which isn't a job of the app itself.
+func jobRunConfig(m *api.Machine) *api.MachineConfig {
+	cfg := machine.CloneConfig(m.Config)
+	cfg.Schedule = ""
+	cfg.ScheduleTimezone = ""
+	delete(cfg.Metadata, metadataKeyCronJob)
+
+	return cfg
+}
@@ -55,16 +55,11 @@ func run(ctx context.Context) error {
 		return err
 	}
 
-	rws, err := prepareRequestWrappers(ctx, url, regionCodes)
+	timings, err := Timings(ctx, url, regionCodes)
 	if err != nil {
 		return err
 	}
 
-	timings := gatherTimings(ctx, rws)
-	if err := ctx.Err(); err != nil {
-		return err
-	}
-
 	if io := iostreams.FromContext(ctx); !config.FromContext(ctx).JSONOutput {
 		renderTextTimings(io.Out, io.ColorScheme(), timings)
 	} else {
@@ -96,6 +91,22 @@ func fetchRegionCodes(ctx context.Context) (codes []string, err error) {
 	return
 }
 
+// Timings requests url from each of the given regions, through the Fly edge
+// probing service, and returns the timings sorted by region.
+func Timings(ctx context.Context, url *url.URL, regionCodes []string) ([]*Timing, error) {
+	rws, err := prepareRequestWrappers(ctx, url, regionCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	timings := gatherTimings(ctx, rws)
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return timings, nil
+}
+
 func prepareRequestWrappers(ctx context.Context, url *url.URL, regionCodes []string) (rws []*requestWrapper, err error) {
 	for _, region := range regionCodes {
 		var rw *requestWrapper
@@ -111,11 +122,11 @@ func prepareRequestWrappers(ctx context.Context, url *url.URL, regionCodes []str
 	return
 }
 
-func gatherTimings(ctx context.Context, rws []*requestWrapper) (timings []*timing) {
+func gatherTimings(ctx context.Context, rws []*requestWrapper) (timings []*Timing) {
 	var wg sync.WaitGroup
 	wg.Add(len(rws))
 
-	c := make(chan *timing, len(rws))
+	c := make(chan *Timing, len(rws))
 
 	for i := range rws {
 		go func(rw *requestWrapper) {
@@ -178,8 +189,8 @@ var httpClient = &http.Client{
 	Timeout: time.Second * 3,
 }
 
-func (rw *requestWrapper) time(c chan<- *timing) {
-	t := &timing{
+func (rw *requestWrapper) time(c chan<- *Timing) {
+	t := &Timing{
 		region: rw.regionCode,
 	}
 	defer func() {
@@ -209,7 +220,8 @@ func (rw *requestWrapper) time(c chan<- *timing) {
 	}
 }
 
-type timing struct {
+// Timing holds the timings of a request made from a region.
+type Timing struct {
 	error
 	region string
 
@@ -226,32 +238,42 @@ type timing struct {
 	Scheme            string  `json:"scheme"`
 }
 
-func (t *timing) formatedHTTPCode(cs *iostreams.ColorScheme) string {
+// Region returns the code of the region the request was made from.
+func (t *Timing) Region() string {
+	return t.region
+}
+
+// Err returns the error the request failed with, if any.
+func (t *Timing) Err() error {
+	return t.error
+}
+
+func (t *Timing) FormattedHTTPCode(cs *iostreams.ColorScheme) string {
 	text := strconv.Itoa(t.HTTPCode)
 	return colorize(cs, text, float64(t.HTTPCode), 299, 399)
 }
 
-func (t *timing) formattedDNS() string {
+func (t *Timing) formattedDNS() string {
 	return humanize.FtoaWithDigits(t.TimeNameLookup*1000, 1) + "ms"
 }
 
-func (t *timing) formattedConnect(cs *iostreams.ColorScheme) string {
+func (t *Timing) FormattedConnect(cs *iostreams.ColorScheme) string {
 	timing := t.TimeConnect * 1000
 	text := humanize.FtoaWithDigits(timing, 1) + "ms"
 	return colorize(cs, text, timing, 200, 500)
 }
 
-func (t *timing) formattedTLS() string {
+func (t *Timing) FormattedTLS() string {
 	return humanize.FtoaWithDigits((t.TimeAppConnect+t.TimePreTransfer)*1000, 1) + "ms"
 }
 
-func (t *timing) formattedTTFB(cs *iostreams.ColorScheme) string {
+func (t *Timing) FormattedTTFB(cs *iostreams.ColorScheme) string {
 	timing := t.TimeStartTransfer * 1000
 	text := humanize.FtoaWithDigits(timing, 1) + "ms"
 	return colorize(cs, text, timing, 400, 1000)
 }
 
-func (t *timing) formattedTotal() string {
+func (t *Timing) FormattedTotal() string {
 	timing := t.TimeTotal * 1000
 	return humanize.FtoaWithDigits(timing, 1) + "ms"
 }
@@ -270,7 +292,7 @@ func colorize(cs *iostreams.ColorScheme, text string, val, greenCutoff, yellowCu
 	return fn(text)
 }
 
-func renderTextTimings(w io.Writer, cs *iostreams.ColorScheme, timings []*timing) {
+func renderTextTimings(w io.Writer, cs *iostreams.ColorScheme, timings []*Timing) {
 	var rows [][]string
 	for _, t := range timings {
 		if t.error != nil {
@@ -279,12 +301,12 @@ func renderTextTimings(w io.Writer, cs *iostreams.ColorScheme, timings []*timing
 
 		rows = append(rows, []string{
 			t.region,
-			t.formatedHTTPCode(cs),
+			t.FormattedHTTPCode(cs),
 			t.formattedDNS(),
-			t.formattedConnect(cs),
-			t.formattedTLS(),
-			t.formattedTTFB(cs),
-			t.formattedTotal(),
+			t.FormattedConnect(cs),
+			t.FormattedTLS(),
+			t.FormattedTTFB(cs),
+			t.FormattedTotal(),
 		})
 	}
 
@@ -309,7 +331,7 @@ func renderTextTimings(w io.Writer, cs *iostreams.ColorScheme, timings []*timing
 	render.Table(w, "Failures", rows, "Region", "Error")
 }
 
-func renderJSONTimings(w io.Writer, timings []*timing) {
+func renderJSONTimings(w io.Writer, timings []*Timing) {
 	items := make(map[string]interface{}, len(timings))
 	for _, t := range timings {
 		if t.error != nil {
@@ -0,0 +1,156 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/sftp"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/command/ssh"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// uploadArtifacts copies the artifacts listed in the app config to the volume
+// of every started machine. Files whose size and modification time already
+// match the local copy are skipped, so unchanged artifacts cost nothing on
+// subsequent deploys.
+func (md *machineDeployment) uploadArtifacts(ctx context.Context) error {
+	if !md.attachArtifacts || len(md.appConfig.Artifacts) == 0 {
+		return nil
+	}
+
+	machines, _, err := md.flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return err
+	}
+
+	dir := state.WorkingDirectory(ctx)
+	if path := md.appConfig.ConfigFilePath(); path != "" {
+		dir = filepath.Dir(path)
+	}
+
+	var dialer agent.Dialer
+	for _, m := range artifactTargets(machines) {
+		if dialer == nil {
+			if dialer, err = md.dialer(ctx); err != nil {
+				return err
+			}
+		}
+
+		fmt.Fprintf(md.io.ErrOut, "  Uploading artifacts to %s\n", md.colorize.Bold(m.ID))
+
+		client, err := ssh.NewSFTPClient(ctx, md.app, dialer, m.PrivateIP)
+		if err != nil {
+			return fmt.Errorf("failed connecting to machine %s: %w", m.ID, err)
+		}
+
+		for _, a := range md.appConfig.Artifacts {
+			if err := md.uploadArtifact(client, artifactSource(dir, a.Source), a.Destination); err != nil {
+				client.Close()
+				return fmt.Errorf("failed uploading %s to machine %s: %w", a.Source, m.ID, err)
+			}
+		}
+		client.Close()
+	}
+
+	return nil
+}
+
+// artifactTargets returns the machines of machines artifacts are uploaded to,
+// those with a volume, warning about those which aren't started.
+func artifactTargets(machines []*api.Machine) []*api.Machine {
+	var targets []*api.Machine
+	for _, m := range machines {
+		if m.Config == nil || len(m.Config.Mounts) == 0 {
+			continue
+		}
+		if m.State != api.MachineStateStarted {
+			terminal.Warnf("Skipping artifacts upload to machine %s, which is %s\n", m.ID, m.State)
+			continue
+		}
+		targets = append(targets, m)
+	}
+
+	return targets
+}
+
+// artifactSource returns the local path of the artifact source src, relative
+// to dir unless absolute.
+func artifactSource(dir, src string) string {
+	if filepath.IsAbs(src) {
+		return src
+	}
+	return filepath.Join(dir, src)
+}
+
+func (md *machineDeployment) uploadArtifact(client *sftp.Client, src, dst string) error {
+	local, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer local.Close()
+
+	fi, err := local.Stat()
+	if err != nil {
+		return err
+	}
+	modTime := fi.ModTime().Truncate(time.Second)
+
+	if remote, err := client.Stat(dst); err == nil && remote.Size() == fi.Size() && remote.ModTime().Equal(modTime) {
+		fmt.Fprintf(md.io.ErrOut, "    %s is up to date\n", dst)
+		return nil
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "    %s (%s)\n", dst, humanize.Bytes(uint64(fi.Size())))
+
+	if err := client.MkdirAll(path.Dir(dst)); err != nil {
+		return err
+	}
+
+	// write next to the destination first so the app never sees a partial file
+	tmp := dst + ".flyctl-upload"
+	remote, err := client.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	if _, err := remote.ReadFrom(local); err != nil {
+		remote.Close()
+		return err
+	}
+	if err := remote.Close(); err != nil {
+		return err
+	}
+
+	if err := client.Chtimes(tmp, modTime, modTime); err != nil {
+		return err
+	}
+
+	return client.PosixRename(tmp, dst)
+}
+
+func (md *machineDeployment) dialer(ctx context.Context) (agent.Dialer, error) {
+	agentclient, err := agent.Establish(ctx, md.apiClient)
+	if err != nil {
+		return nil, fmt.Errorf("can't establish agent: %w", err)
+	}
+
+	dialer, err := agentclient.Dialer(ctx, md.app.Organization.Slug)
+	if err != nil {
+		return nil, fmt.Errorf("can't build tunnel for %s: %w", md.app.Organization.Slug, err)
+	}
+
+	if err := agentclient.WaitForTunnel(ctx, md.app.Organization.Slug); err != nil {
+		return nil, fmt.Errorf("tunnel unavailable: %w", err)
+	}
+
+	return dialer, nil
+}
@@ -0,0 +1,174 @@
+package deploy
+
+import (
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestArtifactTargets(t *testing.T) {
+	machine := func(id, state string, mounts ...api.MachineMount) *api.Machine {
+		return &api.Machine{ID: id, State: state, Config: &api.MachineConfig{Mounts: mounts}}
+	}
+	data := api.MachineMount{Volume: "vol_123", Path: "/data"}
+
+	tests := []struct {
+		name     string
+		machines []*api.Machine
+		want     []string
+	}{
+		{
+			name: "no machines",
+		},
+		{
+			name:     "started machines with a volume",
+			machines: []*api.Machine{machine("m1", api.MachineStateStarted, data), machine("m2", api.MachineStateStarted, data)},
+			want:     []string{"m1", "m2"},
+		},
+		{
+			name:     "machines without a volume",
+			machines: []*api.Machine{machine("m1", api.MachineStateStarted), machine("m2", api.MachineStateStarted, data)},
+			want:     []string{"m2"},
+		},
+		{
+			name:     "stopped machines",
+			machines: []*api.Machine{machine("m1", api.MachineStateStopped, data), machine("m2", api.MachineStateStarted, data)},
+			want:     []string{"m2"},
+		},
+		{
+			name:     "machines without a config",
+			machines: []*api.Machine{{ID: "m1", State: api.MachineStateStarted}},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var ids []string
+			for _, m := range artifactTargets(tc.machines) {
+				ids = append(ids, m.ID)
+			}
+			assert.Equal(t, tc.want, ids)
+		})
+	}
+}
+
+func TestArtifactSource(t *testing.T) {
+	tests := []struct {
+		dir, src, want string
+	}{
+		{dir: "/work/app", src: "seed.db", want: "/work/app/seed.db"},
+		{dir: "/work/app", src: "models/../seed.db", want: "/work/app/seed.db"},
+		{dir: "/work/app", src: "../shared/seed.db", want: "/work/shared/seed.db"},
+		{dir: "/work/app", src: "/srv/seed.db", want: "/srv/seed.db"},
+	}
+
+	for _, tc := range tests {
+		assert.Equal(t, tc.want, artifactSource(tc.dir, tc.src), tc.src)
+	}
+}
+
+func TestUploadArtifact(t *testing.T) {
+	ios, _, _, errOut := iostreams.Test()
+	md := &machineDeployment{io: ios}
+	client := newMemSFTPClient(t)
+
+	dir := t.TempDir()
+	src := filepath.Join(dir, "seed.db")
+	require.NoError(t, os.WriteFile(src, []byte("v1"), 0o600))
+
+	tests := []struct {
+		name    string
+		src     string
+		dst     string
+		prepare func()
+		output  string
+		want    string
+		errMsg  string
+	}{
+		{
+			name:   "new file",
+			src:    src,
+			dst:    "/data/db/seed.db",
+			output: "/data/db/seed.db (2 B)",
+			want:   "v1",
+		},
+		{
+			name:   "unchanged file",
+			src:    src,
+			dst:    "/data/db/seed.db",
+			output: "/data/db/seed.db is up to date",
+			want:   "v1",
+		},
+		{
+			name: "changed file",
+			src:  src,
+			dst:  "/data/db/seed.db",
+			prepare: func() {
+				require.NoError(t, os.WriteFile(src, []byte("v2 and more"), 0o600))
+				later := time.Now().Add(time.Minute)
+				require.NoError(t, os.Chtimes(src, later, later))
+			},
+			output: "/data/db/seed.db (11 B)",
+			want:   "v2 and more",
+		},
+		{
+			name:   "missing source",
+			src:    filepath.Join(dir, "missing.db"),
+			dst:    "/data/missing.db",
+			errMsg: "no such file or directory",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if tc.prepare != nil {
+				tc.prepare()
+			}
+			errOut.Reset()
+
+			err := md.uploadArtifact(client, tc.src, tc.dst)
+			if tc.errMsg != "" {
+				assert.ErrorContains(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Contains(t, errOut.String(), tc.output)
+
+			remote, err := client.Open(tc.dst)
+			require.NoError(t, err)
+			defer remote.Close()
+			content, err := io.ReadAll(remote)
+			require.NoError(t, err)
+			assert.Equal(t, tc.want, string(content))
+
+			_, err = client.Stat(tc.dst + ".flyctl-upload")
+			assert.ErrorIs(t, err, os.ErrNotExist, "the temporary file is renamed")
+		})
+	}
+}
+
+// newMemSFTPClient returns a client of an in-memory SFTP server.
+func newMemSFTPClient(t *testing.T) *sftp.Client {
+	t.Helper()
+
+	serverConn, clientConn := net.Pipe()
+	server := sftp.NewRequestServer(serverConn, sftp.InMemHandler())
+	go server.Serve()
+	t.Cleanup(func() { server.Close() })
+
+	client, err := sftp.NewClientPipe(clientConn, clientConn)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
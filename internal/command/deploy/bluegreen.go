@@ -0,0 +1,275 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	machcmd "github.com/superfly/flyctl/internal/command/machine"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// deployBlueGreen boots a green machine running the new release for every
+// existing (blue) machine, and cuts traffic over once every green machine
+// passes its health checks: the blue machines are stopped, so the proxy only
+// routes to the green ones, and then destroyed unless md.bgKeepOld is set, in
+// which case they're tagged with keptBlueConfig instead. A green machine
+// failing to come up leaves the blue machines untouched.
+func (md *machineDeployment) deployBlueGreen(ctx context.Context) error {
+	blue := md.machineSet.GetMachines()
+	for _, m := range blue {
+		if len(m.Machine().Config.Mounts) > 0 {
+			return errors.New("the bluegreen strategy doesn't support apps with volumes, as every green machine would need a volume of its own; use the rolling strategy instead")
+		}
+	}
+
+	var green []machine.LeasableMachine
+	destroyGreen := func() {
+		for _, m := range green {
+			if err := m.Destroy(ctx, true); err != nil {
+				terminal.Warnf("failed destroying green machine %s: %v\n", m.Machine().ID, err)
+			}
+		}
+	}
+
+	for _, m := range blue {
+		launchInput := md.resolveUpdatedMachineConfig(m.Machine(), false)
+		launchInput.ID = ""
+
+		newMachineRaw, err := md.flapsClient.Launch(ctx, *launchInput)
+		if err != nil {
+			destroyGreen()
+			return fmt.Errorf("error creating green machine to replace %s: %w", m.Machine().ID, err)
+		}
+		fmt.Fprintf(md.io.ErrOut, "  Created green machine %s to replace %s\n", md.colorize.Bold(newMachineRaw.ID), m.Machine().ID)
+
+		green = append(green, machine.NewLeasableMachine(md.flapsClient, md.io, newMachineRaw))
+	}
+
+	// the green machines are waited on together, and the first failure cancels
+	// the other waits
+	eg, egCtx := errgroup.WithContext(ctx)
+	for _, m := range green {
+		m := m
+		eg.Go(func() error {
+			if err := m.WaitForState(egCtx, api.MachineStateStarted, md.waitTimeout); err != nil {
+				return fmt.Errorf("green machine %s failed to start, aborting deployment: %w", m.Machine().ID, err)
+			}
+			if err := m.WaitForHealthchecksToPass(egCtx, md.waitTimeout); err != nil {
+				return fmt.Errorf("green machine %s failed its health checks, aborting deployment: %w", m.Machine().ID, err)
+			}
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		destroyGreen()
+		return err
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "  Every green machine is healthy, cutting traffic over\n")
+
+	for _, m := range blue {
+		if err := md.drainMachine(ctx, m.Machine(), nil); err != nil {
+			destroyGreen()
+			return err
+		}
+	}
+	for _, m := range blue {
+		if err := md.flapsClient.Stop(ctx, api.StopMachineInput{ID: m.Machine().ID}); err != nil {
+			return fmt.Errorf("failed stopping blue machine %s: %w", m.Machine().ID, err)
+		}
+	}
+
+	if md.bgKeepOld {
+		for _, m := range blue {
+			input := api.LaunchMachineInput{
+				ID:         m.Machine().ID,
+				Region:     m.Machine().Region,
+				Config:     keptBlueConfig(m.Machine(), md.releaseVersion),
+				SkipLaunch: true,
+			}
+			if err := m.Update(ctx, input); err != nil {
+				return fmt.Errorf("failed setting aside blue machine %s: %w", m.Machine().ID, err)
+			}
+		}
+		fmt.Fprintf(md.io.ErrOut, "  Kept blue machines %s stopped, with autostart off and out of later deployments; roll back to them with 'fly deploy --bg-rollback', or destroy them with 'fly machine destroy'\n", md.colorize.Bold(formatMachineIDs(blue)))
+		return nil
+	}
+
+	if err := md.machineSet.RemoveMachines(ctx, blue); err != nil {
+		return err
+	}
+	for _, m := range blue {
+		if err := machcmd.Destroy(ctx, md.app, m.Machine(), true); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// keptBlueAutostartKey is the metadata key the autostart settings of the
+// services of a kept blue machine are recorded under, as a comma separated
+// list, so that rolling back to it restores them.
+const keptBlueAutostartKey = "fly_bg_old_autostart"
+
+// keptBlueConfig returns the config of the blue machine m set aside for a
+// rollback: tagged with the release that replaced it, so that later
+// deployments leave it alone, and with autostart off, so that the proxy
+// doesn't wake it up to serve the old release.
+func keptBlueConfig(m *api.Machine, replacedBy int) *api.MachineConfig {
+	cfg := machine.CloneConfig(m.Config)
+	if cfg.Metadata == nil {
+		cfg.Metadata = map[string]string{}
+	}
+	cfg.Metadata[api.MachineConfigMetadataKeyFlyBlueGreenOld] = strconv.Itoa(replacedBy)
+
+	autostart := make([]string, len(cfg.Services))
+	for i := range cfg.Services {
+		if cfg.Services[i].Autostart != nil {
+			autostart[i] = strconv.FormatBool(*cfg.Services[i].Autostart)
+		}
+		cfg.Services[i].Autostart = api.Pointer(false)
+	}
+	cfg.Metadata[keptBlueAutostartKey] = strings.Join(autostart, ",")
+
+	return cfg
+}
+
+// restoredBlueConfig returns the config of the kept blue machine m rolled back
+// to, as it was before keptBlueConfig set it aside.
+func restoredBlueConfig(m *api.Machine) *api.MachineConfig {
+	cfg := machine.CloneConfig(m.Config)
+
+	autostart := strings.Split(cfg.Metadata[keptBlueAutostartKey], ",")
+	for i := range cfg.Services {
+		cfg.Services[i].Autostart = nil
+		if i < len(autostart) && autostart[i] != "" {
+			enabled, _ := strconv.ParseBool(autostart[i])
+			cfg.Services[i].Autostart = api.Pointer(enabled)
+		}
+	}
+	delete(cfg.Metadata, api.MachineConfigMetadataKeyFlyBlueGreenOld)
+	delete(cfg.Metadata, keptBlueAutostartKey)
+
+	return cfg
+}
+
+// blueGreenRollbackPlan returns the blue machines the last bluegreen
+// deployment with --bg-keep-old kept, to roll back to, and the green machines
+// of the release which replaced them, to destroy. Rolling back isn't possible
+// once a later release is deployed, whose machines would be left running.
+func blueGreenRollbackPlan(machines []*api.Machine) (blue, green []*api.Machine, err error) {
+	releaseVersion := func(m *api.Machine, key string) int {
+		v, _ := strconv.Atoi(m.Config.Metadata[key])
+		return v
+	}
+
+	kept := lo.Filter(machines, func(m *api.Machine, _ int) bool { return m.IsKeptBlue() })
+	if len(kept) == 0 {
+		return nil, nil, errors.New("there are no blue machines to roll back to; they're only kept by bluegreen deployments with --bg-keep-old")
+	}
+
+	replacedBy := lo.Max(lo.Map(kept, func(m *api.Machine, _ int) int {
+		return releaseVersion(m, api.MachineConfigMetadataKeyFlyBlueGreenOld)
+	}))
+	blue = lo.Filter(kept, func(m *api.Machine, _ int) bool {
+		return releaseVersion(m, api.MachineConfigMetadataKeyFlyBlueGreenOld) == replacedBy
+	})
+
+	for _, m := range machines {
+		if m.IsKeptBlue() {
+			continue
+		}
+		switch version := releaseVersion(m, api.MachineConfigMetadataKeyFlyReleaseVersion); {
+		case version == replacedBy:
+			green = append(green, m)
+		case version > replacedBy:
+			return nil, nil, fmt.Errorf("machine %s runs release v%d, deployed after v%d replaced the blue machines, which can't be rolled back to anymore", m.ID, version, replacedBy)
+		}
+	}
+
+	return blue, green, nil
+}
+
+// rollbackBlueGreen rolls back a bluegreen deployment with --bg-keep-old: the
+// blue machines it kept are restored and started, and once they pass their
+// health checks the green machines which replaced them are destroyed.
+func rollbackBlueGreen(ctx context.Context, waitTimeout time.Duration) error {
+	var (
+		io          = iostreams.FromContext(ctx)
+		colorize    = io.ColorScheme()
+		flapsClient = flaps.FromContext(ctx)
+	)
+
+	machines, err := flapsClient.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+	blue, green, err := blueGreenRollbackPlan(machines)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.ErrOut, "Rolling back to blue machines %s\n", colorize.Bold(strings.Join(lo.Map(blue, func(m *api.Machine, _ int) string { return m.ID }), ", ")))
+
+	for _, m := range blue {
+		lm := machine.NewLeasableMachine(flapsClient, io, m)
+		if err := lm.AcquireLease(ctx, DefaultLeaseTtl); err != nil {
+			return err
+		}
+		err := lm.Update(ctx, api.LaunchMachineInput{
+			ID:         m.ID,
+			Region:     m.Region,
+			Config:     restoredBlueConfig(m),
+			SkipLaunch: true,
+		})
+		if releaseErr := lm.ReleaseLease(ctx); releaseErr != nil {
+			terminal.Warnf("failed releasing the lease of machine %s: %v\n", m.ID, releaseErr)
+		}
+		if err != nil {
+			return fmt.Errorf("failed restoring blue machine %s: %w", m.ID, err)
+		}
+
+		if err := lm.Start(ctx); err != nil {
+			return fmt.Errorf("failed starting blue machine %s: %w", m.ID, err)
+		}
+		if err := lm.WaitForState(ctx, api.MachineStateStarted, waitTimeout); err != nil {
+			return fmt.Errorf("blue machine %s failed to start, the green machines are left running: %w", m.ID, err)
+		}
+		if err := lm.WaitForHealthchecksToPass(ctx, waitTimeout); err != nil {
+			return fmt.Errorf("blue machine %s failed its health checks, the green machines are left running: %w", m.ID, err)
+		}
+	}
+
+	fmt.Fprintf(io.ErrOut, "  Every blue machine is healthy, destroying the green machines\n")
+
+	for _, m := range green {
+		if err := flapsClient.Destroy(ctx, api.RemoveMachineInput{ID: m.ID, Kill: true}); err != nil {
+			return fmt.Errorf("failed destroying green machine %s: %w", m.ID, err)
+		}
+	}
+
+	return nil
+}
+
+func formatMachineIDs(machines []machine.LeasableMachine) (ids string) {
+	for i, m := range machines {
+		if i > 0 {
+			ids += ", "
+		}
+		ids += m.Machine().ID
+	}
+
+	return
+}
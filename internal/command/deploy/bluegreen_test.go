@@ -0,0 +1,291 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/logger"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// fakeFlaps serves the machines API calls deployment strategies make, from
+// an in-memory set of machines.
+type fakeFlaps struct {
+	mu        sync.Mutex
+	machines  map[string]*api.Machine
+	launched  []string
+	destroyed []string
+	// getState, when set, overrides the state machines are reported in
+	getState string
+}
+
+func newFakeFlaps(t *testing.T, machines ...*api.Machine) *fakeFlaps {
+	f := &fakeFlaps{machines: map[string]*api.Machine{}}
+	for _, m := range machines {
+		f.machines[m.ID] = m
+	}
+
+	server := httptest.NewServer(f)
+	t.Cleanup(server.Close)
+	t.Setenv("FLY_FLAPS_BASE_URL", server.URL)
+
+	return f
+}
+
+func (f *fakeFlaps) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1/apps/my-cool-app/machines")
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+
+	switch {
+	case r.Method == http.MethodGet && path == "":
+		ids := lo.Keys(f.machines)
+		sort.Strings(ids)
+		json.NewEncoder(w).Encode(lo.Map(ids, func(id string, _ int) *api.Machine { return f.machines[id] }))
+		return
+	case r.Method == http.MethodPost && path == "":
+		var input api.LaunchMachineInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		m := &api.Machine{
+			ID:     fmt.Sprintf("green%d", len(f.launched)+1),
+			State:  api.MachineStateStarted,
+			Region: input.Region,
+			Config: input.Config,
+		}
+		f.machines[m.ID] = m
+		f.launched = append(f.launched, m.ID)
+		json.NewEncoder(w).Encode(m)
+		return
+	}
+
+	m, ok := f.machines[parts[0]]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	switch action := strings.Join(parts[1:], "/"); {
+	case r.Method == http.MethodGet && action == "":
+		out := *m
+		if f.getState != "" {
+			out.State = f.getState
+		}
+		json.NewEncoder(w).Encode(out)
+	case r.Method == http.MethodGet && action == "wait":
+		w.WriteHeader(http.StatusOK)
+	case r.Method == http.MethodPost && action == "stop":
+		m.State = api.MachineStateStopped
+	case r.Method == http.MethodPost && action == "start":
+		m.State = api.MachineStateStarted
+		json.NewEncoder(w).Encode(api.MachineStartResponse{})
+	case r.Method == http.MethodDelete && action == "lease":
+	case r.Method == http.MethodPost && action == "lease":
+		json.NewEncoder(w).Encode(api.MachineLease{Status: "success", Data: &api.MachineLeaseData{Nonce: "nonce"}})
+	case r.Method == http.MethodPost && action == "":
+		var input api.LaunchMachineInput
+		if err := json.NewDecoder(r.Body).Decode(&input); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		m.Config = input.Config
+		json.NewEncoder(w).Encode(m)
+	case r.Method == http.MethodDelete && action == "":
+		m.State = api.MachineStateDestroyed
+		f.destroyed = append(f.destroyed, m.ID)
+	default:
+		http.Error(w, fmt.Sprintf("unexpected %s %s", r.Method, r.URL.Path), http.StatusNotImplemented)
+	}
+}
+
+// stabStrategyDeployment returns a deployment of the given machines against
+// f, with their leases acquired.
+func stabStrategyDeployment(t *testing.T, f *fakeFlaps, machines ...*api.Machine) (context.Context, *machineDeployment) {
+	t.Helper()
+
+	io, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), io)
+	ctx = logger.NewContext(ctx, logger.FromEnv(io.ErrOut))
+
+	md, err := stabMachineDeployment(&appconfig.Config{AppName: "my-cool-app"})
+	require.NoError(t, err)
+	md.app.Name = "my-cool-app"
+	md.io = io
+	md.colorize = io.ColorScheme()
+	md.waitTimeout = time.Second
+	md.releaseVersion = 7
+
+	md.flapsClient, err = flaps.NewFromAppName(ctx, "my-cool-app")
+	require.NoError(t, err)
+	ctx = flaps.NewContext(ctx, md.flapsClient)
+
+	md.machineSet = machine.NewMachineSet(md.flapsClient, io, machines)
+	for _, m := range md.machineSet.GetMachines() {
+		require.NoError(t, m.AcquireLease(ctx, time.Minute))
+	}
+
+	return ctx, md
+}
+
+func blueMachine(id string) *api.Machine {
+	return &api.Machine{
+		ID:     id,
+		State:  api.MachineStateStarted,
+		Region: "scl",
+		Config: &api.MachineConfig{
+			Metadata: map[string]string{
+				api.MachineConfigMetadataKeyFlyPlatformVersion: api.MachineFlyPlatformVersion2,
+				api.MachineConfigMetadataKeyFlyProcessGroup:    api.MachineProcessGroupApp,
+			},
+			Services: []api.MachineService{{Protocol: "tcp", InternalPort: 8080, Autostart: api.Pointer(true)}},
+		},
+	}
+}
+
+func TestDeployBlueGreen(t *testing.T) {
+	blue := []*api.Machine{blueMachine("blue1"), blueMachine("blue2")}
+	f := newFakeFlaps(t, blue...)
+	ctx, md := stabStrategyDeployment(t, f, blue...)
+
+	require.NoError(t, md.deployBlueGreen(ctx))
+	assert.Equal(t, []string{"green1", "green2"}, f.launched)
+	assert.ElementsMatch(t, []string{"blue1", "blue2"}, f.destroyed)
+	assert.Equal(t, api.MachineStateStarted, f.machines["green1"].State)
+}
+
+func TestDeployBlueGreen_KeepOld(t *testing.T) {
+	blue := []*api.Machine{blueMachine("blue1"), blueMachine("blue2")}
+	f := newFakeFlaps(t, blue...)
+	ctx, md := stabStrategyDeployment(t, f, blue...)
+	md.bgKeepOld = true
+
+	require.NoError(t, md.deployBlueGreen(ctx))
+	assert.Equal(t, []string{"green1", "green2"}, f.launched)
+	assert.Empty(t, f.destroyed)
+
+	for _, id := range []string{"blue1", "blue2"} {
+		m := f.machines[id]
+		assert.Equal(t, api.MachineStateStopped, m.State)
+		assert.True(t, m.IsKeptBlue(), "%s is tagged as kept", id)
+		assert.Equal(t, "7", m.Config.Metadata[api.MachineConfigMetadataKeyFlyBlueGreenOld])
+		assert.Equal(t, api.Pointer(false), m.Config.Services[0].Autostart)
+	}
+	assert.False(t, f.machines["green1"].IsKeptBlue())
+}
+
+func TestDeployBlueGreen_Volumes(t *testing.T) {
+	m := blueMachine("blue1")
+	m.Config.Mounts = []api.MachineMount{{Volume: "vol_123", Path: "/data"}}
+	f := newFakeFlaps(t, m)
+	ctx, md := stabStrategyDeployment(t, f, m)
+
+	assert.ErrorContains(t, md.deployBlueGreen(ctx), "doesn't support apps with volumes")
+	assert.Empty(t, f.launched)
+}
+
+func TestKeptBlueConfig(t *testing.T) {
+	m := blueMachine("blue1")
+	m.Config.Services = append(m.Config.Services, api.MachineService{Protocol: "tcp", InternalPort: 9090})
+
+	cfg := keptBlueConfig(m, 12)
+	assert.Equal(t, "12", cfg.Metadata[api.MachineConfigMetadataKeyFlyBlueGreenOld])
+	assert.Equal(t, api.Pointer(false), cfg.Services[0].Autostart)
+	assert.Equal(t, api.Pointer(false), cfg.Services[1].Autostart)
+	assert.Equal(t, api.Pointer(true), m.Config.Services[0].Autostart, "the config of the machine itself is left alone")
+	assert.False(t, m.IsKeptBlue())
+
+	kept := &api.Machine{ID: m.ID, Config: cfg}
+	assert.True(t, kept.IsKeptBlue())
+
+	// rolling back restores the config the machine had
+	assert.Equal(t, m.Config, restoredBlueConfig(kept))
+	assert.False(t, (&api.Machine{Config: restoredBlueConfig(kept)}).IsKeptBlue())
+}
+
+func TestBlueGreenRollbackPlan(t *testing.T) {
+	machineOf := func(id string, release, replacedBy int) *api.Machine {
+		m := blueMachine(id)
+		m.Config.Metadata[api.MachineConfigMetadataKeyFlyReleaseVersion] = strconv.Itoa(release)
+		if replacedBy > 0 {
+			m.Config = keptBlueConfig(m, replacedBy)
+		}
+		return m
+	}
+
+	_, _, err := blueGreenRollbackPlan([]*api.Machine{machineOf("m1", 3, 0)})
+	assert.ErrorContains(t, err, "no blue machines to roll back to")
+
+	// the machines kept by the last deployment are rolled back to
+	blue, green, err := blueGreenRollbackPlan([]*api.Machine{
+		machineOf("old", 2, 3),
+		machineOf("blue1", 3, 4),
+		machineOf("green1", 4, 0),
+		machineOf("other", 1, 0),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"blue1"}, lo.Map(blue, func(m *api.Machine, _ int) string { return m.ID }))
+	assert.Equal(t, []string{"green1"}, lo.Map(green, func(m *api.Machine, _ int) string { return m.ID }))
+
+	_, _, err = blueGreenRollbackPlan([]*api.Machine{machineOf("blue1", 3, 4), machineOf("green1", 5, 0)})
+	assert.ErrorContains(t, err, "can't be rolled back to anymore")
+}
+
+func TestDeployBlueGreen_Rollback(t *testing.T) {
+	blue := []*api.Machine{blueMachine("blue1"), blueMachine("blue2")}
+	f := newFakeFlaps(t, blue...)
+	ctx, md := stabStrategyDeployment(t, f, blue...)
+	md.bgKeepOld = true
+
+	require.NoError(t, md.deployBlueGreen(ctx))
+	require.NoError(t, md.machineSet.ReleaseLeases(ctx))
+	require.Empty(t, f.destroyed)
+
+	require.NoError(t, rollbackBlueGreen(ctx, time.Second))
+	assert.ElementsMatch(t, []string{"green1", "green2"}, f.destroyed)
+	for _, id := range []string{"blue1", "blue2"} {
+		m := f.machines[id]
+		assert.Equal(t, api.MachineStateStarted, m.State)
+		assert.False(t, m.IsKeptBlue(), "%s is untagged", id)
+		assert.NotContains(t, m.Config.Metadata, keptBlueAutostartKey)
+		assert.Equal(t, api.Pointer(true), m.Config.Services[0].Autostart)
+	}
+
+	// there's nothing left to roll back to
+	assert.ErrorContains(t, rollbackBlueGreen(ctx, time.Second), "no blue machines to roll back to")
+}
+
+func TestSetMachinesForDeployment_SkipsKeptBlue(t *testing.T) {
+	kept := blueMachine("blue1")
+	kept.State = api.MachineStateStopped
+	kept.Config = keptBlueConfig(kept, 3)
+	f := newFakeFlaps(t, kept, blueMachine("green1"))
+	ctx, md := stabStrategyDeployment(t, f)
+
+	require.NoError(t, md.setMachinesForDeployment(ctx))
+	ids := []string{}
+	for _, m := range md.machineSet.GetMachines() {
+		ids = append(ids, m.Machine().ID)
+	}
+	assert.Equal(t, []string{"green1"}, ids)
+}
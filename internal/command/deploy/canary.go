@@ -0,0 +1,117 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/azazeal/pause"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/terminal"
+)
+
+const (
+	DefaultCanaryWait = 60 * time.Second
+
+	canaryPollInterval = 5 * time.Second
+)
+
+// deployCanary launches a single machine running the new release alongside
+// the existing ones and requires it to stay healthy for md.canaryWait. The
+// canary is destroyed either way: once it has proven itself the regular
+// rollout takes over, and when it fails the deployment is aborted before any
+// existing machine is touched.
+func (md *machineDeployment) deployCanary(ctx context.Context) (err error) {
+	base := md.machineSet.GetMachines()[0].Machine()
+	if len(base.Config.Mounts) > 0 {
+		return errors.New("the canary strategy doesn't support apps with volumes, as the canary machine would need a volume of its own; use the rolling strategy instead")
+	}
+
+	launchInput := md.resolveUpdatedMachineConfig(base, false)
+	launchInput.ID = ""
+
+	fmt.Fprintf(md.io.ErrOut, "  Launching canary machine in %s\n", md.colorize.Bold(base.Region))
+
+	canaryRaw, err := md.flapsClient.Launch(ctx, *launchInput)
+	if err != nil {
+		return fmt.Errorf("error creating canary machine: %w", err)
+	}
+	canary := machine.NewLeasableMachine(md.flapsClient, md.io, canaryRaw)
+
+	defer func() {
+		if destroyErr := md.destroyCanary(ctx, canaryRaw.ID); destroyErr != nil {
+			if err == nil {
+				err = destroyErr
+			} else {
+				terminal.Warnf("%v\n", destroyErr)
+			}
+		}
+	}()
+
+	if err := canary.WaitForState(ctx, api.MachineStateStarted, md.waitTimeout); err != nil {
+		return fmt.Errorf("canary machine %s failed to start, aborting deployment: %w", canaryRaw.ID, err)
+	}
+	if err := canary.WaitForHealthchecksToPass(ctx, md.waitTimeout); err != nil {
+		return fmt.Errorf("canary machine %s failed its health checks, aborting deployment: %w", canaryRaw.ID, err)
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "  Watching canary machine %s for %s\n", md.colorize.Bold(canary.FormattedMachineId()), md.canaryWait)
+
+	if err := md.soakCanary(ctx, canaryRaw.ID); err != nil {
+		return fmt.Errorf("canary machine %s %w, aborting deployment", canaryRaw.ID, err)
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "  Canary machine %s stayed healthy: %s\n",
+		md.colorize.Bold(canary.FormattedMachineId()),
+		md.colorize.Green("success"),
+	)
+
+	return nil
+}
+
+// soakCanary polls the canary until md.canaryWait has passed, and fails as
+// soon as it stops or one of its health checks turns critical.
+func (md *machineDeployment) soakCanary(ctx context.Context, id string) error {
+	deadline := time.Now().Add(md.canaryWait)
+
+	for {
+		m, err := md.flapsClient.Get(ctx, id)
+		if err != nil {
+			return fmt.Errorf("could not be retrieved: %w", err)
+		}
+		if m.State != api.MachineStateStarted {
+			return fmt.Errorf("is %s", m.State)
+		}
+		if status := m.HealthCheckStatus(); status.Critical > 0 {
+			return fmt.Errorf("has %d critical health check(s)", status.Critical)
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return nil
+		}
+		if remaining > canaryPollInterval {
+			remaining = canaryPollInterval
+		}
+		if pause.For(ctx, remaining); ctx.Err() != nil {
+			return ctx.Err()
+		}
+	}
+}
+
+func (md *machineDeployment) destroyCanary(ctx context.Context, id string) error {
+	err := md.flapsClient.Destroy(ctx, api.RemoveMachineInput{
+		AppID: md.app.Name,
+		ID:    id,
+		Kill:  true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed destroying canary machine %s: %w", id, err)
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "  Destroyed canary machine %s\n", md.colorize.Bold(id))
+	return nil
+}
@@ -0,0 +1,43 @@
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestDeployCanary(t *testing.T) {
+	base := blueMachine("base")
+	f := newFakeFlaps(t, base)
+	ctx, md := stabStrategyDeployment(t, f, base)
+
+	require.NoError(t, md.deployCanary(ctx))
+	assert.Equal(t, []string{"green1"}, f.launched)
+	assert.Equal(t, []string{"green1"}, f.destroyed, "the canary is destroyed once it proved itself")
+	assert.Equal(t, api.MachineStateStarted, f.machines["base"].State)
+	assert.Equal(t, "scl", f.machines["green1"].Region)
+}
+
+func TestDeployCanary_Unhealthy(t *testing.T) {
+	base := blueMachine("base")
+	f := newFakeFlaps(t, base)
+	ctx, md := stabStrategyDeployment(t, f, base)
+	f.getState = api.MachineStateStopped
+
+	assert.ErrorContains(t, md.deployCanary(ctx), "canary machine green1 is stopped, aborting deployment")
+	assert.Equal(t, []string{"green1"}, f.destroyed, "a failing canary is destroyed too")
+	assert.Equal(t, api.MachineStateStarted, f.machines["base"].State)
+}
+
+func TestDeployCanary_Volumes(t *testing.T) {
+	base := blueMachine("base")
+	base.Config.Mounts = []api.MachineMount{{Volume: "vol_123", Path: "/data"}}
+	f := newFakeFlaps(t, base)
+	ctx, md := stabStrategyDeployment(t, f, base)
+
+	assert.ErrorContains(t, md.deployCanary(ctx), "doesn't support apps with volumes")
+	assert.Empty(t, f.launched)
+}
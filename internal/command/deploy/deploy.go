@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 	"time"
@@ -19,6 +20,8 @@ import (
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/build/imgsrc"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/scale"
+	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/env"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/render"
@@ -31,6 +34,119 @@ import (
 	"github.com/superfly/flyctl/internal/watch"
 )
 
+// MachinesFlags tune how a deployment rolls out machines. They're shared by
+// every command that ends up calling DeployWithConfig.
+var MachinesFlags = flag.Set{
+	flag.Bool{
+		Name:        "auto-confirm",
+		Description: "Will automatically confirm changes when running non-interactively.",
+	},
+	flag.Int{
+		Name:        "wait-timeout",
+		Description: "Seconds to wait for individual machines to transition states and become healthy.",
+		Default:     int(DefaultWaitTimeout.Seconds()),
+	},
+	flag.Int{
+		Name:        "lease-timeout",
+		Description: "Seconds to lease individual machines while running deployment. All machines are leased at the beginning and released at the end. The lease is refreshed periodically for this same time, which is why it is short. flyctl releases leases in most cases.",
+		Default:     int(DefaultLeaseTtl.Seconds()),
+	},
+	flag.Int{
+		Name:        "canary-wait",
+		Description: "Seconds the canary machine must stay healthy before the remaining machines are updated. Only applies to the canary strategy.",
+		Default:     int(DefaultCanaryWait.Seconds()),
+	},
+	flag.Bool{
+		Name:        "bg-keep-old",
+		Description: "Keep the old machines after a bluegreen deployment, stopped, with autostart off and left alone by later deployments, so it can be rolled back with --bg-rollback.",
+	},
+	flag.Bool{
+		Name:        "bg-rollback",
+		Description: "Roll back the last bluegreen deployment with --bg-keep-old instead of deploying: the old machines it kept are restored and started, and the new ones destroyed once the old ones are healthy",
+	},
+	flag.Bool{
+		Name:        "pre-pull",
+		Description: "Pull the new image on hosts of the regions of the machines being updated before the rollout, so each machine is replaced without waiting for the image. The Machines API can't target hosts, so a machine, never started, is created and destroyed next to each one, on hosts the platform picks",
+	},
+	flag.Int{
+		Name:        "max-unavailable",
+		Description: "Maximum number of machines updated, and so unavailable, at the same time by the rolling strategy. Machines are health checked before the next ones are updated.",
+		Default:     1,
+	},
+	flag.Bool{
+		Name:        "rollback-on-failure",
+		Description: "Restore the previous configuration of the machines already updated when a machine fails to start, pass its health checks or, with --smoke-url, the smoke tests.",
+	},
+	flag.Bool{
+		Name:        "machines-only-metadata",
+		Description: "Tag every machine with the image digest, git commit and time of the deployment, next to its release version, for tools which don't query the API.",
+	},
+	flag.StringSlice{
+		Name:        "only-regions",
+		Description: "Only update the machines in these comma separated regions, to stage a change before deploying it everywhere. No machines are created or destroyed.",
+	},
+	flag.StringSlice{
+		Name:        "exclude-regions",
+		Description: "Don't update the machines in these comma separated regions. No machines are created or destroyed.",
+	},
+	flag.StringSlice{
+		Name:        "only-machines",
+		Description: "Only update the machines with these comma separated IDs. No machines are created or destroyed.",
+	},
+	flag.String{
+		Name:        "machine-id",
+		Description: "Only update the machine with this ID, leaving the others on their current release, to debug or stage a change on it. fly status reports the drift until the others are deployed to.",
+	},
+	flag.StringSlice{
+		Name:        "selector",
+		Description: "Only update the machines with this metadata, as key=value. Can be specified multiple times, in which case machines need all of it. No machines are created or destroyed.",
+	},
+	flag.String{
+		Name:        "vm-size",
+		Description: "The VM size preset of the machines deployed to, overriding their current size and [[vm]] sections of fly.toml",
+	},
+	flag.Int{
+		Name:        "vm-cpus",
+		Description: "The number of CPUs of the machines deployed to, overriding their current size, --vm-size and [[vm]] sections of fly.toml",
+	},
+	flag.Int{
+		Name:        "vm-memory",
+		Description: "The memory, in megabytes, of the machines deployed to, overriding their current size, --vm-size and [[vm]] sections of fly.toml",
+	},
+	flag.String{
+		Name:        "channel",
+		Description: "The release channel to deploy to, such as beta: only its machines, tagged with fly_release_channel metadata, are updated. Machines without a channel are in the stable one, the default",
+	},
+	flag.Int{
+		Name:        "channel-traffic",
+		Description: "The percentage of requests fly-proxy routes to the machines of --channel, the others going to the stable one. Requests with a fly-release-channel header or cookie naming a channel are always routed to it. Unchanged when not set",
+	},
+	flag.String{
+		Name:        "release-command-vm-size",
+		Description: "The VM size preset of the machines release commands run in, overriding [deploy.release_command_vm]",
+	},
+	flag.String{
+		Name:        "release-command-region",
+		Description: "The region release commands run in, overriding [deploy.release_command_vm] and the primary region",
+	},
+	flag.StringSlice{
+		Name:        "smoke-url",
+		Description: "A URL, public or .internal, requested once the machines are deployed to check the new release. The deployment fails when it doesn't respond as expected. Can be specified multiple times.",
+	},
+	flag.Int{
+		Name:        "smoke-status",
+		Description: "The status code expected from --smoke-url, instead of any 2xx or 3xx",
+	},
+	flag.String{
+		Name:        "smoke-contains",
+		Description: "Text the responses of --smoke-url must contain",
+	},
+	flag.String{
+		Name:        "progress-webhook",
+		Description: "A URL each event of the deployment, such as a machine being updated, is POSTed to as JSON while deploying, the events fly deploy --json writes",
+	},
+}
+
 var CommonFlags = flag.Set{
 	flag.Region(),
 	flag.Image(),
@@ -49,24 +165,78 @@ var CommonFlags = flag.Set{
 	flag.NoCache(),
 	flag.Nixpacks(),
 	flag.BuildOnly(),
+	flag.String{
+		Name:        "build-cache-ref",
+		Description: "A registry image, such as registry.fly.io/my-app:cache, the BuildKit cache of Dockerfile builds is imported from and exported to after every build, so that builds on fresh builders start warm",
+	},
+	flag.StringSlice{
+		Name:        "push-to",
+		Description: "An image ref in another registry, such as ghcr.io/my-org/my-app:v1, the built image is also pushed to. Can be specified multiple times",
+	},
+	flag.String{
+		Name:        "output",
+		Description: "Write the built image to this file as a tarball, loadable with docker load, such as to ship the build of --build-only elsewhere",
+	},
+	flag.String{
+		Name:        "push-to-username",
+		Description: "The username --push-to registries are authenticated with, along with the password in " + pushToPasswordEnv,
+	},
+	flag.Bool{
+		Name:        "sbom",
+		Description: "Generate a software bill of materials of the built image with syft, attached to the image in Fly's registry when it's pushed there",
+	},
+	flag.String{
+		Name:        "sbom-format",
+		Description: "The format of the software bill of materials, cyclonedx or spdx",
+		Default:     imgsrc.SBOMFormatCycloneDX,
+	},
+	flag.String{
+		Name:        "sbom-output",
+		Description: "Write the software bill of materials of the built image to this file",
+	},
+	flag.String{
+		Name:        "build-platform",
+		Description: "The platform to build the image for, linux/amd64 or linux/arm64. Building for another platform than the builder's own is emulated",
+		Default:     imgsrc.DefaultBuildPlatform,
+	},
+	flag.String{
+		Name:        "build-network",
+		Description: "The network access of the build steps, default or none. With none, RUN steps can't reach the internet; the builder still pulls base images",
+		Default:     imgsrc.BuildNetworkDefault,
+	},
+	flag.Bool{
+		Name:        "seekable-layers",
+		Description: "Push the image with eStargz compressed layers, which runtimes supporting it can pull lazily. Needs a buildkitd, found as with --local-only without Docker. Machines pull the image in full for now, as the Machines API has no lazy pulling option",
+	},
+	flag.String{
+		Name:        "build-cache-namespace",
+		Description: "Namespace the cache mounts (RUN --mount=type=cache) of the Dockerfile, so builds sharing a namespace, like those of an app or a repository, reuse their caches on the remote builder",
+	},
 	flag.StringSlice{
 		Name:        "env",
 		Shorthand:   "e",
 		Description: "Set of environment variables in the form of NAME=VALUE pairs. Can be specified multiple times.",
 	},
+	flag.String{
+		Name:        "env-config",
+		Description: "The environment whose overlay of the app configuration, such as fly.staging.toml for staging, is merged on top of it. Tables are merged key by key, other values, arrays of tables like [[services]] included, are replaced.",
+	},
 	flag.Bool{
-		Name:        "auto-confirm",
-		Description: "Will automatically confirm changes when running non-interactively.",
+		Name:        "interpolate",
+		Description: "Replace the ${NAME} references of the app configuration with variables of the environment or of --var. Without it, references are left for the shell of the machines",
 	},
-	flag.Int{
-		Name:        "wait-timeout",
-		Description: "Seconds to wait for individual machines to transition states and become healthy.",
-		Default:     int(DefaultWaitTimeout.Seconds()),
+	flag.StringSlice{
+		Name:        "var",
+		Description: "Set a variable referenced as ${NAME} in the app configuration, in the form of NAME=VALUE, taking precedence over the environment. Implies --interpolate. Can be specified multiple times.",
 	},
-	flag.Int{
-		Name:        "lease-timeout",
-		Description: "Seconds to lease individual machines while running deployment. All machines are leased at the beginning and released at the end. The lease is refreshed periodically for this same time, which is why it is short. flyctl releases leases in most cases.",
-		Default:     int(DefaultLeaseTtl.Seconds()),
+	flag.StringSlice{
+		Name:        "secret-file",
+		Description: "Set the secrets of a .env file as part of the deployment, instead of with a separate fly secrets set. Can be specified multiple times, later files taking precedence.",
+	},
+	MachinesFlags,
+	flag.Bool{
+		Name:        "attach-artifacts",
+		Description: "Upload the artifacts listed in the app configuration to the volume of each machine",
 	},
 	flag.Bool{
 		Name:        "force-nomad",
@@ -93,6 +263,7 @@ func New() (cmd *cobra.Command) {
 		command.RequireSession,
 		command.ChangeWorkingDirectoryToFirstArgIfPresent,
 		command.RequireAppName,
+		command.RequireCompatibleVersion("deploy"),
 	)
 
 	cmd.Args = cobra.MaximumNArgs(1)
@@ -101,6 +272,23 @@ func New() (cmd *cobra.Command) {
 		CommonFlags,
 		flag.App(),
 		flag.AppConfig(),
+		flag.Bool{
+			Name:        "dry-run",
+			Description: "Build the image and show how the config of every machine would change, without deploying",
+		},
+		flag.Bool{
+			Name:        "watch",
+			Description: "Keep watching the working directory, honoring .dockerignore, and rebuild and redeploy the app whenever it changes",
+		},
+		flag.Int{
+			Name:        "watch-debounce",
+			Description: "Seconds the working directory must stay unchanged before --watch redeploys",
+			Default:     2,
+		},
+		flag.Bool{
+			Name:        "resume",
+			Description: "Resume the last deployment of the app, which failed, after its last completed phase: the images it pushed are deployed without building and pushing them again",
+		},
 	)
 
 	return
@@ -112,17 +300,63 @@ func run(ctx context.Context) error {
 		return err
 	}
 
-	return DeployWithConfig(ctx, appConfig, DeployWithConfigArgs{
+	args := DeployWithConfigArgs{
 		ForceNomad:    flag.GetBool(ctx, "force-nomad"),
 		ForceMachines: flag.GetBool(ctx, "force-machines"),
 		ForceYes:      flag.GetBool(ctx, "auto-confirm"),
-	})
+		DryRun:        flag.GetBool(ctx, "dry-run"),
+	}
+	if flag.GetBool(ctx, "bg-rollback") {
+		return runBlueGreenRollback(ctx)
+	}
+	if flag.GetBool(ctx, "resume") {
+		switch {
+		case flag.GetBool(ctx, "watch"):
+			return errors.New("--resume can't be used with --watch")
+		case flag.GetString(ctx, "image") != "":
+			return errors.New("--resume can't be used with --image, which skips building already")
+		case flag.GetBuildOnly(ctx):
+			return errors.New("--resume can't be used with --build-only")
+		}
+		if err := resumeDeployment(ctx, appConfig.AppName, &args); err != nil {
+			return err
+		}
+	}
+	if flag.GetBool(ctx, "watch") {
+		return runWatch(ctx, appConfig, args)
+	}
+
+	return DeployWithConfig(ctx, appConfig, args)
+}
+
+// runBlueGreenRollback rolls the app back to the blue machines its last
+// bluegreen deployment kept.
+func runBlueGreenRollback(ctx context.Context) error {
+	apiClient := client.FromContext(ctx).API()
+	appCompact, err := apiClient.GetAppCompact(ctx, appconfig.NameFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	flapsClient, err := flaps.New(ctx, appCompact)
+	if err != nil {
+		return err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	return rollbackBlueGreen(ctx, time.Duration(flag.GetInt(ctx, "wait-timeout"))*time.Second)
 }
 
 type DeployWithConfigArgs struct {
 	ForceMachines bool
 	ForceNomad    bool
 	ForceYes      bool
+	DryRun        bool
+	// Image, when set, is deployed instead of building or resolving the image
+	// appConfig refers to
+	Image *imgsrc.DeploymentImage
+	// ProcessImages, when Image is set, are deployed to the process groups
+	// with their own image instead of building them
+	ProcessImages map[string]*imgsrc.DeploymentImage
 }
 
 func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, args DeployWithConfigArgs) (err error) {
@@ -137,6 +371,45 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, args Dep
 		return err
 	}
 
+	if args.DryRun && !deployToMachines {
+		return errors.New("--dry-run is only supported for apps running on machines")
+	}
+
+	// with --json, stdout carries a stream of events and everything else is
+	// written to stderr. The same events are posted to --progress-webhook.
+	var (
+		jsonOutput = config.FromContext(ctx).JSONOutput
+		webhookURL = flag.GetString(ctx, "progress-webhook")
+	)
+	if (jsonOutput || webhookURL != "") && !args.DryRun {
+		io := iostreams.FromContext(ctx)
+		events := newEventStream(nil, appCompact.Name)
+		if jsonOutput {
+			events = newEventStream(io.Out, appCompact.Name)
+		}
+		if webhookURL != "" {
+			if err := events.postTo(webhookURL); err != nil {
+				return err
+			}
+		}
+		defer func() {
+			e := Event{Type: EventDeployFinished, Status: "succeeded"}
+			if err != nil {
+				e.Status = "failed"
+				e.Error = err.Error()
+			}
+			events.emit(e)
+			events.close()
+		}()
+
+		if jsonOutput {
+			quiet := *io
+			quiet.Out = io.ErrOut
+			ctx = iostreams.NewContext(ctx, &quiet)
+		}
+		ctx = withEvents(ctx, events)
+	}
+
 	if deployToMachines {
 		err := appConfig.EnsureV2Config()
 		if err != nil {
@@ -144,16 +417,78 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, args Dep
 		}
 	}
 
-	// Fetch an image ref or build from source to get the final image reference to deploy
-	img, err := determineImage(ctx, appConfig)
+	if appConfig.Deploy != nil && len(appConfig.Deploy.ReleaseCommands) > 0 && !deployToMachines {
+		return errors.New("release_commands are only supported by the machines platform; use release_command instead")
+	}
+	secrets, err := secretsFromFiles(flag.GetStringSlice(ctx, "secret-file"))
+	if err != nil {
+		return err
+	}
+	if len(secrets) > 0 && !deployToMachines {
+		return errors.New("--secret-file is only supported for apps running on machines; use fly secrets import instead")
+	}
+	onlyMachines, err := selectedMachineIDs(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to fetch an image or build from source: %w", err)
+		return err
+	}
+	if len(onlyMachines) > 0 && !deployToMachines {
+		return errors.New("--machine-id and --only-machines are only supported for apps running on machines")
+	}
+	if deployHooks(appConfig).PostInMachine && !deployToMachines {
+		return errors.New("post-deploy hooks can only run in a machine for apps running on machines; unset post_in_machine in [deploy.hooks]")
+	}
+	if err := runPreDeployHooks(ctx, appConfig); err != nil {
+		return err
+	}
+
+	// Fetch an image ref or build from source to get the final image reference to deploy
+	img := args.Image
+	if img == nil {
+		img, err = DetermineImage(ctx, appConfig)
+		if err != nil {
+			return fmt.Errorf("failed to fetch an image or build from source: %w", err)
+		}
+	}
+
+	processImages := args.ProcessImages
+	if len(appConfig.ProcessGroupsWithOwnImage()) > 0 {
+		if !deployToMachines {
+			return errors.New("per process group images are only supported by the machines platform; remove [build.processes] from the app config")
+		}
+		if processImages == nil {
+			if processImages, err = DetermineProcessImages(ctx, appConfig); err != nil {
+				return err
+			}
+		}
 	}
 
 	if flag.GetBuildOnly(ctx) {
 		return nil
 	}
 
+	// the images are pushed, which a failed deployment is resumed after with
+	// --resume
+	if !args.DryRun {
+		cacheDir := state.CacheDirectory(ctx)
+		pushed := &resumeState{
+			App:           appCompact.Name,
+			Image:         img,
+			ProcessImages: processImages,
+			PushedAt:      time.Now(),
+		}
+		if err := saveResumeState(cacheDir, pushed); err != nil {
+			terminal.Debugf("failed saving the state of the deployment: %v\n", err)
+		}
+		defer func() {
+			if err != nil {
+				return
+			}
+			if err := clearResumeState(cacheDir, appCompact.Name); err != nil {
+				terminal.Debugf("failed clearing the state of the deployment: %v\n", err)
+			}
+		}()
+	}
+
 	var release *api.Release
 	var releaseCommand *api.ReleaseCommand
 
@@ -163,39 +498,96 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, args Dep
 	}
 
 	if deployToMachines {
+		selector, err := cmdutil.ParseKVStringsToMap(flag.GetStringSlice(ctx, "selector"))
+		if err != nil {
+			return fmt.Errorf("invalid selector: %w", err)
+		}
+
+		var channelTraffic *int
+		if flag.IsSpecified(ctx, "channel-traffic") {
+			channelTraffic = api.IntPointer(flag.GetInt(ctx, "channel-traffic"))
+		}
+
+		if err := stageSecrets(ctx, appCompact.Name, secrets, args.DryRun); err != nil {
+			return err
+		}
+
 		primaryRegion := appConfig.PrimaryRegion
 		if flag.GetString(ctx, flag.RegionName) != "" {
 			primaryRegion = flag.GetString(ctx, flag.RegionName)
 		}
 
 		md, err := NewMachineDeployment(ctx, MachineDeploymentArgs{
-			AppCompact:        appCompact,
-			DeploymentImage:   img,
-			Strategy:          flag.GetString(ctx, "strategy"),
-			EnvFromFlags:      flag.GetStringSlice(ctx, "env"),
-			PrimaryRegionFlag: primaryRegion,
-			BuildOnly:         flag.GetBuildOnly(ctx),
-			SkipHealthChecks:  flag.GetDetach(ctx),
-			WaitTimeout:       time.Duration(flag.GetInt(ctx, "wait-timeout")) * time.Second,
-			LeaseTimeout:      time.Duration(flag.GetInt(ctx, "lease-timeout")) * time.Second,
+			AppCompact:           appCompact,
+			DeploymentImage:      img,
+			ProcessImages:        processImages,
+			Strategy:             flag.GetString(ctx, "strategy"),
+			EnvFromFlags:         flag.GetStringSlice(ctx, "env"),
+			PrimaryRegionFlag:    primaryRegion,
+			BuildOnly:            flag.GetBuildOnly(ctx),
+			SkipHealthChecks:     flag.GetDetach(ctx),
+			WaitTimeout:          time.Duration(flag.GetInt(ctx, "wait-timeout")) * time.Second,
+			LeaseTimeout:         time.Duration(flag.GetInt(ctx, "lease-timeout")) * time.Second,
+			CanaryWait:           time.Duration(flag.GetInt(ctx, "canary-wait")) * time.Second,
+			BGKeepOld:            flag.GetBool(ctx, "bg-keep-old"),
+			PrePull:              flag.GetBool(ctx, "pre-pull"),
+			RollbackOnFailure:    flag.GetBool(ctx, "rollback-on-failure"),
+			MaxUnavailable:       flag.GetInt(ctx, "max-unavailable"),
+			OnlyRegions:          flag.GetStringSlice(ctx, "only-regions"),
+			ExcludeRegions:       flag.GetStringSlice(ctx, "exclude-regions"),
+			OnlyMachines:         onlyMachines,
+			Selector:             selector,
+			Channel:              flag.GetString(ctx, "channel"),
+			ChannelTraffic:       channelTraffic,
+			VMSize:               flag.GetString(ctx, "vm-size"),
+			VMCPUs:               flag.GetInt(ctx, "vm-cpus"),
+			VMMemory:             flag.GetInt(ctx, "vm-memory"),
+			ReleaseCommandVMSize: flag.GetString(ctx, "release-command-vm-size"),
+			ReleaseCommandRegion: flag.GetString(ctx, "release-command-region"),
+			ReleaseMetadata:      flag.GetBool(ctx, "machines-only-metadata"),
+			SmokeURLs:            flag.GetStringSlice(ctx, "smoke-url"),
+			SmokeStatus:          flag.GetInt(ctx, "smoke-status"),
+			SmokeContains:        flag.GetString(ctx, "smoke-contains"),
+			AttachArtifacts:      flag.GetBool(ctx, "attach-artifacts"),
+			DryRun:               args.DryRun,
 		})
 		if err != nil {
 			sentry.CaptureExceptionWithAppInfo(err, "deploy", appCompact)
 			return err
 		}
+		if args.DryRun {
+			plan := md.Plan()
+			if config.FromContext(ctx).JSONOutput {
+				return render.JSON(iostreams.FromContext(ctx).Out, plan)
+			}
+			renderPlan(iostreams.FromContext(ctx), plan)
+			return nil
+		}
 		err = md.DeployMachinesApp(ctx)
 		if err != nil {
 			sentry.CaptureExceptionWithAppInfo(err, "deploy", appCompact)
+			return err
 		}
-		return err
+		// only a local fly.toml has the schedule, a config rebuilt from the
+		// machines would remove it
+		if appConfig.ConfigFilePath() != "" {
+			if err := scale.SyncSchedule(ctx, appCompact, appConfig); err != nil {
+				return fmt.Errorf("failed applying the scaling schedule: %w", err)
+			}
+		}
+		return runPostDeployHooks(ctx, appConfig, img, md)
 	}
 
 	release, releaseCommand, err = createRelease(ctx, appConfig, img)
 	if err != nil {
 		return err
 	}
+	eventsFromContext(ctx).emit(Event{Type: EventReleaseCreated, Release: release.Version, Image: img.Tag})
 
 	if flag.GetDetach(ctx) {
+		if len(deployHooks(appConfig).Post) > 0 {
+			terminal.Warnf("Skipping post-deploy hooks, as the deployment isn't waited for with --detach\n")
+		}
 		return nil
 	}
 
@@ -223,12 +615,29 @@ func DeployWithConfig(ctx context.Context, appConfig *appconfig.Config, args Dep
 		logger := logger.FromContext(ctx)
 		logger.Debug("immediate deployment strategy, nothing to monitor")
 
-		return nil
+		return runPostDeployHooks(ctx, appConfig, img, nil)
+	}
+
+	if err = watch.Deployment(ctx, appConfig.AppName, release.EvaluationID); err != nil {
+		return err
 	}
 
-	err = watch.Deployment(ctx, appConfig.AppName, release.EvaluationID)
+	return runPostDeployHooks(ctx, appConfig, img, nil)
+}
 
-	return err
+// selectedMachineIDs returns the IDs of the machines the deployment is
+// restricted to, by --machine-id or --only-machines.
+func selectedMachineIDs(ctx context.Context) ([]string, error) {
+	onlyMachines := flag.GetStringSlice(ctx, "only-machines")
+	machineID := flag.GetString(ctx, "machine-id")
+	switch {
+	case machineID == "":
+		return onlyMachines, nil
+	case len(onlyMachines) > 0:
+		return nil, errors.New("--machine-id and --only-machines can't be used together")
+	default:
+		return []string{machineID}, nil
+	}
 }
 
 func useMachines(ctx context.Context, appConfig *appconfig.Config, appCompact *api.AppCompact, args DeployWithConfigArgs, apiClient *api.Client) (bool, error) {
@@ -302,10 +711,167 @@ func determineAppConfig(ctx context.Context) (cfg *appconfig.Config, err error)
 	return
 }
 
-// determineImage picks the deployment strategy, builds the image and returns a
+// DetermineImage picks the deployment strategy, builds the image and returns a
 // DeploymentImage struct
-func determineImage(ctx context.Context, appConfig *appconfig.Config) (img *imgsrc.DeploymentImage, err error) {
+func DetermineImage(ctx context.Context, appConfig *appconfig.Config) (img *imgsrc.DeploymentImage, err error) {
+	return determineImage(ctx, appConfig, imageTargetsFromFlags(ctx))
+}
+
+// ImageBuild is the image of an app built by BuildImages.
+type ImageBuild struct {
+	// App is the name of the app, and Source what its image is built from,
+	// as printed
+	App    string
+	Source string
+	// Key identifies what the image is built from: builds with the same key
+	// share the image of the first one, those without one are never shared
+	Key string
+	// Context and Config are those DetermineImage builds the image with
+	Context context.Context
+	Config  *appconfig.Config
+}
+
+// BuildImages builds the images of builds, in order, returning them in the
+// same order. Commands deploying several apps build everything upfront so a
+// broken build doesn't leave them half deployed.
+func BuildImages(ctx context.Context, builds []ImageBuild) ([]*imgsrc.DeploymentImage, error) {
+	io := iostreams.FromContext(ctx)
+
+	imgs := make([]*imgsrc.DeploymentImage, len(builds))
+	built := map[string]int{}
+	for i, b := range builds {
+		if from, ok := built[b.Key]; ok && b.Key != "" {
+			fmt.Fprintf(io.Out, "==> [%d/%d] Using the image of %s for %s\n", i+1, len(builds), builds[from].App, b.App)
+			imgs[i] = imgs[from]
+			continue
+		}
+
+		fmt.Fprintf(io.Out, "==> [%d/%d] Building image for %s (%s)\n", i+1, len(builds), b.App, b.Source)
+		img, err := DetermineImage(b.Context, b.Config)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch an image or build from source for %s: %w", b.App, err)
+		}
+		imgs[i] = img
+		built[b.Key] = i
+	}
+
+	return imgs, nil
+}
+
+// imageTargets are how a built image is labelled and where it's shipped
+// besides Fly's registry.
+type imageTargets struct {
+	label      string
+	cacheRef   string
+	pushTo     []string
+	output     string
+	sbomOutput string
+}
+
+func imageTargetsFromFlags(ctx context.Context) imageTargets {
+	return imageTargets{
+		label:      flag.GetString(ctx, "image-label"),
+		cacheRef:   flag.GetString(ctx, "build-cache-ref"),
+		pushTo:     flag.GetStringSlice(ctx, "push-to"),
+		output:     flag.GetString(ctx, "output"),
+		sbomOutput: flag.GetString(ctx, "sbom-output"),
+	}
+}
+
+// forProcessGroup gives the image of a process group its own targets next to
+// those of the app's image, as it's built from another Dockerfile.
+func (t imageTargets) forProcessGroup(group string) imageTargets {
+	groupTargets := imageTargets{
+		cacheRef: processGroupRef(t.cacheRef, group),
+	}
+	// every image of a deployment shares the label, tell them apart
+	if t.label != "" {
+		groupTargets.label = t.label + "-" + group
+	}
+	for _, ref := range t.pushTo {
+		groupTargets.pushTo = append(groupTargets.pushTo, processGroupRef(ref, group))
+	}
+	groupTargets.output = processGroupPath(t.output, group)
+	groupTargets.sbomOutput = processGroupPath(t.sbomOutput, group)
+
+	return groupTargets
+}
+
+// processGroupPath gives the file of a process group its own name next to
+// path, such as app-worker.tar for app.tar.
+func processGroupPath(path, group string) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "-" + group + ext
+}
+
+// DetermineProcessImages builds or resolves the images of the process groups
+// which don't run the app's image, keyed by process group.
+func DetermineProcessImages(ctx context.Context, appConfig *appconfig.Config) (map[string]*imgsrc.DeploymentImage, error) {
+	groups := appConfig.ProcessGroupsWithOwnImage()
+	if len(groups) == 0 {
+		return nil, nil
+	}
+
+	if flag.GetString(ctx, "image") != "" {
+		terminal.Warnf("Deploying the image passed with --image to every process group, ignoring [build.processes]\n")
+		return nil, nil
+	}
+
+	images := make(map[string]*imgsrc.DeploymentImage, len(groups))
+	for _, group := range groups {
+		groupConfig := *appConfig
+		groupConfig.Build = appConfig.BuildForProcessGroup(group)
+
+		img, err := determineImage(ctx, &groupConfig, imageTargetsFromFlags(ctx).forProcessGroup(group))
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch an image or build from source for process group %s: %w", group, err)
+		}
+		images[group] = img
+	}
+
+	return images, nil
+}
+
+// processGroupRef gives the image of a process group its own image ref next to
+// ref.
+func processGroupRef(ref, group string) string {
+	switch {
+	case ref == "":
+		return ""
+	case strings.LastIndex(ref, ":") > strings.LastIndex(ref, "/"):
+		return ref + "-" + group
+	default:
+		return ref + ":" + group
+	}
+}
+
+// pushToPasswordEnv holds the password of --push-to-username, to keep it out
+// of the command line.
+const pushToPasswordEnv = "FLY_PUSH_TO_PASSWORD"
+
+func determineImage(ctx context.Context, appConfig *appconfig.Config, targets imageTargets) (img *imgsrc.DeploymentImage, err error) {
+	label := targets.label
+	if strings.Contains(targets.cacheRef, "@") {
+		return nil, fmt.Errorf("build cache ref %s can't pin a digest, as it's replaced after every build", targets.cacheRef)
+	}
+	for _, ref := range targets.pushTo {
+		if err := imgsrc.ValidatePushRef(ref); err != nil {
+			return nil, err
+		}
+	}
+
 	tb := render.NewTextBlock(ctx, "Building image")
+	events := eventsFromContext(ctx)
+	events.emit(Event{Type: EventBuildStarted, Group: label})
+	defer func() {
+		if err == nil && img != nil {
+			events.emit(Event{Type: EventImagePushed, Group: label, Image: img.Tag})
+		}
+	}()
+
 	daemonType := imgsrc.NewDockerDaemonType(!flag.GetRemoteOnly(ctx), !flag.GetLocalOnly(ctx), env.IsCI(), flag.GetBool(ctx, "nixpacks"))
 
 	client := client.FromContext(ctx).API()
@@ -333,7 +899,7 @@ func determineImage(ctx context.Context, appConfig *appconfig.Config) (img *imgs
 			WorkingDir: state.WorkingDirectory(ctx),
 			Publish:    !flag.GetBuildOnly(ctx),
 			ImageRef:   imageRef,
-			ImageLabel: flag.GetString(ctx, "image-label"),
+			ImageLabel: label,
 		}
 
 		img, err = resolver.ResolveReference(ctx, io, opts)
@@ -351,12 +917,57 @@ func determineImage(ctx context.Context, appConfig *appconfig.Config) (img *imgs
 		AppName:         appConfig.AppName,
 		WorkingDir:      state.WorkingDirectory(ctx),
 		Publish:         flag.GetBool(ctx, "push") || !flag.GetBuildOnly(ctx),
-		ImageLabel:      flag.GetString(ctx, "image-label"),
+		ImageLabel:      label,
 		NoCache:         flag.GetBool(ctx, "no-cache"),
 		BuiltIn:         build.Builtin,
 		BuiltInSettings: build.Settings,
 		Builder:         build.Builder,
 		Buildpacks:      build.Buildpacks,
+		CacheNamespace:  build.CacheNamespace,
+		CacheRef:        targets.cacheRef,
+	}
+
+	if namespace := flag.GetString(ctx, "build-cache-namespace"); namespace != "" {
+		opts.CacheNamespace = namespace
+	}
+
+	if len(targets.pushTo) > 0 {
+		opts.PushTo = targets.pushTo
+		if username := flag.GetString(ctx, "push-to-username"); username != "" {
+			password := os.Getenv(pushToPasswordEnv)
+			if password == "" {
+				return nil, fmt.Errorf("--push-to-username requires the password in %s", pushToPasswordEnv)
+			}
+			opts.PushToAuth = &imgsrc.RegistryCredentials{Username: username, Password: password}
+		}
+	}
+
+	opts.OutputPath = targets.output
+	opts.SeekableLayers = flag.GetBool(ctx, "seekable-layers")
+
+	if flag.GetBool(ctx, "sbom") {
+		format := flag.GetString(ctx, "sbom-format")
+		if err = imgsrc.ValidateSBOMFormat(format); err != nil {
+			return
+		}
+		opts.SBOMFormat = format
+		opts.SBOMOutput = targets.sbomOutput
+	} else if targets.sbomOutput != "" {
+		return nil, fmt.Errorf("--sbom-output requires --sbom")
+	}
+
+	if platform := flag.GetString(ctx, "build-platform"); platform != "" {
+		if err = imgsrc.ValidateBuildPlatform(platform); err != nil {
+			return
+		}
+		opts.Platform = platform
+	}
+
+	if network := flag.GetString(ctx, "build-network"); network != "" {
+		if err = imgsrc.ValidateBuildNetwork(network); err != nil {
+			return
+		}
+		opts.Network = network
 	}
 
 	cliBuildSecrets, err := cmdutil.ParseKVStringsToMap(flag.GetStringSlice(ctx, "build-secret"))
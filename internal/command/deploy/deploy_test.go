@@ -0,0 +1,34 @@
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_processGroupRef(t *testing.T) {
+	assert.Equal(t, "", processGroupRef("", "worker"))
+	assert.Equal(t, "registry.fly.io/my-app:cache-worker", processGroupRef("registry.fly.io/my-app:cache", "worker"))
+	assert.Equal(t, "registry.fly.io/my-app:worker", processGroupRef("registry.fly.io/my-app", "worker"))
+	assert.Equal(t, "localhost:5000/my-app:worker", processGroupRef("localhost:5000/my-app", "worker"))
+}
+
+func Test_imageTargets_forProcessGroup(t *testing.T) {
+	targets := imageTargets{
+		label:      "v42",
+		cacheRef:   "registry.fly.io/my-app:cache",
+		pushTo:     []string{"ghcr.io/my-org/my-app:v42"},
+		output:     "build/my-app.tar",
+		sbomOutput: "sbom.json",
+	}
+
+	assert.Equal(t, imageTargets{
+		label:      "v42-worker",
+		cacheRef:   "registry.fly.io/my-app:cache-worker",
+		pushTo:     []string{"ghcr.io/my-org/my-app:v42-worker"},
+		output:     "build/my-app-worker.tar",
+		sbomOutput: "sbom-worker.json",
+	}, targets.forProcessGroup("worker"))
+
+	assert.Equal(t, imageTargets{}, imageTargets{}.forProcessGroup("worker"))
+}
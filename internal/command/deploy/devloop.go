@@ -0,0 +1,206 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/pkg/fileutils"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// watchPollInterval is how often the build context is scanned for changes.
+const watchPollInterval = 500 * time.Millisecond
+
+// fileStamp identifies a version of a file without reading it.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// sourceSnapshot maps the files of a build context, relative to its root, to
+// their stamps.
+type sourceSnapshot map[string]fileStamp
+
+// snapshotSource stamps the files of dir which aren't matched by the
+// .dockerignore style excludes. The .git directory is always skipped.
+func snapshotSource(dir string, excludes []string) (sourceSnapshot, error) {
+	pm, err := fileutils.NewPatternMatcher(excludes)
+	if err != nil {
+		return nil, fmt.Errorf("invalid .dockerignore patterns: %w", err)
+	}
+
+	snapshot := sourceSnapshot{}
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// files vanish while being edited; they're picked up next time
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil || rel == "." {
+			return err
+		}
+		if d.IsDir() && d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		if excluded, _ := pm.Matches(rel); excluded {
+			// a directory can only be skipped when no pattern brings back
+			// some of its files
+			if d.IsDir() && !pm.Exclusions() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		snapshot[filepath.ToSlash(rel)] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+
+		return nil
+	})
+
+	return snapshot, err
+}
+
+// changedFiles returns the sorted paths added, removed or modified between
+// the snapshots.
+func changedFiles(before, after sourceSnapshot) []string {
+	var changed []string
+	for path, stamp := range after {
+		if prev, ok := before[path]; !ok || prev != stamp {
+			changed = append(changed, path)
+		}
+	}
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	sort.Strings(changed)
+
+	return changed
+}
+
+// waitForChanges blocks until the build context of dir differs from snapshot
+// and then stays unchanged for debounce, so that a burst of saves triggers a
+// single deployment. It returns the new snapshot and the paths which changed.
+func waitForChanges(ctx context.Context, dir, ignoreFile string, snapshot sourceSnapshot, debounce time.Duration) (sourceSnapshot, []string, error) {
+	var (
+		current   = snapshot
+		changedAt time.Time
+	)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, nil, ctx.Err()
+		case <-time.After(watchPollInterval):
+		}
+
+		// the ignore file itself may have been edited
+		excludes, err := imgsrc.ReadDockerignore(dir, ignoreFile)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading .dockerignore: %w", err)
+		}
+		next, err := snapshotSource(dir, excludes)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		switch {
+		case len(changedFiles(current, next)) > 0:
+			current, changedAt = next, time.Now()
+		case !changedAt.IsZero() && time.Since(changedAt) >= debounce:
+			if changed := changedFiles(snapshot, current); len(changed) > 0 {
+				return current, changed, nil
+			}
+			// the files were changed back
+			changedAt = time.Time{}
+		}
+	}
+}
+
+// runWatch deploys the app and then deploys it again every time its build
+// context changes, until interrupted. Failed deployments are reported and the
+// next change tries again.
+func runWatch(ctx context.Context, appConfig *appconfig.Config, args DeployWithConfigArgs) error {
+	switch {
+	case args.DryRun:
+		return errors.New("--watch can't be combined with --dry-run")
+	case flag.GetString(ctx, "image") != "" || (appConfig.Build != nil && appConfig.Build.Image != ""):
+		return errors.New("--watch rebuilds the image from source, so it can't deploy a prebuilt image")
+	case flag.GetBuildOnly(ctx):
+		return errors.New("--watch can't be combined with --build-only")
+	}
+
+	var (
+		io       = iostreams.FromContext(ctx)
+		dir      = state.WorkingDirectory(ctx)
+		debounce = time.Duration(flag.GetInt(ctx, "watch-debounce")) * time.Second
+	)
+
+	ignoreFile, err := resolveIgnorefilePath(ctx, appConfig)
+	if err != nil {
+		return err
+	}
+	excludes, err := imgsrc.ReadDockerignore(dir, ignoreFile)
+	if err != nil {
+		return fmt.Errorf("error reading .dockerignore: %w", err)
+	}
+	snapshot, err := snapshotSource(dir, excludes)
+	if err != nil {
+		return err
+	}
+
+	for {
+		if err := DeployWithConfig(ctx, appConfig, args); err != nil {
+			if errors.Is(err, context.Canceled) {
+				return nil
+			}
+			fmt.Fprintf(io.ErrOut, "%s Deployment failed: %v\n", io.ColorScheme().FailureIcon(), err)
+		}
+
+		fmt.Fprintf(io.ErrOut, "Watching %s for changes, press Ctrl+C to stop\n", dir)
+
+		var changed []string
+		snapshot, changed, err = waitForChanges(ctx, dir, ignoreFile, snapshot, debounce)
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil
+		case err != nil:
+			return err
+		}
+
+		fmt.Fprintf(io.ErrOut, "Changed: %s\n", summarizePaths(changed, 5))
+	}
+}
+
+// summarizePaths joins the first limit paths, counting the others.
+func summarizePaths(paths []string, limit int) string {
+	if len(paths) <= limit {
+		return strings.Join(paths, ", ")
+	}
+	return fmt.Sprintf("%s and %d more", strings.Join(paths[:limit], ", "), len(paths)-limit)
+}
@@ -0,0 +1,53 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_snapshotSource(t *testing.T) {
+	dir := t.TempDir()
+	write := func(path, contents string) {
+		path = filepath.Join(dir, path)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(contents), 0o644))
+	}
+
+	write("main.go", "package main")
+	write("node_modules/dep/index.js", "")
+	write("logs/keep.log", "")
+	write("logs/debug.log", "")
+	write(".git/HEAD", "")
+
+	excludes := []string{"node_modules", "logs", "!logs/keep.log"}
+
+	before, err := snapshotSource(dir, excludes)
+	require.NoError(t, err)
+	assert.Len(t, before, 2)
+	assert.Contains(t, before, "main.go")
+	assert.Contains(t, before, "logs/keep.log")
+
+	write("node_modules/dep/other.js", "")
+	write("logs/debug.log", "more")
+	after, err := snapshotSource(dir, excludes)
+	require.NoError(t, err)
+	assert.Empty(t, changedFiles(before, after))
+
+	write("main.go", "package main\n\nfunc main() {}")
+	require.NoError(t, os.Chtimes(filepath.Join(dir, "main.go"), time.Now(), time.Now().Add(time.Minute)))
+	write("handler.go", "package main")
+	require.NoError(t, os.Remove(filepath.Join(dir, "logs/keep.log")))
+	after, err = snapshotSource(dir, excludes)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"handler.go", "logs/keep.log", "main.go"}, changedFiles(before, after))
+}
+
+func Test_summarizePaths(t *testing.T) {
+	assert.Equal(t, "a, b", summarizePaths([]string{"a", "b"}, 2))
+	assert.Equal(t, "a, b and 2 more", summarizePaths([]string{"a", "b", "c", "d"}, 2))
+}
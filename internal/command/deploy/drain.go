@@ -0,0 +1,89 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// defaultDrainTimeout is how long drain commands run for when their process
+// group doesn't set drain_timeout.
+const defaultDrainTimeout = 5 * time.Minute
+
+// drainConfig returns the drain settings of the process group of m, or nil
+// when its group doesn't drain.
+func drainConfig(appConfig *appconfig.Config, m *api.Machine) *appconfig.ProcessDeploy {
+	if appConfig == nil || appConfig.Deploy == nil {
+		return nil
+	}
+	pd := appConfig.Deploy.Processes[m.ProcessGroup()]
+	if pd == nil || strings.TrimSpace(pd.DrainCommand) == "" {
+		return nil
+	}
+	return pd
+}
+
+// drainMachine runs the drain command of the process group of m inside it,
+// before it's replaced. A failed drain only fails the deployment when the
+// group's drain_failure policy is abort.
+func (md *machineDeployment) drainMachine(ctx context.Context, m *api.Machine, progress *deployProgress) error {
+	pd := drainConfig(md.appConfig, m)
+	// there's nothing to drain in a machine which isn't running
+	if pd == nil || m.State != api.MachineStateStarted {
+		return nil
+	}
+
+	timeout := defaultDrainTimeout
+	if pd.DrainTimeout != nil && pd.DrainTimeout.Duration > 0 {
+		timeout = pd.DrainTimeout.Duration
+	}
+
+	if progress != nil {
+		progress.setStatus(m.ID, "draining")
+	} else {
+		fmt.Fprintf(md.io.ErrOut, "  Draining %s\n", md.colorize.Bold(m.ID))
+	}
+
+	err := md.runDrainCommand(ctx, m, pd.DrainCommand, timeout)
+	if err == nil {
+		return nil
+	}
+
+	err = fmt.Errorf("drain command of machine %s failed: %w", m.ID, err)
+	if pd.DrainFailure == appconfig.DrainFailureAbort {
+		return err
+	}
+	terminal.Warnf("%v, replacing it anyway\n", err)
+
+	return nil
+}
+
+func (md *machineDeployment) runDrainCommand(ctx context.Context, m *api.Machine, command string, timeout time.Duration) error {
+	// leave the machine a moment to report the command timed out before
+	// giving up on the request
+	ctx, cancel := context.WithTimeout(ctx, timeout+10*time.Second)
+	defer cancel()
+
+	res, err := md.flapsClient.Exec(ctx, m.ID, &api.MachineExecRequest{
+		Cmd:     command,
+		Timeout: int(timeout.Seconds()),
+	})
+	switch {
+	case ctx.Err() == context.DeadlineExceeded:
+		return fmt.Errorf("timed out after %s", timeout)
+	case err != nil:
+		return err
+	case res.ExitCode != 0:
+		if res.StdErr != nil && strings.TrimSpace(*res.StdErr) != "" {
+			return fmt.Errorf("exit code %d: %s", res.ExitCode, strings.TrimSpace(*res.StdErr))
+		}
+		return fmt.Errorf("exit code %d", res.ExitCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,24 @@
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func Test_drainConfig(t *testing.T) {
+	worker := &api.Machine{Config: &api.MachineConfig{Metadata: map[string]string{api.MachineConfigMetadataKeyFlyProcessGroup: "worker"}}}
+	web := &api.Machine{Config: &api.MachineConfig{Metadata: map[string]string{api.MachineConfigMetadataKeyFlyProcessGroup: "web"}}}
+
+	assert.Nil(t, drainConfig(&appconfig.Config{}, worker))
+
+	cfg := &appconfig.Config{Deploy: &appconfig.Deploy{Processes: map[string]*appconfig.ProcessDeploy{
+		"worker": {DrainCommand: "bin/sidekiq-quiet"},
+		"web":    {DrainCommand: " "},
+	}}}
+	assert.Equal(t, "bin/sidekiq-quiet", drainConfig(cfg, worker).DrainCommand)
+	assert.Nil(t, drainConfig(cfg, web))
+}
@@ -0,0 +1,276 @@
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// DeploymentPlan describes the changes a deployment would make to the
+// machines of an app, as computed by fly deploy --dry-run.
+type DeploymentPlan struct {
+	App             string
+	Image           string
+	Strategy        string
+	ReleaseCommand  string   `json:",omitempty"`
+	ReleaseCommands []string `json:",omitempty"`
+	Machines        []MachinePlan
+}
+
+// MachinePlan describes what a deployment would do to a single machine: one
+// of create, update, hot-update or destroy. Hot updates apply changes to
+// metadata or checks alone without restarting the machine. Machines to be
+// created have no ID yet.
+type MachinePlan struct {
+	ID      string `json:",omitempty"`
+	Group   string
+	Region  string
+	Action  string
+	Changes []ConfigChange `json:",omitempty"`
+}
+
+// ConfigChange is a change to a single field of a machine config. Old is
+// empty for fields being added, and New for fields being removed.
+type ConfigChange struct {
+	Field string
+	Old   string `json:",omitempty"`
+	New   string `json:",omitempty"`
+}
+
+// Plan computes the config every machine would be sent without contacting
+// flaps, and diffs it against the config the machine runs with.
+func (md *machineDeployment) Plan() *DeploymentPlan {
+	plan := &DeploymentPlan{
+		App:      md.app.Name,
+		Strategy: md.strategy,
+	}
+	if md.img != nil {
+		plan.Image = md.img.Tag
+	}
+	if len(md.releaseCommand) > 0 && !md.restartOnly {
+		plan.ReleaseCommand = md.appConfig.Deploy.ReleaseCommand
+	}
+	if !md.restartOnly {
+		for _, rc := range md.releaseCommands {
+			plan.ReleaseCommands = append(plan.ReleaseCommands, strings.Join(rc.command, " "))
+		}
+	}
+
+	groupsDiff := ProcessGroupsDiff{
+		groupsToRemove:        map[string]int{},
+		groupsNeedingMachines: md.missingMachines(nil),
+	}
+	if !md.machineSet.IsEmpty() {
+		groupsDiff = md.resolveProcessGroupChanges()
+	}
+
+	removed := map[string]bool{}
+	for _, m := range groupsDiff.machinesToRemove {
+		removed[m.Machine().ID] = true
+		plan.Machines = append(plan.Machines, MachinePlan{
+			ID:     m.Machine().ID,
+			Group:  m.Machine().ProcessGroup(),
+			Region: m.Machine().Region,
+			Action: "destroy",
+		})
+	}
+
+	for _, m := range md.machineSet.GetMachines() {
+		if removed[m.Machine().ID] {
+			continue
+		}
+		launchInput := md.resolveUpdatedMachineConfig(m.Machine(), false)
+		plan.Machines = append(plan.Machines, MachinePlan{
+			ID:      m.Machine().ID,
+			Group:   m.Machine().ProcessGroup(),
+			Region:  m.Machine().Region,
+			Action:  lo.Ternary(md.hotUpdates(m.Machine(), launchInput), "hot-update", "update"),
+			Changes: DiffMachineConfigs(m.Machine().Config, launchInput.Config),
+		})
+	}
+
+	groups := make([]string, 0, len(groupsDiff.groupsNeedingMachines))
+	for name := range groupsDiff.groupsNeedingMachines {
+		groups = append(groups, name)
+	}
+	sort.Strings(groups)
+
+	for _, name := range groups {
+		for _, region := range groupsDiff.groupsNeedingMachines[name] {
+			launchInput := md.resolveUpdatedMachineConfig(&api.Machine{
+				Region: region,
+				Config: &api.MachineConfig{
+					Metadata: map[string]string{
+						api.MachineConfigMetadataKeyFlyProcessGroup: name,
+					},
+				},
+			}, false)
+			plan.Machines = append(plan.Machines, MachinePlan{
+				Group:   name,
+				Region:  launchInput.Region,
+				Action:  "create",
+				Changes: DiffMachineConfigs(&api.MachineConfig{}, launchInput.Config),
+			})
+		}
+	}
+
+	return plan
+}
+
+// DiffMachineConfigs lists the changes to the image, environment, services,
+// checks, mounts and guest size between two machine configs. Metadata is left
+// out, as every deployment changes the release it records.
+func DiffMachineConfigs(old, new *api.MachineConfig) (changes []ConfigChange) {
+	add := func(field, o, n string) {
+		if o != n {
+			changes = append(changes, ConfigChange{Field: field, Old: o, New: n})
+		}
+	}
+
+	add("image", old.Image, new.Image)
+
+	for _, name := range unionKeys(old.Env, new.Env) {
+		add("env."+name, old.Env[name], new.Env[name])
+	}
+
+	oldServices, newServices := servicesByPort(old.Services), servicesByPort(new.Services)
+	for _, port := range unionKeys(oldServices, newServices) {
+		add("services."+port, oldServices[port], newServices[port])
+	}
+
+	oldChecks, newChecks := checksByName(old.Checks), checksByName(new.Checks)
+	for _, name := range unionKeys(oldChecks, newChecks) {
+		add("checks."+name, oldChecks[name], newChecks[name])
+	}
+
+	oldMounts, newMounts := mountsByPath(old.Mounts), mountsByPath(new.Mounts)
+	for _, path := range unionKeys(oldMounts, newMounts) {
+		add("mounts."+path, oldMounts[path], newMounts[path])
+	}
+
+	add("guest", formatGuest(old.Guest), formatGuest(new.Guest))
+
+	return
+}
+
+func unionKeys(a, b map[string]string) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	return keys
+}
+
+func servicesByPort(services []api.MachineService) map[string]string {
+	byPort := map[string]string{}
+	for _, s := range services {
+		buf, _ := json.Marshal(s)
+		byPort[fmt.Sprintf("%s/%d", s.Protocol, s.InternalPort)] = string(buf)
+	}
+
+	return byPort
+}
+
+func checksByName(checks map[string]api.MachineCheck) map[string]string {
+	byName := map[string]string{}
+	for name, c := range checks {
+		buf, _ := json.Marshal(c)
+		byName[name] = string(buf)
+	}
+
+	return byName
+}
+
+func mountsByPath(mounts []api.MachineMount) map[string]string {
+	byPath := map[string]string{}
+	for _, m := range mounts {
+		volume := m.Volume
+		if volume == "" {
+			volume = m.Name
+		}
+		if m.SizeGb > 0 {
+			volume = fmt.Sprintf("%s (%dGB)", volume, m.SizeGb)
+		}
+		byPath[m.Path] = volume
+	}
+
+	return byPath
+}
+
+func formatGuest(guest *api.MachineGuest) string {
+	if guest == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s-cpu-%dx, %dMB", guest.CPUKind, guest.CPUs, guest.MemoryMB)
+}
+
+func renderPlan(io *iostreams.IOStreams, plan *DeploymentPlan) {
+	colorize := io.ColorScheme()
+
+	fmt.Fprintf(io.Out, "Dry run of deploying %s with %s strategy; no changes were made\n", colorize.Bold(plan.App), plan.Strategy)
+	fmt.Fprintf(io.Out, "Image: %s\n", plan.Image)
+	if plan.ReleaseCommand != "" {
+		fmt.Fprintf(io.Out, "Release command: %s\n", plan.ReleaseCommand)
+	}
+	for i, command := range plan.ReleaseCommands {
+		fmt.Fprintf(io.Out, "Release command %d: %s\n", i+1, command)
+	}
+
+	for _, m := range plan.Machines {
+		id := m.ID
+		if id == "" {
+			id = "new machine"
+		}
+
+		action := m.Action
+		switch action {
+		case "create":
+			action = colorize.Green(action)
+		case "destroy":
+			action = colorize.Red(action)
+		case "hot-update":
+			action = colorize.Yellow("update in place")
+		case "update":
+			if len(m.Changes) == 0 {
+				action = "no changes"
+			} else {
+				action = colorize.Yellow(action)
+			}
+		}
+
+		fmt.Fprintf(io.Out, "\n%s (%s, %s): %s\n", colorize.Bold(id), m.Group, m.Region, action)
+
+		RenderChanges(io, m.Changes)
+	}
+}
+
+// RenderChanges prints changes one per line, marked as additions, removals or
+// modifications.
+func RenderChanges(io *iostreams.IOStreams, changes []ConfigChange) {
+	colorize := io.ColorScheme()
+
+	for _, c := range changes {
+		switch {
+		case c.Old == "":
+			fmt.Fprintf(io.Out, "  %s %s: %s\n", colorize.Green("+"), c.Field, c.New)
+		case c.New == "":
+			fmt.Fprintf(io.Out, "  %s %s: %s\n", colorize.Red("-"), c.Field, c.Old)
+		default:
+			fmt.Fprintf(io.Out, "  %s %s: %s => %s\n", colorize.Yellow("~"), c.Field, c.Old, c.New)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superfly/flyctl/api"
+)
+
+func Test_DiffMachineConfigs(t *testing.T) {
+	old := &api.MachineConfig{
+		Image: "super/balloon:1",
+		Env: map[string]string{
+			"KEPT":    "same",
+			"CHANGED": "before",
+			"REMOVED": "gone",
+		},
+		Services: []api.MachineService{
+			{Protocol: "tcp", InternalPort: 8080},
+		},
+		Mounts: []api.MachineMount{
+			{Path: "/data", Volume: "vol_123"},
+		},
+		Guest: &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 256},
+		Metadata: map[string]string{
+			api.MachineConfigMetadataKeyFlyReleaseVersion: "1",
+		},
+	}
+	new := &api.MachineConfig{
+		Image: "super/balloon:2",
+		Env: map[string]string{
+			"KEPT":    "same",
+			"CHANGED": "after",
+			"ADDED":   "new",
+		},
+		Services: []api.MachineService{
+			{Protocol: "tcp", InternalPort: 8080},
+		},
+		Mounts: []api.MachineMount{
+			{Path: "/data", Volume: "vol_123"},
+		},
+		Guest: &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 512},
+		Metadata: map[string]string{
+			api.MachineConfigMetadataKeyFlyReleaseVersion: "2",
+		},
+	}
+
+	assert.Equal(t, []ConfigChange{
+		{Field: "image", Old: "super/balloon:1", New: "super/balloon:2"},
+		{Field: "env.ADDED", New: "new"},
+		{Field: "env.CHANGED", Old: "before", New: "after"},
+		{Field: "env.REMOVED", Old: "gone"},
+		{Field: "guest", Old: "shared-cpu-1x, 256MB", New: "shared-cpu-1x, 512MB"},
+	}, DiffMachineConfigs(old, new))
+
+	assert.Empty(t, DiffMachineConfigs(old, old))
+}
@@ -0,0 +1,199 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/superfly/flyctl/terminal"
+)
+
+// The types of the events fly deploy --json writes.
+const (
+	EventBuildStarted      = "build_started"
+	EventImagePushed       = "image_pushed"
+	EventReleaseCreated    = "release_created"
+	EventMachineCreated    = "machine_created"
+	EventMachineUpdated    = "machine_updated"
+	EventHealthCheckPassed = "health_check_passed"
+	EventHealthCheckFailed = "health_check_failed"
+	EventDeployFinished    = "deploy_finished"
+)
+
+// Event is a single line of the newline delimited JSON stream fly deploy
+// --json writes while deploying, for CI systems to follow the deployment.
+type Event struct {
+	Type    string    `json:"type"`
+	Time    time.Time `json:"time"`
+	App     string    `json:"app,omitempty"`
+	Group   string    `json:"group,omitempty"`
+	Image   string    `json:"image,omitempty"`
+	Release int       `json:"release,omitempty"`
+	Machine string    `json:"machine,omitempty"`
+	Status  string    `json:"status,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// eventStream writes events as newline delimited JSON, to w unless it's nil,
+// and posts them to a webhook once postTo is called. A nil eventStream drops
+// them, so callers don't have to check whether --json was passed.
+type eventStream struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	app     string
+	webhook *webhook
+}
+
+func newEventStream(w io.Writer, app string) *eventStream {
+	s := &eventStream{app: app}
+	if w != nil {
+		s.enc = json.NewEncoder(w)
+	}
+	return s
+}
+
+// postTo posts every event emitted from now on to the webhook at rawURL, in
+// the background so that slow webhooks don't hold the deployment up.
+func (s *eventStream) postTo(rawURL string) error {
+	if u, err := url.Parse(rawURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("invalid progress webhook %q, expected an http or https URL", rawURL)
+	}
+
+	s.webhook = newWebhook(rawURL)
+	return nil
+}
+
+// close waits for the events posted to the webhook to be delivered.
+func (s *eventStream) close() {
+	if s == nil || s.webhook == nil {
+		return
+	}
+
+	s.webhook.close()
+}
+
+func (s *eventStream) emit(e Event) {
+	if s == nil {
+		return
+	}
+
+	e.Time = time.Now().UTC()
+	if e.App == "" {
+		e.App = s.app
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.enc != nil {
+		if err := s.enc.Encode(e); err != nil {
+			terminal.Debugf("failed writing deploy event %s: %v\n", e.Type, err)
+		}
+	}
+	if s.webhook != nil {
+		s.webhook.enqueue(e)
+	}
+}
+
+const (
+	webhookQueueSize    = 256
+	webhookTimeout      = 10 * time.Second
+	webhookDrainTimeout = 30 * time.Second
+)
+
+// webhook posts events, one at a time and in order, to a URL. Events are
+// dropped when the queue is full rather than slowing the deployment down.
+type webhook struct {
+	url    string
+	client *http.Client
+	queue  chan Event
+	done   chan struct{}
+	// dropped is counted by enqueue and failed by run, so that they don't
+	// race
+	dropped, failed int
+}
+
+func newWebhook(rawURL string) *webhook {
+	w := &webhook{
+		url:    rawURL,
+		client: &http.Client{Timeout: webhookTimeout},
+		queue:  make(chan Event, webhookQueueSize),
+		done:   make(chan struct{}),
+	}
+	go w.run()
+	return w
+}
+
+func (w *webhook) enqueue(e Event) {
+	select {
+	case w.queue <- e:
+	default:
+		w.dropped++
+		terminal.Debugf("dropped deploy event %s, the progress webhook is behind\n", e.Type)
+	}
+}
+
+func (w *webhook) run() {
+	defer close(w.done)
+
+	for e := range w.queue {
+		if err := w.post(e); err != nil {
+			w.failed++
+			terminal.Debugf("failed posting deploy event %s to the progress webhook: %v\n", e.Type, err)
+		}
+	}
+}
+
+func (w *webhook) post(e Event) error {
+	buf, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(buf))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //skipcq: GO-S2307
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("the webhook responded with %s", resp.Status)
+	}
+	return nil
+}
+
+// close stops accepting events and waits for the queued ones to be posted,
+// for a while at most, warning about the events that couldn't be.
+func (w *webhook) close() {
+	close(w.queue)
+
+	select {
+	case <-w.done:
+	case <-time.After(webhookDrainTimeout):
+		terminal.Warnf("Gave up waiting for the progress webhook to receive the last events of the deployment\n")
+		return
+	}
+
+	if n := w.dropped + w.failed; n > 0 {
+		terminal.Warnf("%d events of the deployment couldn't be posted to the progress webhook\n", n)
+	}
+}
+
+type eventsContextKey struct{}
+
+func withEvents(ctx context.Context, s *eventStream) context.Context {
+	return context.WithValue(ctx, eventsContextKey{}, s)
+}
+
+// eventsFromContext returns the event stream of ctx, or nil when the deploy
+// doesn't write one.
+func eventsFromContext(ctx context.Context) *eventStream {
+	s, _ := ctx.Value(eventsContextKey{}).(*eventStream)
+	return s
+}
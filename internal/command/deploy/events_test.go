@@ -0,0 +1,74 @@
+package deploy
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_eventStream(t *testing.T) {
+	var buf bytes.Buffer
+	events := newEventStream(&buf, "my-app")
+
+	events.emit(Event{Type: EventMachineUpdated, Machine: "148e1234", Release: 3})
+	events.emit(Event{Type: EventDeployFinished, Status: "succeeded"})
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+
+	var e Event
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &e))
+	assert.Equal(t, EventMachineUpdated, e.Type)
+	assert.Equal(t, "my-app", e.App)
+	assert.Equal(t, "148e1234", e.Machine)
+	assert.Equal(t, 3, e.Release)
+	assert.False(t, e.Time.IsZero())
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &e))
+	assert.Equal(t, EventDeployFinished, e.Type)
+	assert.Equal(t, "succeeded", e.Status)
+
+	// a nil stream drops events
+	var none *eventStream
+	none.emit(Event{Type: EventBuildStarted})
+}
+
+func Test_eventStream_webhook(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received []Event
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+
+		var e Event
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&e))
+
+		mu.Lock()
+		received = append(received, e)
+		mu.Unlock()
+	}))
+	defer server.Close()
+
+	events := newEventStream(nil, "my-app")
+	require.NoError(t, events.postTo(server.URL))
+
+	events.emit(Event{Type: EventMachineUpdated, Machine: "148e1234"})
+	events.emit(Event{Type: EventDeployFinished, Status: "succeeded"})
+	events.close()
+
+	require.Len(t, received, 2)
+	assert.Equal(t, EventMachineUpdated, received[0].Type)
+	assert.Equal(t, "my-app", received[0].App)
+	assert.Equal(t, "148e1234", received[0].Machine)
+	assert.Equal(t, EventDeployFinished, received[1].Type)
+
+	assert.ErrorContains(t, newEventStream(nil, "my-app").postTo("hooks.example.com"), "expected an http or https URL")
+}
@@ -0,0 +1,92 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/google/shlex"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func deployHooks(appConfig *appconfig.Config) *appconfig.DeployHooks {
+	if appConfig.Deploy == nil || appConfig.Deploy.Hooks == nil {
+		return &appconfig.DeployHooks{}
+	}
+
+	return appConfig.Deploy.Hooks
+}
+
+// runPreDeployHooks runs the pre-deploy hooks of the app config, before the
+// image is built.
+func runPreDeployHooks(ctx context.Context, appConfig *appconfig.Config) error {
+	return runLocalHooks(ctx, "pre-deploy", deployHooks(appConfig).Pre, []string{
+		"FLY_APP_NAME=" + appConfig.AppName,
+	})
+}
+
+// runPostDeployHooks runs the post-deploy hooks of the app config once img is
+// rolled out, locally or, for apps on machines, in an ephemeral machine of the
+// new release.
+func runPostDeployHooks(ctx context.Context, appConfig *appconfig.Config, img *imgsrc.DeploymentImage, md MachineDeployment) error {
+	hooks := deployHooks(appConfig)
+	if !hooks.PostInMachine {
+		return runLocalHooks(ctx, "post-deploy", hooks.Post, []string{
+			"FLY_APP_NAME=" + appConfig.AppName,
+			"FLY_IMAGE_REF=" + img.Tag,
+		})
+	}
+
+	if md == nil {
+		return errors.New("post-deploy hooks can only run in a machine for apps running on machines")
+	}
+
+	for _, hook := range hooks.Post {
+		command, err := shlex.Split(hook)
+		if err != nil {
+			return fmt.Errorf("invalid post-deploy hook %q: %w", hook, err)
+		}
+		if err := md.RunEphemeralMachine(ctx, "post-deploy hook", command); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// runLocalHooks runs commands one at a time with the shell, in the working
+// directory, and stops at the first one which fails.
+func runLocalHooks(ctx context.Context, kind string, commands []string, env []string) error {
+	io := iostreams.FromContext(ctx)
+
+	for _, command := range commands {
+		fmt.Fprintf(io.ErrOut, "Running %s hook: %s\n", kind, io.ColorScheme().Bold(command))
+
+		cmd := shellCommand(ctx, command)
+		cmd.Dir = state.WorkingDirectory(ctx)
+		cmd.Env = append(os.Environ(), env...)
+		cmd.Stdout = io.Out
+		cmd.Stderr = io.ErrOut
+
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s hook %q failed: %w", kind, command, err)
+		}
+	}
+
+	return nil
+}
+
+func shellCommand(ctx context.Context, command string) *exec.Cmd {
+	if runtime.GOOS == "windows" {
+		return exec.CommandContext(ctx, "cmd", "/C", command)
+	}
+
+	return exec.CommandContext(ctx, "sh", "-c", command)
+}
@@ -0,0 +1,39 @@
+package deploy
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func Test_runLocalHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks run with sh")
+	}
+
+	dir := t.TempDir()
+	ios, _, _, _ := iostreams.Test()
+	ctx := iostreams.NewContext(context.Background(), ios)
+	ctx = state.WithWorkingDirectory(ctx, dir)
+
+	err := runLocalHooks(ctx, "pre-deploy", []string{
+		`echo "$FLY_APP_NAME" > first`,
+		"exit 3",
+		"touch never",
+	}, []string{"FLY_APP_NAME=my-app"})
+	assert.ErrorContains(t, err, `pre-deploy hook "exit 3" failed`)
+
+	out, err := os.ReadFile(filepath.Join(dir, "first"))
+	require.NoError(t, err)
+	assert.Equal(t, "my-app\n", string(out))
+
+	assert.NoFileExists(t, filepath.Join(dir, "never"))
+}
@@ -0,0 +1,83 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/machine"
+)
+
+// updatesInPlace reports whether updating a machine from the old config to
+// the new one only changes its metadata or health checks, which a started
+// machine picks up without being restarted.
+func updatesInPlace(old, new *api.MachineConfig) bool {
+	if old == nil || new == nil {
+		return false
+	}
+
+	o, n := *old, *new
+	o.Metadata, n.Metadata = nil, nil
+	o.Checks, n.Checks = nil, nil
+
+	// compare the configs as flaps receives them, which doesn't tell nil and
+	// empty fields apart
+	oldJSON, err := json.Marshal(o)
+	if err != nil {
+		return false
+	}
+	newJSON, err := json.Marshal(n)
+	if err != nil {
+		return false
+	}
+	return string(oldJSON) == string(newJSON)
+}
+
+// hotUpdates reports whether m is started and would only be updated in place
+// by launchInput. Restart-only deployments, such as those of fly secrets,
+// always restart machines: that's how they pick up what changed outside of
+// their config.
+func (md *machineDeployment) hotUpdates(m *api.Machine, launchInput *api.LaunchMachineInput) bool {
+	return !md.restartOnly && m.State == api.MachineStateStarted && updatesInPlace(m.Config, launchInput.Config)
+}
+
+// hotUpdateMachine applies launchInput to m without restarting it, so there's
+// no need to drain it or to wait for it to start and pass its checks again.
+func (md *machineDeployment) hotUpdateMachine(ctx context.Context, m machine.LeasableMachine, launchInput *api.LaunchMachineInput, progress *deployProgress) error {
+	id := m.Machine().ID
+
+	if progress != nil {
+		progress.setStatus(id, "updating in place")
+	} else {
+		fmt.Fprintf(md.io.ErrOut, "  Updating %s in place, only its metadata and checks changed\n", md.colorize.Bold(m.FormattedMachineId()))
+	}
+
+	launchInput.SkipLaunch = true
+	if err := m.Update(ctx, *launchInput); err != nil {
+		if progress != nil {
+			progress.setStatus(id, "failed")
+		}
+		return err
+	}
+	md.events.emit(Event{Type: EventMachineUpdated, Machine: id, Group: m.Machine().ProcessGroup(), Release: md.releaseVersion, Image: launchInput.Config.Image, Status: "hot-updated"})
+
+	if progress != nil {
+		progress.setStatus(id, "done")
+	}
+	return nil
+}
+
+// reportUpdates lists the machines which were updated in place and those
+// which were replaced by restarting them, when any were updated in place.
+func (md *machineDeployment) reportUpdates(hotUpdated, replaced []string) {
+	if len(hotUpdated) == 0 {
+		return
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "  Hot-updated %d machine(s) without restarting them: %s\n", len(hotUpdated), strings.Join(hotUpdated, ", "))
+	if len(replaced) > 0 {
+		fmt.Fprintf(md.io.ErrOut, "  Replaced %d machine(s) by restarting them: %s\n", len(replaced), strings.Join(replaced, ", "))
+	}
+}
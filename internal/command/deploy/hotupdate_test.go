@@ -0,0 +1,80 @@
+package deploy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func Test_updatesInPlace(t *testing.T) {
+	check := func(interval time.Duration) map[string]api.MachineCheck {
+		return map[string]api.MachineCheck{
+			"alive": {Type: api.StringPointer("tcp"), Port: api.IntPointer(8080), Interval: &api.Duration{Duration: interval}},
+		}
+	}
+	old := &api.MachineConfig{
+		Image:    "super/balloon:1",
+		Env:      map[string]string{"PORT": "8080"},
+		Checks:   check(15 * time.Second),
+		Metadata: map[string]string{api.MachineConfigMetadataKeyFlyReleaseVersion: "1"},
+	}
+
+	hot := &api.MachineConfig{
+		Image:    "super/balloon:1",
+		Env:      map[string]string{"PORT": "8080"},
+		Checks:   check(30 * time.Second),
+		Metadata: map[string]string{api.MachineConfigMetadataKeyFlyReleaseVersion: "2"},
+	}
+	assert.True(t, updatesInPlace(old, hot))
+
+	cold := *hot
+	cold.Env = map[string]string{"PORT": "9090"}
+	assert.False(t, updatesInPlace(old, &cold))
+
+	// empty and missing fields are sent to flaps the same way
+	empty := *hot
+	empty.Mounts = []api.MachineMount{}
+	assert.True(t, updatesInPlace(old, &empty))
+
+	assert.False(t, updatesInPlace(nil, hot))
+
+	md := &machineDeployment{}
+	started := &api.Machine{State: api.MachineStateStarted, Config: old}
+	assert.True(t, md.hotUpdates(started, &api.LaunchMachineInput{Config: hot}))
+	stopped := &api.Machine{State: api.MachineStateStopped, Config: old}
+	assert.False(t, md.hotUpdates(stopped, &api.LaunchMachineInput{Config: hot}))
+
+	assert.Equal(t, []ConfigChange{{
+		Field: "checks.alive",
+		Old:   `{"port":8080,"type":"tcp","interval":"15s"}`,
+		New:   `{"port":8080,"type":"tcp","interval":"30s"}`,
+	}}, DiffMachineConfigs(old, hot))
+}
+
+// Restart-only deployments, such as those of fly secrets, only change the
+// metadata of machines, which they have to restart for the changes they
+// deploy to reach them.
+func Test_hotUpdates_restartOnly(t *testing.T) {
+	md, err := stabMachineDeployment(&appconfig.Config{AppName: "my-cool-app"})
+	require.NoError(t, err)
+	md.img = nil
+	md.restartOnly = true
+	md.releaseVersion = 2
+
+	m := &api.Machine{
+		State: api.MachineStateStarted,
+		Config: &api.MachineConfig{
+			Image:    "super/balloon:1",
+			Metadata: map[string]string{api.MachineConfigMetadataKeyFlyReleaseVersion: "1"},
+		},
+	}
+
+	launchInput := md.resolveUpdatedMachineConfig(m, false)
+	assert.True(t, updatesInPlace(m.Config, launchInput.Config))
+	assert.False(t, md.hotUpdates(m, launchInput))
+}
@@ -2,10 +2,15 @@ package deploy
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"io"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Khan/genqlient/graphql"
@@ -20,10 +25,15 @@ import (
 	"github.com/superfly/flyctl/internal/build/imgsrc"
 	"github.com/superfly/flyctl/internal/cmdutil"
 	machcmd "github.com/superfly/flyctl/internal/command/machine"
+	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/logger"
 	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/primaryregion"
+	"github.com/superfly/flyctl/internal/render"
 	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/logs"
 	"github.com/superfly/flyctl/terminal"
+	"golang.org/x/sync/errgroup"
 )
 
 const (
@@ -33,25 +43,75 @@ const (
 
 type MachineDeployment interface {
 	DeployMachinesApp(context.Context) error
+	Plan() *DeploymentPlan
+	RunEphemeralMachine(ctx context.Context, name string, command []string) error
 }
 
 type ProcessGroupsDiff struct {
-	machinesToRemove      []machine.LeasableMachine
-	groupsToRemove        map[string]int
-	groupsNeedingMachines map[string]*appconfig.ProcessConfig
+	machinesToRemove []machine.LeasableMachine
+	groupsToRemove   map[string]int
+	// groupsNeedingMachines are the regions of the machines to create in
+	// each group, one entry per machine
+	groupsNeedingMachines map[string][]string
 }
 
 type MachineDeploymentArgs struct {
 	AppCompact        *api.AppCompact
 	DeploymentImage   *imgsrc.DeploymentImage
+	ProcessImages     map[string]*imgsrc.DeploymentImage
 	Strategy          string
 	EnvFromFlags      []string
 	PrimaryRegionFlag string
 	BuildOnly         bool
 	SkipHealthChecks  bool
 	RestartOnly       bool
+	AttachArtifacts   bool
 	WaitTimeout       time.Duration
 	LeaseTimeout      time.Duration
+	CanaryWait        time.Duration
+	BGKeepOld         bool
+	PrePull           bool
+	RollbackOnFailure bool
+	ReleaseMetadata   bool
+	MaxUnavailable    int
+	// OnlyRegions, ExcludeRegions, OnlyMachines and Selector restrict the
+	// deployment to some of the machines, picked by region, ID or metadata;
+	// machines are then only updated, never created or destroyed
+	OnlyRegions    []string
+	ExcludeRegions []string
+	OnlyMachines   []string
+	Selector       map[string]string
+	// Channel is the release channel deployed to, only its machines are
+	// updated. Outside the stable channel machines are never created or
+	// destroyed either
+	Channel string
+	// ChannelTraffic is the percentage of requests fly-proxy routes to the
+	// machines of Channel, left as is when nil
+	ChannelTraffic *int
+	// VMSize, VMCPUs and VMMemory override the guest size of the machines
+	// deployed to, the latter two on top of the first
+	VMSize   string
+	VMCPUs   int
+	VMMemory int
+	// ReleaseCommandVMSize and ReleaseCommandRegion override the guest size
+	// and region of [deploy.release_command_vm]
+	ReleaseCommandVMSize string
+	ReleaseCommandRegion string
+	// SmokeURLs are requested once the machines are deployed, expecting the
+	// SmokeStatus code, or any 2xx or 3xx, and a body with SmokeContains
+	SmokeURLs     []string
+	SmokeStatus   int
+	SmokeContains string
+	// DryRun plans the deployment without creating a release or allocating
+	// IPs, so that only Plan may be called
+	DryRun bool
+}
+
+// releaseCommand is one of the release commands of the app config.
+type releaseCommand struct {
+	command      []string
+	timeout      time.Duration
+	allowFailure bool
 }
 
 type machineDeployment struct {
@@ -64,9 +124,15 @@ type machineDeployment struct {
 	appConfig             *appconfig.Config
 	processConfigs        map[string]*appconfig.ProcessConfig
 	img                   *imgsrc.DeploymentImage
+	processImages         map[string]*imgsrc.DeploymentImage
 	machineSet            machine.MachineSet
 	releaseCommandMachine machine.MachineSet
 	releaseCommand        []string
+	releaseCommands       []releaseCommand
+	releaseCommandGuest   *api.MachineGuest
+	releaseCommandRegion  string
+	releaseCommandMounts  []api.MachineMount
+	planChecksum          string
 	volumeDestination     string
 	volumes               []api.Volume
 	strategy              string
@@ -74,9 +140,28 @@ type machineDeployment struct {
 	releaseVersion        int
 	skipHealthChecks      bool
 	restartOnly           bool
+	attachArtifacts       bool
 	waitTimeout           time.Duration
 	leaseTimeout          time.Duration
 	leaseDelayBetween     time.Duration
+	canaryWait            time.Duration
+	bgKeepOld             bool
+	prePull               bool
+	rollbackOnFailure     bool
+	releaseMetadata       map[string]string
+	maxUnavailable        int
+	liveProgress          bool
+	events                *eventStream
+	onlyRegions           []string
+	excludeRegions        []string
+	onlyMachines          []string
+	selector              map[string]string
+	channel               string
+	channelTraffic        *int
+	vmSize                *api.MachineGuest
+	vmCPUs                int
+	vmMemory              int
+	smokeTest             *smokeTest
 }
 
 func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (MachineDeployment, error) {
@@ -102,11 +187,23 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (Mach
 		return nil, err
 	}
 	var releaseCmd []string
+	var releaseCmds []releaseCommand
 	if appConfig.Deploy != nil {
 		releaseCmd, err = shlex.Split(appConfig.Deploy.ReleaseCommand)
 		if err != nil {
 			return nil, err
 		}
+		for _, rc := range appConfig.Deploy.ReleaseCommands {
+			command, err := shlex.Split(rc.Command)
+			if err != nil {
+				return nil, fmt.Errorf("invalid release command '%s': %w", rc.Command, err)
+			}
+			cmd := releaseCommand{command: command, allowFailure: rc.AllowFailure}
+			if rc.Timeout != nil {
+				cmd.timeout = rc.Timeout.Duration
+			}
+			releaseCmds = append(releaseCmds, cmd)
+		}
 	}
 	waitTimeout := args.WaitTimeout
 	if waitTimeout == 0 {
@@ -116,6 +213,14 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (Mach
 	if leaseTimeout == 0 {
 		leaseTimeout = DefaultLeaseTtl
 	}
+	canaryWait := args.CanaryWait
+	if canaryWait == 0 {
+		canaryWait = DefaultCanaryWait
+	}
+	maxUnavailable := args.MaxUnavailable
+	if maxUnavailable < 1 {
+		maxUnavailable = 1
+	}
 	leaseDelayBetween := (leaseTimeout - 1*time.Second) / 3
 	if waitTimeout != DefaultWaitTimeout || leaseTimeout != DefaultLeaseTtl || args.WaitTimeout == 0 || args.LeaseTimeout == 0 {
 		terminal.Infof("Using wait timeout: %s lease timeout: %s delay between lease refreshes: %s\n", waitTimeout, leaseTimeout, leaseDelayBetween)
@@ -136,17 +241,51 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (Mach
 		appConfig:         appConfig,
 		processConfigs:    processConfigs,
 		img:               args.DeploymentImage,
+		processImages:     args.ProcessImages,
 		skipHealthChecks:  args.SkipHealthChecks,
 		restartOnly:       args.RestartOnly,
+		attachArtifacts:   args.AttachArtifacts,
 		waitTimeout:       waitTimeout,
 		leaseTimeout:      leaseTimeout,
 		leaseDelayBetween: leaseDelayBetween,
+		canaryWait:        canaryWait,
+		bgKeepOld:         args.BGKeepOld,
+		prePull:           args.PrePull,
+		rollbackOnFailure: args.RollbackOnFailure,
+		maxUnavailable:    maxUnavailable,
 		releaseCommand:    releaseCmd,
+		releaseCommands:   releaseCmds,
+		events:            eventsFromContext(ctx),
+		onlyRegions:       args.OnlyRegions,
+		excludeRegions:    args.ExcludeRegions,
+		onlyMachines:      args.OnlyMachines,
+		selector:          args.Selector,
+		// restarts apply to every channel
+		channel:        lo.Ternary(args.Channel != "" || args.RestartOnly, args.Channel, api.MachineReleaseChannelStable),
+		channelTraffic: args.ChannelTraffic,
+	}
+	if err := md.validateChannelTraffic(); err != nil {
+		return nil, err
+	}
+	if args.ReleaseMetadata && !args.RestartOnly {
+		md.releaseMetadata = releaseMetadata(ctx, args.DeploymentImage)
 	}
 	err = md.setStrategy(args.Strategy)
 	if err != nil {
 		return nil, err
 	}
+	md.smokeTest, err = newSmokeTest(args.SmokeURLs, args.SmokeStatus, args.SmokeContains)
+	if err != nil {
+		return nil, err
+	}
+	// the progress table only covers rolling updates, and is redrawn in place
+	// so it needs a terminal; --verbose keeps the plain log lines
+	md.liveProgress = md.io.IsInteractive() && !config.FromContext(ctx).VerboseOutput &&
+		(md.strategy == "rolling" || md.strategy == "immediate")
+	err = md.setGuestOverrides(args.VMSize, args.VMCPUs, args.VMMemory)
+	if err != nil {
+		return nil, err
+	}
 	err = md.setMachinesForDeployment(ctx)
 	if err != nil {
 		return nil, err
@@ -159,6 +298,18 @@ func NewMachineDeployment(ctx context.Context, args MachineDeploymentArgs) (Mach
 	if err != nil {
 		return nil, err
 	}
+	err = md.validateGuestOverrides()
+	if err != nil {
+		return nil, err
+	}
+	md.checkPrimaryRegion(ctx)
+	err = md.setReleaseCommandVMConfig(ctx, args.ReleaseCommandVMSize, args.ReleaseCommandRegion)
+	if err != nil {
+		return nil, err
+	}
+	if args.DryRun {
+		return md, nil
+	}
 	err = md.provisionIpsOnFirstDeploy(ctx)
 	if err != nil {
 		return nil, err
@@ -184,12 +335,15 @@ func (md *machineDeployment) runReleaseCommand(ctx context.Context) error {
 		return fmt.Errorf("error running release_command machine: %w", err)
 	}
 	releaseCmdMachine := md.releaseCommandMachine.GetMachines()[0]
+	stopLogs := machine.StreamLogs(ctx, md.app.Name, releaseCmdMachine.Machine().ID, md.printMachineLog)
 	// FIXME: consolidate this wait stuff with deploy waits? Especially once we improve the outpu
 	err = releaseCmdMachine.WaitForState(ctx, api.MachineStateStarted, md.waitTimeout)
 	if err != nil {
+		stopLogs()
 		return fmt.Errorf("error waiting for release_command machine %s to start: %w", releaseCmdMachine.Machine().ID, err)
 	}
 	err = releaseCmdMachine.WaitForState(ctx, api.MachineStateDestroyed, md.waitTimeout)
+	stopLogs()
 	if err != nil {
 		return fmt.Errorf("error waiting for release_command machine %s to finish running: %w", releaseCmdMachine.Machine().ID, err)
 	}
@@ -206,45 +360,179 @@ func (md *machineDeployment) runReleaseCommand(ctx context.Context) error {
 			md.colorize.Bold(releaseCmdMachine.Machine().ID), md.colorize.Red(strconv.Itoa(exitCode)), md.app.Name)
 		return fmt.Errorf("error release_command machine %s exited with non-zero status of %d", releaseCmdMachine.Machine().ID, exitCode)
 	}
-	md.logClearLinesAbove(1)
 	fmt.Fprintf(md.io.ErrOut, "  release_command %s completed successfully\n", md.colorize.Bold(releaseCmdMachine.Machine().ID))
 	return nil
 }
 
+// RunEphemeralMachine runs command in a machine of the release being deployed,
+// configured like the release command machine, which is destroyed once the
+// command exits. name describes the command in output and errors.
+func (md *machineDeployment) RunEphemeralMachine(ctx context.Context, name string, command []string) error {
+	launchInput := md.ephemeralLaunchInput(command)
+	if _, present := md.appConfig.Env["RELEASE_COMMAND"]; !present {
+		delete(launchInput.Config.Env, "RELEASE_COMMAND")
+	}
+
+	return md.runEphemeralMachine(ctx, name, launchInput, md.waitTimeout)
+}
+
+// runReleaseCommands runs the release commands of the app config one at a
+// time, each in its own ephemeral machine.
+func (md *machineDeployment) runReleaseCommands(ctx context.Context) error {
+	if md.restartOnly {
+		return nil
+	}
+
+	for _, rc := range md.releaseCommands {
+		timeout := md.waitTimeout
+		if rc.timeout > 0 {
+			timeout = rc.timeout
+		}
+
+		err := md.runEphemeralMachine(ctx, "release command", md.ephemeralLaunchInput(rc.command), timeout)
+		switch {
+		case err == nil:
+		case rc.allowFailure:
+			terminal.Warnf("Release command '%s' failed, continuing as it allows failure: %v\n", strings.Join(rc.command, " "), err)
+		default:
+			return err
+		}
+	}
+
+	return nil
+}
+
+// ephemeralLaunchInput returns the config of a machine running command with
+// the release being deployed, kept out of the machines deployments manage.
+func (md *machineDeployment) ephemeralLaunchInput(command []string) *api.LaunchMachineInput {
+	launchInput := md.resolveUpdatedMachineConfig(nil, true)
+	launchInput.Config.Init.Cmd = command
+	delete(launchInput.Config.Metadata, api.MachineConfigMetadataKeyFlyPlatformVersion)
+	delete(launchInput.Config.Metadata, api.MachineConfigMetadataKeyFlyProcessGroup)
+
+	return launchInput
+}
+
+// runEphemeralMachine launches a machine and waits up to timeout for it to
+// exit, killing it past that.
+func (md *machineDeployment) runEphemeralMachine(ctx context.Context, name string, launchInput *api.LaunchMachineInput, timeout time.Duration) error {
+	fmt.Fprintf(md.io.ErrOut, "Running %s %s: %s\n", md.colorize.Bold(md.app.Name), name, strings.Join(launchInput.Config.Init.Cmd, " "))
+
+	ctx = flaps.NewContext(ctx, md.flapsClient)
+	m, exitCode, err := machine.RunEphemeral(ctx, machine.EphemeralInput{
+		LaunchInput:  *launchInput,
+		StartTimeout: md.waitTimeout,
+		Timeout:      timeout,
+		PrintLog:     md.printMachineLog,
+	})
+	switch {
+	case m == nil:
+		return fmt.Errorf("error creating a %s machine: %w", name, err)
+	case err != nil:
+		return fmt.Errorf("error running %s machine %s: %w", name, m.ID, err)
+	case exitCode != 0:
+		return fmt.Errorf("%s machine %s exited with non-zero status of %d; check the logs at https://fly.io/apps/%s/monitoring", name, m.ID, exitCode, md.app.Name)
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "  %s %s completed successfully\n", name, md.colorize.Bold(m.ID))
+	return nil
+}
+
+// printMachineLog prints a log entry of a machine the deployment runs a
+// command in.
+func (md *machineDeployment) printMachineLog(entry logs.LogEntry) {
+	_ = render.LogEntry(md.io.ErrOut, entry,
+		render.HideAllocID(),
+		render.RemoveNewlines(),
+		render.HideRegion(),
+	)
+}
+
 func (md *machineDeployment) resolveProcessGroupChanges() ProcessGroupsDiff {
 
 	output := ProcessGroupsDiff{
 		groupsToRemove:        map[string]int{},
-		groupsNeedingMachines: map[string]*appconfig.ProcessConfig{},
+		groupsNeedingMachines: map[string][]string{},
+	}
+
+	// only some of the machines are known when deploying to a subset of them,
+	// so whether groups lack or have too many machines can't be told
+	if md.filtersMachines() {
+		return output
 	}
 
-	groupHasMachine := map[string]bool{}
+	var kept []*api.Machine
 
 	for _, leasableMachine := range md.machineSet.GetMachines() {
 		mach := leasableMachine.Machine()
 		machGroup := mach.ProcessGroup()
-		groupMatch := ""
-		for name := range md.processConfigs {
-			if machGroup == name {
-				groupMatch = machGroup
-				break
-			}
-		}
-		if groupMatch == "" {
+		if _, ok := md.processConfigs[machGroup]; !ok {
 			output.groupsToRemove[machGroup] += 1
 			output.machinesToRemove = append(output.machinesToRemove, leasableMachine)
 		} else {
-			groupHasMachine[groupMatch] = true
+			kept = append(kept, mach)
 		}
 	}
 
-	for name, val := range md.processConfigs {
-		if hasMach, ok := groupHasMachine[name]; !ok || !hasMach {
-			output.groupsNeedingMachines[name] = val
+	output.groupsNeedingMachines = md.missingMachines(kept)
+
+	return output
+}
+
+// missingMachines returns the regions of the machines to create in each group
+// next to machines. Groups without machines get one in the primary region,
+// unless their [[vm]] section places them; then they're topped up to its count
+// in each of its regions, except on restarts. Groups with an [[autoscaling]]
+// section are topped up to their max_machines in each region instead, for the
+// proxy to start as load grows. Surplus machines are left alone.
+func (md *machineDeployment) missingMachines(machines []*api.Machine) map[string][]string {
+	perRegion := map[string]map[string]int{}
+	total := map[string]int{}
+	for _, m := range machines {
+		group := m.ProcessGroup()
+		if perRegion[group] == nil {
+			perRegion[group] = map[string]int{}
+		}
+		perRegion[group][m.Region]++
+		total[group]++
+	}
+
+	missing := map[string][]string{}
+	for name, pc := range md.processConfigs {
+		var regions []string
+		switch {
+		case pc.Autoscaling != nil && !md.restartOnly:
+			bounded := lo.Keys(pc.Autoscaling)
+			sort.Strings(bounded)
+			for _, region := range bounded {
+				at := region
+				if at == "" {
+					at = md.appConfig.PrimaryRegion
+				}
+				for i := perRegion[name][at]; i < pc.Autoscaling[region].Max; i++ {
+					regions = append(regions, at)
+				}
+			}
+		case len(pc.Regions) > 0 && !md.restartOnly:
+			count := lo.Max([]int{pc.Count, 1})
+			for _, region := range pc.Regions {
+				for i := perRegion[name][region]; i < count; i++ {
+					regions = append(regions, region)
+				}
+			}
+		case pc.Count > 0 && !md.restartOnly:
+			for i := total[name]; i < pc.Count; i++ {
+				regions = append(regions, md.appConfig.PrimaryRegion)
+			}
+		case total[name] == 0:
+			regions = []string{md.appConfig.PrimaryRegion}
+		}
+		if len(regions) > 0 {
+			missing[name] = regions
 		}
 	}
 
-	return output
+	return missing
 }
 
 func (md *machineDeployment) warnAboutProcessGroupChanges(ctx context.Context, diff ProcessGroupsDiff) {
@@ -270,35 +558,55 @@ func (md *machineDeployment) warnAboutProcessGroupChanges(ctx context.Context, d
 	if willAddMachines {
 		bullet := colorize.Green("*")
 
-		for name := range diff.groupsNeedingMachines {
-			fmt.Fprintf(io.Out, " %s create 1 \"%s\" machine\n", bullet, name)
+		for name, regions := range diff.groupsNeedingMachines {
+			pluralS := lo.Ternary(len(regions) == 1, "", "s")
+			fmt.Fprintf(io.Out, " %s create %d \"%s\" machine%s", bullet, len(regions), name, pluralS)
+			if regions := lo.Without(lo.Uniq(regions), ""); len(regions) > 0 {
+				fmt.Fprintf(io.Out, " in %s", strings.Join(regions, ", "))
+			}
+			fmt.Fprintln(io.Out)
 		}
 	}
 }
 
-func (md *machineDeployment) spawnMachineInGroup(ctx context.Context, groupName string) error {
+func (md *machineDeployment) spawnMachineInGroup(ctx context.Context, groupName, region string) error {
 	if groupName == "" {
 		// If the group is unspecified, it should have been translated to "app" by this point
 		panic("spawnMachineInGroup requires a non-empty group name. this is a bug!")
 	}
-	fmt.Fprintf(md.io.Out, "No machines in group '%s', launching one new machine\n", md.colorize.Bold(groupName))
+	fmt.Fprintf(md.io.Out, "Launching one new machine in group '%s'\n", md.colorize.Bold(groupName))
 	machBase := &api.Machine{
-		Region: md.appConfig.PrimaryRegion,
+		Region: region,
 		Config: &api.MachineConfig{
 			Metadata: map[string]string{
 				api.MachineConfigMetadataKeyFlyProcessGroup: groupName,
 			},
 		},
 	}
+	// each new machine needs a volume of its own, in its region when there's
+	// one there
+	if md.appConfig.Mounts != nil {
+		if len(md.volumes) == 0 {
+			return fmt.Errorf("no unattached volume named '%s' left for a new '%s' machine; create one with 'fly volumes create %s --region %s'",
+				md.appConfig.Mounts.Source, groupName, md.appConfig.Mounts.Source, region)
+		}
+		_, index, found := lo.FindIndexOf(md.volumes, func(v api.Volume) bool { return v.Region == region })
+		if !found {
+			index = 0
+		}
+		machBase.Config.Mounts = []api.MachineMount{{Path: md.volumeDestination, Volume: md.volumes[index].ID}}
+		md.volumes = append(md.volumes[:index:index], md.volumes[index+1:]...)
+	}
 	launchInput := md.resolveUpdatedMachineConfig(machBase, false)
 	newMachineRaw, err := md.flapsClient.Launch(ctx, *launchInput)
-	newMachine := machine.NewLeasableMachine(md.flapsClient, md.io, newMachineRaw)
 	if err != nil {
 		return fmt.Errorf("error creating a new machine machine: %w", err)
 	}
+	newMachine := machine.NewLeasableMachine(md.flapsClient, md.io, newMachineRaw)
 
 	// FIXME: dry this up with release commands and non-empty update
 	fmt.Fprintf(md.io.ErrOut, "  Created release_command machine %s\n", md.colorize.Bold(newMachineRaw.ID))
+	md.events.emit(Event{Type: EventMachineCreated, Machine: newMachineRaw.ID, Group: groupName, Release: md.releaseVersion})
 	if md.strategy != "immediate" {
 		err := newMachine.WaitForState(ctx, api.MachineStateStarted, md.waitTimeout)
 		if err != nil {
@@ -307,6 +615,7 @@ func (md *machineDeployment) spawnMachineInGroup(ctx context.Context, groupName
 	}
 	if md.strategy != "immediate" && !md.skipHealthChecks {
 		err := newMachine.WaitForHealthchecksToPass(ctx, md.waitTimeout)
+		md.emitHealthChecks(newMachineRaw, err)
 		// FIXME: combine this wait with the wait for start as one update line (or two per in noninteractive case)
 		if err != nil {
 			return err
@@ -322,6 +631,21 @@ func (md *machineDeployment) spawnMachineInGroup(ctx context.Context, groupName
 	return nil
 }
 
+// spawnMissingMachines creates the machines the groups of diff need, group by
+// group.
+func (md *machineDeployment) spawnMissingMachines(ctx context.Context, diff ProcessGroupsDiff) error {
+	groups := lo.Keys(diff.groupsNeedingMachines)
+	sort.Strings(groups)
+	for _, name := range groups {
+		for _, region := range diff.groupsNeedingMachines[name] {
+			if err := md.spawnMachineInGroup(ctx, name, region); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 func (md *machineDeployment) DeployMachinesApp(ctx context.Context) error {
 	ctx = flaps.NewContext(ctx, md.flapsClient)
 
@@ -329,19 +653,28 @@ func (md *machineDeployment) DeployMachinesApp(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("release command failed - aborting deployment. %w", err)
 	}
+	if err := md.runReleaseCommands(ctx); err != nil {
+		return fmt.Errorf("release command failed - aborting deployment. %w", err)
+	}
+
+	md.reportStagedSecrets(ctx)
 
 	if md.machineSet.IsEmpty() {
+		if err := md.verifyPlan(ctx); err != nil {
+			return err
+		}
 		processGroupMachineDiff := ProcessGroupsDiff{
 			groupsToRemove:        map[string]int{},
-			groupsNeedingMachines: md.processConfigs,
+			groupsNeedingMachines: md.missingMachines(nil),
 		}
 		md.warnAboutProcessGroupChanges(ctx, processGroupMachineDiff)
-		for name := range md.processConfigs {
-			if err := md.spawnMachineInGroup(ctx, name); err != nil {
-				return err
-			}
+		if err := md.spawnMissingMachines(ctx, processGroupMachineDiff); err != nil {
+			return err
 		}
-		return nil
+		if err := md.uploadArtifacts(ctx); err != nil {
+			return err
+		}
+		return md.runSmokeTests(ctx)
 	}
 
 	err = md.machineSet.AcquireLeases(ctx, md.leaseTimeout)
@@ -356,6 +689,12 @@ func (md *machineDeployment) DeployMachinesApp(ctx context.Context) error {
 	}
 	md.machineSet.StartBackgroundLeaseRefresh(ctx, md.leaseTimeout, md.leaseDelayBetween)
 
+	// with every lease held nothing can change under us anymore, but a deploy
+	// which ran while this one was planned may have already
+	if err := md.verifyPlan(ctx); err != nil {
+		return err
+	}
+
 	processGroupMachineDiff := md.resolveProcessGroupChanges()
 
 	// If restartOnly is set, that means we're *re*deploying a configuration.
@@ -381,55 +720,208 @@ func (md *machineDeployment) DeployMachinesApp(ctx context.Context) error {
 		}
 	}
 
-	// Create machines for new process groups
-	for name := range processGroupMachineDiff.groupsNeedingMachines {
-		if err := md.spawnMachineInGroup(ctx, name); err != nil {
+	// Create machines for new process groups, and those their [[vm]]
+	// sections lack
+	if err := md.spawnMissingMachines(ctx, processGroupMachineDiff); err != nil {
+		return err
+	}
+
+	// Upload to the running machines first so the new release finds its
+	// artifacts in place; machines created above are covered at the end
+	if err := md.uploadArtifacts(ctx); err != nil {
+		return err
+	}
+
+	if md.prePull {
+		md.prePullImages(ctx)
+	}
+
+	fmt.Fprintf(md.io.Out, "Deploying %s app with %s strategy\n", md.colorize.Bold(md.app.Name), md.strategy)
+
+	switch md.strategy {
+	case "canary":
+		if err := md.deployCanary(ctx); err != nil {
+			return err
+		}
+	case "bluegreen":
+		if err := md.deployBlueGreen(ctx); err != nil {
+			return err
+		}
+		if err := md.uploadArtifacts(ctx); err != nil {
 			return err
 		}
+		fmt.Fprintf(md.io.ErrOut, "  Finished deploying\n")
+		return md.runSmokeTests(ctx)
 	}
 
-	// FIXME: handle deploy strategy: rolling, immediate, canary, bluegreen
+	// the machines as they were before the deployment, to roll back to
+	originals := map[string]*api.Machine{}
+	for _, m := range md.machineSet.GetMachines() {
+		originals[m.Machine().ID] = m.Machine()
+	}
+	var (
+		updatedMu  sync.Mutex
+		updated    []machine.LeasableMachine
+		hotUpdated []string
+		replaced   []string
+	)
+
+	// at most md.maxUnavailable machines are being updated at any time; once
+	// one of them fails no further machine is touched
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(md.maxUnavailable)
+
+	var progress *deployProgress
+	stopProgress := func() {}
+	if md.liveProgress {
+		progress = newDeployProgress(md.io.ErrOut, lo.Map(md.machineSet.GetMachines(), func(m machine.LeasableMachine, _ int) *api.Machine {
+			return m.Machine()
+		}))
+		stopProgress = progress.start(ctx, md.flapsClient)
+	}
 
-	fmt.Fprintf(md.io.Out, "Deploying %s app with %s strategy\n", md.colorize.Bold(md.app.Name), md.strategy)
 	for _, m := range md.machineSet.GetMachines() {
-		launchInput := md.resolveUpdatedMachineConfig(m.Machine(), false)
+		m := m
+		eg.Go(func() error {
+			if egCtx.Err() != nil {
+				return nil
+			}
 
-		fmt.Fprintf(md.io.ErrOut, "  Updating %s\n", md.colorize.Bold(m.FormattedMachineId()))
-		err := m.Update(ctx, *launchInput)
-		if err != nil {
-			if md.strategy != "immediate" {
-				return err
-			} else {
-				fmt.Printf("Continuing after error: %s\n", err)
+			updatedMu.Lock()
+			updated = append(updated, m)
+			updatedMu.Unlock()
+
+			hot, err := md.updateMachine(egCtx, m, progress)
+			if err == nil {
+				updatedMu.Lock()
+				if hot {
+					hotUpdated = append(hotUpdated, m.Machine().ID)
+				} else {
+					replaced = append(replaced, m.Machine().ID)
+				}
+				updatedMu.Unlock()
 			}
-		}
+			return err
+		})
+	}
 
-		if md.strategy != "immediate" {
-			err := m.WaitForState(ctx, api.MachineStateStarted, md.waitTimeout)
+	err = eg.Wait()
+	stopProgress()
+	if err != nil {
+		return md.rollback(ctx, updated, originals, err)
+	}
+	md.reportUpdates(hotUpdated, replaced)
+
+	if err := md.uploadArtifacts(ctx); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "  Finished deploying\n")
+
+	if err := md.runSmokeTests(ctx); err != nil {
+		return md.rollback(ctx, updated, originals, err)
+	}
+	return nil
+}
+
+// updateMachine updates m with the new release and, unless the strategy is
+// immediate, waits for it to start and pass its health checks. A started
+// machine whose metadata or checks are all that change is updated in place
+// instead, which it reports. Progress is reported to progress when set, and
+// logged otherwise.
+func (md *machineDeployment) updateMachine(ctx context.Context, m machine.LeasableMachine, progress *deployProgress) (hot bool, err error) {
+	launchInput := md.resolveUpdatedMachineConfig(m.Machine(), false)
+	if md.hotUpdates(m.Machine(), launchInput) {
+		return true, md.hotUpdateMachine(ctx, m, launchInput, progress)
+	}
+
+	return false, md.replaceMachine(ctx, m, launchInput, progress)
+}
+
+// replaceMachine drains m and restarts it with launchInput.
+func (md *machineDeployment) replaceMachine(ctx context.Context, m machine.LeasableMachine, launchInput *api.LaunchMachineInput, progress *deployProgress) (err error) {
+	id := m.Machine().ID
+	if progress != nil {
+		defer func() {
 			if err != nil {
-				return err
+				progress.setStatus(id, "failed")
 			}
+		}()
+	}
+
+	if err := md.drainMachine(ctx, m.Machine(), progress); err != nil {
+		return err
+	}
+
+	if progress != nil {
+		progress.update(id, func(row *progressRow) {
+			row.newImage = launchInput.Config.Image
+			row.status = "updating"
+		})
+	} else {
+		fmt.Fprintf(md.io.ErrOut, "  Updating %s\n", md.colorize.Bold(m.FormattedMachineId()))
+	}
+	err = m.Update(ctx, *launchInput)
+	if err != nil {
+		if md.strategy != "immediate" {
+			return err
+		} else {
+			fmt.Fprintf(md.io.ErrOut, "Continuing after error: %s\n", err)
 		}
+	} else {
+		md.events.emit(Event{Type: EventMachineUpdated, Machine: id, Group: m.Machine().ProcessGroup(), Release: md.releaseVersion, Image: launchInput.Config.Image})
+	}
 
-		if md.strategy != "immediate" && !md.skipHealthChecks {
-			err := m.WaitForHealthchecksToPass(ctx, md.waitTimeout)
-			// FIXME: combine this wait with the wait for start as one update line (or two per in noninteractive case)
-			if err != nil {
-				return err
-			} else {
+	if md.strategy != "immediate" {
+		if progress != nil {
+			progress.setStatus(id, "starting")
+		}
+		err := m.WaitForState(ctx, api.MachineStateStarted, md.waitTimeout)
+		if err != nil {
+			return err
+		}
+	}
+
+	if md.strategy != "immediate" && !md.skipHealthChecks {
+		if progress != nil {
+			progress.setStatus(id, "checking health")
+		}
+		err := m.WaitForHealthchecksToPass(ctx, md.waitTimeout)
+		md.emitHealthChecks(m.Machine(), err)
+		// FIXME: combine this wait with the wait for start as one update line (or two per in noninteractive case)
+		if err != nil {
+			return err
+		}
+		if progress == nil {
+			// lines of machines updated concurrently interleave
+			if md.maxUnavailable == 1 {
 				md.logClearLinesAbove(1)
-				fmt.Fprintf(md.io.ErrOut, "  Machine %s update finished: %s\n",
-					md.colorize.Bold(m.FormattedMachineId()),
-					md.colorize.Green("success"),
-				)
 			}
+			fmt.Fprintf(md.io.ErrOut, "  Machine %s update finished: %s\n",
+				md.colorize.Bold(m.FormattedMachineId()),
+				md.colorize.Green("success"),
+			)
 		}
 	}
 
-	fmt.Fprintf(md.io.ErrOut, "  Finished deploying\n")
+	if progress != nil {
+		progress.setStatus(id, "done")
+	}
+
 	return nil
 }
 
+// emitHealthChecks reports the outcome of waiting for the health checks of m
+// to pass to the event stream.
+func (md *machineDeployment) emitHealthChecks(m *api.Machine, err error) {
+	e := Event{Type: EventHealthCheckPassed, Machine: m.ID, Group: m.ProcessGroup(), Release: md.releaseVersion}
+	if err != nil {
+		e.Type = EventHealthCheckFailed
+		e.Error = err.Error()
+	}
+	md.events.emit(e)
+}
+
 func (md *machineDeployment) setMachinesForDeployment(ctx context.Context) error {
 	machines, releaseCmdMachine, err := md.flapsClient.ListFlyAppsMachines(ctx)
 	if err != nil {
@@ -456,7 +948,58 @@ func (md *machineDeployment) setMachinesForDeployment(ctx context.Context) error
 		}
 	}
 
-	md.machineSet = machine.NewMachineSet(md.flapsClient, md.io, machines)
+	md.planChecksum = machinesChecksum(machines)
+	terminal.Debugf("Planning deployment against machines with checksum %s\n", md.planChecksum)
+
+	// blue machines kept by a bluegreen deployment only serve for a rollback
+	if kept := lo.CountBy(machines, (*api.Machine).IsKeptBlue); kept > 0 {
+		machines = lo.Reject(machines, func(m *api.Machine, _ int) bool { return m.IsKeptBlue() })
+		fmt.Fprintf(md.io.ErrOut, "Leaving alone %d blue machines kept by an earlier bluegreen deployment\n", kept)
+	}
+
+	// the machines of other release channels are deployed separately
+	otherChannels := lo.CountBy(machines, func(m *api.Machine) bool {
+		return md.channel != "" && m.ReleaseChannel() != md.channel
+	})
+	if otherChannels > 0 {
+		machines = lo.Filter(machines, func(m *api.Machine, _ int) bool {
+			return m.ReleaseChannel() == md.channel
+		})
+		fmt.Fprintf(md.io.ErrOut, "Deploying to the %s release channel, leaving %d machines of other channels alone\n", md.channel, otherChannels)
+	}
+	if md.filtersChannel() && len(machines) == 0 {
+		return fmt.Errorf("no machines of %s are in the %s release channel; move some to it with 'fly machine update <id> --metadata %s=%s'",
+			md.app.Name, md.channel, api.MachineConfigMetadataKeyFlyReleaseChannel, md.channel)
+	}
+
+	if md.filtersMachines() {
+		ids := lo.Map(machines, func(m *api.Machine, _ int) string { return m.ID })
+		if unknown, _ := lo.Difference(md.onlyMachines, ids); len(unknown) > 0 {
+			return fmt.Errorf("%s of %s can't be deployed to: no such machines, or they're in another release channel; list them with fly machines list", strings.Join(unknown, ", "), md.app.Name)
+		}
+
+		total := len(machines)
+		machines = lo.Filter(machines, func(m *api.Machine, _ int) bool {
+			return md.includesMachine(m)
+		})
+		if len(machines) == 0 {
+			return fmt.Errorf("none of the %d machines of %s are selected for this deployment", total, md.app.Name)
+		}
+		fmt.Fprintf(md.io.ErrOut, "Deploying to %d of %d machines, as selected\n", len(machines), total)
+		if !md.restartOnly && len(machines) < total {
+			terminal.Warnf("The other %d machines stay on their current release, fly status reports them as drifted until they're deployed to\n", total-len(machines))
+		}
+	}
+
+	// with the progress table the machines are kept from logging their own
+	// progress, which would scroll the table away
+	setIO := md.io
+	if md.liveProgress {
+		quiet := *md.io
+		quiet.ErrOut = io.Discard
+		setIO = &quiet
+	}
+	md.machineSet = machine.NewMachineSet(md.flapsClient, setIO, machines)
 	var releaseCmdSet []*api.Machine
 	if releaseCmdMachine != nil {
 		releaseCmdSet = []*api.Machine{releaseCmdMachine}
@@ -465,12 +1008,164 @@ func (md *machineDeployment) setMachinesForDeployment(ctx context.Context) error
 	return nil
 }
 
+// filtersMachines reports whether the deployment is restricted to some of the
+// machines of the app.
+func (md *machineDeployment) filtersMachines() bool {
+	return md.filtersChannel() || len(md.onlyRegions) > 0 || len(md.excludeRegions) > 0 ||
+		len(md.onlyMachines) > 0 || len(md.selector) > 0
+}
+
+// validateChannelTraffic checks the share of traffic routed to the release
+// channel deployed to can be set.
+func (md *machineDeployment) validateChannelTraffic() error {
+	switch {
+	case md.channelTraffic == nil:
+		return nil
+	case !md.filtersChannel():
+		return fmt.Errorf("--channel-traffic needs --channel to name a release channel other than %s, which gets the rest of the traffic", api.MachineReleaseChannelStable)
+	case *md.channelTraffic < 0 || *md.channelTraffic > 100:
+		return fmt.Errorf("--channel-traffic must be a percentage, between 0 and 100, got %d", *md.channelTraffic)
+	}
+	return nil
+}
+
+// filtersChannel reports whether the deployment is to a release channel but
+// stable, whose machines are only updated.
+func (md *machineDeployment) filtersChannel() bool {
+	return md.channel != "" && md.channel != api.MachineReleaseChannelStable
+}
+
+// includesMachine reports whether m is selected for the deployment. Every
+// filter given has to select it.
+func (md *machineDeployment) includesMachine(m *api.Machine) bool {
+	if len(md.onlyRegions) > 0 && !lo.Contains(md.onlyRegions, m.Region) {
+		return false
+	}
+	if lo.Contains(md.excludeRegions, m.Region) {
+		return false
+	}
+	if len(md.onlyMachines) > 0 && !lo.Contains(md.onlyMachines, m.ID) {
+		return false
+	}
+	for key, value := range md.selector {
+		if m.Config == nil || m.Config.Metadata[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// verifyPlan ensures the app's machines are the ones the deployment was
+// planned against, so overlapping deploys can't interleave machine updates.
+func (md *machineDeployment) verifyPlan(ctx context.Context) error {
+	machines, _, err := md.flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return err
+	}
+
+	if checksum := machinesChecksum(machines); checksum != md.planChecksum {
+		terminal.Debugf("Machines checksum changed from %s to %s\n", md.planChecksum, checksum)
+		return fmt.Errorf("the machines of app %s changed since this deployment started, likely because of another deployment; run the deployment again to apply it on top of the changes", md.app.Name)
+	}
+
+	return nil
+}
+
+// machinesChecksum identifies the version of every given machine. It only
+// changes when a machine is created, updated or destroyed. The release command
+// machine is left out, as this very deployment updates it before verifying.
+func machinesChecksum(machines []*api.Machine) string {
+	versions := make([]string, 0, len(machines))
+	for _, m := range machines {
+		versions = append(versions, m.ID+":"+m.InstanceID)
+	}
+	sort.Strings(versions)
+
+	sum := sha256.Sum256([]byte(strings.Join(versions, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
 func (md *machineDeployment) createOrUpdateReleaseCmdMachine(ctx context.Context) error {
 	if md.releaseCommandMachine.IsEmpty() {
 		return md.createReleaseCommandMachine(ctx)
-	} else {
-		return md.updateReleaseCommandMachine(ctx)
 	}
+
+	// machines can't move, so the release_command machine is replaced when
+	// it has to run in another region
+	existing := md.releaseCommandMachine.GetMachines()[0].Machine()
+	if region := md.releaseCommandMachineRegion(); region != "" && existing.Region != region {
+		fmt.Fprintf(md.io.ErrOut, "  Replacing release_command machine %s to run in %s\n", md.colorize.Bold(existing.ID), region)
+		if err := md.flapsClient.Destroy(ctx, api.RemoveMachineInput{ID: existing.ID, Kill: true}); err != nil {
+			return fmt.Errorf("error destroying release_command machine %s: %w", existing.ID, err)
+		}
+		md.releaseCommandMachine = machine.NewMachineSet(md.flapsClient, md.io, nil)
+		return md.createReleaseCommandMachine(ctx)
+	}
+
+	return md.updateReleaseCommandMachine(ctx)
+}
+
+// releaseCommandMachineRegion returns the region release commands run in.
+func (md *machineDeployment) releaseCommandMachineRegion() string {
+	if md.releaseCommandRegion != "" {
+		return md.releaseCommandRegion
+	}
+	return md.appConfig.PrimaryRegion
+}
+
+// setReleaseCommandVMConfig resolves how the machines release commands run in
+// differ from the app's machines, from the flags or else from
+// [deploy.release_command_vm].
+func (md *machineDeployment) setReleaseCommandVMConfig(ctx context.Context, sizeFlag, regionFlag string) error {
+	vm := &appconfig.ReleaseCommandVM{}
+	if md.appConfig.Deploy != nil && md.appConfig.Deploy.ReleaseCommandVM != nil {
+		vm = md.appConfig.Deploy.ReleaseCommandVM
+	}
+
+	md.releaseCommandRegion = lo.Ternary(regionFlag != "", regionFlag, vm.Region)
+
+	if size := lo.Ternary(sizeFlag != "", sizeFlag, vm.Size); size != "" {
+		guest, ok := api.MachinePresets[size]
+		if !ok {
+			sizes := lo.Keys(api.MachinePresets)
+			sort.Strings(sizes)
+			return fmt.Errorf("invalid release command vm size '%s', available: %s", size, strings.Join(sizes, ", "))
+		}
+		md.releaseCommandGuest = guest
+	}
+
+	if vm.Mounts == nil || (len(md.releaseCommand) == 0 && len(md.releaseCommands) == 0) || md.restartOnly {
+		return nil
+	}
+
+	// the volume may still be attached to the release_command machine of the
+	// previous deployment, which is replaced or updated
+	var releaseCmdMachineID string
+	if !md.releaseCommandMachine.IsEmpty() {
+		releaseCmdMachineID = md.releaseCommandMachine.GetMachines()[0].Machine().ID
+	}
+
+	volumes, err := md.apiClient.GetVolumes(ctx, md.app.Name)
+	if err != nil {
+		return fmt.Errorf("Error fetching application volumes: %w", err)
+	}
+
+	region := md.releaseCommandMachineRegion()
+	volume, found := lo.Find(volumes, func(v api.Volume) bool {
+		return v.Name == vm.Mounts.Source && (region == "" || v.Region == region) && v.AttachedAllocation == nil &&
+			(v.AttachedMachine == nil || v.AttachedMachine.ID == releaseCmdMachineID)
+	})
+	if !found {
+		return fmt.Errorf("no unattached volume named '%s' in region %s to mount in release command machines; create one with 'fly volumes create %s --region %s'",
+			vm.Mounts.Source, region, vm.Mounts.Source, region)
+	}
+
+	md.releaseCommandMounts = []api.MachineMount{{
+		Path:   vm.Mounts.Destination,
+		Volume: volume.ID,
+	}}
+
+	return nil
 }
 
 func (md *machineDeployment) configureLaunchInputForReleaseCommand(launchInput *api.LaunchMachineInput) *api.LaunchMachineInput {
@@ -481,8 +1176,18 @@ func (md *machineDeployment) configureLaunchInputForReleaseCommand(launchInput *
 	}
 	launchInput.Config.AutoDestroy = true
 	launchInput.Config.DNS = &api.DNSConfig{SkipRegistration: true}
-	if md.appConfig.PrimaryRegion != "" {
-		launchInput.Region = md.appConfig.PrimaryRegion
+	if region := md.releaseCommandMachineRegion(); region != "" {
+		launchInput.Region = region
+	}
+	if md.releaseCommandGuest != nil {
+		guest := *md.releaseCommandGuest
+		if launchInput.Config.Guest != nil {
+			guest.KernelArgs = launchInput.Config.Guest.KernelArgs
+		}
+		launchInput.Config.Guest = &guest
+	}
+	if len(md.releaseCommandMounts) > 0 {
+		launchInput.Config.Mounts = md.releaseCommandMounts
 	}
 	if _, present := launchInput.Config.Env["RELEASE_COMMAND"]; !present {
 		launchInput.Config.Env["RELEASE_COMMAND"] = "1"
@@ -549,6 +1254,47 @@ func (md *machineDeployment) setVolumeConfig(ctx context.Context) error {
 	return nil
 }
 
+// checkPrimaryRegion warns when the primary region lacks the app's machines
+// or volumes, or when the Postgres clusters it's attached to have their
+// primary elsewhere. The app's machines are only all known when they're all
+// deployed to, and being a warning, failures to look things up are ignored.
+func (md *machineDeployment) checkPrimaryRegion(ctx context.Context) {
+	if md.restartOnly || md.filtersMachines() || md.machineSet.IsEmpty() || md.appConfig.PrimaryRegion == "" {
+		return
+	}
+
+	machines := lo.Map(md.machineSet.GetMachines(), func(m machine.LeasableMachine, _ int) *api.Machine {
+		return m.Machine()
+	})
+
+	var (
+		volumes    []api.Volume
+		volumeName string
+	)
+	if md.appConfig.Mounts != nil {
+		var err error
+		if volumes, err = md.apiClient.GetVolumes(ctx, md.app.Name); err != nil {
+			terminal.Debugf("failed listing volumes to check the primary region: %v\n", err)
+			return
+		}
+		volumeName = md.appConfig.Mounts.Source
+	}
+
+	warnings := primaryregion.Check(md.appConfig.PrimaryRegion, machines, volumes, volumeName)
+
+	pgCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	if primaries, err := primaryregion.PostgresPrimaries(pgCtx, md.apiClient, md.app); err != nil {
+		terminal.Debugf("failed looking up the postgres clusters of %s: %v\n", md.app.Name, err)
+	} else {
+		warnings = append(warnings, primaryregion.CheckPostgres(md.appConfig.PrimaryRegion, primaries)...)
+	}
+
+	for _, warning := range warnings {
+		terminal.Warnf("%s; change it with 'fly regions set-primary'\n", warning)
+	}
+}
+
 func (md *machineDeployment) validateVolumeConfig() error {
 	for _, m := range md.machineSet.GetMachines() {
 		mid := m.Machine().ID
@@ -582,8 +1328,10 @@ func (md *machineDeployment) setStrategy(passedInStrategy string) error {
 	} else {
 		md.strategy = "rolling"
 	}
-	if md.strategy != "rolling" && md.strategy != "immediate" {
-		return fmt.Errorf("error unsupported deployment strategy '%s'; fly deploy for machines supports rolling and immediate strategies", md.strategy)
+	switch md.strategy {
+	case "rolling", "immediate", "canary", "bluegreen":
+	default:
+		return fmt.Errorf("error unsupported deployment strategy '%s'; fly deploy for machines supports rolling, immediate, canary and bluegreen strategies", md.strategy)
 	}
 	return nil
 }
@@ -616,6 +1364,7 @@ func (md *machineDeployment) createReleaseInBackend(ctx context.Context) error {
 	}
 	md.releaseId = resp.CreateRelease.Release.Id
 	md.releaseVersion = resp.CreateRelease.Release.Version
+	md.events.emit(Event{Type: EventReleaseCreated, Release: md.releaseVersion, Image: input.Image})
 	return nil
 }
 
@@ -646,6 +1395,12 @@ func (md *machineDeployment) resolveUpdatedMachineConfig(origMachineRaw *api.Mac
 		}),
 	)
 
+	// fly-proxy routes the share of the traffic of the channel by the
+	// metadata of its machines
+	if md.channelTraffic != nil {
+		launchInput.Config.Metadata[api.MachineConfigMetadataKeyFlyProxyChannelTraffic] = strconv.Itoa(*md.channelTraffic)
+	}
+
 	// Stop here If the machine is restarting
 	if md.restartOnly {
 		return launchInput
@@ -690,15 +1445,109 @@ func (md *machineDeployment) resolveUpdatedMachineConfig(origMachineRaw *api.Mac
 	}
 
 	processGroup := launchInput.Config.ProcessGroup()
+	if img, ok := md.processImages[processGroup]; ok {
+		launchInput.Config.Image = img.Tag
+		if md.releaseMetadata != nil && img.ID != "" {
+			launchInput.Config.Metadata[api.MachineConfigMetadataKeyFlyImageDigest] = img.ID
+		}
+	}
 	if processConfig, ok := md.processConfigs[processGroup]; ok {
 		launchInput.Config.Services = processConfig.Services
+		if bounds, ok := processConfig.Autoscaling[launchInput.Region]; ok {
+			launchInput.Config.Services = withMinMachinesRunning(processConfig.Services, bounds.Min)
+		}
 		launchInput.Config.Checks = processConfig.Checks
 		launchInput.Config.Init.Cmd = lo.Ternary(len(processConfig.Cmd) > 0, processConfig.Cmd, nil)
+		if processConfig.Guest != nil {
+			guest := *processConfig.Guest
+			if launchInput.Config.Guest != nil {
+				guest.KernelArgs = launchInput.Config.Guest.KernelArgs
+			}
+			launchInput.Config.Guest = &guest
+		}
+	}
+	if md.overridesGuest() {
+		launchInput.Config.Guest = md.overrideGuest(launchInput.Config.Guest)
 	}
 
 	return launchInput
 }
 
+// withMinMachinesRunning returns a copy of services keeping min machines
+// running in the region of the machine they're of.
+func withMinMachinesRunning(services []api.MachineService, min int) []api.MachineService {
+	res := make([]api.MachineService, len(services))
+	for i, service := range services {
+		service.MinMachinesRunning = api.Pointer(min)
+		res[i] = service
+	}
+	return res
+}
+
+// setGuestOverrides resolves the guest size the machines deployed to are
+// given instead of their own.
+func (md *machineDeployment) setGuestOverrides(size string, cpus, memory int) error {
+	md.vmSize = nil
+	if size != "" {
+		guest, ok := api.MachinePresets[size]
+		if !ok {
+			sizes := lo.Keys(api.MachinePresets)
+			sort.Strings(sizes)
+			return fmt.Errorf("invalid vm size '%s', available: %s", size, strings.Join(sizes, ", "))
+		}
+		md.vmSize = guest
+	}
+	if cpus < 0 || memory < 0 {
+		return errors.New("the number of CPUs and the memory of machines can't be negative")
+	}
+	md.vmCPUs, md.vmMemory = cpus, memory
+	return nil
+}
+
+// overridesGuest reports whether the guest size of the machines is overridden.
+func (md *machineDeployment) overridesGuest() bool {
+	return md.vmSize != nil || md.vmCPUs != 0 || md.vmMemory != 0
+}
+
+// overrideGuest returns guest, defaulting to shared-cpu-1x, with the guest
+// size overrides applied.
+func (md *machineDeployment) overrideGuest(guest *api.MachineGuest) *api.MachineGuest {
+	var overridden api.MachineGuest
+	switch {
+	case md.vmSize != nil:
+		overridden = *md.vmSize
+	case guest != nil:
+		overridden = *guest
+	default:
+		overridden = *api.MachinePresets["shared-cpu-1x"]
+	}
+	if guest != nil {
+		overridden.KernelArgs = guest.KernelArgs
+	}
+	if md.vmCPUs != 0 {
+		overridden.CPUs = md.vmCPUs
+	}
+	if md.vmMemory != 0 {
+		overridden.MemoryMB = md.vmMemory
+	}
+	return &overridden
+}
+
+// validateGuestOverrides checks the guest size every machine would end up
+// with, before any machine is updated.
+func (md *machineDeployment) validateGuestOverrides() error {
+	if !md.overridesGuest() || md.restartOnly {
+		return nil
+	}
+	for _, m := range md.machineSet.GetMachines() {
+		guest := md.resolveUpdatedMachineConfig(m.Machine(), false).Config.Guest
+		if err := machine.ValidateGuest(guest); err != nil {
+			return fmt.Errorf("machine %s can't be resized: %w", m.Machine().ID, err)
+		}
+	}
+	return nil
+}
+
 func (md *machineDeployment) defaultMachineMetadata() map[string]string {
 	res := map[string]string{
 		api.MachineConfigMetadataKeyFlyPlatformVersion: api.MachineFlyPlatformVersion2,
@@ -709,6 +1558,9 @@ func (md *machineDeployment) defaultMachineMetadata() map[string]string {
 	if md.app.IsPostgresApp() {
 		res[api.MachineConfigMetadataKeyFlyManagedPostgres] = "true"
 	}
+	for k, v := range md.releaseMetadata {
+		res[k] = v
+	}
 	return res
 }
 
@@ -716,7 +1568,10 @@ func isFlyAppsPlatformMetadata(key string) bool {
 	return key == api.MachineConfigMetadataKeyFlyPlatformVersion ||
 		key == api.MachineConfigMetadataKeyFlyReleaseId ||
 		key == api.MachineConfigMetadataKeyFlyReleaseVersion ||
-		key == api.MachineConfigMetadataKeyFlyManagedPostgres
+		key == api.MachineConfigMetadataKeyFlyManagedPostgres ||
+		key == api.MachineConfigMetadataKeyFlyImageDigest ||
+		key == api.MachineConfigMetadataKeyFlyGitSHA ||
+		key == api.MachineConfigMetadataKeyFlyDeployedAt
 }
 
 func (md *machineDeployment) provisionIpsOnFirstDeploy(ctx context.Context) error {
@@ -1,6 +1,7 @@
 package deploy
 
 import (
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -211,6 +212,44 @@ func Test_resolveUpdatedMachineConfig_ReleaseCommand(t *testing.T) {
 	}, md.resolveUpdatedMachineConfig(origMachine, true))
 }
 
+// Test release command VM overrides
+func Test_resolveUpdatedMachineConfig_ReleaseCommandVM(t *testing.T) {
+	md, err := stabMachineDeployment(&appconfig.Config{
+		AppName:       "my-cool-app",
+		PrimaryRegion: "scl",
+		Deploy: &appconfig.Deploy{
+			ReleaseCommand: "echo foo",
+			ReleaseCommandVM: &appconfig.ReleaseCommandVM{
+				Size:   "performance-2x",
+				Region: "ord",
+			},
+		},
+	})
+	assert.NoError(t, err)
+	md.releaseCommand = []string{"touch", "sky"}
+	assert.NoError(t, md.setReleaseCommandVMConfig(context.Background(), "", ""))
+	md.releaseCommandMounts = []api.MachineMount{{Volume: "vol_12345", Path: "/migrations"}}
+
+	launchInput := md.resolveUpdatedMachineConfig(nil, true)
+	assert.Equal(t, "ord", launchInput.Region)
+	assert.Equal(t, api.MachinePresets["performance-2x"], launchInput.Config.Guest)
+	assert.Equal(t, md.releaseCommandMounts, launchInput.Config.Mounts)
+
+	// flags override the config
+	assert.NoError(t, md.setReleaseCommandVMConfig(context.Background(), "shared-cpu-4x", "ams"))
+	launchInput = md.resolveUpdatedMachineConfig(nil, true)
+	assert.Equal(t, "ams", launchInput.Region)
+	assert.Equal(t, api.MachinePresets["shared-cpu-4x"], launchInput.Config.Guest)
+
+	// app machines are left alone
+	launchInput = md.resolveUpdatedMachineConfig(nil, false)
+	assert.Equal(t, "scl", launchInput.Region)
+	assert.Nil(t, launchInput.Config.Guest)
+	assert.Empty(t, launchInput.Config.Mounts)
+
+	assert.Error(t, md.setReleaseCommandVMConfig(context.Background(), "huge-cpu", ""))
+}
+
 // Test Mounts
 func Test_resolveUpdatedMachineConfig_Mounts(t *testing.T) {
 	md, err := stabMachineDeployment(&appconfig.Config{
@@ -358,3 +397,283 @@ func Test_resolveUpdatedMachineConfig_restartOnlyProcessGroup(t *testing.T) {
 		},
 	}, md.resolveUpdatedMachineConfig(origMachine, false))
 }
+
+// Test process groups getting their own image
+func Test_resolveUpdatedMachineConfig_processImages(t *testing.T) {
+	md, err := stabMachineDeployment(&appconfig.Config{
+		Processes: map[string]string{
+			"web":    "run web",
+			"worker": "run worker",
+		},
+	})
+	assert.NoError(t, err)
+	md.processImages = map[string]*imgsrc.DeploymentImage{
+		"worker": {Tag: "super/worker"},
+	}
+
+	machineInGroup := func(group string) *api.Machine {
+		return &api.Machine{
+			Config: &api.MachineConfig{
+				Metadata: map[string]string{"fly_process_group": group},
+			},
+		}
+	}
+
+	assert.Equal(t, "super/balloon", md.resolveUpdatedMachineConfig(machineInGroup("web"), false).Config.Image)
+	assert.Equal(t, "super/worker", md.resolveUpdatedMachineConfig(machineInGroup("worker"), false).Config.Image)
+
+	// release commands run in the app's image
+	md.releaseCommand = []string{"migrate"}
+	assert.Equal(t, "super/balloon", md.resolveUpdatedMachineConfig(machineInGroup("worker"), true).Config.Image)
+}
+
+func Test_machinesChecksum(t *testing.T) {
+	m1 := &api.Machine{ID: "m1", InstanceID: "i1"}
+	m2 := &api.Machine{ID: "m2", InstanceID: "i2"}
+
+	checksum := machinesChecksum([]*api.Machine{m1, m2})
+	assert.Equal(t, checksum, machinesChecksum([]*api.Machine{m2, m1}))
+	assert.NotEqual(t, checksum, machinesChecksum([]*api.Machine{m1}))
+	assert.NotEqual(t, checksum, machinesChecksum([]*api.Machine{m1, {ID: "m2", InstanceID: "i3"}}))
+	assert.NotEqual(t, machinesChecksum(nil), machinesChecksum([]*api.Machine{m1}))
+}
+
+func Test_resolveUpdatedMachineConfig_releaseMetadata(t *testing.T) {
+	md, err := stabMachineDeployment(&appconfig.Config{})
+	assert.NoError(t, err)
+	md.releaseMetadata = map[string]string{
+		api.MachineConfigMetadataKeyFlyImageDigest: "sha256:new",
+		api.MachineConfigMetadataKeyFlyDeployedAt:  "2023-05-01T10:00:00Z",
+	}
+
+	metadata := md.resolveUpdatedMachineConfig(&api.Machine{
+		Config: &api.MachineConfig{
+			Metadata: map[string]string{
+				api.MachineConfigMetadataKeyFlyImageDigest: "sha256:old",
+				api.MachineConfigMetadataKeyFlyGitSHA:      "abc123",
+				"owner":                                    "team-a",
+			},
+		},
+	}, false).Config.Metadata
+
+	assert.Equal(t, "sha256:new", metadata[api.MachineConfigMetadataKeyFlyImageDigest])
+	assert.Equal(t, "2023-05-01T10:00:00Z", metadata[api.MachineConfigMetadataKeyFlyDeployedAt])
+	assert.Equal(t, "team-a", metadata["owner"])
+	// stale values from a previous deployment don't linger
+	assert.NotContains(t, metadata, api.MachineConfigMetadataKeyFlyGitSHA)
+}
+
+// Test the share of traffic of a release channel set on its machines for
+// fly-proxy, and left as is when not given.
+func Test_resolveUpdatedMachineConfig_channelTraffic(t *testing.T) {
+	md, err := stabMachineDeployment(&appconfig.Config{})
+	assert.NoError(t, err)
+	md.channel = "beta"
+
+	beta := &api.Machine{
+		Config: &api.MachineConfig{
+			Metadata: map[string]string{
+				api.MachineConfigMetadataKeyFlyReleaseChannel:      "beta",
+				api.MachineConfigMetadataKeyFlyProxyChannelTraffic: "5",
+			},
+		},
+	}
+	metadata := md.resolveUpdatedMachineConfig(beta, false).Config.Metadata
+	assert.Equal(t, "5", metadata[api.MachineConfigMetadataKeyFlyProxyChannelTraffic])
+	assert.Equal(t, "beta", metadata[api.MachineConfigMetadataKeyFlyReleaseChannel])
+
+	md.channelTraffic = api.IntPointer(20)
+	assert.NoError(t, md.validateChannelTraffic())
+	metadata = md.resolveUpdatedMachineConfig(beta, false).Config.Metadata
+	assert.Equal(t, "20", metadata[api.MachineConfigMetadataKeyFlyProxyChannelTraffic])
+
+	md.channelTraffic = api.IntPointer(101)
+	assert.Error(t, md.validateChannelTraffic())
+
+	// the stable channel gets the rest of the traffic
+	md.channel = api.MachineReleaseChannelStable
+	md.channelTraffic = api.IntPointer(20)
+	assert.Error(t, md.validateChannelTraffic())
+}
+
+func Test_includesMachine(t *testing.T) {
+	machine := func(id, region, role string) *api.Machine {
+		return &api.Machine{ID: id, Region: region, Config: &api.MachineConfig{
+			Metadata: map[string]string{"role": role},
+		}}
+	}
+
+	md := &machineDeployment{}
+	assert.False(t, md.filtersMachines())
+	assert.True(t, md.includesMachine(machine("m1", "ord", "web")))
+
+	md.onlyRegions = []string{"ord", "iad"}
+	assert.True(t, md.filtersMachines())
+	assert.True(t, md.includesMachine(machine("m1", "iad", "web")))
+	assert.False(t, md.includesMachine(machine("m1", "cdg", "web")))
+
+	md.excludeRegions = []string{"iad"}
+	assert.True(t, md.includesMachine(machine("m1", "ord", "web")))
+	assert.False(t, md.includesMachine(machine("m1", "iad", "web")))
+
+	md.onlyRegions = nil
+	assert.True(t, md.includesMachine(machine("m1", "cdg", "web")))
+	assert.False(t, md.includesMachine(machine("m1", "iad", "web")))
+
+	md = &machineDeployment{onlyMachines: []string{"m1", "m2"}}
+	assert.True(t, md.filtersMachines())
+	assert.True(t, md.includesMachine(machine("m2", "ord", "web")))
+	assert.False(t, md.includesMachine(machine("m3", "ord", "web")))
+
+	md = &machineDeployment{selector: map[string]string{"role": "worker"}}
+	assert.True(t, md.filtersMachines())
+	assert.True(t, md.includesMachine(machine("m1", "ord", "worker")))
+	assert.False(t, md.includesMachine(machine("m1", "ord", "web")))
+
+	md.onlyMachines = []string{"m2"}
+	assert.False(t, md.includesMachine(machine("m1", "ord", "worker")))
+	assert.True(t, md.includesMachine(machine("m2", "ord", "worker")))
+
+	// only the machines of a release channel but stable are updated
+	md = &machineDeployment{channel: api.MachineReleaseChannelStable}
+	assert.False(t, md.filtersMachines())
+	md.channel = "beta"
+	assert.True(t, md.filtersMachines())
+
+	beta := machine("m1", "ord", "web")
+	beta.Config.Metadata[api.MachineConfigMetadataKeyFlyReleaseChannel] = "beta"
+	assert.Equal(t, "beta", beta.ReleaseChannel())
+	assert.Equal(t, api.MachineReleaseChannelStable, machine("m2", "ord", "web").ReleaseChannel())
+}
+
+// Test [[vm]] sections sizing and placing the machines of process groups
+func Test_processGroupCompute(t *testing.T) {
+	md, err := stabMachineDeployment(&appconfig.Config{
+		PrimaryRegion: "ord",
+		Processes: map[string]string{
+			"web":    "run web",
+			"worker": "run worker",
+			"cron":   "run cron",
+		},
+		Compute: []*appconfig.Compute{
+			{Processes: []string{"web"}, Regions: []string{"ord", "ams"}, Count: 2},
+			{Processes: []string{"worker"}, Size: "performance-1x", Count: 3},
+		},
+	})
+	assert.NoError(t, err)
+
+	machineInGroup := func(group, region string) *api.Machine {
+		return &api.Machine{
+			Region: region,
+			Config: &api.MachineConfig{
+				Metadata: map[string]string{"fly_process_group": group},
+				Guest:    &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 256, KernelArgs: []string{"quiet"}},
+			},
+		}
+	}
+
+	assert.Equal(t, &api.MachineGuest{CPUKind: "performance", CPUs: 1, MemoryMB: 2048, KernelArgs: []string{"quiet"}},
+		md.resolveUpdatedMachineConfig(machineInGroup("worker", "ord"), false).Config.Guest)
+	assert.Equal(t, &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 256, KernelArgs: []string{"quiet"}},
+		md.resolveUpdatedMachineConfig(machineInGroup("web", "ord"), false).Config.Guest)
+
+	assert.Equal(t, map[string][]string{
+		"web":    {"ord", "ord", "ams", "ams"},
+		"worker": {"ord", "ord", "ord"},
+		"cron":   {"ord"},
+	}, md.missingMachines(nil))
+
+	assert.Equal(t, map[string][]string{
+		"web":    {"ams"},
+		"worker": {"ord"},
+	}, md.missingMachines([]*api.Machine{
+		machineInGroup("web", "ord"),
+		machineInGroup("web", "ord"),
+		machineInGroup("web", "ord"),
+		machineInGroup("web", "ams"),
+		machineInGroup("worker", "ams"),
+		machineInGroup("worker", "ord"),
+		machineInGroup("cron", "ams"),
+	}))
+
+	// restarts only create machines for groups without any
+	md.restartOnly = true
+	assert.Equal(t, map[string][]string{"cron": {"ord"}}, md.missingMachines([]*api.Machine{
+		machineInGroup("web", "ord"),
+		machineInGroup("worker", "ord"),
+	}))
+}
+
+// Test [[autoscaling]] sections bounding the machines of process groups
+func Test_processGroupAutoscaling(t *testing.T) {
+	md, err := stabMachineDeployment(&appconfig.Config{
+		PrimaryRegion: "ord",
+		Processes: map[string]string{
+			"web":    "run web",
+			"worker": "run worker",
+		},
+		Services: []appconfig.Service{
+			{Protocol: "tcp", InternalPort: 8080, Processes: []string{"web"}},
+		},
+		Autoscaling: []*appconfig.Autoscaling{
+			{
+				Processes:   []string{"web"},
+				MinMachines: api.Pointer(0),
+				MaxMachines: 3,
+				ScaleToZero: true,
+				Regions:     map[string]*appconfig.RegionAutoscaling{"ams": {MinMachines: api.Pointer(1), MaxMachines: 2}},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	machineInGroup := func(group, region string) *api.Machine {
+		return &api.Machine{
+			Region: region,
+			Config: &api.MachineConfig{
+				Metadata: map[string]string{"fly_process_group": group},
+			},
+		}
+	}
+
+	assert.Equal(t, map[string][]string{
+		"web":    {"ams", "ord", "ord"},
+		"worker": {"ord"},
+	}, md.missingMachines([]*api.Machine{
+		machineInGroup("web", "ams"),
+		machineInGroup("web", "ord"),
+	}))
+
+	services := md.resolveUpdatedMachineConfig(machineInGroup("web", "ams"), false).Config.Services
+	assert.Equal(t, api.Pointer(1), services[0].MinMachinesRunning)
+	assert.Equal(t, api.Pointer(true), services[0].Autostop)
+	services = md.resolveUpdatedMachineConfig(machineInGroup("web", "ord"), false).Config.Services
+	assert.Equal(t, api.Pointer(0), services[0].MinMachinesRunning)
+	assert.Nil(t, md.processConfigs["web"].Services[0].MinMachinesRunning)
+}
+
+// Test --vm-size, --vm-cpus and --vm-memory overriding the guest size
+func Test_resolveUpdatedMachineConfig_guestOverrides(t *testing.T) {
+	md, err := stabMachineDeployment(&appconfig.Config{})
+	assert.NoError(t, err)
+
+	m := &api.Machine{
+		Config: &api.MachineConfig{
+			Guest: &api.MachineGuest{CPUKind: "shared", CPUs: 2, MemoryMB: 512, KernelArgs: []string{"quiet"}},
+		},
+	}
+
+	assert.NoError(t, md.setGuestOverrides("", 0, 1024))
+	assert.Equal(t, &api.MachineGuest{CPUKind: "shared", CPUs: 2, MemoryMB: 1024, KernelArgs: []string{"quiet"}},
+		md.resolveUpdatedMachineConfig(m, false).Config.Guest)
+
+	assert.NoError(t, md.setGuestOverrides("performance-2x", 0, 0))
+	assert.Equal(t, &api.MachineGuest{CPUKind: "performance", CPUs: 2, MemoryMB: 4096, KernelArgs: []string{"quiet"}},
+		md.resolveUpdatedMachineConfig(m, false).Config.Guest)
+
+	assert.NoError(t, md.setGuestOverrides("", 4, 0))
+	assert.Equal(t, &api.MachineGuest{CPUKind: "shared", CPUs: 4, MemoryMB: 256},
+		md.resolveUpdatedMachineConfig(&api.Machine{Config: &api.MachineConfig{}}, false).Config.Guest)
+
+	assert.ErrorContains(t, md.setGuestOverrides("huge", 0, 0), "invalid vm size 'huge'")
+}
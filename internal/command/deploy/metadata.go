@@ -0,0 +1,40 @@
+package deploy
+
+import (
+	"context"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/state"
+)
+
+// releaseMetadata returns the metadata tagging machines with what they were
+// deployed from and when. Values which can't be determined, like the commit
+// of a working directory which isn't a git checkout, are left out.
+func releaseMetadata(ctx context.Context, img *imgsrc.DeploymentImage) map[string]string {
+	metadata := map[string]string{
+		api.MachineConfigMetadataKeyFlyDeployedAt: time.Now().UTC().Format(time.RFC3339),
+	}
+
+	if img != nil && img.ID != "" {
+		metadata[api.MachineConfigMetadataKeyFlyImageDigest] = img.ID
+	}
+
+	if sha := gitCommit(state.WorkingDirectory(ctx)); sha != "" {
+		metadata[api.MachineConfigMetadataKeyFlyGitSHA] = sha
+	}
+
+	return metadata
+}
+
+func gitCommit(dir string) string {
+	out, err := exec.Command("git", "-C", dir, "rev-parse", "HEAD").Output()
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
@@ -0,0 +1,81 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// prePullConcurrency bounds how many pre-pull machines are created at once.
+const prePullConcurrency = 8
+
+// prePullImages has the hosts of the regions of the machines being updated
+// fetch the images of the release before the rollout, so that replacing each
+// machine doesn't wait for its image to be pulled. The Machines API can't pull
+// an image on a given host, so a machine, never started, is created with the
+// image of each machine being updated, in its region, and destroyed once
+// created. Which hosts they land on is up to the platform. Failures are only
+// warned about, as they only slow down the rollout.
+func (md *machineDeployment) prePullImages(ctx context.Context) {
+	machines := lo.Map(md.machineSet.GetMachines(), func(m machine.LeasableMachine, _ int) *api.Machine {
+		return m.Machine()
+	})
+	inputs := md.prePullInputs(machines)
+	if len(inputs) == 0 {
+		return
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "Pre-pulling the image on hosts of %d machines\n", len(inputs))
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(prePullConcurrency)
+	for _, input := range inputs {
+		input := input
+		eg.Go(func() error {
+			m, err := md.flapsClient.Launch(ctx, input)
+			if err != nil {
+				terminal.Warnf("Failed pre-pulling %s in %s: %v\n", input.Config.Image, input.Region, err)
+				return nil
+			}
+			if err := md.flapsClient.Destroy(ctx, api.RemoveMachineInput{AppID: md.app.Name, ID: m.ID, Kill: true}); err != nil {
+				terminal.Warnf("Failed destroying pre-pull machine %s: %v\n", m.ID, err)
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+}
+
+// prePullInputs returns the machines created to pull the images the machines
+// being updated get, one per machine in its region.
+func (md *machineDeployment) prePullInputs(machines []*api.Machine) []api.LaunchMachineInput {
+	var inputs []api.LaunchMachineInput
+	for _, m := range machines {
+		image := md.img.Tag
+		if img, ok := md.processImages[m.ProcessGroup()]; ok {
+			image = img.Tag
+		}
+
+		guest := *api.MachinePresets["shared-cpu-1x"]
+		inputs = append(inputs, api.LaunchMachineInput{
+			AppID:      md.app.Name,
+			Region:     m.Region,
+			SkipLaunch: true,
+			Config: &api.MachineConfig{
+				Image:   image,
+				Guest:   &guest,
+				Restart: api.MachineRestart{Policy: api.MachineRestartPolicyNo},
+			},
+		})
+	}
+
+	sort.SliceStable(inputs, func(i, j int) bool { return inputs[i].Region < inputs[j].Region })
+	return inputs
+}
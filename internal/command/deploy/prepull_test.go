@@ -0,0 +1,49 @@
+package deploy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+)
+
+func TestPrePullInputs(t *testing.T) {
+	md, err := stabMachineDeployment(&appconfig.Config{AppName: "my-cool-app"})
+	require.NoError(t, err)
+	md.app.Name = "my-cool-app"
+	md.processImages = map[string]*imgsrc.DeploymentImage{"worker": {Tag: "super/worker"}}
+
+	worker := blueMachine("blue3")
+	worker.Region = "ams"
+	worker.Config.Metadata[api.MachineConfigMetadataKeyFlyProcessGroup] = "worker"
+
+	inputs := md.prePullInputs([]*api.Machine{blueMachine("blue1"), worker, blueMachine("blue2")})
+	assert.Len(t, inputs, 3)
+
+	for i, want := range []struct{ region, image string }{
+		{"ams", "super/worker"},
+		{"scl", "super/balloon"},
+		{"scl", "super/balloon"},
+	} {
+		assert.Equal(t, want.region, inputs[i].Region)
+		assert.Equal(t, want.image, inputs[i].Config.Image)
+		assert.True(t, inputs[i].SkipLaunch, "pre-pull machines are never started")
+		assert.Empty(t, inputs[i].Config.Metadata, "pre-pull machines aren't managed by deployments")
+	}
+}
+
+func TestPrePullImages(t *testing.T) {
+	blue := []*api.Machine{blueMachine("blue1"), blueMachine("blue2")}
+	f := newFakeFlaps(t, blue...)
+	ctx, md := stabStrategyDeployment(t, f, blue...)
+
+	md.prePullImages(ctx)
+	assert.Equal(t, []string{"green1", "green2"}, f.launched)
+	assert.ElementsMatch(t, []string{"green1", "green2"}, f.destroyed)
+	assert.Equal(t, "super/balloon", f.machines["green1"].Config.Image)
+	assert.Equal(t, api.MachineStateStarted, f.machines["blue1"].State, "the machines being updated are left alone")
+}
@@ -0,0 +1,167 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/azazeal/pause"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// progressPollInterval is the interval between refreshes of the state and
+// health checks of the machines shown by the progress table.
+const progressPollInterval = 2 * time.Second
+
+// deployProgress renders the rollout of a machines deployment as a table of
+// machines which is redrawn in place, rather than as a stream of log lines.
+type deployProgress struct {
+	mu    sync.Mutex
+	table *render.LiveTable
+	ids   []string
+	rows  map[string]*progressRow
+}
+
+type progressRow struct {
+	region   string
+	oldImage string
+	newImage string
+	status   string
+	state    string
+	checks   string
+}
+
+func newDeployProgress(w io.Writer, machines []*api.Machine) *deployProgress {
+	p := &deployProgress{
+		table: render.NewLiveTable(w),
+		rows:  map[string]*progressRow{},
+	}
+
+	for _, m := range machines {
+		p.ids = append(p.ids, m.ID)
+		p.rows[m.ID] = &progressRow{
+			region:   m.Region,
+			oldImage: m.Config.Image,
+			status:   "pending",
+			state:    m.State,
+			checks:   formatChecks(m),
+		}
+	}
+
+	return p
+}
+
+// start renders the table and keeps it up to date with the state of the
+// machines until the returned function is called.
+func (p *deployProgress) start(ctx context.Context, flapsClient *flaps.Client) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	p.render()
+
+	go func() {
+		defer close(done)
+
+		for {
+			if pause.For(ctx, progressPollInterval); ctx.Err() != nil {
+				return
+			}
+
+			machines, err := flapsClient.List(ctx, "")
+			if err != nil {
+				terminal.Debugf("failed refreshing machines for the deploy progress: %v\n", err)
+				continue
+			}
+			p.observe(machines)
+			p.render()
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+		p.render()
+	}
+}
+
+// update changes the row of the machine with the given ID, and redraws the
+// table.
+func (p *deployProgress) update(id string, fn func(*progressRow)) {
+	p.mu.Lock()
+	if row, ok := p.rows[id]; ok {
+		fn(row)
+	}
+	p.mu.Unlock()
+
+	p.render()
+}
+
+func (p *deployProgress) setStatus(id, status string) {
+	p.update(id, func(row *progressRow) {
+		row.status = status
+	})
+}
+
+func (p *deployProgress) observe(machines []*api.Machine) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, m := range machines {
+		if row, ok := p.rows[m.ID]; ok {
+			row.state = m.State
+			row.checks = formatChecks(m)
+		}
+	}
+}
+
+func (p *deployProgress) render() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	rows := make([][]string, 0, len(p.ids))
+	for _, id := range p.ids {
+		row := p.rows[id]
+		rows = append(rows, []string{
+			id,
+			row.region,
+			shortImage(row.oldImage),
+			shortImage(row.newImage),
+			row.status,
+			row.state,
+			row.checks,
+		})
+	}
+
+	if err := p.table.Render(rows, "Machine", "Region", "Old Image", "New Image", "Status", "State", "Checks"); err != nil {
+		terminal.Debugf("failed rendering the deploy progress: %v\n", err)
+	}
+}
+
+func formatChecks(m *api.Machine) string {
+	status := m.HealthCheckStatus()
+	switch {
+	case status.Total == 0:
+		return "-"
+	case status.Critical > 0:
+		return fmt.Sprintf("%d/%d critical", status.Critical, status.Total)
+	default:
+		return fmt.Sprintf("%d/%d passing", status.Passing, status.Total)
+	}
+}
+
+// shortImage drops the registry and repository from an image reference, as
+// they're the same for every machine of an app.
+func shortImage(image string) string {
+	if image == "" {
+		return "-"
+	}
+
+	return image[strings.LastIndex(image, "/")+1:]
+}
@@ -0,0 +1,98 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// resumeDir is the directory of the cache directory the state of unfinished
+// deployments is kept in, one file per app.
+const resumeDir = "deploys"
+
+// resumeState is what a deployment has got done, kept until it succeeds so
+// that fly deploy --resume picks a failed one up after its last completed
+// phase rather than from scratch.
+type resumeState struct {
+	App string `json:"app"`
+	// Image and ProcessImages are the images the deployment pushed, which
+	// are deployed again rather than built and pushed again.
+	Image         *imgsrc.DeploymentImage            `json:"image"`
+	ProcessImages map[string]*imgsrc.DeploymentImage `json:"process_images,omitempty"`
+	PushedAt      time.Time                          `json:"pushed_at"`
+}
+
+func resumeStatePath(cacheDir, appName string) string {
+	return filepath.Join(cacheDir, resumeDir, appName+".json")
+}
+
+func saveResumeState(cacheDir string, s *resumeState) error {
+	path := resumeStatePath(cacheDir, s.App)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0o600)
+}
+
+// loadResumeState returns the state of the unfinished deployment of appName,
+// nil when there's none.
+func loadResumeState(cacheDir, appName string) (*resumeState, error) {
+	data, err := os.ReadFile(resumeStatePath(cacheDir, appName))
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	var s resumeState
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("corrupt state of the last deployment of %s: %w", appName, err)
+	}
+	if s.Image == nil {
+		return nil, nil
+	}
+
+	return &s, nil
+}
+
+func clearResumeState(cacheDir, appName string) error {
+	if err := os.Remove(resumeStatePath(cacheDir, appName)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// resumeDeployment sets args up to deploy the images the last deployment of
+// appName pushed before failing.
+func resumeDeployment(ctx context.Context, appName string, args *DeployWithConfigArgs) error {
+	s, err := loadResumeState(state.CacheDirectory(ctx), appName)
+	if err != nil {
+		return err
+	}
+	if s == nil {
+		return fmt.Errorf("no failed deployment of %s to resume, its last deployment either succeeded or didn't get to push an image", appName)
+	}
+
+	fmt.Fprintf(iostreams.FromContext(ctx).ErrOut, "Resuming the deployment of %s with image %s, pushed %s\n",
+		appName, s.Image.Tag, s.PushedAt.Local().Format(time.RFC822))
+
+	args.Image = s.Image
+	args.ProcessImages = s.ProcessImages
+
+	return nil
+}
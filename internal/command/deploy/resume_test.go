@@ -0,0 +1,44 @@
+package deploy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+)
+
+func Test_ResumeState(t *testing.T) {
+	dir := t.TempDir()
+
+	s, err := loadResumeState(dir, "app")
+	require.NoError(t, err)
+	assert.Nil(t, s)
+
+	pushed := &resumeState{
+		App:   "app",
+		Image: &imgsrc.DeploymentImage{ID: "sha256:abc", Tag: "registry.fly.io/app:deployment-1", Size: 42},
+		ProcessImages: map[string]*imgsrc.DeploymentImage{
+			"worker": {ID: "sha256:def", Tag: "registry.fly.io/app:deployment-1-worker"},
+		},
+		PushedAt: time.Date(2023, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	require.NoError(t, saveResumeState(dir, pushed))
+
+	s, err = loadResumeState(dir, "app")
+	require.NoError(t, err)
+	assert.Equal(t, pushed, s)
+
+	s, err = loadResumeState(dir, "other")
+	require.NoError(t, err)
+	assert.Nil(t, s)
+
+	require.NoError(t, clearResumeState(dir, "app"))
+	require.NoError(t, clearResumeState(dir, "app"))
+
+	s, err = loadResumeState(dir, "app")
+	require.NoError(t, err)
+	assert.Nil(t, s)
+}
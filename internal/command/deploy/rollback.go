@@ -0,0 +1,47 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/machine"
+)
+
+// rollback restores the configuration the updated machines had before the
+// deployment, which failed with deployErr, when md.rollbackOnFailure is set.
+// The error returned always wraps deployErr.
+func (md *machineDeployment) rollback(ctx context.Context, updated []machine.LeasableMachine, originals map[string]*api.Machine, deployErr error) error {
+	if !md.rollbackOnFailure || len(updated) == 0 {
+		return deployErr
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "Deployment failed: %v\n", deployErr)
+	fmt.Fprintf(md.io.ErrOut, "Rolling back %d machine(s) to their previous configuration\n", len(updated))
+
+	for i := len(updated) - 1; i >= 0; i-- {
+		m := updated[i]
+		original := originals[m.Machine().ID]
+
+		fmt.Fprintf(md.io.ErrOut, "  Restoring %s\n", md.colorize.Bold(m.FormattedMachineId()))
+
+		// machines updated in place are rolled back in place too
+		inPlace := !md.restartOnly && original.State == api.MachineStateStarted && updatesInPlace(m.Machine().Config, original.Config)
+		err := m.Update(ctx, api.LaunchMachineInput{
+			ID:         original.ID,
+			AppID:      md.app.Name,
+			OrgSlug:    md.app.Organization.ID,
+			Region:     original.Region,
+			Config:     machine.CloneConfig(original.Config),
+			SkipLaunch: inPlace,
+		})
+		if err == nil && original.State == api.MachineStateStarted && !inPlace {
+			err = m.WaitForState(ctx, api.MachineStateStarted, md.waitTimeout)
+		}
+		if err != nil {
+			return fmt.Errorf("%w; rolling back machine %s failed too: %v", deployErr, original.ID, err)
+		}
+	}
+
+	return fmt.Errorf("deployment failed and was rolled back: %w", deployErr)
+}
@@ -0,0 +1,110 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/dotenv"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// secretsFromFiles parses the .env files at paths, the secrets of later files
+// overriding those of earlier ones.
+func secretsFromFiles(paths []string) (map[string]string, error) {
+	secrets := map[string]string{}
+
+	for _, path := range paths {
+		pairs, err := dotenv.ParseFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed reading secrets: %w", err)
+		}
+		secrets = lo.Assign(secrets, pairs)
+	}
+
+	return secrets, nil
+}
+
+// stageSecrets sets secrets on the app without deploying them, so that the
+// machines the deployment updates next boot with them. With dryRun, the names
+// of the secrets are only listed.
+func stageSecrets(ctx context.Context, appName string, secrets map[string]string, dryRun bool) error {
+	if len(secrets) == 0 {
+		return nil
+	}
+
+	io := iostreams.FromContext(ctx)
+
+	names := lo.Keys(secrets)
+	sort.Strings(names)
+
+	if dryRun {
+		fmt.Fprintf(io.ErrOut, "Would set secrets: %s\n", strings.Join(names, ", "))
+		return nil
+	}
+
+	if _, err := client.FromContext(ctx).API().SetSecrets(ctx, appName, secrets); err != nil {
+		return fmt.Errorf("failed setting secrets: %w", err)
+	}
+	fmt.Fprintf(io.ErrOut, "Set secrets for this deployment: %s\n", strings.Join(names, ", "))
+
+	return nil
+}
+
+// StagedSecrets returns the secrets set after some of the machines were last
+// updated, which those machines don't have in their environment until they're
+// deployed again, as happens with fly secrets set --stage. Without machines,
+// every secret waits for the first deployment.
+func StagedSecrets(secrets []api.Secret, machines []*api.Machine) []api.Secret {
+	var oldest time.Time
+	for _, m := range machines {
+		updatedAt, err := time.Parse(time.RFC3339, m.UpdatedAt)
+		if err != nil {
+			continue
+		}
+		if oldest.IsZero() || updatedAt.Before(oldest) {
+			oldest = updatedAt
+		}
+	}
+	if oldest.IsZero() {
+		return secrets
+	}
+
+	return lo.Filter(secrets, func(s api.Secret, _ int) bool {
+		return s.CreatedAt.After(oldest)
+	})
+}
+
+// reportStagedSecrets lists the staged secrets the deployment ships to the
+// machines. Failing to tell which they are doesn't fail the deployment.
+func (md *machineDeployment) reportStagedSecrets(ctx context.Context) {
+	if md.restartOnly || md.machineSet.IsEmpty() {
+		return
+	}
+
+	secrets, err := md.apiClient.GetAppSecrets(ctx, md.app.Name)
+	if err != nil {
+		terminal.Debugf("failed listing secrets: %v\n", err)
+		return
+	}
+
+	machines := lo.Map(md.machineSet.GetMachines(), func(m machine.LeasableMachine, _ int) *api.Machine {
+		return m.Machine()
+	})
+	staged := StagedSecrets(secrets, machines)
+	if len(staged) == 0 {
+		return
+	}
+
+	names := lo.Map(staged, func(s api.Secret, _ int) string { return s.Name })
+	sort.Strings(names)
+	fmt.Fprintf(md.io.ErrOut, "Deploying staged secrets: %s\n", strings.Join(names, ", "))
+}
@@ -0,0 +1,30 @@
+package deploy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func Test_StagedSecrets(t *testing.T) {
+	at := func(hour int) time.Time {
+		return time.Date(2023, 5, 1, hour, 0, 0, 0, time.UTC)
+	}
+	secrets := []api.Secret{
+		{Name: "OLD", CreatedAt: at(1)},
+		{Name: "BETWEEN", CreatedAt: at(3)},
+		{Name: "NEW", CreatedAt: at(5)},
+	}
+	machines := []*api.Machine{
+		{ID: "m1", UpdatedAt: at(4).Format(time.RFC3339)},
+		{ID: "m2", UpdatedAt: at(2).Format(time.RFC3339)},
+	}
+
+	staged := StagedSecrets(secrets, machines)
+	assert.Equal(t, []api.Secret{secrets[1], secrets[2]}, staged)
+
+	assert.Equal(t, secrets, StagedSecrets(secrets, nil))
+}
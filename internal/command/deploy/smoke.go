@@ -0,0 +1,169 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/agent"
+)
+
+const (
+	// smokeTestTimeout is how long a smoke test URL is retried for, as the new
+	// release may take a moment to be routed to.
+	smokeTestTimeout        = 30 * time.Second
+	smokeTestRequestTimeout = 10 * time.Second
+	smokeTestRetryInterval  = 2 * time.Second
+	// smokeTestMaxBody is the most of a response searched for --smoke-contains
+	smokeTestMaxBody = 1 << 20
+)
+
+// smokeTest are the requests made once a deployment has rolled out, to check
+// the new release serves them.
+type smokeTest struct {
+	urls []*url.URL
+	// status is the expected status code; when zero any 2xx or 3xx will do
+	status   int
+	contains string
+}
+
+func newSmokeTest(urls []string, status int, contains string) (*smokeTest, error) {
+	if len(urls) == 0 {
+		if status != 0 || contains != "" {
+			return nil, fmt.Errorf("--smoke-status and --smoke-contains require --smoke-url")
+		}
+		return nil, nil
+	}
+
+	st := &smokeTest{status: status, contains: contains}
+	for _, raw := range urls {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid smoke test URL %s: %w", raw, err)
+		}
+		if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid smoke test URL %s: expected an absolute http or https URL", raw)
+		}
+		st.urls = append(st.urls, u)
+	}
+
+	return st, nil
+}
+
+// isInternalHost reports whether u is only reachable through the
+// organization's private network.
+func isInternalHost(u *url.URL) bool {
+	return strings.HasSuffix(u.Hostname(), ".internal")
+}
+
+// runSmokeTests requests every smoke test URL, retrying each until it passes
+// or smokeTestTimeout elapses.
+func (md *machineDeployment) runSmokeTests(ctx context.Context) error {
+	if md.smokeTest == nil {
+		return nil
+	}
+
+	fmt.Fprintf(md.io.ErrOut, "Running smoke tests\n")
+
+	var internalClient *http.Client
+	for _, u := range md.smokeTest.urls {
+		client := http.DefaultClient
+		if isInternalHost(u) {
+			if internalClient == nil {
+				var err error
+				if internalClient, err = md.internalHTTPClient(ctx); err != nil {
+					return fmt.Errorf("smoke test of %s failed: %w", u, err)
+				}
+			}
+			client = internalClient
+		}
+
+		if err := md.smokeTest.retry(ctx, client, u); err != nil {
+			fmt.Fprintf(md.io.ErrOut, "  %s %s: %v\n", md.colorize.FailureIcon(), u, err)
+			return fmt.Errorf("smoke test of %s failed: %w", u, err)
+		}
+		fmt.Fprintf(md.io.ErrOut, "  %s %s\n", md.colorize.SuccessIcon(), u)
+	}
+
+	return nil
+}
+
+// internalHTTPClient returns a client dialing through a wireguard tunnel to the
+// app's organization.
+func (md *machineDeployment) internalHTTPClient(ctx context.Context) (*http.Client, error) {
+	agentclient, err := agent.Establish(ctx, md.apiClient)
+	if err != nil {
+		return nil, fmt.Errorf("can't establish agent: %w", err)
+	}
+
+	dialer, err := agentclient.ConnectToTunnel(ctx, md.app.Organization.Slug)
+	if err != nil {
+		return nil, fmt.Errorf("can't build tunnel for %s: %w", md.app.Organization.Slug, err)
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: dialer.DialContext,
+		},
+	}, nil
+}
+
+func (st *smokeTest) retry(ctx context.Context, client *http.Client, u *url.URL) error {
+	ctx, cancel := context.WithTimeout(ctx, smokeTestTimeout)
+	defer cancel()
+
+	for {
+		err := st.check(ctx, client, u)
+		if err == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return err
+		case <-time.After(smokeTestRetryInterval):
+		}
+	}
+}
+
+// check requests u once and compares the response to the expected one.
+func (st *smokeTest) check(ctx context.Context, client *http.Client, u *url.URL) error {
+	ctx, cancel := context.WithTimeout(ctx, smokeTestRequestTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close() //skipcq: GO-S2307
+
+	switch {
+	case st.status != 0 && res.StatusCode != st.status:
+		return fmt.Errorf("got status %d, expected %d", res.StatusCode, st.status)
+	case st.status == 0 && (res.StatusCode < 200 || res.StatusCode >= 400):
+		return fmt.Errorf("got status %d", res.StatusCode)
+	}
+
+	if st.contains == "" {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(res.Body, smokeTestMaxBody))
+	if err != nil {
+		return fmt.Errorf("failed reading response: %w", err)
+	}
+	if !strings.Contains(string(body), st.contains) {
+		return fmt.Errorf("response doesn't contain %q", st.contains)
+	}
+
+	return nil
+}
@@ -0,0 +1,61 @@
+package deploy
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_smokeTest_check(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte("release v42 ok"))
+	}))
+	defer server.Close()
+
+	parse := func(path string) *url.URL {
+		u, err := url.Parse(server.URL + path)
+		require.NoError(t, err)
+		return u
+	}
+	ctx := context.Background()
+
+	st := &smokeTest{}
+	assert.NoError(t, st.check(ctx, server.Client(), parse("/")))
+	assert.ErrorContains(t, st.check(ctx, server.Client(), parse("/missing")), "got status 404")
+
+	st = &smokeTest{status: http.StatusNotFound}
+	assert.NoError(t, st.check(ctx, server.Client(), parse("/missing")))
+	assert.ErrorContains(t, st.check(ctx, server.Client(), parse("/")), "got status 200, expected 404")
+
+	st = &smokeTest{contains: "v42"}
+	assert.NoError(t, st.check(ctx, server.Client(), parse("/")))
+	st = &smokeTest{contains: "v43"}
+	assert.ErrorContains(t, st.check(ctx, server.Client(), parse("/")), `response doesn't contain "v43"`)
+}
+
+func Test_newSmokeTest(t *testing.T) {
+	st, err := newSmokeTest(nil, 0, "")
+	assert.NoError(t, err)
+	assert.Nil(t, st)
+
+	_, err = newSmokeTest(nil, 200, "")
+	assert.Error(t, err)
+
+	_, err = newSmokeTest([]string{"example.com/health"}, 0, "")
+	assert.ErrorContains(t, err, "expected an absolute http or https URL")
+
+	st, err = newSmokeTest([]string{"https://example.com/health", "http://my-app.internal:8080/"}, 0, "")
+	require.NoError(t, err)
+	require.Len(t, st.urls, 2)
+	assert.False(t, isInternalHost(st.urls[0]))
+	assert.True(t, isInternalHost(st.urls[1]))
+}
@@ -2,7 +2,6 @@
 package dig
 
 import (
-	"bytes"
 	"context"
 	"encoding/binary"
 	"errors"
@@ -20,7 +19,9 @@ import (
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
 )
 
 var nameErrorRx = regexp.MustCompile(`\[.*?\]:53`)
@@ -34,17 +35,24 @@ but may be useful if diagnosing a DNS bug) and A and CNAME
 (if you're using the server to test recursive lookups.)
 Note that this resolves names against the server for the current organization. You can
 set the organization with -o <org-slug>; otherwise, the command uses the organization
-attached to the current app (you can pass an app in with -a <appname>).`
+attached to the current app (you can pass an app in with -a <appname>).
+
+Discovery helpers:
+  --apps        list every app in the organization along with its AAAA records
+  --vms         list the machines of the current app (vms.<app>.internal)
+  --region REG  list the addresses of the current app in a region (<region>.<app>.internal)
+
+Use --json for output suitable for service discovery scripts.`
 
 		short = "Make DNS requests against Fly.io's internal DNS server"
 	)
 
-	cmd := command.New("dig [type] <name> [flags]", short, long, run,
+	cmd := command.New("dig [type] [name] [flags]", short, long, run,
 		command.RequireSession,
 		command.LoadAppNameIfPresent,
 	)
 
-	cmd.Args = cobra.RangeArgs(1, 2)
+	cmd.Args = cobra.RangeArgs(0, 2)
 
 	flag.Add(cmd,
 		flag.App(),
@@ -56,6 +64,18 @@ attached to the current app (you can pass an app in with -a <appname>).`
 			Default:     false,
 			Description: "Just print the answers, not DNS record details",
 		},
+		flag.Bool{
+			Name:        "apps",
+			Description: "List every app in the organization along with its AAAA records",
+		},
+		flag.Bool{
+			Name:        "vms",
+			Description: "List the machines of the current app, via vms.<app>.internal",
+		},
+		flag.String{
+			Name:        "region",
+			Description: "List the addresses of the current app in the given region, via <region>.<app>.internal",
+		},
 	)
 
 	return cmd
@@ -63,17 +83,28 @@ attached to the current app (you can pass an app in with -a <appname>).`
 
 func run(ctx context.Context) error {
 	var (
-		client = client.FromContext(ctx).API()
-		io     = iostreams.FromContext(ctx)
+		client  = client.FromContext(ctx).API()
+		appName = appconfig.NameFromContext(ctx)
+		region  = flag.GetString(ctx, "region")
+		listVMs = flag.GetBool(ctx, "vms")
+		apps    = flag.GetBool(ctx, "apps")
 
 		err error
 	)
 
+	discovering := apps || listVMs || region != ""
+	switch {
+	case discovering && len(flag.Args(ctx)) > 0:
+		return errors.New("--apps, --vms and --region can't be combined with a name to look up")
+	case !discovering && len(flag.Args(ctx)) == 0:
+		return errors.New("requires a name to look up, or one of --apps, --vms or --region")
+	case (listVMs || region != "") && appName == "":
+		return errors.New("--vms and --region require an app; set one with -a <appname>")
+	}
+
 	orgSlug := flag.GetOrg(ctx)
 
 	if orgSlug == "" {
-		appName := appconfig.NameFromContext(ctx)
-
 		app, err := client.GetAppBasic(ctx, appName)
 		if err != nil {
 			return fmt.Errorf("get app: %w", err)
@@ -101,7 +132,14 @@ func run(ctx context.Context) error {
 		return err
 	}
 
-	msg := &dns.Msg{}
+	switch {
+	case apps:
+		return digApps(ctx, conn)
+	case listVMs:
+		return digDiscovery(ctx, conn, "TXT", fmt.Sprintf("vms.%s.internal", appName))
+	case region != "":
+		return digDiscovery(ctx, conn, "AAAA", fmt.Sprintf("%s.%s.internal", region, appName))
+	}
 
 	dtype := "AAAA"
 	name := flag.FirstArg(ctx)
@@ -110,52 +148,47 @@ func run(ctx context.Context) error {
 		dtype = strings.ToUpper(flag.FirstArg(ctx))
 		name = flag.Args(ctx)[1]
 	}
+
+	return dig(ctx, conn, r, ns, dtype, name)
+}
+
+func dig(ctx context.Context, conn net.Conn, r *net.Resolver, ns, dtype, name string) error {
+	var (
+		io         = iostreams.FromContext(ctx)
+		jsonOutput = config.FromContext(ctx).JSONOutput
+	)
+
 	// add the trailing dot
 	name = dns.Fqdn(name)
 
-	if strings.HasSuffix(name, ".internal.") {
-		msg.RecursionDesired = false
-	} else {
-		msg.RecursionDesired = true
-	}
-
-	// put this switch block in its own function to reduce the footprint of the main function
-	// e.g: func resolve(ctx context.Context, r *net.Resolver, msg *dns.Msg, name string, dtype string)
 	switch dtype {
 	case "A", "CNAME", "TXT", "AAAA":
-		msg.SetQuestion(name, dns.StringToType[dtype])
-
-		reply, err := roundTrip(conn, msg)
+		reply, err := query(conn, dtype, name)
 		if err != nil {
 			return err
 		}
 
-		if flag.GetBool(ctx, "short") {
+		switch {
+		case jsonOutput:
+			if reply.MsgHdr.Rcode != dns.RcodeSuccess {
+				return fmt.Errorf("lookup failed: %s", dns.RcodeToString[reply.MsgHdr.Rcode])
+			}
+
+			return render.JSON(io.Out, digResult{Name: name, Type: dtype, Answers: answers(reply, dtype)})
+		case flag.GetBool(ctx, "short"):
 			if reply.MsgHdr.Rcode != dns.RcodeSuccess {
 				return fmt.Errorf("lookup failed: %s", dns.RcodeToString[reply.MsgHdr.Rcode])
 			}
 
 			switch dtype {
 			case "AAAA":
-				for _, rr := range reply.Answer {
-					if aaaa, ok := rr.(*dns.AAAA); ok {
-						fmt.Fprintf(io.Out, "%s\n", aaaa.AAAA)
-					}
+				for _, a := range answers(reply, dtype) {
+					fmt.Fprintf(io.Out, "%s\n", a)
 				}
 			case "TXT":
-				buf := &bytes.Buffer{}
-
-				for _, rr := range reply.Answer {
-					if txt, ok := rr.(*dns.TXT); ok {
-						for _, s := range txt.Txt {
-							buf.WriteString(s)
-						}
-					}
-				}
-
-				fmt.Fprintf(io.Out, "%s\n", buf.String())
+				fmt.Fprintf(io.Out, "%s\n", strings.Join(answers(reply, dtype), ""))
 			}
-		} else {
+		default:
 			fmt.Fprintf(io.Out, "%+v\n", reply)
 		}
 
@@ -165,6 +198,10 @@ func run(ctx context.Context) error {
 			return fixNameError(err, ns)
 		}
 
+		if jsonOutput {
+			return render.JSON(io.Out, digResult{Name: name, Type: dtype, Answers: hosts})
+		}
+
 		for _, h := range hosts {
 			fmt.Fprintf(io.Out, "%s\n", h)
 		}
@@ -175,6 +212,10 @@ func run(ctx context.Context) error {
 			return fixNameError(err, ns)
 		}
 
+		if jsonOutput {
+			return render.JSON(io.Out, digResult{Name: name, Type: dtype, Answers: txts})
+		}
+
 		fmt.Fprintf(io.Out, "%s\n", strings.Join(txts, ""))
 
 	default:
@@ -184,6 +225,136 @@ func run(ctx context.Context) error {
 	return nil
 }
 
+type digResult struct {
+	Name    string   `json:"name"`
+	Type    string   `json:"type"`
+	Answers []string `json:"answers"`
+}
+
+// digDiscovery looks up one of the well-known .internal discovery names and
+// prints its answers one per line. TXT answers are comma separated lists,
+// which are split into individual entries.
+func digDiscovery(ctx context.Context, conn net.Conn, dtype, name string) error {
+	io := iostreams.FromContext(ctx)
+
+	result, err := lookup(conn, dtype, name)
+	if err != nil {
+		return err
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, result)
+	}
+
+	for _, a := range result.Answers {
+		fmt.Fprintf(io.Out, "%s\n", a)
+	}
+
+	return nil
+}
+
+// digApps lists every app of the organization via _apps.internal along with
+// the AAAA records of each of them.
+func digApps(ctx context.Context, conn net.Conn) error {
+	io := iostreams.FromContext(ctx)
+
+	apps, err := lookup(conn, "TXT", "_apps.internal")
+	if err != nil {
+		return err
+	}
+
+	var (
+		results []digResult
+		rows    [][]string
+	)
+	for _, app := range apps.Answers {
+		result, err := lookup(conn, "AAAA", fmt.Sprintf("%s.internal", app))
+		if err != nil {
+			return err
+		}
+
+		results = append(results, result)
+		rows = append(rows, []string{app, strings.Join(result.Answers, ", ")})
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, results)
+	}
+
+	return render.Table(io.Out, "", rows, "App", "Addresses")
+}
+
+// lookup queries name and returns its answers. TXT answers are split on
+// commas, which is how the discovery records list their entries.
+func lookup(conn net.Conn, dtype, name string) (digResult, error) {
+	name = dns.Fqdn(name)
+	result := digResult{Name: name, Type: dtype}
+
+	reply, err := query(conn, dtype, name)
+	if err != nil {
+		return result, err
+	}
+
+	switch reply.MsgHdr.Rcode {
+	case dns.RcodeSuccess:
+	case dns.RcodeNameError:
+		// nothing registered under that name
+		return result, nil
+	default:
+		return result, fmt.Errorf("lookup %s failed: %s", name, dns.RcodeToString[reply.MsgHdr.Rcode])
+	}
+
+	for _, a := range answers(reply, dtype) {
+		if dtype != "TXT" {
+			result.Answers = append(result.Answers, a)
+			continue
+		}
+
+		for _, entry := range strings.Split(a, ",") {
+			if entry = strings.TrimSpace(entry); entry != "" {
+				result.Answers = append(result.Answers, entry)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+func query(conn net.Conn, dtype, name string) (*dns.Msg, error) {
+	msg := &dns.Msg{}
+	msg.RecursionDesired = !strings.HasSuffix(name, ".internal.")
+	msg.SetQuestion(name, dns.StringToType[dtype])
+
+	return roundTrip(conn, msg)
+}
+
+// answers extracts the records of the given type from reply. The strings of
+// each TXT record are concatenated.
+func answers(reply *dns.Msg, dtype string) (ret []string) {
+	for _, rr := range reply.Answer {
+		switch rr := rr.(type) {
+		case *dns.AAAA:
+			if dtype == "AAAA" {
+				ret = append(ret, rr.AAAA.String())
+			}
+		case *dns.A:
+			if dtype == "A" {
+				ret = append(ret, rr.A.String())
+			}
+		case *dns.CNAME:
+			if dtype == "CNAME" {
+				ret = append(ret, rr.Target)
+			}
+		case *dns.TXT:
+			if dtype == "TXT" {
+				ret = append(ret, strings.Join(rr.Txt, ""))
+			}
+		}
+	}
+
+	return
+}
+
 // roundTrip a DNS request across a "TCP" socket; we'd just use miekg/dns's Client, but I don't think it promises to
 // work over our weird UDS TCP proxy.
 func roundTrip(conn net.Conn, m *dns.Msg) (*dns.Msg, error) {
@@ -0,0 +1,26 @@
+// Package dockerfile implements the dockerfile command chain.
+package dockerfile
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+)
+
+// New initializes and returns a new dockerfile Command.
+func New() (cmd *cobra.Command) {
+	const (
+		short = "Manage the Dockerfile of an app"
+		long  = `The DOCKERFILE commands generate and keep up to date the Dockerfile fly launch
+creates for the app's framework.
+`
+	)
+
+	cmd = command.New("dockerfile", short, long, nil)
+
+	cmd.AddCommand(
+		newGenerate(),
+	)
+
+	return
+}
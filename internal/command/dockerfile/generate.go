@@ -0,0 +1,262 @@
+package dockerfile
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/scanner"
+)
+
+// generatedFiles are the files framework Dockerfile generators write, which
+// are compared before and after a generator runs.
+var generatedFiles = []string{
+	"Dockerfile",
+	".dockerignore",
+	"bin/docker-entrypoint",
+}
+
+func newGenerate() (cmd *cobra.Command) {
+	const (
+		short = "Generate a Dockerfile for the app's framework"
+		long  = `Generate the Dockerfile fly launch would create for the app in the working
+directory, detecting its framework unless --framework is given.
+
+When a Dockerfile already exists, the regenerated one is shown as a diff against
+it and only written once confirmed, so updated best-practice Dockerfiles can be
+adopted as the generators evolve.
+`
+	)
+
+	cmd = command.New("generate", short, long, runGenerate)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.Yes(),
+		flag.String{
+			Name:        "framework",
+			Description: "The framework or runtime to generate a Dockerfile for, such as rails or nodejs, instead of detecting it",
+		},
+		flag.String{
+			Name:        "runtime-version",
+			Description: "The version of the language runtime to use, instead of the locally installed one",
+		},
+		flag.StringSlice{
+			Name:        "option",
+			Description: "An option for the framework's Dockerfile generator, such as jemalloc or node-version=18.16.0. Can be specified multiple times",
+		},
+	)
+
+	return
+}
+
+func runGenerate(ctx context.Context) error {
+	var (
+		io         = iostreams.FromContext(ctx)
+		workingDir = state.WorkingDirectory(ctx)
+		framework  = flag.GetString(ctx, "framework")
+	)
+
+	config := &scanner.ScannerConfig{
+		Mode:             scanner.ModeGenerate,
+		Family:           framework,
+		RuntimeVersion:   flag.GetString(ctx, "runtime-version"),
+		GeneratorOptions: flag.GetStringSlice(ctx, "option"),
+	}
+
+	srcInfo, err := scanner.Scan(workingDir, config)
+	switch {
+	case err != nil:
+		return err
+	case srcInfo == nil && framework != "":
+		return fmt.Errorf("%s not detected in %s", framework, workingDir)
+	case srcInfo == nil:
+		return errors.New("could not detect a runtime or framework from the source code")
+	}
+
+	fmt.Fprintf(io.Out, "Detected %s app\n", srcInfo.Family)
+
+	switch {
+	case srcInfo.Callback != nil:
+		return runGenerator(ctx, workingDir, srcInfo)
+	case len(srcInfo.Files) > 0:
+		return writeFiles(ctx, workingDir, srcInfo.Files)
+	default:
+		return fmt.Errorf("%s apps are built without a Dockerfile", srcInfo.Family)
+	}
+}
+
+// runGenerator runs the framework's own generator, which writes its files in
+// place, and restores the previous files if the changes aren't confirmed.
+func runGenerator(ctx context.Context, workingDir string, srcInfo *scanner.SourceInfo) error {
+	before, err := readFiles(workingDir, generatedFiles)
+	if err != nil {
+		return err
+	}
+
+	if err := srcInfo.Callback(srcInfo, map[string]bool{}); err != nil {
+		return err
+	}
+
+	after, err := readFiles(workingDir, generatedFiles)
+	if err != nil {
+		return err
+	}
+
+	var changed []string
+	for _, path := range generatedFiles {
+		if before[path] != nil && !bytes.Equal(before[path], after[path]) {
+			changed = append(changed, path)
+		}
+	}
+
+	io := iostreams.FromContext(ctx)
+	if len(changed) == 0 {
+		fmt.Fprintln(io.Out, "Dockerfile is up to date")
+
+		return nil
+	}
+
+	for _, path := range changed {
+		if err := printDiff(io.Out, io.ColorScheme(), path, before[path], after[path]); err != nil {
+			return err
+		}
+	}
+
+	if keep, err := confirm(ctx, "Keep the regenerated files?"); err != nil || keep {
+		return err
+	}
+
+	for _, path := range changed {
+		if err := os.WriteFile(filepath.Join(workingDir, path), before[path], 0o600); err != nil {
+			return fmt.Errorf("failed restoring %s: %w", path, err)
+		}
+	}
+	fmt.Fprintln(io.Out, "Restored the previous files")
+
+	return nil
+}
+
+// writeFiles writes the files generated from templates, showing a diff for
+// each existing file which differs and asking before overwriting it.
+func writeFiles(ctx context.Context, workingDir string, files []scanner.SourceFile) error {
+	io := iostreams.FromContext(ctx)
+
+	for _, f := range files {
+		path := filepath.Join(workingDir, f.Path)
+
+		current, err := os.ReadFile(path)
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return err
+		case bytes.Equal(current, f.Contents):
+			fmt.Fprintf(io.Out, "%s is up to date\n", f.Path)
+
+			continue
+		default:
+			if err := printDiff(io.Out, io.ColorScheme(), f.Path, current, f.Contents); err != nil {
+				return err
+			}
+			if overwrite, err := confirm(ctx, fmt.Sprintf("Overwrite %s?", f.Path)); err != nil {
+				return err
+			} else if !overwrite {
+				continue
+			}
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return err
+		}
+
+		perms := 0o600
+		if strings.Contains(string(f.Contents), "#!") {
+			perms = 0o700
+		}
+
+		if err := os.WriteFile(path, f.Contents, fs.FileMode(perms)); err != nil {
+			return err
+		}
+		fmt.Fprintf(io.Out, "Wrote %s\n", f.Path)
+	}
+
+	return nil
+}
+
+func confirm(ctx context.Context, message string) (bool, error) {
+	if flag.GetYes(ctx) {
+		return true, nil
+	}
+
+	switch confirmed, err := prompt.Confirm(ctx, message); {
+	case err == nil:
+		return confirmed, nil
+	case prompt.IsNonInteractive(err):
+		return false, prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+	default:
+		return false, err
+	}
+}
+
+// readFiles returns the contents of the paths which exist in dir.
+func readFiles(dir string, paths []string) (map[string][]byte, error) {
+	contents := make(map[string][]byte, len(paths))
+
+	for _, path := range paths {
+		data, err := os.ReadFile(filepath.Join(dir, path))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+		case err != nil:
+			return nil, err
+		default:
+			contents[path] = data
+		}
+	}
+
+	return contents, nil
+}
+
+func printDiff(w io.Writer, cs *iostreams.ColorScheme, path string, a, b []byte) error {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: "a/" + path,
+		ToFile:   "b/" + path,
+		Context:  3,
+	})
+	if err != nil {
+		return fmt.Errorf("failed diffing %s: %w", path, err)
+	}
+
+	for _, line := range strings.Split(strings.TrimSuffix(diff, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			line = cs.Bold(line)
+		case strings.HasPrefix(line, "+"):
+			line = cs.Green(line)
+		case strings.HasPrefix(line, "-"):
+			line = cs.Red(line)
+		case strings.HasPrefix(line, "@@"):
+			line = cs.Cyan(line)
+		}
+		fmt.Fprintln(w, line)
+	}
+	fmt.Fprintln(w)
+
+	return nil
+}
@@ -99,7 +99,7 @@ func (ac *AppChecker) checkAll() map[string]string {
 	ac.checkDnsRecords(ipAddresses)
 
 	relPath, err := filepath.Rel(ac.workDir, ac.appConfig.ConfigFilePath())
-	if err == nil && relPath == appconfig.DefaultConfigFileName {
+	if err == nil && appconfig.IsConfigFileName(relPath) {
 		ac.lprint(nil, "\nBuild checks for %s:\n", ac.app.Name)
 		contextSize := ac.checkDockerContext()
 		// only show longer .dockerignore message when context size > 50MB
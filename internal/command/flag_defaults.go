@@ -0,0 +1,122 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/logger"
+)
+
+// applyFlagDefaults is a Preparer which sets the flags the [cli] section of the
+// app config file pins for the command, unless they were passed on the
+// command line. Teams use it to share flags, such as deploying with
+// --remote-only, without wrapping flyctl.
+func applyFlagDefaults(ctx context.Context) (context.Context, error) {
+	cmd := FromContext(ctx)
+	key := flagDefaultsKey(cmd)
+
+	for _, path := range appConfigFilePaths(ctx) {
+		// errors are left to LoadAppConfigIfPresent, for the commands which
+		// need the app config
+		cfg, err := loadAppConfig(ctx, path)
+		if err != nil {
+			continue
+		}
+
+		defaults := cfg.CLI[key]
+		if len(defaults) == 0 {
+			return ctx, nil
+		}
+		// only the flags of the command itself, and not the global ones
+		if err := setFlagDefaults(key, cmd.LocalNonPersistentFlags(), defaults); err != nil {
+			return nil, fmt.Errorf("invalid [cli.%q] of %s: %w", key, path, err)
+		}
+		logger.FromContext(ctx).Debugf("flag defaults of %s loaded from %s", key, path)
+
+		return ctx, nil
+	}
+
+	return ctx, nil
+}
+
+// flagDefaultsKey is the key of the flag defaults of cmd in the [cli] section,
+// its path without the root command, such as "machine run".
+func flagDefaultsKey(cmd *cobra.Command) string {
+	return strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+}
+
+// buildFlagDefaults are the flags of how images are built which the [cli]
+// section can set for the commands deploying them.
+var buildFlagDefaults = []string{
+	"remote-only",
+	flag.LocalOnlyName,
+	flag.DetachName,
+	"no-cache",
+}
+
+// allowedFlagDefaults are the flags the [cli] section can set, by command.
+// They're only those which change how a command runs, but not what it
+// deploys, where it pushes or what it trusts, which an app config from a
+// cloned repository mustn't be able to pick.
+var allowedFlagDefaults = map[string][]string{
+	"deploy":         append([]string{"wait-timeout", "lease-timeout", "canary-wait", "max-unavailable"}, buildFlagDefaults...),
+	"compose deploy": buildFlagDefaults,
+	"stack deploy":   buildFlagDefaults,
+	"machine run":    {flag.DetachName},
+}
+
+// setFlagDefaults sets the flags of defaults of the command of key which
+// weren't passed. Arrays are only accepted by slice flags, each of their
+// elements is set in turn.
+func setFlagDefaults(key string, flags *pflag.FlagSet, defaults map[string]any) error {
+	names := make([]string, 0, len(defaults))
+	for name := range defaults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		f := flags.Lookup(name)
+		if f == nil {
+			return fmt.Errorf("unknown flag --%s", name)
+		}
+		if !slices.Contains(allowedFlagDefaults[key], name) {
+			return fmt.Errorf("--%s can't be set from the app config, pass it on the command line", name)
+		}
+		if f.Changed {
+			continue
+		}
+
+		values, isArray := defaults[name].([]any)
+		if !isArray {
+			values = []any{defaults[name]}
+		} else if !strings.HasSuffix(f.Value.Type(), "Slice") && !strings.HasSuffix(f.Value.Type(), "Array") {
+			return fmt.Errorf("flag --%s takes a single value, not an array", name)
+		}
+
+		for _, v := range values {
+			if err := flags.Set(name, formatFlagValue(v)); err != nil {
+				return fmt.Errorf("invalid value for --%s: %w", name, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// formatFlagValue formats a TOML value the way it's passed on the command
+// line. Numbers are decoded as floats, which must not be printed as exponents.
+func formatFlagValue(v any) string {
+	if f, ok := v.(float64); ok {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
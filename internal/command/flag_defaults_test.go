@@ -0,0 +1,87 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFlagDefaultsKey(t *testing.T) {
+	root := &cobra.Command{Use: "flyctl"}
+	machine := &cobra.Command{Use: "machine"}
+	run := &cobra.Command{Use: "run <image>"}
+	root.AddCommand(machine)
+	machine.AddCommand(run)
+
+	assert.Equal(t, "machine", flagDefaultsKey(machine))
+	assert.Equal(t, "machine run", flagDefaultsKey(run))
+}
+
+func TestSetFlagDefaults(t *testing.T) {
+	newFlags := func() *pflag.FlagSet {
+		flags := pflag.NewFlagSet("deploy", pflag.ContinueOnError)
+		flags.Bool("remote-only", false, "")
+		flags.Int("wait-timeout", 120, "")
+		flags.Int("lease-timeout", 13, "")
+		flags.String("strategy", "", "")
+		return flags
+	}
+
+	flags := newFlags()
+	require.NoError(t, flags.Parse([]string{"--lease-timeout", "30"}))
+	require.NoError(t, setFlagDefaults("deploy", flags, map[string]any{
+		"remote-only":   true,
+		"wait-timeout":  float64(1000000),
+		"lease-timeout": float64(60),
+	}))
+
+	remoteOnly, _ := flags.GetBool("remote-only")
+	assert.True(t, remoteOnly)
+	waitTimeout, _ := flags.GetInt("wait-timeout")
+	assert.Equal(t, 1000000, waitTimeout)
+	// flags passed on the command line win
+	leaseTimeout, _ := flags.GetInt("lease-timeout")
+	assert.Equal(t, 30, leaseTimeout)
+
+	assert.ErrorContains(t, setFlagDefaults("deploy", newFlags(), map[string]any{"remote": true}), "unknown flag --remote")
+	assert.ErrorContains(t, setFlagDefaults("deploy", newFlags(), map[string]any{"wait-timeout": []any{600}}), "takes a single value")
+	assert.ErrorContains(t, setFlagDefaults("deploy", newFlags(), map[string]any{"wait-timeout": "soon"}), "invalid value for --wait-timeout")
+}
+
+// Test only the flags listed for a command are set from the app config.
+func TestSetFlagDefaults_NotAllowed(t *testing.T) {
+	for _, name := range []string{"push-to", "build-cache-ref", "smoke-url", "image", "strategy", "access-token", "ca-bundle", "yes", "auto-confirm", "progress-webhook"} {
+		flags := pflag.NewFlagSet("deploy", pflag.ContinueOnError)
+		flags.String(name, "", "")
+
+		err := setFlagDefaults("deploy", flags, map[string]any{name: "true"})
+		assert.ErrorContains(t, err, "can't be set from the app config", name)
+		assert.False(t, flags.Lookup(name).Changed, name)
+	}
+
+	// flags allowed for a command aren't for the others
+	flags := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	flags.Int("wait-timeout", 0, "")
+	assert.ErrorContains(t, setFlagDefaults("machine run", flags, map[string]any{"wait-timeout": 600}), "can't be set from the app config")
+}
+
+func TestSetFlagDefaults_LocalFlagsOnly(t *testing.T) {
+	root := &cobra.Command{Use: "flyctl"}
+	root.PersistentFlags().String("verbose-level", "", "")
+	deploy := &cobra.Command{Use: "deploy", Run: func(*cobra.Command, []string) {}}
+	deploy.Flags().Bool("remote-only", false, "")
+	root.AddCommand(deploy)
+
+	root.SetArgs([]string{"deploy"})
+	require.NoError(t, root.Execute())
+
+	// global flags are inherited but not declared by the command
+	assert.ErrorContains(t, setFlagDefaults("deploy", deploy.LocalNonPersistentFlags(), map[string]any{"verbose-level": "debug"}), "unknown flag --verbose-level")
+	require.NoError(t, setFlagDefaults("deploy", deploy.LocalNonPersistentFlags(), map[string]any{"remote-only": true}))
+
+	remoteOnly, _ := deploy.Flags().GetBool("remote-only")
+	assert.True(t, remoteOnly)
+}
@@ -0,0 +1,60 @@
+package launch
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/superfly/flyctl/internal/compose"
+	"github.com/superfly/flyctl/scanner"
+)
+
+// composeSourceInfo is what launching the app of plan takes besides its
+// config: the secrets and volume to create and whether to offer databases.
+func composeSourceInfo(plan *compose.LaunchPlan) *scanner.SourceInfo {
+	srcInfo := &scanner.SourceInfo{
+		Family:       "Docker Compose",
+		SkipDatabase: len(plan.Postgres) == 0 && len(plan.Redis) == 0,
+	}
+
+	names := make([]string, 0, len(plan.Secrets))
+	for name := range plan.Secrets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		srcInfo.Secrets = append(srcInfo.Secrets, scanner.Secret{
+			Key:   name,
+			Value: plan.Secrets[name],
+			Help:  "Set in the environment of the compose file",
+		})
+	}
+
+	if mounts := plan.Config.Mounts; mounts != nil {
+		srcInfo.Volumes = []scanner.Volume{{Source: mounts.Source, Destination: mounts.Destination}}
+	}
+
+	return srcInfo
+}
+
+// renderComposePlan describes how the services of a compose file were
+// translated.
+func renderComposePlan(w io.Writer, plan *compose.LaunchPlan) {
+	groups := make([]string, 0, len(plan.Config.Processes))
+	for group := range plan.Config.Processes {
+		groups = append(groups, group)
+	}
+	sort.Strings(groups)
+
+	fmt.Fprintf(w, "Running services as process groups: %s\n", strings.Join(groups, ", "))
+	if len(plan.Postgres) > 0 {
+		fmt.Fprintf(w, "Replacing with Fly Postgres: %s\n", strings.Join(plan.Postgres, ", "))
+	}
+	if len(plan.Redis) > 0 {
+		fmt.Fprintf(w, "Replacing with Upstash Redis: %s\n", strings.Join(plan.Redis, ", "))
+	}
+	for _, warning := range plan.Warnings {
+		fmt.Fprintf(w, "WARNING %s\n", warning)
+	}
+}
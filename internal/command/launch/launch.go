@@ -2,6 +2,7 @@ package launch
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"regexp"
@@ -16,10 +17,12 @@ import (
 	"github.com/superfly/flyctl/internal/build/imgsrc"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/command/deploy"
+	"github.com/superfly/flyctl/internal/compose"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/iostreams"
 	"github.com/superfly/flyctl/scanner"
+	"github.com/superfly/flyctl/terminal"
 	"github.com/superfly/graphql"
 )
 
@@ -29,7 +32,11 @@ func New() (cmd *cobra.Command) {
 		short = long
 	)
 
-	cmd = command.New("launch", short, long, run, command.RequireSession, command.LoadAppConfigIfPresent)
+	cmd = command.New("launch", short, long, run,
+		command.RequireSession,
+		command.LoadAppConfigIfPresent,
+		command.RequireCompatibleVersion("launch"),
+	)
 	cmd.Args = cobra.NoArgs
 
 	flag.Add(cmd,
@@ -40,6 +47,7 @@ func New() (cmd *cobra.Command) {
 		deploy.CommonFlags,
 
 		flag.Org(),
+		flag.AnswersFile(),
 		flag.NoDeploy(),
 		flag.Bool{
 			Name:        "generate-name",
@@ -69,8 +77,14 @@ func New() (cmd *cobra.Command) {
 			Description: "Set internal_port for all services in the generated fly.toml",
 			Default:     -1,
 		},
+		flag.String{
+			Name:        "from-compose",
+			Description: "Path to a Docker Compose file, or a directory containing one, whose services become process groups of the app. Database services are offered as Fly Postgres and Upstash Redis",
+		},
 	)
 
+	cmd.AddCommand(newPlan())
+
 	return
 }
 
@@ -96,9 +110,36 @@ func run(ctx context.Context) (err error) {
 		workingDir = absDir
 	}
 
-	configFilePath := filepath.Join(workingDir, appconfig.DefaultConfigFileName)
+	configFilePath := appconfig.ConfigFilePathIn(workingDir)
+
+	// an overlay is merged on top of the existing fly.toml, which is then left
+	// as it is rather than written with the overlay baked in
+	envConfig := flag.GetString(ctx, "env-config")
+	if envConfig != "" && existingConfig == nil {
+		return fmt.Errorf("--env-config needs an existing %s to merge the overlay of %s on top of", appconfig.DefaultConfigFileName, envConfig)
+	}
+	var (
+		overlayPath    string
+		overlayAppName string
+	)
+	if envConfig != "" {
+		overlayPath = appconfig.OverlayPath(existingConfig.ConfigFilePath(), envConfig)
+		overlayAppName = existingConfig.AppName
+	}
+
+	// launching an app which was launched before only adds what it's missing
+	var existing *existingResources
+	if existingConfig != nil && existingConfig.AppName != "" && !generateName && (name == "" || name == existingConfig.AppName) {
+		if existing, err = detectExisting(ctx, existingConfig.AppName); err != nil {
+			return err
+		}
+	}
 
-	if existingConfig != nil {
+	if existing.relaunch() {
+		fmt.Fprintf(io.Out, "App %s was launched before, only adding what it's missing\n", existingConfig.AppName)
+		copyConfig = true
+		appConfig = existingConfig
+	} else if existingConfig != nil {
 		if existingConfig.AppName != "" {
 			fmt.Fprintln(io.Out, "An existing fly.toml file was found for app", existingConfig.AppName)
 		} else {
@@ -133,7 +174,16 @@ func run(ctx context.Context) (err error) {
 		config.Mode = "launch"
 	}
 
-	if img := flag.GetString(ctx, "image"); img != "" {
+	var composeFile *compose.File
+	if path := flag.GetString(ctx, "from-compose"); path != "" {
+		if existing.relaunch() {
+			return fmt.Errorf("app %s was launched before, edit its fly.toml instead of launching it from a compose file", existingConfig.AppName)
+		}
+		if composeFile, err = compose.Load(path); err != nil {
+			return err
+		}
+		fmt.Fprintln(io.Out, "Using compose file", composeFile.Path())
+	} else if img := flag.GetString(ctx, "image"); img != "" {
 		fmt.Fprintln(io.Out, "Using image", img)
 		appConfig.Build = &appconfig.Build{
 			Image: img,
@@ -208,7 +258,7 @@ func run(ctx context.Context) (err error) {
 		appConfig.AppName = name
 	}
 
-	if !generateName && name == "" {
+	if !generateName && name == "" && !existing.relaunch() {
 		inputName, err := promptForAppName(ctx, appConfig)
 		if err != nil {
 			return err
@@ -217,7 +267,10 @@ func run(ctx context.Context) (err error) {
 	}
 
 	var org *api.Organization
-	if appConfig.AppName != "" {
+	if existing.relaunch() {
+		launchIntoExistingApp = true
+		org = organizationOf(existing.app)
+	} else if appConfig.AppName != "" {
 		exists, app, err := appExists(ctx, appConfig)
 		if err != nil {
 			return err
@@ -233,13 +286,7 @@ func run(ctx context.Context) (err error) {
 				return nil
 			}
 
-			org = &api.Organization{
-				ID:       app.Organization.ID,
-				Name:     app.Organization.Name,
-				Slug:     app.Organization.Slug,
-				PaidPlan: app.Organization.PaidPlan,
-			}
-
+			org = organizationOf(app)
 		}
 
 	}
@@ -253,23 +300,56 @@ func run(ctx context.Context) (err error) {
 		}
 	}
 
+	var composePlan *compose.LaunchPlan
+	if composeFile != nil {
+		if composePlan, err = composeFile.LaunchPlan(appConfig.AppName, workingDir); err != nil {
+			return err
+		}
+		renderComposePlan(io.Out, composePlan)
+		appConfig = composePlan.Config
+		srcInfo = composeSourceInfo(composePlan)
+		copyConfig = true
+	}
+
 	// If we potentially are deploying, launch a remote builder to prepare for deployment.
 	if !flag.GetBool(ctx, "no-deploy") {
 		go imgsrc.EagerlyEnsureRemoteBuilder(ctx, client, org.Slug)
 	}
 
-	region, err := prompt.Region(ctx, !org.PaidPlan, prompt.RegionParams{
-		Message: "Choose a region for deployment:",
-	})
-	if err != nil {
-		return err
+	var region *api.Region
+	if existing.relaunch() && appConfig.PrimaryRegion != "" {
+		region = &api.Region{Code: appConfig.PrimaryRegion}
+	} else {
+		region, err = prompt.Region(ctx, !org.PaidPlan, prompt.RegionParams{
+			Message: "Choose a region for deployment:",
+		})
+		if err != nil {
+			return err
+		}
+		appConfig.PrimaryRegion = region.Code
 	}
-	appConfig.PrimaryRegion = region.Code
 
-	shouldUseMachines, err := shouldAppUseMachinesPlatform(ctx, org.Slug)
-	if err != nil {
+	var shouldUseMachines bool
+	if existing.relaunch() {
+		shouldUseMachines = existing.app.PlatformVersion == "machines"
+	} else if shouldUseMachines, err = shouldAppUseMachinesPlatform(ctx, org.Slug); err != nil {
 		return err
 	}
+	if composeFile != nil && !shouldUseMachines {
+		return errors.New("--from-compose requires the machines platform, as services run as process groups")
+	}
+
+	if existing.relaunch() {
+		if err := renderPlan(io.Out, buildPlan(existing, srcInfo, appConfig.AppName, workingDir)); err != nil {
+			return err
+		}
+		if io.IsInteractive() && !flag.GetBool(ctx, "now") {
+			confirm, err := prompt.Confirm(ctx, "Apply this plan?")
+			if err != nil || !confirm {
+				return err
+			}
+		}
+	}
 
 	if shouldUseMachines && copyConfig {
 		// Check imported fly.toml is a valid V2 config before creating the app
@@ -304,6 +384,17 @@ func run(ctx context.Context) (err error) {
 		} else {
 			appConfig.AppName = createdApp.Name
 		}
+		// the addresses of process groups include the app's generated name
+		if composePlan != nil && composePlan.Config.AppName != createdApp.Name {
+			if composePlan, err = composeFile.LaunchPlan(createdApp.Name, workingDir); err != nil {
+				return err
+			}
+			composePlan.Config.PrimaryRegion = appConfig.PrimaryRegion
+			if err := composePlan.Config.SetMachinesPlatform(); err != nil {
+				return err
+			}
+			appConfig = composePlan.Config
+		}
 		fmt.Fprintf(io.Out, "Created app '%s' in organization '%s'\n", appConfig.AppName, org.Slug)
 	}
 
@@ -311,19 +402,19 @@ func run(ctx context.Context) (err error) {
 	fmt.Fprintf(io.Out, "Hostname: %s.fly.dev\n", appConfig.AppName)
 
 	// If files are requested by the launch scanner, create them.
-	if err := createSourceInfoFiles(ctx, srcInfo, workingDir); err != nil {
+	if err := createSourceInfoFiles(ctx, srcInfo, workingDir, existing); err != nil {
 		return err
 	}
 	// If secrets are requested by the launch scanner, ask the user to input them
-	if err := createSecrets(ctx, srcInfo, appConfig.AppName); err != nil {
+	if err := createSecrets(ctx, srcInfo, appConfig.AppName, existing); err != nil {
 		return err
 	}
 	// If volumes are requested by the launch scanner, create them
-	if err := createVolumes(ctx, srcInfo, appConfig.AppName, region.Code); err != nil {
+	if err := createVolumes(ctx, srcInfo, appConfig.AppName, region.Code, existing); err != nil {
 		return err
 	}
 	// If database are requested by the launch scanner, create them
-	options, err := createDatabases(ctx, srcInfo, appConfig.AppName, region, org)
+	options, err := createDatabases(ctx, srcInfo, appConfig.AppName, region, org, existing)
 	if err != nil {
 		return err
 	}
@@ -354,7 +445,12 @@ func run(ctx context.Context) (err error) {
 	}
 
 	// Finally write application configuration to fly.toml
-	if err := appConfig.WriteToDisk(ctx, configFilePath); err != nil {
+	if envConfig != "" {
+		fmt.Fprintf(io.Out, "Leaving %s as it is, the configuration launched has %s merged on top of it\n", configFilePath, overlayPath)
+		if appConfig.AppName != overlayAppName {
+			terminal.Warnf("Set app = %q in %s to deploy to %s with --env-config %s\n", appConfig.AppName, overlayPath, appConfig.AppName, envConfig)
+		}
+	} else if err := appConfig.WriteToDisk(ctx, configFilePath); err != nil {
 		return err
 	}
 
@@ -456,6 +552,15 @@ func appExists(ctx context.Context, cfg *appconfig.Config) (bool, *api.AppBasic,
 	return true, app, nil
 }
 
+func organizationOf(app *api.AppBasic) *api.Organization {
+	return &api.Organization{
+		ID:       app.Organization.ID,
+		Name:     app.Organization.Name,
+		Slug:     app.Organization.Slug,
+		PaidPlan: app.Organization.PaidPlan,
+	}
+}
+
 func promptForAppName(ctx context.Context, cfg *appconfig.Config) (name string, err error) {
 	if cfg.AppName == "" {
 		return prompt.SelectAppName(ctx)
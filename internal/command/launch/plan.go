@@ -0,0 +1,230 @@
+package launch
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/scanner"
+)
+
+// The actions of a launch plan
+const (
+	planExists = "exists"
+	planCreate = "create"
+	planUpdate = "update"
+	planAsk    = "ask"
+)
+
+// planStep is what launch does about one resource.
+type planStep struct {
+	Resource string `json:"resource"`
+	Name     string `json:"name"`
+	Action   string `json:"action"`
+}
+
+// existingResources is what an earlier launch of an app already set up, so
+// that launching it again only adds what's missing.
+type existingResources struct {
+	app     *api.AppBasic
+	secrets map[string]bool
+	volumes map[string]bool
+}
+
+// detectExisting looks up the app and the secrets and volumes it has. The app
+// is nil when it doesn't exist yet.
+func detectExisting(ctx context.Context, appName string) (*existingResources, error) {
+	existing := &existingResources{secrets: map[string]bool{}, volumes: map[string]bool{}}
+	if appName == "" {
+		return existing, nil
+	}
+
+	exists, app, err := appExists(ctx, &appconfig.Config{AppName: appName})
+	if err != nil || !exists {
+		return existing, err
+	}
+	existing.app = app
+
+	apiClient := client.FromContext(ctx).API()
+	secrets, err := apiClient.GetAppSecrets(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing secrets of %s: %w", appName, err)
+	}
+	for _, s := range secrets {
+		existing.secrets[s.Name] = true
+	}
+
+	volumes, err := apiClient.GetVolumes(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing volumes of %s: %w", appName, err)
+	}
+	for _, v := range volumes {
+		existing.volumes[v.Name] = true
+	}
+
+	return existing, nil
+}
+
+// relaunch reports whether the app was launched before.
+func (e *existingResources) relaunch() bool {
+	return e != nil && e.app != nil
+}
+
+func (e *existingResources) hasSecret(name string) bool {
+	return e != nil && e.secrets[name]
+}
+
+func (e *existingResources) hasVolume(name string) bool {
+	return e != nil && e.volumes[name]
+}
+
+// hasPostgres and hasRedis tell attached databases by the secrets attaching
+// them sets.
+func (e *existingResources) hasPostgres() bool {
+	return e.hasSecret("DATABASE_URL")
+}
+
+func (e *existingResources) hasRedis() bool {
+	return e.hasSecret("REDIS_URL")
+}
+
+// buildPlan lists what launching the app in workingDir does given what
+// exists, without changing anything.
+func buildPlan(existing *existingResources, srcInfo *scanner.SourceInfo, appName, workingDir string) []planStep {
+	var steps []planStep
+	add := func(resource, name string, exists bool, otherwise string) {
+		action := otherwise
+		if exists {
+			action = planExists
+		}
+		steps = append(steps, planStep{Resource: resource, Name: name, Action: action})
+	}
+
+	appLabel := appName
+	if appLabel == "" {
+		appLabel = "(new app)"
+	}
+	add("App", appLabel, existing.relaunch(), planCreate)
+
+	configPath := appconfig.ConfigFilePathIn(workingDir)
+	if helpers.FileExists(configPath) {
+		steps = append(steps, planStep{Resource: "Config", Name: filepath.Base(configPath), Action: planUpdate})
+	} else {
+		add("Config", filepath.Base(configPath), false, planCreate)
+	}
+
+	if srcInfo == nil {
+		return steps
+	}
+
+	for _, f := range srcInfo.Files {
+		add("File", f.Path, helpers.FileExists(filepath.Join(workingDir, f.Path)), planCreate)
+	}
+
+	secrets := make([]string, 0, len(srcInfo.Secrets))
+	for _, s := range srcInfo.Secrets {
+		secrets = append(secrets, s.Key)
+	}
+	sort.Strings(secrets)
+	for _, key := range secrets {
+		add("Secret", key, existing.hasSecret(key), planCreate)
+	}
+
+	for _, v := range srcInfo.Volumes {
+		add("Volume", v.Source, existing.hasVolume(v.Source), planCreate)
+	}
+
+	if !srcInfo.SkipDatabase {
+		dbPrefix := appName
+		if dbPrefix == "" {
+			dbPrefix = "<app>"
+		}
+		add("Postgres", dbPrefix+"-db", existing.hasPostgres(), planAsk)
+		add("Redis", dbPrefix+"-redis", existing.hasRedis(), planAsk)
+	}
+
+	return steps
+}
+
+func renderPlan(w io.Writer, steps []planStep) error {
+	rows := make([][]string, 0, len(steps))
+	for _, s := range steps {
+		rows = append(rows, []string{s.Resource, s.Name, s.Action})
+	}
+
+	return render.Table(w, "Launch plan", rows, "Resource", "Name", "Action")
+}
+
+func newPlan() (cmd *cobra.Command) {
+	const (
+		long = `Show what launching the app in the source directory would add or change,
+given what already exists: the app, its fly.toml, the files generated for its
+framework, and its secrets, volumes and databases. Nothing is changed.
+
+Running launch again on a launched app applies only the missing pieces.
+`
+		short = "Show what launch would add or change"
+	)
+
+	cmd = command.New("plan", short, long, runPlan, command.RequireSession)
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.String{
+			Name:        "path",
+			Description: `Path to the app source root, where fly.toml file is saved`,
+			Default:     ".",
+		},
+		flag.String{
+			Name:        "name",
+			Description: `Name of the app, when it differs from the one of fly.toml`,
+		},
+	)
+
+	return
+}
+
+func runPlan(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		appName = strings.TrimSpace(flag.GetString(ctx, "name"))
+	)
+
+	workingDir, err := filepath.Abs(flag.GetString(ctx, "path"))
+	if err != nil {
+		return err
+	}
+
+	configPath := appconfig.ConfigFilePathIn(workingDir)
+	if appName == "" && helpers.FileExists(configPath) {
+		cfg, err := appconfig.LoadConfig(configPath)
+		if err != nil {
+			return err
+		}
+		appName = cfg.AppName
+	}
+
+	srcInfo, err := scanner.Scan(workingDir, &scanner.ScannerConfig{Mode: "launch"})
+	if err != nil {
+		return err
+	}
+
+	existing, err := detectExisting(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	return renderPlan(io.Out, buildPlan(existing, srcInfo, appName, workingDir))
+}
@@ -0,0 +1,48 @@
+package launch
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/scanner"
+)
+
+func TestBuildPlan(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fly.toml"), []byte(`app = "my-app"`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "Dockerfile"), []byte("FROM scratch"), 0o600))
+
+	srcInfo := &scanner.SourceInfo{
+		Files:   []scanner.SourceFile{{Path: "Dockerfile"}, {Path: ".dockerignore"}},
+		Secrets: []scanner.Secret{{Key: "SECRET_KEY_BASE"}, {Key: "API_KEY"}},
+		Volumes: []scanner.Volume{{Source: "data", Destination: "/data"}},
+	}
+	existing := &existingResources{
+		app:     &api.AppBasic{Name: "my-app"},
+		secrets: map[string]bool{"SECRET_KEY_BASE": true, "DATABASE_URL": true},
+		volumes: map[string]bool{},
+	}
+
+	assert.Equal(t, []planStep{
+		{Resource: "App", Name: "my-app", Action: planExists},
+		{Resource: "Config", Name: "fly.toml", Action: planUpdate},
+		{Resource: "File", Name: "Dockerfile", Action: planExists},
+		{Resource: "File", Name: ".dockerignore", Action: planCreate},
+		{Resource: "Secret", Name: "API_KEY", Action: planCreate},
+		{Resource: "Secret", Name: "SECRET_KEY_BASE", Action: planExists},
+		{Resource: "Volume", Name: "data", Action: planCreate},
+		{Resource: "Postgres", Name: "my-app-db", Action: planExists},
+		{Resource: "Redis", Name: "my-app-redis", Action: planAsk},
+	}, buildPlan(existing, srcInfo, "my-app", dir))
+
+	// nothing exists before a first launch
+	assert.Equal(t, []planStep{
+		{Resource: "App", Name: "(new app)", Action: planCreate},
+		{Resource: "Config", Name: "fly.toml", Action: planCreate},
+	}, buildPlan(nil, nil, "", t.TempDir()))
+}
@@ -23,7 +23,7 @@ import (
 	"github.com/superfly/flyctl/scanner"
 )
 
-func createSourceInfoFiles(ctx context.Context, srcInfo *scanner.SourceInfo, workingDir string) error {
+func createSourceInfoFiles(ctx context.Context, srcInfo *scanner.SourceInfo, workingDir string, existing *existingResources) error {
 	if srcInfo == nil {
 		return nil
 	}
@@ -33,6 +33,10 @@ func createSourceInfoFiles(ctx context.Context, srcInfo *scanner.SourceInfo, wor
 	for _, f := range srcInfo.Files {
 		path := filepath.Join(workingDir, f.Path)
 		if helpers.FileExists(path) {
+			if existing.relaunch() {
+				fmt.Fprintf(io.Out, "Keeping the existing %s\n", path)
+				continue
+			}
 			if flag.GetBool(ctx, "now") {
 				fmt.Fprintf(io.Out, "You specified --now, so not overwriting %s\n", path)
 				continue
@@ -60,7 +64,7 @@ func createSourceInfoFiles(ctx context.Context, srcInfo *scanner.SourceInfo, wor
 }
 
 // If secrets are requested by the launch scanner, ask the user to input them
-func createSecrets(ctx context.Context, srcInfo *scanner.SourceInfo, appName string) error {
+func createSecrets(ctx context.Context, srcInfo *scanner.SourceInfo, appName string, existing *existingResources) error {
 	if srcInfo == nil || len(srcInfo.Secrets) == 0 {
 		return nil
 	}
@@ -70,6 +74,10 @@ func createSecrets(ctx context.Context, srcInfo *scanner.SourceInfo, appName str
 	secrets := map[string]string{}
 
 	for _, secret := range srcInfo.Secrets {
+		if existing.hasSecret(secret.Key) {
+			continue
+		}
+
 		val := ""
 		// If a secret should be a random default, just generate it without displaying
 		// Otherwise, prompt to type it in
@@ -80,9 +88,13 @@ func createSecrets(ctx context.Context, srcInfo *scanner.SourceInfo, appName str
 		} else if secret.Value != "" {
 			val = secret.Value
 		} else {
-			prompt := fmt.Sprintf("Set secret %s:", secret.Key)
-			surveyInput := &survey.Input{Message: prompt, Help: secret.Help}
-			survey.AskOne(surveyInput, &val)
+			msg := fmt.Sprintf("Set secret %s:", secret.Key)
+			if answer, ok := prompt.AnswerString(ctx, msg); ok {
+				val = answer
+			} else {
+				surveyInput := &survey.Input{Message: msg, Help: secret.Help}
+				survey.AskOne(surveyInput, &val)
+			}
 		}
 
 		if val != "" {
@@ -101,7 +113,7 @@ func createSecrets(ctx context.Context, srcInfo *scanner.SourceInfo, appName str
 	return nil
 }
 
-func createVolumes(ctx context.Context, srcInfo *scanner.SourceInfo, appName string, regionCode string) error {
+func createVolumes(ctx context.Context, srcInfo *scanner.SourceInfo, appName string, regionCode string, existing *existingResources) error {
 	if srcInfo == nil || len(srcInfo.Volumes) == 0 {
 		return nil
 	}
@@ -109,6 +121,10 @@ func createVolumes(ctx context.Context, srcInfo *scanner.SourceInfo, appName str
 	client := client.FromContext(ctx).API()
 
 	for _, vol := range srcInfo.Volumes {
+		if existing.hasVolume(vol.Source) {
+			continue
+		}
+
 		appID, err := client.GetAppID(ctx, appName)
 		if err != nil {
 			return err
@@ -130,7 +146,7 @@ func createVolumes(ctx context.Context, srcInfo *scanner.SourceInfo, appName str
 	return nil
 }
 
-func createDatabases(ctx context.Context, srcInfo *scanner.SourceInfo, appName string, region *api.Region, org *api.Organization) (map[string]bool, error) {
+func createDatabases(ctx context.Context, srcInfo *scanner.SourceInfo, appName string, region *api.Region, org *api.Organization, existing *existingResources) (map[string]bool, error) {
 	options := map[string]bool{}
 
 	if srcInfo == nil || srcInfo.SkipDatabase || flag.GetBool(ctx, "no-deploy") || flag.GetBool(ctx, "now") {
@@ -141,7 +157,16 @@ func createDatabases(ctx context.Context, srcInfo *scanner.SourceInfo, appName s
 	io := iostreams.FromContext(ctx)
 	colorize := io.ColorScheme()
 
-	confirmPg, err := prompt.Confirm(ctx, "Would you like to set up a Postgresql database now?")
+	var (
+		confirmPg, confirmRedis bool
+		err                     error
+	)
+	if existing.hasPostgres() {
+		fmt.Fprintf(io.Out, "A Postgres database is already attached to %s\n", appName)
+		options["postgresql"] = true
+	} else {
+		confirmPg, err = prompt.Confirm(ctx, "Would you like to set up a Postgresql database now?")
+	}
 	if confirmPg && err == nil {
 		db_app_name := fmt.Sprintf("%s-db", appName)
 		should_attach_db := false
@@ -191,7 +216,12 @@ func createDatabases(ctx context.Context, srcInfo *scanner.SourceInfo, appName s
 		}
 	}
 
-	confirmRedis, err := prompt.Confirm(ctx, "Would you like to set up an Upstash Redis database now?")
+	if existing.hasRedis() {
+		fmt.Fprintf(io.Out, "A Redis database is already attached to %s\n", appName)
+		options["redis"] = true
+	} else {
+		confirmRedis, err = prompt.Confirm(ctx, "Would you like to set up an Upstash Redis database now?")
+	}
 	if confirmRedis && err == nil {
 		err := LaunchRedis(ctx, appName, org, region)
 		if err != nil {
@@ -57,13 +57,17 @@ to all instances running in a specific region using the --region/-r flag.
 }
 
 func run(ctx context.Context) error {
-	client := client.FromContext(ctx).API()
-
-	opts := &logs.LogOptions{
+	return Tail(ctx, &logs.LogOptions{
 		AppName:    appconfig.NameFromContext(ctx),
 		RegionCode: config.FromContext(ctx).Region,
 		VMID:       flag.GetString(ctx, "instance"),
-	}
+	})
+}
+
+// Tail prints the logs opts selects until ctx is canceled, polling for them
+// until it can stream them live.
+func Tail(ctx context.Context, opts *logs.LogOptions) error {
+	client := client.FromContext(ctx).API()
 
 	var eg *errgroup.Group
 	eg, ctx = errgroup.WithContext(ctx)
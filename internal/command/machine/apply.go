@@ -0,0 +1,230 @@
+package machine
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// machineManifest is the file a machine's config is exported to and applied
+// from.
+type machineManifest struct {
+	ID     string             `json:"id"`
+	Name   string             `json:"name,omitempty"`
+	Region string             `json:"region,omitempty"`
+	Config *api.MachineConfig `json:"config"`
+}
+
+func newApply() *cobra.Command {
+	const (
+		short = "Apply a machine config file to its machine"
+		long  = `Update a machine to the config of a file, as written by 'fly machine export'
+and edited since. The file is JSON, or TOML when its name ends with .toml.
+
+The changes are shown as a diff to be confirmed, and the machine is leased
+while it's updated.
+`
+
+		usage = "apply <path>"
+	)
+
+	cmd := command.New(usage, short, long, runApply,
+		command.RequireSession,
+		command.LoadAppNameIfPresent,
+	)
+
+	flag.Add(
+		cmd,
+		flag.Yes(),
+		flag.Bool{
+			Name:        "skip-health-checks",
+			Description: "Updates machine without waiting for health checks.",
+			Default:     false,
+		},
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+func runApply(ctx context.Context) (err error) {
+	var (
+		io = iostreams.FromContext(ctx)
+
+		autoConfirm      = flag.GetBool(ctx, "yes")
+		skipHealthChecks = flag.GetBool(ctx, "skip-health-checks")
+		path             = flag.FirstArg(ctx)
+	)
+
+	manifest, err := readMachineManifest(path)
+	if err != nil {
+		return err
+	}
+
+	machine, ctx, err := selectOneMachine(ctx, nil, manifest.ID, true)
+	if err != nil {
+		return err
+	}
+	appName := appconfig.NameFromContext(ctx)
+
+	if manifest.Region != "" && manifest.Region != machine.Region {
+		return fmt.Errorf("machine %s is in region %s, not %s; machines can't be moved to another region", machine.ID, machine.Region, manifest.Region)
+	}
+
+	// Acquire lease
+	machine, releaseLeaseFunc, err := mach.AcquireLease(ctx, machine)
+	defer releaseLeaseFunc(ctx, machine)
+	if err != nil {
+		return err
+	}
+
+	// Prompt user to confirm changes
+	if !autoConfirm {
+		var noChanges *mach.ErrNoConfigChangesFound
+		confirmed, err := mach.ConfirmConfigChanges(ctx, machine, *manifest.Config, "")
+		switch {
+		case errors.As(err, &noChanges):
+			fmt.Fprintf(io.Out, "No changes to apply\n")
+			return nil
+		case err != nil:
+			return err
+		case !confirmed:
+			fmt.Fprintf(io.Out, "No changes to apply\n")
+			return nil
+		}
+	}
+
+	name := manifest.Name
+	if name == "" {
+		name = machine.Name
+	}
+
+	input := &api.LaunchMachineInput{
+		ID:               machine.ID,
+		AppID:            appName,
+		Name:             name,
+		Region:           machine.Region,
+		Config:           manifest.Config,
+		SkipHealthChecks: skipHealthChecks,
+	}
+	if err := mach.Update(ctx, machine, input); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "\nMonitor machine status here:\nhttps://fly.io/apps/%s/machines/%s\n", appName, machine.ID)
+
+	return nil
+}
+
+// isTOMLPath reports whether path is a TOML file, going by its extension.
+func isTOMLPath(path string) bool {
+	return strings.EqualFold(filepath.Ext(path), ".toml")
+}
+
+func readMachineManifest(path string) (*machineManifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed reading %s: %w", path, err)
+	}
+
+	manifest, err := decodeMachineManifest(data, isTOMLPath(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing %s: %w", path, err)
+	}
+
+	switch {
+	case manifest.ID == "":
+		return nil, fmt.Errorf("%s doesn't set the id of the machine to apply it to", path)
+	case manifest.Config == nil:
+		return nil, fmt.Errorf("%s doesn't set the config of machine %s", path, manifest.ID)
+	}
+
+	return manifest, nil
+}
+
+// decodeMachineManifest parses a manifest from JSON, or from TOML with the
+// same keys as the JSON.
+func decodeMachineManifest(data []byte, isTOML bool) (*machineManifest, error) {
+	if isTOML {
+		var raw map[string]any
+		if err := toml.Unmarshal(data, &raw); err != nil {
+			return nil, err
+		}
+
+		var err error
+		if data, err = json.Marshal(raw); err != nil {
+			return nil, err
+		}
+	}
+
+	manifest := &machineManifest{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(manifest); err != nil {
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
+// encodeMachineManifest writes manifest as indented JSON, or as TOML with the
+// same keys as the JSON.
+func encodeMachineManifest(manifest *machineManifest, isTOML bool) ([]byte, error) {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil || !isTOML {
+		return append(data, '\n'), err
+	}
+
+	var raw map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(tomlNumbers(raw)); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// tomlNumbers replaces the JSON numbers of v with integers or floats, so they
+// aren't encoded as TOML strings.
+func tomlNumbers(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, e := range v {
+			v[k] = tomlNumbers(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = tomlNumbers(e)
+		}
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	}
+
+	return v
+}
@@ -0,0 +1,45 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestMachineManifestRoundTrip(t *testing.T) {
+	manifest := &machineManifest{
+		ID:     "148ed726c8e8d8",
+		Name:   "quiet-sun-1234",
+		Region: "ord",
+		Config: &api.MachineConfig{
+			Image: "registry.fly.io/app:deployment-1",
+			Env:   map[string]string{"PORT": "8080"},
+			Guest: &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 256},
+			Services: []api.MachineService{{
+				Protocol:     "tcp",
+				InternalPort: 8080,
+				Ports: []api.MachinePort{{
+					Port:     api.Pointer(443),
+					Handlers: []string{"tls", "http"},
+				}},
+			}},
+		},
+	}
+
+	for _, isTOML := range []bool{false, true} {
+		data, err := encodeMachineManifest(manifest, isTOML)
+		require.NoError(t, err)
+
+		decoded, err := decodeMachineManifest(data, isTOML)
+		require.NoError(t, err)
+		assert.Equal(t, manifest, decoded)
+	}
+}
+
+func TestDecodeMachineManifestUnknownField(t *testing.T) {
+	_, err := decodeMachineManifest([]byte("id = \"148ed726c8e8d8\"\n[config]\nimage = \"nginx\"\nimgae = \"typo\"\n"), true)
+	assert.Error(t, err)
+}
@@ -0,0 +1,168 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/samber/lo"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// bulkFlags select every machine of an app, narrowed down by filters, for the
+// lifecycle commands, which then act on several machines at once.
+var bulkFlags = []flag.Flag{
+	flag.Bool{
+		Name:        "all",
+		Description: "Act on every machine of the app, or on those matching --region, --process-group and --metadata",
+	},
+	flag.String{
+		Name:        "region",
+		Description: "With --all, only act on the machines in this region",
+	},
+	flag.String{
+		Name:        "process-group",
+		Description: "With --all, only act on the machines of this process group",
+	},
+	flag.StringSlice{
+		Name:        "metadata",
+		Description: "With --all, only act on the machines with this metadata, as key=value. Can be specified multiple times",
+	},
+	flag.Int{
+		Name:        "concurrency",
+		Description: "Number of machines to act on at once",
+		Default:     4,
+	},
+}
+
+// machineFilters narrow down the machines --all selects.
+type machineFilters struct {
+	Region       string
+	ProcessGroup string
+	Metadata     map[string]string
+}
+
+func machineFiltersFromFlags(ctx context.Context) (machineFilters, error) {
+	filters := machineFilters{
+		Region:       flag.GetString(ctx, "region"),
+		ProcessGroup: flag.GetString(ctx, "process-group"),
+		Metadata:     map[string]string{},
+	}
+
+	for _, kv := range flag.GetStringSlice(ctx, "metadata") {
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return filters, fmt.Errorf("invalid metadata filter %q, expected key=value", kv)
+		}
+		filters.Metadata[key] = value
+	}
+
+	return filters, nil
+}
+
+func (f machineFilters) empty() bool {
+	return f.Region == "" && f.ProcessGroup == "" && len(f.Metadata) == 0
+}
+
+func (f machineFilters) match(m *api.Machine) bool {
+	if f.Region != "" && m.Region != f.Region {
+		return false
+	}
+	if f.ProcessGroup != "" && m.ProcessGroup() != f.ProcessGroup {
+		return false
+	}
+	for key, value := range f.Metadata {
+		if m.Config == nil || m.Config.Metadata[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+type bulkResult struct {
+	Machine *api.Machine
+	Err     error
+}
+
+// runBulk runs op on machines, --concurrency of them at once. Failures don't
+// stop op from running on the other machines; when there were several, a
+// summary of the results is printed and an error returned for any failure.
+func runBulk(ctx context.Context, machines []*api.Machine, verb string, op func(context.Context, *api.Machine) error) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		results  = make([]bulkResult, len(machines))
+		mu       sync.Mutex
+	)
+
+	eg, egCtx := errgroup.WithContext(ctx)
+	eg.SetLimit(lo.Max([]int{flag.GetInt(ctx, "concurrency"), 1}))
+
+	for i, m := range machines {
+		i, m := i, m
+		eg.Go(func() error {
+			err := op(egCtx, m)
+			mu.Lock()
+			results[i] = bulkResult{Machine: m, Err: err}
+			mu.Unlock()
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	failed := lo.CountBy(results, func(r bulkResult) bool { return r.Err != nil })
+
+	if len(machines) == 1 {
+		return results[0].Err
+	}
+
+	rows := make([][]string, 0, len(results))
+	for _, r := range results {
+		result := colorize.Green("ok")
+		if r.Err != nil {
+			result = colorize.Red(r.Err.Error())
+		}
+		rows = append(rows, []string{r.Machine.ID, r.Machine.Region, r.Machine.ProcessGroup(), result})
+	}
+	fmt.Fprintln(io.Out)
+	if err := render.Table(io.Out, "", rows, "Machine", "Region", "Process Group", "Result"); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to %s %d of %d machines", verb, failed, len(machines))
+	}
+	return nil
+}
+
+// selectAllMachines returns the machines of the app matching the filters of
+// the command line.
+func selectAllMachines(ctx context.Context) ([]*api.Machine, error) {
+	filters, err := machineFiltersFromFlags(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	machines, err := flaps.FromContext(ctx).List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("could not get a list of machines: %w", err)
+	}
+
+	machines = lo.Filter(machines, func(m *api.Machine, _ int) bool { return filters.match(m) })
+	if len(machines) == 0 {
+		if filters.empty() {
+			return nil, errors.New("the app has no machines")
+		}
+		return nil, errors.New("no machines match the filters")
+	}
+
+	return machines, nil
+}
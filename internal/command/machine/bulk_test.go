@@ -0,0 +1,58 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func TestMachineFilters(t *testing.T) {
+	m := &api.Machine{
+		Region: "ams",
+		Config: &api.MachineConfig{
+			Metadata: map[string]string{
+				api.MachineConfigMetadataKeyFlyProcessGroup: "worker",
+				"tier": "batch",
+			},
+		},
+	}
+
+	assert.True(t, machineFilters{}.match(m))
+	assert.True(t, machineFilters{Region: "ams", ProcessGroup: "worker", Metadata: map[string]string{"tier": "batch"}}.match(m))
+	assert.False(t, machineFilters{Region: "ord"}.match(m))
+	assert.False(t, machineFilters{ProcessGroup: "app"}.match(m))
+	assert.False(t, machineFilters{Metadata: map[string]string{"tier": "web"}}.match(m))
+}
+
+func TestRunBulk(t *testing.T) {
+	fs := pflag.NewFlagSet("bulk", pflag.ContinueOnError)
+	fs.Int("concurrency", 2, "")
+	ios, _, out, _ := iostreams.Test()
+	ctx := iostreams.NewContext(flag.NewContext(context.Background(), fs), ios)
+
+	machines := []*api.Machine{{ID: "one"}, {ID: "two"}, {ID: "three"}}
+
+	var ran int32
+	err := runBulk(ctx, machines, "stop", func(_ context.Context, m *api.Machine) error {
+		atomic.AddInt32(&ran, 1)
+		if m.ID == "two" {
+			return errors.New("boom")
+		}
+		return nil
+	})
+
+	assert.EqualError(t, err, "failed to stop 1 of 3 machines")
+	assert.EqualValues(t, 3, ran)
+	assert.Contains(t, out.String(), "boom")
+
+	err = runBulk(ctx, machines[1:2], "stop", func(context.Context, *api.Machine) error { return errors.New("boom") })
+	assert.EqualError(t, err, "boom")
+}
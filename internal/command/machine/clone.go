@@ -20,6 +20,11 @@ import (
 	"github.com/superfly/flyctl/terminal"
 )
 
+const (
+	snapshotTimeout      = 10 * time.Minute
+	snapshotPollInterval = 5 * time.Second
+)
+
 func newClone() *cobra.Command {
 	const (
 		short = "Clone a Fly machine"
@@ -73,6 +78,10 @@ func newClone() *cobra.Command {
 			Name:        "clear-auto-destroy",
 			Description: "Disable auto destroy setting on new machine",
 		},
+		flag.Bool{
+			Name:        "across-region",
+			Description: "Clone into the --region given along with the data of attached volumes, by restoring a snapshot of them taken now",
+		},
 	)
 
 	return cmd
@@ -105,6 +114,16 @@ func runMachineClone(ctx context.Context) (err error) {
 		region = source.Region
 	}
 
+	acrossRegion := flag.GetBool(ctx, "across-region")
+	if acrossRegion {
+		switch {
+		case region == source.Region:
+			return fmt.Errorf("--across-region clones into another region than %s, set it with --region", source.Region)
+		case flag.GetString(ctx, "from-snapshot") != "" || flag.GetString(ctx, "attach-volume") != "":
+			return fmt.Errorf("--across-region can't be used with --from-snapshot or --attach-volume")
+		}
+	}
+
 	fmt.Fprintf(out, "Cloning machine %s into region %s\n", colorize.Bold(source.ID), colorize.Bold(region))
 
 	targetConfig := source.Config
@@ -154,6 +173,7 @@ func runMachineClone(ctx context.Context) (err error) {
 		fmt.Fprintf(io.Out, "Auto destroy enabled and will destroy machine on exit. Use --clear-auto-destroy to remove this setting.\n")
 	}
 
+	var createdVolumes []string
 	for _, mnt := range source.Config.Mounts {
 		var vol *api.Volume
 		if volID := flag.GetString(ctx, "attach-volume"); volID != "" {
@@ -168,7 +188,16 @@ func runMachineClone(ctx context.Context) (err error) {
 			}
 		} else {
 			var snapshotID *string
-			switch snapID := flag.GetString(ctx, "from-snapshot"); snapID {
+			snapID := flag.GetString(ctx, "from-snapshot")
+			if acrossRegion {
+				fmt.Fprintf(out, "Taking a snapshot of volume %s to copy it into %s\n", colorize.Bold(mnt.Volume), colorize.Bold(region))
+				snapshot, err := snapshotVolume(ctx, client, mnt.Volume)
+				if err != nil {
+					return err
+				}
+				snapID = snapshot.ID
+			}
+			switch snapID {
 			case "last":
 				snapshots, err := client.GetVolumeSnapshots(ctx, mnt.Volume)
 				if err != nil {
@@ -202,6 +231,7 @@ func runMachineClone(ctx context.Context) (err error) {
 			if err != nil {
 				return err
 			}
+			createdVolumes = append(createdVolumes, vol.ID)
 		}
 
 		targetConfig.Mounts = []api.MachineMount{
@@ -223,6 +253,11 @@ func runMachineClone(ctx context.Context) (err error) {
 
 	launchedMachine, err := flapsClient.Launch(ctx, input)
 	if err != nil {
+		for _, volID := range createdVolumes {
+			if _, err := client.DeleteVolume(ctx, volID); err != nil {
+				terminal.Warnf("Failed to delete volume %s created for the new machine: %v\n", volID, err)
+			}
+		}
 		return err
 	}
 
@@ -244,6 +279,39 @@ func runMachineClone(ctx context.Context) (err error) {
 	return
 }
 
+// snapshotVolume takes a snapshot of a volume and waits for it to be listed
+// among its snapshots.
+func snapshotVolume(ctx context.Context, client *api.Client, volID string) (*api.Snapshot, error) {
+	snapshots, err := client.GetVolumeSnapshots(ctx, volID)
+	if err != nil {
+		return nil, err
+	}
+	existing := lo.SliceToMap(snapshots, func(s api.Snapshot) (string, bool) { return s.ID, true })
+
+	if _, err := client.CreateVolumeSnapshot(ctx, volID); err != nil {
+		return nil, fmt.Errorf("failed to snapshot volume %s: %w", volID, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, snapshotTimeout)
+	defer cancel()
+
+	for {
+		snapshots, err := client.GetVolumeSnapshots(ctx, volID)
+		if err != nil {
+			return nil, err
+		}
+		if snapshot, ok := lo.Find(snapshots, func(s api.Snapshot) bool { return !existing[s.ID] }); ok {
+			return &snapshot, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for the snapshot of volume %s", volID)
+		case <-time.After(snapshotPollInterval):
+		}
+	}
+}
+
 func getAppConfig(ctx context.Context, appName string) (*appconfig.Config, error) {
 	cfg := appconfig.ConfigFromContext(ctx)
 	if cfg == nil {
@@ -0,0 +1,367 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/pkg/sftp"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/ssh"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newMachineCp() *cobra.Command {
+	const (
+		short = "Copy files between a machine and the local filesystem"
+		long  = short + `
+
+One of the source and the destination is a path on a machine, written as
+<machine-id>:<path>, or as :<path> along with --select. As with cp, files are
+copied into the destination when it's an existing directory or ends with a
+slash, and directories are only copied with --recursive. Files are transferred
+over SFTP through the WireGuard tunnel of the organization, which requires an
+SSH key for it and the machine to be started.
+
+  fly machine cp ./dump.sql 3d8d9e1f0c7189:/data/
+  fly machine cp -r 3d8d9e1f0c7189:/data/uploads ./uploads
+`
+		usage = "cp <source> <destination>"
+	)
+
+	cmd := command.New(usage, short, long, runMachineCp,
+		command.RequireSession,
+		command.LoadAppNameIfPresent,
+	)
+
+	flag.Add(
+		cmd,
+		flag.App(),
+		flag.AppConfig(),
+		selectFlag,
+		flag.Bool{
+			Name:        "recursive",
+			Shorthand:   "r",
+			Description: "Copy directories and their contents",
+		},
+	)
+
+	cmd.Args = cobra.ExactArgs(2)
+
+	return cmd
+}
+
+// copyTarget is the source or the destination of a copy.
+type copyTarget struct {
+	MachineID string
+	Path      string
+	Remote    bool
+}
+
+// parseCopyTarget parses arg as <machine-id>:<path> when it's a path on a
+// machine, as a local path otherwise. The drive letters of Windows paths
+// aren't taken for machine IDs.
+func parseCopyTarget(arg string) copyTarget {
+	id, p, ok := strings.Cut(arg, ":")
+	if !ok || len(id) == 1 || strings.ContainsAny(id, `/\`) {
+		return copyTarget{Path: arg}
+	}
+	return copyTarget{MachineID: id, Path: p, Remote: true}
+}
+
+func runMachineCp(ctx context.Context) error {
+	var (
+		args = flag.Args(ctx)
+		src  = parseCopyTarget(args[0])
+		dst  = parseCopyTarget(args[1])
+	)
+
+	switch {
+	case src.Remote == dst.Remote:
+		return errors.New("one of the source and the destination must be a path on a machine, as <machine-id>:<path>")
+	case src.Remote && src.Path == "", dst.Remote && dst.Path == "":
+		return errors.New("the path on the machine must follow the colon, as <machine-id>:<path>")
+	}
+
+	remote := src
+	if dst.Remote {
+		remote = dst
+	}
+
+	machine, ctx, err := selectOneMachine(ctx, nil, remote.MachineID, remote.MachineID != "")
+	if err != nil {
+		return err
+	}
+	if machine.State != api.MachineStateStarted {
+		return fmt.Errorf("machine %s is %s, start it with fly machine start %s", machine.ID, machine.State, machine.ID)
+	}
+
+	app, dialer, err := machineDialer(ctx)
+	if err != nil {
+		return err
+	}
+
+	client, err := ssh.NewSFTPClient(ctx, app, dialer, machine.PrivateIP)
+	if err != nil {
+		return fmt.Errorf("failed connecting to machine %s: %w", machine.ID, err)
+	}
+	defer client.Close()
+
+	io := iostreams.FromContext(ctx)
+	c := &machineCopy{
+		sftp:      client,
+		recursive: flag.GetBool(ctx, "recursive"),
+		progress:  &copyProgress{out: io.ErrOut, live: io.IsStderrTTY()},
+	}
+
+	if dst.Remote {
+		err = c.upload(src.Path, dst.Path)
+	} else {
+		err = c.download(src.Path, dst.Path)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.ErrOut, "Copied %d files (%s)\n", c.progress.files, humanize.Bytes(uint64(c.progress.bytes)))
+
+	return nil
+}
+
+// machineCopy copies files to and from a machine over SFTP.
+type machineCopy struct {
+	sftp      *sftp.Client
+	recursive bool
+	progress  *copyProgress
+}
+
+func (c *machineCopy) upload(src, dst string) error {
+	fi, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if fi.IsDir() && !c.recursive {
+		return fmt.Errorf("%s is a directory, copy it with --recursive", src)
+	}
+
+	if strings.HasSuffix(dst, "/") {
+		dst = path.Join(dst, filepath.Base(src))
+	} else if remote, err := c.sftp.Stat(dst); err == nil && remote.IsDir() {
+		dst = path.Join(dst, filepath.Base(src))
+	}
+
+	if !fi.IsDir() {
+		return c.uploadFile(src, dst, fi)
+	}
+
+	return filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(src, p)
+		if err != nil {
+			return err
+		}
+		target := path.Join(dst, filepath.ToSlash(rel))
+
+		switch {
+		case d.IsDir():
+			return c.sftp.MkdirAll(target)
+		case !d.Type().IsRegular():
+			c.progress.skip(p)
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		return c.uploadFile(p, target, info)
+	})
+}
+
+func (c *machineCopy) uploadFile(src, dst string, fi fs.FileInfo) error {
+	local, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer local.Close()
+
+	if err := c.sftp.MkdirAll(path.Dir(dst)); err != nil {
+		return fmt.Errorf("failed creating %s on the machine: %w", path.Dir(dst), err)
+	}
+
+	remote, err := c.sftp.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC)
+	if err != nil {
+		return fmt.Errorf("failed creating %s on the machine: %w", dst, err)
+	}
+
+	t := c.progress.start(dst, fi.Size())
+	t.r = local
+	if _, err := remote.ReadFrom(t); err != nil {
+		remote.Close()
+		return fmt.Errorf("failed copying %s: %w", src, err)
+	}
+	if err := remote.Close(); err != nil {
+		return fmt.Errorf("failed copying %s: %w", src, err)
+	}
+	t.finish()
+
+	return c.sftp.Chmod(dst, fi.Mode().Perm())
+}
+
+func (c *machineCopy) download(src, dst string) error {
+	src = path.Clean(src)
+
+	fi, err := c.sftp.Stat(src)
+	if err != nil {
+		return fmt.Errorf("failed reading %s on the machine: %w", src, err)
+	}
+	if fi.IsDir() && !c.recursive {
+		return fmt.Errorf("%s is a directory, copy it with --recursive", src)
+	}
+
+	if strings.HasSuffix(dst, "/") || strings.HasSuffix(dst, string(filepath.Separator)) {
+		dst = filepath.Join(dst, path.Base(src))
+	} else if local, err := os.Stat(dst); err == nil && local.IsDir() {
+		dst = filepath.Join(dst, path.Base(src))
+	}
+
+	if !fi.IsDir() {
+		return c.downloadFile(src, dst, fi)
+	}
+
+	walker := c.sftp.Walk(src)
+	for walker.Step() {
+		if err := walker.Err(); err != nil {
+			return err
+		}
+
+		rel := strings.TrimPrefix(strings.TrimPrefix(walker.Path(), src), "/")
+		target := filepath.Join(dst, filepath.FromSlash(rel))
+
+		switch info := walker.Stat(); {
+		case info.IsDir():
+			if err := os.MkdirAll(target, 0o755); err != nil {
+				return err
+			}
+		case !info.Mode().IsRegular():
+			c.progress.skip(walker.Path())
+		default:
+			if err := c.downloadFile(walker.Path(), target, info); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (c *machineCopy) downloadFile(src, dst string, fi fs.FileInfo) error {
+	remote, err := c.sftp.Open(src)
+	if err != nil {
+		return fmt.Errorf("failed opening %s on the machine: %w", src, err)
+	}
+	defer remote.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+
+	local, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	t := c.progress.start(dst, fi.Size())
+	t.w = local
+	if _, err := remote.WriteTo(t); err != nil {
+		local.Close()
+		return fmt.Errorf("failed copying %s: %w", src, err)
+	}
+	if err := local.Close(); err != nil {
+		return err
+	}
+	t.finish()
+
+	return nil
+}
+
+// copyProgress reports the files copied and, on terminals, how far along the
+// copy of the current one is.
+type copyProgress struct {
+	out   io.Writer
+	live  bool
+	files int
+	bytes int64
+}
+
+func (p *copyProgress) start(name string, size int64) *transfer {
+	return &transfer{progress: p, name: name, size: size}
+}
+
+func (p *copyProgress) skip(name string) {
+	fmt.Fprintf(p.out, "  Skipping %s, which isn't a regular file\n", name)
+}
+
+// transfer counts the bytes of a file read from r or written to w.
+type transfer struct {
+	progress *copyProgress
+	name     string
+	size     int64
+	done     int64
+	reported time.Time
+
+	r io.Reader
+	w io.Writer
+}
+
+func (t *transfer) Read(b []byte) (int, error) {
+	n, err := t.r.Read(b)
+	t.add(n)
+	return n, err
+}
+
+func (t *transfer) Write(b []byte) (int, error) {
+	n, err := t.w.Write(b)
+	t.add(n)
+	return n, err
+}
+
+// Size lets sftp upload with concurrent writes, as it does straight from files.
+func (t *transfer) Size() int64 {
+	return t.size
+}
+
+func (t *transfer) add(n int) {
+	t.done += int64(n)
+
+	if !t.progress.live || time.Since(t.reported) < 200*time.Millisecond {
+		return
+	}
+	t.reported = time.Now()
+	fmt.Fprintf(t.progress.out, "\r\033[K  %s %s / %s", t.name, humanize.Bytes(uint64(t.done)), humanize.Bytes(uint64(t.size)))
+}
+
+func (t *transfer) finish() {
+	t.progress.files++
+	t.progress.bytes += t.done
+
+	if t.progress.live {
+		fmt.Fprint(t.progress.out, "\r\033[K")
+	}
+	fmt.Fprintf(t.progress.out, "  %s (%s)\n", t.name, humanize.Bytes(uint64(t.done)))
+}
@@ -0,0 +1,46 @@
+package machine
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCopyTarget(t *testing.T) {
+	cases := map[string]copyTarget{
+		"3d8d9e1f0c7189:/data/":   {MachineID: "3d8d9e1f0c7189", Path: "/data/", Remote: true},
+		":/data/dump.sql":         {Path: "/data/dump.sql", Remote: true},
+		"3d8d9e1f0c7189:":         {MachineID: "3d8d9e1f0c7189", Remote: true},
+		"./dump.sql":              {Path: "./dump.sql"},
+		"dump.sql":                {Path: "dump.sql"},
+		"./backups/a:b.sql":       {Path: "./backups/a:b.sql"},
+		`C:\Users\me\dump.sql`:    {Path: `C:\Users\me\dump.sql`},
+		`backups\a:b.sql`:         {Path: `backups\a:b.sql`},
+		"3d8d9e1f0c7189:data/x:y": {MachineID: "3d8d9e1f0c7189", Path: "data/x:y", Remote: true},
+	}
+
+	for arg, want := range cases {
+		assert.Equal(t, want, parseCopyTarget(arg), arg)
+	}
+}
+
+func TestTransferProgress(t *testing.T) {
+	var out bytes.Buffer
+	p := &copyProgress{out: &out}
+
+	tr := p.start("/data/dump.sql", 11)
+	tr.r = strings.NewReader("hello world")
+
+	var dst bytes.Buffer
+	_, err := dst.ReadFrom(tr)
+	require.NoError(t, err)
+	tr.finish()
+
+	assert.Equal(t, "hello world", dst.String())
+	assert.Equal(t, 1, p.files)
+	assert.Equal(t, int64(11), p.bytes)
+	assert.Equal(t, "  /data/dump.sql (11 B)\n", out.String())
+}
@@ -2,14 +2,25 @@ package machine
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"strings"
 
+	"github.com/docker/docker/pkg/ioutils"
 	"github.com/spf13/cobra"
+	cryptossh "golang.org/x/crypto/ssh"
+
+	"github.com/superfly/flyctl/agent"
 	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/ssh"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
 	"github.com/superfly/flyctl/internal/render"
 	"github.com/superfly/flyctl/iostreams"
 )
@@ -18,7 +29,14 @@ func newMachineExec() *cobra.Command {
 
 	const (
 		short = "Execute a command on a machine"
-		long  = short + "\n"
+		long  = short + `
+
+With --interactive, stdin is streamed to the command, and with --tty it runs
+in a terminal, resized along with yours, so that fly machine exec -it <id> bash
+works like docker exec -it. Both connect to the machine over SSH, which
+requires a key for the organization, and exit with the exit code of the
+command.
+`
 		usage = "exec <machine-id> <command>"
 	)
 
@@ -36,6 +54,16 @@ func newMachineExec() *cobra.Command {
 			Name:        "timeout",
 			Description: "Timeout in seconds",
 		},
+		flag.Bool{
+			Name:        "interactive",
+			Shorthand:   "i",
+			Description: "Keep stdin open and stream it to the command",
+		},
+		flag.Bool{
+			Name:        "tty",
+			Shorthand:   "t",
+			Description: "Run the command in a terminal",
+		},
 	)
 
 	cmd.Args = cobra.RangeArgs(1, 2)
@@ -62,10 +90,25 @@ func runMachineExec(ctx context.Context) (err error) {
 		command = args[0]
 	}
 
+	interactive, tty := flag.GetBool(ctx, "interactive"), flag.GetBool(ctx, "tty")
+	if interactive || tty {
+		switch {
+		case config.JSONOutput:
+			return errors.New("--json can't be used with --interactive or --tty")
+		case flag.GetInt(ctx, "timeout") != 0:
+			return errors.New("--timeout can't be used with --interactive or --tty")
+		}
+	}
+
 	current, ctx, err := selectOneMachine(ctx, nil, machineID, haveMachineID)
 	if err != nil {
 		return err
 	}
+
+	if interactive || tty {
+		return attachMachineExec(ctx, current, command, interactive, tty)
+	}
+
 	flapsClient := flaps.FromContext(ctx)
 
 	var timeout = flag.GetInt(ctx, "timeout")
@@ -94,3 +137,58 @@ func runMachineExec(ctx context.Context) (err error) {
 
 	return
 }
+
+// attachMachineExec runs command on machine over SSH, as the exec API of
+// machines neither streams nor allocates terminals.
+func attachMachineExec(ctx context.Context, machine *api.Machine, command string, interactive, tty bool) error {
+	if machine.State != api.MachineStateStarted {
+		return fmt.Errorf("machine %s is %s, start it with fly machine start %s", machine.ID, machine.State, machine.ID)
+	}
+
+	app, dialer, err := machineDialer(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		streams = iostreams.FromContext(ctx)
+		stdin   = io.Reader(strings.NewReader(""))
+		stdout  = ioutils.NewWriteCloserWrapper(streams.Out, func() error { return nil })
+		stderr  = ioutils.NewWriteCloserWrapper(streams.ErrOut, func() error { return nil })
+	)
+	if interactive {
+		stdin = streams.In
+	}
+
+	err = ssh.Attach(ctx, app, dialer, machine.PrivateIP, command, tty, stdin, stdout, stderr)
+
+	var exitErr *cryptossh.ExitError
+	if errors.As(err, &exitErr) {
+		return flyerr.ExitCodeError{Code: exitErr.ExitStatus()}
+	}
+	return err
+}
+
+// machineDialer returns the app of the context and a dialer reaching its
+// machines through the WireGuard tunnel of its organization.
+func machineDialer(ctx context.Context) (*api.AppCompact, agent.Dialer, error) {
+	apiClient := client.FromContext(ctx).API()
+	app, err := apiClient.GetAppCompact(ctx, appconfig.NameFromContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	agentclient, err := agent.Establish(ctx, apiClient)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't establish agent: %w", err)
+	}
+	dialer, err := agentclient.Dialer(ctx, app.Organization.Slug)
+	if err != nil {
+		return nil, nil, fmt.Errorf("can't build tunnel for %s: %w", app.Organization.Slug, err)
+	}
+	if err := agentclient.WaitForTunnel(ctx, app.Organization.Slug); err != nil {
+		return nil, nil, fmt.Errorf("tunnel unavailable: %w", err)
+	}
+
+	return app, dialer, nil
+}
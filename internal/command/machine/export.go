@@ -0,0 +1,94 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newExport() *cobra.Command {
+	const (
+		short = "Export the config of a machine to a file"
+		long  = `Write the config of a machine as JSON, or as TOML with --format toml or
+an --output path ending with .toml, to be edited and applied back with
+'fly machine apply'.
+`
+
+		usage = "export [machine_id]"
+	)
+
+	cmd := command.New(usage, short, long, runExport,
+		command.RequireSession,
+		command.LoadAppNameIfPresent,
+	)
+
+	flag.Add(
+		cmd,
+		selectFlag,
+		flag.String{
+			Name:        "output",
+			Shorthand:   "o",
+			Description: "The file to write the config to, instead of stdout",
+		},
+		flag.String{
+			Name:        "format",
+			Description: "The format of the config, json or toml",
+		},
+	)
+
+	cmd.Args = cobra.RangeArgs(0, 1)
+
+	return cmd
+}
+
+func runExport(ctx context.Context) (err error) {
+	var (
+		io     = iostreams.FromContext(ctx)
+		output = flag.GetString(ctx, "output")
+	)
+
+	var isTOML bool
+	switch format := flag.GetString(ctx, "format"); format {
+	case "":
+		isTOML = isTOMLPath(output)
+	case "json", "toml":
+		isTOML = format == "toml"
+	default:
+		return fmt.Errorf("invalid format %q, expected json or toml", format)
+	}
+
+	machineID := flag.FirstArg(ctx)
+	haveMachineID := len(flag.Args(ctx)) > 0
+	machine, _, err := selectOneMachine(ctx, nil, machineID, haveMachineID)
+	if err != nil {
+		return err
+	}
+
+	data, err := encodeMachineManifest(&machineManifest{
+		ID:     machine.ID,
+		Name:   machine.Name,
+		Region: machine.Region,
+		Config: machine.Config,
+	}, isTOML)
+	if err != nil {
+		return fmt.Errorf("failed encoding the config of machine %s: %w", machine.ID, err)
+	}
+
+	if output == "" {
+		_, err = io.Out.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(output, data, 0o600); err != nil {
+		return err
+	}
+	fmt.Fprintf(io.ErrOut, "Wrote the config of machine %s to %s\n", machine.ID, output)
+
+	return nil
+}
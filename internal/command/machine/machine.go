@@ -32,6 +32,10 @@ func New() *cobra.Command {
 		newRestart(),
 		newLeases(),
 		newMachineExec(),
+		newMachineCp(),
+		newExport(),
+		newApply(),
+		newSizes(),
 	)
 
 	return cmd
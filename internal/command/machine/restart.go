@@ -54,6 +54,7 @@ func newRestart() *cobra.Command {
 			Default:     false,
 		},
 	)
+	flag.Add(cmd, bulkFlags...)
 
 	return cmd
 }
@@ -96,11 +97,12 @@ func runMachineRestart(ctx context.Context) error {
 	}
 
 	// Restart each machine
-	for _, machine := range machines {
-		if err := mach.Restart(ctx, machine, input, machine.LeaseNonce); err != nil {
+	return runBulk(ctx, machines, "restart", func(ctx context.Context, machine *api.Machine) error {
+		// every restart needs an input of its own, Restart sets its ID
+		input := *input
+		if err := mach.Restart(ctx, machine, &input, machine.LeaseNonce); err != nil {
 			return fmt.Errorf("failed to restart machine %s: %w", machine.ID, err)
 		}
-	}
-
-	return nil
+		return nil
+	})
 }
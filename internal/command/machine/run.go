@@ -32,6 +32,7 @@ import (
 	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/internal/state"
 	"github.com/superfly/flyctl/internal/watch"
+	"github.com/superfly/flyctl/terminal"
 )
 
 var sharedFlags = flag.Set{
@@ -124,7 +125,11 @@ var sharedFlags = flag.Set{
 	},
 	flag.String{
 		Name:        "schedule",
-		Description: `Schedule a machine run at hourly, daily and monthly intervals`,
+		Description: `Schedule a machine run at hourly, daily, weekly or monthly intervals, or at the times of a cron expression such as "0 8 * * mon-fri"`,
+	},
+	flag.String{
+		Name:        "schedule-timezone",
+		Description: `Timezone the cron expression of --schedule is evaluated in, such as "Europe/Paris". Defaults to UTC`,
 	},
 	flag.Bool{
 		Name:        "skip-dns-registration",
@@ -154,6 +159,7 @@ func newRun() *cobra.Command {
 	cmd := command.New(usage, short, long, runMachineRun,
 		command.RequireSession,
 		command.LoadAppNameIfPresent,
+		command.RequireCompatibleVersion("machine run"),
 	)
 
 	flag.Add(
@@ -177,10 +183,15 @@ func newRun() *cobra.Command {
 			Name:        "rm",
 			Description: "Automatically remove the machine when it exits",
 		},
+		flag.String{
+			Name:        "from-snapshot",
+			Description: "Restore the volume passed with --volume from a snapshot of the app's volumes of the same name. Either a snapshot ID or 'last' for the most recent one",
+		},
 		sharedFlags,
 	)
 
 	cmd.Args = cobra.MinimumNArgs(1)
+	cmd.Aliases = []string{"create"}
 
 	return cmd
 }
@@ -258,6 +269,14 @@ func runMachineRun(ctx context.Context) error {
 		return fmt.Errorf("to update an existing machine, use 'flyctl machine update'")
 	}
 
+	snapshot := flag.GetString(ctx, "from-snapshot")
+	var snapshotVolName, snapshotVolPath string
+	if snapshot != "" {
+		if snapshotVolName, snapshotVolPath, err = parseSnapshotVolume(flag.GetStringSlice(ctx, "volume")); err != nil {
+			return err
+		}
+	}
+
 	machineConf, err = determineMachineConfig(ctx, *machineConf, app.Name, flag.FirstArg(ctx), input.Region)
 	if err != nil {
 		return err
@@ -267,10 +286,24 @@ func runMachineRun(ctx context.Context) error {
 		return nil
 	}
 
+	// restored last, so that it isn't left behind when the config is invalid
+	var restored *api.Volume
+	if snapshot != "" {
+		if restored, err = restoreVolume(ctx, snapshotVolName, snapshot, input.Region); err != nil {
+			return err
+		}
+		machineConf.Mounts = mountVolume(machineConf.Mounts, restored.ID, snapshotVolPath)
+	}
+
 	input.Config = machineConf
 
 	machine, err := flapsClient.Launch(ctx, input)
 	if err != nil {
+		if restored != nil {
+			if _, err := client.DeleteVolume(ctx, restored.ID); err != nil {
+				terminal.Warnf("Failed to delete volume %s restored for the new machine: %v\n", restored.ID, err)
+			}
+		}
 		return fmt.Errorf("could not launch machine: %w", err)
 	}
 
@@ -280,6 +313,9 @@ func runMachineRun(ctx context.Context) error {
 	fmt.Fprintf(io.Out, " Machine ID: %s\n", id)
 	fmt.Fprintf(io.Out, " Instance ID: %s\n", instanceID)
 	fmt.Fprintf(io.Out, " State: %s\n", state)
+	if machineConf.Schedule != "" {
+		fmt.Fprintf(io.Out, " Schedule: %s\n", DescribeSchedule(machineConf, time.Now()))
+	}
 
 	fmt.Fprintf(io.Out, "\n Attempting to start machine...\n\n")
 	s.Start()
@@ -440,9 +476,9 @@ func determineImage(ctx context.Context, appName string, imageOrPath string) (im
 func determineMounts(ctx context.Context, mounts []api.MachineMount, region string) ([]api.MachineMount, error) {
 	unattachedVolumes := make(map[string][]api.Volume)
 
-	pathIndex := make(map[string]int)
-	for idx, m := range mounts {
-		pathIndex[m.Path] = idx
+	// the volume restored from a snapshot is mounted right before launching
+	if flag.GetString(ctx, "from-snapshot") != "" {
+		return mounts, nil
 	}
 
 	for _, v := range flag.GetStringSlice(ctx, "volume") {
@@ -472,16 +508,118 @@ func determineMounts(ctx context.Context, mounts []api.MachineMount, region stri
 			unattachedVolumes[volName] = unattachedVolumes[volName][1:]
 		}
 
-		if idx, found := pathIndex[mountPath]; found {
+		mounts = mountVolume(mounts, volID, mountPath)
+	}
+	return mounts, nil
+}
+
+// mountVolume returns mounts with the volume volID mounted at path, in place
+// of the volume mounted there if any.
+func mountVolume(mounts []api.MachineMount, volID, path string) []api.MachineMount {
+	for idx, m := range mounts {
+		if m.Path == path {
 			mounts[idx].Volume = volID
-		} else {
-			mounts = append(mounts, api.MachineMount{
-				Volume: volID,
-				Path:   mountPath,
-			})
+			return mounts
 		}
 	}
-	return mounts, nil
+
+	return append(mounts, api.MachineMount{
+		Volume: volID,
+		Path:   path,
+	})
+}
+
+// parseSnapshotVolume returns the name and mount path of the volume restored
+// from a snapshot, which is the only one of volumes, passed with --volume.
+func parseSnapshotVolume(volumes []string) (name, path string, err error) {
+	if len(volumes) != 1 {
+		return "", "", errors.New("--from-snapshot requires exactly one volume passed with --volume")
+	}
+
+	splittedIDDestOpts := strings.Split(volumes[0], ":")
+	if len(splittedIDDestOpts) < 2 {
+		return "", "", fmt.Errorf("Can't infer volume and mount path from '%s'", volumes[0])
+	}
+	name, path = splittedIDDestOpts[0], splittedIDDestOpts[1]
+
+	if strings.HasPrefix(name, "vol_") {
+		return "", "", fmt.Errorf("--from-snapshot creates a new volume, pass a volume name instead of '%s'", name)
+	}
+	return name, path, nil
+}
+
+// restoreVolume creates a volume named volName from a snapshot of one of the
+// app's volumes with that name. snapshot is either a snapshot ID or "last".
+func restoreVolume(ctx context.Context, volName, snapshot, regionCode string) (*api.Volume, error) {
+	var (
+		appName   = appconfig.NameFromContext(ctx)
+		apiclient = client.FromContext(ctx).API()
+		io        = iostreams.FromContext(ctx)
+		colorize  = io.ColorScheme()
+	)
+
+	app, err := apiclient.GetAppBasic(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+
+	if regionCode == "" {
+		region, err := apiclient.GetNearestRegion(ctx)
+		if err != nil {
+			return nil, err
+		}
+		regionCode = region.Code
+	}
+
+	volumes, err := apiclient.GetVolumes(ctx, appName)
+	if err != nil {
+		return nil, fmt.Errorf("Error fetching application volumes: %w", err)
+	}
+
+	var (
+		source *api.Volume
+		found  *api.Snapshot
+	)
+	for _, v := range lo.Filter(volumes, func(v api.Volume, _ int) bool { return v.Name == volName }) {
+		v := v
+
+		snapshots, err := apiclient.GetVolumeSnapshots(ctx, v.ID)
+		if err != nil {
+			return nil, fmt.Errorf("Error fetching snapshots of volume %s: %w", v.ID, err)
+		}
+
+		for _, s := range snapshots {
+			s := s
+
+			if (snapshot == "last" && (found == nil || s.CreatedAt.After(found.CreatedAt))) || s.ID == snapshot {
+				source, found = &v, &s
+			}
+		}
+	}
+
+	if found == nil {
+		if snapshot == "last" {
+			return nil, fmt.Errorf("no snapshots of volumes named '%s' found", volName)
+		}
+		return nil, fmt.Errorf("snapshot %s not found among the snapshots of volumes named '%s'", snapshot, volName)
+	}
+
+	fmt.Fprintf(io.Out, "Creating volume %s from snapshot %s of %s\n", colorize.Bold(volName), colorize.Bold(found.ID), colorize.Bold(source.ID))
+
+	vol, err := apiclient.CreateVolume(ctx, api.CreateVolumeInput{
+		AppID:             app.ID,
+		Name:              volName,
+		Region:            regionCode,
+		SizeGb:            source.SizeGb,
+		Encrypted:         source.Encrypted,
+		SnapshotID:        api.StringPointer(found.ID),
+		RequireUniqueZone: false,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed creating volume: %w", err)
+	}
+
+	return vol, nil
 }
 
 func getUnattachedVolumes(ctx context.Context, regionCode string) (map[string][]api.Volume, error) {
@@ -519,9 +657,15 @@ func determineServices(ctx context.Context) ([]api.MachineService, error) {
 		return []api.MachineService{}, nil
 	}
 
+	return parseServices(ports)
+}
+
+// parseServices translates port mappings, in the format of --port, to a
+// service each.
+func parseServices(ports []string) ([]api.MachineService, error) {
 	machineServices := make([]api.MachineService, len(ports))
 
-	for i, p := range flag.GetStringSlice(ctx, "port") {
+	for i, p := range ports {
 		proto := "tcp"
 		handlers := []string{}
 
@@ -681,8 +825,8 @@ func determineMachineConfig(ctx context.Context, initialMachineConf api.MachineC
 		machineConf.Env[k] = v
 	}
 
-	if flag.GetString(ctx, "schedule") != "" {
-		machineConf.Schedule = flag.GetString(ctx, "schedule")
+	if err := determineSchedule(ctx, machineConf); err != nil {
+		return machineConf, err
 	}
 
 	if command := flag.GetString(ctx, "command"); command != "" {
@@ -0,0 +1,51 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestParseSnapshotVolume(t *testing.T) {
+	for _, tc := range []struct {
+		volumes []string
+		name    string
+		path    string
+		err     string
+	}{
+		{volumes: []string{"data:/data"}, name: "data", path: "/data"},
+		{volumes: []string{"data:/data:rw"}, name: "data", path: "/data"},
+		{volumes: nil, err: "requires exactly one volume"},
+		{volumes: []string{"data:/data", "logs:/logs"}, err: "requires exactly one volume"},
+		{volumes: []string{"data"}, err: "Can't infer volume and mount path"},
+		{volumes: []string{"vol_123:/data"}, err: "pass a volume name instead of 'vol_123'"},
+	} {
+		name, path, err := parseSnapshotVolume(tc.volumes)
+		if tc.err != "" {
+			assert.ErrorContains(t, err, tc.err, "%v", tc.volumes)
+			continue
+		}
+		require.NoError(t, err, "%v", tc.volumes)
+		assert.Equal(t, tc.name, name)
+		assert.Equal(t, tc.path, path)
+	}
+}
+
+func TestMountVolume(t *testing.T) {
+	mounts := mountVolume(nil, "vol_restored", "/data")
+	assert.Equal(t, []api.MachineMount{{Volume: "vol_restored", Path: "/data"}}, mounts)
+
+	// the volume mounted at the path is replaced, keeping the mount settings
+	mounts = []api.MachineMount{
+		{Volume: "vol_logs", Path: "/logs"},
+		{Volume: "vol_old", Path: "/data", SizeGb: 10},
+	}
+	mounts = mountVolume(mounts, "vol_restored", "/data")
+	assert.Equal(t, []api.MachineMount{
+		{Volume: "vol_logs", Path: "/logs"},
+		{Volume: "vol_restored", Path: "/data", SizeGb: 10},
+	}, mounts)
+}
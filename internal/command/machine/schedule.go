@@ -0,0 +1,82 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/exp/slices"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/cron"
+	"github.com/superfly/flyctl/internal/flag"
+)
+
+// scheduleIntervals are the intervals scheduled machines can run at, besides
+// the times of a cron expression.
+var scheduleIntervals = []string{"hourly", "daily", "weekly", "monthly"}
+
+// determineSchedule sets the schedule of machineConf from --schedule and
+// --schedule-timezone.
+func determineSchedule(ctx context.Context, machineConf *api.MachineConfig) error {
+	return SetSchedule(machineConf, flag.GetString(ctx, "schedule"), flag.GetString(ctx, "schedule-timezone"))
+}
+
+// SetSchedule sets the schedule and timezone of machineConf when they're not
+// empty, and validates the result. Only cron expressions have a timezone.
+func SetSchedule(machineConf *api.MachineConfig, schedule, timezone string) error {
+	if schedule = strings.TrimSpace(schedule); schedule != "" {
+		machineConf.Schedule = schedule
+	}
+	if timezone != "" {
+		machineConf.ScheduleTimezone = timezone
+	}
+
+	switch {
+	case machineConf.Schedule == "":
+		if timezone != "" {
+			return fmt.Errorf("--schedule-timezone applies to machines with a --schedule")
+		}
+		return nil
+	case slices.Contains(scheduleIntervals, machineConf.Schedule):
+		if timezone != "" {
+			return fmt.Errorf("--schedule-timezone applies to cron expressions, not to %s intervals", machineConf.Schedule)
+		}
+		machineConf.ScheduleTimezone = ""
+		return nil
+	}
+
+	if _, err := cron.Parse(machineConf.Schedule); err != nil {
+		return fmt.Errorf("invalid --schedule, expected one of %s or a cron expression: %w", strings.Join(scheduleIntervals, ", "), err)
+	}
+	if machineConf.ScheduleTimezone != "" {
+		if _, err := time.LoadLocation(machineConf.ScheduleTimezone); err != nil {
+			return fmt.Errorf("invalid --schedule-timezone %s: %w", machineConf.ScheduleTimezone, err)
+		}
+	}
+
+	return nil
+}
+
+// DescribeSchedule describes when a machine with machineConf runs, along with
+// the next time it will after now for cron expressions.
+func DescribeSchedule(machineConf *api.MachineConfig, now time.Time) string {
+	s, err := cron.Parse(machineConf.Schedule)
+	if err != nil || slices.Contains(scheduleIntervals, machineConf.Schedule) {
+		return machineConf.Schedule
+	}
+
+	loc := time.UTC
+	if machineConf.ScheduleTimezone != "" {
+		if loc, err = time.LoadLocation(machineConf.ScheduleTimezone); err != nil {
+			return machineConf.Schedule
+		}
+	}
+
+	desc := fmt.Sprintf("%s (%s)", s.Describe(), loc)
+	if next := s.Next(now.In(loc)); !next.IsZero() {
+		desc += fmt.Sprintf(", next at %s", next.Format("2006-01-02 15:04 MST"))
+	}
+	return desc
+}
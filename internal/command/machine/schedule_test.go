@@ -0,0 +1,47 @@
+package machine
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestSetSchedule(t *testing.T) {
+	conf := &api.MachineConfig{}
+	require.NoError(t, SetSchedule(conf, "", ""))
+	assert.Empty(t, conf.Schedule)
+
+	require.NoError(t, SetSchedule(conf, "0 8 * * mon-fri", "Europe/Paris"))
+	assert.Equal(t, "0 8 * * mon-fri", conf.Schedule)
+	assert.Equal(t, "Europe/Paris", conf.ScheduleTimezone)
+
+	// updating the expression keeps the timezone, and an interval drops it
+	require.NoError(t, SetSchedule(conf, "30 9 * * *", ""))
+	assert.Equal(t, "Europe/Paris", conf.ScheduleTimezone)
+	require.NoError(t, SetSchedule(conf, "daily", ""))
+	assert.Empty(t, conf.ScheduleTimezone)
+
+	assert.ErrorContains(t, SetSchedule(conf, "", "UTC"), "applies to cron expressions, not to daily intervals")
+	assert.ErrorContains(t, SetSchedule(&api.MachineConfig{}, "", "UTC"), "applies to machines with a --schedule")
+	assert.ErrorContains(t, SetSchedule(conf, "biweekly", ""), "expected one of hourly, daily, weekly, monthly or a cron expression")
+	assert.ErrorContains(t, SetSchedule(conf, "0 8 * * *", "Mars/Olympus"), "invalid --schedule-timezone Mars/Olympus")
+}
+
+func TestDescribeSchedule(t *testing.T) {
+	// a Friday
+	now := time.Date(2023, 5, 5, 12, 0, 0, 0, time.UTC)
+
+	assert.Equal(t, "daily", DescribeSchedule(&api.MachineConfig{Schedule: "daily"}, now))
+	assert.Equal(t,
+		"at 08:00 on Monday through Friday (UTC), next at 2023-05-08 08:00 UTC",
+		DescribeSchedule(&api.MachineConfig{Schedule: "0 8 * * mon-fri"}, now),
+	)
+	assert.Equal(t,
+		"at 18:00 (Europe/Paris), next at 2023-05-05 18:00 CEST",
+		DescribeSchedule(&api.MachineConfig{Schedule: "0 18 * * *", ScheduleTimezone: "Europe/Paris"}, now),
+	)
+}
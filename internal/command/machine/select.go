@@ -65,12 +65,18 @@ func selectManyMachines(ctx context.Context, machineIDs []string) ([]*api.Machin
 	}
 
 	var machines []*api.Machine
-	if flag.GetBool(ctx, "select") {
+	switch {
+	case flag.GetBool(ctx, "all"):
+		machines, err = selectAllMachines(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+	case flag.GetBool(ctx, "select"):
 		machines, err = promptForManyMachines(ctx)
 		if err != nil {
 			return nil, nil, err
 		}
-	} else {
+	default:
 		flapsClient := flaps.FromContext(ctx)
 		for _, machineID := range machineIDs {
 			machine, err := flapsClient.Get(ctx, machineID)
@@ -213,14 +219,23 @@ func rewriteMachineNotFoundErrors(ctx context.Context, err error, machineID stri
 
 func checkSelectCmdline(ctx context.Context, haveMachineIDs bool) error {
 	haveSelectFlag := flag.GetBool(ctx, "select")
+	haveAllFlag := flag.GetBool(ctx, "all")
+	haveFilters := flag.FromContext(ctx).Lookup("all") != nil &&
+		(flag.IsSpecified(ctx, "region") || flag.IsSpecified(ctx, "process-group") || flag.IsSpecified(ctx, "metadata"))
 	appName := appconfig.NameFromContext(ctx)
 	switch {
 	case haveSelectFlag && haveMachineIDs:
 		return errors.New("machine IDs can't be used with --select")
-	case !haveSelectFlag && !haveMachineIDs:
-		return errors.New("a machine ID must be provided unless --select is used")
+	case haveAllFlag && (haveSelectFlag || haveMachineIDs):
+		return errors.New("machine IDs and --select can't be used with --all")
+	case !haveAllFlag && !haveSelectFlag && !haveMachineIDs:
+		return errors.New("a machine ID must be provided unless --select or --all is used")
+	case !haveAllFlag && haveFilters:
+		return errors.New("--region, --process-group and --metadata filter the machines of --all")
 	case haveSelectFlag && appName == "":
 		return errors.New("an app name must be specified to use --select")
+	case haveAllFlag && appName == "":
+		return errors.New("an app name must be specified to use --all")
 	default:
 		return nil
 	}
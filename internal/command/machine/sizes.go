@@ -0,0 +1,301 @@
+package machine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/metrics"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newSizes() *cobra.Command {
+	const (
+		short = "Commands that size machines"
+		long  = short + "\n"
+		usage = "sizes <command>"
+	)
+
+	cmd := command.New(usage, short, long, nil)
+
+	cmd.Args = cobra.NoArgs
+
+	cmd.AddCommand(
+		newSizesRecommend(),
+	)
+
+	return cmd
+}
+
+func newSizesRecommend() *cobra.Command {
+	const (
+		short = "Recommend a VM size for a machine from its metrics"
+		long  = short + `
+
+The peak memory and CPU usage of the machine over --window are read from the
+metrics of its organization, and the smallest VM size of its CPU kind which
+fits them, with --headroom on top, is recommended. Shared CPUs are counted as
+the full cores they may burst to.
+
+With --apply, the machine is resized to the recommendation and its health
+checks are waited for; it's resized back when they fail.
+`
+		usage = "recommend"
+	)
+
+	cmd := command.New(usage, short, long, runSizesRecommend,
+		command.RequireSession,
+		command.LoadAppNameIfPresent,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(
+		cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+		flag.String{
+			Name:        "from-metrics",
+			Description: "The ID of the machine whose metrics to size from",
+		},
+		flag.String{
+			Name:        "window",
+			Description: "How far back to look for the peak usage of the machine, such as 24h",
+			Default:     "168h",
+		},
+		flag.Int{
+			Name:        "headroom",
+			Description: "The percentage of the peak usage to add on top of it",
+			Default:     25,
+		},
+		flag.Bool{
+			Name:        "apply",
+			Description: "Resize the machine to the recommended size, waiting for its health checks",
+		},
+	)
+
+	return cmd
+}
+
+// sizeRecommendation is the VM size recommended for a machine.
+type sizeRecommendation struct {
+	MachineID    string            `json:"machine_id"`
+	Window       string            `json:"window"`
+	PeakMemoryMB int               `json:"peak_memory_mb"`
+	PeakCPUs     float64           `json:"peak_cpus"`
+	Current      *api.MachineGuest `json:"current"`
+	Recommended  *api.MachineGuest `json:"recommended"`
+}
+
+func runSizesRecommend(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		machineID = flag.GetString(ctx, "from-metrics")
+		headroom  = flag.GetInt(ctx, "headroom")
+	)
+
+	if machineID == "" {
+		return errors.New("--from-metrics must be set to the ID of the machine to size")
+	}
+	if headroom < 0 {
+		return errors.New("--headroom can't be negative")
+	}
+	window, err := time.ParseDuration(flag.GetString(ctx, "window"))
+	if err != nil || window < time.Minute {
+		return fmt.Errorf("invalid --window %s, expected a duration of at least 1m such as 24h", flag.GetString(ctx, "window"))
+	}
+
+	machine, ctx, err := selectOneMachine(ctx, nil, machineID, true)
+	if err != nil {
+		return err
+	}
+	appName := appconfig.NameFromContext(ctx)
+
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	peakMemoryMB, peakCPUs, err := machinePeaks(ctx, metrics.New(ctx, app.Organization.Slug), appName, machine.ID, window)
+	if err != nil {
+		return err
+	}
+
+	current := machine.Config.Guest
+	if current == nil {
+		current = api.MachinePresets["shared-cpu-1x"]
+	}
+	recommended, err := recommendGuest(current, peakMemoryMB, peakCPUs, float64(headroom)/100)
+	if err != nil {
+		return err
+	}
+
+	recommendation := sizeRecommendation{
+		MachineID:    machine.ID,
+		Window:       window.String(),
+		PeakMemoryMB: int(math.Ceil(peakMemoryMB)),
+		PeakCPUs:     math.Round(peakCPUs*100) / 100,
+		Current:      current,
+		Recommended:  recommended,
+	}
+	if config.FromContext(ctx).JSONOutput && !flag.GetBool(ctx, "apply") {
+		return render.JSON(io.Out, recommendation)
+	}
+
+	rows := [][]string{
+		{"Current", describeGuest(current), ""},
+		{"Peak usage", fmt.Sprintf("%.2f CPUs, %d MB", recommendation.PeakCPUs, recommendation.PeakMemoryMB), "over " + recommendation.Window},
+		{"Recommended", describeGuest(recommended), fmt.Sprintf("%d%% headroom", headroom)},
+	}
+	if err := render.Table(io.Out, "", rows, "", "Size", ""); err != nil {
+		return err
+	}
+
+	if sameSize(current, recommended) {
+		fmt.Fprintf(io.Out, "Machine %s is already right-sized\n", machine.ID)
+		return nil
+	}
+	if !flag.GetBool(ctx, "apply") {
+		fmt.Fprintf(io.Out, "Resize machine %s to the recommendation with --apply\n", machine.ID)
+		return nil
+	}
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirmf(ctx, "Resize machine %s to %s? It restarts.", machine.ID, describeGuest(recommended)); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	return resizeMachine(ctx, machine, recommended)
+}
+
+// machinePeaks returns the peak memory, in megabytes, and CPU usage, in cores,
+// of the machine over window.
+func machinePeaks(ctx context.Context, client *metrics.Client, appName, machineID string, window time.Duration) (memoryMB, cpus float64, err error) {
+	var (
+		selector = fmt.Sprintf("app=%q,instance=%q", appName, machineID)
+		rng      = fmt.Sprintf("[%ds:1m]", int(window.Seconds()))
+	)
+
+	memory, err := client.Max(ctx, fmt.Sprintf("max_over_time((fly_instance_memory_mem_total{%s} - fly_instance_memory_mem_available{%s})%s)", selector, selector, rng))
+	if errors.Is(err, metrics.ErrNoData) {
+		return 0, 0, fmt.Errorf("machine %s has no metrics over the last %s, let it run for a while first", machineID, window)
+	}
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// fly_instance_cpu counts centiseconds
+	cpu, err := client.Max(ctx, fmt.Sprintf(`max_over_time(sum(rate(fly_instance_cpu{%s,mode!="idle"}[1m]))%s) / 100`, selector, rng))
+	if err != nil && !errors.Is(err, metrics.ErrNoData) {
+		return 0, 0, err
+	}
+
+	return memory / (1024 * 1024), cpu, nil
+}
+
+// recommendGuest returns the smallest guest of the CPU kind of current fitting
+// the peak memory, in megabytes, and CPU usage, in cores, with the headroom
+// fraction on top of them. Memory is raised in 256MB increments above the
+// preset of the number of CPUs picked.
+func recommendGuest(current *api.MachineGuest, peakMemoryMB, peakCPUs, headroom float64) (*api.MachineGuest, error) {
+	kind := lo.Ternary(current.CPUKind == "", "shared", current.CPUKind)
+
+	presets := lo.Filter(lo.Values(api.MachinePresets), func(g *api.MachineGuest, _ int) bool { return g.CPUKind == kind })
+	sort.Slice(presets, func(i, j int) bool { return presets[i].CPUs < presets[j].CPUs })
+
+	var (
+		neededMemoryMB = int(math.Ceil(peakMemoryMB*(1+headroom)/256)) * 256
+		neededCPUs     = peakCPUs * (1 + headroom)
+	)
+
+	for _, preset := range presets {
+		if float64(preset.CPUs) < neededCPUs {
+			continue
+		}
+
+		maxMemoryMB := preset.CPUs * lo.Ternary(kind == "shared", api.MAX_MEMORY_MB_PER_SHARED_CPU, api.MAX_MEMORY_MB_PER_CPU)
+		memoryMB := lo.Max([]int{preset.MemoryMB, neededMemoryMB})
+		if memoryMB > maxMemoryMB {
+			continue
+		}
+
+		return &api.MachineGuest{CPUKind: kind, CPUs: preset.CPUs, MemoryMB: memoryMB}, nil
+	}
+
+	return nil, fmt.Errorf("no %s VM size fits a peak usage of %.2f CPUs and %.0f MB with %.0f%% headroom", kind, peakCPUs, peakMemoryMB, headroom*100)
+}
+
+func sameSize(a, b *api.MachineGuest) bool {
+	return a.CPUKind == b.CPUKind && a.CPUs == b.CPUs && a.MemoryMB == b.MemoryMB
+}
+
+// describeGuest describes guest as its preset and memory, such as
+// shared-cpu-1x, 512 MB.
+func describeGuest(guest *api.MachineGuest) string {
+	kind := lo.Ternary(guest.CPUKind == "shared", "shared-cpu", guest.CPUKind)
+	return fmt.Sprintf("%s-%dx, %d MB", kind, guest.CPUs, guest.MemoryMB)
+}
+
+// resizeMachine updates the guest of machine, waiting for its health checks,
+// and puts the previous guest back when they fail.
+func resizeMachine(ctx context.Context, machine *api.Machine, guest *api.MachineGuest) error {
+	io := iostreams.FromContext(ctx)
+
+	machine, releaseLeaseFunc, err := mach.AcquireLease(ctx, machine)
+	defer releaseLeaseFunc(ctx, machine)
+	if err != nil {
+		return err
+	}
+
+	previous := mach.CloneConfig(machine.Config)
+	config := mach.CloneConfig(machine.Config)
+	resized := *guest
+	if config.Guest != nil {
+		resized.KernelArgs = config.Guest.KernelArgs
+	}
+	config.Guest = &resized
+
+	input := &api.LaunchMachineInput{
+		ID:     machine.ID,
+		AppID:  appconfig.NameFromContext(ctx),
+		Name:   machine.Name,
+		Region: machine.Region,
+		Config: config,
+	}
+	if err := mach.Update(ctx, machine, input); err != nil {
+		fmt.Fprintf(io.ErrOut, "Resizing machine %s failed, resizing it back: %v\n", machine.ID, err)
+
+		input.Config = previous
+		input.SkipHealthChecks = true
+		if rollbackErr := mach.Update(ctx, machine, input); rollbackErr != nil {
+			return fmt.Errorf("failed resizing machine %s back after %v: %w", machine.ID, err, rollbackErr)
+		}
+		return fmt.Errorf("failed resizing machine %s, it was resized back: %w", machine.ID, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestRecommendGuest(t *testing.T) {
+	type testcase struct {
+		name        string
+		current     *api.MachineGuest
+		memoryMB    float64
+		cpus        float64
+		recommended *api.MachineGuest
+	}
+
+	cases := []testcase{
+		{
+			name:        "overprovisioned shared machine",
+			current:     &api.MachineGuest{CPUKind: "shared", CPUs: 4, MemoryMB: 2048},
+			memoryMB:    150,
+			cpus:        0.3,
+			recommended: &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 256},
+		},
+		{
+			name:        "memory above the preset",
+			current:     &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 1024},
+			memoryMB:    500,
+			cpus:        0.1,
+			recommended: &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 768},
+		},
+		{
+			name:        "memory above the maximum of a CPU",
+			current:     &api.MachineGuest{CPUKind: "shared", CPUs: 8, MemoryMB: 8192},
+			memoryMB:    3000,
+			cpus:        0.5,
+			recommended: &api.MachineGuest{CPUKind: "shared", CPUs: 2, MemoryMB: 3840},
+		},
+		{
+			name:        "CPU bound performance machine",
+			current:     &api.MachineGuest{CPUKind: "performance", CPUs: 16, MemoryMB: 32768},
+			memoryMB:    1000,
+			cpus:        2.5,
+			recommended: &api.MachineGuest{CPUKind: "performance", CPUs: 4, MemoryMB: 8192},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			recommended, err := recommendGuest(tc.current, tc.memoryMB, tc.cpus, 0.25)
+			require.NoError(t, err)
+			assert.Equal(t, tc.recommended, recommended)
+		})
+	}
+
+	_, err := recommendGuest(&api.MachineGuest{CPUKind: "shared", CPUs: 1}, 20000, 1, 0.25)
+	assert.EqualError(t, err, "no shared VM size fits a peak usage of 1.00 CPUs and 20000 MB with 25% headroom")
+
+	assert.Equal(t, "shared-cpu-1x, 768 MB", describeGuest(&api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 768}))
+	assert.Equal(t, "performance-2x, 4096 MB", describeGuest(api.MachinePresets["performance-2x"]))
+}
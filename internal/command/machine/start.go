@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/flaps"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
@@ -32,6 +33,7 @@ func newStart() *cobra.Command {
 		flag.AppConfig(),
 		selectFlag,
 	)
+	flag.Add(cmd, bulkFlags...)
 
 	return cmd
 }
@@ -42,18 +44,18 @@ func runMachineStart(ctx context.Context) (err error) {
 		args = flag.Args(ctx)
 	)
 
-	machineIDs, ctx, err := selectManyMachineIDs(ctx, args)
+	machines, ctx, err := selectManyMachines(ctx, args)
 	if err != nil {
 		return err
 	}
 
-	for _, machineID := range machineIDs {
-		if err = Start(ctx, machineID); err != nil {
-			return
+	return runBulk(ctx, machines, "start", func(ctx context.Context, m *api.Machine) error {
+		if err := Start(ctx, m.ID); err != nil {
+			return err
 		}
-		fmt.Fprintf(io.Out, "%s has been started\n", machineID)
-	}
-	return
+		fmt.Fprintf(io.Out, "%s has been started\n", m.ID)
+		return nil
+	})
 }
 
 func Start(ctx context.Context, machineID string) (err error) {
@@ -85,6 +85,11 @@ func runMachineStatus(ctx context.Context) (err error) {
 		obj[0] = append(obj[0], machine.Config.Mounts[0].Volume)
 	}
 
+	if machine.Config.Schedule != "" {
+		cols = append(cols, "Schedule")
+		obj[0] = append(obj[0], DescribeSchedule(machine.Config, time.Now()))
+	}
+
 	if err = render.VerticalTable(io.Out, "VM", obj, cols...); err != nil {
 		return
 	}
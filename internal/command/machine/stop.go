@@ -33,6 +33,7 @@ func newStop() *cobra.Command {
 		flag.AppConfig(),
 		selectFlag,
 	)
+	flag.Add(cmd, bulkFlags...)
 
 	return cmd
 }
@@ -43,20 +44,20 @@ func runMachineStop(ctx context.Context) (err error) {
 		args = flag.Args(ctx)
 	)
 
-	machineIDs, ctx, err := selectManyMachineIDs(ctx, args)
+	machines, ctx, err := selectManyMachines(ctx, args)
 	if err != nil {
 		return err
 	}
 
-	for _, machineID := range machineIDs {
-		fmt.Fprintf(io.Out, "Sending kill signal to machine %s...\n", machineID)
+	return runBulk(ctx, machines, "stop", func(ctx context.Context, m *api.Machine) error {
+		fmt.Fprintf(io.Out, "Sending kill signal to machine %s...\n", m.ID)
 
-		if err = Stop(ctx, machineID); err != nil {
-			return
+		if err := Stop(ctx, m.ID); err != nil {
+			return err
 		}
-		fmt.Fprintf(io.Out, "%s has been successfully stopped\n", machineID)
-	}
-	return
+		fmt.Fprintf(io.Out, "%s has been successfully stopped\n", m.ID)
+		return nil
+	})
 }
 
 func Stop(ctx context.Context, machineID string) (err error) {
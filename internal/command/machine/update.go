@@ -3,14 +3,17 @@ package machine
 import (
 	"context"
 	"fmt"
+	"strconv"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/exp/slices"
 
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/iostreams"
 
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/dotenv"
 	"github.com/superfly/flyctl/internal/flag"
 	mach "github.com/superfly/flyctl/internal/machine"
 	"github.com/superfly/flyctl/internal/watch"
@@ -19,7 +22,15 @@ import (
 func newUpdate() *cobra.Command {
 	const (
 		short = "Update a machine"
-		long  = short + "\n"
+		long  = short + `
+
+Flags change only the parts of the config they name: --env merges with the
+machine's environment, as does --env-from-file, while --add-port and
+--remove-port edit its services without restating them. --port replaces all
+of its services.
+
+Use --dry-run to preview the resulting changes.
+`
 
 		usage = "update <machine_id>"
 	)
@@ -27,6 +38,7 @@ func newUpdate() *cobra.Command {
 	cmd := command.New(usage, short, long, runUpdate,
 		command.RequireSession,
 		command.LoadAppNameIfPresent,
+		command.RequireCompatibleVersion("machine update"),
 	)
 
 	flag.Add(
@@ -45,6 +57,26 @@ func newUpdate() *cobra.Command {
 			Shorthand:   "C",
 			Description: "Command to run",
 		},
+		flag.String{
+			Name:        "env-from-file",
+			Description: "Merge the NAME=VALUE pairs of a .env file into the machine's environment. --env takes precedence",
+		},
+		flag.StringSlice{
+			Name:        "remove-env",
+			Description: "Remove an environment variable from the machine. Can be specified multiple times.",
+		},
+		flag.StringSlice{
+			Name:        "add-port",
+			Description: "Expose a port mapping, in the format of --port, next to the machine's services. Can be specified multiple times.",
+		},
+		flag.StringSlice{
+			Name:        "remove-port",
+			Description: "Stop exposing an edge port of the machine's services. Can be specified multiple times.",
+		},
+		flag.Bool{
+			Name:        "dry-run",
+			Description: "Show the changes to the machine's config without applying them",
+		},
 	)
 
 	cmd.Args = cobra.RangeArgs(0, 1)
@@ -59,8 +91,6 @@ func runUpdate(ctx context.Context) (err error) {
 
 		autoConfirm      = flag.GetBool(ctx, "yes")
 		skipHealthChecks = flag.GetBool(ctx, "skip-health-checks")
-		image            = flag.GetString(ctx, "image")
-		dockerfile       = flag.GetString(ctx, flag.Dockerfile().Name)
 	)
 
 	machineID := flag.FirstArg(ctx)
@@ -71,6 +101,20 @@ func runUpdate(ctx context.Context) (err error) {
 	}
 	appName := appconfig.NameFromContext(ctx)
 
+	if flag.GetBool(ctx, "dry-run") {
+		machineConf, err := updatedMachineConfig(ctx, machine, appName)
+		if err != nil {
+			return err
+		}
+		diff := mach.ConfigDiff(ctx, *machine.Config, *machineConf)
+		if diff == "" {
+			fmt.Fprintf(io.Out, "No changes to apply to machine %s\n", machine.ID)
+			return nil
+		}
+		fmt.Fprintf(io.Out, "Configuration changes which would be applied to machine: %s (%s)\n\n%s\n", colorize.Bold(machine.ID), colorize.Bold(machine.Name), diff)
+		return nil
+	}
+
 	// Acquire lease
 	machine, releaseLeaseFunc, err := mach.AcquireLease(ctx, machine)
 	defer releaseLeaseFunc(ctx, machine)
@@ -78,22 +122,8 @@ func runUpdate(ctx context.Context) (err error) {
 		return err
 	}
 
-	var imageOrPath string
-
-	if image != "" {
-		imageOrPath = image
-	} else if dockerfile != "" {
-		imageOrPath = "."
-	} else {
-		imageOrPath = machine.FullImageRef()
-	}
-
-	if imageOrPath == "" {
-		return fmt.Errorf("failed to resolve machine image")
-	}
-
 	// Identify configuration changes
-	machineConf, err := determineMachineConfig(ctx, *machine.Config, appName, imageOrPath, machine.Region)
+	machineConf, err := updatedMachineConfig(ctx, machine, appName)
 	if err != nil {
 		return err
 	}
@@ -136,3 +166,154 @@ func runUpdate(ctx context.Context) (err error) {
 
 	return nil
 }
+
+// updatedMachineConfig applies the flags to the config of machine.
+func updatedMachineConfig(ctx context.Context, machine *api.Machine, appName string) (*api.MachineConfig, error) {
+	var imageOrPath string
+
+	if image := flag.GetString(ctx, "image"); image != "" {
+		imageOrPath = image
+	} else if flag.GetString(ctx, flag.Dockerfile().Name) != "" {
+		imageOrPath = "."
+	} else {
+		imageOrPath = machine.FullImageRef()
+	}
+
+	if imageOrPath == "" {
+		return nil, fmt.Errorf("failed to resolve machine image")
+	}
+
+	// the file is merged first, so that --env overrides it
+	initialConf := mach.CloneConfig(machine.Config)
+	if path := flag.GetString(ctx, "env-from-file"); path != "" {
+		env, err := dotenv.ParseFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if initialConf.Env == nil {
+			initialConf.Env = make(map[string]string, len(env))
+		}
+		for k, v := range env {
+			initialConf.Env[k] = v
+		}
+	}
+
+	machineConf, err := determineMachineConfig(ctx, *initialConf, appName, imageOrPath, machine.Region)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range flag.GetStringSlice(ctx, "remove-env") {
+		if _, ok := machineConf.Env[name]; !ok {
+			return nil, fmt.Errorf("machine %s has no environment variable %s", machine.ID, name)
+		}
+		delete(machineConf.Env, name)
+	}
+
+	if ports := flag.GetStringSlice(ctx, "remove-port"); len(ports) > 0 {
+		if machineConf.Services, err = removeServicePorts(machineConf.Services, ports); err != nil {
+			return nil, err
+		}
+	}
+
+	if ports := flag.GetStringSlice(ctx, "add-port"); len(ports) > 0 {
+		added, err := parseServices(ports)
+		if err != nil {
+			return nil, err
+		}
+		if machineConf.Services, err = addServicePorts(machineConf.Services, added); err != nil {
+			return nil, err
+		}
+	}
+
+	return machineConf, nil
+}
+
+// addServicePorts merges the ports of added into services. A port is added to
+// the service of its protocol and internal port when there's one, otherwise it
+// gets a service of its own. Edge ports which are already exposed are refused.
+func addServicePorts(services, added []api.MachineService) ([]api.MachineService, error) {
+	for _, a := range added {
+		for _, p := range a.Ports {
+			if exposed := exposedPort(services, a.Protocol, p); exposed != 0 {
+				return nil, fmt.Errorf("port %d/%s is already exposed", exposed, a.Protocol)
+			}
+		}
+
+		i := slices.IndexFunc(services, func(s api.MachineService) bool {
+			return s.Protocol == a.Protocol && s.InternalPort == a.InternalPort
+		})
+		if i < 0 {
+			services = append(services, a)
+			continue
+		}
+		services[i].Ports = append(services[i].Ports, a.Ports...)
+	}
+
+	return services, nil
+}
+
+// exposedPort returns an edge port of p which services already expose over
+// proto, zero when there's none.
+func exposedPort(services []api.MachineService, proto string, p api.MachinePort) int {
+	var edges []int
+	for _, port := range []*int{p.Port, p.StartPort, p.EndPort} {
+		if port != nil {
+			edges = append(edges, *port)
+		}
+	}
+
+	for _, s := range services {
+		if s.Protocol != proto {
+			continue
+		}
+		for _, existing := range s.Ports {
+			for _, edge := range edges {
+				if existing.ContainsPort(edge) {
+					return edge
+				}
+			}
+		}
+	}
+
+	return 0
+}
+
+// removeServicePorts stops exposing the given edge ports, dropping the
+// services left without any. Ports of a range can't be removed on their own.
+func removeServicePorts(services []api.MachineService, ports []string) ([]api.MachineService, error) {
+	for _, raw := range ports {
+		port, err := strconv.Atoi(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid port %s", raw)
+		}
+
+		var (
+			kept    []api.MachineService
+			removed bool
+		)
+		for _, s := range services {
+			var ports []api.MachinePort
+			for _, p := range s.Ports {
+				switch {
+				case p.Port != nil && *p.Port == port:
+					removed = true
+				case p.ContainsPort(port):
+					return nil, fmt.Errorf("port %d is part of a range, which can only be removed as a whole with --port", port)
+				default:
+					ports = append(ports, p)
+				}
+			}
+			if len(ports) > 0 || len(s.Ports) == 0 {
+				s.Ports = ports
+				kept = append(kept, s)
+			}
+		}
+		if !removed {
+			return nil, fmt.Errorf("port %d isn't exposed", port)
+		}
+		services = kept
+	}
+
+	return services, nil
+}
@@ -0,0 +1,74 @@
+package machine
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestAddServicePorts(t *testing.T) {
+	services := []api.MachineService{{
+		Protocol:     "tcp",
+		InternalPort: 8080,
+		Ports:        []api.MachinePort{{Port: api.IntPointer(443), Handlers: []string{"tls", "http"}}},
+	}}
+
+	added, err := parseServices([]string{"80:8080/tcp:http", "5432:5432/tcp"})
+	require.NoError(t, err)
+
+	services, err = addServicePorts(services, added)
+	require.NoError(t, err)
+	require.Len(t, services, 2)
+	assert.Len(t, services[0].Ports, 2)
+	assert.Equal(t, 80, *services[0].Ports[1].Port)
+	assert.Equal(t, 5432, services[1].InternalPort)
+
+	added, err = parseServices([]string{"443:9090/tcp"})
+	require.NoError(t, err)
+	_, err = addServicePorts(services, added)
+	assert.ErrorContains(t, err, "port 443/tcp is already exposed")
+
+	// the same edge port is free over another protocol
+	added, err = parseServices([]string{"443:9090/udp"})
+	require.NoError(t, err)
+	_, err = addServicePorts(services, added)
+	assert.NoError(t, err)
+}
+
+func TestRemoveServicePorts(t *testing.T) {
+	services := []api.MachineService{
+		{
+			Protocol:     "tcp",
+			InternalPort: 8080,
+			Ports: []api.MachinePort{
+				{Port: api.IntPointer(80)},
+				{Port: api.IntPointer(443)},
+			},
+		},
+		{
+			Protocol:     "tcp",
+			InternalPort: 5432,
+			Ports:        []api.MachinePort{{Port: api.IntPointer(5432)}},
+		},
+		{
+			Protocol:     "udp",
+			InternalPort: 9000,
+			Ports:        []api.MachinePort{{StartPort: api.IntPointer(9000), EndPort: api.IntPointer(9010)}},
+		},
+	}
+
+	updated, err := removeServicePorts(services, []string{"80", "5432"})
+	require.NoError(t, err)
+	require.Len(t, updated, 2)
+	assert.Equal(t, []api.MachinePort{{Port: api.IntPointer(443)}}, updated[0].Ports)
+	assert.Equal(t, "udp", updated[1].Protocol)
+
+	_, err = removeServicePorts(services, []string{"25"})
+	assert.ErrorContains(t, err, "port 25 isn't exposed")
+
+	_, err = removeServicePorts(services, []string{"9005"})
+	assert.ErrorContains(t, err, "part of a range")
+}
@@ -31,6 +31,10 @@ func New() (cmd *cobra.Command) {
 		flag.AppConfig(),
 	)
 
+	cmd.AddCommand(
+		newReleases(),
+	)
+
 	return
 }
 
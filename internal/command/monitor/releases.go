@@ -0,0 +1,242 @@
+package monitor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/azazeal/pause"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// releasesPerApp is the number of recent releases fetched for every app on
+// each poll.
+const releasesPerApp = 5
+
+func newReleases() (cmd *cobra.Command) {
+	const (
+		short = "Stream the deployments of every app in an organization"
+		long  = `Stream recent and in progress deployments across all the apps of an
+organization: who deployed which app, and how it went. Use Control-C to stop
+output.
+
+With --json every event is printed as a single line of JSON, which makes the
+feed easy to pipe into dashboards.`
+	)
+
+	cmd = command.New("releases", short, long, runReleases,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.String{
+			Name:        "interval",
+			Shorthand:   "i",
+			Default:     "10s",
+			Description: "Interval between polls for new deployments",
+		},
+	)
+
+	return
+}
+
+// ReleaseEvent reports a release which was first seen, or whose status
+// changed since it was last seen.
+type ReleaseEvent struct {
+	App         string
+	Version     int
+	Status      string
+	InProgress  bool
+	Reason      string
+	Description string
+	User        string
+	CreatedAt   time.Time
+}
+
+func runReleases(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		jsonOut   = config.FromContext(ctx).JSONOutput
+	)
+
+	interval, err := time.ParseDuration(flag.GetString(ctx, "interval"))
+	if err != nil {
+		return fmt.Errorf("invalid interval: %w", err)
+	}
+	if interval < time.Second {
+		interval = time.Second
+	}
+
+	org, err := prompt.Org(ctx)
+	if err != nil {
+		return err
+	}
+
+	if !jsonOut {
+		fmt.Fprintf(io.ErrOut, "Watching deployments in %s. Use Control-C to stop.\n", org.Slug)
+	}
+
+	f := newFeed()
+	for {
+		releases, err := fetchOrgReleases(ctx, apiClient, org.Slug)
+		switch {
+		case errors.Is(err, context.Canceled):
+			return nil
+		case err != nil:
+			return err
+		}
+
+		for _, event := range f.observe(releases) {
+			if jsonOut {
+				err = json.NewEncoder(io.Out).Encode(event)
+			} else {
+				err = printEvent(io, event)
+			}
+			if err != nil {
+				return err
+			}
+		}
+
+		if pause.For(ctx, interval); ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// fetchOrgReleases returns the recent releases of every app in the org,
+// keyed by app name.
+func fetchOrgReleases(ctx context.Context, apiClient *api.Client, orgSlug string) (map[string][]api.Release, error) {
+	apps, err := apiClient.GetApps(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed listing apps: %w", err)
+	}
+
+	var (
+		mu       sync.Mutex
+		releases = map[string][]api.Release{}
+	)
+
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(8)
+
+	for _, app := range apps {
+		if app.Organization.Slug != orgSlug {
+			continue
+		}
+
+		app := app
+		eg.Go(func() (err error) {
+			var r []api.Release
+			if app.PlatformVersion == appconfig.MachinesPlatform {
+				r, err = apiClient.GetAppReleasesMachines(ctx, app.Name, releasesPerApp)
+			} else {
+				r, err = apiClient.GetAppReleasesNomad(ctx, app.Name, releasesPerApp)
+			}
+			if err != nil {
+				return fmt.Errorf("failed retrieving releases of %s: %w", app.Name, err)
+			}
+
+			mu.Lock()
+			releases[app.Name] = r
+			mu.Unlock()
+
+			return nil
+		})
+	}
+
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+
+	return releases, nil
+}
+
+// feed tracks the releases seen so far.
+type feed struct {
+	statuses map[string]string
+}
+
+func newFeed() *feed {
+	return &feed{statuses: map[string]string{}}
+}
+
+// observe returns, oldest first, the events for the releases which are new or
+// whose status changed since the previous call.
+func (f *feed) observe(releases map[string][]api.Release) (events []ReleaseEvent) {
+	for app, rs := range releases {
+		for _, r := range rs {
+			if status, seen := f.statuses[r.ID]; seen && status == r.Status {
+				continue
+			}
+			f.statuses[r.ID] = r.Status
+
+			events = append(events, ReleaseEvent{
+				App:         app,
+				Version:     r.Version,
+				Status:      r.Status,
+				InProgress:  r.InProgress,
+				Reason:      r.Reason,
+				Description: r.Description,
+				User:        r.User.Email,
+				CreatedAt:   r.CreatedAt,
+			})
+		}
+	}
+
+	sort.SliceStable(events, func(i, j int) bool {
+		if !events[i].CreatedAt.Equal(events[j].CreatedAt) {
+			return events[i].CreatedAt.Before(events[j].CreatedAt)
+		}
+		return events[i].App < events[j].App
+	})
+
+	return
+}
+
+func printEvent(io *iostreams.IOStreams, event ReleaseEvent) error {
+	colorize := io.ColorScheme()
+
+	status := event.Status
+	switch {
+	case event.InProgress:
+		status = colorize.Yellow(status)
+	case status == "complete" || status == "succeeded":
+		status = colorize.Green(status)
+	case status == "failed" || status == "cancelled":
+		status = colorize.Red(status)
+	}
+
+	user := event.User
+	if user == "" {
+		user = "unknown"
+	}
+
+	_, err := fmt.Fprintf(io.Out, "%s  %s v%d  %s  %s  %s\n",
+		event.CreatedAt.Local().Format(time.RFC3339),
+		colorize.Bold(event.App),
+		event.Version,
+		status,
+		user,
+		event.Description,
+	)
+
+	return err
+}
@@ -0,0 +1,31 @@
+package monitor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestFeedObserve(t *testing.T) {
+	now := time.Now()
+	f := newFeed()
+
+	events := f.observe(map[string][]api.Release{
+		"web":    {{ID: "r2", Version: 2, Status: "running", InProgress: true, CreatedAt: now}},
+		"worker": {{ID: "r1", Version: 7, Status: "complete", CreatedAt: now.Add(-time.Minute)}},
+	})
+	assert.Len(t, events, 2)
+	assert.Equal(t, "worker", events[0].App)
+	assert.Equal(t, "web", events[1].App)
+
+	events = f.observe(map[string][]api.Release{
+		"web":    {{ID: "r2", Version: 2, Status: "complete", CreatedAt: now}},
+		"worker": {{ID: "r1", Version: 7, Status: "complete", CreatedAt: now.Add(-time.Minute)}},
+	})
+	assert.Len(t, events, 1)
+	assert.Equal(t, "complete", events[0].Status)
+	assert.Equal(t, 2, events[0].Version)
+}
@@ -39,6 +39,7 @@ Organization admins can also invite or remove users from Organizations.
 		newRemove(),
 		newCreate(),
 		newDelete(),
+		newQuota(),
 		appsv2.New(),
 	)
 
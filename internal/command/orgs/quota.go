@@ -0,0 +1,215 @@
+package orgs
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// quotaWarnPercent is the share of a limit past which quota warns about it.
+const quotaWarnPercent = 80
+
+// quotaResources are the resources organizations have limits on, in the order
+// they're shown.
+var quotaResources = []string{
+	api.OrganizationLimitApps,
+	api.OrganizationLimitMachines,
+	api.OrganizationLimitVolumes,
+	api.OrganizationLimitIPAddresses,
+	api.OrganizationLimitConcurrentBuilds,
+}
+
+func newQuota() *cobra.Command {
+	const (
+		long = `Shows the limits of an organization on the number of apps, machines,
+volumes, IP addresses and concurrent builds, and how much of each it uses.
+Limits close to being reached are warned about, raise them with
+fly orgs quota request before a rollout runs into them.
+`
+		short = "Show the limits of an organization and its usage of them"
+		usage = "quota [slug]"
+	)
+
+	cmd := command.New(usage, short, long, runQuota,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	cmd.AddCommand(
+		newQuotaRequest(),
+	)
+
+	return cmd
+}
+
+func newQuotaRequest() *cobra.Command {
+	const (
+		long = `Requests an increase of the limit of an organization on a resource, one
+of apps, machines, volumes, ip_addresses or concurrent_builds. Requests are
+reviewed by Fly, which follows up by email.
+`
+		short = "Request an increase of a limit of an organization"
+		usage = "request <resource> <limit>"
+	)
+
+	cmd := command.New(usage, short, long, runQuotaRequest,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.ExactArgs(2)
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.String{
+			Name:        "reason",
+			Description: "What the increased limit is needed for",
+		},
+	)
+
+	return cmd
+}
+
+func runQuota(ctx context.Context) error {
+	client := client.FromContext(ctx).API()
+	org, err := OrgFromFirstArgOrSelect(ctx)
+	if err != nil {
+		return err
+	}
+
+	limits, err := client.GetOrganizationLimits(ctx, org.Slug)
+	if err != nil {
+		return fmt.Errorf("failed retrieving limits of organization %s: %w", org.Slug, err)
+	}
+	limits = sortLimits(limits)
+
+	io := iostreams.FromContext(ctx)
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, limits)
+	}
+
+	rows := make([][]string, 0, len(limits))
+	for _, l := range limits {
+		limit, used := "unlimited", "-"
+		if l.Limit > 0 {
+			limit = strconv.Itoa(l.Limit)
+			used = fmt.Sprintf("%d%%", usedPercent(l))
+		}
+		rows = append(rows, []string{l.Resource, strconv.Itoa(l.Usage), limit, used})
+	}
+	if err := render.Table(io.Out, fmt.Sprintf("Limits of %s", org.Slug), rows, "Resource", "Usage", "Limit", "Used"); err != nil {
+		return err
+	}
+
+	colorize := io.ColorScheme()
+	for _, l := range nearLimits(limits) {
+		fmt.Fprintf(io.ErrOut, "%s %s is at %d%% of its limit of %d, request an increase with fly orgs quota request %s <limit> --org %s\n",
+			colorize.WarningIcon(), l.Resource, usedPercent(l), l.Limit, l.Resource, org.Slug)
+	}
+
+	return nil
+}
+
+func runQuotaRequest(ctx context.Context) error {
+	var (
+		client   = client.FromContext(ctx).API()
+		io       = iostreams.FromContext(ctx)
+		args     = flag.Args(ctx)
+		resource = args[0]
+		reason   = flag.GetString(ctx, "reason")
+	)
+
+	if !lo.Contains(quotaResources, resource) {
+		return fmt.Errorf("unknown resource %s, expected one of %s", resource, strings.Join(quotaResources, ", "))
+	}
+	limit, err := strconv.Atoi(args[1])
+	if err != nil || limit < 1 {
+		return fmt.Errorf("limit must be a positive number, got %s", args[1])
+	}
+
+	org, err := prompt.Org(ctx)
+	if err != nil {
+		return err
+	}
+
+	limits, err := client.GetOrganizationLimits(ctx, org.Slug)
+	if err != nil {
+		return fmt.Errorf("failed retrieving limits of organization %s: %w", org.Slug, err)
+	}
+	if current, ok := lo.Find(limits, func(l api.OrganizationLimit) bool { return l.Resource == resource }); ok {
+		if current.Limit <= 0 {
+			return fmt.Errorf("%s of organization %s are unlimited", resource, org.Slug)
+		}
+		if limit <= current.Limit {
+			return fmt.Errorf("the limit of organization %s on %s is already %d", org.Slug, resource, current.Limit)
+		}
+	}
+
+	if reason == "" {
+		if err := prompt.String(ctx, &reason, "What is the increased limit needed for?", "", true); prompt.IsNonInteractive(err) {
+			return prompt.NonInteractiveError("reason must be specified with --reason when not running interactively")
+		} else if err != nil {
+			return err
+		}
+	}
+
+	request, err := client.RequestOrganizationLimitIncrease(ctx, org.ID, resource, limit, reason)
+	if err != nil {
+		return fmt.Errorf("failed requesting a limit increase: %w", err)
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, request)
+	}
+
+	fmt.Fprintf(io.Out, "Requested the limit of %s on %s to be raised to %d (request %s, %s)\n",
+		org.Slug, resource, limit, request.ID, strings.ToLower(request.Status))
+
+	return nil
+}
+
+// sortLimits orders limits as quotaResources are, resources unknown to this
+// version of flyctl last.
+func sortLimits(limits []api.OrganizationLimit) []api.OrganizationLimit {
+	order := func(l api.OrganizationLimit) int {
+		if i := lo.IndexOf(quotaResources, l.Resource); i >= 0 {
+			return i
+		}
+		return len(quotaResources)
+	}
+
+	sorted := append([]api.OrganizationLimit(nil), limits...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return order(sorted[i]) < order(sorted[j])
+	})
+
+	return sorted
+}
+
+func usedPercent(l api.OrganizationLimit) int {
+	if l.Limit <= 0 {
+		return 0
+	}
+	return l.Usage * 100 / l.Limit
+}
+
+// nearLimits returns the limits which are used past quotaWarnPercent.
+func nearLimits(limits []api.OrganizationLimit) []api.OrganizationLimit {
+	return lo.Filter(limits, func(l api.OrganizationLimit, _ int) bool {
+		return l.Limit > 0 && usedPercent(l) >= quotaWarnPercent
+	})
+}
@@ -0,0 +1,40 @@
+package orgs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestSortLimits(t *testing.T) {
+	limits := []api.OrganizationLimit{
+		{Resource: "concurrent_builds", Limit: 2},
+		{Resource: "gpus", Limit: 1},
+		{Resource: "apps", Limit: 100},
+		{Resource: "machines", Limit: 500},
+	}
+
+	assert.Equal(t, []string{"apps", "machines", "concurrent_builds", "gpus"}, resources(sortLimits(limits)))
+}
+
+func TestNearLimits(t *testing.T) {
+	limits := []api.OrganizationLimit{
+		{Resource: "apps", Limit: 100, Usage: 12},
+		{Resource: "machines", Limit: 500, Usage: 400},
+		{Resource: "volumes", Limit: 10, Usage: 10},
+		{Resource: "ip_addresses", Usage: 50},
+	}
+
+	assert.Equal(t, []string{"machines", "volumes"}, resources(nearLimits(limits)))
+	assert.Equal(t, 80, usedPercent(limits[1]))
+	assert.Equal(t, 0, usedPercent(limits[3]))
+}
+
+func resources(limits []api.OrganizationLimit) (names []string) {
+	for _, l := range limits {
+		names = append(names, l.Resource)
+	}
+	return
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/superfly/flyctl/iostreams"
 
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/config"
@@ -17,7 +18,10 @@ import (
 
 func newRegions() (cmd *cobra.Command) {
 	const (
-		long = `View a list of regions where Fly has edges and/or datacenters
+		long = `View a list of regions where Fly has edges and/or datacenters.
+
+With --json, regions are listed with their coordinates and placement
+constraints, for scripts to choose regions by.
 `
 		short = "List regions"
 	)
@@ -44,22 +48,51 @@ func runRegions(ctx context.Context) error {
 
 	out := iostreams.FromContext(ctx).Out
 	if config.FromContext(ctx).JSONOutput {
-		return render.JSON(out, regions)
+		return render.JSON(out, listRegions(regions))
 	}
 
 	var rows [][]string
 	for _, region := range regions {
-		gateway := ""
-		if region.GatewayAvailable {
-			gateway = "✓"
-		}
-
 		rows = append(rows, []string{
 			region.Code,
 			region.Name,
-			gateway,
+			check(region.GatewayAvailable),
+			check(region.RequiresPaidPlan),
+		})
+	}
+
+	return render.Table(out, "", rows, "Code", "Name", "Gateway", "Paid Plan Only")
+}
+
+// regionInfo is how regions are listed as JSON.
+type regionInfo struct {
+	Code             string  `json:"code"`
+	Name             string  `json:"name"`
+	Latitude         float32 `json:"latitude"`
+	Longitude        float32 `json:"longitude"`
+	GatewayAvailable bool    `json:"gateway_available"`
+	RequiresPaidPlan bool    `json:"requires_paid_plan"`
+}
+
+func listRegions(regions []api.Region) []regionInfo {
+	infos := make([]regionInfo, 0, len(regions))
+	for _, r := range regions {
+		infos = append(infos, regionInfo{
+			Code:             r.Code,
+			Name:             r.Name,
+			Latitude:         r.Latitude,
+			Longitude:        r.Longitude,
+			GatewayAvailable: r.GatewayAvailable,
+			RequiresPaidPlan: r.RequiresPaidPlan,
 		})
 	}
 
-	return render.Table(out, "", rows, "Code", "Name", "Gateway")
+	return infos
+}
+
+func check(b bool) string {
+	if b {
+		return "✓"
+	}
+	return ""
 }
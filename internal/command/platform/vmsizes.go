@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"sort"
 
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 
 	"github.com/superfly/flyctl/api"
@@ -17,7 +18,10 @@ import (
 
 func newVMSizes() (cmd *cobra.Command) {
 	const (
-		long = `View a list of VM sizes which can be used with the FLYCTL SCALE VM command
+		long = `View a list of VM sizes which can be used with the FLYCTL SCALE VM command.
+
+With --json, the sizes of both platforms are listed with their memory limits
+and prices, for scripts to size machines by.
 `
 		short = "List VM Sizes"
 	)
@@ -41,7 +45,7 @@ func runVMSizes(ctx context.Context) error {
 
 	out := iostreams.FromContext(ctx).Out
 	if config.FromContext(ctx).JSONOutput {
-		return render.JSON(out, sizes)
+		return render.JSON(out, listVMSizes(sizes))
 	}
 
 	var rows [][]string
@@ -100,6 +104,76 @@ func runMachineVMSizes(ctx context.Context) error {
 	return render.Table(out, "", performance, "Name", "CPU Cores", "Memory")
 }
 
+// vmSizeInfo is how VM sizes are listed as JSON.
+type vmSizeInfo struct {
+	Name               string   `json:"name"`
+	Platform           string   `json:"platform"`
+	CPUKind            string   `json:"cpu_kind,omitempty"`
+	CPUs               float32  `json:"cpus"`
+	MemoryMB           int      `json:"memory_mb"`
+	MinMemoryMB        int      `json:"min_memory_mb"`
+	MaxMemoryMB        int      `json:"max_memory_mb"`
+	MemoryIncrementsMB []int    `json:"memory_increments_mb,omitempty"`
+	PriceMonth         *float32 `json:"price_month,omitempty"`
+	PriceSecond        *float32 `json:"price_second,omitempty"`
+}
+
+// listVMSizes lists the sizes of the nomad platform and the machine presets.
+// Presets are priced like the nomad size of the same name, when there's one.
+func listVMSizes(nomad []api.VMSize) []vmSizeInfo {
+	infos := make([]vmSizeInfo, 0, len(nomad)+len(api.MachinePresets))
+	prices := map[string]api.VMSize{}
+
+	for _, size := range nomad {
+		size := size
+		prices[size.Name] = size
+		infos = append(infos, vmSizeInfo{
+			Name:               size.Name,
+			Platform:           "nomad",
+			CPUs:               size.CPUCores,
+			MemoryMB:           size.MemoryMB,
+			MinMemoryMB:        size.MemoryMB,
+			MaxMemoryMB:        size.MaxMemoryMB,
+			MemoryIncrementsMB: size.MemoryIncrementsMB,
+			PriceMonth:         &size.PriceMonth,
+			PriceSecond:        &size.PriceSecond,
+		})
+	}
+
+	presets := lo.Keys(api.MachinePresets)
+	sort.Slice(presets, func(i, j int) bool {
+		a, b := api.MachinePresets[presets[i]], api.MachinePresets[presets[j]]
+		if a.CPUKind != b.CPUKind {
+			return a.CPUKind > b.CPUKind
+		}
+		return a.CPUs < b.CPUs
+	})
+	for _, name := range presets {
+		guest := api.MachinePresets[name]
+		info := vmSizeInfo{
+			Name:     name,
+			Platform: "machines",
+			CPUKind:  guest.CPUKind,
+			CPUs:     float32(guest.CPUs),
+			MemoryMB: guest.MemoryMB,
+		}
+		if guest.CPUKind == "shared" {
+			info.MinMemoryMB = guest.CPUs * api.MIN_MEMORY_MB_PER_SHARED_CPU
+			info.MaxMemoryMB = guest.CPUs * api.MAX_MEMORY_MB_PER_SHARED_CPU
+		} else {
+			info.MinMemoryMB = guest.CPUs * api.MIN_MEMORY_MB_PER_CPU
+			info.MaxMemoryMB = guest.CPUs * api.MAX_MEMORY_MB_PER_CPU
+		}
+		if size, ok := prices[name]; ok {
+			info.PriceMonth = &size.PriceMonth
+			info.PriceSecond = &size.PriceSecond
+		}
+		infos = append(infos, info)
+	}
+
+	return infos
+}
+
 func cores(cores int) string {
 	if cores < 1.0 {
 		return fmt.Sprintf("%d", cores)
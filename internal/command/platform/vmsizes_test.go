@@ -0,0 +1,33 @@
+package platform
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestListVMSizes(t *testing.T) {
+	sizes := listVMSizes([]api.VMSize{
+		{Name: "shared-cpu-1x", CPUCores: 1, MemoryMB: 256, MaxMemoryMB: 2048, PriceMonth: 1.94, PriceSecond: 0.00000075},
+	})
+	require.Len(t, sizes, 1+len(api.MachinePresets))
+
+	assert.Equal(t, "nomad", sizes[0].Platform)
+	assert.Equal(t, 2048, sizes[0].MaxMemoryMB)
+
+	// shared presets come first, smallest first
+	preset := sizes[1]
+	assert.Equal(t, "shared-cpu-1x", preset.Name)
+	assert.Equal(t, "machines", preset.Platform)
+	assert.Equal(t, 256, preset.MinMemoryMB)
+	assert.Equal(t, 2048, preset.MaxMemoryMB)
+	require.NotNil(t, preset.PriceMonth)
+	assert.Equal(t, float32(1.94), *preset.PriceMonth)
+
+	last := sizes[len(sizes)-1]
+	assert.Equal(t, "performance", last.CPUKind)
+	assert.Nil(t, last.PriceMonth)
+}
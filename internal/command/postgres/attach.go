@@ -29,6 +29,7 @@ type AttachParams struct {
 	VariableName string
 	SuperUser    bool
 	Force        bool
+	ReplicaURL   bool
 }
 
 func newAttach() *cobra.Command {
@@ -65,6 +66,10 @@ func newAttach() *cobra.Command {
 			Default:     true,
 			Description: "Grants attached user superuser privileges",
 		},
+		flag.Bool{
+			Name:        "replica-url",
+			Description: "Also add a secret, DATABASE_REPLICA_URL by default, which connects to the nearest member of the cluster for reads.",
+		},
 		flag.Yes(),
 	)
 
@@ -106,6 +111,7 @@ func runAttach(ctx context.Context) error {
 		VariableName: flag.GetString(ctx, "variable-name"),
 		Force:        flag.GetBool(ctx, "yes"),
 		SuperUser:    flag.GetBool(ctx, "superuser"),
+		ReplicaURL:   flag.GetBool(ctx, "replica-url"),
 	}
 
 	pgAppFull, err := client.GetApp(ctx, pgAppName)
@@ -212,7 +218,7 @@ func nomadAttachCluster(ctx context.Context, pgApp *api.AppCompact, params Attac
 		return err
 	}
 
-	return runAttachCluster(ctx, leaderIP, params, nil)
+	return runAttachCluster(ctx, leaderIP, "", params, nil)
 }
 
 func machineAttachCluster(ctx context.Context, params AttachParams, flycast *string) error {
@@ -241,10 +247,10 @@ func machineAttachCluster(ctx context.Context, params AttachParams, flycast *str
 		return err
 	}
 
-	return runAttachCluster(ctx, leader.PrivateIP, params, flycast)
+	return runAttachCluster(ctx, leader.PrivateIP, leader.Region, params, flycast)
 }
 
-func runAttachCluster(ctx context.Context, leaderIP string, params AttachParams, flycast *string) error {
+func runAttachCluster(ctx context.Context, leaderIP, leaderRegion string, params AttachParams, flycast *string) error {
 	var (
 		client = client.FromContext(ctx).API()
 		dialer = agent.DialerFromContext(ctx)
@@ -292,9 +298,10 @@ func runAttachCluster(ctx context.Context, leaderIP string, params AttachParams,
 	if err != nil {
 		return err
 	}
+	replicaVarName := replicaVariableName(varName)
 	for _, secret := range secrets {
-		if secret.Name == *input.VariableName {
-			return fmt.Errorf("consumer app %q already contains a secret named %s", input.AppID, *input.VariableName)
+		if secret.Name == *input.VariableName || (params.ReplicaURL && secret.Name == replicaVarName) {
+			return fmt.Errorf("consumer app %q already contains a secret named %s", input.AppID, secret.Name)
 		}
 	}
 
@@ -371,6 +378,22 @@ func runAttachCluster(ctx context.Context, leaderIP string, params AttachParams,
 	s := map[string]string{}
 	s[*input.VariableName] = connectionString
 
+	// port 5433 skips the leader-bound proxy, so reads are served by
+	// whichever member is nearest to the connecting machine
+	replicaConnectionString := fmt.Sprintf(
+		"postgres://%s:%s@top1.nearest.of.%s.internal:5433/%s?sslmode=disable",
+		*input.DatabaseUser, pwd, input.PostgresClusterAppID, *input.DatabaseName,
+	)
+	if flycast != nil {
+		replicaConnectionString = fmt.Sprintf(
+			"postgres://%s:%s@%s.flycast:5433/%s?sslmode=disable",
+			*input.DatabaseUser, pwd, input.PostgresClusterAppID, *input.DatabaseName,
+		)
+	}
+	if params.ReplicaURL {
+		s[replicaVarName] = replicaConnectionString
+	}
+
 	_, err = client.SetSecrets(ctx, input.AppID, s)
 	if err != nil {
 		return err
@@ -379,5 +402,32 @@ func runAttachCluster(ctx context.Context, leaderIP string, params AttachParams,
 	fmt.Fprintf(io.Out, "\nPostgres cluster %s is now attached to %s\n", input.PostgresClusterAppID, input.AppID)
 	fmt.Fprintf(io.Out, "The following secret was added to %s:\n  %s=%s\n", input.AppID, *input.VariableName, connectionString)
 
+	if params.ReplicaURL {
+		fmt.Fprintf(io.Out, "  %s=%s\n", replicaVarName, replicaConnectionString)
+		printReplayHint(io, leaderRegion)
+	}
+
 	return nil
 }
+
+// replicaVariableName derives the name of the replica secret from the name
+// of the primary one, so DATABASE_URL goes with DATABASE_REPLICA_URL.
+func replicaVariableName(varName string) string {
+	return strings.TrimSuffix(varName, "_URL") + "_REPLICA_URL"
+}
+
+func printReplayHint(io *iostreams.IOStreams, leaderRegion string) {
+	region := leaderRegion
+	if region == "" {
+		region = "<primary region>"
+	}
+
+	fmt.Fprintf(io.Out, `
+Send reads to the replica URL and writes to the primary one. Writes which
+reach a replica fail with "cannot execute ... in a read-only transaction";
+answer those requests with the following response header, and the proxy
+replays them on your app's machines in the primary region:
+
+  fly-replay: region=%s
+`, region)
+}
@@ -67,6 +67,14 @@ func newConfigUpdate() (cmd *cobra.Command) {
 			Name:        "shared-preload-libraries",
 			Description: "Sets the shared libraries to preload. (comma separated string)",
 		},
+		flag.StringSlice{
+			Name:        "setting",
+			Description: "Sets any other postgresql.conf parameter, in the form of name=value. Can be specified multiple times.",
+		},
+		flag.StringSlice{
+			Name:        "extension",
+			Description: "Installs an extension (pgvector, postgis or timescaledb) in every database, preloading its library if it needs one. Can be specified multiple times.",
+		},
 		flag.Bool{
 			Name:        "force",
 			Description: "Skips pg-setting value verification.",
@@ -177,14 +185,28 @@ func runMachineConfigUpdate(ctx context.Context, app *api.AppCompact) error {
 		}
 	}
 
-	return nil
+	extensions, _, err := resolveExtensions(flag.GetStringSlice(ctx, "extension"))
+	if err != nil || len(extensions) == 0 {
+		return err
+	}
+
+	// the cluster may have failed over while restarting
+	active, err := mach.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+	if leader, err = pickLeader(ctx, active); err != nil {
+		return err
+	}
+
+	return installExtensions(ctx, leader, extensions)
 }
 
 func updateStolonConfig(ctx context.Context, app *api.AppCompact, leaderIP string) (bool, error) {
 	io := iostreams.FromContext(ctx)
 
 	restartRequired, changes, err := resolveConfigChanges(ctx, app, flypg.StolonManager, leaderIP)
-	if err != nil {
+	if err != nil || len(changes) == 0 {
 		return false, err
 	}
 
@@ -210,7 +232,7 @@ func updateFlexConfig(ctx context.Context, app *api.AppCompact, leaderIP string)
 	)
 
 	restartRequired, changes, err := resolveConfigChanges(ctx, app, flypg.ReplicationManager, leaderIP)
-	if err != nil {
+	if err != nil || len(changes) == 0 {
 		return false, err
 	}
 
@@ -252,21 +274,54 @@ func resolveConfigChanges(ctx context.Context, app *api.AppCompact, manager stri
 	)
 
 	// Identify requested configuration changes.
-	changes := map[string]string{}
-	keys := []string{}
+	changes, err := parseSettings(flag.GetStringSlice(ctx, "setting"))
+	if err != nil {
+		return false, nil, err
+	}
 	for key := range pgSettings {
 		val := flag.GetString(ctx, key)
 		if val != "" {
 			changes[pgSettings[key]] = val
-			keys = append(keys, pgSettings[key])
 		}
 	}
 
+	pgclient := flypg.NewFromInstance(leaderIP, dialer)
+
+	extensions, preloads, err := resolveExtensions(flag.GetStringSlice(ctx, "extension"))
+	if err != nil {
+		return false, nil, err
+	}
+	if len(preloads) > 0 {
+		current, ok := changes["shared_preload_libraries"]
+		if !ok {
+			settings, err := pgclient.ViewSettings(ctx, []string{"shared_preload_libraries"}, manager)
+			if err != nil {
+				return false, nil, err
+			}
+			for _, s := range settings.Settings {
+				if s.Name == "shared_preload_libraries" {
+					current = s.Setting
+				}
+			}
+		}
+		if merged := mergePreloads(current, preloads); merged != current || ok {
+			changes["shared_preload_libraries"] = merged
+		}
+	}
+
+	if len(changes) == 0 && len(extensions) > 0 {
+		// only extensions to install
+		return false, nil, nil
+	}
+
+	keys := make([]string, 0, len(changes))
+	for key := range changes {
+		keys = append(keys, key)
+	}
+
 	restartRequired := false
 	if !force {
 		// Query PG settings
-		pgclient := flypg.NewFromInstance(leaderIP, dialer)
-
 		settings, err := pgclient.ViewSettings(ctx, keys, manager)
 		if err != nil {
 			return false, nil, err
@@ -280,6 +335,9 @@ func resolveConfigChanges(ctx context.Context, app *api.AppCompact, manager stri
 		if err != nil {
 			return false, nil, err
 		}
+		if len(changelog) == 0 && len(extensions) > 0 {
+			return false, nil, nil
+		}
 		if len(changelog) == 0 {
 			return false, nil, fmt.Errorf("no changes to apply")
 		}
@@ -369,6 +427,10 @@ func runNomadConfigUpdate(ctx context.Context, app *api.AppCompact) error {
 		return err
 	}
 
+	if len(flag.GetStringSlice(ctx, "extension")) > 0 {
+		return fmt.Errorf("installing extensions is only supported on machines clusters")
+	}
+
 	agentclient, err := agent.Establish(ctx, client)
 	if err != nil {
 		return errors.Wrap(err, "can't establish agent")
@@ -4,12 +4,16 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/agent"
 	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/flypg"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/apps"
 	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
 	"github.com/superfly/flyctl/internal/prompt"
 	"github.com/superfly/flyctl/iostreams"
 )
@@ -28,6 +32,7 @@ func newCreate() *cobra.Command {
 		cmd,
 		flag.Region(),
 		flag.Org(),
+		flag.AnswersFile(),
 		flag.Detach(),
 		flag.String{
 			Name:        "name",
@@ -79,6 +84,14 @@ func newCreate() *cobra.Command {
 			Description: "Automatically start a stopped Postgres app when a network request is received",
 			Default:     false,
 		},
+		flag.StringSlice{
+			Name:        "setting",
+			Description: "Sets a postgresql.conf parameter, in the form of name=value. Can be specified multiple times.",
+		},
+		flag.StringSlice{
+			Name:        "extension",
+			Description: "Installs an extension (pgvector, postgis or timescaledb), preloading its library if it needs one. Can be specified multiple times.",
+		},
 	)
 
 	return cmd
@@ -127,6 +140,11 @@ func run(ctx context.Context) (err error) {
 		Detach:                flag.GetDetach(ctx),
 		Manager:               flypg.StolonManager,
 		Autostart:             flag.GetBool(ctx, "autostart"),
+		Extensions:            flag.GetStringSlice(ctx, "extension"),
+	}
+
+	if params.Settings, err = parseSettings(flag.GetStringSlice(ctx, "setting")); err != nil {
+		return
 	}
 
 	params.Manager = flypg.ReplicationManager
@@ -144,6 +162,15 @@ func CreateCluster(ctx context.Context, org *api.Organization, region *api.Regio
 		io     = iostreams.FromContext(ctx)
 	)
 
+	extensions, preloads, err := resolveExtensions(params.Extensions)
+	if err != nil {
+		return err
+	}
+	customized := len(params.Settings) > 0 || len(extensions) > 0
+	if customized && params.Detach {
+		return fmt.Errorf("custom settings and extensions can't be applied to a detached cluster creation")
+	}
+
 	input := &flypg.CreateClusterInput{
 		AppName:      params.Name,
 		Organization: org,
@@ -239,7 +266,85 @@ func CreateCluster(ctx context.Context, org *api.Organization, region *api.Regio
 
 	launcher := flypg.NewLauncher(client)
 
-	return launcher.LaunchMachinesPostgres(ctx, input, params.Detach)
+	if err := launcher.LaunchMachinesPostgres(ctx, input, params.Detach); err != nil || !customized {
+		return err
+	}
+
+	settings := lo.Assign(params.Settings)
+	if len(preloads) > 0 {
+		settings["shared_preload_libraries"] = mergePreloads(settings["shared_preload_libraries"], preloads)
+	}
+
+	return customizeCluster(ctx, params.Name, settings, extensions)
+}
+
+// customizeCluster applies settings to the newly created cluster, restarting
+// it when there are any, and then installs extensions.
+func customizeCluster(ctx context.Context, appName string, settings map[string]string, extensions []pgExtension) error {
+	var (
+		client = client.FromContext(ctx).API()
+		io     = iostreams.FromContext(ctx)
+	)
+
+	app, err := client.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+
+	if ctx, err = apps.BuildContext(ctx, app); err != nil {
+		return err
+	}
+
+	machines, err := mach.ListActive(ctx)
+	if err != nil {
+		return err
+	}
+	leader, err := pickLeader(ctx, machines)
+	if err != nil {
+		return err
+	}
+
+	if len(settings) > 0 {
+		fmt.Fprintln(io.Out, "Applying custom settings...")
+
+		if IsFlex(leader) {
+			dialer := agent.DialerFromContext(ctx)
+			if err := flypg.NewFromInstance(leader.PrivateIP, dialer).UpdateSettings(ctx, settings); err != nil {
+				return err
+			}
+			for _, machine := range machines {
+				if err := flypg.NewFromInstance(machine.PrivateIP, dialer).SyncSettings(ctx); err != nil {
+					return fmt.Errorf("failed to sync configuration on %s: %s", machine.ID, err)
+				}
+			}
+		} else {
+			cmd, err := flypg.NewCommand(ctx, app)
+			if err != nil {
+				return err
+			}
+			if err := cmd.UpdateSettings(ctx, leader.PrivateIP, settings); err != nil {
+				return err
+			}
+		}
+
+		if err := machinesRestart(ctx, &api.RestartMachineInput{}); err != nil {
+			return err
+		}
+
+		// the cluster may have failed over while restarting
+		if machines, err = mach.ListActive(ctx); err != nil {
+			return err
+		}
+		if leader, err = pickLeader(ctx, machines); err != nil {
+			return err
+		}
+	}
+
+	if len(extensions) == 0 {
+		return nil
+	}
+
+	return installExtensions(ctx, leader, extensions)
 }
 
 func resolveVMSize(ctx context.Context, targetSize string) (*api.VMSize, error) {
@@ -274,6 +379,8 @@ type ClusterParams struct {
 	Detach     bool
 	Manager    string
 	Autostart  bool
+	Settings   map[string]string
+	Extensions []string
 }
 
 func postgresConfigurations(manager string) []PostgresConfiguration {
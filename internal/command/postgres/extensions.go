@@ -0,0 +1,142 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/flypg"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// pgExtension is an extension which can be installed with --extension.
+type pgExtension struct {
+	// name is the name of the extension in Postgres.
+	name string
+	// preload is the library the extension needs in shared_preload_libraries, if any.
+	preload string
+}
+
+var pgExtensions = map[string]pgExtension{
+	"pgvector":    {name: "vector"},
+	"postgis":     {name: "postgis"},
+	"timescaledb": {name: "timescaledb", preload: "timescaledb"},
+}
+
+// parseSettings parses the name=value pairs given with --setting. Names may
+// be spelled with dashes, like the flags of config update, or underscores.
+func parseSettings(pairs []string) (map[string]string, error) {
+	settings := map[string]string{}
+
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.ReplaceAll(strings.TrimSpace(name), "-", "_")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid setting %q; settings must be given as name=value", pair)
+		}
+		settings[name] = strings.TrimSpace(value)
+	}
+
+	return settings, nil
+}
+
+// resolveExtensions validates the extensions given with --extension and
+// returns them along with the libraries they need preloaded.
+func resolveExtensions(names []string) (extensions []pgExtension, preloads []string, err error) {
+	for _, name := range names {
+		ext, ok := pgExtensions[strings.ToLower(name)]
+		if !ok {
+			supported := make([]string, 0, len(pgExtensions))
+			for name := range pgExtensions {
+				supported = append(supported, name)
+			}
+			sort.Strings(supported)
+
+			return nil, nil, fmt.Errorf("extension %s is not supported; supported extensions are %s", name, strings.Join(supported, ", "))
+		}
+
+		extensions = append(extensions, ext)
+		if ext.preload != "" {
+			preloads = append(preloads, ext.preload)
+		}
+	}
+
+	return
+}
+
+// mergePreloads adds preloads to the current value of shared_preload_libraries.
+// It returns the current value unchanged when every library is already
+// preloaded.
+func mergePreloads(current string, preloads []string) string {
+	libs := []string{}
+	seen := map[string]bool{}
+	for _, lib := range strings.Split(current, ",") {
+		if lib = strings.Trim(strings.TrimSpace(lib), `"`); lib != "" && !seen[lib] {
+			seen[lib] = true
+			libs = append(libs, lib)
+		}
+	}
+
+	added := false
+	for _, lib := range preloads {
+		if !seen[lib] {
+			seen[lib] = true
+			libs = append(libs, lib)
+			added = true
+		}
+	}
+
+	if !added {
+		return current
+	}
+
+	return strings.Join(libs, ",")
+}
+
+// installExtensions creates extensions in every database of the cluster, as
+// well as in template1 so databases created later on have them too.
+func installExtensions(ctx context.Context, leader *api.Machine, extensions []pgExtension) error {
+	var (
+		io          = iostreams.FromContext(ctx)
+		flapsClient = flaps.FromContext(ctx)
+		pgclient    = flypg.NewFromInstance(leader.PrivateIP, agent.DialerFromContext(ctx))
+	)
+
+	dbs, err := pgclient.ListDatabases(ctx)
+	if err != nil {
+		return fmt.Errorf("failed listing databases: %w", err)
+	}
+
+	databases := []string{"template1"}
+	for _, db := range dbs {
+		databases = append(databases, db.Name)
+	}
+
+	for _, ext := range extensions {
+		fmt.Fprintf(io.Out, "Installing extension %s\n", ext.name)
+
+		for _, db := range databases {
+			in := &api.MachineExecRequest{
+				Cmd: fmt.Sprintf(`sh -c "PGPASSWORD=$OPERATOR_PASSWORD psql -h localhost -p 5433 -U postgres -d %s -v ON_ERROR_STOP=1 -c 'CREATE EXTENSION IF NOT EXISTS %s'"`, db, ext.name),
+			}
+
+			out, err := flapsClient.Exec(ctx, leader.ID, in)
+			if err != nil {
+				return fmt.Errorf("failed installing extension %s in database %s: %w", ext.name, db, err)
+			}
+			if out.ExitCode != 0 {
+				msg := ""
+				if out.StdErr != nil {
+					msg = strings.TrimSpace(*out.StdErr)
+				}
+				return fmt.Errorf("failed installing extension %s in database %s: %s", ext.name, db, msg)
+			}
+		}
+	}
+
+	return nil
+}
@@ -25,3 +25,19 @@ func TestIsFlex(t *testing.T) {
 		},
 	}))
 }
+
+func TestParseSettings(t *testing.T) {
+	settings, err := parseSettings([]string{"work-mem=64MB", "random_page_cost = 1.1"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"work_mem": "64MB", "random_page_cost": "1.1"}, settings)
+
+	_, err = parseSettings([]string{"work_mem"})
+	assert.Error(t, err)
+}
+
+func TestMergePreloads(t *testing.T) {
+	assert.Equal(t, "timescaledb", mergePreloads("", []string{"timescaledb"}))
+	assert.Equal(t, "repmgr,timescaledb", mergePreloads("repmgr", []string{"timescaledb"}))
+	assert.Equal(t, "repmgr,pg_stat_statements,timescaledb", mergePreloads(`"repmgr", pg_stat_statements`, []string{"timescaledb"}))
+	assert.Equal(t, "repmgr, timescaledb", mergePreloads("repmgr, timescaledb", []string{"timescaledb"}))
+}
@@ -13,19 +13,24 @@ import (
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/flag"
 	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
 	"github.com/superfly/flyctl/proxy"
 )
 
 func New() *cobra.Command {
 	var (
-		long  = strings.Trim(`Proxies connections to a fly VM through a Wireguard tunnel The current application DNS is the default remote host`, "\n")
+		long = strings.Trim(`Proxies connections to a fly VM through a Wireguard tunnel The current application DNS is the default remote host
+
+With --socks5, starts a SOCKS5 proxy instead, through which any host reachable
+from the organization's private network (including .internal names) can be
+reached without installing a WireGuard client.`, "\n")
 		short = `Proxies connections to a fly VM`
 	)
 
-	cmd := command.New("proxy <local:remote> [remote_host]", short, long, run,
+	cmd := command.New("proxy [local:remote] [remote_host]", short, long, run,
 		command.RequireSession, command.LoadAppNameIfPresent)
 
-	cmd.Args = cobra.RangeArgs(1, 2)
+	cmd.Args = cobra.RangeArgs(0, 2)
 
 	flag.Add(cmd,
 		flag.App(),
@@ -42,6 +47,10 @@ func New() *cobra.Command {
 			Shorthand:   "q",
 			Description: "Don't print progress indicators for WireGuard",
 		},
+		flag.String{
+			Name:        "socks5",
+			Description: "Start a SOCKS5 proxy listening on the given port or address (e.g. 1080, listening on localhost) instead of proxying a single port. Clients aren't authenticated, only pass another host than localhost on trusted networks",
+		},
 	)
 
 	return cmd
@@ -53,11 +62,21 @@ func run(ctx context.Context) (err error) {
 	orgSlug := flag.GetString(ctx, "org")
 	args := flag.Args(ctx)
 	promptInstance := flag.GetBool(ctx, "select")
+	socks5Addr := flag.GetString(ctx, "socks5")
 
 	if promptInstance && appName == "" {
 		return errors.New("--app required when --select flag provided")
 	}
 
+	switch {
+	case socks5Addr != "" && len(args) > 0:
+		return errors.New("--socks5 can't be combined with port arguments")
+	case socks5Addr != "" && promptInstance:
+		return errors.New("--socks5 can't be combined with --select")
+	case socks5Addr == "" && len(args) == 0:
+		return errors.New("requires a <local:remote> argument, or --socks5")
+	}
+
 	if orgSlug != "" {
 		_, err := client.GetOrganizationBySlug(ctx, orgSlug)
 		if err != nil {
@@ -98,6 +117,10 @@ func run(ctx context.Context) (err error) {
 		return err
 	}
 
+	if socks5Addr != "" {
+		return runSocks5(ctx, socks5Addr, orgSlug, dialer)
+	}
+
 	ports := strings.Split(args[0], ":")
 
 	params := &proxy.ConnectParams{
@@ -116,3 +139,16 @@ func run(ctx context.Context) (err error) {
 
 	return proxy.Connect(ctx, params)
 }
+
+func runSocks5(ctx context.Context, addr, orgSlug string, dialer agent.Dialer) error {
+	io := iostreams.FromContext(ctx)
+
+	server, err := proxy.NewSocks5Server(addr, dialer.DialContext)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "SOCKS5 proxy to organization %s listening on %s\n", orgSlug, server.Listener.Addr())
+
+	return server.Serve(ctx)
+}
@@ -0,0 +1,190 @@
+// Package regions implements the regions subcommands which work with the new
+// way, grafted onto the regions command.
+package regions
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/primaryregion"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// NewSetPrimary returns the regions set-primary command.
+func NewSetPrimary() *cobra.Command {
+	const (
+		long = `Change the primary region of an app consistently: primary_region in
+fly.toml, the PRIMARY_REGION environment variable of every machine, which
+restarts them, and the PRIMARY_REGION secret when the app has one.
+
+Afterwards, the new primary region is checked to have machines and the
+app's volumes, and the Postgres clusters the app is attached to to have
+their primary there.`
+
+		short = "Change the primary region of an app"
+
+		usage = "set-primary <region>"
+	)
+
+	cmd := command.New(usage, short, long, runSetPrimary,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+	)
+
+	return cmd
+}
+
+func runSetPrimary(ctx context.Context) error {
+	var (
+		io      = iostreams.FromContext(ctx)
+		client  = client.FromContext(ctx).API()
+		appName = appconfig.NameFromContext(ctx)
+		region  = flag.FirstArg(ctx)
+	)
+
+	regions, _, err := client.PlatformRegions(ctx)
+	if err != nil {
+		return err
+	}
+	if !lo.ContainsBy(regions, func(r api.Region) bool { return r.Code == region }) {
+		return fmt.Errorf("unknown region %s, list them with fly platform regions", region)
+	}
+
+	app, err := client.GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return fmt.Errorf("app %s runs on the nomad platform, only machines apps have a primary region", appName)
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	machines, _, err := flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return err
+	}
+	stale := lo.Filter(machines, func(m *api.Machine, _ int) bool {
+		return m.Config.Env[primaryregion.EnvName] != region
+	})
+
+	if len(stale) > 0 && !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirmf(ctx, "Updating the primary region restarts %d machines of %s. Continue?", len(stale), appName); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	// the local fly.toml, when it's the app's
+	cfg := appconfig.ConfigFromContext(ctx)
+	if cfg != nil && (cfg.AppName != appName || cfg.ConfigFilePath() == "") {
+		cfg = nil
+	}
+
+	if cfg != nil {
+		cfg.PrimaryRegion = region
+		if _, ok := cfg.Env[primaryregion.EnvName]; ok {
+			cfg.SetEnvVariable(primaryregion.EnvName, region)
+		}
+		if err := cfg.WriteToDisk(ctx, cfg.ConfigFilePath()); err != nil {
+			return err
+		}
+	} else {
+		terminal.Warnf("No fly.toml of %s was found, set primary_region = %q in it yourself\n", appName, region)
+	}
+
+	secrets, err := client.GetAppSecrets(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if lo.ContainsBy(secrets, func(s api.Secret) bool { return s.Name == primaryregion.EnvName }) {
+		if _, err := client.SetSecrets(ctx, appName, map[string]string{primaryregion.EnvName: region}); err != nil {
+			return fmt.Errorf("failed setting the %s secret: %w", primaryregion.EnvName, err)
+		}
+		fmt.Fprintf(io.Out, "Set the %s secret to %s\n", primaryregion.EnvName, region)
+	}
+
+	for _, m := range stale {
+		if err := setMachinePrimaryRegion(ctx, m, region); err != nil {
+			return fmt.Errorf("failed updating machine %s, run fly regions set-primary %s again to resume: %w", m.ID, region, err)
+		}
+	}
+
+	fmt.Fprintf(io.Out, "The primary region of %s is now %s\n", appName, region)
+
+	var (
+		volumes    []api.Volume
+		volumeName string
+	)
+	if cfg != nil && cfg.Mounts != nil {
+		if volumes, err = client.GetVolumes(ctx, appName); err != nil {
+			return err
+		}
+		volumeName = cfg.Mounts.Source
+	}
+	warnings := primaryregion.Check(region, machines, volumes, volumeName)
+
+	primaries, err := primaryregion.PostgresPrimaries(ctx, client, app)
+	if err != nil {
+		return err
+	}
+	warnings = append(warnings, primaryregion.CheckPostgres(region, primaries)...)
+
+	for _, warning := range warnings {
+		terminal.Warnf("%s\n", warning)
+	}
+
+	return nil
+}
+
+func setMachinePrimaryRegion(ctx context.Context, m *api.Machine, region string) error {
+	m, releaseLeaseFunc, err := mach.AcquireLease(ctx, m)
+	defer releaseLeaseFunc(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	config := mach.CloneConfig(m.Config)
+	if config.Env == nil {
+		config.Env = map[string]string{}
+	}
+	config.Env[primaryregion.EnvName] = region
+
+	return mach.Update(ctx, m, &api.LaunchMachineInput{
+		ID:     m.ID,
+		AppID:  appconfig.NameFromContext(ctx),
+		Name:   m.Name,
+		Region: m.Region,
+		Config: config,
+	})
+}
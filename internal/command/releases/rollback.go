@@ -0,0 +1,237 @@
+package releases
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/internal/sentry"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// releasesPerPage is the number of releases fetched at a time while looking
+// for the one to roll back to.
+const releasesPerPage = 25
+
+func newRollback() (cmd *cobra.Command) {
+	const (
+		short = "Roll back to a previous release"
+		long  = `Roll the machines of an app back to a previous release. The image and
+the configuration of that release are deployed again, the same way fly deploy
+would, so machines are updated under lease and checked for health one at a
+time.
+
+VERSION defaults to the release before the current one.`
+	)
+
+	cmd = command.New("rollback [VERSION]", short, long, runRollback,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Yes(),
+		flag.Detach(),
+		flag.String{
+			Name:        "strategy",
+			Description: "The strategy for replacing running machines. Options are rolling, immediate, canary or bluegreen. Default is rolling.",
+		},
+		flag.Int{
+			Name:        "wait-timeout",
+			Description: "Seconds to wait for individual machines to transition states and become healthy.",
+			Default:     int(deploy.DefaultWaitTimeout.Seconds()),
+		},
+		flag.Int{
+			Name:        "lease-timeout",
+			Description: "Seconds to lease individual machines while rolling back.",
+			Default:     int(deploy.DefaultLeaseTtl.Seconds()),
+		},
+	)
+
+	return
+}
+
+func runRollback(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		colorize  = io.ColorScheme()
+		appName   = appconfig.NameFromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+	)
+
+	version := 0
+	if arg := flag.FirstArg(ctx); arg != "" {
+		v, err := strconv.Atoi(strings.TrimPrefix(strings.ToLower(arg), "v"))
+		if err != nil || v < 1 {
+			return fmt.Errorf("invalid release version %q", arg)
+		}
+		version = v
+	}
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving app %s: %w", appName, err)
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return fmt.Errorf("rollbacks are only supported for apps running on machines; %s runs on %s", appName, app.PlatformVersion)
+	}
+
+	release, err := findRelease(ctx, apiClient, appName, version)
+	if err != nil {
+		return err
+	}
+
+	if release.ImageRef == "" {
+		return fmt.Errorf("release v%d has no image to roll back to", release.Version)
+	}
+	if release.ConfigDefinition == nil {
+		return fmt.Errorf("release v%d has no configuration snapshot to roll back to", release.Version)
+	}
+
+	definition, ok := release.ConfigDefinition.(map[string]any)
+	if !ok {
+		return fmt.Errorf("likely a bug, could not convert config definition of type %T to api map[string]any", release.ConfigDefinition)
+	}
+	cfg, err := appconfig.FromDefinition(api.DefinitionPtr(definition))
+	if err != nil {
+		return fmt.Errorf("failed reading the configuration of release v%d: %w", release.Version, err)
+	}
+	if err := cfg.SetMachinesPlatform(); err != nil {
+		return err
+	}
+	cfg.AppName = appName
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirmf(ctx, "Roll %s back to release v%d (%s)?", appName, release.Version, release.ImageRef); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	fmt.Fprintf(io.ErrOut, "Rolling %s back to release %s\n", appName, colorize.Bold(fmt.Sprintf("v%d", release.Version)))
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return fmt.Errorf("could not create flaps client: %w", err)
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+	ctx = appconfig.WithConfig(ctx, cfg)
+
+	img := &imgsrc.DeploymentImage{
+		ID:  release.Image.Digest,
+		Tag: release.ImageRef,
+	}
+
+	md, err := deploy.NewMachineDeployment(ctx, deploy.MachineDeploymentArgs{
+		AppCompact:       app,
+		DeploymentImage:  img,
+		Strategy:         flag.GetString(ctx, "strategy"),
+		SkipHealthChecks: flag.GetDetach(ctx),
+		WaitTimeout:      time.Duration(flag.GetInt(ctx, "wait-timeout")) * time.Second,
+		LeaseTimeout:     time.Duration(flag.GetInt(ctx, "lease-timeout")) * time.Second,
+	})
+	if err != nil {
+		sentry.CaptureExceptionWithAppInfo(err, "releases rollback", app)
+		return err
+	}
+	err = md.DeployMachinesApp(ctx)
+	if err != nil {
+		sentry.CaptureExceptionWithAppInfo(err, "releases rollback", app)
+	}
+	return err
+}
+
+// findRelease returns the release with the given version, or the one before
+// the current release when version is 0.
+func findRelease(ctx context.Context, apiClient *api.Client, appName string, version int) (*gql.RollbackReleaseFields, error) {
+	_ = `# @genqlient
+	query FlyctlRollbackReleases(
+		$appName: String!,
+		$limit: Int!,
+		# @genqlient(omitempty: true)
+		$after: String,
+	) {
+		app(name: $appName) {
+			releasesUnprocessed(first: $limit, after: $after) {
+				nodes {
+					...RollbackReleaseFields
+				}
+				pageInfo {
+					hasNextPage
+					endCursor
+				}
+			}
+		}
+	}
+
+	fragment RollbackReleaseFields on ReleaseUnprocessed {
+		version
+		imageRef
+		image {
+			digest
+		}
+		configDefinition
+	}
+	`
+
+	var (
+		after   string
+		current *gql.RollbackReleaseFields
+	)
+
+	for {
+		resp, err := gql.FlyctlRollbackReleases(ctx, apiClient.GenqClient, appName, releasesPerPage, after)
+		if err != nil {
+			return nil, fmt.Errorf("failed retrieving releases of %s: %w", appName, err)
+		}
+
+		releases := resp.App.ReleasesUnprocessed
+		for i := range releases.Nodes {
+			release := &releases.Nodes[i].RollbackReleaseFields
+
+			switch {
+			case version != 0 && release.Version == version:
+				return release, nil
+			case version == 0 && current == nil:
+				current = release
+			case version == 0:
+				return release, nil
+			}
+		}
+
+		if !releases.PageInfo.HasNextPage {
+			break
+		}
+		after = releases.PageInfo.EndCursor
+	}
+
+	if version != 0 {
+		return nil, fmt.Errorf("release v%d of %s not found", version, appName)
+	}
+
+	return nil, fmt.Errorf("%s has no release before the current one to roll back to", appName)
+}
@@ -11,13 +11,18 @@ import (
 	"github.com/superfly/flyctl/internal/command/agent"
 	"github.com/superfly/flyctl/internal/command/apps"
 	"github.com/superfly/flyctl/internal/command/auth"
+	"github.com/superfly/flyctl/internal/command/autoscale"
+	"github.com/superfly/flyctl/internal/command/cache"
 	"github.com/superfly/flyctl/internal/command/checks"
+	"github.com/superfly/flyctl/internal/command/compose"
 	"github.com/superfly/flyctl/internal/command/config"
 	"github.com/superfly/flyctl/internal/command/create"
+	"github.com/superfly/flyctl/internal/command/cron"
 	"github.com/superfly/flyctl/internal/command/curl"
 	"github.com/superfly/flyctl/internal/command/deploy"
 	"github.com/superfly/flyctl/internal/command/destroy"
 	"github.com/superfly/flyctl/internal/command/dig"
+	"github.com/superfly/flyctl/internal/command/dockerfile"
 	"github.com/superfly/flyctl/internal/command/docs"
 	"github.com/superfly/flyctl/internal/command/doctor"
 	"github.com/superfly/flyctl/internal/command/help"
@@ -38,15 +43,21 @@ import (
 	"github.com/superfly/flyctl/internal/command/postgres"
 	"github.com/superfly/flyctl/internal/command/proxy"
 	"github.com/superfly/flyctl/internal/command/redis"
+	"github.com/superfly/flyctl/internal/command/regions"
 	"github.com/superfly/flyctl/internal/command/releases"
 	"github.com/superfly/flyctl/internal/command/restart"
 	"github.com/superfly/flyctl/internal/command/resume"
+	"github.com/superfly/flyctl/internal/command/run"
+	"github.com/superfly/flyctl/internal/command/runners"
 	"github.com/superfly/flyctl/internal/command/scale"
 	"github.com/superfly/flyctl/internal/command/secrets"
 	"github.com/superfly/flyctl/internal/command/services"
 	"github.com/superfly/flyctl/internal/command/ssh"
+	"github.com/superfly/flyctl/internal/command/stack"
 	"github.com/superfly/flyctl/internal/command/status"
 	"github.com/superfly/flyctl/internal/command/suspend"
+	"github.com/superfly/flyctl/internal/command/templates"
+	"github.com/superfly/flyctl/internal/command/trace"
 	"github.com/superfly/flyctl/internal/command/turboku"
 	"github.com/superfly/flyctl/internal/command/version"
 	"github.com/superfly/flyctl/internal/command/vm"
@@ -169,6 +180,16 @@ func New() *cobra.Command {
 		services.New(),
 		config.New(),
 		scale.New(),
+		autoscale.New(),
+		cron.New(),
+		run.New(),
+		compose.New(),
+		stack.New(),
+		templates.New(),
+		trace.New(),
+		dockerfile.New(),
+		cache.New(),
+		runners.New(),
 	}
 
 	// if os.Getenv("DEV") != "" {
@@ -204,6 +225,12 @@ func New() *cobra.Command {
 	// and finally, add the new commands
 	root.AddCommand(newCommands...)
 
+	// the regions command is still an old one, the subcommands of it which
+	// work with the new way are grafted onto it
+	if regionsCmd, _, err := root.Find([]string{"regions"}); err == nil && regionsCmd != root {
+		regionsCmd.AddCommand(regions.NewSetPrimary())
+	}
+
 	root.SetHelpCommand(help.New(root))
 
 	root.RunE = help.NewRootHelp().RunE
@@ -0,0 +1,176 @@
+// Package run implements the run command, which runs a one-off command in a
+// temporary machine of an app.
+package run
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/flyerr"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/logs"
+)
+
+// New initializes and returns a new run Command.
+func New() *cobra.Command {
+	const (
+		short = "Run a one-off command in a temporary machine"
+		long  = `Run a command, such as a database migration or a script, in a temporary
+machine running the image of the latest release of the app, with its
+environment and secrets. The output of the command is streamed until it exits,
+then the machine is destroyed and flyctl exits with the exit code of the
+command.
+`
+		usage = "run -- <command> [args...]"
+	)
+
+	cmd := command.New(usage, short, long, run,
+		command.RequireSession,
+		command.RequireAppName,
+		command.RequireCompatibleVersion("run"),
+	)
+
+	cmd.Args = cobra.MinimumNArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Region(),
+		flag.String{
+			Name:        "image",
+			Description: "Image to run the command with, instead of the image of the latest release",
+		},
+		flag.String{
+			Name:        "vm-size",
+			Description: `Size of the machine running the command, such as "shared-cpu-1x". Defaults to the size of the machines of the latest release`,
+		},
+		flag.Int{
+			Name:        "timeout",
+			Description: "Seconds to wait for the command to exit before killing it",
+			Default:     3600,
+		},
+	)
+
+	return cmd
+}
+
+func run(ctx context.Context) error {
+	cmd := flag.Args(ctx)
+
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appconfig.NameFromContext(ctx))
+	if err != nil {
+		return err
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return fmt.Errorf("fly run is only supported for apps running on machines")
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+	ctx = flaps.NewContext(ctx, flapsClient)
+
+	release, err := mach.LatestRelease(ctx)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := machineConfig(release, cmd, flag.GetString(ctx, "image"), flag.GetString(ctx, "vm-size"))
+	if err != nil {
+		return err
+	}
+
+	region := flag.GetString(ctx, flag.RegionName)
+	if region == "" && release != nil {
+		region = release.Region
+	}
+
+	io := iostreams.FromContext(ctx)
+	fmt.Fprintf(io.ErrOut, "Running %s: %s\n", io.ColorScheme().Bold(app.Name), strings.Join(cmd, " "))
+
+	out := io.Out
+	_, exitCode, err := mach.RunEphemeral(ctx, mach.EphemeralInput{
+		LaunchInput: api.LaunchMachineInput{
+			AppID:  app.Name,
+			Region: region,
+			Config: cfg,
+		},
+		StartTimeout: time.Minute,
+		Timeout:      time.Duration(flag.GetInt(ctx, "timeout")) * time.Second,
+		PrintLog:     func(entry logs.LogEntry) { printOutput(out, entry) },
+	})
+	if err != nil {
+		return fmt.Errorf("failed running the command: %w", err)
+	}
+	if exitCode != 0 {
+		return flyerr.ExitCodeError{Code: exitCode}
+	}
+	return nil
+}
+
+// machineConfig returns the config of a machine running cmd with the image
+// and environment of the machine release runs. release may be nil when image
+// is given.
+func machineConfig(release *api.Machine, cmd []string, image, size string) (*api.MachineConfig, error) {
+	cfg := &api.MachineConfig{}
+	switch {
+	case release != nil:
+		cfg = mach.CloneConfig(release.Config)
+	case image == "":
+		return nil, fmt.Errorf("the app has no release to take the image of, pass --image")
+	}
+
+	cfg.Init.Cmd = cmd
+	cfg.Services = nil
+	cfg.Checks = nil
+	cfg.Mounts = nil
+	cfg.Schedule = ""
+	cfg.ScheduleTimezone = ""
+	cfg.Restart = api.MachineRestart{Policy: api.MachineRestartPolicyNo}
+	cfg.AutoDestroy = true
+	cfg.DNS = &api.DNSConfig{SkipRegistration: true}
+
+	// keep the machine out of those deploys manage
+	delete(cfg.Metadata, api.MachineConfigMetadataKeyFlyPlatformVersion)
+	delete(cfg.Metadata, api.MachineConfigMetadataKeyFlyProcessGroup)
+
+	if image != "" {
+		cfg.Image = image
+	}
+
+	if size != "" {
+		guest, ok := api.MachinePresets[size]
+		if !ok {
+			return nil, fmt.Errorf("invalid --vm-size %s, see fly platform vm-sizes", size)
+		}
+		g := *guest
+		if cfg.Guest != nil {
+			g.KernelArgs = cfg.Guest.KernelArgs
+		}
+		cfg.Guest = &g
+	}
+
+	return cfg, nil
+}
+
+// printOutput prints the messages of the command the machine runs, leaving
+// out those of the platform about the machine.
+func printOutput(w io.Writer, entry logs.LogEntry) {
+	if entry.Meta.Event.Provider != "app" {
+		return
+	}
+	fmt.Fprintln(w, entry.Message)
+}
@@ -0,0 +1,57 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestMachineConfig(t *testing.T) {
+	release := &api.Machine{
+		Region: "cdg",
+		Config: &api.MachineConfig{
+			Image:    "registry.fly.io/app:deployment-1",
+			Env:      map[string]string{"RAILS_ENV": "production"},
+			Init:     api.MachineInit{Cmd: []string{"bin/rails", "server"}},
+			Services: []api.MachineService{{InternalPort: 8080}},
+			Guest:    &api.MachineGuest{CPUKind: "shared", CPUs: 1, MemoryMB: 256, KernelArgs: []string{"quiet"}},
+			Metadata: map[string]string{
+				api.MachineConfigMetadataKeyFlyPlatformVersion: api.MachineFlyPlatformVersion2,
+				api.MachineConfigMetadataKeyFlyProcessGroup:    "app",
+				api.MachineConfigMetadataKeyFlyReleaseVersion:  "3",
+			},
+		},
+	}
+
+	cfg, err := machineConfig(release, []string{"bin/rails", "db:migrate"}, "", "performance-1x")
+	require.NoError(t, err)
+
+	assert.Equal(t, "registry.fly.io/app:deployment-1", cfg.Image)
+	assert.Equal(t, "production", cfg.Env["RAILS_ENV"])
+	assert.Equal(t, []string{"bin/rails", "db:migrate"}, cfg.Init.Cmd)
+	assert.Empty(t, cfg.Services)
+	assert.True(t, cfg.AutoDestroy)
+	assert.Equal(t, api.MachineRestartPolicyNo, cfg.Restart.Policy)
+	assert.Equal(t, "performance", cfg.Guest.CPUKind)
+	assert.Equal(t, []string{"quiet"}, cfg.Guest.KernelArgs)
+	assert.NotContains(t, cfg.Metadata, api.MachineConfigMetadataKeyFlyPlatformVersion)
+	assert.NotContains(t, cfg.Metadata, api.MachineConfigMetadataKeyFlyProcessGroup)
+
+	// the release keeps its config
+	assert.Equal(t, []string{"bin/rails", "server"}, release.Config.Init.Cmd)
+	assert.Len(t, release.Config.Services, 1)
+	assert.Equal(t, "app", release.Config.Metadata[api.MachineConfigMetadataKeyFlyProcessGroup])
+
+	_, err = machineConfig(nil, []string{"true"}, "", "")
+	assert.Error(t, err)
+
+	cfg, err = machineConfig(nil, []string{"true"}, "alpine", "")
+	require.NoError(t, err)
+	assert.Equal(t, "alpine", cfg.Image)
+
+	_, err = machineConfig(release, []string{"true"}, "", "huge")
+	assert.Error(t, err)
+}
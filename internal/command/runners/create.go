@@ -0,0 +1,258 @@
+package runners
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/helpers"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newCreate() *cobra.Command {
+	const (
+		short = "Provision CI runners"
+		long  = `Provision runners for GitHub Actions or GitLab CI as the machines of a
+new app in your organization. Each runner runs a single job.
+
+The app also runs a registrar, which receives the webhooks of the CI provider,
+verifying them with a secret, and starts a stopped runner for every job
+queued. The token given is stored as a secret of the app, along with the
+webhook secret and a token limited to the app the registrar starts runners
+with; runners unset them before running jobs. It's a GitHub personal access
+token allowed to manage self-hosted runners, with which the registrar
+registers each GitHub runner for a single job, or the authentication token of
+a GitLab runner, which GitLab runners pick jobs up with.
+
+--min runners are kept on. The others stop after being idle for
+--idle-timeout, which scales them to zero when there's nothing to run.
+`
+		usage = "create [name]"
+	)
+
+	cmd := command.New(usage, short, long, runCreate,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(cmd,
+		flag.Org(),
+		flag.Region(),
+		flag.String{
+			Name:        "provider",
+			Description: "The CI provider to run jobs of, github or gitlab",
+			Default:     "github",
+		},
+		flag.String{
+			Name:        "url",
+			Description: "The URL runners register with: of the GitHub organization or repository, or of the GitLab instance",
+		},
+		flag.String{
+			Name:        "token",
+			Description: "The token runners register with, read from GITHUB_TOKEN or GITLAB_RUNNER_TOKEN when not set",
+		},
+		flag.String{
+			Name:        "image",
+			Description: "The image of runners, instead of the default of the CI provider",
+		},
+		flag.StringSlice{
+			Name:        "labels",
+			Description: "Labels of runners, which jobs select them by",
+		},
+		flag.Int{
+			Name:        "min",
+			Description: "Number of runners always on",
+		},
+		flag.Int{
+			Name:        "max",
+			Description: "Maximum number of runners",
+			Default:     5,
+		},
+		flag.String{
+			Name:        "vm-size",
+			Description: "The VM size of runners",
+			Default:     "shared-cpu-2x",
+		},
+		flag.String{
+			Name:        "idle-timeout",
+			Description: "How long runners wait for a job before stopping",
+			Default:     "5m",
+		},
+	)
+
+	return cmd
+}
+
+func runCreate(ctx context.Context) error {
+	var (
+		io           = iostreams.FromContext(ctx)
+		colorize     = io.ColorScheme()
+		apiClient    = client.FromContext(ctx).API()
+		providerName = flag.GetString(ctx, "provider")
+		runnerURL    = flag.GetString(ctx, "url")
+		minRunners   = flag.GetInt(ctx, "min")
+		maxRunners   = flag.GetInt(ctx, "max")
+	)
+
+	p, ok := providers[providerName]
+	if !ok {
+		return fmt.Errorf("unknown provider %s, expected github or gitlab", providerName)
+	}
+	if runnerURL == "" {
+		return errors.New("the url runners register with must be set with --url")
+	}
+	if err := checkURL(providerName, runnerURL); err != nil {
+		return err
+	}
+	if maxRunners < 1 || minRunners < 0 || minRunners > maxRunners {
+		return errors.New("--min must be between 0 and --max, and --max at least 1")
+	}
+	idleTimeout, err := time.ParseDuration(flag.GetString(ctx, "idle-timeout"))
+	if err != nil || idleTimeout < time.Minute {
+		return fmt.Errorf("invalid --idle-timeout %s, expected a duration of at least 1m such as 5m", flag.GetString(ctx, "idle-timeout"))
+	}
+	guest, ok := api.MachinePresets[flag.GetString(ctx, "vm-size")]
+	if !ok {
+		return fmt.Errorf("invalid VM size %s, see fly platform vm-sizes", flag.GetString(ctx, "vm-size"))
+	}
+
+	token := flag.GetString(ctx, "token")
+	if token == "" {
+		token = os.Getenv(p.TokenEnv)
+	}
+	if token == "" {
+		switch err := prompt.Password(ctx, &token, "Token runners register with:", true); {
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError(fmt.Sprintf("token must be set with --token or %s when not running interactively", p.TokenEnv))
+		case err != nil:
+			return err
+		}
+	}
+	if err := checkToken(providerName, token); err != nil {
+		return err
+	}
+
+	image := flag.GetString(ctx, "image")
+	if image == "" {
+		image = p.Image
+	}
+
+	cfg := runnerConfig{
+		Provider:    providerName,
+		URL:         runnerURL,
+		Image:       image,
+		Labels:      flag.GetStringSlice(ctx, "labels"),
+		IdleTimeout: idleTimeout,
+		Min:         minRunners,
+		Guest:       guest,
+	}
+
+	org, err := prompt.Org(ctx)
+	if err != nil {
+		return err
+	}
+
+	region := flag.GetRegion(ctx)
+	if region == "" {
+		nearest, err := apiClient.GetNearestRegion(ctx)
+		if err != nil {
+			return err
+		}
+		region = nearest.Code
+	}
+
+	name := flag.FirstArg(ctx)
+	if name == "" {
+		name = fmt.Sprintf("%s-%s-runners", org.Slug, providerName)
+	}
+
+	input := gql.DefaultCreateAppInput()
+	input.Machines = true
+	input.OrganizationId = org.ID
+	input.AppRoleId = appRole
+	input.Name = name
+
+	createResponse, err := gql.CreateApp(ctx, apiClient.GenqClient, input)
+	if err != nil {
+		return fmt.Errorf("failed creating app %s: %w", name, err)
+	}
+	app := createResponse.CreateApp.App.AppData
+	fmt.Fprintf(io.ErrOut, "Created app %s for the runners\n", colorize.Bold(app.Name))
+
+	webhookSecret, err := helpers.RandString(32)
+	if err != nil {
+		return err
+	}
+
+	// the registrar starts runners with a token limited to deploying the app
+	flyToken, err := gql.CreateLimitedAccessToken(ctx, apiClient.GenqClient, app.Name+"-registrar", org.ID, "deploy", &gql.LimitedAccessTokenOptions{
+		"app_id": app.Id,
+	})
+	if err != nil {
+		return fmt.Errorf("failed creating a token for the registrar: %w", err)
+	}
+
+	// set before any machine is created, for all of them to get the secrets
+	if _, err := apiClient.SetSecrets(ctx, app.Name, map[string]string{
+		"RUNNER_TOKEN":   token,
+		"WEBHOOK_SECRET": webhookSecret,
+		"FLY_API_TOKEN":  flyToken.CreateLimitedAccessToken.LimitedAccessToken.TokenHeader,
+	}); err != nil {
+		return fmt.Errorf("failed setting the secrets of the runners: %w", err)
+	}
+
+	if _, err := apiClient.AllocateIPAddress(ctx, app.Name, "v6", "", org, ""); err != nil {
+		return fmt.Errorf("failed allocating an IP address: %w", err)
+	}
+	if _, err := apiClient.AllocateSharedIPAddress(ctx, app.Name); err != nil {
+		return fmt.Errorf("failed allocating an IP address: %w", err)
+	}
+
+	flapsClient, err := flaps.New(ctx, gql.AppForFlaps(app))
+	if err != nil {
+		return err
+	}
+
+	// runners are created stopped, it's the registrar which starts them
+	for i := 0; i < maxRunners; i++ {
+		machine, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+			AppID:      app.Name,
+			Region:     region,
+			Config:     machineConfig(cfg, i < minRunners),
+			SkipLaunch: true,
+		})
+		if err != nil {
+			return fmt.Errorf("failed creating runner %d of %d: %w", i+1, maxRunners, err)
+		}
+		fmt.Fprintf(io.ErrOut, "Created runner %s in %s\n", machine.ID, region)
+	}
+
+	registrar, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+		AppID:  app.Name,
+		Region: region,
+		Config: registrarConfig(app.Name, cfg),
+	})
+	if err != nil {
+		return fmt.Errorf("failed launching the registrar: %w", err)
+	}
+	fmt.Fprintf(io.ErrOut, "Launched registrar %s in %s\n", registrar.ID, region)
+
+	fmt.Fprintf(io.Out, "\nRunners %s are registering with %s\n", colorize.Bold(app.Name), runnerURL)
+	fmt.Fprintf(io.Out, "To start runners when jobs are queued, add %s to %s:\n", p.Webhook, runnerURL)
+	fmt.Fprintf(io.Out, "  URL:    https://%s.fly.dev/webhook\n", app.Name)
+	fmt.Fprintf(io.Out, "  Secret: %s\n", webhookSecret)
+
+	return nil
+}
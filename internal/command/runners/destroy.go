@@ -0,0 +1,70 @@
+package runners
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newDestroy() *cobra.Command {
+	const (
+		short = "Destroy CI runners"
+		long  = `Destroy CI runners along with their app. Jobs they're running are
+interrupted.
+`
+		usage = "destroy <name>"
+	)
+
+	cmd := command.New(usage, short, long, runDestroy,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.Yes(),
+	)
+
+	return cmd
+}
+
+func runDestroy(ctx context.Context) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		name     = flag.FirstArg(ctx)
+	)
+
+	app, err := runnerApp(ctx, name)
+	if err != nil {
+		return err
+	}
+
+	if !flag.GetYes(ctx) {
+		switch confirmed, err := prompt.Confirmf(ctx, "Destroy runners %s? Jobs they're running will be interrupted.", app.Name); {
+		case err == nil:
+			if !confirmed {
+				return nil
+			}
+		case prompt.IsNonInteractive(err):
+			return prompt.NonInteractiveError("yes flag must be specified when not running interactively")
+		default:
+			return err
+		}
+	}
+
+	if err := client.FromContext(ctx).API().DeleteApp(ctx, app.Name); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(io.Out, "Destroyed runners %s, remove their webhook from the CI provider as well\n", colorize.Bold(app.Name))
+
+	return nil
+}
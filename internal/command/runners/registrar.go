@@ -0,0 +1,445 @@
+package runners
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/terminal"
+)
+
+const (
+	// maxWebhookSize caps the size of the webhooks the registrar reads.
+	maxWebhookSize = 1 << 20
+
+	// reconcileInterval is how often the registrar keeps --min runners on
+	// and stops idle GitHub runners.
+	reconcileInterval = time.Minute
+)
+
+var (
+	errBadSignature     = errors.New("invalid webhook signature")
+	errNoStoppedRunners = errors.New("every runner is busy")
+)
+
+// githubDefaultLabels are the labels GitHub gives every self-hosted runner.
+var githubDefaultLabels = []string{"self-hosted", "linux", "x64"}
+
+func newRegistrar() *cobra.Command {
+	const (
+		short = "Start CI runners for the jobs the CI provider queues"
+		long  = `Receive the webhooks of the CI provider, verified with WEBHOOK_SECRET, and
+start a stopped runner for every job queued. GitHub runners are started with
+a just-in-time configuration registering them for a single job, got with
+RUNNER_TOKEN, which runners unset before running jobs. This is what the
+registrar machine of runners runs.
+`
+	)
+
+	cmd := command.New("registrar", short, long, runRegistrar,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Hidden = true
+
+	flag.Add(cmd,
+		flag.App(),
+	)
+
+	return cmd
+}
+
+// registrar starts the runners of an app for the jobs the CI provider
+// queues.
+type registrar struct {
+	provider      string
+	url           string
+	labels        []string
+	token         string
+	webhookSecret string
+	min           int
+	idleTimeout   time.Duration
+
+	flapsClient *flaps.Client
+	httpClient  *http.Client
+	// githubAPI is the endpoint of the GitHub runners of url
+	githubAPI string
+	// start starts a stopped runner, startRunner unless testing
+	start func(context.Context) error
+
+	mu sync.Mutex
+}
+
+func runRegistrar(ctx context.Context) error {
+	idleTimeout, err := time.ParseDuration(os.Getenv("RUNNER_IDLE_TIMEOUT"))
+	if err != nil {
+		return fmt.Errorf("invalid RUNNER_IDLE_TIMEOUT: %w", err)
+	}
+	min, err := strconv.Atoi(os.Getenv("RUNNER_MIN"))
+	if err != nil {
+		return fmt.Errorf("invalid RUNNER_MIN: %w", err)
+	}
+
+	r := &registrar{
+		provider:      os.Getenv("RUNNER_PROVIDER"),
+		url:           os.Getenv("RUNNER_URL"),
+		token:         os.Getenv("RUNNER_TOKEN"),
+		webhookSecret: os.Getenv("WEBHOOK_SECRET"),
+		min:           min,
+		idleTimeout:   idleTimeout,
+		httpClient:    &http.Client{Timeout: 30 * time.Second},
+	}
+	if labels := os.Getenv("RUNNER_LABELS"); labels != "" {
+		r.labels = strings.Split(labels, ",")
+	}
+	if r.webhookSecret == "" || r.token == "" {
+		return errors.New("WEBHOOK_SECRET and RUNNER_TOKEN must be set")
+	}
+	if r.provider == "github" {
+		if r.githubAPI, err = githubRunnersEndpoint(r.url); err != nil {
+			return err
+		}
+	}
+	if r.flapsClient, err = flaps.NewFromAppName(ctx, appconfig.NameFromContext(ctx)); err != nil {
+		return err
+	}
+	r.start = r.startRunner
+
+	go func() {
+		for {
+			r.reconcile(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(reconcileInterval):
+			}
+		}
+	}()
+
+	server := &http.Server{
+		Addr:              fmt.Sprintf(":%d", registrarPort),
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		server.Close()
+	}()
+
+	terminal.Infof("Registrar of %s runners listening on %s\n", r.provider, server.Addr)
+	if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+func (r *registrar) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost || req.URL.Path != "/webhook" {
+		http.NotFound(w, req)
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(req.Body, maxWebhookSize))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	queued, err := r.queuedJob(req.Header, body)
+	switch {
+	case errors.Is(err, errBadSignature):
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	case err != nil:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	case !queued:
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+
+	if err := r.start(req.Context()); err != nil {
+		terminal.Warnf("failed starting a runner: %v\n", err)
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// queuedJob verifies the webhook of the given header and body was sent by
+// the CI provider, and reports whether it's about a job queued for runners
+// with the labels of r.
+func (r *registrar) queuedJob(header http.Header, body []byte) (bool, error) {
+	switch r.provider {
+	case "github":
+		if !validGitHubSignature(r.webhookSecret, header.Get("X-Hub-Signature-256"), body) {
+			return false, errBadSignature
+		}
+		if header.Get("X-GitHub-Event") != "workflow_job" {
+			return false, nil
+		}
+
+		var event struct {
+			Action      string `json:"action"`
+			WorkflowJob struct {
+				Labels []string `json:"labels"`
+			} `json:"workflow_job"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil {
+			return false, err
+		}
+		return event.Action == "queued" && runsOn(event.WorkflowJob.Labels, r.labels), nil
+	case "gitlab":
+		if subtle.ConstantTimeCompare([]byte(header.Get("X-Gitlab-Token")), []byte(r.webhookSecret)) != 1 {
+			return false, errBadSignature
+		}
+		if header.Get("X-Gitlab-Event") != "Job Hook" {
+			return false, nil
+		}
+
+		var event struct {
+			BuildStatus string `json:"build_status"`
+		}
+		if err := json.Unmarshal(body, &event); err != nil {
+			return false, err
+		}
+		return event.BuildStatus == "pending", nil
+	default:
+		return false, fmt.Errorf("unknown provider %s", r.provider)
+	}
+}
+
+// validGitHubSignature reports whether signature, the X-Hub-Signature-256
+// header of a GitHub webhook, is the HMAC of body keyed with secret.
+func validGitHubSignature(secret, signature string, body []byte) bool {
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, "sha256="))
+	if err != nil || !strings.HasPrefix(signature, "sha256=") {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// runsOn reports whether a GitHub job asking for jobLabels runs on runners
+// with the given labels, besides the ones GitHub gives every runner.
+func runsOn(jobLabels, labels []string) bool {
+	all := append(append([]string{}, githubDefaultLabels...), labels...)
+	return lo.EveryBy(jobLabels, func(l string) bool {
+		return lo.ContainsBy(all, func(label string) bool { return strings.EqualFold(label, l) })
+	})
+}
+
+// githubRunnersEndpoint returns the API endpoint of the self-hosted runners
+// of the GitHub organization or repository at runnerURL.
+func githubRunnersEndpoint(runnerURL string) (string, error) {
+	u, err := url.Parse(runnerURL)
+	if err != nil {
+		return "", err
+	}
+
+	base := "https://api.github.com"
+	if u.Host != "github.com" {
+		base = fmt.Sprintf("https://%s/api/v3", u.Host)
+	}
+
+	switch parts := strings.Split(strings.Trim(u.Path, "/"), "/"); len(parts) {
+	case 1:
+		return fmt.Sprintf("%s/orgs/%s/actions/runners", base, parts[0]), nil
+	case 2:
+		return fmt.Sprintf("%s/repos/%s/%s/actions/runners", base, parts[0], parts[1]), nil
+	default:
+		return "", fmt.Errorf("runner url must be the URL of a GitHub organization or repository, got %q", runnerURL)
+	}
+}
+
+// runners lists the runner machines of the app in the given state.
+func (r *registrar) runners(ctx context.Context, state string) ([]*api.Machine, error) {
+	machines, err := r.flapsClient.List(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+
+	return lo.Filter(machines, func(m *api.Machine, _ int) bool {
+		return !isRegistrar(m) && m.State == state
+	}), nil
+}
+
+// startRunner starts a stopped runner, registered for a single job when it's
+// a GitHub one.
+func (r *registrar) startRunner(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stopped, err := r.runners(ctx, api.MachineStateStopped)
+	if err != nil {
+		return err
+	}
+	if len(stopped) == 0 {
+		return errNoStoppedRunners
+	}
+	m := stopped[0]
+
+	if r.provider != "github" {
+		_, err := r.flapsClient.Start(ctx, m.ID)
+		return err
+	}
+
+	lease, err := r.flapsClient.AcquireLease(ctx, m.ID, api.IntPointer(60))
+	if err != nil {
+		return err
+	}
+	defer r.flapsClient.ReleaseLease(ctx, m.ID, lease.Data.Nonce)
+
+	jitConfig, runnerID, err := r.generateJITConfig(ctx, fmt.Sprintf("fly-%s-%d", m.ID, time.Now().Unix()))
+	if err != nil {
+		return err
+	}
+
+	cfg := machine.CloneConfig(m.Config)
+	cfg.Env["RUNNER_JITCONFIG"] = jitConfig
+	cfg.Metadata[metadataKeyRunnerID] = strconv.FormatInt(runnerID, 10)
+	cfg.Metadata[metadataKeyStartedAt] = time.Now().UTC().Format(time.RFC3339)
+
+	_, err = r.flapsClient.Update(ctx, api.LaunchMachineInput{ID: m.ID, Region: m.Region, Config: cfg}, lease.Data.Nonce)
+	return err
+}
+
+// generateJITConfig registers a GitHub runner named name for a single job,
+// and returns its just-in-time configuration along with its ID.
+func (r *registrar) generateJITConfig(ctx context.Context, name string) (string, int64, error) {
+	body, err := json.Marshal(map[string]any{
+		"name":            name,
+		"runner_group_id": 1,
+		"labels":          append(append([]string{}, githubDefaultLabels...), r.labels...),
+	})
+	if err != nil {
+		return "", 0, err
+	}
+
+	var out struct {
+		Runner struct {
+			ID int64 `json:"id"`
+		} `json:"runner"`
+		EncodedJITConfig string `json:"encoded_jit_config"`
+	}
+	if err := r.github(ctx, http.MethodPost, r.githubAPI+"/generate-jitconfig", body, &out); err != nil {
+		return "", 0, fmt.Errorf("failed registering runner %s: %w", name, err)
+	}
+
+	return out.EncodedJITConfig, out.Runner.ID, nil
+}
+
+// github sends a request to the GitHub API, authenticated with r.token.
+func (r *registrar) github(ctx context.Context, method, endpoint string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+r.token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+
+	res, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 1024))
+		return fmt.Errorf("GitHub API returned %s: %s", res.Status, bytes.TrimSpace(msg))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(res.Body).Decode(out)
+}
+
+// reconcile keeps r.min runners on and, GitHub runners not exiting on their
+// own, stops the ones idle for longer than r.idleTimeout beyond those.
+func (r *registrar) reconcile(ctx context.Context) {
+	started, err := r.runners(ctx, api.MachineStateStarted)
+	if err != nil {
+		terminal.Warnf("failed listing runners: %v\n", err)
+		return
+	}
+
+	for i := len(started); i < r.min; i++ {
+		if err := r.start(ctx); err != nil {
+			terminal.Warnf("failed starting a runner: %v\n", err)
+			break
+		}
+	}
+
+	if r.provider != "github" {
+		return
+	}
+	for _, m := range idleCandidates(started, r.min, r.idleTimeout, time.Now()) {
+		var runner struct {
+			Busy bool `json:"busy"`
+		}
+		id := m.Config.Metadata[metadataKeyRunnerID]
+		if err := r.github(ctx, http.MethodGet, r.githubAPI+"/"+id, nil, &runner); err != nil || runner.Busy {
+			continue
+		}
+		// GitHub refuses to remove runners which picked a job up meanwhile
+		if err := r.github(ctx, http.MethodDelete, r.githubAPI+"/"+id, nil, nil); err != nil {
+			continue
+		}
+		if err := r.flapsClient.Stop(ctx, api.StopMachineInput{ID: m.ID}); err != nil {
+			terminal.Warnf("failed stopping idle runner %s: %v\n", m.ID, err)
+		}
+	}
+}
+
+// idleCandidates returns the started runners which may be idle for longer
+// than idleTimeout, oldest first, keeping enough of the most recently
+// started ones on for min runners to stay on.
+func idleCandidates(started []*api.Machine, min int, idleTimeout time.Duration, now time.Time) []*api.Machine {
+	startedAt := func(m *api.Machine) time.Time {
+		t, _ := time.Parse(time.RFC3339, m.Config.Metadata[metadataKeyStartedAt])
+		return t
+	}
+
+	candidates := lo.Filter(started, func(m *api.Machine, _ int) bool {
+		return m.Config.Metadata[metadataKeyRunnerID] != "" && !startedAt(m).IsZero() && now.Sub(startedAt(m)) > idleTimeout
+	})
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return startedAt(candidates[i]).Before(startedAt(candidates[j]))
+	})
+
+	if keep := min - (len(started) - len(candidates)); keep > 0 {
+		if keep >= len(candidates) {
+			return nil
+		}
+		candidates = candidates[:len(candidates)-keep]
+	}
+	return candidates
+}
@@ -0,0 +1,198 @@
+package runners
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func githubSignature(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestValidGitHubSignature(t *testing.T) {
+	body := []byte(`{"action":"queued"}`)
+
+	assert.True(t, validGitHubSignature("secret", githubSignature("secret", string(body)), body))
+	assert.False(t, validGitHubSignature("secret", githubSignature("other", string(body)), body))
+	assert.False(t, validGitHubSignature("secret", githubSignature("secret", `{"action":"completed"}`), body))
+	assert.False(t, validGitHubSignature("secret", strings.TrimPrefix(githubSignature("secret", string(body)), "sha256="), body))
+	assert.False(t, validGitHubSignature("secret", "", body))
+}
+
+func TestRunsOn(t *testing.T) {
+	assert.True(t, runsOn([]string{"self-hosted"}, nil))
+	assert.True(t, runsOn([]string{"self-hosted", "Linux", "fly"}, []string{"fly"}))
+	assert.False(t, runsOn([]string{"self-hosted", "gpu"}, []string{"fly"}))
+	assert.False(t, runsOn([]string{"ubuntu-latest"}, []string{"fly"}))
+}
+
+func TestGitHubRunnersEndpoint(t *testing.T) {
+	cases := map[string]string{
+		"https://github.com/acme":            "https://api.github.com/orgs/acme/actions/runners",
+		"https://github.com/acme/app/":       "https://api.github.com/repos/acme/app/actions/runners",
+		"https://ghe.example.com/acme/app":   "https://ghe.example.com/api/v3/repos/acme/app/actions/runners",
+		"https://github.com/acme/app/issues": "",
+	}
+	for runnerURL, expected := range cases {
+		endpoint, err := githubRunnersEndpoint(runnerURL)
+		if expected == "" {
+			assert.Error(t, err, runnerURL)
+			continue
+		}
+		require.NoError(t, err, runnerURL)
+		assert.Equal(t, expected, endpoint)
+	}
+}
+
+func TestRegistrarWebhook(t *testing.T) {
+	const queued = `{"action":"queued","workflow_job":{"labels":["self-hosted","fly"]}}`
+
+	cases := []struct {
+		name     string
+		provider string
+		header   map[string]string
+		body     string
+		startErr error
+		status   int
+		started  bool
+	}{
+		{
+			name:     "github job queued",
+			provider: "github",
+			header:   map[string]string{"X-GitHub-Event": "workflow_job", "X-Hub-Signature-256": githubSignature("whsecret", queued)},
+			body:     queued,
+			status:   http.StatusAccepted,
+			started:  true,
+		},
+		{
+			name:     "github bad signature",
+			provider: "github",
+			header:   map[string]string{"X-GitHub-Event": "workflow_job", "X-Hub-Signature-256": githubSignature("guess", queued)},
+			body:     queued,
+			status:   http.StatusUnauthorized,
+		},
+		{
+			name:     "github unsigned",
+			provider: "github",
+			header:   map[string]string{"X-GitHub-Event": "workflow_job"},
+			body:     queued,
+			status:   http.StatusUnauthorized,
+		},
+		{
+			name:     "github job completed",
+			provider: "github",
+			header:   map[string]string{"X-GitHub-Event": "workflow_job", "X-Hub-Signature-256": githubSignature("whsecret", `{"action":"completed"}`)},
+			body:     `{"action":"completed"}`,
+			status:   http.StatusNoContent,
+		},
+		{
+			name:     "github job for other runners",
+			provider: "github",
+			header: map[string]string{"X-GitHub-Event": "workflow_job", "X-Hub-Signature-256": githubSignature("whsecret",
+				`{"action":"queued","workflow_job":{"labels":["ubuntu-latest"]}}`)},
+			body:   `{"action":"queued","workflow_job":{"labels":["ubuntu-latest"]}}`,
+			status: http.StatusNoContent,
+		},
+		{
+			name:     "github every runner busy",
+			provider: "github",
+			header:   map[string]string{"X-GitHub-Event": "workflow_job", "X-Hub-Signature-256": githubSignature("whsecret", queued)},
+			body:     queued,
+			startErr: errNoStoppedRunners,
+			status:   http.StatusServiceUnavailable,
+			started:  true,
+		},
+		{
+			name:     "gitlab job pending",
+			provider: "gitlab",
+			header:   map[string]string{"X-Gitlab-Event": "Job Hook", "X-Gitlab-Token": "whsecret"},
+			body:     `{"build_status":"pending"}`,
+			status:   http.StatusAccepted,
+			started:  true,
+		},
+		{
+			name:     "gitlab bad token",
+			provider: "gitlab",
+			header:   map[string]string{"X-Gitlab-Event": "Job Hook", "X-Gitlab-Token": "guess"},
+			body:     `{"build_status":"pending"}`,
+			status:   http.StatusUnauthorized,
+		},
+		{
+			name:     "gitlab job running",
+			provider: "gitlab",
+			header:   map[string]string{"X-Gitlab-Event": "Job Hook", "X-Gitlab-Token": "whsecret"},
+			body:     `{"build_status":"running"}`,
+			status:   http.StatusNoContent,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			started := false
+			r := &registrar{
+				provider:      tc.provider,
+				labels:        []string{"fly"},
+				webhookSecret: "whsecret",
+				start: func(context.Context) error {
+					started = true
+					return tc.startErr
+				},
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tc.body))
+			for k, v := range tc.header {
+				req.Header.Set(k, v)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.Equal(t, tc.started, started)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	(&registrar{provider: "github"}).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestIdleCandidates(t *testing.T) {
+	now := time.Date(2023, 6, 1, 12, 0, 0, 0, time.UTC)
+	runner := func(id string, startedAgo time.Duration) *api.Machine {
+		return &api.Machine{ID: id, Config: &api.MachineConfig{Metadata: map[string]string{
+			metadataKeyRunnerID:  "42",
+			metadataKeyStartedAt: now.Add(-startedAgo).Format(time.RFC3339),
+		}}}
+	}
+	started := []*api.Machine{
+		runner("recent", time.Minute),
+		runner("newer", 10*time.Minute),
+		runner("oldest", time.Hour),
+		{ID: "unregistered", Config: &api.MachineConfig{}},
+	}
+	ids := func(machines []*api.Machine) (ids []string) {
+		for _, m := range machines {
+			ids = append(ids, m.ID)
+		}
+		return
+	}
+
+	assert.Equal(t, []string{"oldest", "newer"}, ids(idleCandidates(started, 0, 5*time.Minute, now)))
+	assert.Equal(t, []string{"oldest", "newer"}, ids(idleCandidates(started, 2, 5*time.Minute, now)), "runners not idle count toward the minimum")
+	assert.Equal(t, []string{"oldest"}, ids(idleCandidates(started, 3, 5*time.Minute, now)), "the most recently started are kept on")
+	assert.Empty(t, idleCandidates(started, 4, 5*time.Minute, now))
+}
@@ -0,0 +1,263 @@
+// Package runners implements the runners command chain, which manages CI
+// runners for GitHub Actions and GitLab as machines of an app.
+package runners
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/command"
+)
+
+// appRole is the role of the apps runners are machines of, by which they're
+// told apart from other apps.
+const appRole = "ci-runner"
+
+const (
+	metadataKeyProvider = "fly_runner_provider"
+	// metadataKeyRole tells the registrar machine of runners apart from the
+	// runners themselves.
+	metadataKeyRole = "fly_runner_role"
+	// metadataKeyRunnerID and metadataKeyStartedAt record which GitHub runner
+	// a runner machine was last started as, and when.
+	metadataKeyRunnerID  = "fly_runner_id"
+	metadataKeyStartedAt = "fly_runner_started_at"
+
+	roleRunner    = "runner"
+	roleRegistrar = "registrar"
+
+	// registrarPort is the port the registrar receives the webhooks of the CI
+	// provider on.
+	registrarPort = 8080
+
+	// registrarImage is the image of the registrar machine, which runs
+	// fly runners registrar.
+	registrarImage = "flyio/flyctl:latest"
+)
+
+// registrarSecrets are the secrets of the app only the registrar uses: the
+// token runners register with, the webhook secret and the token runners are
+// started with. Being secrets of the app, every machine of it gets them in
+// its environment, so runners unset them before running jobs.
+var registrarSecrets = []string{"RUNNER_TOKEN", "WEBHOOK_SECRET", "FLY_API_TOKEN"}
+
+// provider is a CI provider runners can be provisioned for.
+type provider struct {
+	// Image is the default image of runners, the official runner image of the
+	// CI provider.
+	Image string
+	// TokenEnv is the environment variable the token is read from when no
+	// --token is given.
+	TokenEnv string
+	// Webhook describes the webhook to add to the CI provider.
+	Webhook string
+}
+
+var providers = map[string]provider{
+	"github": {
+		Image:    "ghcr.io/actions/actions-runner:latest",
+		TokenEnv: "GITHUB_TOKEN",
+		Webhook:  "a webhook sending Workflow jobs events",
+	},
+	"gitlab": {
+		Image:    "gitlab/gitlab-runner:latest",
+		TokenEnv: "GITLAB_RUNNER_TOKEN",
+		Webhook:  "a webhook sending Job events",
+	},
+}
+
+func New() *cobra.Command {
+	const (
+		short = "Manage CI runners"
+		long  = `Commands for managing ephemeral GitHub Actions and GitLab CI runners
+that run as machines in your organization and scale to zero when idle.
+`
+	)
+
+	cmd := command.New("runners", short, long, nil)
+
+	cmd.AddCommand(
+		newCreate(),
+		newStatus(),
+		newDestroy(),
+		newRegistrar(),
+	)
+
+	return cmd
+}
+
+// checkURL checks rawURL is what runners of provider register with: the
+// https URL of a GitHub organization or repository, or of a GitLab instance.
+func checkURL(providerName, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("runner url must be an https URL, got %q", rawURL)
+	}
+
+	if providerName == "github" {
+		if path := strings.Trim(u.Path, "/"); path == "" || strings.Count(path, "/") > 1 {
+			return fmt.Errorf("runner url must be the URL of a GitHub organization or repository, such as https://github.com/acme or https://github.com/acme/app, got %q", rawURL)
+		}
+	}
+
+	return nil
+}
+
+// checkToken checks token can register runners for longer than an hour.
+// Runners are registered each time their machine starts, so rather than the
+// registration tokens the CI providers hand out, which expire, the token must
+// be one runners can get registration tokens with.
+func checkToken(providerName, token string) error {
+	switch providerName {
+	case "github":
+		if !lo.SomeBy([]string{"ghp_", "github_pat_", "gho_"}, func(prefix string) bool { return strings.HasPrefix(token, prefix) }) {
+			return fmt.Errorf("the token must be a GitHub personal access token allowed to manage self-hosted runners; runner registration tokens and app installation tokens expire within an hour, after which runners couldn't register anymore")
+		}
+	case "gitlab":
+		if strings.HasPrefix(token, "GR1348941") {
+			return fmt.Errorf("runner registration tokens are deprecated by GitLab, create a runner in GitLab and use its authentication token, starting with glrt-, instead")
+		}
+		if !strings.HasPrefix(token, "glrt-") {
+			return fmt.Errorf("the token must be the authentication token of a GitLab runner, starting with glrt-")
+		}
+	}
+
+	return nil
+}
+
+type runnerConfig struct {
+	Provider    string
+	URL         string
+	Image       string
+	Labels      []string
+	IdleTimeout time.Duration
+	Min         int
+	Guest       *api.MachineGuest
+}
+
+// machineConfig returns the config of a runner machine, which runs a single
+// job and exits, stopping the machine, and has no services: runners only
+// connect out to the CI provider, and it's the registrar which starts them.
+//
+// The registrar secrets are unset before the runner starts, so jobs can't
+// read them. GitHub runners are started with a just-in-time configuration the
+// registrar gets for each of them, so they never see the token runners
+// register with. GitLab runners authenticate with the token of the GitLab
+// runner itself, which only lets them pick jobs up, taken from the
+// RUNNER_TOKEN secret; they exit once idle for cfg.IdleTimeout, unless always
+// on.
+func machineConfig(cfg runnerConfig, alwaysOn bool) *api.MachineConfig {
+	guest := *cfg.Guest
+
+	mc := &api.MachineConfig{
+		Image: cfg.Image,
+		Env: map[string]string{
+			"RUNNER_PROVIDER": cfg.Provider,
+			"RUNNER_URL":      cfg.URL,
+			"RUNNER_LABELS":   strings.Join(cfg.Labels, ","),
+		},
+		Metadata: map[string]string{
+			metadataKeyProvider: cfg.Provider,
+			metadataKeyRole:     roleRunner,
+		},
+		Restart: api.MachineRestart{Policy: api.MachineRestartPolicyNo},
+		Guest:   &guest,
+	}
+
+	switch cfg.Provider {
+	case "github":
+		mc.Init.Entrypoint = []string{"/bin/sh", "-c", unsetRegistrarSecrets + `exec /home/runner/run.sh --jitconfig "$RUNNER_JITCONFIG"`}
+	case "gitlab":
+		waitTimeout := int(cfg.IdleTimeout.Seconds())
+		if alwaysOn {
+			waitTimeout = 0
+			mc.Restart.Policy = api.MachineRestartPolicyAlways
+		}
+		// the arguments are passed on to the entrypoint of the image, which
+		// runs gitlab-runner with them
+		mc.Init.Entrypoint = []string{"/bin/sh", "-c", `export CI_SERVER_TOKEN="$RUNNER_TOKEN"; ` + unsetRegistrarSecrets + `exec /usr/bin/dumb-init /entrypoint "$@"`, "sh"}
+		mc.Init.Cmd = []string{
+			"run-single",
+			"--url", cfg.URL,
+			"--executor", "shell",
+			"--max-builds", "1",
+			"--wait-timeout", strconv.Itoa(waitTimeout),
+		}
+	}
+
+	return mc
+}
+
+// unsetRegistrarSecrets is the shell command runners unset the registrar
+// secrets with.
+var unsetRegistrarSecrets = "unset " + strings.Join(registrarSecrets, " ") + "; "
+
+// registrarConfig returns the config of the registrar machine, the only one
+// of the app using the registrar secrets, and the only one reachable from the
+// internet, to receive webhooks.
+func registrarConfig(appName string, cfg runnerConfig) *api.MachineConfig {
+	return &api.MachineConfig{
+		Image: registrarImage,
+		Init: api.MachineInit{
+			Cmd: []string{"runners", "registrar", "--app", appName},
+		},
+		Env: map[string]string{
+			"RUNNER_PROVIDER":     cfg.Provider,
+			"RUNNER_URL":          cfg.URL,
+			"RUNNER_LABELS":       strings.Join(cfg.Labels, ","),
+			"RUNNER_IDLE_TIMEOUT": cfg.IdleTimeout.String(),
+			"RUNNER_MIN":          strconv.Itoa(cfg.Min),
+		},
+		Metadata: map[string]string{
+			metadataKeyProvider: cfg.Provider,
+			metadataKeyRole:     roleRegistrar,
+		},
+		Restart: api.MachineRestart{Policy: api.MachineRestartPolicyAlways},
+		Services: []api.MachineService{
+			{
+				Protocol:     "tcp",
+				InternalPort: registrarPort,
+				Ports: []api.MachinePort{
+					{Port: api.IntPointer(80), Handlers: []string{"http"}, ForceHttps: true},
+					{Port: api.IntPointer(443), Handlers: []string{"tls", "http"}},
+				},
+			},
+		},
+		Guest: api.MachinePresets["shared-cpu-1x"],
+	}
+}
+
+func isRegistrar(m *api.Machine) bool {
+	return m.Config != nil && m.Config.Metadata[metadataKeyRole] == roleRegistrar
+}
+
+// runnerApp returns the app of the runners named name, making sure it's one.
+func runnerApp(ctx context.Context, name string) (*gql.AppData, error) {
+	genqClient := client.FromContext(ctx).API().GenqClient
+
+	appResponse, err := gql.GetApp(ctx, genqClient, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed retrieving runners %s: %w", name, err)
+	}
+	app := appResponse.App.AppData
+
+	rolesResponse, err := gql.GetAppsByRole(ctx, genqClient, appRole, app.Organization.Id)
+	if err != nil {
+		return nil, err
+	}
+	if !lo.ContainsBy(rolesResponse.Apps.Nodes, func(a gql.GetAppsByRoleAppsAppConnectionNodesApp) bool { return a.Id == app.Id }) {
+		return nil, fmt.Errorf("app %s doesn't run CI runners", name)
+	}
+
+	return &app, nil
+}
@@ -0,0 +1,88 @@
+package runners
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestCheckURL(t *testing.T) {
+	assert.NoError(t, checkURL("github", "https://github.com/acme"))
+	assert.NoError(t, checkURL("github", "https://github.com/acme/app/"))
+	assert.Error(t, checkURL("github", "https://github.com/"))
+	assert.Error(t, checkURL("github", "https://github.com/acme/app/tree/main"))
+	assert.Error(t, checkURL("github", "http://github.com/acme"))
+	assert.NoError(t, checkURL("gitlab", "https://gitlab.example.com"))
+	assert.Error(t, checkURL("gitlab", "gitlab.example.com"))
+}
+
+func TestCheckToken(t *testing.T) {
+	assert.NoError(t, checkToken("github", "ghp_abc123"))
+	assert.NoError(t, checkToken("github", "github_pat_abc123"))
+	assert.ErrorContains(t, checkToken("github", "AABCDEFGHIJKLMNOPQRSTUVWXYZ12"), "expire")
+	assert.ErrorContains(t, checkToken("github", "ghs_abc123"), "expire")
+	assert.NoError(t, checkToken("gitlab", "glrt-abc123"))
+	assert.ErrorContains(t, checkToken("gitlab", "GR1348941abc123"), "deprecated")
+	assert.Error(t, checkToken("gitlab", "glpat-abc123"))
+}
+
+func TestMachineConfig(t *testing.T) {
+	cfg := runnerConfig{
+		Provider:    "github",
+		URL:         "https://github.com/acme",
+		Image:       providers["github"].Image,
+		Labels:      []string{"linux", "fly"},
+		IdleTimeout: 5 * time.Minute,
+		Guest:       api.MachinePresets["shared-cpu-2x"],
+	}
+
+	github := machineConfig(cfg, false)
+	assert.Equal(t, "linux,fly", github.Env["RUNNER_LABELS"])
+	assert.Equal(t, api.MachineRestartPolicyNo, github.Restart.Policy)
+	assert.Equal(t, "github", github.Metadata[metadataKeyProvider])
+	assert.Equal(t, roleRunner, github.Metadata[metadataKeyRole])
+	assert.Contains(t, github.Init.Entrypoint[2], `--jitconfig "$RUNNER_JITCONFIG"`)
+	assert.Empty(t, github.Services, "runners aren't reachable from the internet")
+	assert.NotSame(t, cfg.Guest, github.Guest)
+	for _, secret := range registrarSecrets {
+		assert.NotContains(t, github.Env, secret)
+		assert.Contains(t, github.Init.Entrypoint[2], secret, "jobs must not get %s", secret)
+	}
+
+	cfg.Provider = "gitlab"
+	cfg.URL = "https://gitlab.example.com"
+	gitlab := machineConfig(cfg, false)
+	assert.NotContains(t, gitlab.Env, "CI_SERVER_TOKEN")
+	assert.Contains(t, gitlab.Init.Entrypoint[2], `export CI_SERVER_TOKEN="$RUNNER_TOKEN"; unset RUNNER_TOKEN WEBHOOK_SECRET FLY_API_TOKEN; `)
+	assert.Equal(t, []string{"run-single", "--url", "https://gitlab.example.com", "--executor", "shell", "--max-builds", "1", "--wait-timeout", "300"}, gitlab.Init.Cmd)
+	assert.Equal(t, api.MachineRestartPolicyNo, gitlab.Restart.Policy)
+
+	alwaysOn := machineConfig(cfg, true)
+	assert.Equal(t, "0", alwaysOn.Init.Cmd[len(alwaysOn.Init.Cmd)-1])
+	assert.Equal(t, api.MachineRestartPolicyAlways, alwaysOn.Restart.Policy)
+}
+
+func TestRegistrarConfig(t *testing.T) {
+	cfg := runnerConfig{
+		Provider:    "github",
+		URL:         "https://github.com/acme",
+		Labels:      []string{"fly"},
+		IdleTimeout: 5 * time.Minute,
+		Min:         2,
+	}
+
+	mc := registrarConfig("acme-runners", cfg)
+	assert.Equal(t, registrarImage, mc.Image)
+	assert.Equal(t, []string{"runners", "registrar", "--app", "acme-runners"}, mc.Init.Cmd)
+	for _, secret := range registrarSecrets {
+		assert.NotContains(t, mc.Env, secret, "secrets are app secrets, not in the config")
+	}
+	assert.Equal(t, "2", mc.Env["RUNNER_MIN"])
+	assert.Equal(t, "5m0s", mc.Env["RUNNER_IDLE_TIMEOUT"])
+	assert.Equal(t, registrarPort, mc.Services[0].InternalPort)
+	assert.True(t, isRegistrar(&api.Machine{Config: mc}))
+	assert.False(t, isRegistrar(&api.Machine{Config: machineConfig(runnerConfig{Provider: "github", Guest: &api.MachineGuest{}}, false)}))
+}
@@ -0,0 +1,114 @@
+package runners
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newStatus() *cobra.Command {
+	const (
+		short = "Show the status of CI runners"
+		long  = short + "\n"
+		usage = "status <name>"
+	)
+
+	cmd := command.New(usage, short, long, runStatus,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	return cmd
+}
+
+type runnersStatus struct {
+	Name     string
+	Provider string
+	URL      string
+	Labels   []string
+	// Registrar is the state of the registrar machine
+	Registrar string
+	Started   int
+	Stopped   int
+	Machines  []*api.Machine
+}
+
+func runStatus(ctx context.Context) error {
+	io := iostreams.FromContext(ctx)
+
+	app, err := runnerApp(ctx, flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+
+	flapsClient, err := flaps.New(ctx, gql.AppForFlaps(*app))
+	if err != nil {
+		return err
+	}
+
+	machines, err := flapsClient.List(ctx, "")
+	if err != nil {
+		return fmt.Errorf("failed listing runners: %w", err)
+	}
+	registrar, _ := lo.Find(machines, isRegistrar)
+	machines = lo.Reject(machines, func(m *api.Machine, _ int) bool { return isRegistrar(m) })
+
+	status := runnersStatus{
+		Name:      app.Name,
+		Registrar: "missing",
+		Started:   lo.CountBy(machines, func(m *api.Machine) bool { return m.State == api.MachineStateStarted }),
+		Stopped:   lo.CountBy(machines, func(m *api.Machine) bool { return m.State == api.MachineStateStopped }),
+		Machines:  machines,
+	}
+	if registrar != nil {
+		status.Registrar = registrar.State
+	}
+	if len(machines) > 0 && machines[0].Config != nil {
+		env := machines[0].Config.Env
+		status.Provider = env["RUNNER_PROVIDER"]
+		status.URL = env["RUNNER_URL"]
+		if env["RUNNER_LABELS"] != "" {
+			status.Labels = strings.Split(env["RUNNER_LABELS"], ",")
+		}
+	}
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, status)
+	}
+
+	fmt.Fprintf(io.Out, "Runners %s of %s\n", io.ColorScheme().Bold(status.Name), status.URL)
+	fmt.Fprintf(io.Out, "  Provider:  %s\n", status.Provider)
+	if len(status.Labels) > 0 {
+		fmt.Fprintf(io.Out, "  Labels:    %s\n", strings.Join(status.Labels, ", "))
+	}
+	fmt.Fprintf(io.Out, "  Registrar: %s\n", status.Registrar)
+	fmt.Fprintf(io.Out, "  Runners:   %d started, %d stopped\n\n", status.Started, status.Stopped)
+
+	rows := make([][]string, 0, len(machines))
+	for _, m := range machines {
+		alwaysOn := m.Config != nil && m.Config.Restart.Policy == api.MachineRestartPolicyAlways
+		rows = append(rows, []string{
+			m.ID,
+			m.State,
+			m.Region,
+			m.ImageRefWithVersion(),
+			lo.Ternary(alwaysOn, "yes", "no"),
+			m.UpdatedAt,
+		})
+	}
+
+	return render.Table(io.Out, "", rows, "ID", "State", "Region", "Image", "Always On", "Updated")
+}
@@ -24,13 +24,19 @@ For pricing, see https://fly.io/docs/about/pricing/`
 	cmd := command.New("count [count]", short, long, runScaleCount,
 		command.RequireSession,
 		command.RequireAppName,
-		failOnMachinesApp,
+		command.RequireCompatibleVersion("scale count"),
+		failOnMachinesAppUnlessScheduled,
 	)
 	cmd.Args = cobra.MinimumNArgs(1)
 	flag.Add(cmd,
 		flag.App(),
 		flag.AppConfig(),
 		flag.Int{Name: "max-per-region", Description: "Max number of VMs per region", Default: -1},
+		flag.String{
+			Name:        "schedule",
+			Description: `Only run this count during a window of the week, such as "mon-fri 9-18" UTC, or from the times of a cron expression, such as "0 8 * * mon-fri" UTC, by adding a rule to the scaling schedule of the app. See fly scale schedule`,
+		},
+		otherwiseFlag,
 	)
 	return cmd
 }
@@ -70,6 +76,10 @@ func runScaleCount(ctx context.Context) error {
 		}
 	}
 
+	if window := flag.GetString(ctx, "schedule"); window != "" {
+		return setSchedule(ctx, window, groups)
+	}
+
 	var maxPerRegion *int
 	if v := flag.GetInt(ctx, "max-per-region"); v >= 0 {
 		maxPerRegion = &v
@@ -90,3 +100,12 @@ func runScaleCount(ctx context.Context) error {
 	fmt.Fprintf(io.Out, "Count changed to %s\n", countMessage(counts))
 	return nil
 }
+
+// failOnMachinesAppUnlessScheduled lets counts scheduled with --schedule
+// through for apps running on machines, which are scaled by their schedule.
+func failOnMachinesAppUnlessScheduled(ctx context.Context) (context.Context, error) {
+	if flag.GetString(ctx, "schedule") != "" {
+		return ctx, nil
+	}
+	return failOnMachinesApp(ctx)
+}
@@ -0,0 +1,196 @@
+package scale
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	mach "github.com/superfly/flyctl/internal/machine"
+	"github.com/superfly/flyctl/internal/watch"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// isMachinesApp reports whether the app runs on machines and, if so, derives
+// a context carrying a flaps client for it.
+func isMachinesApp(ctx context.Context, app *api.AppCompact) (context.Context, bool, error) {
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return ctx, false, nil
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, false, fmt.Errorf("could not create flaps client: %w", err)
+	}
+
+	return flaps.NewContext(ctx, flapsClient), true, nil
+}
+
+// scaleMachinesVM resizes the machines of an app, or of one of its process
+// groups, to the guest resize returns for their current guest. Every new guest
+// is validated before any machine is touched. Machines keeping their CPU kind
+// are updated in place; the others are replaced by a machine of the new size,
+// which has to pass its health checks before the old one is destroyed. One
+// machine is resized at a time.
+func scaleMachinesVM(ctx context.Context, group string, resize func(*api.MachineGuest) *api.MachineGuest) error {
+	var (
+		io          = iostreams.FromContext(ctx)
+		colorize    = io.ColorScheme()
+		flapsClient = flaps.FromContext(ctx)
+	)
+
+	machines, _, err := flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return err
+	}
+	if group != "" {
+		var inGroup []*api.Machine
+		for _, m := range machines {
+			if m.ProcessGroup() == group {
+				inGroup = append(inGroup, m)
+			}
+		}
+		machines = inGroup
+	}
+	if len(machines) == 0 {
+		return fmt.Errorf("no machines to scale")
+	}
+
+	guests := map[string]*api.MachineGuest{}
+	for _, m := range machines {
+		guest := resize(currentGuest(m))
+		if err := mach.ValidateGuest(guest); err != nil {
+			return err
+		}
+		if guest.CPUKind != currentGuest(m).CPUKind && len(m.Config.Mounts) > 0 {
+			return fmt.Errorf("machine %s can't change from %s to %s CPUs, as it would have to be replaced and it has a volume attached; pick a %s size instead",
+				m.ID, currentGuest(m).CPUKind, guest.CPUKind, currentGuest(m).CPUKind)
+		}
+		guests[m.ID] = guest
+	}
+
+	for _, m := range machines {
+		guest := guests[m.ID]
+
+		switch current := currentGuest(m); {
+		case sameGuest(current, guest):
+			fmt.Fprintf(io.Out, "Machine %s is already %s\n", colorize.Bold(m.ID), formatGuest(guest))
+		case current.CPUKind == guest.CPUKind:
+			if err := resizeMachineInPlace(ctx, m, guest); err != nil {
+				return err
+			}
+		default:
+			if err := replaceMachine(ctx, m, guest); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func resizeMachineInPlace(ctx context.Context, m *api.Machine, guest *api.MachineGuest) error {
+	io := iostreams.FromContext(ctx)
+
+	fmt.Fprintf(io.Out, "Resizing machine %s in place from %s to %s\n", io.ColorScheme().Bold(m.ID), formatGuest(currentGuest(m)), formatGuest(guest))
+
+	m, releaseLeaseFunc, err := mach.AcquireLease(ctx, m)
+	defer releaseLeaseFunc(ctx, m)
+	if err != nil {
+		return err
+	}
+
+	config := mach.CloneConfig(m.Config)
+	config.Guest = guest
+
+	return mach.Update(ctx, m, &api.LaunchMachineInput{
+		ID:     m.ID,
+		AppID:  appconfig.NameFromContext(ctx),
+		Name:   m.Name,
+		Region: m.Region,
+		Config: config,
+	})
+}
+
+func replaceMachine(ctx context.Context, m *api.Machine, guest *api.MachineGuest) error {
+	var (
+		io          = iostreams.FromContext(ctx)
+		colorize    = io.ColorScheme()
+		flapsClient = flaps.FromContext(ctx)
+	)
+
+	fmt.Fprintf(io.Out, "Replacing machine %s (%s) with a %s machine\n", colorize.Bold(m.ID), formatGuest(currentGuest(m)), formatGuest(guest))
+
+	config := mach.CloneConfig(m.Config)
+	config.Guest = guest
+
+	replacement, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+		AppID:  appconfig.NameFromContext(ctx),
+		Region: m.Region,
+		Config: config,
+	})
+	if err != nil {
+		return fmt.Errorf("failed launching the replacement of machine %s: %w", m.ID, err)
+	}
+
+	destroyReplacement := func(cause error) error {
+		if err := flapsClient.Destroy(ctx, api.RemoveMachineInput{ID: replacement.ID, Kill: true}); err != nil {
+			return fmt.Errorf("%w; destroying the replacement machine %s failed too: %v", cause, replacement.ID, err)
+		}
+		return cause
+	}
+
+	if err := mach.WaitForStartOrStop(ctx, replacement, "start", 5*time.Minute); err != nil {
+		return destroyReplacement(fmt.Errorf("replacement machine %s failed to start, machine %s is left untouched: %w", replacement.ID, m.ID, err))
+	}
+	if err := watch.MachinesChecks(ctx, []*api.Machine{replacement}); err != nil {
+		return destroyReplacement(fmt.Errorf("replacement machine %s failed its health checks, machine %s is left untouched: %w", replacement.ID, m.ID, err))
+	}
+
+	if err := flapsClient.Destroy(ctx, api.RemoveMachineInput{ID: m.ID, Kill: true}); err != nil {
+		return fmt.Errorf("failed destroying machine %s after replacing it with %s: %w", m.ID, replacement.ID, err)
+	}
+
+	fmt.Fprintf(io.Out, "Machine %s replaced by %s\n", colorize.Bold(m.ID), colorize.Bold(replacement.ID))
+
+	return nil
+}
+
+// currentGuest returns the guest of m, which machines created without one run
+// the smallest preset as.
+func currentGuest(m *api.Machine) *api.MachineGuest {
+	if m.Config.Guest != nil {
+		return m.Config.Guest
+	}
+
+	return api.MachinePresets["shared-cpu-1x"]
+}
+
+func sameGuest(a, b *api.MachineGuest) bool {
+	return a.CPUKind == b.CPUKind && a.CPUs == b.CPUs && a.MemoryMB == b.MemoryMB
+}
+
+func formatGuest(guest *api.MachineGuest) string {
+	return fmt.Sprintf("%s-cpu-%dx with %dMB of memory", guest.CPUKind, guest.CPUs, guest.MemoryMB)
+}
+
+// presetGuest returns a copy of the named machine preset.
+func presetGuest(name string) (*api.MachineGuest, error) {
+	preset, ok := api.MachinePresets[name]
+	if !ok {
+		names := make([]string, 0, len(api.MachinePresets))
+		for name := range api.MachinePresets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		return nil, fmt.Errorf("invalid VM size %s; machines support %s", name, strings.Join(names, ", "))
+	}
+
+	guest := *preset
+	return &guest, nil
+}
@@ -22,7 +22,6 @@ func newScaleMemory() *cobra.Command {
 	cmd := command.New("memory [memoryMB]", short, long, runScaleMemory,
 		command.RequireSession,
 		command.RequireAppName,
-		failOnMachinesApp,
 	)
 	cmd.Args = cobra.ExactArgs(1)
 	flag.Add(cmd,
@@ -44,6 +43,22 @@ func runScaleMemory(ctx context.Context) error {
 		return err
 	}
 
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+	ctx, machines, err := isMachinesApp(ctx, app)
+	if err != nil {
+		return err
+	}
+	if machines {
+		return scaleMachinesVM(ctx, group, func(current *api.MachineGuest) *api.MachineGuest {
+			guest := *current
+			guest.MemoryMB = int(memoryMB)
+			return &guest
+		})
+	}
+
 	// API doesn't allow memory setting on own yet, so get get the current size for the mutation
 	currentsize, _, _, err := apiClient.AppVMResources(ctx, appName)
 	if err != nil {
@@ -22,6 +22,7 @@ func New() *cobra.Command {
 		newScaleMemory(),
 		newScaleShow(),
 		newScaleCount(),
+		newScaleSchedule(),
 	)
 	return cmd
 }
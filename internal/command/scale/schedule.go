@@ -0,0 +1,730 @@
+package scale
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/gql"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/cron"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+const (
+	// metadataKeyScaleScheduler tells the machine applying the scaling
+	// schedule of an app apart from the machines of the app.
+	metadataKeyScaleScheduler = "fly_scale_scheduler"
+
+	// scheduleEnv is the environment variable of the scheduler machine the
+	// scaling schedule is stored in.
+	scheduleEnv = "FLY_SCALE_SCHEDULE"
+
+	// schedulerImage is the image of the scheduler machine, which runs
+	// fly scale schedule reconcile hourly.
+	schedulerImage = "flyio/flyctl:latest"
+)
+
+func newScaleSchedule() *cobra.Command {
+	const (
+		short = "Manage time-based scaling rules"
+		long  = `Manage the scaling schedule of an app, which runs a number of machines
+of a process group at times of the week: during windows, such as 6 on weekdays
+from 09:00 to 18:00 UTC and 2 otherwise, or from the times of cron expressions,
+such as 10 from "0 8 * * mon-fri" and 2 from "0 20 * * mon-fri" UTC.
+
+The schedule is kept in the [[scale_schedule]] sections of fly.toml, and
+applied by a scheduler machine of the app running hourly, which fly deploy
+and these commands keep up to date. It starts and stops the machines of each
+process group to match the count of the current rule. Windows start and end on
+the hour, rules take effect within the hour, and machines are never created,
+so create as many as the largest count needs.
+`
+	)
+
+	cmd := command.New("schedule", short, long, nil)
+
+	cmd.AddCommand(
+		newScaleScheduleList(),
+		newScaleScheduleSet(),
+		newScaleScheduleDelete(),
+		newScaleScheduleReconcile(),
+	)
+
+	return cmd
+}
+
+func newScaleScheduleList() *cobra.Command {
+	const (
+		short = "List the scaling rules of an app"
+		long  = short + "\n"
+	)
+
+	cmd := command.New("list", short, long, runScaleScheduleList,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Aliases = []string{"ls"}
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+func newScaleScheduleSet() *cobra.Command {
+	const (
+		short = "Run a number of machines at times of the week"
+		long  = `Run count machines of a process group at times of the week, given either
+as a window of days and UTC hours, such as "mon-fri 9-18", "sat,sun 0-24",
+"daily 8-20" or "fri 22-6" for a window spanning midnight, or as a cron
+expression in UTC, such as "0 8 * * mon-fri", from the times of which the
+count applies until another rule of the group fires. Windows take precedence
+over cron expressions, and a rule for the same times and process group is
+replaced.
+`
+		usage = "set <window|cron> <count>"
+	)
+
+	cmd := command.New(usage, short, long, runScaleScheduleSet,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(2)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		processGroupFlag,
+		otherwiseFlag,
+	)
+
+	return cmd
+}
+
+func newScaleScheduleDelete() *cobra.Command {
+	const (
+		short = "Delete scaling rules"
+		long  = `Delete the rule of a process group for a window or a cron expression, its
+count outside of the other rules with "otherwise", or every rule of it when
+neither is given. The scheduler machine is destroyed along with the last rule.
+`
+		usage = "delete [<window|cron|otherwise>]"
+	)
+
+	cmd := command.New(usage, short, long, runScaleScheduleDelete,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.MaximumNArgs(1)
+	cmd.Aliases = []string{"rm"}
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		processGroupFlag,
+	)
+
+	return cmd
+}
+
+func newScaleScheduleReconcile() *cobra.Command {
+	const (
+		short = "Apply the scaling schedule of an app"
+		long  = `Start and stop the machines of an app to match the count of the current
+window of its scaling schedule, read from ` + scheduleEnv + `. This is what
+the scheduler machine runs.
+`
+	)
+
+	cmd := command.New("reconcile", short, long, runScaleScheduleReconcile,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+	cmd.Hidden = true
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+	)
+
+	return cmd
+}
+
+var processGroupFlag = flag.String{
+	Name:        "process-group",
+	Description: "The process group of the rule, the app's default one when not set",
+}
+
+// otherwiseFlag is shared by fly scale schedule set and fly scale count
+// --schedule.
+var otherwiseFlag = flag.Int{
+	Name:        "otherwise",
+	Description: "Number of machines to run outside of the windows of the schedule, left as is when not set",
+	Default:     -1,
+}
+
+// otherwise is how rules applying outside of the times of the other rules of
+// their process group are referred to.
+const otherwise = "otherwise"
+
+// scaleSchedule is the scaling schedule of an app, the rules of which all
+// name their process group.
+type scaleSchedule struct {
+	Rules []*appconfig.ScaleRule `json:"rules"`
+}
+
+// newSchedule returns the schedule of rules, the process group of which
+// defaults to defaultGroup.
+func newSchedule(rules []*appconfig.ScaleRule, defaultGroup string) *scaleSchedule {
+	s := &scaleSchedule{}
+	for _, r := range rules {
+		if r == nil {
+			continue
+		}
+		r := *r
+		if r.ProcessGroup == "" {
+			r.ProcessGroup = defaultGroup
+		}
+		s.Rules = append(s.Rules, &r)
+	}
+	return s
+}
+
+// when returns the times rule applies at, its window, cron expression or
+// otherwise.
+func when(rule *appconfig.ScaleRule) string {
+	switch {
+	case rule.Window != "":
+		return rule.Window
+	case rule.Cron != "":
+		return rule.Cron
+	default:
+		return otherwise
+	}
+}
+
+// parseWhen parses the times of a rule, as a cron expression, a window or
+// otherwise, into a rule.
+func parseWhen(s string) (*appconfig.ScaleRule, error) {
+	s = strings.TrimSpace(s)
+	switch {
+	case s == otherwise:
+		return &appconfig.ScaleRule{}, nil
+	case strings.HasPrefix(s, "@") || len(strings.Fields(s)) == 5:
+		if _, err := cron.Parse(s); err != nil {
+			return nil, err
+		}
+		return &appconfig.ScaleRule{Cron: s}, nil
+	default:
+		if _, err := cron.ParseWindow(s); err != nil {
+			return nil, err
+		}
+		return &appconfig.ScaleRule{Window: s}, nil
+	}
+}
+
+// set adds rule, replacing the rule of the same process group and times.
+func (s *scaleSchedule) set(rule *appconfig.ScaleRule) {
+	s.Rules = lo.Reject(s.Rules, func(r *appconfig.ScaleRule, _ int) bool {
+		return r.ProcessGroup == rule.ProcessGroup && when(r) == when(rule)
+	})
+	s.Rules = append(s.Rules, rule)
+}
+
+// remove removes the rule of group for the times of at, every rule of group
+// when at is empty, and returns how many it removed.
+func (s *scaleSchedule) remove(group, at string) int {
+	n := len(s.Rules)
+	s.Rules = lo.Reject(s.Rules, func(r *appconfig.ScaleRule, _ int) bool {
+		return r.ProcessGroup == group && (at == "" || when(r) == strings.TrimSpace(at))
+	})
+	return n - len(s.Rules)
+}
+
+func (s *scaleSchedule) groups() []string {
+	groups := lo.Uniq(lo.Map(s.Rules, func(r *appconfig.ScaleRule, _ int) string { return r.ProcessGroup }))
+	sort.Strings(groups)
+	return groups
+}
+
+// countAt returns the number of machines of group to run at t: the count of
+// the last of its windows t falls within, or else of the cron expression which
+// fired last, or else of its otherwise rule. It's false when the schedule
+// leaves the group as is.
+func (s *scaleSchedule) countAt(group string, t time.Time) (int, bool) {
+	t = t.UTC()
+
+	var rules []*appconfig.ScaleRule
+	for _, r := range s.Rules {
+		if r.ProcessGroup == group {
+			rules = append(rules, r)
+		}
+	}
+
+	for i := len(rules) - 1; i >= 0; i-- {
+		if rules[i].Window == "" {
+			continue
+		}
+		if w, err := cron.ParseWindow(rules[i].Window); err == nil && w.Contains(t) {
+			return rules[i].Count, true
+		}
+	}
+
+	var (
+		fired time.Time
+		count int
+		ok    bool
+	)
+	for _, r := range rules {
+		if r.Cron == "" {
+			continue
+		}
+		c, err := cron.Parse(r.Cron)
+		if err != nil {
+			continue
+		}
+		if at := c.Prev(t); !at.IsZero() && !at.Before(fired) {
+			fired, count, ok = at, r.Count, true
+		}
+	}
+	if ok {
+		return count, true
+	}
+
+	for _, r := range rules {
+		if r.Window == "" && r.Cron == "" {
+			return r.Count, true
+		}
+	}
+	return 0, false
+}
+
+// planSchedule returns the machines of a process group to start and to stop
+// so that count of them run, preferring to keep running the machines which
+// are, and how many machines short of count the group is.
+func planSchedule(machines []*api.Machine, count int) (start, stop []*api.Machine, short int) {
+	sorted := append([]*api.Machine(nil), machines...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		si, sj := sorted[i].State == api.MachineStateStarted, sorted[j].State == api.MachineStateStarted
+		if si != sj {
+			return si
+		}
+		return sorted[i].ID < sorted[j].ID
+	})
+
+	for i, m := range sorted {
+		running := m.State == api.MachineStateStarted
+		switch {
+		case i < count && !running:
+			start = append(start, m)
+		case i >= count && running:
+			stop = append(stop, m)
+		}
+	}
+
+	if count > len(sorted) {
+		short = count - len(sorted)
+	}
+	return start, stop, short
+}
+
+// reconcileSchedule starts and stops the machines of the app to match the
+// counts of s at now.
+func reconcileSchedule(ctx context.Context, s *scaleSchedule, now time.Time) error {
+	var (
+		io          = iostreams.FromContext(ctx)
+		flapsClient = flaps.FromContext(ctx)
+	)
+
+	machines, _, err := flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return err
+	}
+
+	if lo.ContainsBy(machines, func(m *api.Machine) bool { return m.IsPaused() }) {
+		fmt.Fprintln(io.ErrOut, "The app is paused, its machines are left alone until it's resumed with fly apps resume")
+		return nil
+	}
+
+	for _, group := range s.groups() {
+		count, ok := s.countAt(group, now)
+		if !ok {
+			continue
+		}
+
+		inGroup := lo.Filter(machines, func(m *api.Machine, _ int) bool { return m.ProcessGroup() == group })
+		start, stop, short := planSchedule(inGroup, count)
+
+		for _, m := range start {
+			fmt.Fprintf(io.ErrOut, "Starting machine %s of %s\n", m.ID, group)
+			if _, err := flapsClient.Start(ctx, m.ID); err != nil {
+				return fmt.Errorf("failed starting machine %s: %w", m.ID, err)
+			}
+		}
+		for _, m := range stop {
+			fmt.Fprintf(io.ErrOut, "Stopping machine %s of %s\n", m.ID, group)
+			if err := flapsClient.Stop(ctx, api.StopMachineInput{ID: m.ID}); err != nil {
+				return fmt.Errorf("failed stopping machine %s: %w", m.ID, err)
+			}
+		}
+		if short > 0 {
+			fmt.Fprintf(io.ErrOut, "Process group %s should run %d machines but only has %d, add %d with fly machine clone\n", group, count, len(inGroup), short)
+		}
+	}
+
+	return nil
+}
+
+// scheduler returns the scheduler machine of the app and its schedule, nil
+// and an empty schedule when it has none.
+func scheduler(ctx context.Context) (*api.Machine, *scaleSchedule, error) {
+	machines, err := flaps.FromContext(ctx).List(ctx, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	m, ok := lo.Find(machines, func(m *api.Machine) bool {
+		return m.Config != nil && m.Config.Metadata[metadataKeyScaleScheduler] != ""
+	})
+	if !ok {
+		return nil, &scaleSchedule{}, nil
+	}
+
+	s, err := decodeSchedule(m.Config.Env[scheduleEnv])
+	if err != nil {
+		return nil, nil, fmt.Errorf("scheduler machine %s: %w", m.ID, err)
+	}
+	return m, s, nil
+}
+
+func decodeSchedule(data string) (*scaleSchedule, error) {
+	s := &scaleSchedule{}
+	if data == "" {
+		return s, nil
+	}
+	if err := json.Unmarshal([]byte(data), s); err != nil {
+		return nil, fmt.Errorf("invalid scaling schedule: %w", err)
+	}
+	return s, nil
+}
+
+// saveSchedule stores s in the scheduler machine of the app, launching it
+// when the app has none yet, or destroys the machine when s has no rules.
+func saveSchedule(ctx context.Context, app *api.AppCompact, m *api.Machine, s *scaleSchedule) (*api.Machine, error) {
+	flapsClient := flaps.FromContext(ctx)
+
+	if len(s.Rules) == 0 {
+		if m == nil {
+			return nil, nil
+		}
+		if err := flapsClient.Destroy(ctx, api.RemoveMachineInput{AppID: app.Name, ID: m.ID, Kill: true}); err != nil {
+			return nil, fmt.Errorf("failed destroying scheduler machine %s: %w", m.ID, err)
+		}
+		return nil, nil
+	}
+
+	data, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if m != nil {
+		lease, err := flapsClient.AcquireLease(ctx, m.ID, api.IntPointer(60))
+		if err != nil {
+			return nil, fmt.Errorf("failed to obtain lease: %w", err)
+		}
+		defer flapsClient.ReleaseLease(ctx, m.ID, lease.Data.Nonce)
+
+		cfg := *m.Config
+		cfg.Env = lo.Assign(cfg.Env, map[string]string{scheduleEnv: string(data)})
+		return flapsClient.Update(ctx, api.LaunchMachineInput{ID: m.ID, Region: m.Region, Config: &cfg}, lease.Data.Nonce)
+	}
+
+	machines, _, err := flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(machines) == 0 {
+		return nil, fmt.Errorf("app %s has no machines to scale", app.Name)
+	}
+
+	// the scheduler runs with a token limited to deploying the app
+	apiClient := client.FromContext(ctx).API()
+	token, err := gql.CreateLimitedAccessToken(ctx, apiClient.GenqClient, app.Name+"-scale-schedule", app.Organization.ID, "deploy", &gql.LimitedAccessTokenOptions{
+		"app_id": app.ID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed creating a token for the scheduler: %w", err)
+	}
+
+	return flapsClient.Launch(ctx, api.LaunchMachineInput{
+		AppID:  app.Name,
+		Region: machines[0].Region,
+		Config: &api.MachineConfig{
+			Image: schedulerImage,
+			Init: api.MachineInit{
+				Cmd: []string{"scale", "schedule", "reconcile", "--app", app.Name},
+			},
+			Env: map[string]string{
+				scheduleEnv:     string(data),
+				"FLY_API_TOKEN": token.CreateLimitedAccessToken.LimitedAccessToken.TokenHeader,
+			},
+			Metadata: map[string]string{
+				metadataKeyScaleScheduler: "true",
+			},
+			Schedule: "hourly",
+			Restart:  api.MachineRestart{Policy: api.MachineRestartPolicyNo},
+			Guest:    api.MachinePresets["shared-cpu-1x"],
+		},
+	})
+}
+
+// SyncSchedule brings the scheduler machine of the app up to date with the
+// scaling schedule of cfg, launching or destroying it as needed.
+func SyncSchedule(ctx context.Context, app *api.AppCompact, cfg *appconfig.Config) error {
+	ctx, ok, err := isMachinesApp(ctx, app)
+	if err != nil || !ok {
+		return err
+	}
+
+	m, current, err := scheduler(ctx)
+	if err != nil {
+		return err
+	}
+
+	s := newSchedule(cfg.ScaleSchedule, cfg.DefaultProcessName())
+	if reflect.DeepEqual(s.Rules, current.Rules) {
+		return nil
+	}
+
+	if _, err := saveSchedule(ctx, app, m, s); err != nil {
+		return err
+	}
+	return reconcileSchedule(ctx, s, time.Now())
+}
+
+// scheduleContext derives a context carrying a flaps client for the app,
+// which must run on machines.
+func scheduleContext(ctx context.Context) (context.Context, *api.AppCompact, error) {
+	app, err := client.FromContext(ctx).API().GetAppCompact(ctx, appconfig.NameFromContext(ctx))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx, ok, err := isMachinesApp(ctx, app)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, errors.New("scaling schedules are only supported for apps running on machines")
+	}
+
+	return ctx, app, nil
+}
+
+// localConfig returns the local fly.toml of the app, nil when there's none.
+func localConfig(ctx context.Context) *appconfig.Config {
+	cfg := appconfig.ConfigFromContext(ctx)
+	if cfg == nil || cfg.AppName != appconfig.NameFromContext(ctx) || cfg.ConfigFilePath() == "" {
+		return nil
+	}
+	return cfg
+}
+
+// loadSchedule returns the scaling schedule of the app, from its local
+// fly.toml when there's one and from its scheduler machine otherwise, along
+// with the scheduler machine.
+func loadSchedule(ctx context.Context) (*api.Machine, *scaleSchedule, error) {
+	m, s, err := scheduler(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	if cfg := localConfig(ctx); cfg != nil {
+		s = newSchedule(cfg.ScaleSchedule, cfg.DefaultProcessName())
+	}
+	return m, s, nil
+}
+
+// storeSchedule writes s to the local fly.toml of the app and stores it in the
+// scheduler machine m, then applies it.
+func storeSchedule(ctx context.Context, app *api.AppCompact, m *api.Machine, s *scaleSchedule) error {
+	io := iostreams.FromContext(ctx)
+
+	if cfg := localConfig(ctx); cfg != nil {
+		cfg.ScaleSchedule = s.Rules
+		if err := cfg.WriteToDisk(ctx, cfg.ConfigFilePath()); err != nil {
+			return err
+		}
+	} else if len(s.Rules) > 0 {
+		terminal.Warnf("No fly.toml of %s was found, add the [[scale_schedule]] sections of fly scale schedule list --json to it yourself, or deploying it will remove them\n", app.Name)
+	}
+
+	m, err := saveSchedule(ctx, app, m, s)
+	if err != nil {
+		return fmt.Errorf("failed saving the scaling schedule: %w", err)
+	}
+	if m == nil {
+		fmt.Fprintln(io.Out, "Scaling schedule removed along with its scheduler machine")
+		return nil
+	}
+	fmt.Fprintf(io.Out, "Scaling schedule saved to scheduler machine %s\n", m.ID)
+
+	return reconcileSchedule(ctx, s, time.Now())
+}
+
+// scheduleGroup returns the process group rules set on the command line
+// apply to.
+func scheduleGroup(ctx context.Context) string {
+	if group := flag.GetString(ctx, "process-group"); group != "" {
+		return group
+	}
+	if cfg := appconfig.ConfigFromContext(ctx); cfg != nil {
+		return cfg.DefaultProcessName()
+	}
+	return api.MachineProcessGroupApp
+}
+
+// setSchedule adds a rule running count machines of each of groups at the
+// times of at, and --otherwise of them outside of the times of the other
+// rules, and applies the schedule.
+func setSchedule(ctx context.Context, at string, groups map[string]int) error {
+	rule, err := parseWhen(at)
+	if err != nil {
+		return err
+	}
+
+	ctx, app, err := scheduleContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	m, s, err := loadSchedule(ctx)
+	if err != nil {
+		return err
+	}
+
+	otherwiseCount := flag.GetInt(ctx, "otherwise")
+	for group, count := range groups {
+		if count < 0 {
+			return fmt.Errorf("count must be 0 or more, got %d", count)
+		}
+		r := *rule
+		r.ProcessGroup, r.Count = group, count
+		s.set(&r)
+		if otherwiseCount >= 0 {
+			s.set(&appconfig.ScaleRule{ProcessGroup: group, Count: otherwiseCount})
+		}
+	}
+
+	return storeSchedule(ctx, app, m, s)
+}
+
+func runScaleScheduleSet(ctx context.Context) error {
+	args := flag.Args(ctx)
+
+	count, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid count %s: %w", args[1], err)
+	}
+
+	return setSchedule(ctx, args[0], map[string]int{scheduleGroup(ctx): count})
+}
+
+func runScaleScheduleDelete(ctx context.Context) error {
+	ctx, app, err := scheduleContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	m, s, err := loadSchedule(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		group = scheduleGroup(ctx)
+		at    = flag.FirstArg(ctx)
+	)
+	if s.remove(group, at) == 0 {
+		if at == "" {
+			return fmt.Errorf("process group %s has no scaling rules", group)
+		}
+		return fmt.Errorf("process group %s has no scaling rule for %s, list them with fly scale schedule list", group, at)
+	}
+
+	return storeSchedule(ctx, app, m, s)
+}
+
+func runScaleScheduleList(ctx context.Context) error {
+	ctx, _, err := scheduleContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, s, err := loadSchedule(ctx)
+	if err != nil {
+		return err
+	}
+
+	io := iostreams.FromContext(ctx)
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, s.Rules)
+	}
+
+	if len(s.Rules) == 0 {
+		fmt.Fprintln(io.Out, "No scaling schedule, set one with fly scale schedule set")
+		return nil
+	}
+
+	rows := make([][]string, 0, len(s.Rules))
+	for _, group := range s.groups() {
+		for _, r := range s.Rules {
+			if r.ProcessGroup == group {
+				rows = append(rows, []string{group, when(r), strconv.Itoa(r.Count)})
+			}
+		}
+	}
+
+	return render.Table(io.Out, "", rows, "Process Group", "When (UTC)", "Count")
+}
+
+func runScaleScheduleReconcile(ctx context.Context) error {
+	ctx, _, err := scheduleContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	s, err := decodeSchedule(os.Getenv(scheduleEnv))
+	if err != nil {
+		return err
+	}
+
+	return reconcileSchedule(ctx, s, time.Now())
+}
@@ -0,0 +1,136 @@
+package scale
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestParseWhen(t *testing.T) {
+	rule, err := parseWhen("mon-fri 9-18")
+	require.NoError(t, err)
+	assert.Equal(t, &appconfig.ScaleRule{Window: "mon-fri 9-18"}, rule)
+
+	rule, err = parseWhen("0 8 * * mon-fri")
+	require.NoError(t, err)
+	assert.Equal(t, &appconfig.ScaleRule{Cron: "0 8 * * mon-fri"}, rule)
+
+	rule, err = parseWhen("@daily")
+	require.NoError(t, err)
+	assert.Equal(t, &appconfig.ScaleRule{Cron: "@daily"}, rule)
+
+	rule, err = parseWhen("otherwise")
+	require.NoError(t, err)
+	assert.Equal(t, &appconfig.ScaleRule{}, rule)
+
+	for _, invalid := range []string{"weekdays 9-18", "0 25 * * *", "@sometimes"} {
+		_, err := parseWhen(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestScheduleCountAt(t *testing.T) {
+	s := newSchedule([]*appconfig.ScaleRule{
+		{Count: 2},
+		{Window: "mon-fri 9-18", Count: 6},
+		{Window: "mon 12-13", Count: 10},
+		{ProcessGroup: "worker", Window: "daily 0-6", Count: 1},
+	}, "app")
+
+	monday := func(hour int) time.Time {
+		return time.Date(2023, 5, 1, hour, 0, 0, 0, time.UTC)
+	}
+
+	count, ok := s.countAt("app", monday(10))
+	assert.True(t, ok)
+	assert.Equal(t, 6, count)
+
+	count, _ = s.countAt("app", monday(12))
+	assert.Equal(t, 10, count)
+
+	count, _ = s.countAt("app", monday(20))
+	assert.Equal(t, 2, count)
+
+	_, ok = s.countAt("worker", monday(10))
+	assert.False(t, ok)
+
+	s.set(&appconfig.ScaleRule{ProcessGroup: "app", Window: "mon-fri 9-18", Count: 4})
+	assert.Len(t, s.Rules, 4)
+	count, _ = s.countAt("app", monday(10))
+	assert.Equal(t, 4, count)
+
+	assert.Equal(t, []string{"app", "worker"}, s.groups())
+}
+
+func TestScheduleCountAtCron(t *testing.T) {
+	s := newSchedule([]*appconfig.ScaleRule{
+		{ProcessGroup: "web", Cron: "0 8 * * mon-fri", Count: 10},
+		{ProcessGroup: "web", Cron: "0 20 * * mon-fri", Count: 2},
+		{ProcessGroup: "web", Window: "sat 10-12", Count: 4},
+	}, "app")
+
+	// 2023-05-01 is a Monday
+	at := func(day, hour int) time.Time {
+		return time.Date(2023, 5, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	count, ok := s.countAt("web", at(1, 9))
+	assert.True(t, ok)
+	assert.Equal(t, 10, count)
+
+	count, _ = s.countAt("web", at(1, 21))
+	assert.Equal(t, 2, count)
+
+	count, _ = s.countAt("web", at(2, 7))
+	assert.Equal(t, 2, count)
+
+	// windows take precedence, then the last cron expression to fire does
+	count, _ = s.countAt("web", at(6, 11))
+	assert.Equal(t, 4, count)
+	count, _ = s.countAt("web", at(7, 11))
+	assert.Equal(t, 2, count)
+}
+
+func TestScheduleRemove(t *testing.T) {
+	s := newSchedule([]*appconfig.ScaleRule{
+		{Count: 2},
+		{Window: "mon-fri 9-18", Count: 6},
+		{Cron: "0 8 * * *", Count: 3},
+		{ProcessGroup: "worker", Window: "daily 0-6", Count: 1},
+	}, "app")
+
+	assert.Equal(t, 0, s.remove("app", "sat 9-18"))
+	assert.Equal(t, 1, s.remove("app", " 0 8 * * *"))
+	assert.Equal(t, 1, s.remove("app", "otherwise"))
+	assert.Len(t, s.Rules, 2)
+
+	assert.Equal(t, 1, s.remove("worker", ""))
+	assert.Equal(t, []string{"app"}, s.groups())
+}
+
+func TestPlanSchedule(t *testing.T) {
+	machines := []*api.Machine{
+		{ID: "a", State: api.MachineStateStopped},
+		{ID: "b", State: api.MachineStateStarted},
+		{ID: "c", State: api.MachineStateStopped},
+		{ID: "d", State: api.MachineStateStarted},
+	}
+
+	start, stop, short := planSchedule(machines, 3)
+	assert.Equal(t, []*api.Machine{machines[0]}, start)
+	assert.Empty(t, stop)
+	assert.Zero(t, short)
+
+	start, stop, _ = planSchedule(machines, 1)
+	assert.Empty(t, start)
+	assert.Equal(t, []*api.Machine{machines[3]}, stop)
+
+	start, _, short = planSchedule(machines, 6)
+	assert.Equal(t, []*api.Machine{machines[0], machines[2]}, start)
+	assert.Equal(t, 2, short)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
@@ -24,12 +25,15 @@ e.g. flyctl scale vm shared-cpu-1x --memory=2048
 
 For dedicated vms, this should be a multiple of 1024MB.
 For shared vms, this can be 256MB or a a multiple of 1024MB.
-For pricing, see https://fly.io/docs/about/pricing/`
+For pricing, see https://fly.io/docs/about/pricing/
+
+Machines keeping their CPU kind are resized in place. Machines moving between
+shared and performance CPUs are replaced, one at a time, by a new machine
+which has to pass its health checks before the old one is destroyed.`
 	)
 	cmd := command.New("vm [size]", short, long, runScaleVM,
 		command.RequireSession,
 		command.RequireAppName,
-		failOnMachinesApp,
 	)
 	cmd.Args = cobra.ExactArgs(1)
 	flag.Add(cmd,
@@ -49,6 +53,28 @@ func runScaleVM(ctx context.Context) error {
 	group := flag.GetString(ctx, "group")
 	memoryMB := int64(flag.GetInt(ctx, "memory"))
 
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+	ctx, machines, err := isMachinesApp(ctx, app)
+	if err != nil {
+		return err
+	}
+	if machines {
+		guest, err := presetGuest(sizeName)
+		if err != nil {
+			return err
+		}
+		if memoryMB > 0 {
+			guest.MemoryMB = int(memoryMB)
+		}
+
+		return scaleMachinesVM(ctx, group, func(*api.MachineGuest) *api.MachineGuest {
+			return guest
+		})
+	}
+
 	size, err := apiClient.SetAppVMSize(ctx, appName, group, sizeName, memoryMB)
 	if err != nil {
 		return err
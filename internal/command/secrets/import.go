@@ -10,14 +10,16 @@ import (
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/dotenv"
 	"github.com/superfly/flyctl/internal/flag"
 )
 
 func newImport() (cmd *cobra.Command) {
 	const (
-		long  = `Set one or more encrypted secrets for an application. Values are read from stdin as NAME=VALUE pairs`
-		short = `Set secrets as NAME=VALUE pairs from stdin`
-		usage = "import [flags]"
+		long = `Set one or more encrypted secrets for an application. Values are read as
+NAME=VALUE pairs from stdin, or from the .env file at the given path`
+		short = `Set secrets as NAME=VALUE pairs from stdin or a .env file`
+		usage = "import [flags] [path]"
 	)
 
 	cmd = command.New(usage, short, long, runImport, command.RequireSession, command.RequireAppName)
@@ -26,6 +28,8 @@ func newImport() (cmd *cobra.Command) {
 		sharedFlags,
 	)
 
+	cmd.Args = cobra.MaximumNArgs(1)
+
 	return cmd
 }
 
@@ -37,8 +41,12 @@ func runImport(ctx context.Context) (err error) {
 		return
 	}
 
-	secrets, err := parseSecrets(os.Stdin)
-	if err != nil {
+	var secrets map[string]string
+	if path := flag.FirstArg(ctx); path != "" {
+		if secrets, err = dotenv.ParseFile(path); err != nil {
+			return err
+		}
+	} else if secrets, err = dotenv.Parse(os.Stdin); err != nil {
 		return fmt.Errorf("Failed to parse secrets from stdin: %w", err)
 	}
 	if len(secrets) < 1 {
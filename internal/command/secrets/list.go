@@ -3,6 +3,7 @@ package secrets
 import (
 	"context"
 
+	"github.com/samber/lo"
 	"github.com/spf13/cobra"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/appconfig"
@@ -17,8 +18,10 @@ import (
 func newList() (cmd *cobra.Command) {
 	const (
 		long = `List the secrets available to the application. It shows each secret's
-name, a digest of its value and the time the secret was last set. The
-actual value of the secret is only available to the application.`
+name, a digest of its value and the time the secret was last set. For
+machines apps, secrets set with --stage and not deployed yet are listed
+as staged. The actual value of the secret is only available to the
+application.`
 		short = `List application secret names, digests and creation times`
 		usage = "list [flags]"
 	)
@@ -44,14 +47,41 @@ func runList(ctx context.Context) (err error) {
 		return err
 	}
 
+	if cfg.JSONOutput {
+		return render.JSON(out, secrets)
+	}
+
+	app, err := client.GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	// secrets set with --stage only reach machines when they're next deployed
+	var staged map[string]bool
+	if app.PlatformVersion == "machines" {
+		names, err := stagedSecrets(ctx, app, secrets)
+		if err != nil {
+			return err
+		}
+		staged = lo.SliceToMap(names, func(name string) (string, bool) { return name, true })
+	}
+
 	var rows [][]string
 
 	for _, secret := range secrets {
-		rows = append(rows, []string{
+		row := []string{
 			secret.Name,
 			secret.Digest,
 			format.RelativeTime(secret.CreatedAt),
-		})
+		}
+		if staged != nil {
+			status := "Deployed"
+			if staged[secret.Name] {
+				status = "Staged"
+			}
+			row = append(row, status)
+		}
+		rows = append(rows, row)
 	}
 
 	headers := []string{
@@ -59,9 +89,8 @@ func runList(ctx context.Context) (err error) {
 		"Digest",
 		"Created At",
 	}
-	if cfg.JSONOutput {
-		return render.JSON(out, secrets)
-	} else {
-		return render.Table(out, "", rows, headers...)
+	if staged != nil {
+		headers = append(headers, "Status")
 	}
+	return render.Table(out, "", rows, headers...)
 }
@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/flaps"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
@@ -24,7 +27,7 @@ var sharedFlags = flag.Set{
 	flag.Detach(),
 	flag.Bool{
 		Name:        "stage",
-		Description: "Set secrets but skip deployment for machine apps",
+		Description: "Set secrets but skip deployment for machine apps; the next fly deploy ships them along with its other changes",
 	},
 }
 
@@ -60,7 +63,19 @@ func deployForSecrets(ctx context.Context, app *api.AppCompact, release *api.Rel
 		}
 
 		fmt.Fprint(out, "Secrets have been staged, but not set on VMs. Deploy or update machines in this app for the secrets to take effect.\n")
-		return
+
+		secrets, err := client.FromContext(ctx).API().GetAppSecrets(ctx, app.Name)
+		if err != nil {
+			return err
+		}
+		staged, err := stagedSecrets(ctx, app, secrets)
+		if err != nil {
+			return err
+		}
+		if len(staged) > 0 {
+			fmt.Fprintf(out, "Staged secrets, shipped by the next fly deploy: %s\n", strings.Join(staged, ", "))
+		}
+		return nil
 	}
 
 	if !app.Deployed {
@@ -112,3 +127,24 @@ func deployForSecrets(ctx context.Context, app *api.AppCompact, release *api.Rel
 
 	return err
 }
+
+// stagedSecrets returns the sorted names of the secrets of app which its
+// machines don't have yet.
+func stagedSecrets(ctx context.Context, app *api.AppCompact, secrets []api.Secret) ([]string, error) {
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, fmt.Errorf("could not create flaps client: %w", err)
+	}
+	machines, err := flapsClient.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, secret := range deploy.StagedSecrets(secrets, machines) {
+		names = append(names, secret.Name)
+	}
+	sort.Strings(names)
+
+	return names, nil
+}
@@ -16,6 +16,7 @@ func New() *cobra.Command {
 
 	services.AddCommand(
 		newList(),
+		newTestUDP(),
 	)
 
 	return services
@@ -0,0 +1,465 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// udpProbeImage is the image of the machine probes are sent from, whose
+// busybox has a UDP capable nc.
+const udpProbeImage = "alpine:3"
+
+func newTestUDP() *cobra.Command {
+	const (
+		short = "Test the UDP services of an app"
+		long  = `Tests the UDP services of an app end to end, as UDP misconfigurations fail
+silently. It checks that:
+
+  * the app has a dedicated IPv4 address, which UDP requires
+  * the machines listen on the internal port of each service, bound to the
+    fly-global-services address, without which replies leave from the wrong
+    address and never reach clients
+  * probes sent to the public port from an ephemeral machine reach the
+    machines, and whether they're answered
+
+Services which only answer valid requests can be probed with one, as --payload.
+`
+	)
+
+	cmd := command.New("test-udp", short, long, runTestUDP,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Region(),
+		flag.String{
+			Name:        "payload",
+			Description: "The payload of the probes",
+			Default:     "fly-udp-probe",
+		},
+		flag.Int{
+			Name:        "wait",
+			Description: "Seconds to wait for a reply to each probe",
+			Default:     3,
+		},
+	)
+
+	return cmd
+}
+
+// udpService is a UDP service of the machines of an app.
+type udpService struct {
+	InternalPort int
+	Ports        []int
+	Machines     []*api.Machine
+}
+
+// udpServices returns the UDP services of machines by internal port.
+func udpServices(machines []*api.Machine) []*udpService {
+	var services []*udpService
+	for _, m := range machines {
+		for _, s := range m.Config.Services {
+			if s.Protocol != "udp" {
+				continue
+			}
+			svc, ok := lo.Find(services, func(svc *udpService) bool { return svc.InternalPort == s.InternalPort })
+			if !ok {
+				svc = &udpService{InternalPort: s.InternalPort}
+				services = append(services, svc)
+			}
+			for _, p := range s.Ports {
+				if p.Port != nil && !lo.Contains(svc.Ports, *p.Port) {
+					svc.Ports = append(svc.Ports, *p.Port)
+				}
+			}
+			svc.Machines = append(svc.Machines, m)
+		}
+	}
+	return services
+}
+
+// udpChecks reports the result of each check.
+type udpChecks struct {
+	io     *iostreams.IOStreams
+	failed int
+}
+
+func (c *udpChecks) pass(format string, a ...any) {
+	fmt.Fprintf(c.io.Out, "  %s %s\n", c.io.ColorScheme().SuccessIcon(), fmt.Sprintf(format, a...))
+}
+
+func (c *udpChecks) warn(format string, a ...any) {
+	fmt.Fprintf(c.io.Out, "  %s %s\n", c.io.ColorScheme().WarningIcon(), fmt.Sprintf(format, a...))
+}
+
+func (c *udpChecks) fail(format string, a ...any) {
+	c.failed++
+	fmt.Fprintf(c.io.Out, "  %s %s\n", c.io.ColorScheme().FailureIcon(), fmt.Sprintf(format, a...))
+}
+
+func runTestUDP(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		colorize  = io.ColorScheme()
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		checks    = &udpChecks{io: io}
+	)
+
+	app, err := apiClient.GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return errors.New("testing UDP services is only supported for apps running on machines")
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	machines, _, err := flapsClient.ListFlyAppsMachines(ctx)
+	if err != nil {
+		return err
+	}
+	services := udpServices(machines)
+	if len(services) == 0 {
+		return fmt.Errorf("app %s has no UDP services, add a [[services]] section with protocol = \"udp\" to its config", appName)
+	}
+
+	fmt.Fprintln(io.Out, colorize.Bold("Addresses"))
+	ips, err := apiClient.GetIPAddresses(ctx, appName)
+	if err != nil {
+		return err
+	}
+	ip, hasIPv4 := lo.Find(ips, func(ip api.IPAddress) bool { return ip.Type == "v4" })
+	if hasIPv4 {
+		checks.pass("dedicated IPv4 address %s", ip.Address)
+	} else {
+		checks.fail("no dedicated IPv4 address, which UDP requires, allocate one with fly ips allocate-v4")
+	}
+
+	for _, svc := range services {
+		fmt.Fprintf(io.Out, "\n%s\n", colorize.Bold(fmt.Sprintf("Service on internal port %d", svc.InternalPort)))
+
+		for _, m := range svc.Machines {
+			if m.State != api.MachineStateStarted {
+				checks.warn("machine %s is %s, start it to test it", m.ID, m.State)
+				continue
+			}
+			checkUDPBinding(ctx, flapsClient, checks, m, svc.InternalPort)
+		}
+	}
+
+	if hasIPv4 {
+		fmt.Fprintf(io.Out, "\n%s\n", colorize.Bold("Probes"))
+		if err := probeUDPServices(ctx, flapsClient, checks, app, ip.Address, services); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintln(io.Out)
+	if checks.failed > 0 {
+		return fmt.Errorf("%d UDP checks failed", checks.failed)
+	}
+	fmt.Fprintln(io.Out, "All UDP checks passed")
+
+	return nil
+}
+
+// execOutput runs cmd on machine and returns its stdout.
+func execOutput(ctx context.Context, flapsClient *flaps.Client, machineID, cmd string) (string, error) {
+	out, err := flapsClient.Exec(ctx, machineID, &api.MachineExecRequest{Cmd: cmd, Timeout: 30})
+	if err != nil {
+		return "", err
+	}
+	if out.ExitCode != 0 {
+		msg := ""
+		if out.StdErr != nil {
+			msg = strings.TrimSpace(*out.StdErr)
+		}
+		return "", fmt.Errorf("%s exited with code %d: %s", cmd, out.ExitCode, msg)
+	}
+	if out.StdOut == nil {
+		return "", nil
+	}
+	return *out.StdOut, nil
+}
+
+// checkUDPBinding checks the socket machine listens on port with is bound to
+// the fly-global-services address, which replies must leave from.
+func checkUDPBinding(ctx context.Context, flapsClient *flaps.Client, checks *udpChecks, m *api.Machine, port int) {
+	hosts, err := execOutput(ctx, flapsClient, m.ID, "getent hosts fly-global-services")
+	if err != nil {
+		checks.fail("machine %s can't resolve fly-global-services: %v", m.ID, err)
+		return
+	}
+	fields := strings.Fields(hosts)
+	if len(fields) == 0 || net.ParseIP(fields[0]) == nil {
+		checks.fail("machine %s can't resolve fly-global-services", m.ID)
+		return
+	}
+	globalServices := net.ParseIP(fields[0])
+
+	procNetUDP, err := execOutput(ctx, flapsClient, m.ID, "cat /proc/net/udp")
+	if err != nil {
+		checks.fail("machine %s: failed reading its UDP sockets: %v", m.ID, err)
+		return
+	}
+	sockets, err := parseProcNetUDP(procNetUDP)
+	if err != nil {
+		checks.fail("machine %s: %v", m.ID, err)
+		return
+	}
+
+	switch bindingOf(sockets, port, globalServices) {
+	case udpBoundToGlobalServices:
+		checks.pass("machine %s listens on fly-global-services (%s) port %d", m.ID, globalServices, port)
+	case udpBoundToAny:
+		checks.fail("machine %s listens on 0.0.0.0 port %d, bind to fly-global-services (%s) instead or replies leave from the wrong address", m.ID, port, globalServices)
+	case udpBoundElsewhere:
+		checks.fail("machine %s listens on port %d, but not on fly-global-services (%s), which UDP packets are delivered to", m.ID, port, globalServices)
+	default:
+		checks.fail("machine %s doesn't listen on UDP port %d", m.ID, port)
+	}
+}
+
+// udpSocket is a local address a UDP socket is bound to.
+type udpSocket struct {
+	IP   net.IP
+	Port int
+}
+
+// parseProcNetUDP parses the local addresses of the sockets in /proc/net/udp,
+// where they're hex encoded in host byte order, little endian on Fly.
+func parseProcNetUDP(content string) ([]udpSocket, error) {
+	var sockets []udpSocket
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] == "sl" {
+			continue
+		}
+
+		addr, port, ok := strings.Cut(fields[1], ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid socket address %s in /proc/net/udp", fields[1])
+		}
+		raw, err := hex.DecodeString(addr)
+		if err != nil || len(raw) != 4 {
+			return nil, fmt.Errorf("invalid socket address %s in /proc/net/udp", fields[1])
+		}
+		p, err := strconv.ParseUint(port, 16, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid socket address %s in /proc/net/udp", fields[1])
+		}
+
+		ip := make(net.IP, 4)
+		binary.BigEndian.PutUint32(ip, binary.LittleEndian.Uint32(raw))
+		sockets = append(sockets, udpSocket{IP: ip, Port: int(p)})
+	}
+
+	return sockets, scanner.Err()
+}
+
+type udpBinding int
+
+const (
+	udpNotBound udpBinding = iota
+	udpBoundElsewhere
+	udpBoundToAny
+	udpBoundToGlobalServices
+)
+
+// bindingOf returns how the sockets listening on port are bound, the best
+// binding when there are several.
+func bindingOf(sockets []udpSocket, port int, globalServices net.IP) udpBinding {
+	binding := udpNotBound
+	for _, s := range sockets {
+		if s.Port != port {
+			continue
+		}
+		b := udpBoundElsewhere
+		switch {
+		case s.IP.Equal(globalServices):
+			b = udpBoundToGlobalServices
+		case s.IP.IsUnspecified():
+			b = udpBoundToAny
+		}
+		if b > binding {
+			binding = b
+		}
+	}
+	return binding
+}
+
+// udpCounters are the UDP counters of /proc/net/snmp.
+type udpCounters struct {
+	InDatagrams int64
+	NoPorts     int64
+}
+
+func parseUDPCounters(snmp string) (udpCounters, error) {
+	var lines [][]string
+	scanner := bufio.NewScanner(strings.NewReader(snmp))
+	for scanner.Scan() {
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 && fields[0] == "Udp:" {
+			lines = append(lines, fields[1:])
+		}
+	}
+	if len(lines) != 2 || len(lines[0]) != len(lines[1]) {
+		return udpCounters{}, errors.New("no UDP counters in /proc/net/snmp")
+	}
+
+	var (
+		counters udpCounters
+		err      error
+	)
+	for i, name := range lines[0] {
+		switch name {
+		case "InDatagrams":
+			counters.InDatagrams, err = strconv.ParseInt(lines[1][i], 10, 64)
+		case "NoPorts":
+			counters.NoPorts, err = strconv.ParseInt(lines[1][i], 10, 64)
+		}
+		if err != nil {
+			return udpCounters{}, fmt.Errorf("invalid UDP counter %s in /proc/net/snmp: %w", name, err)
+		}
+	}
+
+	return counters, nil
+}
+
+// totalUDPCounters sums the UDP counters of the started machines.
+func totalUDPCounters(ctx context.Context, flapsClient *flaps.Client, machines []*api.Machine) (udpCounters, error) {
+	var total udpCounters
+	for _, m := range machines {
+		if m.State != api.MachineStateStarted {
+			continue
+		}
+		snmp, err := execOutput(ctx, flapsClient, m.ID, "cat /proc/net/snmp")
+		if err != nil {
+			return total, fmt.Errorf("failed reading the UDP counters of machine %s: %w", m.ID, err)
+		}
+		counters, err := parseUDPCounters(snmp)
+		if err != nil {
+			return total, fmt.Errorf("machine %s: %w", m.ID, err)
+		}
+		total.InDatagrams += counters.InDatagrams
+		total.NoPorts += counters.NoPorts
+	}
+	return total, nil
+}
+
+// probeUDPServices sends probes to the public ports of services from an
+// ephemeral machine, and checks the machines of the services receive them.
+func probeUDPServices(ctx context.Context, flapsClient *flaps.Client, checks *udpChecks, app *api.AppCompact, addr string, services []*udpService) error {
+	io := iostreams.FromContext(ctx)
+
+	region := flag.GetRegion(ctx)
+	if region == "" {
+		region = services[0].Machines[0].Region
+	}
+
+	fmt.Fprintf(io.ErrOut, "Launching a machine in %s to send probes from\n", region)
+	prober, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+		AppID:  app.Name,
+		Region: region,
+		Config: &api.MachineConfig{
+			Image:       udpProbeImage,
+			Init:        api.MachineInit{Exec: []string{"sleep", "600"}},
+			AutoDestroy: true,
+			Restart:     api.MachineRestart{Policy: api.MachineRestartPolicyNo},
+			Guest:       api.MachinePresets["shared-cpu-1x"],
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed launching a machine to send probes from: %w", err)
+	}
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+		defer cancel()
+		if err := flapsClient.Destroy(ctx, api.RemoveMachineInput{AppID: app.Name, ID: prober.ID, Kill: true}); err != nil {
+			terminal.Warnf("Failed destroying probe machine %s, destroy it with fly machine destroy --force %s: %v\n", prober.ID, prober.ID, err)
+		}
+	}()
+
+	if err := flapsClient.Wait(ctx, prober, api.MachineStateStarted, time.Minute); err != nil {
+		return fmt.Errorf("probe machine %s didn't start: %w", prober.ID, err)
+	}
+
+	payload := flag.GetString(ctx, "payload")
+	wait := flag.GetInt(ctx, "wait")
+
+	for _, svc := range services {
+		for _, port := range svc.Ports {
+			before, err := totalUDPCounters(ctx, flapsClient, svc.Machines)
+			if err != nil {
+				return err
+			}
+
+			cmd := fmt.Sprintf(`sh -c "printf %%s '%s' | nc -u -w %d %s %d; true"`, strings.ReplaceAll(payload, "'", ""), wait, addr, port)
+			reply, err := execOutput(ctx, flapsClient, prober.ID, cmd)
+			if err != nil {
+				return fmt.Errorf("failed sending a probe to %s port %d: %w", addr, port, err)
+			}
+
+			after, err := totalUDPCounters(ctx, flapsClient, svc.Machines)
+			if err != nil {
+				return err
+			}
+
+			target := fmt.Sprintf("%s:%d", addr, port)
+			switch received, unbound := after.InDatagrams-before.InDatagrams, after.NoPorts-before.NoPorts; {
+			case received > 0:
+				checks.pass("probe to %s reached the machines", target)
+			case unbound > 0:
+				checks.fail("probe to %s reached a machine, but nothing listened on internal port %d", target, svc.InternalPort)
+			default:
+				checks.fail("probe to %s didn't reach the machines, check the ports of the service", target)
+			}
+
+			if reply != "" {
+				checks.pass("probe to %s was answered with %q", target, truncate(reply, 64))
+			} else {
+				checks.warn("probe to %s wasn't answered within %ds, expected if the service ignores the payload", target, wait)
+			}
+		}
+	}
+
+	return nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
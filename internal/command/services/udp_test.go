@@ -0,0 +1,61 @@
+package services
+
+import (
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const procNetUDP = `   sl  local_address rem_address   st tx_queue rx_queue tr tm->when retrnsmt   uid  timeout inode ref pointer drops
+  101: 0100007F:0035 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 12345 2 0000000000000000 0
+  102: 00000000:1F90 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 12346 2 0000000000000000 0
+  103: 050013AC:14E9 00000000:0000 07 00000000:00000000 00:00000000 00000000     0        0 12347 2 0000000000000000 0
+`
+
+func TestParseProcNetUDP(t *testing.T) {
+	sockets, err := parseProcNetUDP(procNetUDP)
+	require.NoError(t, err)
+	require.Len(t, sockets, 3)
+
+	assert.Equal(t, "127.0.0.1", sockets[0].IP.String())
+	assert.Equal(t, 53, sockets[0].Port)
+	assert.Equal(t, "0.0.0.0", sockets[1].IP.String())
+	assert.Equal(t, 8080, sockets[1].Port)
+	assert.Equal(t, "172.19.0.5", sockets[2].IP.String())
+	assert.Equal(t, 5353, sockets[2].Port)
+
+	_, err = parseProcNetUDP("  1: nonsense 00000000:0000 07\n")
+	assert.Error(t, err)
+}
+
+func TestBindingOf(t *testing.T) {
+	sockets, err := parseProcNetUDP(procNetUDP)
+	require.NoError(t, err)
+
+	globalServices := net.ParseIP("172.19.0.5")
+	assert.Equal(t, udpBoundToGlobalServices, bindingOf(sockets, 5353, globalServices))
+	assert.Equal(t, udpBoundToAny, bindingOf(sockets, 8080, globalServices))
+	assert.Equal(t, udpBoundElsewhere, bindingOf(sockets, 53, globalServices))
+	assert.Equal(t, udpNotBound, bindingOf(sockets, 9999, globalServices))
+
+	both := append(sockets, udpSocket{IP: globalServices, Port: 8080})
+	assert.Equal(t, udpBoundToGlobalServices, bindingOf(both, 8080, globalServices))
+}
+
+func TestParseUDPCounters(t *testing.T) {
+	snmp := `Ip: Forwarding DefaultTTL InReceives
+Ip: 1 64 1000
+Udp: InDatagrams NoPorts InErrors OutDatagrams RcvbufErrors SndbufErrors InCsumErrors IgnoredMulti MemErrors
+Udp: 42 7 0 40 0 0 0 0 0
+UdpLite: InDatagrams NoPorts InErrors OutDatagrams RcvbufErrors SndbufErrors InCsumErrors IgnoredMulti MemErrors
+UdpLite: 0 0 0 0 0 0 0 0 0
+`
+	counters, err := parseUDPCounters(snmp)
+	require.NoError(t, err)
+	assert.Equal(t, udpCounters{InDatagrams: 42, NoPorts: 7}, counters)
+
+	_, err = parseUDPCounters("Ip: Forwarding\nIp: 1\n")
+	assert.Error(t, err)
+}
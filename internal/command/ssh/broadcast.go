@@ -0,0 +1,228 @@
+package ssh
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/AlecAivazis/survey/v2"
+	"github.com/pkg/errors"
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/cmdutil"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/ssh"
+)
+
+// broadcasting reports whether the command is to be run on several machines
+// rather than in a session with one.
+func broadcasting(ctx context.Context) bool {
+	return flag.GetBool(ctx, "all") || len(flag.GetStringSlice(ctx, "selector")) > 0
+}
+
+// broadcastResult is the outcome of running a command on a machine.
+type broadcastResult struct {
+	machine *api.Machine
+	output  []byte
+	err     error
+}
+
+// matchMachines returns the machines which have all the metadata of selector.
+func matchMachines(machines []*api.Machine, selector map[string]string) []*api.Machine {
+	return lo.Filter(machines, func(m *api.Machine, _ int) bool {
+		for key, value := range selector {
+			if m.Config == nil || m.Config.Metadata[key] != value {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// broadcastTargets returns the started machines of app the command is run on:
+// those matching --selector, narrowed down interactively with --select.
+func broadcastTargets(ctx context.Context, app *api.AppCompact) ([]*api.Machine, error) {
+	selector, err := cmdutil.ParseKVStringsToMap(flag.GetStringSlice(ctx, "selector"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector: %w", err)
+	}
+
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+	machines, err := flapsClient.ListActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	machines = lo.Filter(matchMachines(machines, selector), func(m *api.Machine, _ int) bool {
+		return m.State == "started"
+	})
+	if len(machines) == 0 {
+		return nil, fmt.Errorf("app %s has no started VMs matching the selector", app.Name)
+	}
+
+	if !flag.GetBool(ctx, "select") {
+		return machines, nil
+	}
+
+	options := lo.Map(machines, func(m *api.Machine, _ int) string {
+		return fmt.Sprintf("%s: %s %s %s", m.Region, m.ID, m.PrivateIP, m.Name)
+	})
+	var selected []int
+	prompt := &survey.MultiSelect{
+		Message:  "Select VMs:",
+		Options:  options,
+		Default:  options,
+		PageSize: 15,
+	}
+	if err := survey.AskOne(prompt, &selected, survey.WithValidator(survey.Required)); err != nil {
+		return nil, fmt.Errorf("selecting VMs: %w", err)
+	}
+
+	return lo.Map(selected, func(i int, _ int) *api.Machine { return machines[i] }), nil
+}
+
+// validateBroadcast checks the command can be run on several machines of app,
+// before connecting to them.
+func validateBroadcast(ctx context.Context, app *api.AppCompact) error {
+	switch {
+	case flag.GetString(ctx, "command") == "":
+		return errors.New("--all and --selector run a single --command, an interactive session can only be opened with one VM")
+	case app.PlatformVersion != "machines":
+		return errors.New("--all and --selector are only supported by apps on the machines platform")
+	case flag.GetString(ctx, "address") != "" || len(flag.Args(ctx)) > 0:
+		return errors.New("--all and --selector can't be combined with an address")
+	}
+	return nil
+}
+
+// runBroadcast runs --command on every machine of app matching --selector, or
+// on all of them with --all, a few at a time. The output of each machine is
+// printed once it's done, followed by a summary. It fails when the command
+// failed on any machine.
+func runBroadcast(ctx context.Context, app *api.AppCompact, dialer agent.Dialer) error {
+	var (
+		io          = iostreams.FromContext(ctx)
+		command     = flag.GetString(ctx, "command")
+		concurrency = flag.GetInt(ctx, "concurrency")
+	)
+
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	machines, err := broadcastTargets(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	cert, pk, err := singleUseSSHCertificate(ctx, app.Organization)
+	if err != nil {
+		return fmt.Errorf("create ssh certificate: %w (if you haven't created a key for your org yet, try `flyctl ssh issue`)", err)
+	}
+	pemkey := string(ssh.MarshalED25519PrivateKey(pk, "single-use certificate"))
+
+	if !quiet(ctx) {
+		fmt.Fprintf(io.ErrOut, "Running %q on %d VMs\n", command, len(machines))
+	}
+
+	var (
+		results = make([]broadcastResult, len(machines))
+		sem     = make(chan struct{}, concurrency)
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+	)
+	for i, m := range machines {
+		wg.Add(1)
+		go func(i int, m *api.Machine) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			client := &ssh.Client{
+				Addr:        net.JoinHostPort(m.PrivateIP, "22"),
+				User:        "root",
+				Dial:        dialer.DialContext,
+				Certificate: cert.Certificate,
+				PrivateKey:  pemkey,
+			}
+			output, err := runOnMachine(ctx, client, command)
+			results[i] = broadcastResult{machine: m, output: output, err: err}
+
+			mu.Lock()
+			defer mu.Unlock()
+			printBroadcastResult(io.Out, io.ColorScheme(), results[i])
+		}(i, m)
+	}
+	wg.Wait()
+
+	failed := lo.Filter(results, func(r broadcastResult, _ int) bool { return r.err != nil })
+	fmt.Fprintln(io.Out, summarizeBroadcast(results))
+	if len(failed) > 0 {
+		return fmt.Errorf("command failed on %d of %d VMs", len(failed), len(results))
+	}
+
+	return nil
+}
+
+// runOnMachine runs command without a terminal, returning what it wrote to
+// stdout and stderr.
+func runOnMachine(ctx context.Context, client *ssh.Client, command string) ([]byte, error) {
+	if err := client.Connect(ctx); err != nil {
+		return nil, errors.Wrap(err, "error connecting to SSH server")
+	}
+	defer client.Close() //skipcq: GO-S2307
+
+	sess, err := client.Client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Close() //skipcq: GO-S2307
+
+	return sess.CombinedOutput(command)
+}
+
+func printBroadcastResult(w io.Writer, cs *iostreams.ColorScheme, r broadcastResult) {
+	icon := cs.SuccessIcon()
+	if r.err != nil {
+		icon = cs.FailureIcon()
+	}
+	fmt.Fprintf(w, "%s %s (%s)\n", icon, cs.Bold(r.machine.ID), r.machine.Region)
+
+	if output := strings.TrimRight(string(r.output), "\r\n"); output != "" {
+		for _, line := range strings.Split(output, "\n") {
+			fmt.Fprintf(w, "  %s\n", strings.TrimRight(line, "\r"))
+		}
+	}
+	if r.err != nil {
+		fmt.Fprintf(w, "  %s\n", cs.Red(r.err.Error()))
+	}
+}
+
+// summarizeBroadcast counts the machines the command succeeded and failed on,
+// listing the latter.
+func summarizeBroadcast(results []broadcastResult) string {
+	var failed []string
+	for _, r := range results {
+		if r.err != nil {
+			failed = append(failed, r.machine.ID)
+		}
+	}
+	sort.Strings(failed)
+
+	summary := fmt.Sprintf("Succeeded on %d of %d VMs", len(results)-len(failed), len(results))
+	if len(failed) > 0 {
+		summary += fmt.Sprintf(", failed on %s", strings.Join(failed, ", "))
+	}
+	return summary
+}
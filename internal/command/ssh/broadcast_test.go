@@ -0,0 +1,41 @@
+package ssh
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestMatchMachines(t *testing.T) {
+	machines := []*api.Machine{
+		{ID: "web", Config: &api.MachineConfig{Metadata: map[string]string{"fly_process_group": "app", "tier": "1"}}},
+		{ID: "worker", Config: &api.MachineConfig{Metadata: map[string]string{"fly_process_group": "worker", "tier": "1"}}},
+		{ID: "unconfigured"},
+	}
+
+	ids := func(machines []*api.Machine) (ids []string) {
+		for _, m := range machines {
+			ids = append(ids, m.ID)
+		}
+		return
+	}
+
+	assert.Equal(t, []string{"web", "worker", "unconfigured"}, ids(matchMachines(machines, nil)))
+	assert.Equal(t, []string{"worker"}, ids(matchMachines(machines, map[string]string{"fly_process_group": "worker"})))
+	assert.Equal(t, []string{"web", "worker"}, ids(matchMachines(machines, map[string]string{"tier": "1"})))
+	assert.Empty(t, matchMachines(machines, map[string]string{"tier": "1", "fly_process_group": "db"}))
+}
+
+func TestSummarizeBroadcast(t *testing.T) {
+	results := []broadcastResult{
+		{machine: &api.Machine{ID: "c"}, err: errors.New("Process exited with status 1")},
+		{machine: &api.Machine{ID: "b"}},
+		{machine: &api.Machine{ID: "a"}, err: errors.New("error connecting to SSH server")},
+	}
+
+	assert.Equal(t, "Succeeded on 1 of 3 VMs, failed on a, c", summarizeBroadcast(results))
+	assert.Equal(t, "Succeeded on 1 of 1 VMs", summarizeBroadcast(results[1:2]))
+}
@@ -91,7 +91,10 @@ func lookupAddress(ctx context.Context, cli *agent.Client, dialer agent.Dialer,
 
 func newConsole() *cobra.Command {
 	const (
-		long  = `Connect to a running instance of the current app.`
+		long = `Connect to a running instance of the current app.
+
+With --all or --selector, the --command is run on every started VM of the app,
+or those with the given metadata, and their output is printed as they finish.`
 		short = long
 		usage = "console"
 	)
@@ -101,6 +104,21 @@ func newConsole() *cobra.Command {
 	cmd.Args = cobra.MaximumNArgs(1)
 
 	stdArgsSSH(cmd)
+	flag.Add(cmd,
+		flag.Bool{
+			Name:        "all",
+			Description: "Run the command on every started VM of the app. Combined with --select, choose several VMs",
+		},
+		flag.StringSlice{
+			Name:        "selector",
+			Description: "Run the command on every started VM with this metadata, as key=value, such as fly_process_group=worker. Can be specified multiple times, in which case VMs need all of it",
+		},
+		flag.Int{
+			Name:        "concurrency",
+			Description: "How many VMs --all and --selector run the command on at once",
+			Default:     8,
+		},
+	)
 
 	return cmd
 }
@@ -166,11 +184,21 @@ func runConsole(ctx context.Context) error {
 		return fmt.Errorf("get app: %w", err)
 	}
 
+	if broadcasting(ctx) {
+		if err := validateBroadcast(ctx, app); err != nil {
+			return err
+		}
+	}
+
 	agentclient, dialer, err := bringUp(ctx, client, app)
 	if err != nil {
 		return err
 	}
 
+	if broadcasting(ctx) {
+		return runBroadcast(ctx, app, dialer)
+	}
+
 	addr, err := lookupAddress(ctx, agentclient, dialer, app, true)
 	if err != nil {
 		return err
@@ -14,6 +14,8 @@ import (
 
 	"github.com/pkg/sftp"
 	"github.com/spf13/cobra"
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/api"
 	"github.com/superfly/flyctl/client"
 	"github.com/superfly/flyctl/internal/appconfig"
 	"github.com/superfly/flyctl/internal/command"
@@ -104,11 +106,17 @@ func newSFTPConnection(ctx context.Context) (*sftp.Client, error) {
 		return nil, err
 	}
 
+	return NewSFTPClient(ctx, app, dialer, addr)
+}
+
+// NewSFTPClient opens an SFTP session with the machine or VM of app at addr,
+// reachable through dialer.
+func NewSFTPClient(ctx context.Context, app *api.AppCompact, dialer agent.Dialer, addr string) (*sftp.Client, error) {
 	params := &SSHParams{
 		Ctx:            ctx,
 		Org:            app.Organization,
 		Dialer:         dialer,
-		App:            appName,
+		App:            app.Name,
 		Stdin:          os.Stdin,
 		Stdout:         os.Stdout,
 		Stderr:         os.Stderr,
@@ -11,6 +11,7 @@ import (
 	"io"
 	"net"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
@@ -88,6 +89,110 @@ func RunSSHCommand(ctx context.Context, app *api.AppCompact, dialer agent.Dialer
 	return outBuf.Bytes(), nil
 }
 
+// Exec runs cmd on the machine or VM of app at addr without a terminal,
+// streaming stdin to it and its stdout back, so that binary data such as
+// archives passes through untouched. Errors include what cmd wrote to stderr.
+func Exec(ctx context.Context, app *api.AppCompact, dialer agent.Dialer, addr, cmd string, stdin io.Reader, stdout io.Writer) error {
+	client, err := sshConnect(&SSHParams{
+		Ctx:            ctx,
+		Org:            app.Organization,
+		Dialer:         dialer,
+		App:            app.Name,
+		DisableSpinner: true,
+	}, addr)
+	if err != nil {
+		captureError(err, app)
+		return err
+	}
+	defer client.Close() //skipcq: GO-S2307
+
+	sess, err := client.Client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer sess.Close() //skipcq: GO-S2307
+
+	// sessions don't take a context, closing the connection interrupts them
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			client.Close()
+		case <-done:
+		}
+	}()
+
+	var stderr bytes.Buffer
+	sess.Stdin, sess.Stdout, sess.Stderr = stdin, stdout, &stderr
+	if err := sess.Run(cmd); err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// Attach runs cmd on the machine or VM of app at addr, attaching stdin,
+// stdout and stderr to it as docker exec does. With tty, cmd runs in a
+// terminal sized and resized as the one of stdin, if any, which is put in raw
+// mode meanwhile. The error returned by a failed cmd is an *ssh.ExitError
+// carrying its exit status.
+func Attach(ctx context.Context, app *api.AppCompact, dialer agent.Dialer, addr, cmd string, tty bool, stdin io.Reader, stdout, stderr io.WriteCloser) error {
+	client, err := sshConnect(&SSHParams{
+		Ctx:            ctx,
+		Org:            app.Organization,
+		Dialer:         dialer,
+		App:            app.Name,
+		DisableSpinner: true,
+	}, addr)
+	if err != nil {
+		captureError(err, app)
+		return err
+	}
+	defer client.Close() //skipcq: GO-S2307
+
+	if tty {
+		currentStdin, currentStdout, currentStderr, err := setupConsole()
+		if err != nil {
+			return err
+		}
+		defer cleanupConsole(currentStdin, currentStdout, currentStderr)
+
+		return client.Shell(ctx, &ssh.Terminal{
+			Stdin:  stdin,
+			Stdout: stdout,
+			Stderr: stderr,
+			Mode:   "xterm",
+		}, cmd)
+	}
+
+	sess, err := client.Client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer sess.Close() //skipcq: GO-S2307
+
+	// stdin is copied apart from the session, which would otherwise wait for
+	// stdin to be closed before returning once cmd exits
+	in, err := sess.StdinPipe()
+	if err != nil {
+		return err
+	}
+	go func() {
+		_, _ = io.Copy(in, stdin)
+		in.Close()
+	}()
+
+	sess.Stdout, sess.Stderr = stdout, stderr
+	return sess.Run(cmd)
+}
+
 func SSHConnect(p *SSHParams, addr string) error {
 	terminal.Debugf("Fetching certificate for %s\n", addr)
 
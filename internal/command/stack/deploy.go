@@ -0,0 +1,417 @@
+package stack
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/build/imgsrc"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/deploy"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/internal/state"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+// ManifestFileName is the name of the optional file, at the root of the stack,
+// declaring how its apps depend on each other.
+const ManifestFileName = "fly.stack.toml"
+
+// skippedDirs are never searched for app config files.
+var skippedDirs = map[string]bool{
+	".git":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+func newDeploy() (cmd *cobra.Command) {
+	const (
+		short = "Deploy every app of a directory tree"
+		long  = `Deploy every app of a directory tree, such as a monorepo with a fly.toml per
+app, found in the working directory or the one given and its subdirectories.
+
+A fly.stack.toml file at the root of the tree can order the deployments, or
+leave apps out:
+
+  [apps.web]
+  depends_on = ["api"]
+
+  [apps.admin]
+  skip = true
+
+Every image is built before any app is deployed, once for the apps of an
+organization built from the same directory and build section. An app whose
+deployment failed fails the apps depending on it, the others are still
+deployed.`
+	)
+
+	cmd = command.New("deploy [DIRECTORY]", short, long, runDeploy,
+		command.RequireSession,
+		command.ChangeWorkingDirectoryToFirstArgIfPresent,
+	)
+	cmd.Args = cobra.MaximumNArgs(1)
+
+	flag.Add(cmd,
+		flag.RemoteOnly(false),
+		flag.LocalOnly(),
+		flag.Detach(),
+		flag.Strategy(),
+		flag.NoCache(),
+		flag.BuildOnly(),
+		deploy.MachinesFlags,
+		flag.StringSlice{
+			Name:        "only",
+			Description: "Only deploy these comma separated apps of the stack, in the order of the stack",
+		},
+	)
+
+	return
+}
+
+// Manifest declares how the apps of a stack depend on each other.
+type Manifest struct {
+	Apps map[string]AppManifest `toml:"apps"`
+}
+
+// AppManifest declares how a single app of the stack is deployed.
+type AppManifest struct {
+	DependsOn []string `toml:"depends_on"`
+	Skip      bool     `toml:"skip"`
+}
+
+// stackApp is an app of the stack along with its deployment.
+type stackApp struct {
+	name      string
+	dir       string
+	cfg       *appconfig.Config
+	dependsOn []string
+
+	org    string
+	img    *imgsrc.DeploymentImage
+	status string
+	err    error
+}
+
+// Statuses of the deployment of an app.
+const (
+	statusPending  = "pending"
+	statusDeployed = "deployed"
+	statusBuilt    = "built"
+	statusFailed   = "failed"
+	statusSkipped  = "skipped"
+)
+
+func runDeploy(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		root      = state.WorkingDirectory(ctx)
+	)
+
+	manifest, err := loadManifest(root)
+	if err != nil {
+		return err
+	}
+
+	apps, err := discoverApps(root)
+	if err != nil {
+		return err
+	}
+	if apps, err = orderApps(apps, manifest, flag.GetStringSlice(ctx, "only")); err != nil {
+		return err
+	}
+
+	names := make([]string, 0, len(apps))
+	for _, app := range apps {
+		names = append(names, app.name)
+	}
+	fmt.Fprintf(io.Out, "Deploying %d apps: %s\n", len(apps), strings.Join(names, ", "))
+
+	for _, app := range apps {
+		compact, err := apiClient.GetAppCompact(ctx, app.name)
+		if err != nil {
+			return fmt.Errorf("failed retrieving app %s: %w", app.name, err)
+		}
+		app.org = compact.Organization.Slug
+	}
+
+	builds := make([]deploy.ImageBuild, len(apps))
+	for i, app := range apps {
+		key, err := buildKey(app)
+		if err != nil {
+			return err
+		}
+		builds[i] = deploy.ImageBuild{
+			App:     app.name,
+			Source:  relativeDir(root, app.dir),
+			Key:     key,
+			Context: appContext(ctx, app),
+			Config:  app.cfg,
+		}
+	}
+	imgs, err := deploy.BuildImages(ctx, builds)
+	if err != nil {
+		return err
+	}
+	for i, app := range apps {
+		app.img = imgs[i]
+	}
+
+	if flag.GetBuildOnly(ctx) {
+		for _, app := range apps {
+			app.status = statusBuilt
+		}
+		return renderSummary(ctx, root, apps)
+	}
+
+	for i, app := range apps {
+		if failed := failedDependency(app, apps); failed != "" {
+			app.status, app.err = statusSkipped, fmt.Errorf("%s failed", failed)
+			continue
+		}
+
+		fmt.Fprintf(io.Out, "==> [%d/%d] Deploying %s\n", i+1, len(apps), app.name)
+
+		err := deploy.DeployWithConfig(appContext(ctx, app), app.cfg, deploy.DeployWithConfigArgs{
+			ForceYes: flag.GetBool(ctx, "auto-confirm"),
+			Image:    app.img,
+		})
+		if errors.Is(err, context.Canceled) {
+			return err
+		}
+		if err != nil {
+			app.status, app.err = statusFailed, err
+			fmt.Fprintf(io.ErrOut, "%s Deploying %s failed: %v\n", io.ColorScheme().FailureIcon(), app.name, err)
+			continue
+		}
+		app.status = statusDeployed
+	}
+
+	return renderSummary(ctx, root, apps)
+}
+
+// renderSummary prints the outcome of the deployment of every app, failing
+// unless they were all deployed.
+func renderSummary(ctx context.Context, root string, apps []*stackApp) error {
+	io := iostreams.FromContext(ctx)
+
+	var (
+		rows   [][]string
+		failed int
+	)
+	for _, app := range apps {
+		status := app.status
+		if app.err != nil {
+			failed++
+			status = fmt.Sprintf("%s: %v", status, app.err)
+		}
+		rows = append(rows, []string{app.name, relativeDir(root, app.dir), status})
+	}
+
+	fmt.Fprintln(io.Out)
+	if err := render.Table(io.Out, "Stack", rows, "App", "Directory", "Status"); err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d apps of the stack failed to deploy", failed, len(apps))
+	}
+	return nil
+}
+
+// appContext derives the context the deployment of app runs with.
+func appContext(ctx context.Context, app *stackApp) context.Context {
+	ctx = state.WithWorkingDirectory(ctx, app.dir)
+	ctx = appconfig.WithName(ctx, app.name)
+	return appconfig.WithConfig(ctx, app.cfg)
+}
+
+// failedDependency returns the first app app depends on which failed, or was
+// skipped as one of its own dependencies failed.
+func failedDependency(app *stackApp, apps []*stackApp) string {
+	byName := map[string]*stackApp{}
+	for _, a := range apps {
+		byName[a.name] = a
+	}
+
+	for _, dep := range app.dependsOn {
+		if d, ok := byName[dep]; ok && (d.status == statusFailed || d.status == statusSkipped) {
+			return dep
+		}
+	}
+	return ""
+}
+
+// buildKey identifies the image of app: apps of the same organization built
+// from the same directory and build section share it.
+func buildKey(app *stackApp) (string, error) {
+	build, err := json.Marshal(app.cfg.Build)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join([]string{app.org, app.dir, string(build)}, "\x00"), nil
+}
+
+func loadManifest(root string) (*Manifest, error) {
+	path := filepath.Join(root, ManifestFileName)
+	manifest := &Manifest{}
+
+	buf, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return manifest, nil
+	case err != nil:
+		return nil, err
+	}
+
+	if err := toml.Unmarshal(buf, manifest); err != nil {
+		return nil, fmt.Errorf("failed parsing %s: %w", path, err)
+	}
+
+	return manifest, nil
+}
+
+// discoverApps loads the app config files found in root and its
+// subdirectories, sorted by path.
+func discoverApps(root string) ([]*stackApp, error) {
+	var (
+		apps  []*stackApp
+		paths = map[string]string{}
+	)
+
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		switch {
+		case err != nil:
+			return err
+		case d.IsDir() && path != root && (skippedDirs[d.Name()] || strings.HasPrefix(d.Name(), ".")):
+			return filepath.SkipDir
+		case d.IsDir() || !appconfig.IsConfigFileName(d.Name()):
+			return nil
+		}
+
+		cfg, err := appconfig.LoadConfig(path)
+		if err != nil {
+			return fmt.Errorf("failed loading %s: %w", path, err)
+		}
+		if cfg.AppName == "" {
+			return fmt.Errorf("%s doesn't set the name of its app", path)
+		}
+		if other, ok := paths[cfg.AppName]; ok {
+			return fmt.Errorf("both %s and %s configure app %s", other, path, cfg.AppName)
+		}
+		paths[cfg.AppName] = path
+
+		apps = append(apps, &stackApp{name: cfg.AppName, dir: filepath.Dir(path), cfg: cfg, status: statusPending})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(apps) == 0 {
+		return nil, fmt.Errorf("no %s found in %s", appconfig.DefaultConfigFileName, root)
+	}
+
+	return apps, nil
+}
+
+// orderApps drops the apps manifest skips, or those not listed in only when
+// it's set, and sorts the others so that every app comes after those it
+// depends on. Apps which don't depend on each other keep their order.
+func orderApps(apps []*stackApp, manifest *Manifest, only []string) ([]*stackApp, error) {
+	byName := map[string]*stackApp{}
+	for _, app := range apps {
+		byName[app.name] = app
+	}
+
+	for name, m := range manifest.Apps {
+		if _, ok := byName[name]; !ok {
+			return nil, fmt.Errorf("%s configures app %s, which has no %s", ManifestFileName, name, appconfig.DefaultConfigFileName)
+		}
+		for _, dep := range m.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, fmt.Errorf("app %s depends on app %s, which has no %s", name, dep, appconfig.DefaultConfigFileName)
+			}
+		}
+		byName[name].dependsOn = m.DependsOn
+	}
+	for _, name := range only {
+		if _, ok := byName[name]; !ok {
+			return nil, fmt.Errorf("app %s is not part of the stack", name)
+		}
+	}
+
+	included := func(app *stackApp) bool {
+		if manifest.Apps[app.name].Skip {
+			return false
+		}
+		if len(only) == 0 {
+			return true
+		}
+		for _, name := range only {
+			if name == app.name {
+				return true
+			}
+		}
+		return false
+	}
+
+	var (
+		sorted   []*stackApp
+		visited  = map[string]bool{}
+		visiting = map[string]bool{}
+		visit    func(*stackApp) error
+	)
+	visit = func(app *stackApp) error {
+		switch {
+		case visited[app.name]:
+			return nil
+		case visiting[app.name]:
+			return fmt.Errorf("circular dependency between app %s and the apps it depends on", app.name)
+		}
+		visiting[app.name] = true
+		deps := append([]string(nil), app.dependsOn...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if err := visit(byName[dep]); err != nil {
+				return err
+			}
+		}
+		visiting[app.name] = false
+		visited[app.name] = true
+		if included(app) {
+			sorted = append(sorted, app)
+		}
+		return nil
+	}
+	for _, app := range apps {
+		if err := visit(app); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(sorted) == 0 {
+		return nil, errors.New("every app is skipped; nothing to deploy")
+	}
+
+	return sorted, nil
+}
+
+func relativeDir(root, dir string) string {
+	if rel, err := filepath.Rel(root, dir); err == nil {
+		return rel
+	}
+	return dir
+}
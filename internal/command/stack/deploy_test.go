@@ -0,0 +1,98 @@
+package stack
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeAppConfig(t *testing.T, root, dir, app string) {
+	t.Helper()
+
+	dir = filepath.Join(root, dir)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fly.toml"), []byte("app = \""+app+"\"\n"), 0o644))
+}
+
+func appNames(apps []*stackApp) (names []string) {
+	for _, app := range apps {
+		names = append(names, app.name)
+	}
+	return
+}
+
+func TestDiscoverApps(t *testing.T) {
+	root := t.TempDir()
+	writeAppConfig(t, root, "services/web", "stack-web")
+	writeAppConfig(t, root, "services/api", "stack-api")
+	writeAppConfig(t, root, "node_modules/pkg", "ignored")
+	writeAppConfig(t, root, ".github", "ignored-too")
+
+	apps, err := discoverApps(root)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stack-api", "stack-web"}, appNames(apps))
+	assert.Equal(t, filepath.Join(root, "services/api"), apps[0].dir)
+
+	writeAppConfig(t, root, "legacy/web", "stack-web")
+	_, err = discoverApps(root)
+	assert.ErrorContains(t, err, "configure app stack-web")
+
+	_, err = discoverApps(t.TempDir())
+	assert.ErrorContains(t, err, "no fly.toml found")
+}
+
+func TestOrderApps(t *testing.T) {
+	apps := func() []*stackApp {
+		return []*stackApp{{name: "admin"}, {name: "api"}, {name: "db-proxy"}, {name: "web"}}
+	}
+
+	sorted, err := orderApps(apps(), &Manifest{
+		Apps: map[string]AppManifest{
+			"web":   {DependsOn: []string{"api"}},
+			"api":   {DependsOn: []string{"db-proxy"}},
+			"admin": {DependsOn: []string{"api"}},
+		},
+	}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db-proxy", "api", "admin", "web"}, appNames(sorted))
+
+	sorted, err = orderApps(apps(), &Manifest{
+		Apps: map[string]AppManifest{
+			"web":   {DependsOn: []string{"api"}},
+			"admin": {Skip: true},
+		},
+	}, []string{"web", "api"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api", "web"}, appNames(sorted))
+
+	_, err = orderApps(apps(), &Manifest{
+		Apps: map[string]AppManifest{
+			"web": {DependsOn: []string{"api"}},
+			"api": {DependsOn: []string{"web"}},
+		},
+	}, nil)
+	assert.ErrorContains(t, err, "circular dependency")
+
+	_, err = orderApps(apps(), &Manifest{
+		Apps: map[string]AppManifest{"web": {DependsOn: []string{"cache"}}},
+	}, nil)
+	assert.ErrorContains(t, err, "depends on app cache")
+
+	_, err = orderApps(apps(), &Manifest{}, []string{"worker"})
+	assert.ErrorContains(t, err, "app worker is not part of the stack")
+}
+
+func TestFailedDependency(t *testing.T) {
+	api := &stackApp{name: "api", status: statusFailed}
+	web := &stackApp{name: "web", dependsOn: []string{"api"}, status: statusSkipped}
+	admin := &stackApp{name: "admin", dependsOn: []string{"web"}}
+	worker := &stackApp{name: "worker"}
+	apps := []*stackApp{api, web, admin, worker}
+
+	assert.Equal(t, "api", failedDependency(web, apps))
+	assert.Equal(t, "web", failedDependency(admin, apps))
+	assert.Equal(t, "", failedDependency(worker, apps))
+}
@@ -0,0 +1,23 @@
+// Package stack implements the stack command chain.
+package stack
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+)
+
+// New initializes and returns a new stack Command.
+func New() (cmd *cobra.Command) {
+	const (
+		short = "Work with the apps of a monorepo"
+		long  = `The STACK commands deploy every app of a directory tree, such as a monorepo
+with a fly.toml per app, together.`
+	)
+	cmd = command.New("stack", short, long, nil)
+
+	cmd.AddCommand(
+		newDeploy(),
+	)
+	return
+}
@@ -0,0 +1,221 @@
+package status
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"sort"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/command/curl"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newLatency() (cmd *cobra.Command) {
+	const (
+		long = `Measure the latency of requests to the app from Fly edge regions.
+
+Requests are made to the app's hostname from each region, through the Fly
+edge probing service. By default, only the regions the app runs in are probed;
+use --all-regions to probe every region. Requests landing on an address which
+isn't one of the app's are flagged, as they point to broken anycast routing.
+`
+		short = "Show edge latency to the app per region"
+	)
+
+	cmd = command.New("latency", short, long, runLatency,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.NoArgs
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Bool{
+			Name:        "all-regions",
+			Description: "Probe from every region instead of only the app's regions",
+		},
+		flag.String{
+			Name:        "path",
+			Description: "The path to request",
+			Default:     "/",
+		},
+	)
+
+	return
+}
+
+func runLatency(ctx context.Context) error {
+	var (
+		appName = appconfig.NameFromContext(ctx)
+		client  = client.FromContext(ctx).API()
+	)
+
+	app, err := client.GetAppCompact(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed to get app: %w", err)
+	}
+	if app.Hostname == "" {
+		return fmt.Errorf("app %s has no hostname", appName)
+	}
+
+	target := &url.URL{
+		Scheme: "https",
+		Host:   app.Hostname,
+		Path:   flag.GetString(ctx, "path"),
+	}
+
+	regionCodes, err := latencyRegions(ctx, app)
+	if err != nil {
+		return err
+	}
+
+	ips, err := client.GetIPAddresses(ctx, appName)
+	if err != nil {
+		return fmt.Errorf("failed retrieving IP addresses for %s: %w", appName, err)
+	}
+	appIPs := make(map[string]bool, len(ips))
+	for _, ip := range ips {
+		appIPs[ip.Address] = true
+	}
+
+	timings, err := curl.Timings(ctx, target, regionCodes)
+	if err != nil {
+		return err
+	}
+
+	io := iostreams.FromContext(ctx)
+	if config.FromContext(ctx).JSONOutput {
+		return renderLatencyJSON(io.Out, timings, appIPs)
+	}
+
+	return renderLatency(io.Out, io.ColorScheme(), target, timings, appIPs)
+}
+
+// latencyRegions returns the regions to probe the app from: every region with
+// --all-regions, or else the regions the app runs in.
+func latencyRegions(ctx context.Context, app *api.AppCompact) ([]string, error) {
+	client := client.FromContext(ctx).API()
+
+	var regions []api.Region
+	switch {
+	case flag.GetBool(ctx, "all-regions"):
+		all, _, err := client.PlatformRegions(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed retrieving regions: %w", err)
+		}
+		regions = all
+	case app.PlatformVersion == "machines":
+		flapsClient, err := flaps.New(ctx, app)
+		if err != nil {
+			return nil, fmt.Errorf("could not create flaps client: %w", err)
+		}
+		machines, err := flapsClient.ListActive(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed retrieving machines: %w", err)
+		}
+		for _, m := range machines {
+			regions = append(regions, api.Region{Code: m.Region})
+		}
+	default:
+		appRegions, _, err := client.ListAppRegions(ctx, app.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed retrieving regions for %s: %w", app.Name, err)
+		}
+		regions = appRegions
+	}
+
+	seen := map[string]bool{}
+	var codes []string
+	for _, region := range regions {
+		if !seen[region.Code] {
+			seen[region.Code] = true
+			codes = append(codes, region.Code)
+		}
+	}
+	if len(codes) == 0 {
+		return nil, errors.New("the app doesn't run in any region, use --all-regions to probe every region")
+	}
+	sort.Strings(codes)
+
+	return codes, nil
+}
+
+func renderLatency(w io.Writer, cs *iostreams.ColorScheme, target *url.URL, timings []*curl.Timing, appIPs map[string]bool) error {
+	var (
+		rows      [][]string
+		failures  [][]string
+		misrouted int
+	)
+	for _, t := range timings {
+		if err := t.Err(); err != nil {
+			failures = append(failures, []string{t.Region(), err.Error()})
+
+			continue
+		}
+
+		ip := t.RemoteIP
+		if !appIPs[ip] {
+			ip = cs.Red(ip + " (unexpected)")
+			misrouted++
+		}
+
+		rows = append(rows, []string{
+			t.Region(),
+			ip,
+			t.FormattedHTTPCode(cs),
+			t.FormattedConnect(cs),
+			t.FormattedTLS(),
+			t.FormattedTTFB(cs),
+			t.FormattedTotal(),
+		})
+	}
+
+	title := fmt.Sprintf("Latency to %s", target)
+	if err := render.Table(w, title, rows, "Region", "Edge IP", "Status", "Connect", "TLS", "TTFB", "Total"); err != nil {
+		return err
+	}
+
+	if misrouted > 0 {
+		fmt.Fprintf(w, "%s %d region(s) reached an address which isn't allocated to the app; check its DNS records and IP addresses\n\n",
+			cs.WarningIcon(), misrouted)
+	}
+
+	if len(failures) == 0 {
+		return nil
+	}
+
+	return render.Table(w, "Failures", failures, "Region", "Error")
+}
+
+func renderLatencyJSON(w io.Writer, timings []*curl.Timing, appIPs map[string]bool) error {
+	type regionLatency struct {
+		*curl.Timing
+		Error     string `json:"error,omitempty"`
+		Misrouted bool   `json:"misrouted,omitempty"`
+	}
+
+	items := make(map[string]regionLatency, len(timings))
+	for _, t := range timings {
+		if err := t.Err(); err != nil {
+			items[t.Region()] = regionLatency{Error: err.Error()}
+		} else {
+			items[t.Region()] = regionLatency{Timing: t, Misrouted: !appIPs[t.RemoteIP]}
+		}
+	}
+
+	return render.JSON(w, items)
+}
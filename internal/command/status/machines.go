@@ -68,6 +68,26 @@ func getImage(machines []*api.Machine) (string, error) {
 	return latestImage, nil
 }
 
+// driftedMachines returns the machines on an older release than the latest of
+// their release channel, as deployments to some of the machines leave them,
+// along with the latest release version of each channel.
+func driftedMachines(machines []*api.Machine) (drifted []*api.Machine, latest map[string]int) {
+	latest = map[string]int{}
+	for _, machine := range machines {
+		if version, err := strconv.Atoi(getReleaseVersion(machine)); err == nil && version > latest[machine.ReleaseChannel()] {
+			latest[machine.ReleaseChannel()] = version
+		}
+	}
+
+	for _, machine := range machines {
+		if version, err := strconv.Atoi(getReleaseVersion(machine)); err == nil && version < latest[machine.ReleaseChannel()] {
+			drifted = append(drifted, machine)
+		}
+	}
+
+	return drifted, latest
+}
+
 func renderMachineStatus(ctx context.Context, app *api.AppCompact) error {
 	var (
 		io         = iostreams.FromContext(ctx)
@@ -180,6 +200,18 @@ func renderMachineStatus(ctx context.Context, app *api.AppCompact) error {
 		}
 	}
 
+	if drifted, latest := driftedMachines(managed); len(drifted) > 0 {
+		msgs := []string{"Machines behind the latest release:\n\n"}
+
+		for _, machine := range drifted {
+			msg := fmt.Sprintf("Machine %q v%s -> v%d\n", machine.ID, getReleaseVersion(machine), latest[machine.ReleaseChannel()])
+			msgs = append(msgs, msg)
+		}
+
+		fmt.Fprintln(io.ErrOut, colorize.Yellow(strings.Join(msgs, "")))
+		fmt.Fprintln(io.ErrOut, colorize.Yellow("Run `flyctl deploy` to bring them up to date."))
+	}
+
 	if len(unmanaged) > 0 {
 		msg := fmt.Sprintf("Found machines that aren't part of the Fly Apps Platform, run %s to see them.\n", io.ColorScheme().Yellow("fly machines list"))
 		fmt.Fprint(io.ErrOut, msg)
@@ -3,6 +3,7 @@ package status
 import (
 	"testing"
 
+	"github.com/samber/lo"
 	"github.com/stretchr/testify/require"
 	"github.com/superfly/flyctl/api"
 )
@@ -81,3 +82,27 @@ func TestGetImage(t *testing.T) {
 	}
 
 }
+
+func TestDriftedMachines(t *testing.T) {
+	machine := func(id, version, channel string) *api.Machine {
+		metadata := map[string]string{api.MachineConfigMetadataKeyFlyReleaseVersion: version}
+		if channel != "" {
+			metadata[api.MachineConfigMetadataKeyFlyReleaseChannel] = channel
+		}
+		return &api.Machine{ID: id, Config: &api.MachineConfig{Metadata: metadata}}
+	}
+
+	drifted, _ := driftedMachines([]*api.Machine{machine("m1", "3", ""), machine("m2", "3", "")})
+	require.Empty(t, drifted)
+
+	// after fly deploy --machine-id m2
+	drifted, latest := driftedMachines([]*api.Machine{
+		machine("m1", "3", ""),
+		machine("m2", "4", ""),
+		machine("m3", "3", ""),
+		machine("m4", "", ""),
+		machine("m5", "2", "beta"),
+	})
+	require.Equal(t, []string{"m1", "m3"}, lo.Map(drifted, func(m *api.Machine, _ int) string { return m.ID }))
+	require.Equal(t, map[string]int{api.MachineReleaseChannelStable: 4, "beta": 2}, latest)
+}
@@ -67,6 +67,7 @@ currently allocated.
 
 	cmd.AddCommand(
 		newInstance(),
+		newLatency(),
 	)
 
 	return
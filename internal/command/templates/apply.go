@@ -0,0 +1,176 @@
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newApply() (cmd *cobra.Command) {
+	const (
+		short = "Apply a template to a directory"
+		long  = `Copy the files of a template into a directory, the working directory by
+default, and list the secrets the template expects to be set.
+
+Existing files are left untouched unless --force is given.`
+	)
+
+	cmd = command.New("apply <template>", short, long, runApply)
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		sourceFlags,
+		flag.String{
+			Name:        "path",
+			Description: "Directory to apply the template to",
+			Default:     ".",
+		},
+		flag.String{
+			Name:        "name",
+			Description: "Name of the app to set in the template's fly.toml",
+		},
+		flag.Bool{
+			Name:        "force",
+			Description: "Overwrite existing files",
+		},
+	)
+
+	return
+}
+
+func runApply(ctx context.Context) error {
+	var (
+		io       = iostreams.FromContext(ctx)
+		colorize = io.ColorScheme()
+		dest     = flag.GetString(ctx, "path")
+		force    = flag.GetBool(ctx, "force")
+	)
+
+	src, err := openSource(ctx)
+	if err != nil {
+		return err
+	}
+	defer src.cleanup()
+
+	t, err := src.template(flag.FirstArg(ctx))
+	if err != nil {
+		return err
+	}
+
+	if err := applyTemplate(io, t, dest, force); err != nil {
+		return fmt.Errorf("failed applying template %s: %w", t.Name, err)
+	}
+
+	if name := flag.GetString(ctx, "name"); name != "" {
+		if err := setAppName(filepath.Join(dest, appconfig.DefaultConfigFileName), name); err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprintf(io.Out, "Applied template %s\n", colorize.Bold(t.Name))
+
+	if len(t.Secrets) > 0 {
+		fmt.Fprintln(io.Out, "\nThis template expects the following secrets to be set:")
+		for _, name := range t.Secrets {
+			fmt.Fprintf(io.Out, "  %s\n", name)
+		}
+		fmt.Fprintln(io.Out, "Set them with 'fly secrets set NAME=VALUE' once the app is created.")
+	}
+
+	return nil
+}
+
+// applyTemplate copies the files of t into dest, leaving existing files alone
+// unless force is set. Symlinks are skipped, as they may lead out of the
+// template.
+func applyTemplate(io *iostreams.IOStreams, t *Template, dest string, force bool) error {
+	colorize := io.ColorScheme()
+
+	return filepath.WalkDir(t.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(t.dir, path)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case d.IsDir() && d.Name() == ".git":
+			return filepath.SkipDir
+		case d.IsDir(), rel == ManifestFileName:
+			return nil
+		case !d.Type().IsRegular():
+			fmt.Fprintf(io.ErrOut, "  %s %s isn't a regular file; skipped\n", colorize.Yellow("*"), rel)
+			return nil
+		}
+
+		target := filepath.Join(dest, rel)
+		if _, err := os.Stat(target); err == nil && !force {
+			fmt.Fprintf(io.ErrOut, "  %s %s already exists; skipped\n", colorize.Yellow("*"), rel)
+			return nil
+		}
+
+		if err := copyFile(path, target); err != nil {
+			return err
+		}
+		fmt.Fprintf(io.Out, "  %s %s\n", colorize.Green("*"), rel)
+
+		return nil
+	})
+}
+
+func setAppName(path, name string) error {
+	cfg, err := appconfig.LoadConfig(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil
+	case err != nil:
+		return err
+	}
+
+	cfg.AppName = name
+	return cfg.WriteToFile(path)
+}
+
+func copyFile(src, dst string) (err error) {
+	if err = os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return
+	}
+
+	in, err := os.Open(src)
+	if err != nil {
+		return
+	}
+	defer in.Close()
+
+	fi, err := in.Stat()
+	if err != nil {
+		return
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fi.Mode().Perm())
+	if err != nil {
+		return
+	}
+	defer func() {
+		if e := out.Close(); err == nil {
+			err = e
+		}
+	}()
+
+	_, err = io.Copy(out, in)
+	return
+}
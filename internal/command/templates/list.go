@@ -0,0 +1,58 @@
+package templates
+
+import (
+	"context"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newList() (cmd *cobra.Command) {
+	const (
+		short = "List available templates"
+		long  = short + "\n"
+	)
+
+	cmd = command.New("list", short, long, runList)
+	cmd.Args = cobra.NoArgs
+	cmd.Aliases = []string{"ls"}
+
+	flag.Add(cmd, sourceFlags)
+
+	return
+}
+
+func runList(ctx context.Context) error {
+	src, err := openSource(ctx)
+	if err != nil {
+		return err
+	}
+	defer src.cleanup()
+
+	templates, err := src.templates()
+	if err != nil {
+		return err
+	}
+
+	out := iostreams.FromContext(ctx).Out
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(out, templates)
+	}
+
+	rows := make([][]string, 0, len(templates))
+	for _, t := range templates {
+		rows = append(rows, []string{
+			t.Name,
+			t.Description,
+			strings.Join(t.Secrets, ", "),
+		})
+	}
+
+	return render.Table(out, "", rows, "Name", "Description", "Secrets")
+}
@@ -0,0 +1,189 @@
+// Package templates implements the templates command chain.
+package templates
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/cli/safeexec"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/env"
+	"github.com/superfly/flyctl/internal/flag"
+)
+
+// ManifestFileName is the name of the file describing a template. Every
+// directory at the root of a template source containing one is a template.
+const ManifestFileName = "template.toml"
+
+const sourceEnvKey = "FLY_TEMPLATES_SOURCE"
+
+// New initializes and returns a new templates Command.
+func New() (cmd *cobra.Command) {
+	const (
+		short = "Work with app templates"
+		long  = `The TEMPLATES commands list and apply app templates: curated fly.toml
+skeletons, Dockerfiles and secrets manifests, so new apps start from a blessed
+configuration.
+
+Templates are read from a git repository or a local directory, given with
+--source or the ` + sourceEnvKey + ` environment variable. Every directory at
+the root of the source containing a ` + ManifestFileName + ` file is a template:
+
+  description = "Background worker backed by Postgres"
+  secrets = ["DATABASE_URL", "SENTRY_DSN"]`
+	)
+	cmd = command.New("templates", short, long, nil)
+
+	cmd.AddCommand(
+		newList(),
+		newApply(),
+	)
+	return
+}
+
+var sourceFlags = flag.Set{
+	flag.String{
+		Name:        "source",
+		Description: "Git repository URL or local directory to read templates from. Defaults to $" + sourceEnvKey,
+	},
+	flag.String{
+		Name:        "ref",
+		Description: "Branch or tag of the git repository to read templates from",
+	},
+}
+
+// Template wraps a template and its manifest.
+type Template struct {
+	Name        string   `toml:"-" json:"name"`
+	Description string   `toml:"description" json:"description"`
+	Secrets     []string `toml:"secrets" json:"secrets"`
+
+	dir string
+}
+
+// source is a checked out set of templates.
+type source struct {
+	dir     string
+	cleanup func()
+}
+
+// openSource makes the templates of the source the user selected available
+// locally. Callers must call cleanup once done.
+func openSource(ctx context.Context) (*source, error) {
+	location := flag.GetString(ctx, "source")
+	if location == "" {
+		location = env.First(sourceEnvKey)
+	}
+	if location == "" {
+		return nil, fmt.Errorf("no template source; pass one with --source or set %s", sourceEnvKey)
+	}
+
+	if fi, err := os.Stat(location); err == nil && fi.IsDir() {
+		return &source{dir: location, cleanup: func() {}}, nil
+	}
+
+	git, err := safeexec.LookPath("git")
+	if err != nil {
+		return nil, fmt.Errorf("git is required to read templates from %s: %w", location, err)
+	}
+
+	dir, err := os.MkdirTemp("", "flyctl-templates-*")
+	if err != nil {
+		return nil, err
+	}
+	cleanup := func() { _ = os.RemoveAll(dir) }
+
+	// the location and ref may not be taken for options, and the ext
+	// transport, which runs commands, is never allowed
+	args := []string{"-c", "protocol.ext.allow=never", "clone", "--quiet", "--depth", "1"}
+	if ref := flag.GetString(ctx, "ref"); ref != "" {
+		args = append(args, "--branch="+ref)
+	}
+	args = append(args, "--", location, dir)
+
+	out, err := exec.CommandContext(ctx, git, args...).CombinedOutput()
+	if err != nil {
+		cleanup()
+		return nil, fmt.Errorf("failed cloning %s: %w\n%s", location, err, strings.TrimSpace(string(out)))
+	}
+
+	return &source{dir: dir, cleanup: cleanup}, nil
+}
+
+// templates returns the templates of the source sorted by name.
+func (s *source) templates() ([]*Template, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var templates []*Template
+	for _, e := range entries {
+		// symlinked directories aren't templates, as they may lead out of
+		// the source
+		if !e.IsDir() || strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+
+		t, err := loadTemplate(filepath.Join(s.dir, e.Name()))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			continue
+		case err != nil:
+			return nil, err
+		}
+		templates = append(templates, t)
+	}
+
+	sort.Slice(templates, func(i, j int) bool {
+		return templates[i].Name < templates[j].Name
+	})
+
+	return templates, nil
+}
+
+// template returns the named template of the source.
+func (s *source) template(name string) (*Template, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+
+	dir := filepath.Join(s.dir, name)
+	if fi, err := os.Lstat(dir); err == nil && !fi.IsDir() {
+		return nil, fmt.Errorf("template %s isn't a directory of the source", name)
+	}
+
+	t, err := loadTemplate(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("template %s not found; run 'fly templates list' to see the available ones", name)
+	}
+
+	return t, err
+}
+
+func loadTemplate(dir string) (*Template, error) {
+	buf, err := os.ReadFile(filepath.Join(dir, ManifestFileName))
+	if err != nil {
+		return nil, err
+	}
+
+	t := &Template{
+		Name: filepath.Base(dir),
+		dir:  dir,
+	}
+	if err := toml.Unmarshal(buf, t); err != nil {
+		return nil, fmt.Errorf("failed parsing manifest of template %s: %w", t.Name, err)
+	}
+
+	return t, nil
+}
@@ -0,0 +1,136 @@
+package templates
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+// testSource returns a source with a worker template, a directory which
+// isn't a template, and a symlink to a template outside of the source.
+func testSource(t *testing.T) *source {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "worker", ManifestFileName), `description = "Background worker"
+secrets = ["DATABASE_URL"]`)
+	writeFile(t, filepath.Join(dir, "worker", "fly.toml"), `app = "template"`)
+	writeFile(t, filepath.Join(dir, "docs", "README.md"), "not a template")
+	writeFile(t, filepath.Join(dir, ".hidden", ManifestFileName), "")
+
+	outside := t.TempDir()
+	writeFile(t, filepath.Join(outside, ManifestFileName), `description = "Outside"`)
+	require.NoError(t, os.Symlink(outside, filepath.Join(dir, "linked")))
+
+	return &source{dir: dir, cleanup: func() {}}
+}
+
+func TestSourceTemplates(t *testing.T) {
+	templates, err := testSource(t).templates()
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	assert.Equal(t, "worker", templates[0].Name)
+	assert.Equal(t, "Background worker", templates[0].Description)
+	assert.Equal(t, []string{"DATABASE_URL"}, templates[0].Secrets)
+}
+
+func TestSourceTemplate(t *testing.T) {
+	src := testSource(t)
+
+	cases := []struct {
+		name string
+		err  string
+	}{
+		{name: "worker"},
+		{name: "", err: "invalid template name"},
+		{name: "../worker", err: "invalid template name"},
+		{name: `..\worker`, err: "invalid template name"},
+		{name: ".hidden", err: "invalid template name"},
+		{name: "worker/nested", err: "invalid template name"},
+		{name: "docs", err: "template docs not found"},
+		{name: "missing", err: "template missing not found"},
+		{name: "linked", err: "template linked isn't a directory of the source"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tmpl, err := src.template(tc.name)
+			if tc.err != "" {
+				assert.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.name, tmpl.Name)
+		})
+	}
+}
+
+func TestApplyTemplate(t *testing.T) {
+	secret := filepath.Join(t.TempDir(), "id_rsa")
+	writeFile(t, secret, "private key")
+
+	tmplDir := t.TempDir()
+	writeFile(t, filepath.Join(tmplDir, ManifestFileName), `description = "Web"`)
+	writeFile(t, filepath.Join(tmplDir, "fly.toml"), `app = "template"`)
+	writeFile(t, filepath.Join(tmplDir, "config", "nginx.conf"), "server {}")
+	writeFile(t, filepath.Join(tmplDir, ".git", "HEAD"), "ref: refs/heads/main")
+	require.NoError(t, os.Symlink(secret, filepath.Join(tmplDir, "key")))
+	tmpl := &Template{Name: "web", dir: tmplDir}
+
+	cases := []struct {
+		name     string
+		existing map[string]string
+		force    bool
+		expected map[string]string
+		missing  []string
+	}{
+		{
+			name:     "fresh directory",
+			expected: map[string]string{"fly.toml": `app = "template"`, "config/nginx.conf": "server {}"},
+			missing:  []string{ManifestFileName, ".git/HEAD", "key"},
+		},
+		{
+			name:     "existing files are kept",
+			existing: map[string]string{"fly.toml": `app = "mine"`},
+			expected: map[string]string{"fly.toml": `app = "mine"`, "config/nginx.conf": "server {}"},
+		},
+		{
+			name:     "existing files are overwritten with force",
+			existing: map[string]string{"fly.toml": `app = "mine"`},
+			force:    true,
+			expected: map[string]string{"fly.toml": `app = "template"`},
+			missing:  []string{"key"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			dest := t.TempDir()
+			for name, content := range tc.existing {
+				writeFile(t, filepath.Join(dest, name), content)
+			}
+
+			io, _, _, _ := iostreams.Test()
+			require.NoError(t, applyTemplate(io, tmpl, dest, tc.force))
+
+			for name, content := range tc.expected {
+				buf, err := os.ReadFile(filepath.Join(dest, name))
+				require.NoError(t, err, name)
+				assert.Equal(t, content, string(buf), name)
+			}
+			for _, name := range tc.missing {
+				_, err := os.Lstat(filepath.Join(dest, name))
+				assert.ErrorIs(t, err, os.ErrNotExist, name)
+			}
+		})
+	}
+}
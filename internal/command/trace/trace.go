@@ -0,0 +1,303 @@
+// Package trace implements the trace command.
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/azazeal/pause"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/config"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/render"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/logs"
+)
+
+// followPollInterval is how often new log lines are fetched while following
+// the app's logs for the request.
+const followPollInterval = time.Second
+
+var errNoRequestID = errors.New("request id must not be empty")
+
+func New() (cmd *cobra.Command) {
+	const (
+		long = `Show the end-to-end path of a single request, given the value of its
+fly-request-id response header.
+
+The proxy's routing record (edge region, machine, status, errors and retries)
+and the app's log lines are assembled from the log lines mentioning the
+request. The logs API only pages forward in time, so the search covers the
+most recent page of the app's log buffer, followed by the lines logged while
+trace runs, for as long as --follow: trace a request right after making it.
+`
+		short = "Trace a single request through the proxy and the app"
+		usage = "trace <request-id>"
+	)
+
+	cmd = command.New(usage, short, long, run,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.String{
+			Name:        "follow",
+			Description: "How long to keep following the app's logs for lines of the request, such as 10s",
+			Default:     "5s",
+		},
+	)
+
+	return
+}
+
+// Trace wraps what's known about a single request.
+type Trace struct {
+	RequestID string          `json:"request_id"`
+	Edge      string          `json:"edge,omitempty"`
+	Machines  []string        `json:"machines"`
+	Method    string          `json:"method,omitempty"`
+	URL       string          `json:"url,omitempty"`
+	Status    int             `json:"status,omitempty"`
+	Errors    []string        `json:"errors"`
+	Retries   int             `json:"retries"`
+	Start     time.Time       `json:"start"`
+	End       time.Time       `json:"end"`
+	Duration  time.Duration   `json:"duration_ns"`
+	Entries   []logs.LogEntry `json:"entries"`
+}
+
+func run(ctx context.Context) error {
+	var (
+		io        = iostreams.FromContext(ctx)
+		apiClient = client.FromContext(ctx).API()
+		appName   = appconfig.NameFromContext(ctx)
+		requestID = strings.TrimSpace(flag.FirstArg(ctx))
+	)
+
+	if requestID == "" {
+		return errNoRequestID
+	}
+	follow, err := time.ParseDuration(flag.GetString(ctx, "follow"))
+	if err != nil || follow < 0 {
+		return fmt.Errorf("invalid --follow %s, expected a duration such as 10s", flag.GetString(ctx, "follow"))
+	}
+
+	getLogs := func(ctx context.Context, token string) ([]api.LogEntry, string, error) {
+		return apiClient.GetAppLogs(ctx, appName, token, "", "")
+	}
+	entries, err := fetchEntries(ctx, getLogs, requestID, follow)
+	if err != nil {
+		return err
+	}
+
+	if len(entries) == 0 {
+		return fmt.Errorf("no log lines found for request %s; it may predate the most recent page of the log buffer, or be logged later than --follow allows for", requestID)
+	}
+
+	trace := build(requestID, entries)
+
+	if config.FromContext(ctx).JSONOutput {
+		return render.JSON(io.Out, trace)
+	}
+
+	colorize := io.ColorScheme()
+
+	fmt.Fprintf(io.Out, "%s %s\n\n", colorize.Bold("Request"), trace.RequestID)
+
+	rows := [][]string{
+		{"Edge", orDash(trace.Edge)},
+		{"Machines", orDash(strings.Join(trace.Machines, ", "))},
+		{"Method", orDash(trace.Method)},
+		{"URL", orDash(trace.URL)},
+		{"Status", orDash(statusString(trace.Status))},
+		{"Retries", fmt.Sprint(trace.Retries)},
+		{"First seen", trace.Start.Format(time.RFC3339Nano)},
+		{"Duration", trace.Duration.String()},
+	}
+	for _, e := range trace.Errors {
+		rows = append(rows, []string{"Error", colorize.Red(e)})
+	}
+
+	if err := render.VerticalTable(io.Out, "Routing", [][]string{flatten(rows)}, headers(rows)...); err != nil {
+		return err
+	}
+
+	fmt.Fprintln(io.Out, colorize.Bold("Logs"))
+	for _, entry := range trace.Entries {
+		if err := render.LogEntry(io.Out, entry, render.RemoveNewlines()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// fetchEntries returns the log lines which mention requestID, oldest first.
+//
+// The logs API only pages forward in time: a request without a token returns
+// the most recent page of the buffer, and next tokens lead to newer lines. So
+// rather than searching the whole buffer, fetchEntries searches that page and
+// then follows the lines logged after it until follow has passed.
+func fetchEntries(ctx context.Context, getLogs func(context.Context, string) ([]api.LogEntry, string, error), requestID string, follow time.Duration) ([]logs.LogEntry, error) {
+	var (
+		matched  []logs.LogEntry
+		token    string
+		deadline = time.Now().Add(follow)
+	)
+
+	for {
+		entries, next, err := getLogs(ctx, token)
+		if err != nil {
+			return nil, fmt.Errorf("failed fetching logs: %w", err)
+		}
+
+		for _, entry := range entries {
+			if entry.Meta.HTTP.Request.ID != requestID && !strings.Contains(entry.Message, requestID) {
+				continue
+			}
+
+			matched = append(matched, logs.LogEntry{
+				Instance:  entry.Instance,
+				Level:     entry.Level,
+				Message:   entry.Message,
+				Region:    entry.Region,
+				Timestamp: entry.Timestamp,
+				Meta:      entry.Meta,
+			})
+		}
+
+		if next != "" {
+			token = next
+		}
+
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			break
+		}
+		// pages are fetched back to back until caught up with the buffer
+		if len(entries) > 0 && next != "" {
+			continue
+		}
+		if remaining > followPollInterval {
+			remaining = followPollInterval
+		}
+		if pause.For(ctx, remaining); ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Timestamp < matched[j].Timestamp
+	})
+
+	return matched, nil
+}
+
+func build(requestID string, entries []logs.LogEntry) *Trace {
+	trace := &Trace{
+		RequestID: requestID,
+		Entries:   entries,
+	}
+
+	machines := map[string]bool{}
+
+	for _, entry := range entries {
+		if ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp); err == nil {
+			if trace.Start.IsZero() || ts.Before(trace.Start) {
+				trace.Start = ts
+			}
+			if ts.After(trace.End) {
+				trace.End = ts
+			}
+		}
+
+		if entry.Meta.Event.Provider == "proxy" {
+			if trace.Edge == "" {
+				trace.Edge = entry.Region
+			}
+			if entry.Meta.Error.Code != 0 || entry.Meta.Error.Message != "" {
+				trace.Errors = append(trace.Errors, errorString(entry))
+			}
+		} else if entry.Instance != "" && !machines[entry.Instance] {
+			machines[entry.Instance] = true
+			trace.Machines = append(trace.Machines, entry.Instance)
+		}
+
+		if m := entry.Meta.HTTP.Request.Method; m != "" {
+			trace.Method = m
+		}
+		if u := entry.Meta.URL.Full; u != "" {
+			trace.URL = u
+		}
+		if s := entry.Meta.HTTP.Response.StatusCode; s != 0 {
+			trace.Status = s
+		}
+	}
+
+	// every proxy error short of a final failure led to another attempt
+	if trace.Retries = len(trace.Errors); trace.Retries > 0 && trace.Status == 0 {
+		trace.Retries--
+	}
+
+	trace.Duration = trace.End.Sub(trace.Start)
+
+	return trace
+}
+
+func errorString(entry logs.LogEntry) string {
+	msg := entry.Meta.Error.Message
+	if msg == "" {
+		msg = entry.Message
+	}
+	if entry.Meta.Error.Code != 0 {
+		msg = fmt.Sprintf("%s (code %d)", msg, entry.Meta.Error.Code)
+	}
+
+	return msg
+}
+
+func statusString(status int) string {
+	if status == 0 {
+		return ""
+	}
+
+	return fmt.Sprint(status)
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
+
+func headers(rows [][]string) (h []string) {
+	for _, row := range rows {
+		h = append(h, row[0])
+	}
+
+	return
+}
+
+func flatten(rows [][]string) (values []string) {
+	for _, row := range rows {
+		values = append(values, row[1])
+	}
+
+	return
+}
@@ -0,0 +1,109 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/logs"
+)
+
+func TestBuild(t *testing.T) {
+	proxy := func(ts, region string, status int, errMsg string) logs.LogEntry {
+		e := logs.LogEntry{Timestamp: ts, Region: region, Instance: "edge1"}
+		e.Meta.Event.Provider = "proxy"
+		e.Meta.HTTP.Request.ID = "req1"
+		e.Meta.HTTP.Request.Method = "GET"
+		e.Meta.URL.Full = "https://app.fly.dev/"
+		e.Meta.HTTP.Response.StatusCode = status
+		e.Meta.Error.Message = errMsg
+		return e
+	}
+	app := func(ts, instance string) logs.LogEntry {
+		e := logs.LogEntry{Timestamp: ts, Region: "ord", Instance: instance, Message: "handled req1"}
+		e.Meta.Event.Provider = "app"
+		return e
+	}
+
+	trace := build("req1", []logs.LogEntry{
+		proxy("2023-05-01T10:00:00.000Z", "ams", 0, "connection refused"),
+		app("2023-05-01T10:00:00.100Z", "m1"),
+		app("2023-05-01T10:00:00.200Z", "m2"),
+		proxy("2023-05-01T10:00:00.250Z", "ams", 200, ""),
+	})
+
+	assert.Equal(t, "ams", trace.Edge)
+	assert.Equal(t, []string{"m1", "m2"}, trace.Machines)
+	assert.Equal(t, "GET", trace.Method)
+	assert.Equal(t, "https://app.fly.dev/", trace.URL)
+	assert.Equal(t, 200, trace.Status)
+	assert.Equal(t, []string{"connection refused"}, trace.Errors)
+	assert.Equal(t, 1, trace.Retries)
+	assert.Equal(t, 250*time.Millisecond, trace.Duration)
+}
+
+func TestBuildFailedRequest(t *testing.T) {
+	e := logs.LogEntry{Timestamp: "2023-05-01T10:00:00Z", Region: "ams"}
+	e.Meta.Event.Provider = "proxy"
+	e.Meta.Error.Code = 1
+	e.Meta.Error.Message = "no healthy machines"
+
+	trace := build("req1", []logs.LogEntry{e})
+
+	assert.Equal(t, []string{"no healthy machines (code 1)"}, trace.Errors)
+	assert.Equal(t, 0, trace.Retries)
+	assert.Equal(t, 0, trace.Status)
+}
+
+func TestFetchEntries(t *testing.T) {
+	entry := func(ts, msg string) (e api.LogEntry) {
+		e.Timestamp = ts
+		e.Message = msg
+		return
+	}
+
+	// the buffer pages forward in time from its most recent page
+	pages := map[string]struct {
+		entries []api.LogEntry
+		next    string
+	}{
+		"":   {[]api.LogEntry{entry("2023-05-01T10:00:02Z", "handled req1"), entry("2023-05-01T10:00:01Z", "other")}, "t1"},
+		"t1": {[]api.LogEntry{entry("2023-05-01T10:00:01Z", "started req1")}, "t2"},
+		"t2": {nil, ""},
+	}
+	var tokens []string
+	getLogs := func(ctx context.Context, token string) ([]api.LogEntry, string, error) {
+		tokens = append(tokens, token)
+		return pages[token].entries, pages[token].next, nil
+	}
+
+	entries, err := fetchEntries(context.Background(), getLogs, "req1", 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{""}, tokens, "without --follow only the most recent page is searched")
+	assert.Len(t, entries, 1)
+
+	tokens = nil
+	entries, err = fetchEntries(context.Background(), getLogs, "req1", 50*time.Millisecond)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"", "t1", "t2"}, tokens[:3], "newer pages are followed until --follow passes")
+	require.Len(t, entries, 2)
+	assert.Equal(t, "started req1", entries[0].Message, "entries are sorted oldest first")
+	assert.Equal(t, "handled req1", entries[1].Message)
+}
+
+func TestTraceJSON(t *testing.T) {
+	data, err := json.Marshal(&Trace{RequestID: "req1", Status: 200, Duration: time.Millisecond})
+	require.NoError(t, err)
+
+	var fields map[string]any
+	require.NoError(t, json.Unmarshal(data, &fields))
+	assert.Equal(t, "req1", fields["request_id"])
+	assert.Equal(t, float64(200), fields["status"])
+	assert.Equal(t, float64(time.Millisecond), fields["duration_ns"])
+	assert.NotContains(t, fields, "edge")
+}
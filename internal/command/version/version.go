@@ -6,6 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 
+	"golang.org/x/exp/slices"
+
 	"github.com/spf13/cobra"
 
 	"github.com/superfly/flyctl/iostreams"
@@ -15,6 +17,7 @@ import (
 	"github.com/superfly/flyctl/internal/command"
 	"github.com/superfly/flyctl/internal/config"
 	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/update"
 )
 
 const saveInstallName = "saveinstall"
@@ -25,7 +28,8 @@ func New() *cobra.Command {
 		short = "Show version information for the flyctl command"
 
 		long = `Shows version information for the flyctl command itself, including version
-number and build date.`
+number and build date, and the commands the Fly.io API no longer supports with
+it, if any.`
 	)
 
 	version := command.New("version", short, long, run)
@@ -69,6 +73,30 @@ func run(ctx context.Context) (err error) {
 	} else {
 		_, err = fmt.Fprintln(out, info)
 	}
+	if err != nil {
+		return
+	}
+
+	if r := cache.FromContext(ctx).LatestRelease(); r != nil && !cfg.JSONOutput {
+		printIncompatible(iostreams.FromContext(ctx), r.Incompatible(info.Version))
+	}
 
 	return
 }
+
+// printIncompatible warns about the commands the API no longer supports with
+// this version of flyctl.
+func printIncompatible(io *iostreams.IOStreams, commands []string) {
+	switch {
+	case len(commands) == 0:
+		return
+	case slices.Contains(commands, update.AllCommands):
+		fmt.Fprintln(io.ErrOut, io.ColorScheme().Yellow("The Fly.io API no longer supports this version of flyctl."))
+	default:
+		fmt.Fprintln(io.ErrOut, io.ColorScheme().Yellow("The Fly.io API no longer supports these commands with this version of flyctl:"))
+		for _, c := range commands {
+			fmt.Fprintf(io.ErrOut, "  fly %s\n", c)
+		}
+	}
+	fmt.Fprintf(io.ErrOut, "Run \"%s\" to upgrade.\n", io.ColorScheme().Bold(buildinfo.Name()+" version update"))
+}
@@ -0,0 +1,172 @@
+package volumes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/terminal"
+)
+
+func newBackup() *cobra.Command {
+	const (
+		long = `Back up a volume to S3 compatible object storage, as a gzipped tarball of its
+files, so that disaster recovery copies live outside of Fly.
+
+The backup is made from the latest snapshot of the volume, or the one given
+with --snapshot-id, restored to a temporary volume, so that it's consistent
+and the machine the volume is attached to isn't disturbed. The temporary
+volume and the machine it's read from are destroyed afterwards.`
+
+		short = "Back up a volume to S3 compatible object storage"
+
+		usage = "backup <id>"
+	)
+
+	cmd := command.New(usage, short, long, runBackup,
+		command.RequireSession,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.String{
+			Name:        "to",
+			Description: "The s3://bucket/key URL the backup is written to",
+		},
+		flag.String{
+			Name:        "snapshot-id",
+			Description: "Back up this snapshot of the volume instead of the latest one",
+		},
+		endpointFlag(),
+	)
+
+	return cmd
+}
+
+func runBackup(ctx context.Context) error {
+	var (
+		out      = iostreams.FromContext(ctx).Out
+		client   = client.FromContext(ctx).API()
+		volumeID = flag.FirstArg(ctx)
+		to       = flag.GetString(ctx, "to")
+	)
+
+	if to == "" {
+		return fmt.Errorf("--to is required, such as s3://my-bucket/%s.tar.gz", volumeID)
+	}
+	storage, bucket, key, err := objectStorage(ctx, to)
+	if err != nil {
+		return err
+	}
+
+	volume, err := client.GetVolume(ctx, volumeID)
+	if err != nil {
+		return fmt.Errorf("failed retrieving volume: %w", err)
+	}
+
+	snapshotID := flag.GetString(ctx, "snapshot-id")
+	if snapshotID == "" {
+		snapshots, err := client.GetVolumeSnapshots(ctx, volume.ID)
+		if err != nil {
+			return fmt.Errorf("failed retrieving snapshots of volume %s: %w", volume.ID, err)
+		}
+		snapshot := latestSnapshot(snapshots)
+		if snapshot == nil {
+			return fmt.Errorf("volume %s has no snapshot to back up yet, snapshots are taken daily", volume.ID)
+		}
+		snapshotID = snapshot.ID
+		fmt.Fprintf(out, "Backing up snapshot %s of volume %s, taken %s\n", snapshotID, volume.ID, humanize.Time(snapshot.CreatedAt))
+	}
+
+	app, err := client.GetAppCompact(ctx, volume.App.Name)
+	if err != nil {
+		return err
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return fmt.Errorf("volume %s belongs to app %s, which runs on the nomad platform; only volumes of machines apps can be backed up", volume.ID, app.Name)
+	}
+	appBasic, err := client.GetAppBasic(ctx, volume.App.Name)
+	if err != nil {
+		return err
+	}
+
+	copied, err := client.CreateVolume(ctx, api.CreateVolumeInput{
+		AppID:      appBasic.ID,
+		Name:       "flyctl_backup",
+		Region:     volume.Region,
+		SizeGb:     volume.SizeGb,
+		Encrypted:  volume.Encrypted,
+		SnapshotID: api.StringPointer(snapshotID),
+	})
+	if err != nil {
+		return fmt.Errorf("failed restoring snapshot %s: %w", snapshotID, err)
+	}
+	defer deleteVolume(client, copied.ID)
+	fmt.Fprintf(out, "Restored snapshot %s to temporary volume %s\n", snapshotID, copied.ID)
+
+	machine, err := launchTransferMachine(ctx, app, copied)
+	if err != nil {
+		return err
+	}
+	defer machine.destroy()
+
+	fmt.Fprintf(out, "Uploading the files of volume %s to %s\n", volume.ID, to)
+
+	start := time.Now()
+	archive, writer := io.Pipe()
+	go func() {
+		writer.CloseWithError(machine.exec(ctx, "tar -C "+transferMountPath+" -czf - .", nil, writer))
+	}()
+
+	size, err := storage.Upload(ctx, bucket, key, archive)
+	archive.CloseWithError(err)
+	if err != nil {
+		return fmt.Errorf("failed backing up volume %s: %w", volume.ID, err)
+	}
+
+	fmt.Fprintf(out, "Backed up volume %s to %s (%s in %s)\n", volume.ID, to, humanize.Bytes(uint64(size)), time.Since(start).Round(time.Second))
+
+	return nil
+}
+
+// latestSnapshot returns the most recent of snapshots, or nil when there are
+// none.
+func latestSnapshot(snapshots []api.Snapshot) *api.Snapshot {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return &snapshots[0]
+}
+
+// deleteVolume deletes a temporary volume once the machine it was attached to
+// is gone, which takes a few seconds.
+func deleteVolume(client *api.Client, volumeID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	var err error
+	for attempt := 0; attempt < 10; attempt++ {
+		if _, err = client.DeleteVolume(ctx, volumeID); err == nil {
+			return
+		}
+		time.Sleep(3 * time.Second)
+	}
+
+	terminal.Warnf("Failed deleting temporary volume %s, destroy it with fly volumes destroy %s: %v\n", volumeID, volumeID, err)
+}
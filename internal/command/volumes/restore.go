@@ -0,0 +1,136 @@
+package volumes
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/dustin/go-humanize"
+	"github.com/spf13/cobra"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/internal/appconfig"
+	"github.com/superfly/flyctl/internal/command"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/prompt"
+	"github.com/superfly/flyctl/iostreams"
+)
+
+func newRestore() *cobra.Command {
+	const (
+		long = `Create a volume for app from a backup made with fly volumes backup. --region
+flag must be included to specify region the volume exists in. The volume can
+then be mounted by the machines of the app.`
+
+		short = "Create a volume from a backup in S3 compatible object storage"
+
+		usage = "restore <volumename>"
+	)
+
+	cmd := command.New(usage, short, long, runRestore,
+		command.RequireSession,
+		command.RequireAppName,
+	)
+
+	cmd.Args = cobra.ExactArgs(1)
+
+	flag.Add(cmd,
+		flag.App(),
+		flag.AppConfig(),
+		flag.Region(),
+		flag.String{
+			Name:        "from",
+			Description: "The s3://bucket/key URL of the backup",
+		},
+		flag.Int{
+			Name:        "size",
+			Shorthand:   "s",
+			Default:     3,
+			Description: "Size of volume in gigabytes",
+		},
+		flag.Bool{
+			Name:        "no-encryption",
+			Description: "Do not encrypt the volume contents",
+			Default:     false,
+		},
+		endpointFlag(),
+	)
+
+	return cmd
+}
+
+func runRestore(ctx context.Context) error {
+	var (
+		io     = iostreams.FromContext(ctx)
+		client = client.FromContext(ctx).API()
+
+		volumeName = flag.FirstArg(ctx)
+		appName    = appconfig.NameFromContext(ctx)
+		from       = flag.GetString(ctx, "from")
+	)
+
+	if from == "" {
+		return fmt.Errorf("--from is required, such as s3://my-bucket/%s.tar.gz", volumeName)
+	}
+	storage, bucket, key, err := objectStorage(ctx, from)
+	if err != nil {
+		return err
+	}
+
+	// fail before creating anything when the backup is missing
+	size, err := storage.Size(ctx, bucket, key)
+	if err != nil {
+		return fmt.Errorf("failed looking up %s: %w", from, err)
+	}
+
+	app, err := client.GetAppCompact(ctx, appName)
+	if err != nil {
+		return err
+	}
+	if app.PlatformVersion != appconfig.MachinesPlatform {
+		return fmt.Errorf("app %s runs on the nomad platform; backups can only be restored to machines apps", app.Name)
+	}
+	appBasic, err := client.GetAppBasic(ctx, appName)
+	if err != nil {
+		return err
+	}
+
+	region, err := prompt.Region(ctx, !appBasic.Organization.PaidPlan, prompt.RegionParams{
+		Message: "",
+	})
+	if err != nil {
+		return err
+	}
+
+	volume, err := client.CreateVolume(ctx, api.CreateVolumeInput{
+		AppID:     appBasic.ID,
+		Name:      volumeName,
+		Region:    region.Code,
+		SizeGb:    flag.GetInt(ctx, "size"),
+		Encrypted: !flag.GetBool(ctx, "no-encryption"),
+	})
+	if err != nil {
+		return fmt.Errorf("failed creating volume: %w", err)
+	}
+	fmt.Fprintf(io.Out, "Created volume %s, restoring %s (%s) to it\n", volume.ID, from, humanize.Bytes(uint64(size)))
+
+	machine, err := launchTransferMachine(ctx, app, volume)
+	if err != nil {
+		return err
+	}
+	defer machine.destroy()
+
+	archive, _, err := storage.Download(ctx, bucket, key)
+	if err != nil {
+		return fmt.Errorf("failed downloading %s: %w", from, err)
+	}
+	defer archive.Close() //skipcq: GO-S2307
+
+	if err := machine.exec(ctx, "tar -C "+transferMountPath+" -xzpf -", archive, io.ErrOut); err != nil {
+		return fmt.Errorf("failed restoring %s to volume %s, destroy it with fly volumes destroy %s: %w", from, volume.ID, volume.ID, err)
+	}
+
+	fmt.Fprintf(io.Out, "Restored %s to volume %s\n", from, volume.ID)
+
+	return printVolume(io.Out, volume)
+}
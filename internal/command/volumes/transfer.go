@@ -0,0 +1,133 @@
+package volumes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/superfly/flyctl/agent"
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/command/ssh"
+	"github.com/superfly/flyctl/internal/flag"
+	"github.com/superfly/flyctl/internal/s3"
+	"github.com/superfly/flyctl/terminal"
+)
+
+const (
+	// transferImage runs the machines volumes are copied from and to, it only
+	// needs tar
+	transferImage = "alpine:3.17"
+	// transferMountPath is where the volume is mounted in them
+	transferMountPath = "/volume"
+)
+
+func endpointFlag() flag.String {
+	return flag.String{
+		Name:        "endpoint",
+		Description: "The URL of the S3 compatible object storage, such as https://fly.storage.tigris.dev. Defaults to AWS_ENDPOINT_URL_S3, or AWS S3 in AWS_REGION. Credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY",
+	}
+}
+
+// transferMachine is a short lived machine of an app with a volume mounted,
+// to copy the files of the volume over SSH without disturbing the app.
+type transferMachine struct {
+	app     *api.AppCompact
+	flaps   *flaps.Client
+	machine *api.Machine
+	dialer  agent.Dialer
+}
+
+// launchTransferMachine starts a machine of app with volume mounted at
+// transferMountPath and connects to its network.
+func launchTransferMachine(ctx context.Context, app *api.AppCompact, volume *api.Volume) (*transferMachine, error) {
+	flapsClient, err := flaps.New(ctx, app)
+	if err != nil {
+		return nil, err
+	}
+
+	machine, err := flapsClient.Launch(ctx, api.LaunchMachineInput{
+		AppID:  app.Name,
+		Region: volume.Region,
+		Config: &api.MachineConfig{
+			Image: transferImage,
+			Init: api.MachineInit{
+				Exec: []string{"tail", "-f", "/dev/null"},
+			},
+			Mounts: []api.MachineMount{{
+				Volume: volume.ID,
+				Path:   transferMountPath,
+			}},
+			Guest: &api.MachineGuest{
+				CPUKind:  "shared",
+				CPUs:     1,
+				MemoryMB: 256,
+			},
+			Restart:     api.MachineRestart{Policy: api.MachineRestartPolicyNo},
+			AutoDestroy: true,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed launching a machine with volume %s: %w", volume.ID, err)
+	}
+
+	t := &transferMachine{app: app, flaps: flapsClient, machine: machine}
+	if err := flapsClient.Wait(ctx, machine, api.MachineStateStarted, 2*time.Minute); err != nil {
+		t.destroy()
+		return nil, fmt.Errorf("machine %s with volume %s didn't start: %w", machine.ID, volume.ID, err)
+	}
+
+	if t.dialer, err = dialer(ctx, app); err != nil {
+		t.destroy()
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// exec runs cmd on the machine, streaming stdin to it and its stdout back.
+func (t *transferMachine) exec(ctx context.Context, cmd string, stdin io.Reader, stdout io.Writer) error {
+	return ssh.Exec(ctx, t.app, t.dialer, t.machine.PrivateIP, cmd, stdin, stdout)
+}
+
+// destroy destroys the machine, even when the command was interrupted.
+func (t *transferMachine) destroy() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	if err := t.flaps.Destroy(ctx, api.RemoveMachineInput{AppID: t.app.Name, ID: t.machine.ID, Kill: true}); err != nil {
+		terminal.Warnf("Failed destroying machine %s, destroy it with fly machine destroy --force %s: %v\n", t.machine.ID, t.machine.ID, err)
+	}
+}
+
+func dialer(ctx context.Context, app *api.AppCompact) (agent.Dialer, error) {
+	agentclient, err := agent.Establish(ctx, client.FromContext(ctx).API())
+	if err != nil {
+		return nil, fmt.Errorf("can't establish agent: %w", err)
+	}
+
+	dialer, err := agentclient.Dialer(ctx, app.Organization.Slug)
+	if err != nil {
+		return nil, fmt.Errorf("can't build tunnel for %s: %w", app.Organization.Slug, err)
+	}
+
+	if err := agentclient.WaitForTunnel(ctx, app.Organization.Slug); err != nil {
+		return nil, fmt.Errorf("tunnel unavailable: %w", err)
+	}
+
+	return dialer, nil
+}
+
+// objectStorage parses an s3:// URL and returns a client of the object storage
+// it's in.
+func objectStorage(ctx context.Context, rawURL string) (client *s3.Client, bucket, key string, err error) {
+	if bucket, key, err = s3.ParseURL(rawURL); err != nil {
+		return nil, "", "", err
+	}
+	if client, err = s3.NewFromEnv(flag.GetString(ctx, "endpoint")); err != nil {
+		return nil, "", "", err
+	}
+	return client, bucket, key, nil
+}
@@ -33,6 +33,8 @@ func New() *cobra.Command {
 		newDestroy(),
 		newExtend(),
 		newShow(),
+		newBackup(),
+		newRestore(),
 		snapshots.New(),
 	)
 
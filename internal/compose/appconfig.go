@@ -0,0 +1,133 @@
+package compose
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"sort"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+// AppConfig translates the given services into the configuration of a single
+// app. processes maps the name of each service to include to the name of the
+// process group it runs as.
+//
+// All of the services must share the same image or build, as every process
+// group of an app runs the same image. Settings which have no equivalent on
+// Fly are reported as warnings rather than errors.
+func (f *File) AppConfig(appName string, processes map[string]string) (cfg *appconfig.Config, warnings []string, err error) {
+	if len(processes) == 0 {
+		return nil, nil, fmt.Errorf("no services to translate for app %s", appName)
+	}
+
+	names := make([]string, 0, len(processes))
+	for name := range processes {
+		if _, ok := f.Services[name]; !ok {
+			return nil, nil, fmt.Errorf("service %s is not defined in %s", name, f.Path())
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	cfg = appconfig.NewConfig()
+	cfg.AppName = appName
+	cfg.Env = map[string]string{}
+	cfg.Processes = map[string]string{}
+
+	first := f.Services[names[0]]
+	cfg.Build = f.build(first)
+
+	for _, name := range names {
+		svc := f.Services[name]
+		group := processes[name]
+
+		if build := f.build(svc); !reflect.DeepEqual(build, cfg.Build) {
+			return nil, nil, fmt.Errorf("services %s and %s are deployed to app %s but don't share the same image or build", names[0], name, appName)
+		}
+
+		if len(svc.Entrypoint) > 0 {
+			warnings = append(warnings, fmt.Sprintf("service %s: entrypoint is not supported and was ignored; set it in the image instead", name))
+		}
+
+		cfg.Processes[group] = svc.Command.String()
+
+		for k, v := range svc.Environment {
+			if existing, ok := cfg.Env[k]; ok && existing != v {
+				return nil, nil, fmt.Errorf("services deployed to app %s set different values for environment variable %s", appName, k)
+			}
+			cfg.Env[k] = v
+		}
+
+		for _, port := range svc.Ports {
+			if port.Published == 0 {
+				// only reachable over the private network, which needs no configuration
+				continue
+			}
+			cfg.Services = append(cfg.Services, service(port, group))
+		}
+
+		for _, mount := range svc.Volumes {
+			if !mount.IsNamed() {
+				warnings = append(warnings, fmt.Sprintf("service %s: only named volumes are supported, ignoring %s", name, mount.Target))
+				continue
+			}
+
+			if cfg.Mounts != nil && (cfg.Mounts.Source != mount.Source || cfg.Mounts.Destination != mount.Target) {
+				return nil, nil, fmt.Errorf("app %s can mount a single volume, but its services mount both %s and %s", appName, cfg.Mounts.Source, mount.Source)
+			}
+			cfg.Mounts = &appconfig.Volume{
+				Source:      mount.Source,
+				Destination: mount.Target,
+			}
+		}
+	}
+
+	if len(cfg.Env) == 0 {
+		cfg.Env = nil
+	}
+
+	return cfg, warnings, nil
+}
+
+// build returns the build section of the app config which produces the image
+// of svc. Paths are absolute.
+func (f *File) build(svc *Service) *appconfig.Build {
+	if svc.Build == nil {
+		return &appconfig.Build{Image: svc.Image}
+	}
+
+	dockerfile := svc.DockerfilePath(f)
+	if dockerfile == "" {
+		dockerfile = filepath.Join(svc.ContextDir(f), "Dockerfile")
+	}
+
+	return &appconfig.Build{
+		Dockerfile:        dockerfile,
+		DockerBuildTarget: svc.Build.Target,
+		Args:              svc.Build.Args,
+	}
+}
+
+func service(port Port, group string) appconfig.Service {
+	svc := appconfig.Service{
+		Protocol:     port.Protocol,
+		InternalPort: port.Target,
+		Processes:    []string{group},
+		Ports: []api.MachinePort{{
+			Port: api.IntPointer(port.Published),
+		}},
+	}
+
+	if port.Protocol == "tcp" {
+		switch port.Published {
+		case 80:
+			svc.Ports[0].Handlers = []string{"http"}
+		case 443:
+			svc.Ports[0].Handlers = []string{"tls", "http"}
+		}
+	}
+
+	return svc
+}
@@ -0,0 +1,463 @@
+// Package compose implements loading of Docker Compose files, covering the
+// subset of the specification flyctl is able to translate into Fly apps.
+package compose
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/google/shlex"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFileNames are the file names Load looks for when given a directory,
+// in order of preference.
+var DefaultFileNames = []string{
+	"compose.yaml",
+	"compose.yml",
+	"docker-compose.yaml",
+	"docker-compose.yml",
+}
+
+// File wraps a Compose file.
+type File struct {
+	Services map[string]*Service `yaml:"services"`
+	Volumes  map[string]any      `yaml:"volumes"`
+
+	path string
+}
+
+// Service wraps a single entry of the services section.
+type Service struct {
+	Image       string        `yaml:"image"`
+	Build       *Build        `yaml:"build"`
+	Command     Command       `yaml:"command"`
+	Entrypoint  Command       `yaml:"entrypoint"`
+	Environment Environment   `yaml:"environment"`
+	Ports       []Port        `yaml:"ports"`
+	Volumes     []VolumeMount `yaml:"volumes"`
+	DependsOn   DependsOn     `yaml:"depends_on"`
+}
+
+// Build wraps the build section of a service. The short syntax, which is just
+// the path to the build context, sets Context only.
+type Build struct {
+	Context    string            `yaml:"context"`
+	Dockerfile string            `yaml:"dockerfile"`
+	Target     string            `yaml:"target"`
+	Args       map[string]string `yaml:"args"`
+}
+
+// Command is a command line, given either as a string or as a list.
+type Command []string
+
+// Environment maps variable names to values, given either as a map or as a
+// list of NAME=VALUE pairs.
+type Environment map[string]string
+
+// DependsOn lists the names of the services a service depends on, given
+// either as a list or as a map keyed by service name.
+type DependsOn []string
+
+// Port wraps an entry of the ports section of a service.
+type Port struct {
+	Target    int
+	Published int
+	Protocol  string
+}
+
+// VolumeMount wraps an entry of the volumes section of a service.
+type VolumeMount struct {
+	Source   string
+	Target   string
+	ReadOnly bool
+}
+
+// Load loads the Compose file at path. When path is a directory, the first of
+// DefaultFileNames found in it is loaded.
+func Load(path string) (*File, error) {
+	if fi, err := os.Stat(path); err == nil && fi.IsDir() {
+		if path, err = resolveFile(path); err != nil {
+			return nil, err
+		}
+	}
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := Parse(buf)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing %s: %w", path, err)
+	}
+
+	if f.path, err = filepath.Abs(path); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func resolveFile(dir string) (string, error) {
+	for _, name := range DefaultFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("no compose file found in %s", dir)
+}
+
+// Parse parses buf as a Compose file.
+func Parse(buf []byte) (*File, error) {
+	f := &File{}
+	if err := yaml.Unmarshal(buf, f); err != nil {
+		return nil, err
+	}
+
+	if len(f.Services) == 0 {
+		return nil, fmt.Errorf("no services defined")
+	}
+
+	for name, svc := range f.Services {
+		if svc == nil {
+			return nil, fmt.Errorf("service %s is empty", name)
+		}
+		if svc.Image == "" && svc.Build == nil {
+			return nil, fmt.Errorf("service %s has neither an image nor a build section", name)
+		}
+		for _, dep := range svc.DependsOn {
+			if _, ok := f.Services[dep]; !ok {
+				return nil, fmt.Errorf("service %s depends on undefined service %s", name, dep)
+			}
+		}
+	}
+
+	return f, nil
+}
+
+// Path returns the absolute path the file was loaded from.
+func (f *File) Path() string {
+	return f.path
+}
+
+// Dir returns the directory relative paths in the file resolve against.
+func (f *File) Dir() string {
+	if f.path == "" {
+		return "."
+	}
+
+	return filepath.Dir(f.path)
+}
+
+// ServiceNames returns the names of the services in ascending order.
+func (f *File) ServiceNames() []string {
+	names := make([]string, 0, len(f.Services))
+	for name := range f.Services {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// Order returns the names of the services sorted so that every service comes
+// after the services it depends on.
+func (f *File) Order() ([]string, error) {
+	var (
+		order    []string
+		visited  = map[string]bool{}
+		visiting = map[string]bool{}
+		visit    func(string) error
+	)
+
+	visit = func(name string) error {
+		switch {
+		case visited[name]:
+			return nil
+		case visiting[name]:
+			return fmt.Errorf("circular dependency involving service %s", name)
+		}
+
+		visiting[name] = true
+		deps := append([]string(nil), f.Services[name].DependsOn...)
+		sort.Strings(deps)
+		for _, dep := range deps {
+			if err := visit(dep); err != nil {
+				return err
+			}
+		}
+		visiting[name] = false
+		visited[name] = true
+		order = append(order, name)
+
+		return nil
+	}
+
+	for _, name := range f.ServiceNames() {
+		if err := visit(name); err != nil {
+			return nil, err
+		}
+	}
+
+	return order, nil
+}
+
+// ContextDir returns the absolute path of the build context of the service.
+func (svc *Service) ContextDir(f *File) string {
+	if svc.Build == nil {
+		return f.Dir()
+	}
+
+	return resolvePath(f.Dir(), svc.Build.Context)
+}
+
+// DockerfilePath returns the absolute path of the Dockerfile of the service, or
+// an empty string when the default Dockerfile of the build context is used.
+func (svc *Service) DockerfilePath(f *File) string {
+	if svc.Build == nil || svc.Build.Dockerfile == "" {
+		return ""
+	}
+
+	return resolvePath(svc.ContextDir(f), svc.Build.Dockerfile)
+}
+
+func resolvePath(dir, path string) string {
+	if path == "" {
+		return dir
+	}
+	if filepath.IsAbs(path) {
+		return path
+	}
+
+	return filepath.Join(dir, path)
+}
+
+// IsNamed reports whether the mount refers to a named volume, as opposed to a
+// bind mount of a host path or an anonymous volume.
+func (m VolumeMount) IsNamed() bool {
+	return m.Source != "" &&
+		!strings.HasPrefix(m.Source, ".") &&
+		!strings.HasPrefix(m.Source, "/") &&
+		!strings.HasPrefix(m.Source, "~")
+}
+
+// String renders the command as a single, shell quoted, command line.
+func (c Command) String() string {
+	quoted := make([]string, 0, len(c))
+	for _, arg := range c {
+		if arg == "" || strings.ContainsAny(arg, " \t\n\"'\\$`") {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'"'"'`) + "'"
+		}
+		quoted = append(quoted, arg)
+	}
+
+	return strings.Join(quoted, " ")
+}
+
+func (b *Build) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		b.Context = node.Value
+
+		return nil
+	}
+
+	type plain Build
+	var p plain
+	if err := node.Decode(&p); err != nil {
+		return err
+	}
+	*b = Build(p)
+
+	if b.Context == "" {
+		b.Context = "."
+	}
+
+	return nil
+}
+
+func (c *Command) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		args, err := shlex.Split(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		*c = args
+
+		return nil
+	case yaml.SequenceNode:
+		var args []string
+		if err := node.Decode(&args); err != nil {
+			return err
+		}
+		*c = args
+
+		return nil
+	default:
+		return fmt.Errorf("line %d: command must be a string or a list", node.Line)
+	}
+}
+
+func (e *Environment) UnmarshalYAML(node *yaml.Node) error {
+	env := Environment{}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		var m map[string]*string
+		if err := node.Decode(&m); err != nil {
+			return err
+		}
+		for k, v := range m {
+			if v != nil {
+				env[k] = *v
+			} else {
+				env[k] = ""
+			}
+		}
+	case yaml.SequenceNode:
+		var list []string
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+		for _, item := range list {
+			k, v, _ := strings.Cut(item, "=")
+			env[k] = v
+		}
+	default:
+		return fmt.Errorf("line %d: environment must be a map or a list", node.Line)
+	}
+
+	*e = env
+
+	return nil
+}
+
+func (d *DependsOn) UnmarshalYAML(node *yaml.Node) error {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var list []string
+		if err := node.Decode(&list); err != nil {
+			return err
+		}
+		*d = list
+	case yaml.MappingNode:
+		var m map[string]any
+		if err := node.Decode(&m); err != nil {
+			return err
+		}
+		names := make([]string, 0, len(m))
+		for name := range m {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		*d = names
+	default:
+		return fmt.Errorf("line %d: depends_on must be a list or a map", node.Line)
+	}
+
+	return nil
+}
+
+func (p *Port) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		var long struct {
+			Target    int    `yaml:"target"`
+			Published any    `yaml:"published"`
+			Protocol  string `yaml:"protocol"`
+		}
+		if err := node.Decode(&long); err != nil {
+			return err
+		}
+
+		p.Target = long.Target
+		p.Protocol = long.Protocol
+		if long.Published != nil {
+			published, err := strconv.Atoi(fmt.Sprint(long.Published))
+			if err != nil {
+				return fmt.Errorf("line %d: invalid published port %v", node.Line, long.Published)
+			}
+			p.Published = published
+		}
+	} else {
+		parsed, err := ParsePort(node.Value)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", node.Line, err)
+		}
+		*p = parsed
+	}
+
+	if p.Protocol == "" {
+		p.Protocol = "tcp"
+	}
+
+	return nil
+}
+
+// ParsePort parses the short port syntax: [[HOST_IP:]PUBLISHED:]TARGET[/PROTOCOL].
+// Port ranges are not supported.
+func ParsePort(s string) (Port, error) {
+	var p Port
+
+	spec, proto, _ := strings.Cut(s, "/")
+	p.Protocol = proto
+
+	parts := strings.Split(spec, ":")
+	if len(parts) > 3 {
+		// IPv6 host addresses aren't meaningful on Fly; drop them
+		parts = parts[len(parts)-2:]
+	}
+
+	target, err := strconv.Atoi(parts[len(parts)-1])
+	if err != nil {
+		return p, fmt.Errorf("invalid port %q", s)
+	}
+	p.Target = target
+
+	if len(parts) > 1 && parts[len(parts)-2] != "" {
+		published, err := strconv.Atoi(parts[len(parts)-2])
+		if err != nil {
+			return p, fmt.Errorf("invalid port %q", s)
+		}
+		p.Published = published
+	}
+
+	return p, nil
+}
+
+func (m *VolumeMount) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.MappingNode {
+		var long struct {
+			Source   string `yaml:"source"`
+			Target   string `yaml:"target"`
+			ReadOnly bool   `yaml:"read_only"`
+		}
+		if err := node.Decode(&long); err != nil {
+			return err
+		}
+		*m = VolumeMount(long)
+
+		return nil
+	}
+
+	parts := strings.Split(node.Value, ":")
+	switch len(parts) {
+	case 1:
+		m.Target = parts[0]
+	case 2:
+		m.Source, m.Target = parts[0], parts[1]
+	case 3:
+		m.Source, m.Target = parts[0], parts[1]
+		m.ReadOnly = strings.Contains(parts[2], "ro")
+	default:
+		return fmt.Errorf("line %d: invalid volume %q", node.Line, node.Value)
+	}
+
+	return nil
+}
@@ -0,0 +1,195 @@
+package compose
+
+import (
+	"testing"
+
+	"github.com/google/shlex"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	f, err := Parse([]byte(`
+services:
+  web:
+    build:
+      context: ./web
+      dockerfile: Dockerfile.prod
+      target: release
+    command: bundle exec puma -p 3000
+    environment:
+      RAILS_ENV: production
+      EMPTY:
+    ports:
+      - "80:3000"
+      - target: 9091
+        published: "9091"
+        protocol: udp
+    volumes:
+      - data:/data
+      - ./config:/config:ro
+    depends_on:
+      db:
+        condition: service_healthy
+  worker:
+    build: ./web
+    command: ["bin/worker", "--queue", "default critical"]
+    environment:
+      - QUEUE=default
+    depends_on:
+      - db
+      - web
+  db:
+    image: postgres:15
+    ports:
+      - "5432"
+volumes:
+  data:
+`))
+	require.NoError(t, err)
+
+	web := f.Services["web"]
+	assert.Equal(t, &Build{Context: "./web", Dockerfile: "Dockerfile.prod", Target: "release"}, web.Build)
+	assert.Equal(t, Command{"bundle", "exec", "puma", "-p", "3000"}, web.Command)
+	assert.Equal(t, Environment{"RAILS_ENV": "production", "EMPTY": ""}, web.Environment)
+	assert.Equal(t, []Port{
+		{Target: 3000, Published: 80, Protocol: "tcp"},
+		{Target: 9091, Published: 9091, Protocol: "udp"},
+	}, web.Ports)
+	assert.Equal(t, []VolumeMount{
+		{Source: "data", Target: "/data"},
+		{Source: "./config", Target: "/config", ReadOnly: true},
+	}, web.Volumes)
+	assert.True(t, web.Volumes[0].IsNamed())
+	assert.False(t, web.Volumes[1].IsNamed())
+	assert.Equal(t, DependsOn{"db"}, web.DependsOn)
+
+	worker := f.Services["worker"]
+	assert.Equal(t, &Build{Context: "./web"}, worker.Build)
+	assert.Equal(t, Environment{"QUEUE": "default"}, worker.Environment)
+
+	assert.Equal(t, []Port{{Target: 5432, Protocol: "tcp"}}, f.Services["db"].Ports)
+
+	order, err := f.Order()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db", "web", "worker"}, order)
+}
+
+func TestParse_Invalid(t *testing.T) {
+	_, err := Parse([]byte(`services: {}`))
+	assert.Error(t, err)
+
+	_, err = Parse([]byte(`
+services:
+  web:
+    command: echo
+`))
+	assert.ErrorContains(t, err, "neither an image nor a build section")
+
+	_, err = Parse([]byte(`
+services:
+  web:
+    image: nginx
+    depends_on: [db]
+`))
+	assert.ErrorContains(t, err, "undefined service db")
+}
+
+func TestOrder_Cycle(t *testing.T) {
+	f, err := Parse([]byte(`
+services:
+  a:
+    image: a
+    depends_on: [b]
+  b:
+    image: b
+    depends_on: [a]
+`))
+	require.NoError(t, err)
+
+	_, err = f.Order()
+	assert.ErrorContains(t, err, "circular dependency")
+}
+
+func TestParsePort(t *testing.T) {
+	cases := map[string]Port{
+		"3000":                {Target: 3000},
+		"8080:80":             {Target: 80, Published: 8080},
+		"127.0.0.1:8080:80":   {Target: 80, Published: 8080},
+		"53:53/udp":           {Target: 53, Published: 53, Protocol: "udp"},
+		"127.0.0.1::5000/tcp": {Target: 5000, Protocol: "tcp"},
+	}
+	for in, expected := range cases {
+		p, err := ParsePort(in)
+		assert.NoError(t, err, in)
+		assert.Equal(t, expected, p, in)
+	}
+
+	_, err := ParsePort("3000-3005")
+	assert.Error(t, err)
+}
+
+func TestCommandString(t *testing.T) {
+	cmd := Command{"sh", "-c", "echo 'hi there' && sleep 1", ""}
+	args, err := shlex.Split(cmd.String())
+	require.NoError(t, err)
+	assert.Equal(t, []string(cmd), args)
+}
+
+func TestAppConfig(t *testing.T) {
+	f, err := Parse([]byte(`
+services:
+  web:
+    build: .
+    environment:
+      LOG_LEVEL: info
+    ports:
+      - "80:8080"
+      - "9000"
+    volumes:
+      - data:/data
+      - ./tmp:/tmp
+  worker:
+    build: .
+    command: bin/worker --verbose
+    environment:
+      LOG_LEVEL: info
+`))
+	require.NoError(t, err)
+
+	cfg, warnings, err := f.AppConfig("stack", map[string]string{"web": "app", "worker": "worker"})
+	require.NoError(t, err)
+	assert.Len(t, warnings, 1)
+	assert.Equal(t, "stack", cfg.AppName)
+	assert.Equal(t, map[string]string{"app": "", "worker": "bin/worker --verbose"}, cfg.Processes)
+	assert.Equal(t, map[string]string{"LOG_LEVEL": "info"}, cfg.Env)
+	assert.Equal(t, "data", cfg.Mounts.Source)
+	assert.Equal(t, "/data", cfg.Mounts.Destination)
+	require.Len(t, cfg.Services, 1)
+	assert.Equal(t, 8080, cfg.Services[0].InternalPort)
+	assert.Equal(t, []string{"http"}, cfg.Services[0].Ports[0].Handlers)
+	assert.Equal(t, []string{"app"}, cfg.Services[0].Processes)
+}
+
+func TestAppConfig_Conflicts(t *testing.T) {
+	f, err := Parse([]byte(`
+services:
+  web:
+    build: .
+    environment:
+      MODE: web
+  worker:
+    build: .
+    environment:
+      MODE: worker
+  db:
+    image: postgres
+`))
+	require.NoError(t, err)
+
+	_, _, err = f.AppConfig("stack", map[string]string{"web": "web", "worker": "worker"})
+	assert.ErrorContains(t, err, "different values for environment variable MODE")
+
+	_, _, err = f.AppConfig("stack", map[string]string{"web": "web", "db": "db"})
+	assert.ErrorContains(t, err, "don't share the same image or build")
+}
@@ -0,0 +1,257 @@
+package compose
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+// Images of services which are better replaced by Fly's databases than run as
+// process groups, without their tag or registry.
+var (
+	postgresImages = []string{"postgres", "postgis/postgis", "timescale/timescaledb", "bitnami/postgresql"}
+	redisImages    = []string{"redis", "bitnami/redis", "redis/redis-stack", "redis/redis-stack-server", "valkey/valkey", "eqalpha/keydb"}
+)
+
+// sensitiveEnv matches the names of environment variables which belong in
+// secrets rather than in fly.toml.
+var sensitiveEnv = regexp.MustCompile(`(?i)(PASSWORD|PASSWD|SECRET|TOKEN|API_?KEY|PRIVATE_?KEY|ACCESS_?KEY|CREDENTIALS)`)
+
+var invalidVolumeChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// LaunchPlan is a Compose file translated into a single app, along with the
+// resources the app needs besides its machines.
+type LaunchPlan struct {
+	// Config runs every service which isn't a database as a process group
+	// named after it
+	Config *appconfig.Config
+	// Secrets are the environment variables which look sensitive, kept out of
+	// Config. Those without a value are to be asked for.
+	Secrets map[string]string
+	// Postgres and Redis are the services which are replaced by a Fly
+	// Postgres cluster and an Upstash Redis database
+	Postgres []string
+	Redis    []string
+	// Warnings report settings which were dropped or approximated
+	Warnings []string
+}
+
+// LaunchPlan translates the services of the file into the config of the app
+// launched from dir, where its fly.toml is written. Services built differently
+// than the first one get an image of their own, and the hostnames of services
+// in environment variables are rewritten to their process group's address on
+// the private network.
+func (f *File) LaunchPlan(appName, dir string) (*LaunchPlan, error) {
+	plan := &LaunchPlan{Secrets: map[string]string{}}
+
+	var groups []string
+	for _, name := range f.ServiceNames() {
+		switch image := imageName(f.Services[name].Image); {
+		case f.Services[name].Build == nil && lo.Contains(postgresImages, image):
+			plan.Postgres = append(plan.Postgres, name)
+		case f.Services[name].Build == nil && lo.Contains(redisImages, image):
+			plan.Redis = append(plan.Redis, name)
+		default:
+			groups = append(groups, name)
+		}
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("every service of %s is a database, there's no app to launch", f.Path())
+	}
+
+	databases := append(append([]string(nil), plan.Postgres...), plan.Redis...)
+
+	cfg := appconfig.NewConfig()
+	cfg.AppName = appName
+	cfg.Processes = map[string]string{}
+	env := map[string]string{}
+	envFrom := map[string]string{}
+
+	// the app is built like the first service with a build section
+	main := groups[0]
+	for _, name := range groups {
+		if f.Services[name].Build != nil {
+			main = name
+			break
+		}
+	}
+	mainBuild := f.build(f.Services[main])
+	cfg.Build = relativeBuild(mainBuild, dir)
+	if svc := f.Services[main]; svc.Build != nil && svc.ContextDir(f) != dir {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("service %s: images are built with %s as context rather than %s", main, dir, svc.ContextDir(f)))
+	}
+
+	for _, name := range groups {
+		svc := f.Services[name]
+		group := processGroupName(name)
+
+		if build := f.build(svc); !reflect.DeepEqual(build, mainBuild) {
+			override := &appconfig.ProcessBuild{Image: build.Image}
+			if build.Image == "" {
+				override.Dockerfile = relativePath(build.Dockerfile, dir)
+				override.DockerBuildTarget = build.DockerBuildTarget
+				if !reflect.DeepEqual(build.Args, mainBuild.Args) {
+					plan.Warnings = append(plan.Warnings, fmt.Sprintf("service %s: build args are shared by every image, using those of %s", name, main))
+				}
+			}
+			if cfg.Build.Processes == nil {
+				cfg.Build.Processes = map[string]*appconfig.ProcessBuild{}
+			}
+			cfg.Build.Processes[group] = override
+		}
+
+		if len(svc.Entrypoint) > 0 {
+			plan.Warnings = append(plan.Warnings, fmt.Sprintf("service %s: entrypoint is not supported and was ignored; set it in the image instead", name))
+		}
+		cfg.Processes[group] = svc.Command.String()
+
+		keys := lo.Keys(svc.Environment)
+		sort.Strings(keys)
+		for _, k := range keys {
+			v := svc.Environment[k]
+			if db := referencedService(k, v, databases); db != "" {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("service %s: dropped %s, which refers to %s; attaching the database sets DATABASE_URL or REDIS_URL instead", name, k, db))
+				continue
+			}
+			v = rewriteHostnames(k, v, groups, appName)
+
+			if existing, ok := env[k]; ok && existing != v {
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("service %s: %s differs from the value of service %s, which is kept as it's shared by every process group", name, k, envFrom[k]))
+				continue
+			}
+			env[k], envFrom[k] = v, name
+		}
+
+		for _, port := range svc.Ports {
+			if port.Published == 0 {
+				// only reachable over the private network, which needs no configuration
+				continue
+			}
+			cfg.Services = append(cfg.Services, service(port, group))
+		}
+
+		for _, mount := range svc.Volumes {
+			switch {
+			case !mount.IsNamed():
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("service %s: only named volumes are supported, ignoring %s", name, mount.Target))
+			case cfg.Mounts == nil:
+				cfg.Mounts = &appconfig.Volume{Source: volumeName(mount.Source), Destination: mount.Target}
+			case cfg.Mounts.Source != volumeName(mount.Source) || cfg.Mounts.Destination != mount.Target:
+				plan.Warnings = append(plan.Warnings, fmt.Sprintf("service %s: an app mounts a single volume, ignoring %s", name, mount.Source))
+			}
+		}
+	}
+
+	if cfg.Mounts != nil && len(groups) > 1 {
+		plan.Warnings = append(plan.Warnings, fmt.Sprintf("volume %s is mounted by the machines of every process group", cfg.Mounts.Source))
+	}
+
+	for k, v := range env {
+		if sensitiveEnv.MatchString(k) {
+			plan.Secrets[k] = v
+			delete(env, k)
+		}
+	}
+	if len(env) > 0 {
+		cfg.Env = env
+	}
+
+	plan.Config = cfg
+	return plan, nil
+}
+
+// hostPatterns match name used as a hostname, along with a port as in
+// db:5432 or in a URL as in http://api/, capturing what surrounds it. Like
+// hostnames, they ignore case.
+func hostPatterns(name string) []*regexp.Regexp {
+	quoted := regexp.QuoteMeta(name)
+	return []*regexp.Regexp{
+		regexp.MustCompile(`(?i)(^|[/@,\s])` + quoted + `(:[0-9]+)`),
+		regexp.MustCompile(`(?i)(//|@)` + quoted + `(/|,|\s|$)`),
+	}
+}
+
+// isHostVariable reports whether the environment variable k names a host, so
+// that its whole value is one, as in DB_HOST=db.
+func isHostVariable(k string) bool {
+	k = strings.ToUpper(k)
+	return strings.HasSuffix(k, "HOST") || strings.HasSuffix(k, "HOSTNAME") || strings.HasSuffix(k, "ADDR") || strings.HasSuffix(k, "ADDRESS")
+}
+
+// referencedService returns the first of services the environment variable k
+// uses as a hostname.
+func referencedService(k, v string, services []string) string {
+	for _, name := range services {
+		if isHostVariable(k) && strings.EqualFold(v, name) {
+			return name
+		}
+		for _, pattern := range hostPatterns(name) {
+			if pattern.MatchString(v) {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// rewriteHostnames replaces the services the environment variable k uses as
+// hostnames with the address of their process group's machines.
+func rewriteHostnames(k, v string, services []string, appName string) string {
+	for _, name := range services {
+		host := fmt.Sprintf("%s.process.%s.internal", processGroupName(name), appName)
+		if isHostVariable(k) && strings.EqualFold(v, name) {
+			return host
+		}
+		for _, pattern := range hostPatterns(name) {
+			v = pattern.ReplaceAllString(v, "${1}"+host+"${2}")
+		}
+	}
+	return v
+}
+
+// processGroupName turns a service name into a process group name, which are
+// lowercase.
+func processGroupName(service string) string {
+	return strings.ToLower(service)
+}
+
+// volumeName turns a named volume into a Fly volume name, which only has
+// letters, digits and underscores.
+func volumeName(source string) string {
+	return invalidVolumeChars.ReplaceAllString(source, "_")
+}
+
+// imageName strips the tag, digest and Docker Hub registry from an image
+// reference, such as postgres for docker.io/library/postgres:15.
+func imageName(image string) string {
+	image, _, _ = strings.Cut(image, "@")
+	if i := strings.LastIndex(image, ":"); i > strings.LastIndex(image, "/") {
+		image = image[:i]
+	}
+	image = strings.TrimPrefix(image, "docker.io/")
+	return strings.TrimPrefix(image, "library/")
+}
+
+// relativeBuild makes the Dockerfile of build relative to dir.
+func relativeBuild(build *appconfig.Build, dir string) *appconfig.Build {
+	relative := *build
+	relative.Dockerfile = relativePath(build.Dockerfile, dir)
+	return &relative
+}
+
+func relativePath(path, dir string) string {
+	if path == "" {
+		return ""
+	}
+	if rel, err := filepath.Rel(dir, path); err == nil && !strings.HasPrefix(rel, "..") {
+		return filepath.ToSlash(rel)
+	}
+	return path
+}
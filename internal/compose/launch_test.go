@@ -0,0 +1,103 @@
+package compose
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+func TestLaunchPlan(t *testing.T) {
+	f, err := Parse([]byte(`
+services:
+  web:
+    build:
+      context: ./web
+      dockerfile: Dockerfile.prod
+    environment:
+      API_URL: http://api:8080/v1
+      DATABASE_URL: postgres://postgres:secret@db:5432/app
+      SESSION_SECRET: hunter2
+      ROLE: web
+    ports:
+      - "80:3000"
+    volumes:
+      - uploads-data:/uploads
+  API:
+    image: ghcr.io/acme/api:1.2
+    environment:
+      CACHE_HOST: cache
+  db:
+    image: postgres:15
+  cache:
+    image: docker.io/library/redis:7-alpine
+`))
+	require.NoError(t, err)
+
+	plan, err := f.LaunchPlan("my-app", ".")
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"db"}, plan.Postgres)
+	assert.Equal(t, []string{"cache"}, plan.Redis)
+
+	cfg := plan.Config
+	assert.Equal(t, "my-app", cfg.AppName)
+	assert.Equal(t, map[string]string{"api": "", "web": ""}, cfg.Processes)
+	assert.Equal(t, "web/Dockerfile.prod", cfg.Build.Dockerfile)
+	assert.Equal(t, map[string]*appconfig.ProcessBuild{"api": {Image: "ghcr.io/acme/api:1.2"}}, cfg.Build.Processes)
+
+	assert.Equal(t, map[string]string{
+		"API_URL": "http://api.process.my-app.internal:8080/v1",
+		"ROLE":    "web",
+	}, cfg.Env)
+	assert.Equal(t, map[string]string{"SESSION_SECRET": "hunter2"}, plan.Secrets)
+
+	assert.Equal(t, "uploads_data", cfg.Mounts.Source)
+	assert.Equal(t, "/uploads", cfg.Mounts.Destination)
+	require.Len(t, cfg.Services, 1)
+	assert.Equal(t, 3000, cfg.Services[0].InternalPort)
+	assert.Equal(t, []string{"web"}, cfg.Services[0].Processes)
+
+	assert.Contains(t, plan.Warnings, "service API: dropped CACHE_HOST, which refers to cache; attaching the database sets DATABASE_URL or REDIS_URL instead")
+	assert.Contains(t, plan.Warnings, "service web: dropped DATABASE_URL, which refers to db; attaching the database sets DATABASE_URL or REDIS_URL instead")
+}
+
+func TestLaunchPlan_OnlyDatabases(t *testing.T) {
+	f, err := Parse([]byte(`
+services:
+  db:
+    image: postgres
+`))
+	require.NoError(t, err)
+
+	_, err = f.LaunchPlan("my-app", ".")
+	assert.ErrorContains(t, err, "every service")
+}
+
+func TestRewriteHostnames(t *testing.T) {
+	services := []string{"api", "worker"}
+
+	cases := []struct {
+		k, v, expected string
+	}{
+		{"API_URL", "http://api/", "http://api.process.app.internal/"},
+		{"API_ADDR", "api:9000", "api.process.app.internal:9000"},
+		{"API_HOST", "api", "api.process.app.internal"},
+		{"ROLE", "worker", "worker"},
+		{"PEERS", "api:1,worker:2", "api.process.app.internal:1,worker.process.app.internal:2"},
+		{"NAME", "rapid:80", "rapid:80"},
+	}
+
+	for _, c := range cases {
+		assert.Equal(t, c.expected, rewriteHostnames(c.k, c.v, services, "app"), c.k)
+	}
+}
+
+func TestImageName(t *testing.T) {
+	assert.Equal(t, "postgres", imageName("postgres:15"))
+	assert.Equal(t, "postgres", imageName("docker.io/library/postgres@sha256:abc"))
+	assert.Equal(t, "bitnami/redis", imageName("bitnami/redis:7.0"))
+	assert.Equal(t, "localhost:5000/redis", imageName("localhost:5000/redis"))
+}
@@ -70,6 +70,9 @@ type Config struct {
 
 	// AccessToken denotes the user's access token.
 	AccessToken string
+
+	// keychainToken denotes the access token read from the OS keychain.
+	keychainToken string
 }
 
 // New returns a new instance of Config populated with default values.
@@ -115,16 +118,35 @@ func (cfg *Config) ApplyFile(path string) (err error) {
 	defer cfg.mu.Unlock()
 
 	var w struct {
-		AccessToken string `yaml:"access_token"`
+		AccessToken        string `yaml:"access_token"`
+		AccessTokenStorage string `yaml:"access_token_storage"`
+	}
+
+	if err = unmarshal(path, &w); err != nil {
+		return
 	}
 
-	if err = unmarshal(path, &w); err == nil {
-		cfg.AccessToken = w.AccessToken
+	cfg.AccessToken = w.AccessToken
+	if w.AccessToken == "" && w.AccessTokenStorage == keychainStorage {
+		// a token missing from the keychain means logging in again
+		if token, err := keychainGet(); err == nil {
+			cfg.AccessToken = token
+			cfg.keychainToken = token
+		}
 	}
 
 	return
 }
 
+// AccessTokenFromKeychain reports whether the access token in use was read
+// from the OS keychain, rather than set via the environment or a flag.
+func (cfg *Config) AccessTokenFromKeychain() bool {
+	cfg.mu.RLock()
+	defer cfg.mu.RUnlock()
+
+	return cfg.keychainToken != "" && cfg.keychainToken == cfg.AccessToken
+}
+
 // ApplyFlags sets the properties of cfg which may be set via command line flags
 // to the values the flags of the given FlagSet may contain.
 func (cfg *Config) ApplyFlags(fs *pflag.FlagSet) {
@@ -11,20 +11,40 @@ import (
 	"github.com/superfly/flyctl/internal/filemu"
 )
 
-// SetAccessToken sets the value of the access token at the configuration file
-// found at path.
-func SetAccessToken(path, token string) error {
-	return set(path, map[string]interface{}{
-		AccessTokenFileKey: token,
+// SetAccessToken stores the access token in the OS keychain, and records that
+// it did so at the configuration file found at path. The token is stored in
+// the configuration file itself when the keychain is disabled via
+// NoKeychainEnvKey or fails, in which case inKeychain is false.
+func SetAccessToken(path, token string) (inKeychain bool, err error) {
+	if keychainEnabled() {
+		if err = keychainSet(token); err == nil {
+			err = set(path, map[string]interface{}{
+				AccessTokenFileKey:        "",
+				AccessTokenStorageFileKey: keychainStorage,
+			})
+
+			return err == nil, err
+		}
+	}
+
+	err = set(path, map[string]interface{}{
+		AccessTokenFileKey:        token,
+		AccessTokenStorageFileKey: "",
 	})
+
+	return
 }
 
 // Clear clears the access token and wireguard-related keys of the configuration
-// file found at path.
+// file found at path, along with the access token of the OS keychain.
 func Clear(path string) (err error) {
+	// the token may not be in the keychain at all
+	_ = keychainDelete()
+
 	return set(path, map[string]interface{}{
-		AccessTokenFileKey:    "",
-		WireGuardStateFileKey: map[string]interface{}{},
+		AccessTokenFileKey:        "",
+		AccessTokenStorageFileKey: "",
+		WireGuardStateFileKey:     map[string]interface{}{},
 	})
 }
 
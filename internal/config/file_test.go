@@ -0,0 +1,30 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSetAccessTokenWithoutKeychain(t *testing.T) {
+	t.Setenv(NoKeychainEnvKey, "1")
+
+	path := filepath.Join(t.TempDir(), FileName)
+
+	inKeychain, err := SetAccessToken(path, "secret")
+	require.NoError(t, err)
+	assert.False(t, inKeychain)
+
+	cfg := New()
+	require.NoError(t, cfg.ApplyFile(path))
+	assert.Equal(t, "secret", cfg.AccessToken)
+	assert.False(t, cfg.AccessTokenFromKeychain())
+
+	require.NoError(t, Clear(path))
+
+	cfg = New()
+	require.NoError(t, cfg.ApplyFile(path))
+	assert.Empty(t, cfg.AccessToken)
+}
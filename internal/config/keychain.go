@@ -0,0 +1,35 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/superfly/flyctl/internal/env"
+)
+
+const (
+	// AccessTokenStorageFileKey denotes where the access token is stored when
+	// it's not in the configuration file.
+	AccessTokenStorageFileKey = "access_token_storage"
+
+	// NoKeychainEnvKey denotes the environment variable which keeps the access
+	// token out of the OS keychain, in the configuration file.
+	NoKeychainEnvKey = envKeyPrefix + "NO_KEYCHAIN"
+
+	keychainStorage = "keychain"
+
+	// keychainService and keychainAccount identify the access token in the
+	// OS keychain.
+	keychainService = "flyctl"
+	keychainAccount = AccessTokenFileKey
+)
+
+var errKeychainUnsupported = errors.New("no OS keychain is supported on this platform")
+
+func keychainEnabled() bool {
+	return !env.IsTruthy(NoKeychainEnvKey)
+}
+
+// KeychainAccessToken returns the access token stored in the OS keychain.
+func KeychainAccessToken() (string, error) {
+	return keychainGet()
+}
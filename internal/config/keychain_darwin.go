@@ -0,0 +1,63 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"os/exec"
+	"strings"
+)
+
+// The macOS Keychain is driven through the security command line tool, which
+// ships with every macOS install.
+
+func keychainGet() (string, error) {
+	out, err := exec.Command("security", "find-generic-password",
+		"-s", keychainService, "-a", keychainAccount, "-w").Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+// keychainSet passes the token to security on stdin, in interactive mode, so
+// that it doesn't show up in the arguments of the process for other users.
+func keychainSet(token string) error {
+	if strings.ContainsAny(token, "\r\n") {
+		return errors.New("access token can't contain line breaks")
+	}
+
+	var stderr bytes.Buffer
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(securityCommandLine("add-generic-password", "-U",
+		"-s", keychainService, "-a", keychainAccount, "-w", token))
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return err
+	}
+	// in interactive mode, security exits successfully even when the commands
+	// it ran failed, which they report on stderr
+	if msg := strings.TrimSpace(stderr.String()); msg != "" {
+		return errors.New(msg)
+	}
+	return nil
+}
+
+// securityCommandLine returns the line running args in the interactive mode of
+// security, which splits it on spaces outside of double quotes.
+func securityCommandLine(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		arg = strings.ReplaceAll(arg, `\`, `\\`)
+		arg = strings.ReplaceAll(arg, `"`, `\"`)
+		quoted[i] = `"` + arg + `"`
+	}
+
+	return strings.Join(quoted, " ") + "\n"
+}
+
+func keychainDelete() error {
+	return exec.Command("security", "delete-generic-password",
+		"-s", keychainService, "-a", keychainAccount).Run()
+}
@@ -0,0 +1,14 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityCommandLine(t *testing.T) {
+	assert.Equal(t,
+		`"add-generic-password" "-s" "fly cli" "-w" "fo1_a\"b\\c"`+"\n",
+		securityCommandLine("add-generic-password", "-s", "fly cli", "-w", `fo1_a"b\c`),
+	)
+}
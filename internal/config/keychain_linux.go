@@ -0,0 +1,32 @@
+package config
+
+import (
+	"os/exec"
+	"strings"
+)
+
+// On Linux the access token is stored with libsecret, through its secret-tool
+// command line tool, so that it ends up in the keyring of the desktop session.
+
+func keychainGet() (string, error) {
+	out, err := exec.Command("secret-tool", "lookup",
+		"service", keychainService, "account", keychainAccount).Output()
+	if err != nil {
+		return "", err
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
+
+func keychainSet(token string) error {
+	cmd := exec.Command("secret-tool", "store", "--label=flyctl access token",
+		"service", keychainService, "account", keychainAccount)
+	cmd.Stdin = strings.NewReader(token)
+
+	return cmd.Run()
+}
+
+func keychainDelete() error {
+	return exec.Command("secret-tool", "clear",
+		"service", keychainService, "account", keychainAccount).Run()
+}
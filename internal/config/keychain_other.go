@@ -0,0 +1,15 @@
+//go:build !darwin && !linux && !windows
+
+package config
+
+func keychainGet() (string, error) {
+	return "", errKeychainUnsupported
+}
+
+func keychainSet(string) error {
+	return errKeychainUnsupported
+}
+
+func keychainDelete() error {
+	return errKeychainUnsupported
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// On Windows the access token is stored as a generic credential of the
+// Credential Manager.
+
+var (
+	advapi32       = windows.NewLazySystemDLL("advapi32.dll")
+	procCredRead   = advapi32.NewProc("CredReadW")
+	procCredWrite  = advapi32.NewProc("CredWriteW")
+	procCredDelete = advapi32.NewProc("CredDeleteW")
+	procCredFree   = advapi32.NewProc("CredFree")
+)
+
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+// credential mirrors the CREDENTIALW structure.
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+func keychainTarget() (*uint16, error) {
+	return windows.UTF16PtrFromString(keychainService + ":" + keychainAccount)
+}
+
+func keychainGet() (string, error) {
+	target, err := keychainTarget()
+	if err != nil {
+		return "", err
+	}
+
+	var cred *credential
+	if r, _, err := procCredRead.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0, uintptr(unsafe.Pointer(&cred))); r == 0 {
+		return "", err
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+func keychainSet(token string) error {
+	target, err := keychainTarget()
+	if err != nil {
+		return err
+	}
+	user, err := windows.UTF16PtrFromString(keychainAccount)
+	if err != nil {
+		return err
+	}
+
+	blob := []byte(token)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	if r, _, err := procCredWrite.Call(uintptr(unsafe.Pointer(&cred)), 0); r == 0 {
+		return err
+	}
+
+	return nil
+}
+
+func keychainDelete() error {
+	target, err := keychainTarget()
+	if err != nil {
+		return err
+	}
+
+	if r, _, err := procCredDelete.Call(uintptr(unsafe.Pointer(target)), credTypeGeneric, 0); r == 0 {
+		return err
+	}
+
+	return nil
+}
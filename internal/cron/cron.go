@@ -0,0 +1,251 @@
+// Package cron implements parsing and evaluating cron expressions, and windows
+// of the week.
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression of five fields: minute, hour, day of
+// the month, month and day of the week. It's evaluated in the location of the
+// times it's given.
+type Schedule struct {
+	expr string
+	// fields are the fields of the expression, with macros expanded
+	fields []string
+
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+	// anyDay and anyWeekday are whether the day of the month and of the
+	// week were left as *, since a day matches when either of them do
+	// otherwise
+	anyDay     bool
+	anyWeekday bool
+}
+
+type field struct {
+	name     string
+	min, max int
+	names    map[string]int
+}
+
+var (
+	minuteField = field{name: "minute", min: 0, max: 59}
+	hourField   = field{name: "hour", min: 0, max: 23}
+	dayField    = field{name: "day of month", min: 1, max: 31}
+	monthField  = field{name: "month", min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	// days of the week run from 0 to 7, both of which are Sunday
+	weekdayField = field{name: "day of week", min: 0, max: 7, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+)
+
+var macros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// Parse parses a cron expression, such as "0 8 * * mon-fri" or "*/15 * * * *",
+// or one of the @hourly, @daily, @weekly, @monthly and @yearly macros.
+func Parse(expr string) (*Schedule, error) {
+	expr = strings.TrimSpace(expr)
+
+	spec := strings.ToLower(expr)
+	if macro, ok := macros[spec]; ok {
+		spec = macro
+	}
+
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q, expected 5 fields: minute, hour, day of month, month and day of week", expr)
+	}
+
+	s := &Schedule{
+		expr:       expr,
+		fields:     fields,
+		anyDay:     fields[2] == "*",
+		anyWeekday: fields[4] == "*",
+	}
+
+	var err error
+	for i, f := range []struct {
+		field *field
+		bits  *uint64
+	}{
+		{&minuteField, &s.minutes},
+		{&hourField, &s.hours},
+		{&dayField, &s.days},
+		{&monthField, &s.months},
+		{&weekdayField, &s.weekdays},
+	} {
+		if *f.bits, err = f.field.parse(fields[i]); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+	}
+
+	// Sunday is both 0 and 7
+	if s.weekdays&(1<<7) != 0 {
+		s.weekdays |= 1
+	}
+
+	return s, nil
+}
+
+// parse parses a comma separated list of values, ranges and steps, such as
+// "1,15", "mon-fri", "*/10" or "9-17/2", into a bit set of values.
+func (f *field) parse(s string) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(s, ",") {
+		rng, step, hasStep := strings.Cut(part, "/")
+
+		var from, to int
+		switch {
+		case rng == "*":
+			from, to = f.min, f.max
+		case strings.Contains(rng, "-"):
+			lo, hi, _ := strings.Cut(rng, "-")
+			var err error
+			if from, err = f.value(lo); err != nil {
+				return 0, err
+			}
+			if to, err = f.value(hi); err != nil {
+				return 0, err
+			}
+			if to < from {
+				return 0, fmt.Errorf("invalid %s range %s, it ends before it starts", f.name, rng)
+			}
+		default:
+			v, err := f.value(rng)
+			if err != nil {
+				return 0, err
+			}
+			from, to = v, v
+			if hasStep {
+				to = f.max
+			}
+		}
+
+		every := 1
+		if hasStep {
+			n, err := strconv.Atoi(step)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid %s step %s", f.name, step)
+			}
+			every = n
+		}
+
+		for v := from; v <= to; v += every {
+			bits |= 1 << v
+		}
+	}
+
+	return bits, nil
+}
+
+func (f *field) value(s string) (int, error) {
+	if v, ok := f.names[s]; ok {
+		return v, nil
+	}
+	v, err := strconv.Atoi(s)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("invalid %s %s, expected %d to %d", f.name, s, f.min, f.max)
+	}
+	return v, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.expr
+}
+
+// Matches reports whether the schedule fires at the minute of t.
+func (s *Schedule) Matches(t time.Time) bool {
+	return s.minutes&(1<<t.Minute()) != 0 &&
+		s.hours&(1<<t.Hour()) != 0 &&
+		s.months&(1<<int(t.Month())) != 0 &&
+		s.matchesDay(t)
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	day := s.days&(1<<t.Day()) != 0
+	weekday := s.weekdays&(1<<int(t.Weekday())) != 0
+
+	switch {
+	case s.anyDay && s.anyWeekday:
+		return true
+	case s.anyDay:
+		return weekday
+	case s.anyWeekday:
+		return day
+	default:
+		return day || weekday
+	}
+}
+
+// searchLimit bounds how far Next and Prev look for a time the schedule
+// fires at, beyond which expressions such as "0 0 30 2 *" never do.
+const searchLimit = 5 * 366 * 24 * time.Hour
+
+// Next returns the first time after t the schedule fires at, the zero time
+// when it never does.
+func (s *Schedule) Next(t time.Time) time.Time {
+	limit := t.Add(searchLimit)
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	for t.Before(limit) {
+		switch {
+		case s.months&(1<<int(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hours&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minutes&(1<<t.Minute()) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
+
+// Prev returns the last time at or before t the schedule fired at, the zero
+// time when it never did.
+func (s *Schedule) Prev(t time.Time) time.Time {
+	limit := t.Add(-searchLimit)
+	t = t.Truncate(time.Minute)
+
+	for t.After(limit) {
+		switch {
+		case s.months&(1<<int(t.Month())) == 0:
+			// the last minute of the previous month
+			t = time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location()).Add(-time.Minute)
+		case s.hours&(1<<t.Hour()) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location()).Add(-time.Minute)
+		case s.minutes&(1<<t.Minute()) == 0:
+			t = t.Add(-time.Minute)
+		default:
+			return t
+		}
+	}
+
+	return time.Time{}
+}
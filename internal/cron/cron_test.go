@@ -0,0 +1,96 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	for _, expr := range []string{
+		"* * * * *",
+		"0 8 * * mon-fri",
+		"*/15 9-17 * * 1-5",
+		"0 0 1,15 * *",
+		"30 2 * jan-mar sun",
+		"5/10 * * * *",
+		"@daily",
+		"@HOURLY",
+	} {
+		_, err := Parse(expr)
+		assert.NoError(t, err, expr)
+	}
+
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"* * * * fri-mon",
+		"*/0 * * * *",
+		"@sometimes",
+	} {
+		_, err := Parse(expr)
+		assert.Error(t, err, expr)
+	}
+}
+
+func mustParse(t *testing.T, expr string) *Schedule {
+	s, err := Parse(expr)
+	require.NoError(t, err)
+	return s
+}
+
+func TestMatches(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return tm
+	}
+
+	weekdays := mustParse(t, "0 8 * * mon-fri")
+	assert.True(t, weekdays.Matches(at("2023-05-01T08:00:30Z")))  // Monday
+	assert.False(t, weekdays.Matches(at("2023-05-01T08:01:00Z"))) // a minute late
+	assert.False(t, weekdays.Matches(at("2023-05-06T08:00:00Z"))) // Saturday
+
+	sunday := mustParse(t, "0 0 * * 7")
+	assert.True(t, sunday.Matches(at("2023-05-07T00:00:00Z")))
+
+	// restricting both days matches either of them
+	either := mustParse(t, "0 0 13 * fri")
+	assert.True(t, either.Matches(at("2023-05-13T00:00:00Z"))) // Saturday the 13th
+	assert.True(t, either.Matches(at("2023-05-05T00:00:00Z"))) // Friday the 5th
+	assert.False(t, either.Matches(at("2023-05-06T00:00:00Z")))
+}
+
+func TestNextPrev(t *testing.T) {
+	at := func(s string) time.Time {
+		tm, err := time.Parse(time.RFC3339, s)
+		require.NoError(t, err)
+		return tm
+	}
+
+	weekdays := mustParse(t, "0 8 * * mon-fri")
+	assert.Equal(t, at("2023-05-08T08:00:00Z"), weekdays.Next(at("2023-05-05T08:00:00Z")))
+	assert.Equal(t, at("2023-05-05T08:00:00Z"), weekdays.Prev(at("2023-05-07T12:00:00Z")))
+	assert.Equal(t, at("2023-05-05T08:00:00Z"), weekdays.Prev(at("2023-05-05T08:00:00Z")))
+
+	quarterly := mustParse(t, "30 6 1 */3 *")
+	assert.Equal(t, at("2023-07-01T06:30:00Z"), quarterly.Next(at("2023-05-15T00:00:00Z")))
+	assert.Equal(t, at("2023-04-01T06:30:00Z"), quarterly.Prev(at("2023-05-15T00:00:00Z")))
+
+	never := mustParse(t, "0 0 30 2 *")
+	assert.True(t, never.Next(at("2023-05-15T00:00:00Z")).IsZero())
+	assert.True(t, never.Prev(at("2023-05-15T00:00:00Z")).IsZero())
+
+	// evaluated in the location of the time given
+	ist := time.FixedZone("IST", 5*3600+1800)
+	daily := mustParse(t, "0 9 * * *")
+	assert.Equal(t, time.Date(2023, 5, 2, 9, 0, 0, 0, ist), daily.Next(time.Date(2023, 5, 1, 9, 30, 0, 0, ist)))
+	assert.Equal(t, time.Date(2023, 5, 1, 9, 0, 0, 0, ist), daily.Prev(time.Date(2023, 5, 1, 10, 15, 0, 0, ist)))
+}
@@ -0,0 +1,156 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Describe returns when the schedule fires in words, such as "at 08:00 on
+// Monday through Friday" or "every 15 minutes".
+func (s *Schedule) Describe() string {
+	minute, hour, day, month, weekday := s.fields[0], s.fields[1], s.fields[2], s.fields[3], s.fields[4]
+
+	parts := []string{describeTime(minute, hour)}
+
+	days := dayField.describe(day, "day", ordinal)
+	if !strings.Contains(day, "/") {
+		days = "the " + days
+	}
+	weekdays := weekdayField.describe(weekday, "day of the week", func(v int) string { return time.Weekday(v % 7).String() })
+
+	// a day matches when either the day of the month or of the week does
+	switch {
+	case day != "*" && weekday != "*":
+		parts = append(parts, fmt.Sprintf("on %s of the month or on %s", days, weekdays))
+	case day != "*":
+		parts = append(parts, fmt.Sprintf("on %s of the month", days))
+	case weekday != "*":
+		parts = append(parts, "on "+weekdays)
+	}
+
+	if month != "*" {
+		parts = append(parts, "in "+monthField.describe(month, "month", func(v int) string { return time.Month(v).String() }))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+// describeTime describes the minute and hour fields, as times of the day when
+// there are a few of them.
+func describeTime(minute, hour string) string {
+	if isList(minute) && isList(hour) {
+		minutes, hours := strings.Split(minute, ","), strings.Split(hour, ",")
+		if len(minutes)*len(hours) <= 4 {
+			var times []string
+			for _, h := range hours {
+				for _, m := range minutes {
+					hh, _ := strconv.Atoi(h)
+					mm, _ := strconv.Atoi(m)
+					times = append(times, fmt.Sprintf("%02d:%02d", hh, mm))
+				}
+			}
+			return "at " + joinList(times)
+		}
+	}
+
+	if hour == "*" {
+		switch {
+		case minute == "*":
+			return "every minute"
+		case strings.HasPrefix(minute, "*/") && !strings.Contains(minute, ","):
+			return "every " + strings.TrimPrefix(minute, "*/") + " minutes"
+		}
+	}
+
+	number := func(v int) string { return strconv.Itoa(v) }
+	return fmt.Sprintf("at %s past %s",
+		minuteField.describeWithNoun(minute, "minute", "minutes", number),
+		hourField.describeWithNoun(hour, "hour", "hours", number),
+	)
+}
+
+// isList reports whether s is a list of plain values.
+func isList(s string) bool {
+	return strings.Trim(s, "0123456789,") == ""
+}
+
+// describeWithNoun describes s, prefixed with the noun of a single value or
+// the plural of several, and "every" followed by the noun when it's *.
+func (f *field) describeWithNoun(s, noun, plural string, name func(int) string) string {
+	switch {
+	case s == "*":
+		return "every " + noun
+	case strings.Contains(s, "/"):
+		return f.describe(s, noun, name)
+	case !strings.ContainsAny(s, ",-"):
+		return noun + " " + f.describe(s, noun, name)
+	default:
+		return plural + " " + f.describe(s, noun, name)
+	}
+}
+
+// describe describes the values of the field s, naming them with name, such
+// as "Monday through Friday" or "every 2nd hour from 8 through 18".
+func (f *field) describe(s, noun string, name func(int) string) string {
+	valueName := func(v string) string {
+		n, err := f.value(v)
+		if err != nil {
+			return v
+		}
+		return name(n)
+	}
+
+	var parts []string
+	for _, part := range strings.Split(s, ",") {
+		rng, step, hasStep := strings.Cut(part, "/")
+
+		var desc string
+		if hasStep {
+			n, _ := strconv.Atoi(step)
+			desc = "every " + noun
+			if n > 1 {
+				desc = fmt.Sprintf("every %s %s", ordinal(n), noun)
+			}
+		}
+
+		lo, hi, isRange := strings.Cut(rng, "-")
+		switch {
+		case rng == "*":
+		case isRange && hasStep:
+			desc += fmt.Sprintf(" from %s through %s", valueName(lo), valueName(hi))
+		case isRange:
+			desc = fmt.Sprintf("%s through %s", valueName(lo), valueName(hi))
+		case hasStep:
+			desc += " from " + valueName(rng)
+		default:
+			desc = valueName(rng)
+		}
+		parts = append(parts, desc)
+	}
+
+	return joinList(parts)
+}
+
+// joinList joins items as "a", "a and b" or "a, b and c".
+func joinList(items []string) string {
+	if len(items) < 2 {
+		return strings.Join(items, "")
+	}
+	return strings.Join(items[:len(items)-1], ", ") + " and " + items[len(items)-1]
+}
+
+func ordinal(n int) string {
+	suffix := "th"
+	switch {
+	case n%100 >= 11 && n%100 <= 13:
+	case n%10 == 1:
+		suffix = "st"
+	case n%10 == 2:
+		suffix = "nd"
+	case n%10 == 3:
+		suffix = "rd"
+	}
+	return strconv.Itoa(n) + suffix
+}
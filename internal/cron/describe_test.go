@@ -0,0 +1,34 @@
+package cron
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescribe(t *testing.T) {
+	cases := map[string]string{
+		"0 8 * * mon-fri":       "at 08:00 on Monday through Friday",
+		"30 8,20 * * *":         "at 08:30 and 20:30",
+		"@daily":                "at 00:00",
+		"@hourly":               "at minute 0 past every hour",
+		"* * * * *":             "every minute",
+		"*/15 * * * *":          "every 15 minutes",
+		"*/5 9-17 * * *":        "at every 5th minute past hours 9 through 17",
+		"0 */2 * * *":           "at minute 0 past every 2nd hour",
+		"0 0 1,15 * *":          "at 00:00 on the 1st and 15th of the month",
+		"0 0 */2 * *":           "at 00:00 on every 2nd day of the month",
+		"0 12 1 jan-mar *":      "at 12:00 on the 1st of the month in January through March",
+		"0 6 13 * fri":          "at 06:00 on the 13th of the month or on Friday",
+		"0 22 * * sat,sun":      "at 22:00 on Saturday and Sunday",
+		"0 0 * * 7":             "at 00:00 on Sunday",
+		"5,10,15,20,25 * * * *": "at minutes 5, 10, 15, 20 and 25 past every hour",
+	}
+
+	for expr, want := range cases {
+		s, err := Parse(expr)
+		require.NoError(t, err, expr)
+		assert.Equal(t, want, s.Describe(), expr)
+	}
+}
@@ -0,0 +1,103 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Window is a window of the week, from the Start hour to the End hour UTC of
+// Days. Windows ending before they start span midnight.
+type Window struct {
+	Days  [7]bool
+	Start int
+	End   int
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday,
+	"mon": time.Monday,
+	"tue": time.Tuesday,
+	"wed": time.Wednesday,
+	"thu": time.Thursday,
+	"fri": time.Friday,
+	"sat": time.Saturday,
+}
+
+// ParseWindow parses windows such as "mon-fri 9-18", "sat,sun 00:00-24:00"
+// or "daily 22-6".
+func ParseWindow(s string) (Window, error) {
+	var w Window
+
+	days, hours, ok := strings.Cut(strings.TrimSpace(strings.ToLower(s)), " ")
+	if !ok {
+		return w, fmt.Errorf("invalid window %q, expected days and hours such as \"mon-fri 9-18\"", s)
+	}
+
+	if days == "daily" || days == "*" {
+		days = "sun-sat"
+	}
+	for _, part := range strings.Split(days, ",") {
+		from, to, isRange := strings.Cut(part, "-")
+		first, ok := weekdays[from]
+		if !ok {
+			return w, fmt.Errorf("invalid day %q in window %q, expected one of mon, tue, wed, thu, fri, sat, sun or daily", from, s)
+		}
+		last := first
+		if isRange {
+			if last, ok = weekdays[to]; !ok {
+				return w, fmt.Errorf("invalid day %q in window %q, expected one of mon, tue, wed, thu, fri, sat, sun or daily", to, s)
+			}
+		}
+		for d := first; ; d = (d + 1) % 7 {
+			w.Days[d] = true
+			if d == last {
+				break
+			}
+		}
+	}
+
+	from, to, ok := strings.Cut(strings.TrimSpace(hours), "-")
+	if !ok {
+		return w, fmt.Errorf("invalid hours %q in window %q, expected a range such as 9-18", hours, s)
+	}
+	var err error
+	if w.Start, err = parseHour(from); err != nil {
+		return w, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	if w.End, err = parseHour(to); err != nil {
+		return w, fmt.Errorf("invalid window %q: %w", s, err)
+	}
+	if w.Start == w.End || w.Start == 24 {
+		return w, fmt.Errorf("invalid window %q, it must start before 24 and end at another hour", s)
+	}
+
+	return w, nil
+}
+
+// parseHour parses hours as 9 or 09:00. Windows start and end on the hour, as
+// the machines applying them run hourly.
+func parseHour(s string) (int, error) {
+	hour, minutes, hasMinutes := strings.Cut(s, ":")
+	if hasMinutes && minutes != "00" {
+		return 0, fmt.Errorf("windows start and end on the hour, got %s", s)
+	}
+	h, err := strconv.Atoi(hour)
+	if err != nil || h < 0 || h > 24 {
+		return 0, fmt.Errorf("invalid hour %s, expected 0 to 24", s)
+	}
+	return h, nil
+}
+
+// Contains reports whether t falls within the window.
+func (w Window) Contains(t time.Time) bool {
+	t = t.UTC()
+	day, hour := t.Weekday(), t.Hour()
+
+	if w.Start < w.End {
+		return w.Days[day] && hour >= w.Start && hour < w.End
+	}
+	// the window spans midnight, its end belongs to the day after it started
+	return (w.Days[day] && hour >= w.Start) || (w.Days[(day+6)%7] && hour < w.End)
+}
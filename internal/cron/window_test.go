@@ -0,0 +1,49 @@
+package cron
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseWindow(t *testing.T) {
+	w, err := ParseWindow("mon-fri 09:00-18:00")
+	require.NoError(t, err)
+	assert.Equal(t, Window{Days: [7]bool{false, true, true, true, true, true, false}, Start: 9, End: 18}, w)
+
+	w, err = ParseWindow("fri-mon 22-6")
+	require.NoError(t, err)
+	assert.Equal(t, Window{Days: [7]bool{true, true, false, false, false, true, true}, Start: 22, End: 6}, w)
+
+	w, err = ParseWindow("daily 0-24")
+	require.NoError(t, err)
+	assert.Equal(t, [7]bool{true, true, true, true, true, true, true}, w.Days)
+
+	for _, invalid := range []string{"mon-fri", "weekdays 9-18", "mon 9", "mon 9:30-18", "mon 9-9", "mon 24-2", "mon 9-25"} {
+		_, err := ParseWindow(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
+
+func TestWindowContains(t *testing.T) {
+	// 2023-05-01 is a Monday
+	at := func(day, hour int) time.Time {
+		return time.Date(2023, 5, day, hour, 0, 0, 0, time.UTC)
+	}
+
+	weekdays, err := ParseWindow("mon-fri 9-18")
+	require.NoError(t, err)
+	assert.True(t, weekdays.Contains(at(1, 9)))
+	assert.True(t, weekdays.Contains(at(5, 17)))
+	assert.False(t, weekdays.Contains(at(1, 18)))
+	assert.False(t, weekdays.Contains(at(6, 12)))
+
+	overnight, err := ParseWindow("fri 22-6")
+	require.NoError(t, err)
+	assert.True(t, overnight.Contains(at(5, 23)))
+	assert.True(t, overnight.Contains(at(6, 5)))
+	assert.False(t, overnight.Contains(at(6, 23)))
+	assert.False(t, overnight.Contains(at(5, 5)))
+}
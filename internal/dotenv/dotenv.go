@@ -0,0 +1,104 @@
+// Package dotenv implements parsing of NAME=VALUE pairs, as found in .env
+// files.
+package dotenv
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	parserStateSingleline = iota
+	parserStateMultiline  = iota
+)
+
+// Parse reads NAME=VALUE pairs, one per line. Empty lines and lines starting
+// with # are skipped, names may be prefixed with export, values may be quoted,
+// and values spanning several lines are delimited with triple quotes.
+func Parse(reader io.Reader) (map[string]string, error) {
+	secrets := map[string]string{}
+	scanner := bufio.NewScanner(reader)
+	parserState := parserStateSingleline
+	parsedKey := ""
+	parsedVal := strings.Builder{}
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch parserState {
+		case parserStateSingleline:
+			// Skip comments and empty lines
+			if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
+				continue
+			}
+
+			parts := strings.SplitN(line, "=", 2)
+			if len(parts) == 2 {
+				parts[0] = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(parts[0]), "export "))
+			}
+			if len(parts) != 2 || parts[0] == "" {
+				return nil, fmt.Errorf("Secrets must be provided as NAME=VALUE pairs (%s is invalid)", line)
+			}
+
+			if strings.HasPrefix(parts[1], `"""`) {
+				// Switch to multiline
+				parserState = parserStateMultiline
+				parsedKey = parts[0]
+				parsedVal.WriteString(strings.TrimPrefix(parts[1], "\"\"\""))
+				parsedVal.WriteString("\n")
+			} else {
+				secrets[parts[0]] = unquote(parts[1])
+			}
+		case parserStateMultiline:
+			if strings.HasSuffix(line, `"""`) {
+				// End of multiline
+				parsedVal.WriteString(strings.TrimSuffix(line, `"""`))
+				secrets[parsedKey] = parsedVal.String()
+				parsedVal.Reset()
+				parserState = parserStateSingleline
+				parsedKey = ""
+			} else {
+				parsedVal.WriteString(line + "\n")
+			}
+
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if parserState == parserStateMultiline {
+		return nil, fmt.Errorf("the value of %s is missing its closing \"\"\"", parsedKey)
+	}
+
+	return secrets, nil
+}
+
+// ParseFile parses the NAME=VALUE pairs of the file at path.
+func ParseFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	pairs, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed parsing %s: %w", path, err)
+	}
+
+	return pairs, nil
+}
+
+// unquote strips the single or double quotes value is wrapped in, if any.
+func unquote(value string) string {
+	if len(value) >= 2 {
+		if first := value[0]; (first == '"' || first == '\'') && value[len(value)-1] == first {
+			return value[1 : len(value)-1]
+		}
+	}
+
+	return value
+}
@@ -1,4 +1,4 @@
-package secrets
+package dotenv
 
 import (
 	"strings"
@@ -15,7 +15,7 @@ FOO=BAR
 # Another comment
 QUX=NAH
 `)
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO": "BAR",
@@ -25,7 +25,7 @@ QUX=NAH
 
 func Test_parse_unix(t *testing.T) {
 	reader := strings.NewReader("FOO=BAR\nQUX=NAH\n")
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO": "BAR",
@@ -35,7 +35,7 @@ func Test_parse_unix(t *testing.T) {
 
 func Test_parse_windows(t *testing.T) {
 	reader := strings.NewReader("FOO=BAR\r\nQUX=NAH\r\n")
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO": "BAR",
@@ -54,7 +54,7 @@ TRAILERENV=what
 FIN="""Here is the end,
 my only friend"""
 `)
-	secrets, err := parseSecrets(reader)
+	secrets, err := Parse(reader)
 	assert.NoError(t, err)
 	assert.Equal(t, map[string]string{
 		"FOO":        "BAR",
@@ -63,3 +63,33 @@ my only friend"""
 		"FIN":        "Here is the end,\nmy only friend",
 	}, secrets)
 }
+
+func Test_parse_dotenv(t *testing.T) {
+	reader := strings.NewReader(`
+export FOO=BAR
+QUOTED="some value"
+SINGLE='it''s'
+ SPACED =value
+EMPTY=
+`)
+	secrets, err := Parse(reader)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"FOO":    "BAR",
+		"QUOTED": "some value",
+		"SINGLE": "it''s",
+		"SPACED": "value",
+		"EMPTY":  "",
+	}, secrets)
+}
+
+func Test_parse_invalid(t *testing.T) {
+	for _, input := range []string{
+		"FOO",
+		"=BAR",
+		"FOO=\"\"\"never ends\n",
+	} {
+		_, err := Parse(strings.NewReader(input))
+		assert.Error(t, err, input)
+	}
+}
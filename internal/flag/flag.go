@@ -49,6 +49,18 @@ const (
 
 	// DetachName denotes the name of the detach flag.
 	DetachName = "detach"
+
+	// AnswersFileName denotes the name of the answers file flag.
+	AnswersFileName = "answers-file"
+
+	// CacheDirName denotes the name of the cache dir flag.
+	CacheDirName = "cache-dir"
+
+	// AppDirName denotes the name of the app dir flag.
+	AppDirName = "app-dir"
+
+	// CABundleName denotes the name of the CA bundle flag.
+	CABundleName = "ca-bundle"
 )
 
 // Flag wraps the set of flags.
@@ -172,6 +184,14 @@ func Org() String {
 	}
 }
 
+// AnswersFile returns an answers file string flag.
+func AnswersFile() String {
+	return String{
+		Name:        AnswersFileName,
+		Description: "Path to a YAML file answering the command's prompts, keyed by prompt message",
+	}
+}
+
 // Region returns a region string flag.
 func Region() String {
 	return String{
@@ -263,7 +283,7 @@ const localOnlyName = "local-only"
 func LocalOnly() Bool {
 	return Bool{
 		Name:        localOnlyName,
-		Description: "Only perform builds locally using the local docker daemon",
+		Description: "Only perform builds locally using the local docker or podman daemon, or buildkitd ($BUILDKIT_HOST) when there is none",
 	}
 }
 
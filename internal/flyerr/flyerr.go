@@ -39,6 +39,17 @@ func GetErrorSuggestion(err error) string {
 	return ""
 }
 
+// ExitCodeError is the exit code of a command flyctl ran, such as on a
+// machine, which flyctl exits with in turn, without printing an error: the
+// command has reported what went wrong itself.
+type ExitCodeError struct {
+	Code int
+}
+
+func (e ExitCodeError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.Code)
+}
+
 func PrintCLIOutput(err error) {
 	if err == nil {
 		return
@@ -55,6 +55,12 @@ func ConfirmConfigChanges(ctx context.Context, machine *api.Machine, targetConfi
 	return true, nil
 }
 
+// ConfigDiff renders the changes from original to new as a colorized JSON
+// diff, empty when there are none.
+func ConfigDiff(ctx context.Context, original, new api.MachineConfig) string {
+	return configCompare(ctx, original, new)
+}
+
 // CloneConfig deep-copies a MachineConfig.
 // If CloneConfig is called on a nil config, nil is returned.
 func CloneConfig(orig *api.MachineConfig) *api.MachineConfig {
@@ -0,0 +1,130 @@
+package machine
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/client"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/logger"
+	"github.com/superfly/flyctl/iostreams"
+	"github.com/superfly/flyctl/logs"
+	"github.com/superfly/flyctl/terminal"
+)
+
+// logsGracePeriod is how long the logs of a machine which exited keep being
+// streamed, as they're delivered with some delay.
+const logsGracePeriod = 2 * time.Second
+
+// EphemeralInput describes a machine running a single command, which is
+// destroyed once the command exits.
+type EphemeralInput struct {
+	LaunchInput api.LaunchMachineInput
+	// StartTimeout is how long the machine may take to start, and its exit
+	// event to be reported
+	StartTimeout time.Duration
+	// Timeout is how long the command may run before the machine is killed
+	Timeout time.Duration
+	// PrintLog prints the log entries of the machine while it runs
+	PrintLog func(logs.LogEntry)
+}
+
+// RunEphemeral launches the machine of input, with the flaps client of ctx,
+// streams its logs and waits for its command to exit, returning the machine
+// and the exit code of the command. The machine is killed when the command
+// doesn't exit within the timeout or ctx is canceled. The returned machine is
+// nil when it couldn't be launched.
+func RunEphemeral(ctx context.Context, input EphemeralInput) (*api.Machine, int, error) {
+	var (
+		flapsClient = flaps.FromContext(ctx)
+		io          = iostreams.FromContext(ctx)
+	)
+
+	launchInput := input.LaunchInput
+	config := CloneConfig(launchInput.Config)
+	config.AutoDestroy = true
+	config.Restart = api.MachineRestart{Policy: api.MachineRestartPolicyNo}
+	launchInput.Config = config
+
+	raw, err := flapsClient.Launch(ctx, launchInput)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed creating a machine: %w", err)
+	}
+	m := NewLeasableMachine(flapsClient, io, raw)
+
+	stopLogs := StreamLogs(ctx, launchInput.AppID, raw.ID, input.PrintLog)
+	err = m.WaitForState(ctx, api.MachineStateStarted, input.StartTimeout)
+	if err == nil {
+		err = m.WaitForState(ctx, api.MachineStateDestroyed, input.Timeout)
+	}
+	stopLogs()
+	if err != nil {
+		// the context may be canceled, which shouldn't leave the machine running
+		destroyCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+
+		if err := flapsClient.Destroy(destroyCtx, api.RemoveMachineInput{AppID: launchInput.AppID, ID: raw.ID, Kill: true}); err != nil {
+			terminal.Warnf("failed destroying machine %s: %v\n", raw.ID, err)
+		}
+		if ctx.Err() != nil {
+			return raw, 0, ctx.Err()
+		}
+		return raw, 0, fmt.Errorf("machine %s didn't finish running within %s, killed it: %w", raw.ID, input.Timeout, err)
+	}
+
+	exitCode, err := ExitCode(ctx, m, input.StartTimeout)
+	return raw, exitCode, err
+}
+
+// ExitCode returns the exit code of the last command m ran, waiting up to
+// timeout for its exit event.
+func ExitCode(ctx context.Context, m LeasableMachine, timeout time.Duration) (int, error) {
+	id := m.Machine().ID
+
+	exitEvent, err := m.WaitForEventTypeAfterType(ctx, "exit", "start", timeout)
+	if err != nil {
+		return 0, fmt.Errorf("failed finding the exit event of machine %s: %w", id, err)
+	}
+	if exitEvent.Request == nil {
+		return 0, fmt.Errorf("the exit event of machine %s has no exit code", id)
+	}
+	return exitEvent.Request.GetExitCode()
+}
+
+// StreamLogs passes the log entries of the machine to print as they're
+// produced, over NATS or by polling when NATS can't be reached, until the
+// returned function is called.
+func StreamLogs(ctx context.Context, appName, machineID string, print func(logs.LogEntry)) (stop func()) {
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		apiClient := client.FromContext(ctx).API()
+		opts := &logs.LogOptions{
+			AppName: appName,
+			VMID:    machineID,
+		}
+
+		stream, err := logs.NewNatsStream(ctx, apiClient, opts)
+		if err != nil {
+			logger.FromContext(ctx).Debugf("could not stream logs over nats, falling back to polling: %v", err)
+			if stream, err = logs.NewPollingStream(apiClient, opts); err != nil {
+				return
+			}
+		}
+
+		for entry := range stream.Stream(ctx, opts) {
+			print(entry)
+		}
+	}()
+
+	return func() {
+		pause(ctx, logsGracePeriod)
+		cancel()
+		<-done
+	}
+}
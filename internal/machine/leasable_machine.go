@@ -170,7 +170,15 @@ func (lm *leasableMachine) WaitForState(ctx context.Context, desiredState string
 		case errors.Is(err, context.DeadlineExceeded):
 			return fmt.Errorf("timeout reached waiting for machine to %s %w", desiredState, err)
 		case !destroyedMachineNotFoundResponse && err != nil:
-			time.Sleep(b.Duration())
+			// a crashed machine isn't going to reach the state by waiting longer
+			if desiredState == api.MachineStateStarted {
+				if m, getErr := lm.flapsClient.Get(waitCtx, lm.Machine().ID); getErr == nil {
+					if exitErr := m.ExitError(); exitErr != nil {
+						return exitErr
+					}
+				}
+			}
+			pause(waitCtx, b.Duration())
 			continue
 		}
 		lm.logClearLinesAbove(1)
@@ -186,15 +194,21 @@ func (lm *leasableMachine) WaitForHealthchecksToPass(ctx context.Context, timeou
 	waitCtx, cancel := context.WithTimeout(ctx, timeout)
 	defer cancel()
 
+	// poll quickly at first, as checks often pass on their first run, then back
+	// off to half the interval of the most frequent check
 	shortestInterval := 120 * time.Second
 	for _, c := range lm.Machine().Config.Checks {
 		if c.Interval != nil && c.Interval.Duration < shortestInterval {
 			shortestInterval = c.Interval.Duration
 		}
 	}
+	maxDelay := shortestInterval / 2
+	if maxDelay < time.Second {
+		maxDelay = time.Second
+	}
 	b := &backoff.Backoff{
-		Min:    shortestInterval / 2,
-		Max:    2 * shortestInterval,
+		Min:    500 * time.Millisecond,
+		Max:    maxDelay,
 		Factor: 2,
 		Jitter: true,
 	}
@@ -209,13 +223,15 @@ func (lm *leasableMachine) WaitForHealthchecksToPass(ctx context.Context, timeou
 			return fmt.Errorf("timeout reached waiting for healthchecks to pass for machine %s %w", lm.Machine().ID, err)
 		case err != nil:
 			return fmt.Errorf("error getting machine %s from api: %w", lm.Machine().ID, err)
+		case updateMachine.ExitError() != nil:
+			return updateMachine.ExitError()
 		case !updateMachine.HealthCheckStatus().AllPassing():
 			if !printedFirst || lm.io.IsInteractive() {
 				lm.logClearLinesAbove(1)
 				lm.logHealthCheckStatus(updateMachine.HealthCheckStatus())
 				printedFirst = true
 			}
-			time.Sleep(b.Duration())
+			pause(waitCtx, b.Duration())
 			continue
 		}
 		lm.logClearLinesAbove(1)
@@ -253,11 +269,20 @@ func (lm *leasableMachine) WaitForEventTypeAfterType(ctx context.Context, eventT
 		if exitEvent != nil {
 			return exitEvent, nil
 		} else {
-			time.Sleep(b.Duration())
+			pause(waitCtx, b.Duration())
 		}
 	}
 }
 
+// pause sleeps for d, returning early when ctx is done so that the caller
+// notices the deadline right away.
+func pause(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
+
 func (lm *leasableMachine) Machine() *api.Machine {
 	return lm.machine
 }
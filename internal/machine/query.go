@@ -2,6 +2,7 @@ package machine
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/samber/lo"
 	"github.com/superfly/flyctl/api"
@@ -25,3 +26,28 @@ func ListActive(ctx context.Context) ([]*api.Machine, error) {
 	return machines, nil
 
 }
+
+// LatestRelease returns a machine of the app running its latest release, nil
+// when it has none.
+func LatestRelease(ctx context.Context) (*api.Machine, error) {
+	machines, _, err := flaps.FromContext(ctx).ListFlyAppsMachines(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		latest  *api.Machine
+		version = -1
+	)
+	for _, m := range machines {
+		v, err := strconv.Atoi(m.Config.Metadata[api.MachineConfigMetadataKeyFlyReleaseVersion])
+		if err != nil {
+			v = 0
+		}
+		if v > version {
+			latest, version = m, v
+		}
+	}
+
+	return latest, nil
+}
@@ -21,99 +21,108 @@ func Update(ctx context.Context, m *api.Machine, input *api.LaunchMachineInput)
 	)
 
 	if input != nil && input.Config != nil && input.Config.Guest != nil {
-		// Check that there's a valid number of CPUs
-		var validNumCpus []int
-
-		if input.Config.Guest.CPUKind == "shared" {
-			validNumCpus = append(validNumCpus, 1, 2, 4, 6, 8)
+		if err := ValidateGuest(input.Config.Guest); err != nil {
+			return err
+		}
+	}
 
-		} else if input.Config.Guest.CPUKind == "performance" {
-			validNumCpus = append(validNumCpus, 1, 2, 4, 6, 8, 10, 12, 14, 16)
+	fmt.Fprintf(io.Out, "Updating machine %s\n", colorize.Bold(m.ID))
 
-		}
+	input.ID = m.ID
+	updatedMachine, err = flapsClient.Update(ctx, *input, m.LeaseNonce)
+	if err != nil {
+		return fmt.Errorf("could not stop machine %s: %w", input.ID, err)
+	}
 
-		validCpuNum := false
+	waitForAction := "start"
+	if m.Config.Schedule != "" {
+		waitForAction = "stop"
+	}
 
-		for _, num := range validNumCpus {
-			if num == input.Config.Guest.CPUs {
-				validCpuNum = true
-				break
+	if err := WaitForStartOrStop(ctx, updatedMachine, waitForAction, time.Minute*5); err != nil {
+		return err
+	}
 
-			}
+	if !input.SkipHealthChecks {
+		if err := watch.MachinesChecks(ctx, []*api.Machine{updatedMachine}); err != nil {
+			return fmt.Errorf("failed to wait for health checks to pass: %w", err)
 		}
+	}
 
-		if !validCpuNum {
-			return fmt.Errorf("invalid config: invalid number of CPUs for %s guest. Valid numbers are %v\nView more information here: https://fly.io/docs/about/pricing/#machines", input.Config.Guest.CPUKind, validNumCpus)
+	fmt.Fprintf(io.Out, "Machine %s updated successfully!\n", colorize.Bold(m.ID))
 
-		}
+	return nil
+}
 
-		if input.Config.Guest.CPUKind == "shared" && input.Config.Guest.MemoryMB%256 != 0 {
-			suggestion := input.Config.Guest.MemoryMB - (input.Config.Guest.MemoryMB % 256)
-			if suggestion == 0 {
-				suggestion = 256
-			}
-			return fmt.Errorf("invalid config: invalid memory size %d; must be in 256 MiB increment (%d would work)\nView more information here: https://fly.io/docs/about/pricing/#machines", input.Config.Guest.MemoryMB, suggestion)
-		} else if input.Config.Guest.CPUKind == "performance" && input.Config.Guest.MemoryMB%1024 != 0 {
-			suggestion := input.Config.Guest.MemoryMB - (input.Config.Guest.MemoryMB % 1024)
-			if suggestion == 0 {
-				suggestion = 1024
-			}
-			return fmt.Errorf("invalid config: invalid memory size %d; must be in 1024 MiB increment (%d would work)\nView more information here: https://fly.io/docs/about/pricing/#machines", input.Config.Guest.MemoryMB, suggestion)
-		}
+// ValidateGuest checks the number of CPUs and the amount of memory of guest
+// against the limits of its CPU kind.
+func ValidateGuest(guest *api.MachineGuest) error {
+	// Check that there's a valid number of CPUs
+	var validNumCpus []int
 
-		// Check memory sizes
-		var min_memory_size int
+	if guest.CPUKind == "shared" {
+		validNumCpus = append(validNumCpus, 1, 2, 4, 6, 8)
 
-		if input.Config.Guest.CPUKind == "shared" {
-			min_memory_size = api.MIN_MEMORY_MB_PER_SHARED_CPU * input.Config.Guest.CPUs
-		} else if input.Config.Guest.CPUKind == "performance" {
-			min_memory_size = api.MIN_MEMORY_MB_PER_CPU * input.Config.Guest.CPUs
-		}
+	} else if guest.CPUKind == "performance" {
+		validNumCpus = append(validNumCpus, 1, 2, 4, 6, 8, 10, 12, 14, 16)
 
-		if min_memory_size > input.Config.Guest.MemoryMB {
-			return fmt.Errorf("invalid config: for machines with %d CPUs, the minimum amount of memory is %d MiB\nView more information here: https://fly.io/docs/about/pricing/#machines", input.Config.Guest.CPUs, min_memory_size)
+	}
 
-		}
+	validCpuNum := false
 
-		var maxMemory int
+	for _, num := range validNumCpus {
+		if num == guest.CPUs {
+			validCpuNum = true
+			break
 
-		if input.Config.Guest.CPUKind == "shared" {
-			maxMemory = input.Config.Guest.CPUs * api.MAX_MEMORY_MB_PER_SHARED_CPU
-		} else if input.Config.Guest.CPUKind == "performance" {
-			maxMemory = input.Config.Guest.CPUs * api.MAX_MEMORY_MB_PER_CPU
 		}
+	}
 
-		if input.Config.Guest.MemoryMB > maxMemory {
-			return fmt.Errorf("invalid config: for machines with %d CPUs, the maximum amount of memory is %d MiB\nView more information here: https://fly.io/docs/about/pricing/#machines", input.Config.Guest.CPUs, maxMemory)
+	if !validCpuNum {
+		return fmt.Errorf("invalid config: invalid number of CPUs for %s guest. Valid numbers are %v\nView more information here: https://fly.io/docs/about/pricing/#machines", guest.CPUKind, validNumCpus)
 
-		}
+	}
 
+	if guest.CPUKind == "shared" && guest.MemoryMB%256 != 0 {
+		suggestion := guest.MemoryMB - (guest.MemoryMB % 256)
+		if suggestion == 0 {
+			suggestion = 256
+		}
+		return fmt.Errorf("invalid config: invalid memory size %d; must be in 256 MiB increment (%d would work)\nView more information here: https://fly.io/docs/about/pricing/#machines", guest.MemoryMB, suggestion)
+	} else if guest.CPUKind == "performance" && guest.MemoryMB%1024 != 0 {
+		suggestion := guest.MemoryMB - (guest.MemoryMB % 1024)
+		if suggestion == 0 {
+			suggestion = 1024
+		}
+		return fmt.Errorf("invalid config: invalid memory size %d; must be in 1024 MiB increment (%d would work)\nView more information here: https://fly.io/docs/about/pricing/#machines", guest.MemoryMB, suggestion)
 	}
 
-	fmt.Fprintf(io.Out, "Updating machine %s\n", colorize.Bold(m.ID))
+	// Check memory sizes
+	var min_memory_size int
 
-	input.ID = m.ID
-	updatedMachine, err = flapsClient.Update(ctx, *input, m.LeaseNonce)
-	if err != nil {
-		return fmt.Errorf("could not stop machine %s: %w", input.ID, err)
+	if guest.CPUKind == "shared" {
+		min_memory_size = api.MIN_MEMORY_MB_PER_SHARED_CPU * guest.CPUs
+	} else if guest.CPUKind == "performance" {
+		min_memory_size = api.MIN_MEMORY_MB_PER_CPU * guest.CPUs
 	}
 
-	waitForAction := "start"
-	if m.Config.Schedule != "" {
-		waitForAction = "stop"
-	}
+	if min_memory_size > guest.MemoryMB {
+		return fmt.Errorf("invalid config: for machines with %d CPUs, the minimum amount of memory is %d MiB\nView more information here: https://fly.io/docs/about/pricing/#machines", guest.CPUs, min_memory_size)
 
-	if err := WaitForStartOrStop(ctx, updatedMachine, waitForAction, time.Minute*5); err != nil {
-		return err
 	}
 
-	if !input.SkipHealthChecks {
-		if err := watch.MachinesChecks(ctx, []*api.Machine{updatedMachine}); err != nil {
-			return fmt.Errorf("failed to wait for health checks to pass: %w", err)
-		}
+	var maxMemory int
+
+	if guest.CPUKind == "shared" {
+		maxMemory = guest.CPUs * api.MAX_MEMORY_MB_PER_SHARED_CPU
+	} else if guest.CPUKind == "performance" {
+		maxMemory = guest.CPUs * api.MAX_MEMORY_MB_PER_CPU
 	}
 
-	fmt.Fprintf(io.Out, "Machine %s updated successfully!\n", colorize.Bold(m.ID))
+	if guest.MemoryMB > maxMemory {
+		return fmt.Errorf("invalid config: for machines with %d CPUs, the maximum amount of memory is %d MiB\nView more information here: https://fly.io/docs/about/pricing/#machines", guest.CPUs, maxMemory)
+
+	}
 
 	return nil
 }
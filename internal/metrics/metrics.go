@@ -0,0 +1,100 @@
+// Package metrics queries the Prometheus compatible metrics Fly.io keeps of
+// the machines of each organization.
+package metrics
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/internal/config"
+)
+
+// Client queries the metrics of an organization.
+type Client struct {
+	// BaseURL is the URL of the Prometheus API of the organization, which
+	// paths such as /api/v1/query are relative to.
+	BaseURL    string
+	Token      string
+	HTTPClient *http.Client
+}
+
+// New returns a Client of the metrics of the organization with the given slug,
+// authenticated as the user of ctx.
+func New(ctx context.Context, orgSlug string) *Client {
+	cfg := config.FromContext(ctx)
+
+	return &Client{
+		BaseURL:    strings.TrimSuffix(cfg.APIBaseURL, "/") + "/prometheus/" + url.PathEscape(orgSlug),
+		Token:      cfg.AccessToken,
+		HTTPClient: http.DefaultClient,
+	}
+}
+
+// ErrNoData is returned by queries whose result is empty, such as those of
+// machines with no metrics for the time range queried.
+var ErrNoData = errors.New("no metrics match the query")
+
+// Max returns the largest of the values of the instant vector of query.
+func (c *Client) Max(ctx context.Context, query string) (float64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.BaseURL+"/api/v1/query?"+url.Values{"query": {query}}.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", api.AuthorizationHeader(c.Token))
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed querying metrics: %w", err)
+	}
+	defer res.Body.Close() //skipcq: GO-S2307
+
+	var body struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+		Data   struct {
+			ResultType string `json:"resultType"`
+			Result     []struct {
+				Value [2]any `json:"value"`
+			} `json:"result"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		return 0, fmt.Errorf("failed querying metrics: %s responded with %s", c.BaseURL, res.Status)
+	}
+	if body.Status != "success" {
+		return 0, fmt.Errorf("failed querying metrics: %s", body.Error)
+	}
+	if body.Data.ResultType != "vector" {
+		return 0, fmt.Errorf("failed querying metrics: expected a vector, got a %s", body.Data.ResultType)
+	}
+
+	var (
+		max   float64
+		found bool
+	)
+	for _, sample := range body.Data.Result {
+		s, ok := sample.Value[1].(string)
+		if !ok {
+			continue
+		}
+		value, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return 0, fmt.Errorf("failed querying metrics: invalid value %q", s)
+		}
+		if !found || value > max {
+			max, found = value, true
+		}
+	}
+	if !found {
+		return 0, ErrNoData
+	}
+
+	return max, nil
+}
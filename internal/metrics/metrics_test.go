@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMax(t *testing.T) {
+	var response string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/prometheus/personal/api/v1/query", r.URL.Path)
+		assert.Equal(t, `max(fly_instance_memory_mem_total{app="web"})`, r.URL.Query().Get("query"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		io.WriteString(w, response)
+	}))
+	defer server.Close()
+
+	c := &Client{BaseURL: server.URL + "/prometheus/personal", Token: "token", HTTPClient: server.Client()}
+	query := `max(fly_instance_memory_mem_total{app="web"})`
+
+	response = `{"status":"success","data":{"resultType":"vector","result":[
+		{"metric":{"instance":"m1"},"value":[1700000000,"1024"]},
+		{"metric":{"instance":"m2"},"value":[1700000000,"2048.5"]}]}}`
+	max, err := c.Max(context.Background(), query)
+	require.NoError(t, err)
+	assert.Equal(t, 2048.5, max)
+
+	response = `{"status":"success","data":{"resultType":"vector","result":[]}}`
+	_, err = c.Max(context.Background(), query)
+	assert.ErrorIs(t, err, ErrNoData)
+
+	response = `{"status":"error","error":"parse error"}`
+	_, err = c.Max(context.Background(), query)
+	assert.EqualError(t, err, "failed querying metrics: parse error")
+}
@@ -0,0 +1,103 @@
+// Package primaryregion checks the primary region of an app is where its
+// machines, volumes and databases are, as it's where writes are expected to
+// happen.
+package primaryregion
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+
+	"github.com/superfly/flyctl/api"
+	"github.com/superfly/flyctl/flaps"
+	"github.com/superfly/flyctl/internal/appconfig"
+)
+
+// EnvName is the environment variable machines are told the primary region in.
+const EnvName = "PRIMARY_REGION"
+
+// Check returns what's inconsistent about primary being the primary region of
+// an app with machines, and volumes of which those named volumeName are
+// mounted by the app: no machine or no such volume being there.
+func Check(primary string, machines []*api.Machine, volumes []api.Volume, volumeName string) (warnings []string) {
+	if primary == "" {
+		return nil
+	}
+
+	if len(machines) > 0 && !lo.ContainsBy(machines, func(m *api.Machine) bool { return m.Region == primary }) {
+		regions := lo.Uniq(lo.Map(machines, func(m *api.Machine, _ int) string { return m.Region }))
+		sort.Strings(regions)
+		warnings = append(warnings, fmt.Sprintf("the primary region %s has no machines, they run in %s", primary, strings.Join(regions, ", ")))
+	}
+
+	if volumeName != "" && !lo.ContainsBy(volumes, func(v api.Volume) bool { return v.Name == volumeName && v.Region == primary }) {
+		warnings = append(warnings, fmt.Sprintf("the primary region %s has no volume named %s", primary, volumeName))
+	}
+
+	return warnings
+}
+
+// PostgresPrimaries returns the region of the primary of each Postgres cluster
+// of the organization of app it's attached to, by the name of the cluster's
+// app. Clusters on the nomad platform are left out.
+func PostgresPrimaries(ctx context.Context, client *api.Client, app *api.AppCompact) (map[string]string, error) {
+	clusters, err := client.GetApps(ctx, api.StringPointer("postgres_cluster"))
+	if err != nil {
+		return nil, fmt.Errorf("failed listing postgres clusters: %w", err)
+	}
+
+	primaries := map[string]string{}
+	for _, cluster := range clusters {
+		if cluster.Organization.Slug != app.Organization.Slug || cluster.PlatformVersion != appconfig.MachinesPlatform {
+			continue
+		}
+
+		attachments, err := client.ListPostgresClusterAttachments(ctx, app.Name, cluster.Name)
+		if err != nil {
+			return nil, fmt.Errorf("failed listing attachments of postgres cluster %s: %w", cluster.Name, err)
+		}
+		if len(attachments) == 0 {
+			continue
+		}
+
+		clusterApp, err := client.GetAppCompact(ctx, cluster.Name)
+		if err != nil {
+			return nil, err
+		}
+		flapsClient, err := flaps.New(ctx, clusterApp)
+		if err != nil {
+			return nil, err
+		}
+		machines, err := flapsClient.ListActive(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed listing machines of postgres cluster %s: %w", cluster.Name, err)
+		}
+
+		for _, m := range machines {
+			if region := m.Config.Env[EnvName]; region != "" {
+				primaries[cluster.Name] = region
+				break
+			}
+		}
+	}
+
+	return primaries, nil
+}
+
+// CheckPostgres returns a warning for each Postgres cluster of primaries whose
+// primary isn't in primary.
+func CheckPostgres(primary string, primaries map[string]string) (warnings []string) {
+	clusters := lo.Keys(primaries)
+	sort.Strings(clusters)
+
+	for _, cluster := range clusters {
+		if region := primaries[cluster]; region != primary {
+			warnings = append(warnings, fmt.Sprintf("the primary of postgres cluster %s is in %s, not in the primary region %s, so writes to it cross regions", cluster, region, primary))
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,32 @@
+package primaryregion
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/superfly/flyctl/api"
+)
+
+func TestCheck(t *testing.T) {
+	machines := []*api.Machine{{Region: "ams"}, {Region: "ord"}, {Region: "ams"}}
+	volumes := []api.Volume{{Name: "data", Region: "ord"}, {Name: "cache", Region: "iad"}}
+
+	assert.Empty(t, Check("ord", machines, volumes, "data"))
+	assert.Empty(t, Check("", machines, volumes, "data"))
+	assert.Empty(t, Check("iad", nil, nil, ""))
+
+	assert.Equal(t, []string{
+		"the primary region iad has no machines, they run in ams, ord",
+		"the primary region iad has no volume named data",
+	}, Check("iad", machines, volumes, "data"))
+}
+
+func TestCheckPostgres(t *testing.T) {
+	primaries := map[string]string{"db-b": "ams", "db-a": "ord"}
+
+	assert.Empty(t, CheckPostgres("ord", map[string]string{"db-a": "ord"}))
+	assert.Equal(t, []string{
+		"the primary of postgres cluster db-b is in ams, not in the primary region ord, so writes to it cross regions",
+	}, CheckPostgres("ord", primaries))
+}
@@ -0,0 +1,157 @@
+package prompt
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Answers pre-fill prompts. They're keyed by prompt message, compared without
+// regard to case or trailing punctuation, so that both "Select Organization:"
+// and "select organization" answer the same prompt.
+//
+// Selections are answered with the text of the option, or with the code shown
+// in parentheses at its end (an org slug or a region code, for example).
+// Confirmations are answered with booleans, and multiple selections with
+// lists.
+type Answers map[string]interface{}
+
+// LoadAnswers reads the YAML answers file at path.
+func LoadAnswers(path string) (Answers, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	raw := map[string]interface{}{}
+	if err := yaml.Unmarshal(buf, &raw); err != nil {
+		return nil, fmt.Errorf("failed parsing answers file %s: %w", path, err)
+	}
+
+	answers := make(Answers, len(raw))
+	for msg, answer := range raw {
+		answers[answerKey(msg)] = answer
+	}
+
+	return answers, nil
+}
+
+type answersContextKey struct{}
+
+// WithAnswers derives a context that carries answers from ctx.
+func WithAnswers(ctx context.Context, answers Answers) context.Context {
+	return context.WithValue(ctx, answersContextKey{}, answers)
+}
+
+func answerFor(ctx context.Context, msg string) (interface{}, bool) {
+	answers, _ := ctx.Value(answersContextKey{}).(Answers)
+
+	answer, ok := answers[answerKey(msg)]
+	return answer, ok
+}
+
+func answerKey(msg string) string {
+	return strings.ToLower(strings.TrimRight(strings.TrimSpace(msg), ":? "))
+}
+
+// AnswerString returns the answer to the prompt with the given message, if
+// the answers ctx carries include one. It's meant for the few prompts which
+// aren't issued through this package.
+func AnswerString(ctx context.Context, msg string) (string, bool) {
+	answer, ok := answerFor(ctx, msg)
+	if !ok {
+		return "", false
+	}
+
+	return fmt.Sprint(answer), true
+}
+
+func answerInt(ctx context.Context, msg string) (int, bool, error) {
+	answer, ok := answerFor(ctx, msg)
+	if !ok {
+		return 0, false, nil
+	}
+
+	switch v := answer.(type) {
+	case int:
+		return v, true, nil
+	case string:
+		if i, err := strconv.Atoi(v); err == nil {
+			return i, true, nil
+		}
+	}
+
+	return 0, false, fmt.Errorf("answer to %q must be an integer", msg)
+}
+
+func answerBool(ctx context.Context, msg string) (bool, bool, error) {
+	answer, ok := answerFor(ctx, msg)
+	if !ok {
+		return false, false, nil
+	}
+
+	switch v := answer.(type) {
+	case bool:
+		return v, true, nil
+	case string:
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b, true, nil
+		}
+	}
+
+	return false, false, fmt.Errorf("answer to %q must be a boolean", msg)
+}
+
+func answerSelect(ctx context.Context, msg string, options []string) (int, bool, error) {
+	answer, ok := answerFor(ctx, msg)
+	if !ok {
+		return 0, false, nil
+	}
+
+	index, err := matchOption(msg, fmt.Sprint(answer), options)
+	return index, true, err
+}
+
+func answerMultiSelect(ctx context.Context, msg string, options []string) ([]int, bool, error) {
+	answer, ok := answerFor(ctx, msg)
+	if !ok {
+		return nil, false, nil
+	}
+
+	values, isList := answer.([]interface{})
+	if !isList {
+		values = []interface{}{answer}
+	}
+
+	indices := make([]int, 0, len(values))
+	for _, v := range values {
+		index, err := matchOption(msg, fmt.Sprint(v), options)
+		if err != nil {
+			return nil, true, err
+		}
+		indices = append(indices, index)
+	}
+
+	return indices, true, nil
+}
+
+func matchOption(msg, answer string, options []string) (int, error) {
+	for i, option := range options {
+		if strings.EqualFold(option, answer) {
+			return i, nil
+		}
+	}
+
+	code := "(" + strings.ToLower(answer) + ")"
+	for i, option := range options {
+		if strings.HasSuffix(strings.ToLower(strings.TrimSuffix(option, " [personal]")), code) {
+			return i, nil
+		}
+	}
+
+	return 0, fmt.Errorf("answer %q to %q matches none of its options", answer, msg)
+}
@@ -0,0 +1,50 @@
+package prompt
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAnswers(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "answers.yml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+Choose an app name (leave blank to generate one): my-app
+select organization: acme
+"Would you like to deploy now?": false
+Initial cluster size: 3
+Select regions: [ams, "Chicago, Illinois (US) (ord)"]
+Select VM size: bogus
+`), 0o600))
+
+	answers, err := LoadAnswers(path)
+	require.NoError(t, err)
+	ctx := WithAnswers(context.Background(), answers)
+
+	name, err := SelectAppName(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "my-app", name)
+
+	var index int
+	require.NoError(t, Select(ctx, &index, "Select Organization:", "", "Personal (personal) [personal]", "Acme (acme)"))
+	assert.Equal(t, 1, index)
+
+	deploy, err := Confirm(ctx, "Would you like to deploy now?")
+	require.NoError(t, err)
+	assert.False(t, deploy)
+
+	var size int
+	require.NoError(t, Int(ctx, &size, "Initial cluster size", 1, true))
+	assert.Equal(t, 3, size)
+
+	var indices []int
+	regions := []string{"Amsterdam, Netherlands (ams)", "Chicago, Illinois (US) (ord)"}
+	require.NoError(t, MultiSelect(ctx, &indices, "Select regions:", nil, regions...))
+	assert.Equal(t, []int{0, 1}, indices)
+
+	assert.Error(t, Select(ctx, &index, "Select VM size:", "", "shared-cpu-1x"))
+}
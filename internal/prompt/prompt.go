@@ -25,6 +25,11 @@ type RegionParams struct {
 }
 
 func String(ctx context.Context, dst *string, msg, def string, required bool) error {
+	if answer, ok := AnswerString(ctx, msg); ok {
+		*dst = answer
+		return nil
+	}
+
 	opt, err := newSurveyIO(ctx)
 	if err != nil {
 		return err
@@ -44,6 +49,11 @@ func String(ctx context.Context, dst *string, msg, def string, required bool) er
 }
 
 func Int(ctx context.Context, dst *int, msg string, def int, required bool) error {
+	if answer, ok, err := answerInt(ctx, msg); ok || err != nil {
+		*dst = answer
+		return err
+	}
+
 	opt, err := newSurveyIO(ctx)
 	if err != nil {
 		return err
@@ -70,6 +80,11 @@ func Int(ctx context.Context, dst *int, msg string, def int, required bool) erro
 }
 
 func Password(ctx context.Context, dst *string, msg string, required bool) error {
+	if answer, ok := AnswerString(ctx, msg); ok {
+		*dst = answer
+		return nil
+	}
+
 	opt, err := newSurveyIO(ctx)
 	if err != nil {
 		return err
@@ -88,6 +103,11 @@ func Password(ctx context.Context, dst *string, msg string, required bool) error
 }
 
 func MultiSelect(ctx context.Context, indices *[]int, msg string, def []int, options ...string) error {
+	if answer, ok, err := answerMultiSelect(ctx, msg, options); ok {
+		*indices = answer
+		return err
+	}
+
 	opt, err := newSurveyIO(ctx)
 	if err != nil {
 		return err
@@ -104,6 +124,11 @@ func MultiSelect(ctx context.Context, indices *[]int, msg string, def []int, opt
 }
 
 func Select(ctx context.Context, index *int, msg, def string, options ...string) error {
+	if answer, ok, err := answerSelect(ctx, msg, options); ok {
+		*index = answer
+		return err
+	}
+
 	opt, err := newSurveyIO(ctx)
 	if err != nil {
 		return err
@@ -127,6 +152,11 @@ func Confirmf(ctx context.Context, format string, a ...interface{}) (bool, error
 }
 
 func Confirm(ctx context.Context, message string) (confirm bool, err error) {
+	var answered bool
+	if confirm, answered, err = answerBool(ctx, message); answered || err != nil {
+		return
+	}
+
 	var opt survey.AskOpt
 	if opt, err = newSurveyIO(ctx); err != nil {
 		return
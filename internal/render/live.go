@@ -0,0 +1,42 @@
+package render
+
+import (
+	"bytes"
+	"io"
+	"strings"
+
+	"github.com/morikuni/aec"
+)
+
+// LiveTable renders a table which is redrawn in place every time Render is
+// called, for output which changes while a command runs. It's only meant for
+// interactive terminals, and nothing else may be written to w in between
+// renders.
+type LiveTable struct {
+	w     io.Writer
+	lines int
+}
+
+func NewLiveTable(w io.Writer) *LiveTable {
+	return &LiveTable{w: w}
+}
+
+// Render replaces the table rendered last with the one defined by the given
+// rows and columns.
+func (t *LiveTable) Render(rows [][]string, cols ...string) error {
+	var buf bytes.Buffer
+	for i := 0; i < t.lines; i++ {
+		buf.WriteString(aec.Up(1).String())
+		buf.WriteString(aec.EraseLine(aec.EraseModes.All).String())
+	}
+
+	var table bytes.Buffer
+	if err := Table(&table, "", rows, cols...); err != nil {
+		return err
+	}
+	t.lines = strings.Count(table.String(), "\n")
+	buf.Write(table.Bytes())
+
+	_, err := t.w.Write(buf.Bytes())
+	return err
+}
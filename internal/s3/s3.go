@@ -0,0 +1,398 @@
+// Package s3 implements a client for the few S3 operations flyctl needs to
+// store files in S3 compatible object storage, such as AWS S3, Cloudflare R2 or
+// Tigris.
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PartSize is the size of the parts of multipart uploads. S3 takes at most
+// 10,000 parts, which at this size caps objects to about 156GiB.
+const PartSize = 16 << 20
+
+const maxParts = 10000
+
+// Client signs requests to an S3 compatible endpoint, addressing buckets by
+// path.
+type Client struct {
+	Endpoint        string
+	Region          string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	HTTPClient *http.Client
+}
+
+// NewFromEnv returns a client authenticated with the standard AWS environment
+// variables. The endpoint, when empty, is read from AWS_ENDPOINT_URL_S3 or
+// AWS_ENDPOINT_URL, or else is AWS S3 in the configured region.
+func NewFromEnv(endpoint string) (*Client, error) {
+	c := &Client{
+		Endpoint:        endpoint,
+		Region:          firstEnv("AWS_REGION", "AWS_DEFAULT_REGION"),
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		HTTPClient:      http.DefaultClient,
+	}
+
+	if c.AccessKeyID == "" || c.SecretAccessKey == "" {
+		return nil, errors.New("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set to access object storage")
+	}
+	if c.Region == "" {
+		c.Region = "us-east-1"
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = firstEnv("AWS_ENDPOINT_URL_S3", "AWS_ENDPOINT_URL")
+	}
+	if c.Endpoint == "" {
+		c.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", c.Region)
+	}
+
+	return c, nil
+}
+
+func firstEnv(names ...string) string {
+	for _, name := range names {
+		if v := os.Getenv(name); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// ParseURL splits an s3://bucket/key URL.
+func ParseURL(raw string) (bucket, key string, err error) {
+	u, err := url.Parse(raw)
+	switch {
+	case err != nil:
+		return "", "", err
+	case u.Scheme != "s3":
+		return "", "", fmt.Errorf("%s is not an s3:// URL", raw)
+	case u.Host == "" || strings.Trim(u.Path, "/") == "":
+		return "", "", fmt.Errorf("%s doesn't name both a bucket and a key, as in s3://bucket/key", raw)
+	}
+
+	return u.Host, strings.TrimPrefix(u.Path, "/"), nil
+}
+
+// Error is an error response of the endpoint.
+type Error struct {
+	StatusCode int
+	Code       string `xml:"Code"`
+	Message    string `xml:"Message"`
+}
+
+func (e *Error) Error() string {
+	if e.Code == "" {
+		return fmt.Sprintf("object storage responded with status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("object storage responded with %s: %s", e.Code, e.Message)
+}
+
+// Upload stores what r reads as the object key of bucket, in parts so that it
+// needn't be read in memory at once. It returns the size of the object.
+func (c *Client) Upload(ctx context.Context, bucket, key string, r io.Reader) (size int64, err error) {
+	var created struct {
+		UploadID string `xml:"UploadId"`
+	}
+	if err := c.do(ctx, http.MethodPost, bucket, key, url.Values{"uploads": {""}}, nil, &created); err != nil {
+		return 0, err
+	}
+
+	defer func() {
+		if err != nil {
+			// don't leave the parts behind, they're billed
+			abortCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+			defer cancel()
+			_ = c.do(abortCtx, http.MethodDelete, bucket, key, url.Values{"uploadId": {created.UploadID}}, nil, nil)
+		}
+	}()
+
+	type part struct {
+		Number int    `xml:"PartNumber"`
+		ETag   string `xml:"ETag"`
+	}
+	var parts []part
+
+	buf := make([]byte, PartSize)
+	for number := 1; ; number++ {
+		n, readErr := io.ReadFull(r, buf)
+		if readErr != nil && readErr != io.EOF && readErr != io.ErrUnexpectedEOF {
+			return 0, readErr
+		}
+		// an empty object still takes a part
+		if n == 0 && number > 1 {
+			break
+		}
+		if number > maxParts {
+			return 0, fmt.Errorf("object is larger than %d parts of %d bytes", maxParts, PartSize)
+		}
+
+		query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {created.UploadID}}
+		etag, err := c.put(ctx, bucket, key, query, buf[:n])
+		if err != nil {
+			return 0, fmt.Errorf("failed uploading part %d: %w", number, err)
+		}
+		parts = append(parts, part{Number: number, ETag: etag})
+		size += int64(n)
+
+		if readErr != nil {
+			break
+		}
+	}
+
+	complete, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []part   `xml:"Part"`
+	}{Parts: parts})
+	if err != nil {
+		return 0, err
+	}
+	if err := c.do(ctx, http.MethodPost, bucket, key, url.Values{"uploadId": {created.UploadID}}, complete, nil); err != nil {
+		return 0, err
+	}
+
+	return size, nil
+}
+
+// Size returns the size of the object key of bucket, failing when it doesn't
+// exist.
+func (c *Client) Size(ctx context.Context, bucket, key string) (int64, error) {
+	req, err := c.newRequest(ctx, http.MethodHead, bucket, key, nil, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close() //skipcq: GO-S2307
+
+	if res.StatusCode != http.StatusOK {
+		return 0, &Error{StatusCode: res.StatusCode}
+	}
+
+	return res.ContentLength, nil
+}
+
+// Download returns the content of the object key of bucket, along with its
+// size.
+func (c *Client) Download(ctx context.Context, bucket, key string) (io.ReadCloser, int64, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, bucket, key, nil, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close() //skipcq: GO-S2307
+		return nil, 0, responseError(res)
+	}
+
+	return res.Body, res.ContentLength, nil
+}
+
+func (c *Client) put(ctx context.Context, bucket, key string, query url.Values, body []byte) (string, error) {
+	req, err := c.newRequest(ctx, http.MethodPut, bucket, key, query, body)
+	if err != nil {
+		return "", err
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close() //skipcq: GO-S2307
+
+	if res.StatusCode != http.StatusOK {
+		return "", responseError(res)
+	}
+
+	return res.Header.Get("ETag"), nil
+}
+
+// do sends a request and decodes its XML response into out, when not nil.
+func (c *Client) do(ctx context.Context, method, bucket, key string, query url.Values, body []byte, out any) error {
+	req, err := c.newRequest(ctx, method, bucket, key, query, body)
+	if err != nil {
+		return err
+	}
+
+	res, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close() //skipcq: GO-S2307
+
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return responseError(res)
+	}
+
+	// CompleteMultipartUpload reports errors in the body of 200 responses
+	buf, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if bytes.Contains(buf, []byte("<Error>")) {
+		e := &Error{StatusCode: res.StatusCode}
+		if err := xml.Unmarshal(buf, e); err == nil {
+			return e
+		}
+	}
+
+	if out == nil {
+		return nil
+	}
+	return xml.Unmarshal(buf, out)
+}
+
+func responseError(res *http.Response) error {
+	e := &Error{StatusCode: res.StatusCode}
+	if buf, err := io.ReadAll(io.LimitReader(res.Body, 64<<10)); err == nil {
+		_ = xml.Unmarshal(buf, e)
+	}
+	return e
+}
+
+func (c *Client) newRequest(ctx context.Context, method, bucket, key string, query url.Values, body []byte) (*http.Request, error) {
+	u, err := url.Parse(strings.TrimSuffix(c.Endpoint, "/") + "/" + bucket + "/" + escapePath(key))
+	if err != nil {
+		return nil, fmt.Errorf("invalid endpoint: %w", err)
+	}
+	u.RawQuery = encodeQuery(query)
+
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.ContentLength = int64(len(body))
+
+	c.sign(req, body, time.Now())
+	return req, nil
+}
+
+// sign adds the AWS Signature Version 4 of the request to its headers.
+func (c *Client) sign(req *http.Request, body []byte, now time.Time) {
+	var (
+		timestamp   = now.UTC().Format("20060102T150405Z")
+		date        = timestamp[:8]
+		payloadHash = sha256Hex(body)
+		scope       = strings.Join([]string{date, c.Region, "s3", "aws4_request"}, "/")
+	)
+
+	req.Header.Set("X-Amz-Date", timestamp)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if c.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", c.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "range" || name == "content-type" || name == "content-md5" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&canonicalHeaders, "%s:%s\n", name, headers[name])
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		escapePath(req.URL.Path),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		timestamp,
+		scope,
+		sha256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+c.SecretAccessKey), date)
+	key = hmacSHA256(key, c.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		c.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// escapePath escapes the segments of path as S3 expects them to be in URLs
+// and signatures.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		segments[i] = escape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// encodeQuery encodes query sorted by key, with the escaping of signatures.
+func encodeQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			pairs = append(pairs, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escape percent-encodes everything but unreserved characters, unlike
+// url.QueryEscape which turns spaces into +.
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -16,6 +16,7 @@ const (
 	workDirKey
 	userHomeDirKey
 	configDirKey
+	cacheDirKey
 )
 
 // WithHostname returns a copy of ctx that carries hostname.
@@ -65,6 +66,18 @@ func ConfigDirectory(ctx context.Context) string {
 	return get(ctx, configDirKey).(string)
 }
 
+// WithCacheDirectory derives a Context that carries the given cache directory
+// from ctx.
+func WithCacheDirectory(ctx context.Context, cd string) context.Context {
+	return set(ctx, cacheDirKey, cd)
+}
+
+// CacheDirectory returns the cache directory ctx carries. It panics in case
+// ctx carries no cache directory.
+func CacheDirectory(ctx context.Context) string {
+	return get(ctx, cacheDirKey).(string)
+}
+
 // ConfigFile returns the config file ctx carries. It panics in case
 // ctx carries no config directory.
 func ConfigFile(ctx context.Context) string {
@@ -0,0 +1,193 @@
+// Package transport implements how flyctl connects to the Fly service, its
+// registry and the WireGuard gateways: through the proxies HTTPS_PROXY,
+// HTTP_PROXY and NO_PROXY select, trusting the certificates of a CA bundle
+// besides those of the system, as TLS intercepting proxies require.
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+// CABundleEnvKey is the environment variable the CA bundle may be set with
+// instead of --ca-bundle. Processes flyctl starts, such as the agent, get the
+// bundle through it.
+const CABundleEnvKey = "FLY_CA_BUNDLE"
+
+var (
+	mu      sync.RWMutex
+	rootCAs *x509.CertPool
+)
+
+// UseCABundle makes flyctl trust the certificates of the PEM file at path,
+// besides those of the system, in the connections it makes from now on. It
+// configures http.DefaultTransport, which the clients of the Fly service
+// are built on.
+func UseCABundle(path string) error {
+	pem, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed reading CA bundle: %w", err)
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return fmt.Errorf("CA bundle %s holds no PEM encoded certificates", path)
+	}
+
+	mu.Lock()
+	rootCAs = pool
+	mu.Unlock()
+
+	if t, ok := http.DefaultTransport.(*http.Transport); ok {
+		t.TLSClientConfig = TLSConfig()
+	}
+
+	return nil
+}
+
+// TLSConfig returns a TLS config trusting the CA bundle in use, if any.
+func TLSConfig() *tls.Config {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	return &tls.Config{RootCAs: rootCAs}
+}
+
+// Proxy returns the URL of the proxy to connect to the https endpoint at addr
+// through, nil when there's none. Unlike http.ProxyFromEnvironment it reads
+// the environment on each call.
+func Proxy(addr string) (*url.URL, error) {
+	return httpproxy.FromEnvironment().ProxyFunc()(&url.URL{Scheme: "https", Host: addr})
+}
+
+// DialTLS connects to addr, as host:port, with TLS configured by conf, through
+// the proxy for it if there's one.
+func DialTLS(ctx context.Context, addr string, conf *tls.Config) (net.Conn, error) {
+	proxyURL, err := Proxy(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy: %w", err)
+	}
+
+	var conn net.Conn
+	if proxyURL == nil {
+		conn, err = (&net.Dialer{}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialThroughProxy(ctx, proxyURL, addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if conf.ServerName == "" {
+		conf = conf.Clone()
+		conf.ServerName, _, _ = net.SplitHostPort(addr)
+	}
+
+	tlsConn := tls.Client(conn, conf)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	return tlsConn, nil
+}
+
+// dialThroughProxy opens a tunnel to addr with a CONNECT request to the
+// proxy at proxyURL.
+func dialThroughProxy(ctx context.Context, proxyURL *url.URL, addr string) (net.Conn, error) {
+	proxyAddr := proxyURL.Host
+	if proxyURL.Port() == "" {
+		port := "80"
+		if proxyURL.Scheme == "https" {
+			port = "443"
+		}
+		proxyAddr = net.JoinHostPort(proxyURL.Hostname(), port)
+	}
+
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", proxyAddr)
+	if err != nil {
+		return nil, fmt.Errorf("failed connecting to proxy %s: %w", proxyAddr, err)
+	}
+
+	if proxyURL.Scheme == "https" {
+		conf := TLSConfig()
+		conf.ServerName = proxyURL.Hostname()
+		tlsConn := tls.Client(conn, conf)
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("failed connecting to proxy %s: %w", proxyAddr, err)
+		}
+		conn = tlsConn
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: http.Header{},
+	}
+	if user := proxyURL.User; user != nil {
+		password, _ := user.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(user.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer conn.SetDeadline(time.Time{})
+	}
+
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed writing to proxy %s: %w", proxyAddr, err)
+	}
+
+	// the response to a CONNECT request is all the proxy sends before the
+	// server, which only speaks once spoken to, so nothing is lost to the
+	// buffer of the reader
+	resp, err := http.ReadResponse(bufio.NewReader(conn), req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed reading from proxy %s: %w", proxyAddr, err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		conn.Close()
+		return nil, fmt.Errorf("proxy %s refused to connect to %s: %s", proxyAddr, addr, resp.Status)
+	}
+
+	return conn, nil
+}
+
+// ProxyBuildArgs returns the proxy settings of the environment as the build
+// args Docker predefines for them, for RUN instructions of local builds to
+// reach the network the way flyctl does.
+func ProxyBuildArgs() map[string]string {
+	args := map[string]string{}
+	for _, key := range []string{"HTTP_PROXY", "HTTPS_PROXY", "NO_PROXY"} {
+		for _, k := range []string{key, strings.ToLower(key)} {
+			if value, ok := os.LookupEnv(k); ok {
+				args[key] = value
+				break
+			}
+		}
+	}
+	return args
+}
@@ -0,0 +1,102 @@
+package transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDialThroughProxy(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer server.Close()
+
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+	require.NoError(t, UseCABundle(bundle))
+
+	proxy, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer proxy.Close()
+
+	authorization := make(chan string, 1)
+	go func() {
+		conn, err := proxy.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		req, err := http.ReadRequest(bufio.NewReader(conn))
+		if err != nil {
+			return
+		}
+		authorization <- req.Header.Get("Proxy-Authorization")
+
+		upstream, err := net.Dial("tcp", req.Host)
+		if err != nil {
+			return
+		}
+		defer upstream.Close()
+
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+		go func() { _, _ = io.Copy(upstream, conn) }()
+		_, _ = io.Copy(conn, upstream)
+	}()
+
+	addr := server.Listener.Addr().String()
+	conn, err := dialThroughProxy(context.Background(), &url.URL{Scheme: "http", Host: proxy.Addr().String(), User: url.UserPassword("user", "secret")}, addr)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	assert.Equal(t, "Basic dXNlcjpzZWNyZXQ=", <-authorization)
+
+	conf := TLSConfig()
+	conf.ServerName = "example.com"
+	tlsConn := tls.Client(conn, conf)
+	require.NoError(t, tlsConn.Handshake(), "the certificate of the server is trusted through the CA bundle")
+
+	_, err = io.WriteString(tlsConn, "GET / HTTP/1.1\r\nHost: example.com\r\nConnection: close\r\n\r\n")
+	require.NoError(t, err)
+	resp, err := http.ReadResponse(bufio.NewReader(tlsConn), nil)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "ok", string(body))
+}
+
+func TestUseCABundle(t *testing.T) {
+	bundle := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, os.WriteFile(bundle, []byte("not a certificate"), 0o600))
+
+	assert.ErrorContains(t, UseCABundle(bundle), "holds no PEM encoded certificates")
+	assert.Error(t, UseCABundle(filepath.Join(t.TempDir(), "missing.pem")))
+}
+
+func TestProxyBuildArgs(t *testing.T) {
+	t.Setenv("HTTPS_PROXY", "http://proxy.corp:3128")
+	t.Setenv("https_proxy", "")
+	t.Setenv("no_proxy", ".corp")
+	t.Setenv("NO_PROXY", "")
+	os.Unsetenv("NO_PROXY")
+	os.Unsetenv("https_proxy")
+	os.Unsetenv("HTTP_PROXY")
+	os.Unsetenv("http_proxy")
+
+	assert.Equal(t, map[string]string{
+		"HTTPS_PROXY": "http://proxy.corp:3128",
+		"NO_PROXY":    ".corp",
+	}, ProxyBuildArgs())
+}
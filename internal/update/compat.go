@@ -0,0 +1,73 @@
+package update
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/blang/semver"
+)
+
+// AllCommands is the entry of Release.MinimumVersions which applies to all
+// commands.
+const AllCommands = "*"
+
+// IncompatibleError is returned when the API no longer supports a command
+// with the running version of flyctl.
+type IncompatibleError struct {
+	Command    string
+	Current    semver.Version
+	Minimum    semver.Version
+	Prerelease bool
+}
+
+func (e *IncompatibleError) Error() string {
+	return fmt.Sprintf("flyctl v%s is too old for fly %s, which requires v%s or later",
+		e.Current, e.Command, e.Minimum)
+}
+
+func (e *IncompatibleError) Suggestion() string {
+	return fmt.Sprintf("Upgrade flyctl with 'fly version update', or by running:\n  %s", updateCommand(e.Prerelease))
+}
+
+// CheckCompatible fails with an *IncompatibleError when r requires a version
+// of flyctl newer than current for command. Minimum versions which don't
+// parse are ignored.
+func (r *Release) CheckCompatible(current semver.Version, command string) error {
+	var (
+		minimum semver.Version
+		found   bool
+	)
+	for _, f := range []string{AllCommands, command} {
+		v, err := semver.ParseTolerant(r.MinimumVersions[f])
+		if err != nil {
+			continue
+		}
+		if !found || v.GT(minimum) {
+			minimum, found = v, true
+		}
+	}
+
+	if !found || current.GTE(minimum) {
+		return nil
+	}
+
+	return &IncompatibleError{
+		Command:    command,
+		Current:    current,
+		Minimum:    minimum,
+		Prerelease: r.Prerelease,
+	}
+}
+
+// Incompatible returns the commands, sorted, r requires a version of flyctl
+// newer than current for.
+func (r *Release) Incompatible(current semver.Version) (commands []string) {
+	for c := range r.MinimumVersions {
+		if r.CheckCompatible(current, c) != nil {
+			commands = append(commands, c)
+		}
+	}
+	sort.Strings(commands)
+
+	return
+}
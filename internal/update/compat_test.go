@@ -0,0 +1,36 @@
+package update
+
+import (
+	"testing"
+
+	"github.com/blang/semver"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckCompatible(t *testing.T) {
+	r := &Release{
+		Version: "0.1.30",
+		MinimumVersions: map[string]string{
+			AllCommands: "0.1.0",
+			"deploy":    "v0.1.20",
+			"launch":    "not a version",
+		},
+	}
+
+	assert.NoError(t, r.CheckCompatible(semver.MustParse("0.1.20"), "deploy"))
+	assert.NoError(t, r.CheckCompatible(semver.MustParse("0.1.10"), "launch"))
+	assert.NoError(t, (&Release{}).CheckCompatible(semver.MustParse("0.0.1"), "deploy"))
+
+	err := r.CheckCompatible(semver.MustParse("0.1.10"), "deploy")
+	var incompatible *IncompatibleError
+	require.ErrorAs(t, err, &incompatible)
+	assert.Equal(t, "flyctl v0.1.10 is too old for fly deploy, which requires v0.1.20 or later", err.Error())
+
+	err = r.CheckCompatible(semver.MustParse("0.0.9"), "machine run")
+	require.ErrorAs(t, err, &incompatible)
+	assert.Equal(t, "0.1.0", incompatible.Minimum.String())
+
+	assert.Equal(t, []string{"deploy"}, r.Incompatible(semver.MustParse("0.1.10")))
+	assert.Equal(t, []string{AllCommands, "deploy", "launch"}, r.Incompatible(semver.MustParse("0.0.9")))
+}
@@ -25,6 +25,11 @@ type Release struct {
 	Prerelease  bool      `yaml:"prerelease"`
 	DownloadURL string    `yaml:"download_url" json:"download_url"`
 	Timestamp   time.Time `yaml:"timestamp"`
+
+	// MinimumVersions maps commands, such as "deploy" or "machine run", to
+	// the oldest version of flyctl the API still supports them with. The "*"
+	// entry applies to all of them.
+	MinimumVersions map[string]string `yaml:"minimum_versions,omitempty" json:"minimum_versions"`
 }
 
 // Check reports whether update checks should take place.
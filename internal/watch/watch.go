@@ -12,6 +12,7 @@ import (
 	"golang.org/x/sync/errgroup"
 
 	"github.com/avast/retry-go/v4"
+	"github.com/jpillora/backoff"
 	"github.com/morikuni/aec"
 	"github.com/samber/lo"
 	"github.com/superfly/flyctl/api"
@@ -311,10 +312,12 @@ func MachinesChecks(ctx context.Context, machines []*api.Machine) error {
 	defer cancel()
 	iteration := 0
 
-	fn := func() error {
+	// poll reports whether every check passes, failing right away when a
+	// machine crashed as its checks will never pass
+	poll := func() (bool, error) {
 		checked, err := retryGetMachines(ctx, machineIDs...)
 		if err != nil {
-			return retry.Unrecoverable(err)
+			return false, err
 		}
 
 		iteration++
@@ -326,6 +329,9 @@ func MachinesChecks(ctx context.Context, machines []*api.Machine) error {
 
 		checksPassed := 0
 		for _, machine := range checked {
+			if err := machine.ExitError(); err != nil {
+				return false, err
+			}
 			if machine.Config.Checks == nil {
 				continue
 			}
@@ -340,13 +346,31 @@ func MachinesChecks(ctx context.Context, machines []*api.Machine) error {
 		}
 
 		// if all checks are passing, we're done
-		if checksPassed != checksTotal {
-			return fmt.Errorf("Waiting for %d non-passing checks", checksTotal-checksPassed)
-		}
-		return nil
+		return checksPassed == checksTotal, nil
 	}
 
-	return retry.Do(fn, retry.Delay(time.Second), retry.DelayType(retry.FixedDelay), retry.Attempts(0), retry.Context(ctx))
+	// poll quickly at first, as checks often pass on their first run, backing
+	// off with some jitter from there
+	b := &backoff.Backoff{
+		Min:    500 * time.Millisecond,
+		Max:    5 * time.Second,
+		Factor: 2,
+		Jitter: true,
+	}
+	for {
+		switch done, err := poll(); {
+		case err != nil:
+			return err
+		case done:
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timeout reached waiting for health checks to pass: %w", ctx.Err())
+		case <-time.After(b.Duration()):
+		}
+	}
 }
 
 // retryGetMachines calls flaps with exponential backoff 10s max interval and up to 6 times
@@ -0,0 +1,265 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/superfly/flyctl/terminal"
+)
+
+// SOCKS5 protocol constants, as defined in RFC 1928.
+const (
+	socks5Version = 0x05
+
+	socks5AuthNone         = 0x00
+	socks5AuthNoAcceptable = 0xff
+
+	socks5CmdConnect = 0x01
+
+	socks5AddrIPv4   = 0x01
+	socks5AddrDomain = 0x03
+	socks5AddrIPv6   = 0x04
+
+	socks5ReplySucceeded           = 0x00
+	socks5ReplyGeneralFailure      = 0x01
+	socks5ReplyHostUnreachable     = 0x04
+	socks5ReplyCommandNotSupported = 0x07
+	socks5ReplyAddrNotSupported    = 0x08
+)
+
+var (
+	errSocks5UnsupportedAddr = errors.New("unsupported address type")
+	errSocks5UnsupportedCmd  = errors.New("unsupported command")
+)
+
+// Socks5Server is a minimal SOCKS5 server which only supports the CONNECT
+// command without authentication. Connections are established with Dial,
+// which is expected to route them through a WireGuard tunnel, so names
+// under .internal resolve the same way they do from within the organization.
+type Socks5Server struct {
+	Listener net.Listener
+	Dial     func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+// NewSocks5Server listens on addr and returns a Socks5Server which proxies
+// connections via dial.
+func NewSocks5Server(addr string, dial func(ctx context.Context, network, addr string) (net.Conn, error)) (*Socks5Server, error) {
+	addr, err := socks5ListenAddr(addr)
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Socks5Server{
+		Listener: listener,
+		Dial:     dial,
+	}, nil
+}
+
+// socks5ListenAddr returns the address to listen on for addr, which is a port
+// or a host:port. Without a host, only loopback is listened on: clients of
+// the proxy reach the private network of the organization without
+// authenticating, so listening on other interfaces must be asked for.
+func socks5ListenAddr(addr string) (string, error) {
+	if _, err := strconv.Atoi(addr); err == nil {
+		return net.JoinHostPort("127.0.0.1", addr), nil
+	}
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid socks5 address %q: %w", addr, err)
+	}
+	if host == "" {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
+// Serve accepts SOCKS5 clients until ctx is done.
+func (srv *Socks5Server) Serve(ctx context.Context) error {
+	defer srv.Listener.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+			if ls, ok := srv.Listener.(*net.TCPListener); ok {
+				if err := ls.SetDeadline(time.Now().Add(time.Second)); err != nil {
+					return err
+				}
+			}
+
+			source, err := srv.Listener.Accept()
+			if err != nil {
+				if os.IsTimeout(err) {
+					continue
+				}
+				terminal.Debug("Error accepting connection: ", err)
+				continue
+			}
+
+			terminal.Debug("accepted new socks5 connection from: ", source.RemoteAddr())
+
+			go func() {
+				defer source.Close()
+
+				if err := srv.handle(ctx, source); err != nil {
+					terminal.Debug("socks5 connection failed: ", err)
+				}
+			}()
+		}
+	}
+}
+
+func (srv *Socks5Server) handle(ctx context.Context, source net.Conn) error {
+	r := bufio.NewReader(source)
+
+	if err := socks5Negotiate(r, source); err != nil {
+		return err
+	}
+
+	addr, err := socks5ReadRequest(r)
+	if err != nil {
+		var reply byte = socks5ReplyGeneralFailure
+		switch {
+		case errors.Is(err, errSocks5UnsupportedAddr):
+			reply = socks5ReplyAddrNotSupported
+		case errors.Is(err, errSocks5UnsupportedCmd):
+			reply = socks5ReplyCommandNotSupported
+		}
+		_ = socks5WriteReply(source, reply)
+
+		return err
+	}
+
+	target, err := srv.Dial(ctx, "tcp", addr)
+	if err != nil {
+		_ = socks5WriteReply(source, socks5ReplyHostUnreachable)
+
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	defer target.Close()
+
+	if err := socks5WriteReply(source, socks5ReplySucceeded); err != nil {
+		return err
+	}
+
+	terminal.Debugf("proxying socks5 connection to %s\n", addr)
+
+	wg := &sync.WaitGroup{}
+	wg.Add(2)
+
+	copyFunc := func(dst net.Conn, src io.Reader) {
+		defer wg.Done()
+		io.Copy(dst, src)
+
+		// close the write half if it exports a CloseWrite() method
+		if conn, ok := dst.(ClosableWrite); ok {
+			conn.CloseWrite()
+		}
+	}
+
+	// anything the client pipelined after the request is still buffered in r
+	go copyFunc(target, r)
+	go copyFunc(source, target)
+
+	wg.Wait()
+
+	return nil
+}
+
+// socks5Negotiate reads the client greeting and selects the "no
+// authentication" method, which is the only one we support.
+func socks5Negotiate(r *bufio.Reader, w io.Writer) error {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return err
+	}
+	if header[0] != socks5Version {
+		return fmt.Errorf("unsupported socks version %d", header[0])
+	}
+
+	methods := make([]byte, header[1])
+	if _, err := io.ReadFull(r, methods); err != nil {
+		return err
+	}
+
+	for _, m := range methods {
+		if m == socks5AuthNone {
+			_, err := w.Write([]byte{socks5Version, socks5AuthNone})
+			return err
+		}
+	}
+
+	_, _ = w.Write([]byte{socks5Version, socks5AuthNoAcceptable})
+
+	return errors.New("client does not support unauthenticated socks5")
+}
+
+// socks5ReadRequest reads a request and returns the host:port it targets.
+func socks5ReadRequest(r *bufio.Reader) (string, error) {
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(r, header); err != nil {
+		return "", err
+	}
+	if header[0] != socks5Version {
+		return "", fmt.Errorf("unsupported socks version %d", header[0])
+	}
+	if header[1] != socks5CmdConnect {
+		return "", errSocks5UnsupportedCmd
+	}
+
+	var host string
+	switch header[3] {
+	case socks5AddrIPv4, socks5AddrIPv6:
+		size := net.IPv4len
+		if header[3] == socks5AddrIPv6 {
+			size = net.IPv6len
+		}
+		ip := make([]byte, size)
+		if _, err := io.ReadFull(r, ip); err != nil {
+			return "", err
+		}
+		host = net.IP(ip).String()
+	case socks5AddrDomain:
+		size, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		name := make([]byte, size)
+		if _, err := io.ReadFull(r, name); err != nil {
+			return "", err
+		}
+		host = string(name)
+	default:
+		return "", errSocks5UnsupportedAddr
+	}
+
+	port := make([]byte, 2)
+	if _, err := io.ReadFull(r, port); err != nil {
+		return "", err
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(int(binary.BigEndian.Uint16(port)))), nil
+}
+
+// socks5WriteReply writes a reply with an unspecified bound address; clients
+// don't need it for CONNECT.
+func socks5WriteReply(w io.Writer, reply byte) error {
+	_, err := w.Write([]byte{socks5Version, reply, 0x00, socks5AddrIPv4, 0, 0, 0, 0, 0, 0})
+	return err
+}
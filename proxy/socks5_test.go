@@ -0,0 +1,101 @@
+package proxy
+
+import (
+	"bufio"
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSocks5ListenAddr(t *testing.T) {
+	cases := map[string]string{
+		"1080":           "127.0.0.1:1080",
+		":1080":          "127.0.0.1:1080",
+		"localhost:1080": "localhost:1080",
+		"0.0.0.0:1080":   "0.0.0.0:1080",
+		"[::1]:1080":     "[::1]:1080",
+	}
+	for addr, expected := range cases {
+		listenAddr, err := socks5ListenAddr(addr)
+		require.NoError(t, err, addr)
+		assert.Equal(t, expected, listenAddr, addr)
+	}
+
+	_, err := socks5ListenAddr("localhost")
+	assert.Error(t, err)
+}
+
+func TestSocks5Negotiate(t *testing.T) {
+	var w bytes.Buffer
+	err := socks5Negotiate(bufio.NewReader(bytes.NewReader([]byte{socks5Version, 2, 0x02, socks5AuthNone})), &w)
+	require.NoError(t, err)
+	assert.Equal(t, []byte{socks5Version, socks5AuthNone}, w.Bytes())
+
+	// only username/password offered
+	w.Reset()
+	err = socks5Negotiate(bufio.NewReader(bytes.NewReader([]byte{socks5Version, 1, 0x02})), &w)
+	assert.Error(t, err)
+	assert.Equal(t, []byte{socks5Version, socks5AuthNoAcceptable}, w.Bytes())
+
+	w.Reset()
+	err = socks5Negotiate(bufio.NewReader(bytes.NewReader([]byte{0x04, 1, socks5AuthNone})), &w)
+	assert.ErrorContains(t, err, "unsupported socks version 4")
+	assert.Empty(t, w.Bytes())
+
+	// truncated greeting
+	err = socks5Negotiate(bufio.NewReader(bytes.NewReader([]byte{socks5Version, 2, socks5AuthNone})), &w)
+	assert.Error(t, err)
+}
+
+func TestSocks5ReadRequest(t *testing.T) {
+	cases := []struct {
+		name     string
+		request  []byte
+		expected string
+		err      error
+	}{
+		{
+			name:     "ipv4",
+			request:  []byte{socks5Version, socks5CmdConnect, 0, socks5AddrIPv4, 10, 0, 0, 1, 0x1f, 0x90},
+			expected: "10.0.0.1:8080",
+		},
+		{
+			name: "ipv6",
+			request: append(append([]byte{socks5Version, socks5CmdConnect, 0, socks5AddrIPv6},
+				0xfd, 0xaa, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2), 0x15, 0x38),
+			expected: "[fdaa::2]:5432",
+		},
+		{
+			name:     "domain",
+			request:  append(append([]byte{socks5Version, socks5CmdConnect, 0, socks5AddrDomain, 12}, "app.internal"...), 0, 80),
+			expected: "app.internal:80",
+		},
+		{
+			name:    "bind",
+			request: []byte{socks5Version, 0x02, 0, socks5AddrIPv4, 10, 0, 0, 1, 0, 80},
+			err:     errSocks5UnsupportedCmd,
+		},
+		{
+			name:    "unknown address type",
+			request: []byte{socks5Version, socks5CmdConnect, 0, 0x05, 10, 0, 0, 1, 0, 80},
+			err:     errSocks5UnsupportedAddr,
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			addr, err := socks5ReadRequest(bufio.NewReader(bytes.NewReader(tc.request)))
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, addr)
+		})
+	}
+
+	_, err := socks5ReadRequest(bufio.NewReader(bytes.NewReader([]byte{socks5Version, socks5CmdConnect, 0, socks5AddrIPv4, 10, 0})))
+	assert.Error(t, err)
+}
@@ -8,7 +8,7 @@ import (
 )
 
 func configureDockerfile(sourceDir string, config *ScannerConfig) (*SourceInfo, error) {
-	if !checksPass(sourceDir, fileExists("Dockerfile")) {
+	if config.Mode == ModeGenerate || !checksPass(sourceDir, fileExists("Dockerfile")) {
 		return nil, nil
 	}
 
@@ -47,16 +47,16 @@ func configureNode(sourceDir string, config *ScannerConfig) (*SourceInfo, error)
 	// or default to an LTS version
 	var nodeVersion string = "18.15.0"
 
-	out, err := exec.Command("node", "-v").Output()
-
-	if err == nil {
+	if config.RuntimeVersion != "" {
+		nodeVersion = strings.TrimPrefix(config.RuntimeVersion, "v")
+	} else if out, err := exec.Command("node", "-v").Output(); err == nil {
 		nodeVersion = strings.TrimSpace(string(out))
 		if nodeVersion[:1] == "v" {
 			nodeVersion = nodeVersion[1:]
 		}
 	}
 
-	out, err = exec.Command("yarn", "-v").Output()
+	out, err := exec.Command("yarn", "-v").Output()
 
 	if err == nil {
 		yarnVersion = strings.TrimSpace(string(out))
@@ -26,8 +26,10 @@ func configureRails(sourceDir string, config *ScannerConfig) (*SourceInfo, error
 	}
 
 	s := &SourceInfo{
-		Family:   "Rails",
-		Callback: RailsCallback,
+		Family: "Rails",
+		Callback: func(srcInfo *SourceInfo, options map[string]bool) error {
+			return railsCallback(srcInfo, options, config)
+		},
 	}
 
 	// master.key comes with Rails apps from v5.2 onwards, but may not be present
@@ -88,7 +90,7 @@ Once ready: run 'fly deploy' to deploy your Rails app.
 	return s, nil
 }
 
-func RailsCallback(srcInfo *SourceInfo, options map[string]bool) error {
+func railsCallback(srcInfo *SourceInfo, options map[string]bool, config *ScannerConfig) error {
 	// install dockerfile-rails gem, if not already included
 	gemfile, err := os.ReadFile("Gemfile")
 	if err != nil {
@@ -124,12 +126,21 @@ func RailsCallback(srcInfo *SourceInfo, options map[string]bool) error {
 		}
 	}
 
-	// generate Dockerfile if it doesn't already exist
+	// generate Dockerfile if it doesn't already exist, or regenerate it when
+	// asked to
 	_, err = os.Stat("Dockerfile")
-	if errors.Is(err, fs.ErrNotExist) {
+	if errors.Is(err, fs.ErrNotExist) || config.Mode == ModeGenerate {
 		args := []string{"./bin/rails", "generate", "dockerfile",
 			"--label=fly_launch_runtime:rails"}
 
+		if config.Mode == ModeGenerate {
+			args = append(args, "--force")
+		}
+
+		for _, option := range config.GeneratorOptions {
+			args = append(args, "--"+strings.TrimPrefix(option, "--"))
+		}
+
 		if options["postgresql"] {
 			args = append(args, "--postgresql")
 		}
@@ -72,8 +72,22 @@ type Volume struct {
 }
 type ScannerConfig struct {
 	Mode string
+	// Family restricts detection to the framework or runtime of that family,
+	// case insensitively.
+	Family string
+	// RuntimeVersion is the version of the language runtime the generated
+	// Dockerfile uses, instead of the locally installed one.
+	RuntimeVersion string
+	// GeneratorOptions are passed, as --name or --name=value flags, to the
+	// Dockerfile generators of frameworks which have one.
+	GeneratorOptions []string
 }
 
+// ModeGenerate is the scanner mode used to (re)generate the Dockerfile of an
+// app: an existing Dockerfile doesn't stop framework detection, and framework
+// generators overwrite it.
+const ModeGenerate = "generate"
+
 func Scan(sourceDir string, config *ScannerConfig) (*SourceInfo, error) {
 	scanners := []sourceScanner{
 		configureDjango,
@@ -103,9 +117,13 @@ func Scan(sourceDir string, config *ScannerConfig) (*SourceInfo, error) {
 		if err != nil {
 			return nil, err
 		}
-		if si != nil {
-			return si, nil
+		if si == nil {
+			continue
+		}
+		if config.Family != "" && !strings.EqualFold(si.Family, config.Family) {
+			continue
 		}
+		return si, nil
 	}
 
 	return nil, nil
@@ -63,21 +63,23 @@ func doConnect(ctx context.Context, state *WireGuardState, wswg bool) (*Tunnel,
 		return nil, err
 	}
 
-	endpointIPs, err := net.LookupIP(endpointHost)
-	if err != nil {
-		return nil, err
-	}
-
-	endpointIP := endpointIPs[rand.Intn(len(endpointIPs))]
-	endpointAddr := net.JoinHostPort(endpointIP.String(), endpointPort)
-
+	var endpointAddr string
 	if wswg {
+		// the endpoint is resolved by the proxy, when there's one
 		port, err := websocketConnect(ctx, endpointHost)
 		if err != nil {
 			return nil, err
 		}
 
 		endpointAddr = fmt.Sprintf("127.0.0.1:%d", port)
+	} else {
+		endpointIPs, err := net.LookupIP(endpointHost)
+		if err != nil {
+			return nil, err
+		}
+
+		endpointIP := endpointIPs[rand.Intn(len(endpointIPs))]
+		endpointAddr = net.JoinHostPort(endpointIP.String(), endpointPort)
 	}
 
 	wgDev := device.NewDevice(tunDev, conn.NewDefaultBind(), device.NewLogger(cfg.LogLevel, "(fly-ssh) "))
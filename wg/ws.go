@@ -14,8 +14,12 @@ import (
 
 	"golang.org/x/net/websocket"
 	"golang.org/x/time/rate"
+
+	"github.com/superfly/flyctl/internal/transport"
 )
 
+const wsConnectTimeout = 30 * time.Second
+
 func ConnectWS(ctx context.Context, state *WireGuardState) (*Tunnel, error) {
 	ctx, cancel := context.WithCancel(ctx)
 
@@ -146,9 +150,20 @@ func (wswg *WsWgProxy) Connect(endpoint string) error {
 		InsecureSkipVerify: true,
 	}
 
+	// the connection goes through the proxy of the environment, if any, as
+	// the UDP of WireGuard can't
+	ctx, cancel := context.WithTimeout(context.Background(), wsConnectTimeout)
+	defer cancel()
+
+	conn, err := transport.DialTLS(ctx, net.JoinHostPort(endpoint, "443"), conf.TlsConfig)
+	if err != nil {
+		return fmt.Errorf("websocket: %w", err)
+	}
+
 	// oh well, if it'll end horror
-	ws, err := websocket.DialConfig(conf)
+	ws, err := websocket.NewClient(conf, conn)
 	if err != nil {
+		conn.Close()
 		return fmt.Errorf("websocket: %w", err)
 	}
 